@@ -19,6 +19,8 @@ limitations under the License.
 package main
 
 import (
+	"crypto"
+	"crypto/ed25519"
 	"log"
 	"os"
 	"time"
@@ -78,6 +80,38 @@ func realMode(confPath string) {
 		log.Fatalf("Error parsing server configuration: %s\n", err)
 	}
 
+	var signer crypto.Signer
+	if conf.SigningKeyPath != "" {
+		keyData, err := os.ReadFile(conf.SigningKeyPath)
+		if err != nil {
+			log.Fatalf("Error reading signing key: %s\n", err)
+		}
+		signer, err = verifiable.ParseTreeHeadSigningKey(keyData)
+		if err != nil {
+			log.Fatalf("Error parsing signing key: %s\n", err)
+		}
+	}
+
+	var vrfKey ed25519.PrivateKey
+	if conf.VrfKeyPath != "" {
+		keyData, err := os.ReadFile(conf.VrfKeyPath)
+		if err != nil {
+			log.Fatalf("Error reading VRF key: %s\n", err)
+		}
+		vrfKey, err = verifiable.ParseVRFKey(keyData)
+		if err != nil {
+			log.Fatalf("Error parsing VRF key: %s\n", err)
+		}
+	}
+
+	var witnesses []pb.WitnessServiceServer
+	for _, w := range conf.Witnesses {
+		witnesses = append(witnesses, (&grpc.Client{
+			Address:        w.Address,
+			NoGrpcSecurity: w.NoGrpcSecurity,
+		}).MustDialWitness())
+	}
+
 	db := &bolt.Storage{
 		Path: conf.BoltDbPath,
 	}
@@ -90,7 +124,13 @@ func realMode(confPath string) {
 		Mutator: &instant.Mutator{
 			Writer: db,
 		},
-		Reader: db,
+		Reader:        db,
+		Signer:        signer,
+		Witnesses:     witnesses,
+		WitnessQuorum: int(conf.WitnessQuorum),
+		MapRetention:  conf.MapRetention,
+		VRFKey:        vrfKey,
+		DerivedMaps:   conf.DerivedMaps,
 	}).MustCreate()
 
 	if conf.GrpcServer {
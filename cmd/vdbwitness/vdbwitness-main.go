@@ -0,0 +1,101 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package main
+
+import (
+	"crypto"
+	"encoding/hex"
+	"log"
+	"os"
+
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/server/grpc"
+	"github.com/continusec/verifiabledatastructures/storage/bolt"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	"github.com/continusec/verifiabledatastructures/witness"
+	"google.golang.org/protobuf/encoding/prototext"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		log.Fatalf("Usage: %s path/to/witness.conf\n", os.Args[0])
+	}
+
+	confData, err := os.ReadFile(os.Args[1])
+	if err != nil {
+		log.Fatalf("Error reading witness configuration: %s\n", err)
+	}
+
+	conf := &pb.WitnessConfig{}
+	err = prototext.Unmarshal(confData, conf)
+	if err != nil {
+		log.Fatalf("Error parsing witness configuration: %s\n", err)
+	}
+
+	keyData, err := os.ReadFile(conf.SigningKeyPath)
+	if err != nil {
+		log.Fatalf("Error reading signing key: %s\n", err)
+	}
+	signer, err := verifiable.ParseTreeHeadSigningKey(keyData)
+	if err != nil {
+		log.Fatalf("Error parsing signing key: %s\n", err)
+	}
+
+	logs := make(map[string]*witness.Log)
+	for _, l := range conf.Logs {
+		var pub crypto.PublicKey
+		if l.PublicKeyPath != "" {
+			pubData, err := os.ReadFile(l.PublicKeyPath)
+			if err != nil {
+				log.Fatalf("Error reading public key for %s: %s\n", l.Origin, err)
+			}
+			pub, err = verifiable.ParseTreeHeadPublicKey(pubData)
+			if err != nil {
+				log.Fatalf("Error parsing public key for %s: %s\n", l.Origin, err)
+			}
+		}
+		var startHead *pb.LogTreeHashResponse
+		if l.StartTreeSize != 0 {
+			rootHash, err := hex.DecodeString(l.StartRootHash)
+			if err != nil {
+				log.Fatalf("Error parsing start root hash for %s: %s\n", l.Origin, err)
+			}
+			startHead = &pb.LogTreeHashResponse{
+				TreeSize: l.StartTreeSize,
+				RootHash: rootHash,
+			}
+		}
+		logs[l.Origin] = &witness.Log{
+			PublicKey:     pub,
+			HashAlgorithm: l.HashAlgorithm,
+			StartHead:     startHead,
+		}
+	}
+
+	db := &bolt.Storage{
+		Path: conf.BoltDbPath,
+	}
+	defer db.Close() // release file locks
+
+	grpc.StartWitnessServer(conf, (&witness.Service{
+		Signer:  signer,
+		Storage: db,
+		Logs:    logs,
+	}).MustCreate())
+}
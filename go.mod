@@ -5,6 +5,7 @@ go 1.23.0
 toolchain go1.24.5
 
 require (
+	filippo.io/edwards25519 v1.0.0
 	github.com/continusec/objecthash v0.0.0-20190705044459-0b7c35f0e039
 	github.com/dgraph-io/badger v1.6.2
 	github.com/gorilla/handlers v1.5.2
@@ -12,9 +13,11 @@ require (
 	github.com/jackc/pgx/v5 v5.7.5
 	github.com/pkg/browser v0.0.0-20240102092130-5ac0b6a4141c
 	go.etcd.io/bbolt v1.4.2
+	golang.org/x/crypto v0.40.0
 	golang.org/x/net v0.42.0
 	google.golang.org/grpc v1.74.2
 	google.golang.org/protobuf v1.36.6
+	lukechampine.com/blake3 v1.4.1
 )
 
 require (
@@ -28,8 +31,8 @@ require (
 	github.com/jackc/pgpassfile v1.0.0 // indirect
 	github.com/jackc/pgservicefile v0.0.0-20240606120523-5a60cdf6a761 // indirect
 	github.com/jackc/puddle/v2 v2.2.2 // indirect
+	github.com/klauspost/cpuid/v2 v2.0.12 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
-	golang.org/x/crypto v0.40.0 // indirect
 	golang.org/x/sync v0.16.0 // indirect
 	golang.org/x/sys v0.34.0 // indirect
 	golang.org/x/text v0.27.0 // indirect
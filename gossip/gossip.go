@@ -0,0 +1,181 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+// Package gossip allows clients to share the tree heads they have observed with peers. Each
+// client on its own can only check that a log is consistent with its own history. By sending
+// observed tree heads to peers that check them against their own view, a server that presents
+// different views of a log or map to different clients can be detected.
+package gossip
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+)
+
+// Pool holds the tree heads observed by a client, ready to be sent to peers. Only the
+// largest tree head seen for each log or map is kept. API keys are never kept.
+// The zero value is ready to use, and it is safe for concurrent use.
+type Pool struct {
+	mu   sync.Mutex
+	logs map[string]*pb.LogGossip
+	maps map[string]*pb.MapGossip
+}
+
+// AddLogTreeHead adds a verified log tree head to the pool.
+func (p *Pool) AddLogTreeHead(logRef *pb.LogRef, head *pb.LogTreeHashResponse) {
+	if head == nil || head.TreeSize == 0 {
+		return
+	}
+	origin := verifiable.LogOrigin(logRef)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.logs == nil {
+		p.logs = make(map[string]*pb.LogGossip)
+	}
+	prev, ok := p.logs[origin]
+	if ok && prev.Head.TreeSize >= head.TreeSize {
+		return
+	}
+	p.logs[origin] = &pb.LogGossip{
+		Log: &pb.LogRef{
+			Account: &pb.AccountRef{Id: logRef.Account.Id},
+			Name:    logRef.Name,
+			LogType: logRef.LogType,
+		},
+		Head: head,
+	}
+}
+
+// AddMapTreeState adds a verified map tree state to the pool.
+func (p *Pool) AddMapTreeState(mapRef *pb.MapRef, state *verifiable.MapTreeState) {
+	if state == nil || state.MapTreeHead == nil || state.TreeSize() == 0 {
+		return
+	}
+	origin := verifiable.MapOrigin(mapRef)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.maps == nil {
+		p.maps = make(map[string]*pb.MapGossip)
+	}
+	prev, ok := p.maps[origin]
+	if ok && prev.MapHead.MutationLog.TreeSize >= state.TreeSize() {
+		return
+	}
+	p.maps[origin] = &pb.MapGossip{
+		Map: &pb.MapRef{
+			Account: &pb.AccountRef{Id: mapRef.Account.Id},
+			Name:    mapRef.Name,
+		},
+		MapHead:         state.MapTreeHead,
+		TreeHeadLogHead: state.TreeHeadLogTreeHead,
+	}
+}
+
+// Request returns a request containing all tree heads in the pool.
+func (p *Pool) Request() *pb.GossipRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	rv := &pb.GossipRequest{}
+	for _, g := range p.logs {
+		rv.Logs = append(rv.Logs, g)
+	}
+	for _, g := range p.maps {
+		rv.Maps = append(rv.Maps, g)
+	}
+	return rv
+}
+
+// Gossiper periodically sends the tree heads in a pool to each of its peers.
+type Gossiper struct {
+	// Pool holds the tree heads to send
+	Pool *Pool
+
+	// Peers are sent the tree heads. These are normally clients for other servers
+	// that mirror the same logs and maps, or for the original server, but reached
+	// via a different network path.
+	Peers []pb.VerifiableDataStructuresServiceServer
+
+	// Interval is the time between exchanges when Run is called
+	Interval time.Duration
+
+	// OnInconsistent, if set, is called with the tree heads a peer reports as being
+	// inconsistent with its own view. If nil, these are logged.
+	OnInconsistent func(peer pb.VerifiableDataStructuresServiceServer, resp *pb.GossipResponse)
+}
+
+// Exchange sends the tree heads in the pool to each peer once. If a peer can't be reached, the
+// remaining peers are still sent the tree heads, and the first error is returned.
+func (g *Gossiper) Exchange(ctx context.Context) error {
+	req := g.Pool.Request()
+	if len(req.Logs) == 0 && len(req.Maps) == 0 {
+		return nil
+	}
+
+	var rv error
+	for _, peer := range g.Peers {
+		resp, err := peer.Gossip(ctx, req)
+		if err != nil {
+			if rv == nil {
+				rv = err
+			}
+			continue
+		}
+		if len(resp.InconsistentLogs) == 0 && len(resp.InconsistentMaps) == 0 {
+			continue
+		}
+		if g.OnInconsistent != nil {
+			g.OnInconsistent(peer, resp)
+		} else {
+			for _, l := range resp.InconsistentLogs {
+				log.Printf("Gossip peer reports inconsistent tree head for %s at size %d\n", verifiable.LogOrigin(l.Log), l.GetHead().GetTreeSize())
+			}
+			for _, m := range resp.InconsistentMaps {
+				log.Printf("Gossip peer reports inconsistent map state for %s at size %d\n", verifiable.MapOrigin(m.Map), m.GetMapHead().GetMutationLog().GetTreeSize())
+			}
+		}
+	}
+	return rv
+}
+
+// Run calls Exchange every Interval until the context is done. Errors are logged.
+func (g *Gossiper) Run(ctx context.Context) error {
+	ticker := time.NewTicker(g.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			err := g.Exchange(ctx)
+			if err != nil {
+				log.Printf("Error exchanging tree heads: %s\n", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,133 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package merkle
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+
+	"golang.org/x/crypto/blake2b"
+	"lukechampine.com/blake3"
+)
+
+// Hasher is a hash strategy for logs and maps. All hashers must produce 32 byte outputs,
+// as map keys are hashed to a 256 bit path.
+type Hasher interface {
+	// LeafHash returns the Merkle Tree Hash for a leaf.
+	LeafHash(b []byte) []byte
+
+	// NodeHash returns the Merkle Tree Hash for a node.
+	NodeHash(l, r []byte) []byte
+
+	// KeyHash returns the hash of a map key, which is used to determine its path in the tree.
+	KeyHash(key []byte) []byte
+
+	// DefaultLeafValue returns the hash of an empty sub-tree in a map at the given depth (0-256).
+	// Callers must not modify the returned value.
+	DefaultLeafValue(depth int) []byte
+}
+
+type hasher struct {
+	newHash  func() hash.Hash
+	defaults [][]byte
+}
+
+// NewHasher returns a Hasher based on the given hash function, which must have a 32 byte output.
+func NewHasher(newHash func() hash.Hash) Hasher {
+	rv := &hasher{newHash: newHash}
+	rv.defaults = make([][]byte, 257)
+	rv.defaults[256] = rv.LeafHash(nil)
+	for i := 255; i >= 0; i-- {
+		rv.defaults[i] = rv.NodeHash(rv.defaults[i+1], rv.defaults[i+1])
+	}
+	return rv
+}
+
+func (h *hasher) LeafHash(b []byte) []byte {
+	d := h.newHash()
+	d.Write([]byte{0})
+	d.Write(b)
+	return d.Sum(nil)
+}
+
+func (h *hasher) NodeHash(l, r []byte) []byte {
+	d := h.newHash()
+	d.Write([]byte{1})
+	d.Write(l)
+	d.Write(r)
+	return d.Sum(nil)
+}
+
+func (h *hasher) KeyHash(key []byte) []byte {
+	d := h.newHash()
+	d.Write(key)
+	return d.Sum(nil)
+}
+
+func (h *hasher) DefaultLeafValue(depth int) []byte {
+	return h.defaults[depth]
+}
+
+func newBlake2b256() hash.Hash {
+	rv, err := blake2b.New256(nil)
+	if err != nil { // only happens for a bad key
+		panic(err)
+	}
+	return rv
+}
+
+func newBlake3() hash.Hash {
+	return blake3.New(32, nil)
+}
+
+var (
+	// SHA256 is the default Hasher, as described in RFC6962.
+	SHA256 = NewHasher(sha256.New)
+
+	// SHA512_256 is a Hasher using SHA-512/256.
+	SHA512_256 = NewHasher(sha512.New512_256)
+
+	// BLAKE2b256 is a Hasher using BLAKE2b with a 256 bit output.
+	BLAKE2b256 = NewHasher(newBlake2b256)
+
+	// BLAKE3 is a Hasher using BLAKE3 with a 256 bit output.
+	BLAKE3 = NewHasher(newBlake3)
+)
+
+// KeyPath returns the path in the tree for a given key using the given Hasher. Specifically
+// it hashes the key, and then returns a big-endian slice of booleans representing
+// the equivalent path in the tree.
+func KeyPath(h Hasher, key []byte) []bool {
+	return KeyHashPath(h.KeyHash(key))
+}
+
+// KeyHashPath returns the path in the tree for a given key hash, as a big-endian slice of booleans.
+func KeyHashPath(kh []byte) []bool {
+	nm := len(kh) * 8
+	rv := make([]bool, nm)
+	for i, b := range kh {
+		for j := uint(0); j < 8; j++ {
+			if ((b >> j) & 1) == 1 {
+				rv[(uint(i)<<3)+7-j] = true
+			}
+		}
+	}
+	return rv
+}
@@ -0,0 +1,95 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package merkle
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+)
+
+// SumHashLength is the length of a node hash from a SumHasher, being the hash followed by the
+// big-endian count and sum of the leaves below it.
+const SumHashLength = 48
+
+// SumHasher is a Hasher for maps where each node also commits to the number of non-empty leaves
+// below it, and the sum of their amounts, so that the root hash proves both totals. NodeHash and
+// DefaultLeafValue operate on node hashes of SumHashLength, which are made for each leaf by
+// LeafNodeHash. LeafHash and KeyHash are those of the underlying Hasher. Sums are capped at the
+// maximum uint64, rather than overflowing.
+type SumHasher struct {
+	Hasher
+	defaults [][]byte
+}
+
+// NewSumHasher returns a SumHasher based on the given Hasher.
+func NewSumHasher(h Hasher) *SumHasher {
+	rv := &SumHasher{Hasher: h}
+	rv.defaults = make([][]byte, 257)
+	rv.defaults[256] = sumNodeHash(h.DefaultLeafValue(256), 0, 0)
+	for i := 255; i >= 0; i-- {
+		rv.defaults[i] = rv.NodeHash(rv.defaults[i+1], rv.defaults[i+1])
+	}
+	return rv
+}
+
+// LeafNodeHash returns the node hash for a leaf with the given leaf hash, which counts as one
+// leaf with the given amount unless it is the leaf hash of an empty value.
+func (h *SumHasher) LeafNodeHash(leafHash []byte, amount uint64) []byte {
+	if bytes.Equal(leafHash, h.Hasher.DefaultLeafValue(256)) {
+		return h.defaults[256]
+	}
+	return sumNodeHash(leafHash, 1, amount)
+}
+
+// NodeHash returns the node hash for a parent of two node hashes, which commits to both in full.
+func (h *SumHasher) NodeHash(l, r []byte) []byte {
+	lc, ls, _ := SumTotals(l)
+	rc, rs, _ := SumTotals(r)
+	return sumNodeHash(h.Hasher.NodeHash(l, r), addCapped(lc, rc), addCapped(ls, rs))
+}
+
+// DefaultLeafValue returns the node hash of an empty sub-tree at the given depth (0-256).
+// Callers must not modify the returned value.
+func (h *SumHasher) DefaultLeafValue(depth int) []byte {
+	return h.defaults[depth]
+}
+
+// SumTotals returns the number of non-empty leaves below a node, and the sum of their amounts,
+// given its node hash from a SumHasher. Returns false if it is not of SumHashLength.
+func SumTotals(nodeHash []byte) (uint64, uint64, bool) {
+	if len(nodeHash) != SumHashLength {
+		return 0, 0, false
+	}
+	return binary.BigEndian.Uint64(nodeHash[32:40]), binary.BigEndian.Uint64(nodeHash[40:48]), true
+}
+
+func sumNodeHash(hash []byte, count, sum uint64) []byte {
+	rv := make([]byte, 0, SumHashLength)
+	rv = append(rv, hash...)
+	rv = binary.BigEndian.AppendUint64(rv, count)
+	return binary.BigEndian.AppendUint64(rv, sum)
+}
+
+func addCapped(a, b uint64) uint64 {
+	if a > math.MaxUint64-b {
+		return math.MaxUint64
+	}
+	return a + b
+}
@@ -18,23 +18,17 @@ limitations under the License.
 
 package merkle
 
-import "crypto/sha256"
+import (
+	"cmp"
+	"crypto/sha256"
+	"slices"
+)
 
 // ConstructMapKeyPath returns the path in the tree for a given key. Specifically it takes
 // the SHA256 hash of the key, and then returns a big-endian slice of booleans representing
-// the equivalent path in the tree.
+// the equivalent path in the tree. See KeyPath for other hash algorithms.
 func ConstructMapKeyPath(key []byte) []bool {
-	h := sha256.Sum256(key)
-	nm := len(h) * 8
-	rv := make([]bool, nm)
-	for i, b := range h {
-		for j := uint(0); j < 8; j++ {
-			if ((b >> j) & 1) == 1 {
-				rv[(uint(i)<<3)+7-j] = true
-			}
-		}
-	}
-	return rv
+	return KeyPath(SHA256, key)
 }
 
 var defaultLeafValues = GenerateMapDefaultLeafValues()
@@ -50,7 +44,7 @@ func GenerateMapDefaultLeafValues() [][]byte {
 	return rv
 }
 
-// NodeHash is a utility function for calculating the Merkle Tree Hash for a node.
+// NodeHash is a utility function for calculating the SHA256 Merkle Tree Hash for a node.
 func NodeHash(l, r []byte) []byte {
 	h := sha256.New()
 	h.Write([]byte{1})
@@ -59,7 +53,7 @@ func NodeHash(l, r []byte) []byte {
 	return h.Sum(nil)
 }
 
-// LeafHash is a utility function for calculating the Merkle Tree Hash for a leaf.
+// LeafHash is a utility function for calculating the SHA256 Merkle Tree Hash for a leaf.
 func LeafHash(b []byte) []byte {
 	h := sha256.New()
 	h.Write([]byte{0})
@@ -114,3 +108,33 @@ func SubProof(m, startN, endN int64, b bool) [][2]int64 {
 	}
 	return append(SubProof(m-k, startN+k, endN, false), [2]int64{startN, startN + k})
 }
+
+// BatchPath returns the deduplicated set of subtree ranges needed to prove the inclusion of all
+// of the given leaf indices in a tree of size n, ordered by start. Each range is taken from the
+// Path for one of the leaves, and ranges that contain any of the leaves are omitted, as these can
+// be calculated from the other ranges. Leaf indices must be in the range [0, n).
+func BatchPath(leaves []int64, n int64) [][2]int64 {
+	sorted := append([]int64(nil), leaves...)
+	slices.Sort(sorted)
+	sorted = slices.Compact(sorted)
+
+	containsLeaf := func(r [2]int64) bool {
+		i, _ := slices.BinarySearch(sorted, r[0])
+		return i < len(sorted) && sorted[i] < r[1]
+	}
+
+	seen := make(map[[2]int64]bool)
+	var rv [][2]int64
+	for _, m := range sorted {
+		for _, r := range Path(m, 0, n) {
+			if !seen[r] && !containsLeaf(r) {
+				seen[r] = true
+				rv = append(rv, r)
+			}
+		}
+	}
+	slices.SortFunc(rv, func(a, b [2]int64) int {
+		return cmp.Compare(a[0], b[0])
+	})
+	return rv
+}
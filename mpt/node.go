@@ -0,0 +1,198 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package mpt
+
+// encodeNode returns the RLP encoding of a full or short node. Each node below it that is referred
+// to by its hash is written to db, unless db is nil.
+func encodeNode(n node, db NodeWriter) ([]byte, error) {
+	switch n := n.(type) {
+	case *shortNode:
+		val, err := encodeRef(n.Val, db)
+		if err != nil {
+			return nil, err
+		}
+		return EncodeList(EncodeBytes(compactKey(n.Key)), val), nil
+	case *fullNode:
+		items := make([][]byte, len(n.Children))
+		for i, c := range n.Children {
+			var err error
+			items[i], err = encodeRef(c, db)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return EncodeList(items...), nil
+	default:
+		return nil, ErrInvalidRLP
+	}
+}
+
+// encodeRef returns how n is included in its parent. A node with an encoding shorter than a hash is
+// included as is, else it is referred to by its hash, and written to db unless db is nil.
+func encodeRef(n node, db NodeWriter) ([]byte, error) {
+	switch n := n.(type) {
+	case nil:
+		return emptyString, nil
+	case hashNode:
+		return EncodeBytes(n), nil
+	case valueNode:
+		return EncodeBytes(n), nil
+	}
+	enc, err := encodeNode(n, db)
+	if err != nil {
+		return nil, err
+	}
+	if len(enc) < 32 {
+		return enc, nil
+	}
+	h := Keccak256(enc)
+	if db != nil {
+		err = db.PutNode(h, enc)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return EncodeBytes(h), nil
+}
+
+// decodeNode returns the full or short node with the RLP encoding enc
+func decodeNode(enc []byte) (node, error) {
+	items, err := splitList(enc)
+	if err != nil {
+		return nil, err
+	}
+	switch len(items) {
+	case 2:
+		compact, err := DecodeBytes(items[0])
+		if err != nil {
+			return nil, err
+		}
+		key, err := keyFromCompact(compact)
+		if err != nil {
+			return nil, err
+		}
+		var val node
+		if key[len(key)-1] == terminator {
+			val, err = decodeValue(items[1])
+		} else {
+			val, err = decodeRef(items[1])
+		}
+		if err != nil {
+			return nil, err
+		}
+		if val == nil {
+			return nil, ErrInvalidRLP
+		}
+		return &shortNode{Key: key, Val: val}, nil
+	case 17:
+		rv := &fullNode{}
+		for i, item := range items[:16] {
+			rv.Children[i], err = decodeRef(item)
+			if err != nil {
+				return nil, err
+			}
+		}
+		rv.Children[16], err = decodeValue(items[16])
+		if err != nil {
+			return nil, err
+		}
+		return rv, nil
+	default:
+		return nil, ErrInvalidRLP
+	}
+}
+
+// decodeRef returns the node included in, or referred to by, the item from a parent node
+func decodeRef(item []byte) (node, error) {
+	isList, content, _, err := splitRLP(item)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case isList:
+		if len(item) >= 32 {
+			return nil, ErrInvalidRLP
+		}
+		return decodeNode(item)
+	case len(content) == 0:
+		return nil, nil
+	case len(content) == 32:
+		return hashNode(content), nil
+	default:
+		return nil, ErrInvalidRLP
+	}
+}
+
+// decodeValue returns the value in the item from a parent node, or nil if empty
+func decodeValue(item []byte) (node, error) {
+	v, err := DecodeBytes(item)
+	if err != nil {
+		return nil, err
+	}
+	if len(v) == 0 {
+		return nil, nil
+	}
+	return valueNode(v), nil
+}
+
+// compactKey returns the hex-prefix encoding of the nibbles in key, which records whether it ends
+// with the terminator, and whether it has an odd number of other nibbles
+func compactKey(key []byte) []byte {
+	flag := byte(0)
+	if len(key) != 0 && key[len(key)-1] == terminator {
+		flag = 2
+		key = key[:len(key)-1]
+	}
+	if len(key)%2 == 1 {
+		flag |= 1
+		key = concat([]byte{flag}, key...)
+	} else {
+		key = concat([]byte{flag, 0}, key...)
+	}
+	rv := make([]byte, len(key)/2)
+	for i := range rv {
+		rv[i] = key[i*2]<<4 | key[i*2+1]
+	}
+	return rv
+}
+
+// keyFromCompact returns the nibbles of a key with the hex-prefix encoding compact
+func keyFromCompact(compact []byte) ([]byte, error) {
+	if len(compact) == 0 {
+		return nil, ErrInvalidRLP
+	}
+	flag := compact[0] >> 4
+	if flag > 3 || (flag&1 == 0 && compact[0]&0x0f != 0) {
+		return nil, ErrInvalidRLP
+	}
+	var rv []byte
+	if flag&1 == 1 {
+		rv = append(rv, compact[0]&0x0f)
+	}
+	for _, b := range compact[1:] {
+		rv = append(rv, b>>4, b&0x0f)
+	}
+	if flag&2 == 2 {
+		rv = append(rv, terminator)
+	}
+	if len(rv) == 0 {
+		return nil, ErrInvalidRLP
+	}
+	return rv, nil
+}
@@ -0,0 +1,148 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package mpt
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// ErrInvalidRLP is returned when data is not a canonical RLP encoding of the form expected.
+var ErrInvalidRLP = errors.New("invalid RLP encoding")
+
+// emptyString is the RLP encoding of an empty string
+var emptyString = []byte{0x80}
+
+// EncodeBytes returns the RLP encoding of b as a string.
+func EncodeBytes(b []byte) []byte {
+	if len(b) == 1 && b[0] < 0x80 {
+		return []byte{b[0]}
+	}
+	return append(encodeLength(0x80, len(b)), b...)
+}
+
+// EncodeList returns the RLP encoding of a list of items, each of which must already be RLP encoded.
+func EncodeList(items ...[]byte) []byte {
+	size := 0
+	for _, item := range items {
+		size += len(item)
+	}
+	rv := encodeLength(0xc0, size)
+	for _, item := range items {
+		rv = append(rv, item...)
+	}
+	return rv
+}
+
+// DecodeBytes returns the string that b is the RLP encoding of.
+func DecodeBytes(b []byte) ([]byte, error) {
+	isList, content, rest, err := splitRLP(b)
+	if err != nil {
+		return nil, err
+	}
+	if isList || len(rest) != 0 {
+		return nil, ErrInvalidRLP
+	}
+	return content, nil
+}
+
+// encodeLength returns the RLP header for a string (offset 0x80) or list (offset 0xc0) of size bytes
+func encodeLength(offset byte, size int) []byte {
+	if size < 56 {
+		return []byte{offset + byte(size)}
+	}
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(size))
+	i := 0
+	for buf[i] == 0 {
+		i++
+	}
+	return append([]byte{offset + 55 + byte(8-i)}, buf[i:]...)
+}
+
+// splitRLP returns whether the first item in b is a list, its content, and the bytes that follow it.
+// Encodings that are not canonical are rejected.
+func splitRLP(b []byte) (bool, []byte, []byte, error) {
+	if len(b) == 0 {
+		return false, nil, nil, ErrInvalidRLP
+	}
+	prefix := b[0]
+	switch {
+	case prefix < 0x80:
+		return false, b[:1], b[1:], nil
+	case prefix < 0xb8:
+		size := int(prefix - 0x80)
+		if size > len(b)-1 {
+			return false, nil, nil, ErrInvalidRLP
+		}
+		// A single byte below 0x80 is its own encoding
+		if size == 1 && b[1] < 0x80 {
+			return false, nil, nil, ErrInvalidRLP
+		}
+		return false, b[1 : 1+size], b[1+size:], nil
+	case prefix < 0xc0:
+		content, rest, err := splitLong(b[1:], int(prefix-0xb7))
+		return false, content, rest, err
+	case prefix < 0xf8:
+		size := int(prefix - 0xc0)
+		if size > len(b)-1 {
+			return false, nil, nil, ErrInvalidRLP
+		}
+		return true, b[1 : 1+size], b[1+size:], nil
+	default:
+		content, rest, err := splitLong(b[1:], int(prefix-0xf7))
+		return true, content, rest, err
+	}
+}
+
+// splitLong splits an item with a size of sizeLen bytes at the start of b
+func splitLong(b []byte, sizeLen int) ([]byte, []byte, error) {
+	if sizeLen > len(b) || sizeLen > 4 || b[0] == 0 {
+		return nil, nil, ErrInvalidRLP
+	}
+	size := 0
+	for _, c := range b[:sizeLen] {
+		size = size<<8 | int(c)
+	}
+	if size < 56 || size > len(b)-sizeLen {
+		return nil, nil, ErrInvalidRLP
+	}
+	return b[sizeLen : sizeLen+size], b[sizeLen+size:], nil
+}
+
+// splitList returns the raw encoding of each item in the RLP list b
+func splitList(b []byte) ([][]byte, error) {
+	isList, content, rest, err := splitRLP(b)
+	if err != nil {
+		return nil, err
+	}
+	if !isList || len(rest) != 0 {
+		return nil, ErrInvalidRLP
+	}
+	var rv [][]byte
+	for len(content) != 0 {
+		_, _, next, err := splitRLP(content)
+		if err != nil {
+			return nil, err
+		}
+		rv = append(rv, content[:len(content)-len(next)])
+		content = next
+	}
+	return rv, nil
+}
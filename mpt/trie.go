@@ -0,0 +1,450 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+// Package mpt implements the Merkle Patricia Trie used by Ethereum, with RLP encoded nodes hashed
+// with Keccak-256, as described in the Ethereum Yellow Paper. Root hashes and proofs are the same
+// as those from Ethereum, so proofs can be verified by the same code as the storage proofs returned
+// by eth_getProof, and vice versa.
+package mpt
+
+import (
+	"bytes"
+	"errors"
+
+	"golang.org/x/crypto/sha3"
+)
+
+var (
+	// ErrMissingNode is returned by a NodeReader when it does not have the node with a hash.
+	ErrMissingNode = errors.New("missing trie node")
+
+	// ErrInvalidProof is returned when a proof does not verify.
+	ErrInvalidProof = errors.New("invalid trie proof")
+
+	// EmptyRoot is the root hash of an empty trie, being the hash of the RLP encoding of an empty string.
+	EmptyRoot = Keccak256(emptyString)
+)
+
+// Keccak256 returns the Keccak-256 hash of b, as used by Ethereum. This is not the same as SHA3-256.
+func Keccak256(b []byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write(b)
+	return h.Sum(nil)
+}
+
+// NodeReader returns the RLP encoding of the node with the given hash, or ErrMissingNode if it has none.
+type NodeReader interface {
+	Node(hash []byte) ([]byte, error)
+}
+
+// NodeWriter can also store the RLP encoding of a node by its hash.
+type NodeWriter interface {
+	NodeReader
+	PutNode(hash, enc []byte) error
+}
+
+// MemoryNodes is a NodeWriter that keeps nodes in memory.
+type MemoryNodes map[string][]byte
+
+// Node returns the node with the given hash.
+func (m MemoryNodes) Node(hash []byte) ([]byte, error) {
+	rv, ok := m[string(hash)]
+	if !ok {
+		return nil, ErrMissingNode
+	}
+	return rv, nil
+}
+
+// PutNode stores the node with the given hash.
+func (m MemoryNodes) PutNode(hash, enc []byte) error {
+	m[string(hash)] = enc
+	return nil
+}
+
+// A node is one of *fullNode, *shortNode, hashNode, valueNode, or nil for empty
+type node interface{}
+
+// fullNode is a branch, with a child for each nibble, and a value for a key that ends here
+type fullNode struct {
+	Children [17]node
+}
+
+// shortNode is a leaf, if Key ends with the terminator and Val is a valueNode, else an extension
+type shortNode struct {
+	Key []byte // nibbles
+	Val node
+}
+
+// hashNode is a node not yet loaded, referred to by its hash
+type hashNode []byte
+
+// valueNode is the value for a key
+type valueNode []byte
+
+// terminator is appended to the nibbles of a key, so that a key is never a prefix of another
+const terminator = 16
+
+// Trie is a Merkle Patricia Trie. Nodes are loaded from a NodeReader as needed, and changes are
+// kept in memory until written with Commit. It is not go-routine safe.
+type Trie struct {
+	root node
+	db   NodeReader
+}
+
+// New returns the trie with the given root hash, loading nodes from db as needed. A nil or
+// empty root hash, or EmptyRoot, is the empty trie.
+func New(root []byte, db NodeReader) *Trie {
+	rv := &Trie{db: db}
+	if len(root) != 0 && !bytes.Equal(root, EmptyRoot) {
+		rv.root = hashNode(root)
+	}
+	return rv
+}
+
+// Get returns the value for key, or nil if it has none.
+func (t *Trie) Get(key []byte) ([]byte, error) {
+	k := keyNibbles(key)
+	n := t.root
+	for {
+		switch nn := n.(type) {
+		case nil:
+			return nil, nil
+		case valueNode:
+			return nn, nil
+		case hashNode:
+			var err error
+			n, err = t.resolve(nn)
+			if err != nil {
+				return nil, err
+			}
+		case *shortNode:
+			if !bytes.HasPrefix(k, nn.Key) {
+				return nil, nil
+			}
+			n, k = nn.Val, k[len(nn.Key):]
+		case *fullNode:
+			n, k = nn.Children[k[0]], k[1:]
+		}
+	}
+}
+
+// Update sets the value for key. An empty value deletes the key.
+func (t *Trie) Update(key, value []byte) error {
+	var err error
+	if len(value) == 0 {
+		t.root, err = t.delete(t.root, keyNibbles(key))
+	} else {
+		t.root, err = t.insert(t.root, keyNibbles(key), valueNode(append([]byte(nil), value...)))
+	}
+	return err
+}
+
+// Hash returns the root hash of the trie.
+func (t *Trie) Hash() []byte {
+	// Nothing is written, so this cannot fail
+	rv, _ := t.commit(nil)
+	return rv
+}
+
+// Commit writes each node that has changed, and is referred to by its hash, to db, and returns the
+// root hash of the trie. Nodes are then loaded from db as needed.
+func (t *Trie) Commit(db NodeWriter) ([]byte, error) {
+	rv, err := t.commit(db)
+	if err != nil {
+		return nil, err
+	}
+	t.db = db
+	if t.root != nil {
+		t.root = hashNode(rv)
+	}
+	return rv, nil
+}
+
+func (t *Trie) commit(db NodeWriter) ([]byte, error) {
+	switch n := t.root.(type) {
+	case nil:
+		return EmptyRoot, nil
+	case hashNode:
+		return n, nil
+	}
+	// The root is always referred to by its hash, however short
+	enc, err := encodeNode(t.root, db)
+	if err != nil {
+		return nil, err
+	}
+	rv := Keccak256(enc)
+	if db != nil {
+		err = db.PutNode(rv, enc)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rv, nil
+}
+
+// Prove returns the proof for the value of key, or that it has none. This is the RLP encoding of
+// each node referred to by hash on the path to key, starting with the root. Nodes that are short
+// enough to be included in their parent are not listed separately.
+func (t *Trie) Prove(key []byte) ([][]byte, error) {
+	k := keyNibbles(key)
+	n := t.root
+	var rv [][]byte
+	for len(k) != 0 && n != nil {
+		cur := n
+		switch nn := cur.(type) {
+		case hashNode:
+			var err error
+			n, err = t.resolve(nn)
+			if err != nil {
+				return nil, err
+			}
+			continue
+		case *shortNode:
+			if bytes.HasPrefix(k, nn.Key) {
+				n, k = nn.Val, k[len(nn.Key):]
+			} else {
+				n = nil
+			}
+		case *fullNode:
+			n, k = nn.Children[k[0]], k[1:]
+		default:
+			return nil, ErrInvalidProof
+		}
+		enc, err := encodeNode(cur, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(rv) == 0 || len(enc) >= 32 {
+			rv = append(rv, enc)
+		}
+	}
+	return rv, nil
+}
+
+// VerifyProof verifies a proof from Prove for key in the trie with the given root hash, and returns
+// the value for key, or nil if the proof shows it has none.
+func VerifyProof(root, key []byte, proof [][]byte) ([]byte, error) {
+	nodes := make(map[string][]byte)
+	for _, enc := range proof {
+		nodes[string(Keccak256(enc))] = enc
+	}
+	if len(proof) == 0 && bytes.Equal(root, EmptyRoot) {
+		return nil, nil
+	}
+
+	k := keyNibbles(key)
+	want := root
+	for {
+		enc, ok := nodes[string(want)]
+		if !ok {
+			return nil, ErrInvalidProof
+		}
+		n, err := decodeNode(enc)
+		if err != nil {
+			return nil, ErrInvalidProof
+		}
+
+		// Descend through the node and any included in it, until we need the next from the proof
+		for want = nil; want == nil; {
+			switch nn := n.(type) {
+			case nil:
+				return nil, nil
+			case valueNode:
+				if len(k) != 0 {
+					return nil, ErrInvalidProof
+				}
+				return nn, nil
+			case hashNode:
+				want = nn
+			case *shortNode:
+				if !bytes.HasPrefix(k, nn.Key) {
+					return nil, nil
+				}
+				n, k = nn.Val, k[len(nn.Key):]
+			case *fullNode:
+				if len(k) == 0 {
+					return nil, ErrInvalidProof
+				}
+				n, k = nn.Children[k[0]], k[1:]
+			}
+		}
+	}
+}
+
+// resolve loads the node with the given hash
+func (t *Trie) resolve(n hashNode) (node, error) {
+	if t.db == nil {
+		return nil, ErrMissingNode
+	}
+	enc, err := t.db.Node(n)
+	if err != nil {
+		return nil, err
+	}
+	return decodeNode(enc)
+}
+
+// insert sets value for the nibbles in key below n, returning the replacement for n
+func (t *Trie) insert(n node, key []byte, value node) (node, error) {
+	if len(key) == 0 {
+		return value, nil
+	}
+	switch n := n.(type) {
+	case nil:
+		return &shortNode{Key: key, Val: value}, nil
+	case hashNode:
+		rn, err := t.resolve(n)
+		if err != nil {
+			return nil, err
+		}
+		return t.insert(rn, key, value)
+	case *shortNode:
+		matched := prefixLen(key, n.Key)
+		if matched == len(n.Key) {
+			nn, err := t.insert(n.Val, key[matched:], value)
+			if err != nil {
+				return nil, err
+			}
+			return &shortNode{Key: n.Key, Val: nn}, nil
+		}
+
+		// Otherwise branch out where the keys differ
+		branch := &fullNode{}
+		var err error
+		branch.Children[n.Key[matched]], err = t.insert(nil, n.Key[matched+1:], n.Val)
+		if err != nil {
+			return nil, err
+		}
+		branch.Children[key[matched]], err = t.insert(nil, key[matched+1:], value)
+		if err != nil {
+			return nil, err
+		}
+		if matched == 0 {
+			return branch, nil
+		}
+		return &shortNode{Key: key[:matched], Val: branch}, nil
+	case *fullNode:
+		nn, err := t.insert(n.Children[key[0]], key[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		rv := *n
+		rv.Children[key[0]] = nn
+		return &rv, nil
+	default:
+		return nil, ErrInvalidProof
+	}
+}
+
+// delete removes the value for the nibbles in key below n, returning the replacement for n
+func (t *Trie) delete(n node, key []byte) (node, error) {
+	switch n := n.(type) {
+	case nil, valueNode:
+		return nil, nil
+	case hashNode:
+		rn, err := t.resolve(n)
+		if err != nil {
+			return nil, err
+		}
+		return t.delete(rn, key)
+	case *shortNode:
+		matched := prefixLen(key, n.Key)
+		if matched < len(n.Key) {
+			return n, nil // not present
+		}
+		if matched == len(key) {
+			return nil, nil
+		}
+		child, err := t.delete(n.Val, key[matched:])
+		if err != nil {
+			return nil, err
+		}
+		switch child := child.(type) {
+		case nil:
+			return nil, nil
+		case *shortNode:
+			// Merge with the child so that there are never two short nodes in a row
+			return &shortNode{Key: concat(n.Key, child.Key...), Val: child.Val}, nil
+		default:
+			return &shortNode{Key: n.Key, Val: child}, nil
+		}
+	case *fullNode:
+		nn, err := t.delete(n.Children[key[0]], key[1:])
+		if err != nil {
+			return nil, err
+		}
+		rv := *n
+		rv.Children[key[0]] = nn
+		if nn != nil {
+			return &rv, nil
+		}
+
+		// A branch left with a single child is replaced by a short node
+		pos := -1
+		for i, c := range rv.Children {
+			if c != nil {
+				if pos != -1 {
+					return &rv, nil
+				}
+				pos = i
+			}
+		}
+		if pos == -1 {
+			return nil, nil
+		}
+		if pos != terminator {
+			child := rv.Children[pos]
+			if h, ok := child.(hashNode); ok {
+				child, err = t.resolve(h)
+				if err != nil {
+					return nil, err
+				}
+			}
+			if child, ok := child.(*shortNode); ok {
+				return &shortNode{Key: concat([]byte{byte(pos)}, child.Key...), Val: child.Val}, nil
+			}
+		}
+		return &shortNode{Key: []byte{byte(pos)}, Val: rv.Children[pos]}, nil
+	default:
+		return nil, ErrInvalidProof
+	}
+}
+
+// keyNibbles returns the nibbles of key, followed by the terminator
+func keyNibbles(key []byte) []byte {
+	rv := make([]byte, len(key)*2+1)
+	for i, b := range key {
+		rv[i*2] = b >> 4
+		rv[i*2+1] = b & 0x0f
+	}
+	rv[len(rv)-1] = terminator
+	return rv
+}
+
+// prefixLen returns the length of the common prefix of a and b
+func prefixLen(a, b []byte) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+func concat(a []byte, b ...byte) []byte {
+	rv := make([]byte, 0, len(a)+len(b))
+	return append(append(rv, a...), b...)
+}
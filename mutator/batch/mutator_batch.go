@@ -84,6 +84,9 @@ type mapNoLockDB struct {
 type batchMutatorImpl struct {
 	Conf *Mutator
 	Ch   chan *chObject
+
+	// Notifies watchers as batches are written out
+	verifiable.UpdateBroadcaster
 }
 
 // It must return nil, ErrNoSuchKey if none found
@@ -181,7 +184,7 @@ func (bm *batchMutatorImpl) consume() {
 			log.Fatal(err)
 		}
 
-		if nextSize > startSize { // save it out
+		if len(wrapper.L) > 0 { // save it out, some mutations (such as cosigned tree heads) do not change the size
 			err = bm.Conf.Writer.ExecuteUpdate(ctx, ns, func(ctx context.Context, kw verifiable.KeyWriter) error {
 				for _, o := range wrapper.L {
 					err := kw.Set(ctx, o.Key, o.Value)
@@ -194,6 +197,7 @@ func (bm *batchMutatorImpl) consume() {
 			if err != nil {
 				log.Fatal(err)
 			}
+			bm.Notify(ns)
 		}
 	}
 }
@@ -30,11 +30,14 @@ import (
 type Mutator struct {
 	// Writer is the database to apply the mutations
 	Writer verifiable.StorageWriter
+
+	// Notifies watchers as mutations are applied
+	verifiable.UpdateBroadcaster
 }
 
 // QueueMutation applies the mutation, normally asynchronously, but synchronously for the InstantMutator
 func (m *Mutator) QueueMutation(ctx context.Context, ns []byte, mut *pb.Mutation) error {
-	return m.Writer.ExecuteUpdate(ctx, ns, func(ctx context.Context, kw verifiable.KeyWriter) error {
+	err := m.Writer.ExecuteUpdate(ctx, ns, func(ctx context.Context, kw verifiable.KeyWriter) error {
 		startSize, err := verifiable.ReadObjectSize(ctx, kw)
 		if err != nil {
 			return err
@@ -51,4 +54,9 @@ func (m *Mutator) QueueMutation(ctx context.Context, ns []byte, mut *pb.Mutation
 		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+	m.Notify(ns)
+	return nil
 }
@@ -87,6 +87,59 @@ func (LogType) EnumDescriptor() ([]byte, []int) {
 	return file_api_proto_rawDescGZIP(), []int{0}
 }
 
+// Hash algorithm used for leaf, node and key hashes in a log or map. Recorded when the first entry is added.
+type HashAlgorithm int32
+
+const (
+	HashAlgorithm_HASH_SHA256      HashAlgorithm = 0 // default
+	HashAlgorithm_HASH_SHA512_256  HashAlgorithm = 1
+	HashAlgorithm_HASH_BLAKE2B_256 HashAlgorithm = 2
+	HashAlgorithm_HASH_BLAKE3      HashAlgorithm = 3
+)
+
+// Enum value maps for HashAlgorithm.
+var (
+	HashAlgorithm_name = map[int32]string{
+		0: "HASH_SHA256",
+		1: "HASH_SHA512_256",
+		2: "HASH_BLAKE2B_256",
+		3: "HASH_BLAKE3",
+	}
+	HashAlgorithm_value = map[string]int32{
+		"HASH_SHA256":      0,
+		"HASH_SHA512_256":  1,
+		"HASH_BLAKE2B_256": 2,
+		"HASH_BLAKE3":      3,
+	}
+)
+
+func (x HashAlgorithm) Enum() *HashAlgorithm {
+	p := new(HashAlgorithm)
+	*p = x
+	return p
+}
+
+func (x HashAlgorithm) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (HashAlgorithm) Descriptor() protoreflect.EnumDescriptor {
+	return file_api_proto_enumTypes[1].Descriptor()
+}
+
+func (HashAlgorithm) Type() protoreflect.EnumType {
+	return &file_api_proto_enumTypes[1]
+}
+
+func (x HashAlgorithm) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use HashAlgorithm.Descriptor instead.
+func (HashAlgorithm) EnumDescriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{1}
+}
+
 type DataFormat int32
 
 const (
@@ -117,11 +170,11 @@ func (x DataFormat) String() string {
 }
 
 func (DataFormat) Descriptor() protoreflect.EnumDescriptor {
-	return file_api_proto_enumTypes[1].Descriptor()
+	return file_api_proto_enumTypes[2].Descriptor()
 }
 
 func (DataFormat) Type() protoreflect.EnumType {
-	return &file_api_proto_enumTypes[1]
+	return &file_api_proto_enumTypes[2]
 }
 
 func (x DataFormat) Number() protoreflect.EnumNumber {
@@ -130,7 +183,7 @@ func (x DataFormat) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use DataFormat.Descriptor instead.
 func (DataFormat) EnumDescriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{1}
+	return file_api_proto_rawDescGZIP(), []int{2}
 }
 
 type AccountRef struct {
@@ -190,6 +243,12 @@ type LogRef struct {
 	Account       *AccountRef            `protobuf:"bytes,1,opt,name=account,proto3" json:"account,omitempty"`
 	LogType       LogType                `protobuf:"varint,2,opt,name=log_type,json=logType,proto3,enum=com.continusec.verifiabledatastructures.api.LogType" json:"log_type,omitempty"`
 	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	HashAlgorithm HashAlgorithm          `protobuf:"varint,4,opt,name=hash_algorithm,json=hashAlgorithm,proto3,enum=com.continusec.verifiabledatastructures.api.HashAlgorithm" json:"hash_algorithm,omitempty"` // for a mutation or treehead log, must match the map
+	WriteOnce     bool                   `protobuf:"varint,5,opt,name=write_once,json=writeOnce,proto3" json:"write_once,omitempty"`                                                                            // for a mutation log, must match the map
+	VrfPublicKey  []byte                 `protobuf:"bytes,6,opt,name=vrf_public_key,json=vrfPublicKey,proto3" json:"vrf_public_key,omitempty"`                                                                  // for a mutation log of a map with VRF keys, set by the server to its VRF public key
+	SumField      string                 `protobuf:"bytes,7,opt,name=sum_field,json=sumField,proto3" json:"sum_field,omitempty"`                                                                                // for a mutation log, must match the map
+	Reducer       *MapReducer            `protobuf:"bytes,8,opt,name=reducer,proto3" json:"reducer,omitempty"`                                                                                                  // for a user log with a derived map, set by the server to the reducer for the map
+	PatriciaTrie  bool                   `protobuf:"varint,9,opt,name=patricia_trie,json=patriciaTrie,proto3" json:"patricia_trie,omitempty"`                                                                   // for a mutation log, must match the map
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -245,28 +304,72 @@ func (x *LogRef) GetName() string {
 	return ""
 }
 
-type MapRef struct {
+func (x *LogRef) GetHashAlgorithm() HashAlgorithm {
+	if x != nil {
+		return x.HashAlgorithm
+	}
+	return HashAlgorithm_HASH_SHA256
+}
+
+func (x *LogRef) GetWriteOnce() bool {
+	if x != nil {
+		return x.WriteOnce
+	}
+	return false
+}
+
+func (x *LogRef) GetVrfPublicKey() []byte {
+	if x != nil {
+		return x.VrfPublicKey
+	}
+	return nil
+}
+
+func (x *LogRef) GetSumField() string {
+	if x != nil {
+		return x.SumField
+	}
+	return ""
+}
+
+func (x *LogRef) GetReducer() *MapReducer {
+	if x != nil {
+		return x.Reducer
+	}
+	return nil
+}
+
+func (x *LogRef) GetPatriciaTrie() bool {
+	if x != nil {
+		return x.PatriciaTrie
+	}
+	return false
+}
+
+// Deterministic rules for deriving a map mutation from each entry in a log. Entries are read as JSON.
+type MapReducer struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Account       *AccountRef            `protobuf:"bytes,1,opt,name=account,proto3" json:"account,omitempty"`
-	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	KeyPath       string                 `protobuf:"bytes,1,opt,name=key_path,json=keyPath,proto3" json:"key_path,omitempty"`       // dot separated path to the key in each entry, e.g. "subject" or "event.subject". Entries where this is not a string or number are skipped
+	ValuePath     string                 `protobuf:"bytes,2,opt,name=value_path,json=valuePath,proto3" json:"value_path,omitempty"` // dot separated path to the value to set for the key. If empty, the entry itself is set
+	Rules         []*MapReducerRule      `protobuf:"bytes,3,rep,name=rules,proto3" json:"rules,omitempty"`                          // the first rule that matches an entry decides what is done with it. If none match, the value is set
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *MapRef) Reset() {
-	*x = MapRef{}
+func (x *MapReducer) Reset() {
+	*x = MapReducer{}
 	mi := &file_api_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *MapRef) String() string {
+func (x *MapReducer) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*MapRef) ProtoMessage() {}
+func (*MapReducer) ProtoMessage() {}
 
-func (x *MapRef) ProtoReflect() protoreflect.Message {
+func (x *MapReducer) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -278,47 +381,55 @@ func (x *MapRef) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use MapRef.ProtoReflect.Descriptor instead.
-func (*MapRef) Descriptor() ([]byte, []int) {
+// Deprecated: Use MapReducer.ProtoReflect.Descriptor instead.
+func (*MapReducer) Descriptor() ([]byte, []int) {
 	return file_api_proto_rawDescGZIP(), []int{2}
 }
 
-func (x *MapRef) GetAccount() *AccountRef {
+func (x *MapReducer) GetKeyPath() string {
 	if x != nil {
-		return x.Account
+		return x.KeyPath
 	}
-	return nil
+	return ""
 }
 
-func (x *MapRef) GetName() string {
+func (x *MapReducer) GetValuePath() string {
 	if x != nil {
-		return x.Name
+		return x.ValuePath
 	}
 	return ""
 }
 
-type LogTreeHashRequest struct {
+func (x *MapReducer) GetRules() []*MapReducerRule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+type MapReducerRule struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Log           *LogRef                `protobuf:"bytes,1,opt,name=log,proto3" json:"log,omitempty"`
-	TreeSize      int64                  `protobuf:"varint,2,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`     // dot separated path in the entry. If empty, the rule matches every entry
+	Equals        string                 `protobuf:"bytes,2,opt,name=equals,proto3" json:"equals,omitempty"` // the rule matches if the value at path is this string, or for other values, their JSON encoding, e.g. "true" or "3"
+	Action        string                 `protobuf:"bytes,3,opt,name=action,proto3" json:"action,omitempty"` // one of "set", "delete" or "skip"
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *LogTreeHashRequest) Reset() {
-	*x = LogTreeHashRequest{}
+func (x *MapReducerRule) Reset() {
+	*x = MapReducerRule{}
 	mi := &file_api_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *LogTreeHashRequest) String() string {
+func (x *MapReducerRule) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LogTreeHashRequest) ProtoMessage() {}
+func (*MapReducerRule) ProtoMessage() {}
 
-func (x *LogTreeHashRequest) ProtoReflect() protoreflect.Message {
+func (x *MapReducerRule) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -330,47 +441,59 @@ func (x *LogTreeHashRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LogTreeHashRequest.ProtoReflect.Descriptor instead.
-func (*LogTreeHashRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use MapReducerRule.ProtoReflect.Descriptor instead.
+func (*MapReducerRule) Descriptor() ([]byte, []int) {
 	return file_api_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *LogTreeHashRequest) GetLog() *LogRef {
+func (x *MapReducerRule) GetPath() string {
 	if x != nil {
-		return x.Log
+		return x.Path
 	}
-	return nil
+	return ""
 }
 
-func (x *LogTreeHashRequest) GetTreeSize() int64 {
+func (x *MapReducerRule) GetEquals() string {
 	if x != nil {
-		return x.TreeSize
+		return x.Equals
 	}
-	return 0
+	return ""
 }
 
-type LogTreeHashResponse struct {
+func (x *MapReducerRule) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+type MapRef struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	TreeSize      int64                  `protobuf:"varint,1,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
-	RootHash      []byte                 `protobuf:"bytes,2,opt,name=root_hash,json=rootHash,proto3" json:"root_hash,omitempty"`
+	Account       *AccountRef            `protobuf:"bytes,1,opt,name=account,proto3" json:"account,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	HashAlgorithm HashAlgorithm          `protobuf:"varint,4,opt,name=hash_algorithm,json=hashAlgorithm,proto3,enum=com.continusec.verifiabledatastructures.api.HashAlgorithm" json:"hash_algorithm,omitempty"`
+	WriteOnce     bool                   `protobuf:"varint,5,opt,name=write_once,json=writeOnce,proto3" json:"write_once,omitempty"`          // if set, a key with a non-empty value can never be changed. Fixed when the first mutation is added.
+	VrfKeys       bool                   `protobuf:"varint,6,opt,name=vrf_keys,json=vrfKeys,proto3" json:"vrf_keys,omitempty"`                // if set, the path for a key is derived from the server's VRF of it, so that proofs do not reveal which keys exist. Fixed when the first mutation is added.
+	SumField      string                 `protobuf:"bytes,7,opt,name=sum_field,json=sumField,proto3" json:"sum_field,omitempty"`              // if set, each node also commits to the number of non-empty values below it, and the sum of this integer field in them. Fixed when the first mutation is added.
+	PatriciaTrie  bool                   `protobuf:"varint,8,opt,name=patricia_trie,json=patriciaTrie,proto3" json:"patricia_trie,omitempty"` // if set, the root hash is that of an Ethereum compatible Merkle Patricia Trie of the values, so proofs are in the eth_getProof format. Cannot be used with vrf_keys or sum_field. Fixed when the first mutation is added.
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *LogTreeHashResponse) Reset() {
-	*x = LogTreeHashResponse{}
+func (x *MapRef) Reset() {
+	*x = MapRef{}
 	mi := &file_api_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *LogTreeHashResponse) String() string {
+func (x *MapRef) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LogTreeHashResponse) ProtoMessage() {}
+func (*MapRef) ProtoMessage() {}
 
-func (x *LogTreeHashResponse) ProtoReflect() protoreflect.Message {
+func (x *MapRef) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -382,47 +505,85 @@ func (x *LogTreeHashResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LogTreeHashResponse.ProtoReflect.Descriptor instead.
-func (*LogTreeHashResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use MapRef.ProtoReflect.Descriptor instead.
+func (*MapRef) Descriptor() ([]byte, []int) {
 	return file_api_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *LogTreeHashResponse) GetTreeSize() int64 {
+func (x *MapRef) GetAccount() *AccountRef {
 	if x != nil {
-		return x.TreeSize
+		return x.Account
 	}
-	return 0
+	return nil
 }
 
-func (x *LogTreeHashResponse) GetRootHash() []byte {
+func (x *MapRef) GetName() string {
 	if x != nil {
-		return x.RootHash
+		return x.Name
 	}
-	return nil
+	return ""
 }
 
-type MapTreeHashRequest struct {
+func (x *MapRef) GetHashAlgorithm() HashAlgorithm {
+	if x != nil {
+		return x.HashAlgorithm
+	}
+	return HashAlgorithm_HASH_SHA256
+}
+
+func (x *MapRef) GetWriteOnce() bool {
+	if x != nil {
+		return x.WriteOnce
+	}
+	return false
+}
+
+func (x *MapRef) GetVrfKeys() bool {
+	if x != nil {
+		return x.VrfKeys
+	}
+	return false
+}
+
+func (x *MapRef) GetSumField() string {
+	if x != nil {
+		return x.SumField
+	}
+	return ""
+}
+
+func (x *MapRef) GetPatriciaTrie() bool {
+	if x != nil {
+		return x.PatriciaTrie
+	}
+	return false
+}
+
+// A multimap is a map where each key has an append-only list of values. The value in the map for
+// a key is the tree head of a log of the values for the key.
+type MultimapRef struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Map           *MapRef                `protobuf:"bytes,1,opt,name=map,proto3" json:"map,omitempty"`
-	TreeSize      int64                  `protobuf:"varint,2,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
+	Account       *AccountRef            `protobuf:"bytes,1,opt,name=account,proto3" json:"account,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	HashAlgorithm HashAlgorithm          `protobuf:"varint,3,opt,name=hash_algorithm,json=hashAlgorithm,proto3,enum=com.continusec.verifiabledatastructures.api.HashAlgorithm" json:"hash_algorithm,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *MapTreeHashRequest) Reset() {
-	*x = MapTreeHashRequest{}
+func (x *MultimapRef) Reset() {
+	*x = MultimapRef{}
 	mi := &file_api_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *MapTreeHashRequest) String() string {
+func (x *MultimapRef) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*MapTreeHashRequest) ProtoMessage() {}
+func (*MultimapRef) ProtoMessage() {}
 
-func (x *MapTreeHashRequest) ProtoReflect() protoreflect.Message {
+func (x *MultimapRef) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -434,47 +595,55 @@ func (x *MapTreeHashRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use MapTreeHashRequest.ProtoReflect.Descriptor instead.
-func (*MapTreeHashRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use MultimapRef.ProtoReflect.Descriptor instead.
+func (*MultimapRef) Descriptor() ([]byte, []int) {
 	return file_api_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *MapTreeHashRequest) GetMap() *MapRef {
+func (x *MultimapRef) GetAccount() *AccountRef {
 	if x != nil {
-		return x.Map
+		return x.Account
 	}
 	return nil
 }
 
-func (x *MapTreeHashRequest) GetTreeSize() int64 {
+func (x *MultimapRef) GetName() string {
 	if x != nil {
-		return x.TreeSize
+		return x.Name
 	}
-	return 0
+	return ""
 }
 
-type MapTreeHashResponse struct {
+func (x *MultimapRef) GetHashAlgorithm() HashAlgorithm {
+	if x != nil {
+		return x.HashAlgorithm
+	}
+	return HashAlgorithm_HASH_SHA256
+}
+
+type LogTreeHashRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	RootHash      []byte                 `protobuf:"bytes,1,opt,name=root_hash,json=rootHash,proto3" json:"root_hash,omitempty"`
-	MutationLog   *LogTreeHashResponse   `protobuf:"bytes,2,opt,name=mutation_log,json=mutationLog,proto3" json:"mutation_log,omitempty"`
+	Log           *LogRef                `protobuf:"bytes,1,opt,name=log,proto3" json:"log,omitempty"`
+	TreeSize      int64                  `protobuf:"varint,2,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
+	Cosigned      bool                   `protobuf:"varint,3,opt,name=cosigned,proto3" json:"cosigned,omitempty"` // if set, only return a tree head that has been cosigned by a quorum of witnesses
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *MapTreeHashResponse) Reset() {
-	*x = MapTreeHashResponse{}
+func (x *LogTreeHashRequest) Reset() {
+	*x = LogTreeHashRequest{}
 	mi := &file_api_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *MapTreeHashResponse) String() string {
+func (x *LogTreeHashRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*MapTreeHashResponse) ProtoMessage() {}
+func (*LogTreeHashRequest) ProtoMessage() {}
 
-func (x *MapTreeHashResponse) ProtoReflect() protoreflect.Message {
+func (x *LogTreeHashRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -486,50 +655,56 @@ func (x *MapTreeHashResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use MapTreeHashResponse.ProtoReflect.Descriptor instead.
-func (*MapTreeHashResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use LogTreeHashRequest.ProtoReflect.Descriptor instead.
+func (*LogTreeHashRequest) Descriptor() ([]byte, []int) {
 	return file_api_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *MapTreeHashResponse) GetRootHash() []byte {
+func (x *LogTreeHashRequest) GetLog() *LogRef {
 	if x != nil {
-		return x.RootHash
+		return x.Log
 	}
 	return nil
 }
 
-func (x *MapTreeHashResponse) GetMutationLog() *LogTreeHashResponse {
+func (x *LogTreeHashRequest) GetTreeSize() int64 {
 	if x != nil {
-		return x.MutationLog
+		return x.TreeSize
 	}
-	return nil
+	return 0
 }
 
-type LogInclusionProofRequest struct {
-	state    protoimpl.MessageState `protogen:"open.v1"`
-	Log      *LogRef                `protobuf:"bytes,1,opt,name=log,proto3" json:"log,omitempty"`
-	TreeSize int64                  `protobuf:"varint,2,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"` // may be 0 (HEAD)
-	// One of:
-	MtlHash       []byte `protobuf:"bytes,3,opt,name=mtl_hash,json=mtlHash,proto3" json:"mtl_hash,omitempty"`        // used, if not nil
-	LeafIndex     int64  `protobuf:"varint,4,opt,name=leaf_index,json=leafIndex,proto3" json:"leaf_index,omitempty"` // used if mtl_hash is nil
+func (x *LogTreeHashRequest) GetCosigned() bool {
+	if x != nil {
+		return x.Cosigned
+	}
+	return false
+}
+
+type LogTreeHashResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TreeSize      int64                  `protobuf:"varint,1,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
+	RootHash      []byte                 `protobuf:"bytes,2,opt,name=root_hash,json=rootHash,proto3" json:"root_hash,omitempty"`
+	Signature     *TreeHeadSignature     `protobuf:"bytes,3,opt,name=signature,proto3" json:"signature,omitempty"`       // set by the server if configured with a signing key. Not stored.
+	Cosignatures  []*TreeHeadSignature   `protobuf:"bytes,4,rep,name=cosignatures,proto3" json:"cosignatures,omitempty"` // set only if cosigned is requested, one per witness
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *LogInclusionProofRequest) Reset() {
-	*x = LogInclusionProofRequest{}
+func (x *LogTreeHashResponse) Reset() {
+	*x = LogTreeHashResponse{}
 	mi := &file_api_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *LogInclusionProofRequest) String() string {
+func (x *LogTreeHashResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LogInclusionProofRequest) ProtoMessage() {}
+func (*LogTreeHashResponse) ProtoMessage() {}
 
-func (x *LogInclusionProofRequest) ProtoReflect() protoreflect.Message {
+func (x *LogTreeHashResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -541,62 +716,61 @@ func (x *LogInclusionProofRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LogInclusionProofRequest.ProtoReflect.Descriptor instead.
-func (*LogInclusionProofRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use LogTreeHashResponse.ProtoReflect.Descriptor instead.
+func (*LogTreeHashResponse) Descriptor() ([]byte, []int) {
 	return file_api_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *LogInclusionProofRequest) GetLog() *LogRef {
-	if x != nil {
-		return x.Log
-	}
-	return nil
-}
-
-func (x *LogInclusionProofRequest) GetTreeSize() int64 {
+func (x *LogTreeHashResponse) GetTreeSize() int64 {
 	if x != nil {
 		return x.TreeSize
 	}
 	return 0
 }
 
-func (x *LogInclusionProofRequest) GetMtlHash() []byte {
+func (x *LogTreeHashResponse) GetRootHash() []byte {
 	if x != nil {
-		return x.MtlHash
+		return x.RootHash
 	}
 	return nil
 }
 
-func (x *LogInclusionProofRequest) GetLeafIndex() int64 {
+func (x *LogTreeHashResponse) GetSignature() *TreeHeadSignature {
 	if x != nil {
-		return x.LeafIndex
+		return x.Signature
 	}
-	return 0
+	return nil
 }
 
-type LogInclusionProofResponse struct {
+func (x *LogTreeHashResponse) GetCosignatures() []*TreeHeadSignature {
+	if x != nil {
+		return x.Cosignatures
+	}
+	return nil
+}
+
+type LogCheckpointRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	TreeSize      int64                  `protobuf:"varint,1,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
-	LeafIndex     int64                  `protobuf:"varint,2,opt,name=leaf_index,json=leafIndex,proto3" json:"leaf_index,omitempty"`
-	AuditPath     [][]byte               `protobuf:"bytes,3,rep,name=audit_path,json=auditPath,proto3" json:"audit_path,omitempty"`
+	Log           *LogRef                `protobuf:"bytes,1,opt,name=log,proto3" json:"log,omitempty"`
+	TreeSize      int64                  `protobuf:"varint,2,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *LogInclusionProofResponse) Reset() {
-	*x = LogInclusionProofResponse{}
+func (x *LogCheckpointRequest) Reset() {
+	*x = LogCheckpointRequest{}
 	mi := &file_api_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *LogInclusionProofResponse) String() string {
+func (x *LogCheckpointRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LogInclusionProofResponse) ProtoMessage() {}
+func (*LogCheckpointRequest) ProtoMessage() {}
 
-func (x *LogInclusionProofResponse) ProtoReflect() protoreflect.Message {
+func (x *LogCheckpointRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_api_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -608,56 +782,94 @@ func (x *LogInclusionProofResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LogInclusionProofResponse.ProtoReflect.Descriptor instead.
-func (*LogInclusionProofResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use LogCheckpointRequest.ProtoReflect.Descriptor instead.
+func (*LogCheckpointRequest) Descriptor() ([]byte, []int) {
 	return file_api_proto_rawDescGZIP(), []int{8}
 }
 
-func (x *LogInclusionProofResponse) GetTreeSize() int64 {
+func (x *LogCheckpointRequest) GetLog() *LogRef {
+	if x != nil {
+		return x.Log
+	}
+	return nil
+}
+
+func (x *LogCheckpointRequest) GetTreeSize() int64 {
 	if x != nil {
 		return x.TreeSize
 	}
 	return 0
 }
 
-func (x *LogInclusionProofResponse) GetLeafIndex() int64 {
+type LogCheckpointResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Checkpoint    []byte                 `protobuf:"bytes,1,opt,name=checkpoint,proto3" json:"checkpoint,omitempty"` // signed note in the C2SP checkpoint format, see verifiable.ParseCheckpoint()
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogCheckpointResponse) Reset() {
+	*x = LogCheckpointResponse{}
+	mi := &file_api_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogCheckpointResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogCheckpointResponse) ProtoMessage() {}
+
+func (x *LogCheckpointResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[9]
 	if x != nil {
-		return x.LeafIndex
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return 0
+	return mi.MessageOf(x)
 }
 
-func (x *LogInclusionProofResponse) GetAuditPath() [][]byte {
+// Deprecated: Use LogCheckpointResponse.ProtoReflect.Descriptor instead.
+func (*LogCheckpointResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *LogCheckpointResponse) GetCheckpoint() []byte {
 	if x != nil {
-		return x.AuditPath
+		return x.Checkpoint
 	}
 	return nil
 }
 
-type LogConsistencyProofRequest struct {
+type MapListLeavesRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Log           *LogRef                `protobuf:"bytes,1,opt,name=log,proto3" json:"log,omitempty"`
-	FromSize      int64                  `protobuf:"varint,2,opt,name=from_size,json=fromSize,proto3" json:"from_size,omitempty"`
-	TreeSize      int64                  `protobuf:"varint,3,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"` // may be 0 (HEAD)
+	Map           *MapRef                `protobuf:"bytes,1,opt,name=map,proto3" json:"map,omitempty"`
+	TreeSize      int64                  `protobuf:"varint,2,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`              // may be 0 (HEAD)
+	StartKeyHash  []byte                 `protobuf:"bytes,3,opt,name=start_key_hash,json=startKeyHash,proto3" json:"start_key_hash,omitempty"` // first key hash to list, empty for the start of the map
+	Limit         int32                  `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`                                    // maximum number of leaves to return, 0 for the server maximum
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *LogConsistencyProofRequest) Reset() {
-	*x = LogConsistencyProofRequest{}
-	mi := &file_api_proto_msgTypes[9]
+func (x *MapListLeavesRequest) Reset() {
+	*x = MapListLeavesRequest{}
+	mi := &file_api_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *LogConsistencyProofRequest) String() string {
+func (x *MapListLeavesRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LogConsistencyProofRequest) ProtoMessage() {}
+func (*MapListLeavesRequest) ProtoMessage() {}
 
-func (x *LogConsistencyProofRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[9]
+func (x *MapListLeavesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -668,56 +880,63 @@ func (x *LogConsistencyProofRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LogConsistencyProofRequest.ProtoReflect.Descriptor instead.
-func (*LogConsistencyProofRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{9}
+// Deprecated: Use MapListLeavesRequest.ProtoReflect.Descriptor instead.
+func (*MapListLeavesRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{10}
 }
 
-func (x *LogConsistencyProofRequest) GetLog() *LogRef {
+func (x *MapListLeavesRequest) GetMap() *MapRef {
 	if x != nil {
-		return x.Log
+		return x.Map
 	}
 	return nil
 }
 
-func (x *LogConsistencyProofRequest) GetFromSize() int64 {
+func (x *MapListLeavesRequest) GetTreeSize() int64 {
 	if x != nil {
-		return x.FromSize
+		return x.TreeSize
 	}
 	return 0
 }
 
-func (x *LogConsistencyProofRequest) GetTreeSize() int64 {
+func (x *MapListLeavesRequest) GetStartKeyHash() []byte {
 	if x != nil {
-		return x.TreeSize
+		return x.StartKeyHash
+	}
+	return nil
+}
+
+func (x *MapListLeavesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
 	}
 	return 0
 }
 
-type LogConsistencyProofResponse struct {
+type MapLeaf struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	FromSize      int64                  `protobuf:"varint,1,opt,name=from_size,json=fromSize,proto3" json:"from_size,omitempty"`
-	TreeSize      int64                  `protobuf:"varint,2,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
-	AuditPath     [][]byte               `protobuf:"bytes,3,rep,name=audit_path,json=auditPath,proto3" json:"audit_path,omitempty"`
+	KeyHash       []byte                 `protobuf:"bytes,1,opt,name=key_hash,json=keyHash,proto3" json:"key_hash,omitempty"`
+	LeafHash      []byte                 `protobuf:"bytes,2,opt,name=leaf_hash,json=leafHash,proto3" json:"leaf_hash,omitempty"`
+	Value         *LeafData              `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *LogConsistencyProofResponse) Reset() {
-	*x = LogConsistencyProofResponse{}
-	mi := &file_api_proto_msgTypes[10]
+func (x *MapLeaf) Reset() {
+	*x = MapLeaf{}
+	mi := &file_api_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *LogConsistencyProofResponse) String() string {
+func (x *MapLeaf) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LogConsistencyProofResponse) ProtoMessage() {}
+func (*MapLeaf) ProtoMessage() {}
 
-func (x *LogConsistencyProofResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[10]
+func (x *MapLeaf) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -728,56 +947,195 @@ func (x *LogConsistencyProofResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LogConsistencyProofResponse.ProtoReflect.Descriptor instead.
-func (*LogConsistencyProofResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{10}
+// Deprecated: Use MapLeaf.ProtoReflect.Descriptor instead.
+func (*MapLeaf) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *LogConsistencyProofResponse) GetFromSize() int64 {
+func (x *MapLeaf) GetKeyHash() []byte {
 	if x != nil {
-		return x.FromSize
+		return x.KeyHash
+	}
+	return nil
+}
+
+func (x *MapLeaf) GetLeafHash() []byte {
+	if x != nil {
+		return x.LeafHash
+	}
+	return nil
+}
+
+func (x *MapLeaf) GetValue() *LeafData {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+// Hash of the subtree at depth containing each key hash that starts with the first depth bits of key_hash
+type MapSubTreeHash struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Depth         int32                  `protobuf:"varint,1,opt,name=depth,proto3" json:"depth,omitempty"`
+	KeyHash       []byte                 `protobuf:"bytes,2,opt,name=key_hash,json=keyHash,proto3" json:"key_hash,omitempty"` // remaining bits are zero
+	Hash          []byte                 `protobuf:"bytes,3,opt,name=hash,proto3" json:"hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MapSubTreeHash) Reset() {
+	*x = MapSubTreeHash{}
+	mi := &file_api_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapSubTreeHash) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapSubTreeHash) ProtoMessage() {}
+
+func (x *MapSubTreeHash) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapSubTreeHash.ProtoReflect.Descriptor instead.
+func (*MapSubTreeHash) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *MapSubTreeHash) GetDepth() int32 {
+	if x != nil {
+		return x.Depth
 	}
 	return 0
 }
 
-func (x *LogConsistencyProofResponse) GetTreeSize() int64 {
+func (x *MapSubTreeHash) GetKeyHash() []byte {
+	if x != nil {
+		return x.KeyHash
+	}
+	return nil
+}
+
+func (x *MapSubTreeHash) GetHash() []byte {
+	if x != nil {
+		return x.Hash
+	}
+	return nil
+}
+
+type MapListLeavesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TreeSize      int64                  `protobuf:"varint,1,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
+	Leaves        []*MapLeaf             `protobuf:"bytes,2,rep,name=leaves,proto3" json:"leaves,omitempty"`                                                                                                    // every non-empty leaf from start_key_hash up to next_key_hash, in key hash order
+	NextKeyHash   []byte                 `protobuf:"bytes,3,opt,name=next_key_hash,json=nextKeyHash,proto3" json:"next_key_hash,omitempty"`                                                                     // start_key_hash for the next page, empty if there are no more leaves
+	Boundary      []*MapSubTreeHash      `protobuf:"bytes,4,rep,name=boundary,proto3" json:"boundary,omitempty"`                                                                                                // non-empty subtrees with no key hashes in the page, needed to calculate the root hash
+	HashAlgorithm HashAlgorithm          `protobuf:"varint,5,opt,name=hash_algorithm,json=hashAlgorithm,proto3,enum=com.continusec.verifiabledatastructures.api.HashAlgorithm" json:"hash_algorithm,omitempty"` // as recorded for the map
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MapListLeavesResponse) Reset() {
+	*x = MapListLeavesResponse{}
+	mi := &file_api_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapListLeavesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapListLeavesResponse) ProtoMessage() {}
+
+func (x *MapListLeavesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapListLeavesResponse.ProtoReflect.Descriptor instead.
+func (*MapListLeavesResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *MapListLeavesResponse) GetTreeSize() int64 {
 	if x != nil {
 		return x.TreeSize
 	}
 	return 0
 }
 
-func (x *LogConsistencyProofResponse) GetAuditPath() [][]byte {
+func (x *MapListLeavesResponse) GetLeaves() []*MapLeaf {
 	if x != nil {
-		return x.AuditPath
+		return x.Leaves
 	}
 	return nil
 }
 
-type LeafData struct {
+func (x *MapListLeavesResponse) GetNextKeyHash() []byte {
+	if x != nil {
+		return x.NextKeyHash
+	}
+	return nil
+}
+
+func (x *MapListLeavesResponse) GetBoundary() []*MapSubTreeHash {
+	if x != nil {
+		return x.Boundary
+	}
+	return nil
+}
+
+func (x *MapListLeavesResponse) GetHashAlgorithm() HashAlgorithm {
+	if x != nil {
+		return x.HashAlgorithm
+	}
+	return HashAlgorithm_HASH_SHA256
+}
+
+type MapDiffRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	LeafInput     []byte                 `protobuf:"bytes,1,opt,name=leaf_input,json=leafInput,proto3" json:"leaf_input,omitempty"`
-	ExtraData     []byte                 `protobuf:"bytes,2,opt,name=extra_data,json=extraData,proto3" json:"extra_data,omitempty"`                                       // ignored by the server, returned to the user, who may have business logic to confirm that the leaf_input has been derived from this, e.g. objecthash
-	Format        DataFormat             `protobuf:"varint,3,opt,name=format,proto3,enum=com.continusec.verifiabledatastructures.api.DataFormat" json:"format,omitempty"` // optional, if set, can define metadata about the format of the ExtraData. e.g. if set to JSON, fields will be filtered.
+	Map           *MapRef                `protobuf:"bytes,1,opt,name=map,proto3" json:"map,omitempty"`
+	FromSize      int64                  `protobuf:"varint,2,opt,name=from_size,json=fromSize,proto3" json:"from_size,omitempty"`              // may be 0 for the empty map
+	ToSize        int64                  `protobuf:"varint,3,opt,name=to_size,json=toSize,proto3" json:"to_size,omitempty"`                    // may be 0 (HEAD)
+	StartKeyHash  []byte                 `protobuf:"bytes,4,opt,name=start_key_hash,json=startKeyHash,proto3" json:"start_key_hash,omitempty"` // first key hash to compare, empty for the start of the map
+	Limit         int32                  `protobuf:"varint,5,opt,name=limit,proto3" json:"limit,omitempty"`                                    // maximum number of changed keys to return, 0 for the server maximum
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *LeafData) Reset() {
-	*x = LeafData{}
-	mi := &file_api_proto_msgTypes[11]
+func (x *MapDiffRequest) Reset() {
+	*x = MapDiffRequest{}
+	mi := &file_api_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *LeafData) String() string {
+func (x *MapDiffRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LeafData) ProtoMessage() {}
+func (*MapDiffRequest) ProtoMessage() {}
 
-func (x *LeafData) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[11]
+func (x *MapDiffRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -788,55 +1146,70 @@ func (x *LeafData) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LeafData.ProtoReflect.Descriptor instead.
-func (*LeafData) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{11}
+// Deprecated: Use MapDiffRequest.ProtoReflect.Descriptor instead.
+func (*MapDiffRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{14}
 }
 
-func (x *LeafData) GetLeafInput() []byte {
+func (x *MapDiffRequest) GetMap() *MapRef {
 	if x != nil {
-		return x.LeafInput
+		return x.Map
 	}
 	return nil
 }
 
-func (x *LeafData) GetExtraData() []byte {
+func (x *MapDiffRequest) GetFromSize() int64 {
 	if x != nil {
-		return x.ExtraData
+		return x.FromSize
+	}
+	return 0
+}
+
+func (x *MapDiffRequest) GetToSize() int64 {
+	if x != nil {
+		return x.ToSize
+	}
+	return 0
+}
+
+func (x *MapDiffRequest) GetStartKeyHash() []byte {
+	if x != nil {
+		return x.StartKeyHash
 	}
 	return nil
 }
 
-func (x *LeafData) GetFormat() DataFormat {
+func (x *MapDiffRequest) GetLimit() int32 {
 	if x != nil {
-		return x.Format
+		return x.Limit
 	}
-	return DataFormat_UNSPECIFIED
+	return 0
 }
 
-type LogAddEntryRequest struct {
+type MapDiffEntry struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Log           *LogRef                `protobuf:"bytes,1,opt,name=log,proto3" json:"log,omitempty"`
-	Value         *LeafData              `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	KeyHash       []byte                 `protobuf:"bytes,1,opt,name=key_hash,json=keyHash,proto3" json:"key_hash,omitempty"`
+	Before        *LeafData              `protobuf:"bytes,2,opt,name=before,proto3" json:"before,omitempty"` // empty if not set at from_size
+	After         *LeafData              `protobuf:"bytes,3,opt,name=after,proto3" json:"after,omitempty"`   // empty if not set at to_size
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *LogAddEntryRequest) Reset() {
-	*x = LogAddEntryRequest{}
-	mi := &file_api_proto_msgTypes[12]
+func (x *MapDiffEntry) Reset() {
+	*x = MapDiffEntry{}
+	mi := &file_api_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *LogAddEntryRequest) String() string {
+func (x *MapDiffEntry) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LogAddEntryRequest) ProtoMessage() {}
+func (*MapDiffEntry) ProtoMessage() {}
 
-func (x *LogAddEntryRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[12]
+func (x *MapDiffEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -847,47 +1220,2792 @@ func (x *LogAddEntryRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LogAddEntryRequest.ProtoReflect.Descriptor instead.
-func (*LogAddEntryRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{12}
+// Deprecated: Use MapDiffEntry.ProtoReflect.Descriptor instead.
+func (*MapDiffEntry) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *MapDiffEntry) GetKeyHash() []byte {
+	if x != nil {
+		return x.KeyHash
+	}
+	return nil
+}
+
+func (x *MapDiffEntry) GetBefore() *LeafData {
+	if x != nil {
+		return x.Before
+	}
+	return nil
+}
+
+func (x *MapDiffEntry) GetAfter() *LeafData {
+	if x != nil {
+		return x.After
+	}
+	return nil
+}
+
+type MapDiffResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FromSize      int64                  `protobuf:"varint,1,opt,name=from_size,json=fromSize,proto3" json:"from_size,omitempty"`
+	ToSize        int64                  `protobuf:"varint,2,opt,name=to_size,json=toSize,proto3" json:"to_size,omitempty"`
+	Entries       []*MapDiffEntry        `protobuf:"bytes,3,rep,name=entries,proto3" json:"entries,omitempty"`                                                                                                  // every key hash from start_key_hash up to next_key_hash with a different leaf hash, in key hash order
+	NextKeyHash   []byte                 `protobuf:"bytes,4,opt,name=next_key_hash,json=nextKeyHash,proto3" json:"next_key_hash,omitempty"`                                                                     // start_key_hash for the next page, empty if there are no more changes
+	AuditPath     []*MapSubTreeHash      `protobuf:"bytes,5,rep,name=audit_path,json=auditPath,proto3" json:"audit_path,omitempty"`                                                                             // non-empty subtrees in the page with no changes, the same at both sizes
+	FromBoundary  []*MapSubTreeHash      `protobuf:"bytes,6,rep,name=from_boundary,json=fromBoundary,proto3" json:"from_boundary,omitempty"`                                                                    // non-empty subtrees outside the page at from_size
+	ToBoundary    []*MapSubTreeHash      `protobuf:"bytes,7,rep,name=to_boundary,json=toBoundary,proto3" json:"to_boundary,omitempty"`                                                                          // non-empty subtrees outside the page at to_size
+	HashAlgorithm HashAlgorithm          `protobuf:"varint,8,opt,name=hash_algorithm,json=hashAlgorithm,proto3,enum=com.continusec.verifiabledatastructures.api.HashAlgorithm" json:"hash_algorithm,omitempty"` // as recorded for the map
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MapDiffResponse) Reset() {
+	*x = MapDiffResponse{}
+	mi := &file_api_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapDiffResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapDiffResponse) ProtoMessage() {}
+
+func (x *MapDiffResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapDiffResponse.ProtoReflect.Descriptor instead.
+func (*MapDiffResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *MapDiffResponse) GetFromSize() int64 {
+	if x != nil {
+		return x.FromSize
+	}
+	return 0
+}
+
+func (x *MapDiffResponse) GetToSize() int64 {
+	if x != nil {
+		return x.ToSize
+	}
+	return 0
+}
+
+func (x *MapDiffResponse) GetEntries() []*MapDiffEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *MapDiffResponse) GetNextKeyHash() []byte {
+	if x != nil {
+		return x.NextKeyHash
+	}
+	return nil
+}
+
+func (x *MapDiffResponse) GetAuditPath() []*MapSubTreeHash {
+	if x != nil {
+		return x.AuditPath
+	}
+	return nil
+}
+
+func (x *MapDiffResponse) GetFromBoundary() []*MapSubTreeHash {
+	if x != nil {
+		return x.FromBoundary
+	}
+	return nil
+}
+
+func (x *MapDiffResponse) GetToBoundary() []*MapSubTreeHash {
+	if x != nil {
+		return x.ToBoundary
+	}
+	return nil
+}
+
+func (x *MapDiffResponse) GetHashAlgorithm() HashAlgorithm {
+	if x != nil {
+		return x.HashAlgorithm
+	}
+	return HashAlgorithm_HASH_SHA256
+}
+
+type MapGetValuesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Map           *MapRef                `protobuf:"bytes,1,opt,name=map,proto3" json:"map,omitempty"`
+	TreeSize      int64                  `protobuf:"varint,2,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
+	Keys          [][]byte               `protobuf:"bytes,3,rep,name=keys,proto3" json:"keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MapGetValuesRequest) Reset() {
+	*x = MapGetValuesRequest{}
+	mi := &file_api_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapGetValuesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapGetValuesRequest) ProtoMessage() {}
+
+func (x *MapGetValuesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapGetValuesRequest.ProtoReflect.Descriptor instead.
+func (*MapGetValuesRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *MapGetValuesRequest) GetMap() *MapRef {
+	if x != nil {
+		return x.Map
+	}
+	return nil
+}
+
+func (x *MapGetValuesRequest) GetTreeSize() int64 {
+	if x != nil {
+		return x.TreeSize
+	}
+	return 0
+}
+
+func (x *MapGetValuesRequest) GetKeys() [][]byte {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+type MapGetValuesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TreeSize      int64                  `protobuf:"varint,1,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
+	Values        []*LeafData            `protobuf:"bytes,2,rep,name=values,proto3" json:"values,omitempty"`                                                                                                    // one for each key, in the order requested
+	AuditPath     []*MapSubTreeHash      `protobuf:"bytes,3,rep,name=audit_path,json=auditPath,proto3" json:"audit_path,omitempty"`                                                                             // non-empty subtrees with none of the keys in them, needed to calculate the root hash
+	HashAlgorithm HashAlgorithm          `protobuf:"varint,4,opt,name=hash_algorithm,json=hashAlgorithm,proto3,enum=com.continusec.verifiabledatastructures.api.HashAlgorithm" json:"hash_algorithm,omitempty"` // as recorded for the map
+	KeyProofs     [][]byte               `protobuf:"bytes,5,rep,name=key_proofs,json=keyProofs,proto3" json:"key_proofs,omitempty"`                                                                             // for a map with VRF keys, the VRF proof for each key, in the order requested
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MapGetValuesResponse) Reset() {
+	*x = MapGetValuesResponse{}
+	mi := &file_api_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapGetValuesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapGetValuesResponse) ProtoMessage() {}
+
+func (x *MapGetValuesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapGetValuesResponse.ProtoReflect.Descriptor instead.
+func (*MapGetValuesResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *MapGetValuesResponse) GetTreeSize() int64 {
+	if x != nil {
+		return x.TreeSize
+	}
+	return 0
+}
+
+func (x *MapGetValuesResponse) GetValues() []*LeafData {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+func (x *MapGetValuesResponse) GetAuditPath() []*MapSubTreeHash {
+	if x != nil {
+		return x.AuditPath
+	}
+	return nil
+}
+
+func (x *MapGetValuesResponse) GetHashAlgorithm() HashAlgorithm {
+	if x != nil {
+		return x.HashAlgorithm
+	}
+	return HashAlgorithm_HASH_SHA256
+}
+
+func (x *MapGetValuesResponse) GetKeyProofs() [][]byte {
+	if x != nil {
+		return x.KeyProofs
+	}
+	return nil
+}
+
+type MapGetKeyHistoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Map           *MapRef                `protobuf:"bytes,1,opt,name=map,proto3" json:"map,omitempty"`
+	TreeSize      int64                  `protobuf:"varint,2,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"` // zero for the current head
+	Key           []byte                 `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MapGetKeyHistoryRequest) Reset() {
+	*x = MapGetKeyHistoryRequest{}
+	mi := &file_api_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapGetKeyHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapGetKeyHistoryRequest) ProtoMessage() {}
+
+func (x *MapGetKeyHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapGetKeyHistoryRequest.ProtoReflect.Descriptor instead.
+func (*MapGetKeyHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *MapGetKeyHistoryRequest) GetMap() *MapRef {
+	if x != nil {
+		return x.Map
+	}
+	return nil
+}
+
+func (x *MapGetKeyHistoryRequest) GetTreeSize() int64 {
+	if x != nil {
+		return x.TreeSize
+	}
+	return 0
+}
+
+func (x *MapGetKeyHistoryRequest) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+// A value that a key had, and the mutation that set it
+type MapKeyHistoryEntry struct {
+	state          protoimpl.MessageState     `protogen:"open.v1"`
+	MutationIndex  int64                      `protobuf:"varint,1,opt,name=mutation_index,json=mutationIndex,proto3" json:"mutation_index,omitempty"`
+	Value          *LeafData                  `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`                                         // the value for the key after the mutation, empty if deleted
+	Mutation       *LeafData                  `protobuf:"bytes,3,opt,name=mutation,proto3" json:"mutation,omitempty"`                                   // the mutation log entry at mutation_index
+	InclusionProof *LogInclusionProofResponse `protobuf:"bytes,4,opt,name=inclusion_proof,json=inclusionProof,proto3" json:"inclusion_proof,omitempty"` // for the mutation log entry in the mutation log at tree_size
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *MapKeyHistoryEntry) Reset() {
+	*x = MapKeyHistoryEntry{}
+	mi := &file_api_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapKeyHistoryEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapKeyHistoryEntry) ProtoMessage() {}
+
+func (x *MapKeyHistoryEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapKeyHistoryEntry.ProtoReflect.Descriptor instead.
+func (*MapKeyHistoryEntry) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *MapKeyHistoryEntry) GetMutationIndex() int64 {
+	if x != nil {
+		return x.MutationIndex
+	}
+	return 0
+}
+
+func (x *MapKeyHistoryEntry) GetValue() *LeafData {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *MapKeyHistoryEntry) GetMutation() *LeafData {
+	if x != nil {
+		return x.Mutation
+	}
+	return nil
+}
+
+func (x *MapKeyHistoryEntry) GetInclusionProof() *LogInclusionProofResponse {
+	if x != nil {
+		return x.InclusionProof
+	}
+	return nil
+}
+
+type MapGetKeyHistoryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TreeSize      int64                  `protobuf:"varint,1,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
+	Entries       []*MapKeyHistoryEntry  `protobuf:"bytes,2,rep,name=entries,proto3" json:"entries,omitempty"`                                                                                                  // each mutation that changed the value for the key, in order
+	HashAlgorithm HashAlgorithm          `protobuf:"varint,3,opt,name=hash_algorithm,json=hashAlgorithm,proto3,enum=com.continusec.verifiabledatastructures.api.HashAlgorithm" json:"hash_algorithm,omitempty"` // as recorded for the map
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MapGetKeyHistoryResponse) Reset() {
+	*x = MapGetKeyHistoryResponse{}
+	mi := &file_api_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapGetKeyHistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapGetKeyHistoryResponse) ProtoMessage() {}
+
+func (x *MapGetKeyHistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapGetKeyHistoryResponse.ProtoReflect.Descriptor instead.
+func (*MapGetKeyHistoryResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *MapGetKeyHistoryResponse) GetTreeSize() int64 {
+	if x != nil {
+		return x.TreeSize
+	}
+	return 0
+}
+
+func (x *MapGetKeyHistoryResponse) GetEntries() []*MapKeyHistoryEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+func (x *MapGetKeyHistoryResponse) GetHashAlgorithm() HashAlgorithm {
+	if x != nil {
+		return x.HashAlgorithm
+	}
+	return HashAlgorithm_HASH_SHA256
+}
+
+type MapTreeHashRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Map           *MapRef                `protobuf:"bytes,1,opt,name=map,proto3" json:"map,omitempty"`
+	TreeSize      int64                  `protobuf:"varint,2,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MapTreeHashRequest) Reset() {
+	*x = MapTreeHashRequest{}
+	mi := &file_api_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapTreeHashRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapTreeHashRequest) ProtoMessage() {}
+
+func (x *MapTreeHashRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapTreeHashRequest.ProtoReflect.Descriptor instead.
+func (*MapTreeHashRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *MapTreeHashRequest) GetMap() *MapRef {
+	if x != nil {
+		return x.Map
+	}
+	return nil
+}
+
+func (x *MapTreeHashRequest) GetTreeSize() int64 {
+	if x != nil {
+		return x.TreeSize
+	}
+	return 0
+}
+
+type MapTreeHashResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RootHash      []byte                 `protobuf:"bytes,1,opt,name=root_hash,json=rootHash,proto3" json:"root_hash,omitempty"`
+	MutationLog   *LogTreeHashResponse   `protobuf:"bytes,2,opt,name=mutation_log,json=mutationLog,proto3" json:"mutation_log,omitempty"`
+	Signature     *TreeHeadSignature     `protobuf:"bytes,3,opt,name=signature,proto3" json:"signature,omitempty"`                             // set by the server if configured with a signing key. Not stored.
+	Rejected      bool                   `protobuf:"varint,4,opt,name=rejected,proto3" json:"rejected,omitempty"`                              // set if the last mutation was rejected by a write-once map. Not stored.
+	VrfPublicKey  []byte                 `protobuf:"bytes,5,opt,name=vrf_public_key,json=vrfPublicKey,proto3" json:"vrf_public_key,omitempty"` // for a map with VRF keys, the Ed25519 public key that key proofs are verified with
+	SumField      string                 `protobuf:"bytes,6,opt,name=sum_field,json=sumField,proto3" json:"sum_field,omitempty"`               // for a sum-tree map, the field that is summed. The root hash is then followed by the count and sum.
+	PatriciaTrie  bool                   `protobuf:"varint,7,opt,name=patricia_trie,json=patriciaTrie,proto3" json:"patricia_trie,omitempty"`  // for a Merkle Patricia Trie map, in which case the root hash is that of the trie
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MapTreeHashResponse) Reset() {
+	*x = MapTreeHashResponse{}
+	mi := &file_api_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapTreeHashResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapTreeHashResponse) ProtoMessage() {}
+
+func (x *MapTreeHashResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapTreeHashResponse.ProtoReflect.Descriptor instead.
+func (*MapTreeHashResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *MapTreeHashResponse) GetRootHash() []byte {
+	if x != nil {
+		return x.RootHash
+	}
+	return nil
+}
+
+func (x *MapTreeHashResponse) GetMutationLog() *LogTreeHashResponse {
+	if x != nil {
+		return x.MutationLog
+	}
+	return nil
+}
+
+func (x *MapTreeHashResponse) GetSignature() *TreeHeadSignature {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *MapTreeHashResponse) GetRejected() bool {
+	if x != nil {
+		return x.Rejected
+	}
+	return false
+}
+
+func (x *MapTreeHashResponse) GetVrfPublicKey() []byte {
+	if x != nil {
+		return x.VrfPublicKey
+	}
+	return nil
+}
+
+func (x *MapTreeHashResponse) GetSumField() string {
+	if x != nil {
+		return x.SumField
+	}
+	return ""
+}
+
+func (x *MapTreeHashResponse) GetPatriciaTrie() bool {
+	if x != nil {
+		return x.PatriciaTrie
+	}
+	return false
+}
+
+type LogGossip struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Log           *LogRef                `protobuf:"bytes,1,opt,name=log,proto3" json:"log,omitempty"`
+	Head          *LogTreeHashResponse   `protobuf:"bytes,2,opt,name=head,proto3" json:"head,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogGossip) Reset() {
+	*x = LogGossip{}
+	mi := &file_api_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogGossip) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogGossip) ProtoMessage() {}
+
+func (x *LogGossip) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogGossip.ProtoReflect.Descriptor instead.
+func (*LogGossip) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *LogGossip) GetLog() *LogRef {
+	if x != nil {
+		return x.Log
+	}
+	return nil
+}
+
+func (x *LogGossip) GetHead() *LogTreeHashResponse {
+	if x != nil {
+		return x.Head
+	}
+	return nil
+}
+
+type MapGossip struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Map             *MapRef                `protobuf:"bytes,1,opt,name=map,proto3" json:"map,omitempty"`
+	MapHead         *MapTreeHashResponse   `protobuf:"bytes,2,opt,name=map_head,json=mapHead,proto3" json:"map_head,omitempty"`
+	TreeHeadLogHead *LogTreeHashResponse   `protobuf:"bytes,3,opt,name=tree_head_log_head,json=treeHeadLogHead,proto3" json:"tree_head_log_head,omitempty"` // as per verifiable.MapTreeState
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *MapGossip) Reset() {
+	*x = MapGossip{}
+	mi := &file_api_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapGossip) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapGossip) ProtoMessage() {}
+
+func (x *MapGossip) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapGossip.ProtoReflect.Descriptor instead.
+func (*MapGossip) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *MapGossip) GetMap() *MapRef {
+	if x != nil {
+		return x.Map
+	}
+	return nil
+}
+
+func (x *MapGossip) GetMapHead() *MapTreeHashResponse {
+	if x != nil {
+		return x.MapHead
+	}
+	return nil
+}
+
+func (x *MapGossip) GetTreeHeadLogHead() *LogTreeHashResponse {
+	if x != nil {
+		return x.TreeHeadLogHead
+	}
+	return nil
+}
+
+type GossipRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Logs          []*LogGossip           `protobuf:"bytes,1,rep,name=logs,proto3" json:"logs,omitempty"`
+	Maps          []*MapGossip           `protobuf:"bytes,2,rep,name=maps,proto3" json:"maps,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GossipRequest) Reset() {
+	*x = GossipRequest{}
+	mi := &file_api_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GossipRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GossipRequest) ProtoMessage() {}
+
+func (x *GossipRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GossipRequest.ProtoReflect.Descriptor instead.
+func (*GossipRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *GossipRequest) GetLogs() []*LogGossip {
+	if x != nil {
+		return x.Logs
+	}
+	return nil
+}
+
+func (x *GossipRequest) GetMaps() []*MapGossip {
+	if x != nil {
+		return x.Maps
+	}
+	return nil
+}
+
+type GossipResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	InconsistentLogs []*LogGossip           `protobuf:"bytes,1,rep,name=inconsistent_logs,json=inconsistentLogs,proto3" json:"inconsistent_logs,omitempty"` // those submitted that are not consistent with the server's own logs
+	InconsistentMaps []*MapGossip           `protobuf:"bytes,2,rep,name=inconsistent_maps,json=inconsistentMaps,proto3" json:"inconsistent_maps,omitempty"` // those submitted that are not consistent with the server's own maps
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *GossipResponse) Reset() {
+	*x = GossipResponse{}
+	mi := &file_api_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GossipResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GossipResponse) ProtoMessage() {}
+
+func (x *GossipResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GossipResponse.ProtoReflect.Descriptor instead.
+func (*GossipResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *GossipResponse) GetInconsistentLogs() []*LogGossip {
+	if x != nil {
+		return x.InconsistentLogs
+	}
+	return nil
+}
+
+func (x *GossipResponse) GetInconsistentMaps() []*MapGossip {
+	if x != nil {
+		return x.InconsistentMaps
+	}
+	return nil
+}
+
+type TreeHeadSignature struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	KeyId         []byte                 `protobuf:"bytes,1,opt,name=key_id,json=keyId,proto3" json:"key_id,omitempty"` // SHA256 of the DER encoded PKIX public key of the signer
+	Signature     []byte                 `protobuf:"bytes,2,opt,name=signature,proto3" json:"signature,omitempty"`      // Ed25519, or ASN.1 ECDSA P-256 over the SHA256 of the tree head text
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TreeHeadSignature) Reset() {
+	*x = TreeHeadSignature{}
+	mi := &file_api_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TreeHeadSignature) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TreeHeadSignature) ProtoMessage() {}
+
+func (x *TreeHeadSignature) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TreeHeadSignature.ProtoReflect.Descriptor instead.
+func (*TreeHeadSignature) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *TreeHeadSignature) GetKeyId() []byte {
+	if x != nil {
+		return x.KeyId
+	}
+	return nil
+}
+
+func (x *TreeHeadSignature) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+type LogInclusionProofRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Log      *LogRef                `protobuf:"bytes,1,opt,name=log,proto3" json:"log,omitempty"`
+	TreeSize int64                  `protobuf:"varint,2,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"` // may be 0 (HEAD)
+	// One of:
+	MtlHash       []byte `protobuf:"bytes,3,opt,name=mtl_hash,json=mtlHash,proto3" json:"mtl_hash,omitempty"`        // used, if not nil
+	LeafIndex     int64  `protobuf:"varint,4,opt,name=leaf_index,json=leafIndex,proto3" json:"leaf_index,omitempty"` // used if mtl_hash is nil
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogInclusionProofRequest) Reset() {
+	*x = LogInclusionProofRequest{}
+	mi := &file_api_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogInclusionProofRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogInclusionProofRequest) ProtoMessage() {}
+
+func (x *LogInclusionProofRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogInclusionProofRequest.ProtoReflect.Descriptor instead.
+func (*LogInclusionProofRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *LogInclusionProofRequest) GetLog() *LogRef {
+	if x != nil {
+		return x.Log
+	}
+	return nil
+}
+
+func (x *LogInclusionProofRequest) GetTreeSize() int64 {
+	if x != nil {
+		return x.TreeSize
+	}
+	return 0
+}
+
+func (x *LogInclusionProofRequest) GetMtlHash() []byte {
+	if x != nil {
+		return x.MtlHash
+	}
+	return nil
+}
+
+func (x *LogInclusionProofRequest) GetLeafIndex() int64 {
+	if x != nil {
+		return x.LeafIndex
+	}
+	return 0
+}
+
+type LogInclusionProofResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TreeSize      int64                  `protobuf:"varint,1,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
+	LeafIndex     int64                  `protobuf:"varint,2,opt,name=leaf_index,json=leafIndex,proto3" json:"leaf_index,omitempty"`
+	AuditPath     [][]byte               `protobuf:"bytes,3,rep,name=audit_path,json=auditPath,proto3" json:"audit_path,omitempty"`
+	HashAlgorithm HashAlgorithm          `protobuf:"varint,4,opt,name=hash_algorithm,json=hashAlgorithm,proto3,enum=com.continusec.verifiabledatastructures.api.HashAlgorithm" json:"hash_algorithm,omitempty"` // as recorded for the log
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogInclusionProofResponse) Reset() {
+	*x = LogInclusionProofResponse{}
+	mi := &file_api_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogInclusionProofResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogInclusionProofResponse) ProtoMessage() {}
+
+func (x *LogInclusionProofResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogInclusionProofResponse.ProtoReflect.Descriptor instead.
+func (*LogInclusionProofResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *LogInclusionProofResponse) GetTreeSize() int64 {
+	if x != nil {
+		return x.TreeSize
+	}
+	return 0
+}
+
+func (x *LogInclusionProofResponse) GetLeafIndex() int64 {
+	if x != nil {
+		return x.LeafIndex
+	}
+	return 0
+}
+
+func (x *LogInclusionProofResponse) GetAuditPath() [][]byte {
+	if x != nil {
+		return x.AuditPath
+	}
+	return nil
+}
+
+func (x *LogInclusionProofResponse) GetHashAlgorithm() HashAlgorithm {
+	if x != nil {
+		return x.HashAlgorithm
+	}
+	return HashAlgorithm_HASH_SHA256
+}
+
+type LogBatchInclusionProofRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Log      *LogRef                `protobuf:"bytes,1,opt,name=log,proto3" json:"log,omitempty"`
+	TreeSize int64                  `protobuf:"varint,2,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"` // may be 0 (HEAD)
+	// One of:
+	MtlHashes     [][]byte `protobuf:"bytes,3,rep,name=mtl_hashes,json=mtlHashes,proto3" json:"mtl_hashes,omitempty"`               // used, if not empty
+	LeafIndices   []int64  `protobuf:"varint,4,rep,packed,name=leaf_indices,json=leafIndices,proto3" json:"leaf_indices,omitempty"` // used if mtl_hashes is empty
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogBatchInclusionProofRequest) Reset() {
+	*x = LogBatchInclusionProofRequest{}
+	mi := &file_api_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogBatchInclusionProofRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogBatchInclusionProofRequest) ProtoMessage() {}
+
+func (x *LogBatchInclusionProofRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogBatchInclusionProofRequest.ProtoReflect.Descriptor instead.
+func (*LogBatchInclusionProofRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *LogBatchInclusionProofRequest) GetLog() *LogRef {
+	if x != nil {
+		return x.Log
+	}
+	return nil
+}
+
+func (x *LogBatchInclusionProofRequest) GetTreeSize() int64 {
+	if x != nil {
+		return x.TreeSize
+	}
+	return 0
+}
+
+func (x *LogBatchInclusionProofRequest) GetMtlHashes() [][]byte {
+	if x != nil {
+		return x.MtlHashes
+	}
+	return nil
+}
+
+func (x *LogBatchInclusionProofRequest) GetLeafIndices() []int64 {
+	if x != nil {
+		return x.LeafIndices
+	}
+	return nil
+}
+
+// Hash of the subtree covering leaves [start, end)
+type LogSubTreeHash struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Start         int64                  `protobuf:"varint,1,opt,name=start,proto3" json:"start,omitempty"`
+	End           int64                  `protobuf:"varint,2,opt,name=end,proto3" json:"end,omitempty"`
+	Hash          []byte                 `protobuf:"bytes,3,opt,name=hash,proto3" json:"hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogSubTreeHash) Reset() {
+	*x = LogSubTreeHash{}
+	mi := &file_api_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogSubTreeHash) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogSubTreeHash) ProtoMessage() {}
+
+func (x *LogSubTreeHash) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogSubTreeHash.ProtoReflect.Descriptor instead.
+func (*LogSubTreeHash) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *LogSubTreeHash) GetStart() int64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *LogSubTreeHash) GetEnd() int64 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+func (x *LogSubTreeHash) GetHash() []byte {
+	if x != nil {
+		return x.Hash
+	}
+	return nil
+}
+
+type LogBatchInclusionProofResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TreeSize      int64                  `protobuf:"varint,1,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
+	LeafIndices   []int64                `protobuf:"varint,2,rep,packed,name=leaf_indices,json=leafIndices,proto3" json:"leaf_indices,omitempty"`                                                               // one per requested leaf, in the order requested
+	AuditPath     []*LogSubTreeHash      `protobuf:"bytes,3,rep,name=audit_path,json=auditPath,proto3" json:"audit_path,omitempty"`                                                                             // deduplicated across all leaves, ordered by start
+	HashAlgorithm HashAlgorithm          `protobuf:"varint,4,opt,name=hash_algorithm,json=hashAlgorithm,proto3,enum=com.continusec.verifiabledatastructures.api.HashAlgorithm" json:"hash_algorithm,omitempty"` // as recorded for the log
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogBatchInclusionProofResponse) Reset() {
+	*x = LogBatchInclusionProofResponse{}
+	mi := &file_api_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogBatchInclusionProofResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogBatchInclusionProofResponse) ProtoMessage() {}
+
+func (x *LogBatchInclusionProofResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogBatchInclusionProofResponse.ProtoReflect.Descriptor instead.
+func (*LogBatchInclusionProofResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *LogBatchInclusionProofResponse) GetTreeSize() int64 {
+	if x != nil {
+		return x.TreeSize
+	}
+	return 0
+}
+
+func (x *LogBatchInclusionProofResponse) GetLeafIndices() []int64 {
+	if x != nil {
+		return x.LeafIndices
+	}
+	return nil
+}
+
+func (x *LogBatchInclusionProofResponse) GetAuditPath() []*LogSubTreeHash {
+	if x != nil {
+		return x.AuditPath
+	}
+	return nil
+}
+
+func (x *LogBatchInclusionProofResponse) GetHashAlgorithm() HashAlgorithm {
+	if x != nil {
+		return x.HashAlgorithm
+	}
+	return HashAlgorithm_HASH_SHA256
+}
+
+type LogConsistencyProofRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Log           *LogRef                `protobuf:"bytes,1,opt,name=log,proto3" json:"log,omitempty"`
+	FromSize      int64                  `protobuf:"varint,2,opt,name=from_size,json=fromSize,proto3" json:"from_size,omitempty"`
+	TreeSize      int64                  `protobuf:"varint,3,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"` // may be 0 (HEAD)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogConsistencyProofRequest) Reset() {
+	*x = LogConsistencyProofRequest{}
+	mi := &file_api_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogConsistencyProofRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogConsistencyProofRequest) ProtoMessage() {}
+
+func (x *LogConsistencyProofRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogConsistencyProofRequest.ProtoReflect.Descriptor instead.
+func (*LogConsistencyProofRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *LogConsistencyProofRequest) GetLog() *LogRef {
+	if x != nil {
+		return x.Log
+	}
+	return nil
+}
+
+func (x *LogConsistencyProofRequest) GetFromSize() int64 {
+	if x != nil {
+		return x.FromSize
+	}
+	return 0
+}
+
+func (x *LogConsistencyProofRequest) GetTreeSize() int64 {
+	if x != nil {
+		return x.TreeSize
+	}
+	return 0
+}
+
+type LogConsistencyProofResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FromSize      int64                  `protobuf:"varint,1,opt,name=from_size,json=fromSize,proto3" json:"from_size,omitempty"`
+	TreeSize      int64                  `protobuf:"varint,2,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
+	AuditPath     [][]byte               `protobuf:"bytes,3,rep,name=audit_path,json=auditPath,proto3" json:"audit_path,omitempty"`
+	HashAlgorithm HashAlgorithm          `protobuf:"varint,4,opt,name=hash_algorithm,json=hashAlgorithm,proto3,enum=com.continusec.verifiabledatastructures.api.HashAlgorithm" json:"hash_algorithm,omitempty"` // as recorded for the log
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogConsistencyProofResponse) Reset() {
+	*x = LogConsistencyProofResponse{}
+	mi := &file_api_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogConsistencyProofResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogConsistencyProofResponse) ProtoMessage() {}
+
+func (x *LogConsistencyProofResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogConsistencyProofResponse.ProtoReflect.Descriptor instead.
+func (*LogConsistencyProofResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *LogConsistencyProofResponse) GetFromSize() int64 {
+	if x != nil {
+		return x.FromSize
+	}
+	return 0
+}
+
+func (x *LogConsistencyProofResponse) GetTreeSize() int64 {
+	if x != nil {
+		return x.TreeSize
+	}
+	return 0
+}
+
+func (x *LogConsistencyProofResponse) GetAuditPath() [][]byte {
+	if x != nil {
+		return x.AuditPath
+	}
+	return nil
+}
+
+func (x *LogConsistencyProofResponse) GetHashAlgorithm() HashAlgorithm {
+	if x != nil {
+		return x.HashAlgorithm
+	}
+	return HashAlgorithm_HASH_SHA256
+}
+
+type LeafData struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LeafInput     []byte                 `protobuf:"bytes,1,opt,name=leaf_input,json=leafInput,proto3" json:"leaf_input,omitempty"`
+	ExtraData     []byte                 `protobuf:"bytes,2,opt,name=extra_data,json=extraData,proto3" json:"extra_data,omitempty"`                                       // ignored by the server, returned to the user, who may have business logic to confirm that the leaf_input has been derived from this, e.g. objecthash
+	Format        DataFormat             `protobuf:"varint,3,opt,name=format,proto3,enum=com.continusec.verifiabledatastructures.api.DataFormat" json:"format,omitempty"` // optional, if set, can define metadata about the format of the ExtraData. e.g. if set to JSON, fields will be filtered.
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LeafData) Reset() {
+	*x = LeafData{}
+	mi := &file_api_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LeafData) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LeafData) ProtoMessage() {}
+
+func (x *LeafData) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LeafData.ProtoReflect.Descriptor instead.
+func (*LeafData) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *LeafData) GetLeafInput() []byte {
+	if x != nil {
+		return x.LeafInput
+	}
+	return nil
+}
+
+func (x *LeafData) GetExtraData() []byte {
+	if x != nil {
+		return x.ExtraData
+	}
+	return nil
+}
+
+func (x *LeafData) GetFormat() DataFormat {
+	if x != nil {
+		return x.Format
+	}
+	return DataFormat_UNSPECIFIED
+}
+
+type LogAddEntryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Log           *LogRef                `protobuf:"bytes,1,opt,name=log,proto3" json:"log,omitempty"`
+	Value         *LeafData              `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogAddEntryRequest) Reset() {
+	*x = LogAddEntryRequest{}
+	mi := &file_api_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogAddEntryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogAddEntryRequest) ProtoMessage() {}
+
+func (x *LogAddEntryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogAddEntryRequest.ProtoReflect.Descriptor instead.
+func (*LogAddEntryRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *LogAddEntryRequest) GetLog() *LogRef {
+	if x != nil {
+		return x.Log
+	}
+	return nil
+}
+
+func (x *LogAddEntryRequest) GetValue() *LeafData {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+type LogAddEntryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LeafHash      []byte                 `protobuf:"bytes,1,opt,name=leaf_hash,json=leafHash,proto3" json:"leaf_hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogAddEntryResponse) Reset() {
+	*x = LogAddEntryResponse{}
+	mi := &file_api_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogAddEntryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogAddEntryResponse) ProtoMessage() {}
+
+func (x *LogAddEntryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogAddEntryResponse.ProtoReflect.Descriptor instead.
+func (*LogAddEntryResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *LogAddEntryResponse) GetLeafHash() []byte {
+	if x != nil {
+		return x.LeafHash
+	}
+	return nil
+}
+
+type LogAddEntriesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Log           *LogRef                `protobuf:"bytes,1,opt,name=log,proto3" json:"log,omitempty"`
+	Values        []*LeafData            `protobuf:"bytes,2,rep,name=values,proto3" json:"values,omitempty"` // sequenced in order
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogAddEntriesRequest) Reset() {
+	*x = LogAddEntriesRequest{}
+	mi := &file_api_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogAddEntriesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogAddEntriesRequest) ProtoMessage() {}
+
+func (x *LogAddEntriesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogAddEntriesRequest.ProtoReflect.Descriptor instead.
+func (*LogAddEntriesRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *LogAddEntriesRequest) GetLog() *LogRef {
+	if x != nil {
+		return x.Log
+	}
+	return nil
+}
+
+func (x *LogAddEntriesRequest) GetValues() []*LeafData {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type LogAddEntriesResponse struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	LeafHashes     [][]byte               `protobuf:"bytes,1,rep,name=leaf_hashes,json=leafHashes,proto3" json:"leaf_hashes,omitempty"`                     // in the same order as the request
+	AlreadyPresent []bool                 `protobuf:"varint,2,rep,packed,name=already_present,json=alreadyPresent,proto3" json:"already_present,omitempty"` // in the same order as the request, set for each entry that is already in the log, or earlier in the request, so will not be added again
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *LogAddEntriesResponse) Reset() {
+	*x = LogAddEntriesResponse{}
+	mi := &file_api_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogAddEntriesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogAddEntriesResponse) ProtoMessage() {}
+
+func (x *LogAddEntriesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogAddEntriesResponse.ProtoReflect.Descriptor instead.
+func (*LogAddEntriesResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *LogAddEntriesResponse) GetLeafHashes() [][]byte {
+	if x != nil {
+		return x.LeafHashes
+	}
+	return nil
+}
+
+func (x *LogAddEntriesResponse) GetAlreadyPresent() []bool {
+	if x != nil {
+		return x.AlreadyPresent
+	}
+	return nil
+}
+
+type MapSetValueRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Map           *MapRef                `protobuf:"bytes,1,opt,name=map,proto3" json:"map,omitempty"`
+	Mutation      *MapMutation           `protobuf:"bytes,2,opt,name=mutation,proto3" json:"mutation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MapSetValueRequest) Reset() {
+	*x = MapSetValueRequest{}
+	mi := &file_api_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapSetValueRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapSetValueRequest) ProtoMessage() {}
+
+func (x *MapSetValueRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapSetValueRequest.ProtoReflect.Descriptor instead.
+func (*MapSetValueRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *MapSetValueRequest) GetMap() *MapRef {
+	if x != nil {
+		return x.Map
+	}
+	return nil
+}
+
+func (x *MapSetValueRequest) GetMutation() *MapMutation {
+	if x != nil {
+		return x.Mutation
+	}
+	return nil
+}
+
+type MapSetValueResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LeafHash      []byte                 `protobuf:"bytes,1,opt,name=leaf_hash,json=leafHash,proto3" json:"leaf_hash,omitempty"` // of mutation log entry
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MapSetValueResponse) Reset() {
+	*x = MapSetValueResponse{}
+	mi := &file_api_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapSetValueResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapSetValueResponse) ProtoMessage() {}
+
+func (x *MapSetValueResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapSetValueResponse.ProtoReflect.Descriptor instead.
+func (*MapSetValueResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *MapSetValueResponse) GetLeafHash() []byte {
+	if x != nil {
+		return x.LeafHash
+	}
+	return nil
+}
+
+type MapApplyTransactionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Map           *MapRef                `protobuf:"bytes,1,opt,name=map,proto3" json:"map,omitempty"`
+	Mutations     []*MapMutation         `protobuf:"bytes,2,rep,name=mutations,proto3" json:"mutations,omitempty"` // each one of "set", "delete" or "update"
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MapApplyTransactionRequest) Reset() {
+	*x = MapApplyTransactionRequest{}
+	mi := &file_api_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapApplyTransactionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapApplyTransactionRequest) ProtoMessage() {}
+
+func (x *MapApplyTransactionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapApplyTransactionRequest.ProtoReflect.Descriptor instead.
+func (*MapApplyTransactionRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *MapApplyTransactionRequest) GetMap() *MapRef {
+	if x != nil {
+		return x.Map
+	}
+	return nil
+}
+
+func (x *MapApplyTransactionRequest) GetMutations() []*MapMutation {
+	if x != nil {
+		return x.Mutations
+	}
+	return nil
+}
+
+type MapApplyTransactionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LeafHash      []byte                 `protobuf:"bytes,1,opt,name=leaf_hash,json=leafHash,proto3" json:"leaf_hash,omitempty"` // of mutation log entry
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MapApplyTransactionResponse) Reset() {
+	*x = MapApplyTransactionResponse{}
+	mi := &file_api_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapApplyTransactionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapApplyTransactionResponse) ProtoMessage() {}
+
+func (x *MapApplyTransactionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapApplyTransactionResponse.ProtoReflect.Descriptor instead.
+func (*MapApplyTransactionResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *MapApplyTransactionResponse) GetLeafHash() []byte {
+	if x != nil {
+		return x.LeafHash
+	}
+	return nil
+}
+
+type MapGetValueRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Map           *MapRef                `protobuf:"bytes,1,opt,name=map,proto3" json:"map,omitempty"`
+	TreeSize      int64                  `protobuf:"varint,2,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
+	Key           []byte                 `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
+	CompactProof  bool                   `protobuf:"varint,4,opt,name=compact_proof,json=compactProof,proto3" json:"compact_proof,omitempty"` // if set, return audit_path_bitmap and compact_audit_path in place of audit_path
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MapGetValueRequest) Reset() {
+	*x = MapGetValueRequest{}
+	mi := &file_api_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapGetValueRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapGetValueRequest) ProtoMessage() {}
+
+func (x *MapGetValueRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapGetValueRequest.ProtoReflect.Descriptor instead.
+func (*MapGetValueRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *MapGetValueRequest) GetMap() *MapRef {
+	if x != nil {
+		return x.Map
+	}
+	return nil
+}
+
+func (x *MapGetValueRequest) GetTreeSize() int64 {
+	if x != nil {
+		return x.TreeSize
+	}
+	return 0
+}
+
+func (x *MapGetValueRequest) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *MapGetValueRequest) GetCompactProof() bool {
+	if x != nil {
+		return x.CompactProof
+	}
+	return false
+}
+
+type MapGetValueResponse struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	TreeSize         int64                  `protobuf:"varint,1,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
+	AuditPath        [][]byte               `protobuf:"bytes,2,rep,name=audit_path,json=auditPath,proto3" json:"audit_path,omitempty"` // up to 256 long. Consumers should substitute empties and missing for known defaults.
+	Value            *LeafData              `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	HashAlgorithm    HashAlgorithm          `protobuf:"varint,4,opt,name=hash_algorithm,json=hashAlgorithm,proto3,enum=com.continusec.verifiabledatastructures.api.HashAlgorithm" json:"hash_algorithm,omitempty"` // as recorded for the map
+	AuditPathBitmap  []byte                 `protobuf:"bytes,5,opt,name=audit_path_bitmap,json=auditPathBitmap,proto3" json:"audit_path_bitmap,omitempty"`                                                         // compact proofs only. 32 bytes, most significant bit first, with a bit set for each level of the audit path that is not the default.
+	CompactAuditPath [][]byte               `protobuf:"bytes,6,rep,name=compact_audit_path,json=compactAuditPath,proto3" json:"compact_audit_path,omitempty"`                                                      // compact proofs only. The hashes for each bit set in audit_path_bitmap, in level order.
+	KeyProof         []byte                 `protobuf:"bytes,7,opt,name=key_proof,json=keyProof,proto3" json:"key_proof,omitempty"`                                                                                // for a map with VRF keys, the VRF proof for the key, from which its path is derived
+	PatriciaProof    [][]byte               `protobuf:"bytes,8,rep,name=patricia_proof,json=patriciaProof,proto3" json:"patricia_proof,omitempty"`                                                                 // for a Merkle Patricia Trie map, the RLP encoded trie nodes on the path to the key, starting with the root, as for eth_getProof. The audit path is then not set.
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *MapGetValueResponse) Reset() {
+	*x = MapGetValueResponse{}
+	mi := &file_api_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapGetValueResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapGetValueResponse) ProtoMessage() {}
+
+func (x *MapGetValueResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapGetValueResponse.ProtoReflect.Descriptor instead.
+func (*MapGetValueResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *MapGetValueResponse) GetTreeSize() int64 {
+	if x != nil {
+		return x.TreeSize
+	}
+	return 0
+}
+
+func (x *MapGetValueResponse) GetAuditPath() [][]byte {
+	if x != nil {
+		return x.AuditPath
+	}
+	return nil
+}
+
+func (x *MapGetValueResponse) GetValue() *LeafData {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *MapGetValueResponse) GetHashAlgorithm() HashAlgorithm {
+	if x != nil {
+		return x.HashAlgorithm
+	}
+	return HashAlgorithm_HASH_SHA256
+}
+
+func (x *MapGetValueResponse) GetAuditPathBitmap() []byte {
+	if x != nil {
+		return x.AuditPathBitmap
+	}
+	return nil
+}
+
+func (x *MapGetValueResponse) GetCompactAuditPath() [][]byte {
+	if x != nil {
+		return x.CompactAuditPath
+	}
+	return nil
+}
+
+func (x *MapGetValueResponse) GetKeyProof() []byte {
+	if x != nil {
+		return x.KeyProof
+	}
+	return nil
+}
+
+func (x *MapGetValueResponse) GetPatriciaProof() [][]byte {
+	if x != nil {
+		return x.PatriciaProof
+	}
+	return nil
+}
+
+type LogFetchEntriesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Log           *LogRef                `protobuf:"bytes,1,opt,name=log,proto3" json:"log,omitempty"`
+	First         int64                  `protobuf:"varint,2,opt,name=first,proto3" json:"first,omitempty"` // inclusive
+	Last          int64                  `protobuf:"varint,3,opt,name=last,proto3" json:"last,omitempty"`   // exclusive, may be HEAD(0)
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogFetchEntriesRequest) Reset() {
+	*x = LogFetchEntriesRequest{}
+	mi := &file_api_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogFetchEntriesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogFetchEntriesRequest) ProtoMessage() {}
+
+func (x *LogFetchEntriesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogFetchEntriesRequest.ProtoReflect.Descriptor instead.
+func (*LogFetchEntriesRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *LogFetchEntriesRequest) GetLog() *LogRef {
+	if x != nil {
+		return x.Log
+	}
+	return nil
+}
+
+func (x *LogFetchEntriesRequest) GetFirst() int64 {
+	if x != nil {
+		return x.First
+	}
+	return 0
+}
+
+func (x *LogFetchEntriesRequest) GetLast() int64 {
+	if x != nil {
+		return x.Last
+	}
+	return 0
+}
+
+type LogFetchEntriesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Values        []*LeafData            `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogFetchEntriesResponse) Reset() {
+	*x = LogFetchEntriesResponse{}
+	mi := &file_api_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogFetchEntriesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogFetchEntriesResponse) ProtoMessage() {}
+
+func (x *LogFetchEntriesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogFetchEntriesResponse.ProtoReflect.Descriptor instead.
+func (*LogFetchEntriesResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *LogFetchEntriesResponse) GetValues() []*LeafData {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type StreamLogEntriesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Log           *LogRef                `protobuf:"bytes,1,opt,name=log,proto3" json:"log,omitempty"`
+	First         int64                  `protobuf:"varint,2,opt,name=first,proto3" json:"first,omitempty"`   // inclusive
+	Last          int64                  `protobuf:"varint,3,opt,name=last,proto3" json:"last,omitempty"`     // exclusive, zero means the current head, or forever if following
+	Follow        bool                   `protobuf:"varint,4,opt,name=follow,proto3" json:"follow,omitempty"` // if set, keep streaming new entries as they are sequenced until last is reached or the call is cancelled
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamLogEntriesRequest) Reset() {
+	*x = StreamLogEntriesRequest{}
+	mi := &file_api_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamLogEntriesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamLogEntriesRequest) ProtoMessage() {}
+
+func (x *StreamLogEntriesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamLogEntriesRequest.ProtoReflect.Descriptor instead.
+func (*StreamLogEntriesRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *StreamLogEntriesRequest) GetLog() *LogRef {
+	if x != nil {
+		return x.Log
+	}
+	return nil
+}
+
+func (x *StreamLogEntriesRequest) GetFirst() int64 {
+	if x != nil {
+		return x.First
+	}
+	return 0
+}
+
+func (x *StreamLogEntriesRequest) GetLast() int64 {
+	if x != nil {
+		return x.Last
+	}
+	return 0
+}
+
+func (x *StreamLogEntriesRequest) GetFollow() bool {
+	if x != nil {
+		return x.Follow
+	}
+	return false
+}
+
+type StreamLogEntriesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         int64                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Value         *LeafData              `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamLogEntriesResponse) Reset() {
+	*x = StreamLogEntriesResponse{}
+	mi := &file_api_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamLogEntriesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamLogEntriesResponse) ProtoMessage() {}
+
+func (x *StreamLogEntriesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamLogEntriesResponse.ProtoReflect.Descriptor instead.
+func (*StreamLogEntriesResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *StreamLogEntriesResponse) GetIndex() int64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *StreamLogEntriesResponse) GetValue() *LeafData {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+type WatchLogTreeHeadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Log           *LogRef                `protobuf:"bytes,1,opt,name=log,proto3" json:"log,omitempty"`
+	TreeSize      int64                  `protobuf:"varint,2,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"` // only tree heads larger than this are sent
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchLogTreeHeadRequest) Reset() {
+	*x = WatchLogTreeHeadRequest{}
+	mi := &file_api_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchLogTreeHeadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchLogTreeHeadRequest) ProtoMessage() {}
+
+func (x *WatchLogTreeHeadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[51]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchLogTreeHeadRequest.ProtoReflect.Descriptor instead.
+func (*WatchLogTreeHeadRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *WatchLogTreeHeadRequest) GetLog() *LogRef {
+	if x != nil {
+		return x.Log
+	}
+	return nil
+}
+
+func (x *WatchLogTreeHeadRequest) GetTreeSize() int64 {
+	if x != nil {
+		return x.TreeSize
+	}
+	return 0
+}
+
+type WatchMapTreeHeadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Map           *MapRef                `protobuf:"bytes,1,opt,name=map,proto3" json:"map,omitempty"`
+	TreeSize      int64                  `protobuf:"varint,2,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"` // only tree heads larger than this are sent
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchMapTreeHeadRequest) Reset() {
+	*x = WatchMapTreeHeadRequest{}
+	mi := &file_api_proto_msgTypes[52]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchMapTreeHeadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchMapTreeHeadRequest) ProtoMessage() {}
+
+func (x *WatchMapTreeHeadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[52]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchMapTreeHeadRequest.ProtoReflect.Descriptor instead.
+func (*WatchMapTreeHeadRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{52}
+}
+
+func (x *WatchMapTreeHeadRequest) GetMap() *MapRef {
+	if x != nil {
+		return x.Map
+	}
+	return nil
+}
+
+func (x *WatchMapTreeHeadRequest) GetTreeSize() int64 {
+	if x != nil {
+		return x.TreeSize
+	}
+	return 0
+}
+
+type LogFetchEntryBundleRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Log           *LogRef                `protobuf:"bytes,1,opt,name=log,proto3" json:"log,omitempty"`
+	LeafIndex     int64                  `protobuf:"varint,2,opt,name=leaf_index,json=leafIndex,proto3" json:"leaf_index,omitempty"`
+	TreeSize      int64                  `protobuf:"varint,3,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"` // zero for the current head
+	FromSize      int64                  `protobuf:"varint,4,opt,name=from_size,json=fromSize,proto3" json:"from_size,omitempty"` // if set, include a consistency proof between this size and the head
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogFetchEntryBundleRequest) Reset() {
+	*x = LogFetchEntryBundleRequest{}
+	mi := &file_api_proto_msgTypes[53]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogFetchEntryBundleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogFetchEntryBundleRequest) ProtoMessage() {}
+
+func (x *LogFetchEntryBundleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[53]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogFetchEntryBundleRequest.ProtoReflect.Descriptor instead.
+func (*LogFetchEntryBundleRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{53}
+}
+
+func (x *LogFetchEntryBundleRequest) GetLog() *LogRef {
+	if x != nil {
+		return x.Log
+	}
+	return nil
+}
+
+func (x *LogFetchEntryBundleRequest) GetLeafIndex() int64 {
+	if x != nil {
+		return x.LeafIndex
+	}
+	return 0
+}
+
+func (x *LogFetchEntryBundleRequest) GetTreeSize() int64 {
+	if x != nil {
+		return x.TreeSize
+	}
+	return 0
+}
+
+func (x *LogFetchEntryBundleRequest) GetFromSize() int64 {
+	if x != nil {
+		return x.FromSize
+	}
+	return 0
+}
+
+// Everything needed to verify an entry, read from a single consistent snapshot
+type LogFetchEntryBundleResponse struct {
+	state            protoimpl.MessageState       `protogen:"open.v1"`
+	Head             *LogTreeHashResponse         `protobuf:"bytes,1,opt,name=head,proto3" json:"head,omitempty"`
+	Value            *LeafData                    `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	InclusionProof   *LogInclusionProofResponse   `protobuf:"bytes,3,opt,name=inclusion_proof,json=inclusionProof,proto3" json:"inclusion_proof,omitempty"`       // of value in head
+	ConsistencyProof *LogConsistencyProofResponse `protobuf:"bytes,4,opt,name=consistency_proof,json=consistencyProof,proto3" json:"consistency_proof,omitempty"` // set only if from_size is set and differs from the head size
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *LogFetchEntryBundleResponse) Reset() {
+	*x = LogFetchEntryBundleResponse{}
+	mi := &file_api_proto_msgTypes[54]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogFetchEntryBundleResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogFetchEntryBundleResponse) ProtoMessage() {}
+
+func (x *LogFetchEntryBundleResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[54]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogFetchEntryBundleResponse.ProtoReflect.Descriptor instead.
+func (*LogFetchEntryBundleResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{54}
+}
+
+func (x *LogFetchEntryBundleResponse) GetHead() *LogTreeHashResponse {
+	if x != nil {
+		return x.Head
+	}
+	return nil
+}
+
+func (x *LogFetchEntryBundleResponse) GetValue() *LeafData {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *LogFetchEntryBundleResponse) GetInclusionProof() *LogInclusionProofResponse {
+	if x != nil {
+		return x.InclusionProof
+	}
+	return nil
+}
+
+func (x *LogFetchEntryBundleResponse) GetConsistencyProof() *LogConsistencyProofResponse {
+	if x != nil {
+		return x.ConsistencyProof
+	}
+	return nil
+}
+
+type MapGetValueBundleRequest struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	Map                 *MapRef                `protobuf:"bytes,1,opt,name=map,proto3" json:"map,omitempty"`
+	Key                 []byte                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	TreeSize            int64                  `protobuf:"varint,3,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`                                        // zero for the current head
+	FromSize            int64                  `protobuf:"varint,4,opt,name=from_size,json=fromSize,proto3" json:"from_size,omitempty"`                                        // if set, include a mutation log consistency proof between this size and the map head
+	TreeHeadLogFromSize int64                  `protobuf:"varint,5,opt,name=tree_head_log_from_size,json=treeHeadLogFromSize,proto3" json:"tree_head_log_from_size,omitempty"` // if set, include a tree head log consistency proof between this size and its head
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *MapGetValueBundleRequest) Reset() {
+	*x = MapGetValueBundleRequest{}
+	mi := &file_api_proto_msgTypes[55]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapGetValueBundleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapGetValueBundleRequest) ProtoMessage() {}
+
+func (x *MapGetValueBundleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[55]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapGetValueBundleRequest.ProtoReflect.Descriptor instead.
+func (*MapGetValueBundleRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{55}
+}
+
+func (x *MapGetValueBundleRequest) GetMap() *MapRef {
+	if x != nil {
+		return x.Map
+	}
+	return nil
+}
+
+func (x *MapGetValueBundleRequest) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *MapGetValueBundleRequest) GetTreeSize() int64 {
+	if x != nil {
+		return x.TreeSize
+	}
+	return 0
+}
+
+func (x *MapGetValueBundleRequest) GetFromSize() int64 {
+	if x != nil {
+		return x.FromSize
+	}
+	return 0
+}
+
+func (x *MapGetValueBundleRequest) GetTreeHeadLogFromSize() int64 {
+	if x != nil {
+		return x.TreeHeadLogFromSize
+	}
+	return 0
+}
+
+// Everything needed to verify a map value and the map state, read from a single consistent snapshot
+type MapGetValueBundleResponse struct {
+	state                       protoimpl.MessageState       `protogen:"open.v1"`
+	MapHead                     *MapTreeHashResponse         `protobuf:"bytes,1,opt,name=map_head,json=mapHead,proto3" json:"map_head,omitempty"`
+	Value                       *MapGetValueResponse         `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`                                                                                      // proof of value in map_head
+	TreeHeadLogHead             *LogTreeHashResponse         `protobuf:"bytes,3,opt,name=tree_head_log_head,json=treeHeadLogHead,proto3" json:"tree_head_log_head,omitempty"`                                       // current head of the tree head log
+	TreeHeadLogInclusionProof   *LogInclusionProofResponse   `protobuf:"bytes,4,opt,name=tree_head_log_inclusion_proof,json=treeHeadLogInclusionProof,proto3" json:"tree_head_log_inclusion_proof,omitempty"`       // of map_head in tree_head_log_head
+	MutationLogConsistencyProof *LogConsistencyProofResponse `protobuf:"bytes,5,opt,name=mutation_log_consistency_proof,json=mutationLogConsistencyProof,proto3" json:"mutation_log_consistency_proof,omitempty"`   // set only if from_size is set and differs from the map size
+	TreeHeadLogConsistencyProof *LogConsistencyProofResponse `protobuf:"bytes,6,opt,name=tree_head_log_consistency_proof,json=treeHeadLogConsistencyProof,proto3" json:"tree_head_log_consistency_proof,omitempty"` // set only if tree_head_log_from_size is set and differs from the head size
+	unknownFields               protoimpl.UnknownFields
+	sizeCache                   protoimpl.SizeCache
+}
+
+func (x *MapGetValueBundleResponse) Reset() {
+	*x = MapGetValueBundleResponse{}
+	mi := &file_api_proto_msgTypes[56]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapGetValueBundleResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapGetValueBundleResponse) ProtoMessage() {}
+
+func (x *MapGetValueBundleResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[56]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapGetValueBundleResponse.ProtoReflect.Descriptor instead.
+func (*MapGetValueBundleResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{56}
+}
+
+func (x *MapGetValueBundleResponse) GetMapHead() *MapTreeHashResponse {
+	if x != nil {
+		return x.MapHead
+	}
+	return nil
+}
+
+func (x *MapGetValueBundleResponse) GetValue() *MapGetValueResponse {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *MapGetValueBundleResponse) GetTreeHeadLogHead() *LogTreeHashResponse {
+	if x != nil {
+		return x.TreeHeadLogHead
+	}
+	return nil
+}
+
+func (x *MapGetValueBundleResponse) GetTreeHeadLogInclusionProof() *LogInclusionProofResponse {
+	if x != nil {
+		return x.TreeHeadLogInclusionProof
+	}
+	return nil
+}
+
+func (x *MapGetValueBundleResponse) GetMutationLogConsistencyProof() *LogConsistencyProofResponse {
+	if x != nil {
+		return x.MutationLogConsistencyProof
+	}
+	return nil
+}
+
+func (x *MapGetValueBundleResponse) GetTreeHeadLogConsistencyProof() *LogConsistencyProofResponse {
+	if x != nil {
+		return x.TreeHeadLogConsistencyProof
+	}
+	return nil
+}
+
+type MapMutation struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp        string                 `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // Set by the server. RFC3339 - may not be applied in order. Added as a rough nonce.
+	Action           string                 `protobuf:"bytes,2,opt,name=action,proto3" json:"action,omitempty"`       // One of "set", "delete", "update", "create", "compare_and_delete", "set_if_version", "patch", "increment", "transaction"
+	Key              []byte                 `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
+	Value            *LeafData              `protobuf:"bytes,4,opt,name=value,proto3" json:"value,omitempty"`
+	PreviousLeafHash []byte                 `protobuf:"bytes,5,opt,name=previous_leaf_hash,json=previousLeafHash,proto3" json:"previous_leaf_hash,omitempty"` // for "update" and "compare_and_delete" only
+	Operations       []*MapMutation         `protobuf:"bytes,6,rep,name=operations,proto3" json:"operations,omitempty"`                                       // for "transaction" only, applied in order, all or none
+	Version          int64                  `protobuf:"varint,7,opt,name=version,proto3" json:"version,omitempty"`                                            // for "set_if_version" only, the mutation index at which the key was last modified, or -1 if never
+	Patch            []byte                 `protobuf:"bytes,8,opt,name=patch,proto3" json:"patch,omitempty"`                                                 // for "patch" only, a JSON merge patch (RFC 7396) to apply to the JSON value
+	Field            string                 `protobuf:"bytes,9,opt,name=field,proto3" json:"field,omitempty"`                                                 // for "increment" only, the field in the JSON object value to add to, or empty if the value is a number
+	Delta            int64                  `protobuf:"varint,10,opt,name=delta,proto3" json:"delta,omitempty"`                                               // for "increment" only, the amount to add
+	KeyProof         []byte                 `protobuf:"bytes,11,opt,name=key_proof,json=keyProof,proto3" json:"key_proof,omitempty"`                          // for a map with VRF keys, set by the server to the VRF proof for key, from which its path is derived, and key is then cleared. Set on each operation of a transaction.
+	SourceIndex      int64                  `protobuf:"varint,12,opt,name=source_index,json=sourceIndex,proto3" json:"source_index,omitempty"`                // for a map derived from a log, the index of the log entry that the mutation was derived from
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *MapMutation) Reset() {
+	*x = MapMutation{}
+	mi := &file_api_proto_msgTypes[57]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapMutation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapMutation) ProtoMessage() {}
+
+func (x *MapMutation) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[57]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapMutation.ProtoReflect.Descriptor instead.
+func (*MapMutation) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{57}
+}
+
+func (x *MapMutation) GetTimestamp() string {
+	if x != nil {
+		return x.Timestamp
+	}
+	return ""
+}
+
+func (x *MapMutation) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *MapMutation) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+func (x *MapMutation) GetValue() *LeafData {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *MapMutation) GetPreviousLeafHash() []byte {
+	if x != nil {
+		return x.PreviousLeafHash
+	}
+	return nil
+}
+
+func (x *MapMutation) GetOperations() []*MapMutation {
+	if x != nil {
+		return x.Operations
+	}
+	return nil
+}
+
+func (x *MapMutation) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *MapMutation) GetPatch() []byte {
+	if x != nil {
+		return x.Patch
+	}
+	return nil
+}
+
+func (x *MapMutation) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *MapMutation) GetDelta() int64 {
+	if x != nil {
+		return x.Delta
+	}
+	return 0
+}
+
+func (x *MapMutation) GetKeyProof() []byte {
+	if x != nil {
+		return x.KeyProof
+	}
+	return nil
+}
+
+func (x *MapMutation) GetSourceIndex() int64 {
+	if x != nil {
+		return x.SourceIndex
+	}
+	return 0
+}
+
+type MultimapAddValueRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Multimap      *MultimapRef           `protobuf:"bytes,1,opt,name=multimap,proto3" json:"multimap,omitempty"`
+	Key           []byte                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Value         *LeafData              `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MultimapAddValueRequest) Reset() {
+	*x = MultimapAddValueRequest{}
+	mi := &file_api_proto_msgTypes[58]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MultimapAddValueRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MultimapAddValueRequest) ProtoMessage() {}
+
+func (x *MultimapAddValueRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[58]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MultimapAddValueRequest.ProtoReflect.Descriptor instead.
+func (*MultimapAddValueRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{58}
+}
+
+func (x *MultimapAddValueRequest) GetMultimap() *MultimapRef {
+	if x != nil {
+		return x.Multimap
+	}
+	return nil
 }
 
-func (x *LogAddEntryRequest) GetLog() *LogRef {
+func (x *MultimapAddValueRequest) GetKey() []byte {
 	if x != nil {
-		return x.Log
+		return x.Key
 	}
 	return nil
 }
 
-func (x *LogAddEntryRequest) GetValue() *LeafData {
+func (x *MultimapAddValueRequest) GetValue() *LeafData {
 	if x != nil {
 		return x.Value
 	}
 	return nil
 }
 
-type LogAddEntryResponse struct {
+type MultimapAddValueResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	LeafHash      []byte                 `protobuf:"bytes,1,opt,name=leaf_hash,json=leafHash,proto3" json:"leaf_hash,omitempty"`
+	LeafHash      []byte                 `protobuf:"bytes,1,opt,name=leaf_hash,json=leafHash,proto3" json:"leaf_hash,omitempty"` // of the value, in the log of values for the key
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *LogAddEntryResponse) Reset() {
-	*x = LogAddEntryResponse{}
-	mi := &file_api_proto_msgTypes[13]
+func (x *MultimapAddValueResponse) Reset() {
+	*x = MultimapAddValueResponse{}
+	mi := &file_api_proto_msgTypes[59]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *LogAddEntryResponse) String() string {
+func (x *MultimapAddValueResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LogAddEntryResponse) ProtoMessage() {}
+func (*MultimapAddValueResponse) ProtoMessage() {}
 
-func (x *LogAddEntryResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[13]
+func (x *MultimapAddValueResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[59]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -898,41 +4016,41 @@ func (x *LogAddEntryResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LogAddEntryResponse.ProtoReflect.Descriptor instead.
-func (*LogAddEntryResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{13}
+// Deprecated: Use MultimapAddValueResponse.ProtoReflect.Descriptor instead.
+func (*MultimapAddValueResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{59}
 }
 
-func (x *LogAddEntryResponse) GetLeafHash() []byte {
+func (x *MultimapAddValueResponse) GetLeafHash() []byte {
 	if x != nil {
 		return x.LeafHash
 	}
 	return nil
 }
 
-type MapSetValueRequest struct {
+type MultimapTreeHashRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Map           *MapRef                `protobuf:"bytes,1,opt,name=map,proto3" json:"map,omitempty"`
-	Mutation      *MapMutation           `protobuf:"bytes,2,opt,name=mutation,proto3" json:"mutation,omitempty"`
+	Multimap      *MultimapRef           `protobuf:"bytes,1,opt,name=multimap,proto3" json:"multimap,omitempty"`
+	TreeSize      int64                  `protobuf:"varint,2,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"` // zero for the current head
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *MapSetValueRequest) Reset() {
-	*x = MapSetValueRequest{}
-	mi := &file_api_proto_msgTypes[14]
+func (x *MultimapTreeHashRequest) Reset() {
+	*x = MultimapTreeHashRequest{}
+	mi := &file_api_proto_msgTypes[60]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *MapSetValueRequest) String() string {
+func (x *MultimapTreeHashRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*MapSetValueRequest) ProtoMessage() {}
+func (*MultimapTreeHashRequest) ProtoMessage() {}
 
-func (x *MapSetValueRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[14]
+func (x *MultimapTreeHashRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[60]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -943,47 +4061,49 @@ func (x *MapSetValueRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use MapSetValueRequest.ProtoReflect.Descriptor instead.
-func (*MapSetValueRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{14}
+// Deprecated: Use MultimapTreeHashRequest.ProtoReflect.Descriptor instead.
+func (*MultimapTreeHashRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{60}
 }
 
-func (x *MapSetValueRequest) GetMap() *MapRef {
+func (x *MultimapTreeHashRequest) GetMultimap() *MultimapRef {
 	if x != nil {
-		return x.Map
+		return x.Multimap
 	}
 	return nil
 }
 
-func (x *MapSetValueRequest) GetMutation() *MapMutation {
+func (x *MultimapTreeHashRequest) GetTreeSize() int64 {
 	if x != nil {
-		return x.Mutation
+		return x.TreeSize
 	}
-	return nil
+	return 0
 }
 
-type MapSetValueResponse struct {
+type MultimapGetValuesRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	LeafHash      []byte                 `protobuf:"bytes,1,opt,name=leaf_hash,json=leafHash,proto3" json:"leaf_hash,omitempty"` // of mutation log entry
+	Multimap      *MultimapRef           `protobuf:"bytes,1,opt,name=multimap,proto3" json:"multimap,omitempty"`
+	TreeSize      int64                  `protobuf:"varint,2,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"` // zero for the current head
+	Key           []byte                 `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *MapSetValueResponse) Reset() {
-	*x = MapSetValueResponse{}
-	mi := &file_api_proto_msgTypes[15]
+func (x *MultimapGetValuesRequest) Reset() {
+	*x = MultimapGetValuesRequest{}
+	mi := &file_api_proto_msgTypes[61]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *MapSetValueResponse) String() string {
+func (x *MultimapGetValuesRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*MapSetValueResponse) ProtoMessage() {}
+func (*MultimapGetValuesRequest) ProtoMessage() {}
 
-func (x *MapSetValueResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[15]
+func (x *MultimapGetValuesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[61]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -994,42 +4114,55 @@ func (x *MapSetValueResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use MapSetValueResponse.ProtoReflect.Descriptor instead.
-func (*MapSetValueResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{15}
+// Deprecated: Use MultimapGetValuesRequest.ProtoReflect.Descriptor instead.
+func (*MultimapGetValuesRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{61}
 }
 
-func (x *MapSetValueResponse) GetLeafHash() []byte {
+func (x *MultimapGetValuesRequest) GetMultimap() *MultimapRef {
 	if x != nil {
-		return x.LeafHash
+		return x.Multimap
 	}
 	return nil
 }
 
-type MapGetValueRequest struct {
+func (x *MultimapGetValuesRequest) GetTreeSize() int64 {
+	if x != nil {
+		return x.TreeSize
+	}
+	return 0
+}
+
+func (x *MultimapGetValuesRequest) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
+}
+
+type MultimapGetValuesResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Map           *MapRef                `protobuf:"bytes,1,opt,name=map,proto3" json:"map,omitempty"`
-	TreeSize      int64                  `protobuf:"varint,2,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
-	Key           []byte                 `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
+	KeyHead       *MapGetValueResponse   `protobuf:"bytes,1,opt,name=key_head,json=keyHead,proto3" json:"key_head,omitempty"` // the value for the key, which is the JSON LogTreeHashResponse for the log of values for the key, empty if none
+	Values        []*LeafData            `protobuf:"bytes,2,rep,name=values,proto3" json:"values,omitempty"`                  // each value in the log of values for the key at the size in key_head, in the order added
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *MapGetValueRequest) Reset() {
-	*x = MapGetValueRequest{}
-	mi := &file_api_proto_msgTypes[16]
+func (x *MultimapGetValuesResponse) Reset() {
+	*x = MultimapGetValuesResponse{}
+	mi := &file_api_proto_msgTypes[62]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *MapGetValueRequest) String() string {
+func (x *MultimapGetValuesResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*MapGetValueRequest) ProtoMessage() {}
+func (*MultimapGetValuesResponse) ProtoMessage() {}
 
-func (x *MapGetValueRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[16]
+func (x *MultimapGetValuesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[62]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1040,56 +4173,52 @@ func (x *MapGetValueRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use MapGetValueRequest.ProtoReflect.Descriptor instead.
-func (*MapGetValueRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{16}
+// Deprecated: Use MultimapGetValuesResponse.ProtoReflect.Descriptor instead.
+func (*MultimapGetValuesResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{62}
 }
 
-func (x *MapGetValueRequest) GetMap() *MapRef {
+func (x *MultimapGetValuesResponse) GetKeyHead() *MapGetValueResponse {
 	if x != nil {
-		return x.Map
+		return x.KeyHead
 	}
 	return nil
 }
 
-func (x *MapGetValueRequest) GetTreeSize() int64 {
-	if x != nil {
-		return x.TreeSize
-	}
-	return 0
-}
-
-func (x *MapGetValueRequest) GetKey() []byte {
+func (x *MultimapGetValuesResponse) GetValues() []*LeafData {
 	if x != nil {
-		return x.Key
+		return x.Values
 	}
 	return nil
 }
 
-type MapGetValueResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	TreeSize      int64                  `protobuf:"varint,1,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"`
-	AuditPath     [][]byte               `protobuf:"bytes,2,rep,name=audit_path,json=auditPath,proto3" json:"audit_path,omitempty"` // up to 256 long. Consumers should substitute empties and missing for known defaults.
-	Value         *LeafData              `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+type MultimapInclusionProofRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Multimap *MultimapRef           `protobuf:"bytes,1,opt,name=multimap,proto3" json:"multimap,omitempty"`
+	TreeSize int64                  `protobuf:"varint,2,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"` // zero for the current head
+	Key      []byte                 `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
+	// One of:
+	MtlHash       []byte `protobuf:"bytes,4,opt,name=mtl_hash,json=mtlHash,proto3" json:"mtl_hash,omitempty"`           // used, if not nil
+	ValueIndex    int64  `protobuf:"varint,5,opt,name=value_index,json=valueIndex,proto3" json:"value_index,omitempty"` // used if mtl_hash is nil
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *MapGetValueResponse) Reset() {
-	*x = MapGetValueResponse{}
-	mi := &file_api_proto_msgTypes[17]
+func (x *MultimapInclusionProofRequest) Reset() {
+	*x = MultimapInclusionProofRequest{}
+	mi := &file_api_proto_msgTypes[63]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *MapGetValueResponse) String() string {
+func (x *MultimapInclusionProofRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*MapGetValueResponse) ProtoMessage() {}
+func (*MultimapInclusionProofRequest) ProtoMessage() {}
 
-func (x *MapGetValueResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[17]
+func (x *MultimapInclusionProofRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[63]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1100,56 +4229,69 @@ func (x *MapGetValueResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use MapGetValueResponse.ProtoReflect.Descriptor instead.
-func (*MapGetValueResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{17}
+// Deprecated: Use MultimapInclusionProofRequest.ProtoReflect.Descriptor instead.
+func (*MultimapInclusionProofRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{63}
 }
 
-func (x *MapGetValueResponse) GetTreeSize() int64 {
+func (x *MultimapInclusionProofRequest) GetMultimap() *MultimapRef {
+	if x != nil {
+		return x.Multimap
+	}
+	return nil
+}
+
+func (x *MultimapInclusionProofRequest) GetTreeSize() int64 {
 	if x != nil {
 		return x.TreeSize
 	}
 	return 0
 }
 
-func (x *MapGetValueResponse) GetAuditPath() [][]byte {
+func (x *MultimapInclusionProofRequest) GetKey() []byte {
 	if x != nil {
-		return x.AuditPath
+		return x.Key
 	}
 	return nil
 }
 
-func (x *MapGetValueResponse) GetValue() *LeafData {
+func (x *MultimapInclusionProofRequest) GetMtlHash() []byte {
 	if x != nil {
-		return x.Value
+		return x.MtlHash
 	}
 	return nil
 }
 
-type LogFetchEntriesRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Log           *LogRef                `protobuf:"bytes,1,opt,name=log,proto3" json:"log,omitempty"`
-	First         int64                  `protobuf:"varint,2,opt,name=first,proto3" json:"first,omitempty"` // inclusive
-	Last          int64                  `protobuf:"varint,3,opt,name=last,proto3" json:"last,omitempty"`   // exclusive, may be HEAD(0)
+func (x *MultimapInclusionProofRequest) GetValueIndex() int64 {
+	if x != nil {
+		return x.ValueIndex
+	}
+	return 0
+}
+
+type MultimapInclusionProofResponse struct {
+	state         protoimpl.MessageState     `protogen:"open.v1"`
+	KeyHead       *MapGetValueResponse       `protobuf:"bytes,1,opt,name=key_head,json=keyHead,proto3" json:"key_head,omitempty"` // as per MultimapGetValuesResponse
+	Proof         *LogInclusionProofResponse `protobuf:"bytes,2,opt,name=proof,proto3" json:"proof,omitempty"`                    // of the value in the log of values for the key, at the size in key_head
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *LogFetchEntriesRequest) Reset() {
-	*x = LogFetchEntriesRequest{}
-	mi := &file_api_proto_msgTypes[18]
+func (x *MultimapInclusionProofResponse) Reset() {
+	*x = MultimapInclusionProofResponse{}
+	mi := &file_api_proto_msgTypes[64]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *LogFetchEntriesRequest) String() string {
+func (x *MultimapInclusionProofResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LogFetchEntriesRequest) ProtoMessage() {}
+func (*MultimapInclusionProofResponse) ProtoMessage() {}
 
-func (x *LogFetchEntriesRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[18]
+func (x *MultimapInclusionProofResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[64]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1160,54 +4302,50 @@ func (x *LogFetchEntriesRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LogFetchEntriesRequest.ProtoReflect.Descriptor instead.
-func (*LogFetchEntriesRequest) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{18}
+// Deprecated: Use MultimapInclusionProofResponse.ProtoReflect.Descriptor instead.
+func (*MultimapInclusionProofResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{64}
 }
 
-func (x *LogFetchEntriesRequest) GetLog() *LogRef {
+func (x *MultimapInclusionProofResponse) GetKeyHead() *MapGetValueResponse {
 	if x != nil {
-		return x.Log
+		return x.KeyHead
 	}
 	return nil
 }
 
-func (x *LogFetchEntriesRequest) GetFirst() int64 {
-	if x != nil {
-		return x.First
-	}
-	return 0
-}
-
-func (x *LogFetchEntriesRequest) GetLast() int64 {
+func (x *MultimapInclusionProofResponse) GetProof() *LogInclusionProofResponse {
 	if x != nil {
-		return x.Last
+		return x.Proof
 	}
-	return 0
+	return nil
 }
 
-type LogFetchEntriesResponse struct {
+type MultimapKeyConsistencyProofRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Values        []*LeafData            `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
+	Multimap      *MultimapRef           `protobuf:"bytes,1,opt,name=multimap,proto3" json:"multimap,omitempty"`
+	Key           []byte                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	FromSize      int64                  `protobuf:"varint,3,opt,name=from_size,json=fromSize,proto3" json:"from_size,omitempty"` // multimap size
+	TreeSize      int64                  `protobuf:"varint,4,opt,name=tree_size,json=treeSize,proto3" json:"tree_size,omitempty"` // multimap size, zero for the current head
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *LogFetchEntriesResponse) Reset() {
-	*x = LogFetchEntriesResponse{}
-	mi := &file_api_proto_msgTypes[19]
+func (x *MultimapKeyConsistencyProofRequest) Reset() {
+	*x = MultimapKeyConsistencyProofRequest{}
+	mi := &file_api_proto_msgTypes[65]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *LogFetchEntriesResponse) String() string {
+func (x *MultimapKeyConsistencyProofRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*LogFetchEntriesResponse) ProtoMessage() {}
+func (*MultimapKeyConsistencyProofRequest) ProtoMessage() {}
 
-func (x *LogFetchEntriesResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[19]
+func (x *MultimapKeyConsistencyProofRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[65]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1218,44 +4356,63 @@ func (x *LogFetchEntriesResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use LogFetchEntriesResponse.ProtoReflect.Descriptor instead.
-func (*LogFetchEntriesResponse) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{19}
+// Deprecated: Use MultimapKeyConsistencyProofRequest.ProtoReflect.Descriptor instead.
+func (*MultimapKeyConsistencyProofRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{65}
 }
 
-func (x *LogFetchEntriesResponse) GetValues() []*LeafData {
+func (x *MultimapKeyConsistencyProofRequest) GetMultimap() *MultimapRef {
 	if x != nil {
-		return x.Values
+		return x.Multimap
 	}
 	return nil
 }
 
-type MapMutation struct {
-	state            protoimpl.MessageState `protogen:"open.v1"`
-	Timestamp        string                 `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // Set by the server. RFC3339 - may not be applied in order. Added as a rough nonce.
-	Action           string                 `protobuf:"bytes,2,opt,name=action,proto3" json:"action,omitempty"`       // One of "set", "delete", "update"
-	Key              []byte                 `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
-	Value            *LeafData              `protobuf:"bytes,4,opt,name=value,proto3" json:"value,omitempty"`
-	PreviousLeafHash []byte                 `protobuf:"bytes,5,opt,name=previous_leaf_hash,json=previousLeafHash,proto3" json:"previous_leaf_hash,omitempty"`
-	unknownFields    protoimpl.UnknownFields
-	sizeCache        protoimpl.SizeCache
+func (x *MultimapKeyConsistencyProofRequest) GetKey() []byte {
+	if x != nil {
+		return x.Key
+	}
+	return nil
 }
 
-func (x *MapMutation) Reset() {
-	*x = MapMutation{}
-	mi := &file_api_proto_msgTypes[20]
+func (x *MultimapKeyConsistencyProofRequest) GetFromSize() int64 {
+	if x != nil {
+		return x.FromSize
+	}
+	return 0
+}
+
+func (x *MultimapKeyConsistencyProofRequest) GetTreeSize() int64 {
+	if x != nil {
+		return x.TreeSize
+	}
+	return 0
+}
+
+type MultimapKeyConsistencyProofResponse struct {
+	state         protoimpl.MessageState       `protogen:"open.v1"`
+	FromKeyHead   *MapGetValueResponse         `protobuf:"bytes,1,opt,name=from_key_head,json=fromKeyHead,proto3" json:"from_key_head,omitempty"` // as per MultimapGetValuesResponse, at from_size
+	KeyHead       *MapGetValueResponse         `protobuf:"bytes,2,opt,name=key_head,json=keyHead,proto3" json:"key_head,omitempty"`               // as per MultimapGetValuesResponse, at tree_size
+	Proof         *LogConsistencyProofResponse `protobuf:"bytes,3,opt,name=proof,proto3" json:"proof,omitempty"`                                  // between the logs of values for the key in each, set only if both are non-empty and they differ in size
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MultimapKeyConsistencyProofResponse) Reset() {
+	*x = MultimapKeyConsistencyProofResponse{}
+	mi := &file_api_proto_msgTypes[66]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *MapMutation) String() string {
+func (x *MultimapKeyConsistencyProofResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*MapMutation) ProtoMessage() {}
+func (*MultimapKeyConsistencyProofResponse) ProtoMessage() {}
 
-func (x *MapMutation) ProtoReflect() protoreflect.Message {
-	mi := &file_api_proto_msgTypes[20]
+func (x *MultimapKeyConsistencyProofResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_msgTypes[66]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1266,42 +4423,28 @@ func (x *MapMutation) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use MapMutation.ProtoReflect.Descriptor instead.
-func (*MapMutation) Descriptor() ([]byte, []int) {
-	return file_api_proto_rawDescGZIP(), []int{20}
-}
-
-func (x *MapMutation) GetTimestamp() string {
-	if x != nil {
-		return x.Timestamp
-	}
-	return ""
-}
-
-func (x *MapMutation) GetAction() string {
-	if x != nil {
-		return x.Action
-	}
-	return ""
+// Deprecated: Use MultimapKeyConsistencyProofResponse.ProtoReflect.Descriptor instead.
+func (*MultimapKeyConsistencyProofResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_rawDescGZIP(), []int{66}
 }
 
-func (x *MapMutation) GetKey() []byte {
+func (x *MultimapKeyConsistencyProofResponse) GetFromKeyHead() *MapGetValueResponse {
 	if x != nil {
-		return x.Key
+		return x.FromKeyHead
 	}
 	return nil
 }
 
-func (x *MapMutation) GetValue() *LeafData {
+func (x *MultimapKeyConsistencyProofResponse) GetKeyHead() *MapGetValueResponse {
 	if x != nil {
-		return x.Value
+		return x.KeyHead
 	}
 	return nil
 }
 
-func (x *MapMutation) GetPreviousLeafHash() []byte {
+func (x *MultimapKeyConsistencyProofResponse) GetProof() *LogConsistencyProofResponse {
 	if x != nil {
-		return x.PreviousLeafHash
+		return x.Proof
 	}
 	return nil
 }
@@ -1314,47 +4457,188 @@ const file_api_proto_rawDesc = "" +
 	"\n" +
 	"AccountRef\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x17\n" +
-	"\aapi_key\x18\x02 \x01(\tR\x06apiKey\"\xc0\x01\n" +
+	"\aapi_key\x18\x02 \x01(\tR\x06apiKey\"\xfd\x03\n" +
 	"\x06LogRef\x12Q\n" +
 	"\aaccount\x18\x01 \x01(\v27.com.continusec.verifiabledatastructures.api.AccountRefR\aaccount\x12O\n" +
 	"\blog_type\x18\x02 \x01(\x0e24.com.continusec.verifiabledatastructures.api.LogTypeR\alogType\x12\x12\n" +
-	"\x04name\x18\x03 \x01(\tR\x04name\"o\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12a\n" +
+	"\x0ehash_algorithm\x18\x04 \x01(\x0e2:.com.continusec.verifiabledatastructures.api.HashAlgorithmR\rhashAlgorithm\x12\x1d\n" +
+	"\n" +
+	"write_once\x18\x05 \x01(\bR\twriteOnce\x12$\n" +
+	"\x0evrf_public_key\x18\x06 \x01(\fR\fvrfPublicKey\x12\x1b\n" +
+	"\tsum_field\x18\a \x01(\tR\bsumField\x12Q\n" +
+	"\areducer\x18\b \x01(\v27.com.continusec.verifiabledatastructures.api.MapReducerR\areducer\x12#\n" +
+	"\rpatricia_trie\x18\t \x01(\bR\fpatriciaTrie\"\x99\x01\n" +
+	"\n" +
+	"MapReducer\x12\x19\n" +
+	"\bkey_path\x18\x01 \x01(\tR\akeyPath\x12\x1d\n" +
+	"\n" +
+	"value_path\x18\x02 \x01(\tR\tvaluePath\x12Q\n" +
+	"\x05rules\x18\x03 \x03(\v2;.com.continusec.verifiabledatastructures.api.MapReducerRuleR\x05rules\"T\n" +
+	"\x0eMapReducerRule\x12\x12\n" +
+	"\x04path\x18\x01 \x01(\tR\x04path\x12\x16\n" +
+	"\x06equals\x18\x02 \x01(\tR\x06equals\x12\x16\n" +
+	"\x06action\x18\x03 \x01(\tR\x06action\"\xce\x02\n" +
 	"\x06MapRef\x12Q\n" +
 	"\aaccount\x18\x01 \x01(\v27.com.continusec.verifiabledatastructures.api.AccountRefR\aaccount\x12\x12\n" +
-	"\x04name\x18\x03 \x01(\tR\x04name\"x\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12a\n" +
+	"\x0ehash_algorithm\x18\x04 \x01(\x0e2:.com.continusec.verifiabledatastructures.api.HashAlgorithmR\rhashAlgorithm\x12\x1d\n" +
+	"\n" +
+	"write_once\x18\x05 \x01(\bR\twriteOnce\x12\x19\n" +
+	"\bvrf_keys\x18\x06 \x01(\bR\avrfKeys\x12\x1b\n" +
+	"\tsum_field\x18\a \x01(\tR\bsumField\x12#\n" +
+	"\rpatricia_trie\x18\b \x01(\bR\fpatriciaTrie\"\xd7\x01\n" +
+	"\vMultimapRef\x12Q\n" +
+	"\aaccount\x18\x01 \x01(\v27.com.continusec.verifiabledatastructures.api.AccountRefR\aaccount\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12a\n" +
+	"\x0ehash_algorithm\x18\x03 \x01(\x0e2:.com.continusec.verifiabledatastructures.api.HashAlgorithmR\rhashAlgorithm\"\x94\x01\n" +
 	"\x12LogTreeHashRequest\x12E\n" +
 	"\x03log\x18\x01 \x01(\v23.com.continusec.verifiabledatastructures.api.LogRefR\x03log\x12\x1b\n" +
-	"\ttree_size\x18\x02 \x01(\x03R\btreeSize\"O\n" +
+	"\ttree_size\x18\x02 \x01(\x03R\btreeSize\x12\x1a\n" +
+	"\bcosigned\x18\x03 \x01(\bR\bcosigned\"\x91\x02\n" +
 	"\x13LogTreeHashResponse\x12\x1b\n" +
 	"\ttree_size\x18\x01 \x01(\x03R\btreeSize\x12\x1b\n" +
-	"\troot_hash\x18\x02 \x01(\fR\brootHash\"x\n" +
+	"\troot_hash\x18\x02 \x01(\fR\brootHash\x12\\\n" +
+	"\tsignature\x18\x03 \x01(\v2>.com.continusec.verifiabledatastructures.api.TreeHeadSignatureR\tsignature\x12b\n" +
+	"\fcosignatures\x18\x04 \x03(\v2>.com.continusec.verifiabledatastructures.api.TreeHeadSignatureR\fcosignatures\"z\n" +
+	"\x14LogCheckpointRequest\x12E\n" +
+	"\x03log\x18\x01 \x01(\v23.com.continusec.verifiabledatastructures.api.LogRefR\x03log\x12\x1b\n" +
+	"\ttree_size\x18\x02 \x01(\x03R\btreeSize\"7\n" +
+	"\x15LogCheckpointResponse\x12\x1e\n" +
+	"\n" +
+	"checkpoint\x18\x01 \x01(\fR\n" +
+	"checkpoint\"\xb6\x01\n" +
+	"\x14MapListLeavesRequest\x12E\n" +
+	"\x03map\x18\x01 \x01(\v23.com.continusec.verifiabledatastructures.api.MapRefR\x03map\x12\x1b\n" +
+	"\ttree_size\x18\x02 \x01(\x03R\btreeSize\x12$\n" +
+	"\x0estart_key_hash\x18\x03 \x01(\fR\fstartKeyHash\x12\x14\n" +
+	"\x05limit\x18\x04 \x01(\x05R\x05limit\"\x8e\x01\n" +
+	"\aMapLeaf\x12\x19\n" +
+	"\bkey_hash\x18\x01 \x01(\fR\akeyHash\x12\x1b\n" +
+	"\tleaf_hash\x18\x02 \x01(\fR\bleafHash\x12K\n" +
+	"\x05value\x18\x03 \x01(\v25.com.continusec.verifiabledatastructures.api.LeafDataR\x05value\"U\n" +
+	"\x0eMapSubTreeHash\x12\x14\n" +
+	"\x05depth\x18\x01 \x01(\x05R\x05depth\x12\x19\n" +
+	"\bkey_hash\x18\x02 \x01(\fR\akeyHash\x12\x12\n" +
+	"\x04hash\x18\x03 \x01(\fR\x04hash\"\xe2\x02\n" +
+	"\x15MapListLeavesResponse\x12\x1b\n" +
+	"\ttree_size\x18\x01 \x01(\x03R\btreeSize\x12L\n" +
+	"\x06leaves\x18\x02 \x03(\v24.com.continusec.verifiabledatastructures.api.MapLeafR\x06leaves\x12\"\n" +
+	"\rnext_key_hash\x18\x03 \x01(\fR\vnextKeyHash\x12W\n" +
+	"\bboundary\x18\x04 \x03(\v2;.com.continusec.verifiabledatastructures.api.MapSubTreeHashR\bboundary\x12a\n" +
+	"\x0ehash_algorithm\x18\x05 \x01(\x0e2:.com.continusec.verifiabledatastructures.api.HashAlgorithmR\rhashAlgorithm\"\xc9\x01\n" +
+	"\x0eMapDiffRequest\x12E\n" +
+	"\x03map\x18\x01 \x01(\v23.com.continusec.verifiabledatastructures.api.MapRefR\x03map\x12\x1b\n" +
+	"\tfrom_size\x18\x02 \x01(\x03R\bfromSize\x12\x17\n" +
+	"\ato_size\x18\x03 \x01(\x03R\x06toSize\x12$\n" +
+	"\x0estart_key_hash\x18\x04 \x01(\fR\fstartKeyHash\x12\x14\n" +
+	"\x05limit\x18\x05 \x01(\x05R\x05limit\"\xc5\x01\n" +
+	"\fMapDiffEntry\x12\x19\n" +
+	"\bkey_hash\x18\x01 \x01(\fR\akeyHash\x12M\n" +
+	"\x06before\x18\x02 \x01(\v25.com.continusec.verifiabledatastructures.api.LeafDataR\x06before\x12K\n" +
+	"\x05after\x18\x03 \x01(\v25.com.continusec.verifiabledatastructures.api.LeafDataR\x05after\"\xbf\x04\n" +
+	"\x0fMapDiffResponse\x12\x1b\n" +
+	"\tfrom_size\x18\x01 \x01(\x03R\bfromSize\x12\x17\n" +
+	"\ato_size\x18\x02 \x01(\x03R\x06toSize\x12S\n" +
+	"\aentries\x18\x03 \x03(\v29.com.continusec.verifiabledatastructures.api.MapDiffEntryR\aentries\x12\"\n" +
+	"\rnext_key_hash\x18\x04 \x01(\fR\vnextKeyHash\x12Z\n" +
+	"\n" +
+	"audit_path\x18\x05 \x03(\v2;.com.continusec.verifiabledatastructures.api.MapSubTreeHashR\tauditPath\x12`\n" +
+	"\rfrom_boundary\x18\x06 \x03(\v2;.com.continusec.verifiabledatastructures.api.MapSubTreeHashR\ffromBoundary\x12\\\n" +
+	"\vto_boundary\x18\a \x03(\v2;.com.continusec.verifiabledatastructures.api.MapSubTreeHashR\n" +
+	"toBoundary\x12a\n" +
+	"\x0ehash_algorithm\x18\b \x01(\x0e2:.com.continusec.verifiabledatastructures.api.HashAlgorithmR\rhashAlgorithm\"\x8d\x01\n" +
+	"\x13MapGetValuesRequest\x12E\n" +
+	"\x03map\x18\x01 \x01(\v23.com.continusec.verifiabledatastructures.api.MapRefR\x03map\x12\x1b\n" +
+	"\ttree_size\x18\x02 \x01(\x03R\btreeSize\x12\x12\n" +
+	"\x04keys\x18\x03 \x03(\fR\x04keys\"\xe0\x02\n" +
+	"\x14MapGetValuesResponse\x12\x1b\n" +
+	"\ttree_size\x18\x01 \x01(\x03R\btreeSize\x12M\n" +
+	"\x06values\x18\x02 \x03(\v25.com.continusec.verifiabledatastructures.api.LeafDataR\x06values\x12Z\n" +
+	"\n" +
+	"audit_path\x18\x03 \x03(\v2;.com.continusec.verifiabledatastructures.api.MapSubTreeHashR\tauditPath\x12a\n" +
+	"\x0ehash_algorithm\x18\x04 \x01(\x0e2:.com.continusec.verifiabledatastructures.api.HashAlgorithmR\rhashAlgorithm\x12\x1d\n" +
+	"\n" +
+	"key_proofs\x18\x05 \x03(\fR\tkeyProofs\"\x8f\x01\n" +
+	"\x17MapGetKeyHistoryRequest\x12E\n" +
+	"\x03map\x18\x01 \x01(\v23.com.continusec.verifiabledatastructures.api.MapRefR\x03map\x12\x1b\n" +
+	"\ttree_size\x18\x02 \x01(\x03R\btreeSize\x12\x10\n" +
+	"\x03key\x18\x03 \x01(\fR\x03key\"\xcc\x02\n" +
+	"\x12MapKeyHistoryEntry\x12%\n" +
+	"\x0emutation_index\x18\x01 \x01(\x03R\rmutationIndex\x12K\n" +
+	"\x05value\x18\x02 \x01(\v25.com.continusec.verifiabledatastructures.api.LeafDataR\x05value\x12Q\n" +
+	"\bmutation\x18\x03 \x01(\v25.com.continusec.verifiabledatastructures.api.LeafDataR\bmutation\x12o\n" +
+	"\x0finclusion_proof\x18\x04 \x01(\v2F.com.continusec.verifiabledatastructures.api.LogInclusionProofResponseR\x0einclusionProof\"\xf5\x01\n" +
+	"\x18MapGetKeyHistoryResponse\x12\x1b\n" +
+	"\ttree_size\x18\x01 \x01(\x03R\btreeSize\x12Y\n" +
+	"\aentries\x18\x02 \x03(\v2?.com.continusec.verifiabledatastructures.api.MapKeyHistoryEntryR\aentries\x12a\n" +
+	"\x0ehash_algorithm\x18\x03 \x01(\x0e2:.com.continusec.verifiabledatastructures.api.HashAlgorithmR\rhashAlgorithm\"x\n" +
 	"\x12MapTreeHashRequest\x12E\n" +
 	"\x03map\x18\x01 \x01(\v23.com.continusec.verifiabledatastructures.api.MapRefR\x03map\x12\x1b\n" +
-	"\ttree_size\x18\x02 \x01(\x03R\btreeSize\"\x97\x01\n" +
+	"\ttree_size\x18\x02 \x01(\x03R\btreeSize\"\xf9\x02\n" +
 	"\x13MapTreeHashResponse\x12\x1b\n" +
 	"\troot_hash\x18\x01 \x01(\fR\brootHash\x12c\n" +
-	"\fmutation_log\x18\x02 \x01(\v2@.com.continusec.verifiabledatastructures.api.LogTreeHashResponseR\vmutationLog\"\xb8\x01\n" +
+	"\fmutation_log\x18\x02 \x01(\v2@.com.continusec.verifiabledatastructures.api.LogTreeHashResponseR\vmutationLog\x12\\\n" +
+	"\tsignature\x18\x03 \x01(\v2>.com.continusec.verifiabledatastructures.api.TreeHeadSignatureR\tsignature\x12\x1a\n" +
+	"\brejected\x18\x04 \x01(\bR\brejected\x12$\n" +
+	"\x0evrf_public_key\x18\x05 \x01(\fR\fvrfPublicKey\x12\x1b\n" +
+	"\tsum_field\x18\x06 \x01(\tR\bsumField\x12#\n" +
+	"\rpatricia_trie\x18\a \x01(\bR\fpatriciaTrie\"\xa8\x01\n" +
+	"\tLogGossip\x12E\n" +
+	"\x03log\x18\x01 \x01(\v23.com.continusec.verifiabledatastructures.api.LogRefR\x03log\x12T\n" +
+	"\x04head\x18\x02 \x01(\v2@.com.continusec.verifiabledatastructures.api.LogTreeHashResponseR\x04head\"\x9e\x02\n" +
+	"\tMapGossip\x12E\n" +
+	"\x03map\x18\x01 \x01(\v23.com.continusec.verifiabledatastructures.api.MapRefR\x03map\x12[\n" +
+	"\bmap_head\x18\x02 \x01(\v2@.com.continusec.verifiabledatastructures.api.MapTreeHashResponseR\amapHead\x12m\n" +
+	"\x12tree_head_log_head\x18\x03 \x01(\v2@.com.continusec.verifiabledatastructures.api.LogTreeHashResponseR\x0ftreeHeadLogHead\"\xa7\x01\n" +
+	"\rGossipRequest\x12J\n" +
+	"\x04logs\x18\x01 \x03(\v26.com.continusec.verifiabledatastructures.api.LogGossipR\x04logs\x12J\n" +
+	"\x04maps\x18\x02 \x03(\v26.com.continusec.verifiabledatastructures.api.MapGossipR\x04maps\"\xda\x01\n" +
+	"\x0eGossipResponse\x12c\n" +
+	"\x11inconsistent_logs\x18\x01 \x03(\v26.com.continusec.verifiabledatastructures.api.LogGossipR\x10inconsistentLogs\x12c\n" +
+	"\x11inconsistent_maps\x18\x02 \x03(\v26.com.continusec.verifiabledatastructures.api.MapGossipR\x10inconsistentMaps\"H\n" +
+	"\x11TreeHeadSignature\x12\x15\n" +
+	"\x06key_id\x18\x01 \x01(\fR\x05keyId\x12\x1c\n" +
+	"\tsignature\x18\x02 \x01(\fR\tsignature\"\xb8\x01\n" +
 	"\x18LogInclusionProofRequest\x12E\n" +
 	"\x03log\x18\x01 \x01(\v23.com.continusec.verifiabledatastructures.api.LogRefR\x03log\x12\x1b\n" +
 	"\ttree_size\x18\x02 \x01(\x03R\btreeSize\x12\x19\n" +
 	"\bmtl_hash\x18\x03 \x01(\fR\amtlHash\x12\x1d\n" +
 	"\n" +
-	"leaf_index\x18\x04 \x01(\x03R\tleafIndex\"v\n" +
+	"leaf_index\x18\x04 \x01(\x03R\tleafIndex\"\xd9\x01\n" +
 	"\x19LogInclusionProofResponse\x12\x1b\n" +
 	"\ttree_size\x18\x01 \x01(\x03R\btreeSize\x12\x1d\n" +
 	"\n" +
 	"leaf_index\x18\x02 \x01(\x03R\tleafIndex\x12\x1d\n" +
 	"\n" +
-	"audit_path\x18\x03 \x03(\fR\tauditPath\"\x9d\x01\n" +
+	"audit_path\x18\x03 \x03(\fR\tauditPath\x12a\n" +
+	"\x0ehash_algorithm\x18\x04 \x01(\x0e2:.com.continusec.verifiabledatastructures.api.HashAlgorithmR\rhashAlgorithm\"\xc5\x01\n" +
+	"\x1dLogBatchInclusionProofRequest\x12E\n" +
+	"\x03log\x18\x01 \x01(\v23.com.continusec.verifiabledatastructures.api.LogRefR\x03log\x12\x1b\n" +
+	"\ttree_size\x18\x02 \x01(\x03R\btreeSize\x12\x1d\n" +
+	"\n" +
+	"mtl_hashes\x18\x03 \x03(\fR\tmtlHashes\x12!\n" +
+	"\fleaf_indices\x18\x04 \x03(\x03R\vleafIndices\"L\n" +
+	"\x0eLogSubTreeHash\x12\x14\n" +
+	"\x05start\x18\x01 \x01(\x03R\x05start\x12\x10\n" +
+	"\x03end\x18\x02 \x01(\x03R\x03end\x12\x12\n" +
+	"\x04hash\x18\x03 \x01(\fR\x04hash\"\x9f\x02\n" +
+	"\x1eLogBatchInclusionProofResponse\x12\x1b\n" +
+	"\ttree_size\x18\x01 \x01(\x03R\btreeSize\x12!\n" +
+	"\fleaf_indices\x18\x02 \x03(\x03R\vleafIndices\x12Z\n" +
+	"\n" +
+	"audit_path\x18\x03 \x03(\v2;.com.continusec.verifiabledatastructures.api.LogSubTreeHashR\tauditPath\x12a\n" +
+	"\x0ehash_algorithm\x18\x04 \x01(\x0e2:.com.continusec.verifiabledatastructures.api.HashAlgorithmR\rhashAlgorithm\"\x9d\x01\n" +
 	"\x1aLogConsistencyProofRequest\x12E\n" +
 	"\x03log\x18\x01 \x01(\v23.com.continusec.verifiabledatastructures.api.LogRefR\x03log\x12\x1b\n" +
 	"\tfrom_size\x18\x02 \x01(\x03R\bfromSize\x12\x1b\n" +
-	"\ttree_size\x18\x03 \x01(\x03R\btreeSize\"v\n" +
+	"\ttree_size\x18\x03 \x01(\x03R\btreeSize\"\xd9\x01\n" +
 	"\x1bLogConsistencyProofResponse\x12\x1b\n" +
 	"\tfrom_size\x18\x01 \x01(\x03R\bfromSize\x12\x1b\n" +
 	"\ttree_size\x18\x02 \x01(\x03R\btreeSize\x12\x1d\n" +
 	"\n" +
-	"audit_path\x18\x03 \x03(\fR\tauditPath\"\x99\x01\n" +
+	"audit_path\x18\x03 \x03(\fR\tauditPath\x12a\n" +
+	"\x0ehash_algorithm\x18\x04 \x01(\x0e2:.com.continusec.verifiabledatastructures.api.HashAlgorithmR\rhashAlgorithm\"\x99\x01\n" +
 	"\bLeafData\x12\x1d\n" +
 	"\n" +
 	"leaf_input\x18\x01 \x01(\fR\tleafInput\x12\x1d\n" +
@@ -1365,50 +4649,175 @@ const file_api_proto_rawDesc = "" +
 	"\x03log\x18\x01 \x01(\v23.com.continusec.verifiabledatastructures.api.LogRefR\x03log\x12K\n" +
 	"\x05value\x18\x02 \x01(\v25.com.continusec.verifiabledatastructures.api.LeafDataR\x05value\"2\n" +
 	"\x13LogAddEntryResponse\x12\x1b\n" +
-	"\tleaf_hash\x18\x01 \x01(\fR\bleafHash\"\xb1\x01\n" +
+	"\tleaf_hash\x18\x01 \x01(\fR\bleafHash\"\xac\x01\n" +
+	"\x14LogAddEntriesRequest\x12E\n" +
+	"\x03log\x18\x01 \x01(\v23.com.continusec.verifiabledatastructures.api.LogRefR\x03log\x12M\n" +
+	"\x06values\x18\x02 \x03(\v25.com.continusec.verifiabledatastructures.api.LeafDataR\x06values\"a\n" +
+	"\x15LogAddEntriesResponse\x12\x1f\n" +
+	"\vleaf_hashes\x18\x01 \x03(\fR\n" +
+	"leafHashes\x12'\n" +
+	"\x0falready_present\x18\x02 \x03(\bR\x0ealreadyPresent\"\xb1\x01\n" +
 	"\x12MapSetValueRequest\x12E\n" +
 	"\x03map\x18\x01 \x01(\v23.com.continusec.verifiabledatastructures.api.MapRefR\x03map\x12T\n" +
 	"\bmutation\x18\x02 \x01(\v28.com.continusec.verifiabledatastructures.api.MapMutationR\bmutation\"2\n" +
 	"\x13MapSetValueResponse\x12\x1b\n" +
-	"\tleaf_hash\x18\x01 \x01(\fR\bleafHash\"\x8a\x01\n" +
+	"\tleaf_hash\x18\x01 \x01(\fR\bleafHash\"\xbb\x01\n" +
+	"\x1aMapApplyTransactionRequest\x12E\n" +
+	"\x03map\x18\x01 \x01(\v23.com.continusec.verifiabledatastructures.api.MapRefR\x03map\x12V\n" +
+	"\tmutations\x18\x02 \x03(\v28.com.continusec.verifiabledatastructures.api.MapMutationR\tmutations\":\n" +
+	"\x1bMapApplyTransactionResponse\x12\x1b\n" +
+	"\tleaf_hash\x18\x01 \x01(\fR\bleafHash\"\xaf\x01\n" +
 	"\x12MapGetValueRequest\x12E\n" +
 	"\x03map\x18\x01 \x01(\v23.com.continusec.verifiabledatastructures.api.MapRefR\x03map\x12\x1b\n" +
 	"\ttree_size\x18\x02 \x01(\x03R\btreeSize\x12\x10\n" +
-	"\x03key\x18\x03 \x01(\fR\x03key\"\x9e\x01\n" +
+	"\x03key\x18\x03 \x01(\fR\x03key\x12#\n" +
+	"\rcompact_proof\x18\x04 \x01(\bR\fcompactProof\"\x9f\x03\n" +
 	"\x13MapGetValueResponse\x12\x1b\n" +
 	"\ttree_size\x18\x01 \x01(\x03R\btreeSize\x12\x1d\n" +
 	"\n" +
 	"audit_path\x18\x02 \x03(\fR\tauditPath\x12K\n" +
-	"\x05value\x18\x03 \x01(\v25.com.continusec.verifiabledatastructures.api.LeafDataR\x05value\"\x89\x01\n" +
+	"\x05value\x18\x03 \x01(\v25.com.continusec.verifiabledatastructures.api.LeafDataR\x05value\x12a\n" +
+	"\x0ehash_algorithm\x18\x04 \x01(\x0e2:.com.continusec.verifiabledatastructures.api.HashAlgorithmR\rhashAlgorithm\x12*\n" +
+	"\x11audit_path_bitmap\x18\x05 \x01(\fR\x0fauditPathBitmap\x12,\n" +
+	"\x12compact_audit_path\x18\x06 \x03(\fR\x10compactAuditPath\x12\x1b\n" +
+	"\tkey_proof\x18\a \x01(\fR\bkeyProof\x12%\n" +
+	"\x0epatricia_proof\x18\b \x03(\fR\rpatriciaProof\"\x89\x01\n" +
 	"\x16LogFetchEntriesRequest\x12E\n" +
 	"\x03log\x18\x01 \x01(\v23.com.continusec.verifiabledatastructures.api.LogRefR\x03log\x12\x14\n" +
 	"\x05first\x18\x02 \x01(\x03R\x05first\x12\x12\n" +
 	"\x04last\x18\x03 \x01(\x03R\x04last\"h\n" +
 	"\x17LogFetchEntriesResponse\x12M\n" +
-	"\x06values\x18\x01 \x03(\v25.com.continusec.verifiabledatastructures.api.LeafDataR\x06values\"\xd0\x01\n" +
+	"\x06values\x18\x01 \x03(\v25.com.continusec.verifiabledatastructures.api.LeafDataR\x06values\"\xa2\x01\n" +
+	"\x17StreamLogEntriesRequest\x12E\n" +
+	"\x03log\x18\x01 \x01(\v23.com.continusec.verifiabledatastructures.api.LogRefR\x03log\x12\x14\n" +
+	"\x05first\x18\x02 \x01(\x03R\x05first\x12\x12\n" +
+	"\x04last\x18\x03 \x01(\x03R\x04last\x12\x16\n" +
+	"\x06follow\x18\x04 \x01(\bR\x06follow\"}\n" +
+	"\x18StreamLogEntriesResponse\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x03R\x05index\x12K\n" +
+	"\x05value\x18\x02 \x01(\v25.com.continusec.verifiabledatastructures.api.LeafDataR\x05value\"}\n" +
+	"\x17WatchLogTreeHeadRequest\x12E\n" +
+	"\x03log\x18\x01 \x01(\v23.com.continusec.verifiabledatastructures.api.LogRefR\x03log\x12\x1b\n" +
+	"\ttree_size\x18\x02 \x01(\x03R\btreeSize\"}\n" +
+	"\x17WatchMapTreeHeadRequest\x12E\n" +
+	"\x03map\x18\x01 \x01(\v23.com.continusec.verifiabledatastructures.api.MapRefR\x03map\x12\x1b\n" +
+	"\ttree_size\x18\x02 \x01(\x03R\btreeSize\"\xbc\x01\n" +
+	"\x1aLogFetchEntryBundleRequest\x12E\n" +
+	"\x03log\x18\x01 \x01(\v23.com.continusec.verifiabledatastructures.api.LogRefR\x03log\x12\x1d\n" +
+	"\n" +
+	"leaf_index\x18\x02 \x01(\x03R\tleafIndex\x12\x1b\n" +
+	"\ttree_size\x18\x03 \x01(\x03R\btreeSize\x12\x1b\n" +
+	"\tfrom_size\x18\x04 \x01(\x03R\bfromSize\"\xa8\x03\n" +
+	"\x1bLogFetchEntryBundleResponse\x12T\n" +
+	"\x04head\x18\x01 \x01(\v2@.com.continusec.verifiabledatastructures.api.LogTreeHashResponseR\x04head\x12K\n" +
+	"\x05value\x18\x02 \x01(\v25.com.continusec.verifiabledatastructures.api.LeafDataR\x05value\x12o\n" +
+	"\x0finclusion_proof\x18\x03 \x01(\v2F.com.continusec.verifiabledatastructures.api.LogInclusionProofResponseR\x0einclusionProof\x12u\n" +
+	"\x11consistency_proof\x18\x04 \x01(\v2H.com.continusec.verifiabledatastructures.api.LogConsistencyProofResponseR\x10consistencyProof\"\xe3\x01\n" +
+	"\x18MapGetValueBundleRequest\x12E\n" +
+	"\x03map\x18\x01 \x01(\v23.com.continusec.verifiabledatastructures.api.MapRefR\x03map\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\fR\x03key\x12\x1b\n" +
+	"\ttree_size\x18\x03 \x01(\x03R\btreeSize\x12\x1b\n" +
+	"\tfrom_size\x18\x04 \x01(\x03R\bfromSize\x124\n" +
+	"\x17tree_head_log_from_size\x18\x05 \x01(\x03R\x13treeHeadLogFromSize\"\xeb\x05\n" +
+	"\x19MapGetValueBundleResponse\x12[\n" +
+	"\bmap_head\x18\x01 \x01(\v2@.com.continusec.verifiabledatastructures.api.MapTreeHashResponseR\amapHead\x12V\n" +
+	"\x05value\x18\x02 \x01(\v2@.com.continusec.verifiabledatastructures.api.MapGetValueResponseR\x05value\x12m\n" +
+	"\x12tree_head_log_head\x18\x03 \x01(\v2@.com.continusec.verifiabledatastructures.api.LogTreeHashResponseR\x0ftreeHeadLogHead\x12\x88\x01\n" +
+	"\x1dtree_head_log_inclusion_proof\x18\x04 \x01(\v2F.com.continusec.verifiabledatastructures.api.LogInclusionProofResponseR\x19treeHeadLogInclusionProof\x12\x8d\x01\n" +
+	"\x1emutation_log_consistency_proof\x18\x05 \x01(\v2H.com.continusec.verifiabledatastructures.api.LogConsistencyProofResponseR\x1bmutationLogConsistencyProof\x12\x8e\x01\n" +
+	"\x1ftree_head_log_consistency_proof\x18\x06 \x01(\v2H.com.continusec.verifiabledatastructures.api.LogConsistencyProofResponseR\x1btreeHeadLogConsistencyProof\"\xc6\x03\n" +
 	"\vMapMutation\x12\x1c\n" +
 	"\ttimestamp\x18\x01 \x01(\tR\ttimestamp\x12\x16\n" +
 	"\x06action\x18\x02 \x01(\tR\x06action\x12\x10\n" +
 	"\x03key\x18\x03 \x01(\fR\x03key\x12K\n" +
 	"\x05value\x18\x04 \x01(\v25.com.continusec.verifiabledatastructures.api.LeafDataR\x05value\x12,\n" +
-	"\x12previous_leaf_hash\x18\x05 \x01(\fR\x10previousLeafHash*Z\n" +
+	"\x12previous_leaf_hash\x18\x05 \x01(\fR\x10previousLeafHash\x12X\n" +
+	"\n" +
+	"operations\x18\x06 \x03(\v28.com.continusec.verifiabledatastructures.api.MapMutationR\n" +
+	"operations\x12\x18\n" +
+	"\aversion\x18\a \x01(\x03R\aversion\x12\x14\n" +
+	"\x05patch\x18\b \x01(\fR\x05patch\x12\x14\n" +
+	"\x05field\x18\t \x01(\tR\x05field\x12\x14\n" +
+	"\x05delta\x18\n" +
+	" \x01(\x03R\x05delta\x12\x1b\n" +
+	"\tkey_proof\x18\v \x01(\fR\bkeyProof\x12!\n" +
+	"\fsource_index\x18\f \x01(\x03R\vsourceIndex\"\xce\x01\n" +
+	"\x17MultimapAddValueRequest\x12T\n" +
+	"\bmultimap\x18\x01 \x01(\v28.com.continusec.verifiabledatastructures.api.MultimapRefR\bmultimap\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\fR\x03key\x12K\n" +
+	"\x05value\x18\x03 \x01(\v25.com.continusec.verifiabledatastructures.api.LeafDataR\x05value\"7\n" +
+	"\x18MultimapAddValueResponse\x12\x1b\n" +
+	"\tleaf_hash\x18\x01 \x01(\fR\bleafHash\"\x8c\x01\n" +
+	"\x17MultimapTreeHashRequest\x12T\n" +
+	"\bmultimap\x18\x01 \x01(\v28.com.continusec.verifiabledatastructures.api.MultimapRefR\bmultimap\x12\x1b\n" +
+	"\ttree_size\x18\x02 \x01(\x03R\btreeSize\"\x9f\x01\n" +
+	"\x18MultimapGetValuesRequest\x12T\n" +
+	"\bmultimap\x18\x01 \x01(\v28.com.continusec.verifiabledatastructures.api.MultimapRefR\bmultimap\x12\x1b\n" +
+	"\ttree_size\x18\x02 \x01(\x03R\btreeSize\x12\x10\n" +
+	"\x03key\x18\x03 \x01(\fR\x03key\"\xc7\x01\n" +
+	"\x19MultimapGetValuesResponse\x12[\n" +
+	"\bkey_head\x18\x01 \x01(\v2@.com.continusec.verifiabledatastructures.api.MapGetValueResponseR\akeyHead\x12M\n" +
+	"\x06values\x18\x02 \x03(\v25.com.continusec.verifiabledatastructures.api.LeafDataR\x06values\"\xe0\x01\n" +
+	"\x1dMultimapInclusionProofRequest\x12T\n" +
+	"\bmultimap\x18\x01 \x01(\v28.com.continusec.verifiabledatastructures.api.MultimapRefR\bmultimap\x12\x1b\n" +
+	"\ttree_size\x18\x02 \x01(\x03R\btreeSize\x12\x10\n" +
+	"\x03key\x18\x03 \x01(\fR\x03key\x12\x19\n" +
+	"\bmtl_hash\x18\x04 \x01(\fR\amtlHash\x12\x1f\n" +
+	"\vvalue_index\x18\x05 \x01(\x03R\n" +
+	"valueIndex\"\xdb\x01\n" +
+	"\x1eMultimapInclusionProofResponse\x12[\n" +
+	"\bkey_head\x18\x01 \x01(\v2@.com.continusec.verifiabledatastructures.api.MapGetValueResponseR\akeyHead\x12\\\n" +
+	"\x05proof\x18\x02 \x01(\v2F.com.continusec.verifiabledatastructures.api.LogInclusionProofResponseR\x05proof\"\xc6\x01\n" +
+	"\"MultimapKeyConsistencyProofRequest\x12T\n" +
+	"\bmultimap\x18\x01 \x01(\v28.com.continusec.verifiabledatastructures.api.MultimapRefR\bmultimap\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\fR\x03key\x12\x1b\n" +
+	"\tfrom_size\x18\x03 \x01(\x03R\bfromSize\x12\x1b\n" +
+	"\ttree_size\x18\x04 \x01(\x03R\btreeSize\"\xc8\x02\n" +
+	"#MultimapKeyConsistencyProofResponse\x12d\n" +
+	"\rfrom_key_head\x18\x01 \x01(\v2@.com.continusec.verifiabledatastructures.api.MapGetValueResponseR\vfromKeyHead\x12[\n" +
+	"\bkey_head\x18\x02 \x01(\v2@.com.continusec.verifiabledatastructures.api.MapGetValueResponseR\akeyHead\x12^\n" +
+	"\x05proof\x18\x03 \x01(\v2H.com.continusec.verifiabledatastructures.api.LogConsistencyProofResponseR\x05proof*Z\n" +
 	"\aLogType\x12\x13\n" +
 	"\x0fSTRUCT_TYPE_LOG\x10\x00\x12\x1c\n" +
 	"\x18STRUCT_TYPE_MUTATION_LOG\x10\x01\x12\x1c\n" +
-	"\x18STRUCT_TYPE_TREEHEAD_LOG\x10\x02*'\n" +
+	"\x18STRUCT_TYPE_TREEHEAD_LOG\x10\x02*\\\n" +
+	"\rHashAlgorithm\x12\x0f\n" +
+	"\vHASH_SHA256\x10\x00\x12\x13\n" +
+	"\x0fHASH_SHA512_256\x10\x01\x12\x14\n" +
+	"\x10HASH_BLAKE2B_256\x10\x02\x12\x0f\n" +
+	"\vHASH_BLAKE3\x10\x03*'\n" +
 	"\n" +
 	"DataFormat\x12\x0f\n" +
 	"\vUNSPECIFIED\x10\x00\x12\b\n" +
-	"\x04JSON\x10\x012\xff\t\n" +
+	"\x04JSON\x10\x012\x9f\"\n" +
 	"\x1fVerifiableDataStructuresService\x12\x92\x01\n" +
-	"\vLogAddEntry\x12?.com.continusec.verifiabledatastructures.api.LogAddEntryRequest\x1a@.com.continusec.verifiabledatastructures.api.LogAddEntryResponse\"\x00\x12\x9e\x01\n" +
-	"\x0fLogFetchEntries\x12C.com.continusec.verifiabledatastructures.api.LogFetchEntriesRequest\x1aD.com.continusec.verifiabledatastructures.api.LogFetchEntriesResponse\"\x00\x12\x92\x01\n" +
+	"\vLogAddEntry\x12?.com.continusec.verifiabledatastructures.api.LogAddEntryRequest\x1a@.com.continusec.verifiabledatastructures.api.LogAddEntryResponse\"\x00\x12\x98\x01\n" +
+	"\rLogAddEntries\x12A.com.continusec.verifiabledatastructures.api.LogAddEntriesRequest\x1aB.com.continusec.verifiabledatastructures.api.LogAddEntriesResponse\"\x00\x12\x9e\x01\n" +
+	"\x0fLogFetchEntries\x12C.com.continusec.verifiabledatastructures.api.LogFetchEntriesRequest\x1aD.com.continusec.verifiabledatastructures.api.LogFetchEntriesResponse\"\x00\x12\xa3\x01\n" +
+	"\x10StreamLogEntries\x12D.com.continusec.verifiabledatastructures.api.StreamLogEntriesRequest\x1aE.com.continusec.verifiabledatastructures.api.StreamLogEntriesResponse\"\x000\x01\x12\xaa\x01\n" +
+	"\x13LogFetchEntryBundle\x12G.com.continusec.verifiabledatastructures.api.LogFetchEntryBundleRequest\x1aH.com.continusec.verifiabledatastructures.api.LogFetchEntryBundleResponse\"\x00\x12\x92\x01\n" +
 	"\vLogTreeHash\x12?.com.continusec.verifiabledatastructures.api.LogTreeHashRequest\x1a@.com.continusec.verifiabledatastructures.api.LogTreeHashResponse\"\x00\x12\xa4\x01\n" +
-	"\x11LogInclusionProof\x12E.com.continusec.verifiabledatastructures.api.LogInclusionProofRequest\x1aF.com.continusec.verifiabledatastructures.api.LogInclusionProofResponse\"\x00\x12\xaa\x01\n" +
-	"\x13LogConsistencyProof\x12G.com.continusec.verifiabledatastructures.api.LogConsistencyProofRequest\x1aH.com.continusec.verifiabledatastructures.api.LogConsistencyProofResponse\"\x00\x12\x92\x01\n" +
-	"\vMapSetValue\x12?.com.continusec.verifiabledatastructures.api.MapSetValueRequest\x1a@.com.continusec.verifiabledatastructures.api.MapSetValueResponse\"\x00\x12\x92\x01\n" +
-	"\vMapGetValue\x12?.com.continusec.verifiabledatastructures.api.MapGetValueRequest\x1a@.com.continusec.verifiabledatastructures.api.MapGetValueResponse\"\x00\x12\x92\x01\n" +
-	"\vMapTreeHash\x12?.com.continusec.verifiabledatastructures.api.MapTreeHashRequest\x1a@.com.continusec.verifiabledatastructures.api.MapTreeHashResponse\"\x00B3Z1github.com/continusec/verifiabledatastructures/pbb\x06proto3"
+	"\x11LogInclusionProof\x12E.com.continusec.verifiabledatastructures.api.LogInclusionProofRequest\x1aF.com.continusec.verifiabledatastructures.api.LogInclusionProofResponse\"\x00\x12\xb3\x01\n" +
+	"\x16LogBatchInclusionProof\x12J.com.continusec.verifiabledatastructures.api.LogBatchInclusionProofRequest\x1aK.com.continusec.verifiabledatastructures.api.LogBatchInclusionProofResponse\"\x00\x12\xaa\x01\n" +
+	"\x13LogConsistencyProof\x12G.com.continusec.verifiabledatastructures.api.LogConsistencyProofRequest\x1aH.com.continusec.verifiabledatastructures.api.LogConsistencyProofResponse\"\x00\x12\x98\x01\n" +
+	"\rLogCheckpoint\x12A.com.continusec.verifiabledatastructures.api.LogCheckpointRequest\x1aB.com.continusec.verifiabledatastructures.api.LogCheckpointResponse\"\x00\x12\x9e\x01\n" +
+	"\x10WatchLogTreeHead\x12D.com.continusec.verifiabledatastructures.api.WatchLogTreeHeadRequest\x1a@.com.continusec.verifiabledatastructures.api.LogTreeHashResponse\"\x000\x01\x12\x92\x01\n" +
+	"\vMapSetValue\x12?.com.continusec.verifiabledatastructures.api.MapSetValueRequest\x1a@.com.continusec.verifiabledatastructures.api.MapSetValueResponse\"\x00\x12\xaa\x01\n" +
+	"\x13MapApplyTransaction\x12G.com.continusec.verifiabledatastructures.api.MapApplyTransactionRequest\x1aH.com.continusec.verifiabledatastructures.api.MapApplyTransactionResponse\"\x00\x12\x92\x01\n" +
+	"\vMapGetValue\x12?.com.continusec.verifiabledatastructures.api.MapGetValueRequest\x1a@.com.continusec.verifiabledatastructures.api.MapGetValueResponse\"\x00\x12\xa4\x01\n" +
+	"\x11MapGetValueBundle\x12E.com.continusec.verifiabledatastructures.api.MapGetValueBundleRequest\x1aF.com.continusec.verifiabledatastructures.api.MapGetValueBundleResponse\"\x00\x12\x95\x01\n" +
+	"\fMapGetValues\x12@.com.continusec.verifiabledatastructures.api.MapGetValuesRequest\x1aA.com.continusec.verifiabledatastructures.api.MapGetValuesResponse\"\x00\x12\xa1\x01\n" +
+	"\x10MapGetKeyHistory\x12D.com.continusec.verifiabledatastructures.api.MapGetKeyHistoryRequest\x1aE.com.continusec.verifiabledatastructures.api.MapGetKeyHistoryResponse\"\x00\x12\x98\x01\n" +
+	"\rMapListLeaves\x12A.com.continusec.verifiabledatastructures.api.MapListLeavesRequest\x1aB.com.continusec.verifiabledatastructures.api.MapListLeavesResponse\"\x00\x12\x86\x01\n" +
+	"\aMapDiff\x12;.com.continusec.verifiabledatastructures.api.MapDiffRequest\x1a<.com.continusec.verifiabledatastructures.api.MapDiffResponse\"\x00\x12\x92\x01\n" +
+	"\vMapTreeHash\x12?.com.continusec.verifiabledatastructures.api.MapTreeHashRequest\x1a@.com.continusec.verifiabledatastructures.api.MapTreeHashResponse\"\x00\x12\x9e\x01\n" +
+	"\x10WatchMapTreeHead\x12D.com.continusec.verifiabledatastructures.api.WatchMapTreeHeadRequest\x1a@.com.continusec.verifiabledatastructures.api.MapTreeHashResponse\"\x000\x01\x12\xa1\x01\n" +
+	"\x10MultimapAddValue\x12D.com.continusec.verifiabledatastructures.api.MultimapAddValueRequest\x1aE.com.continusec.verifiabledatastructures.api.MultimapAddValueResponse\"\x00\x12\xa4\x01\n" +
+	"\x11MultimapGetValues\x12E.com.continusec.verifiabledatastructures.api.MultimapGetValuesRequest\x1aF.com.continusec.verifiabledatastructures.api.MultimapGetValuesResponse\"\x00\x12\xb3\x01\n" +
+	"\x16MultimapInclusionProof\x12J.com.continusec.verifiabledatastructures.api.MultimapInclusionProofRequest\x1aK.com.continusec.verifiabledatastructures.api.MultimapInclusionProofResponse\"\x00\x12\xc2\x01\n" +
+	"\x1bMultimapKeyConsistencyProof\x12O.com.continusec.verifiabledatastructures.api.MultimapKeyConsistencyProofRequest\x1aP.com.continusec.verifiabledatastructures.api.MultimapKeyConsistencyProofResponse\"\x00\x12\x9c\x01\n" +
+	"\x10MultimapTreeHash\x12D.com.continusec.verifiabledatastructures.api.MultimapTreeHashRequest\x1a@.com.continusec.verifiabledatastructures.api.MapTreeHashResponse\"\x00\x12\x83\x01\n" +
+	"\x06Gossip\x12:.com.continusec.verifiabledatastructures.api.GossipRequest\x1a;.com.continusec.verifiabledatastructures.api.GossipResponse\"\x00B3Z1github.com/continusec/verifiabledatastructures/pbb\x06proto3"
 
 var (
 	file_api_proto_rawDescOnce sync.Once
@@ -1422,73 +4831,240 @@ func file_api_proto_rawDescGZIP() []byte {
 	return file_api_proto_rawDescData
 }
 
-var file_api_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_api_proto_msgTypes = make([]protoimpl.MessageInfo, 21)
+var file_api_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_api_proto_msgTypes = make([]protoimpl.MessageInfo, 67)
 var file_api_proto_goTypes = []any{
-	(LogType)(0),                        // 0: com.continusec.verifiabledatastructures.api.LogType
-	(DataFormat)(0),                     // 1: com.continusec.verifiabledatastructures.api.DataFormat
-	(*AccountRef)(nil),                  // 2: com.continusec.verifiabledatastructures.api.AccountRef
-	(*LogRef)(nil),                      // 3: com.continusec.verifiabledatastructures.api.LogRef
-	(*MapRef)(nil),                      // 4: com.continusec.verifiabledatastructures.api.MapRef
-	(*LogTreeHashRequest)(nil),          // 5: com.continusec.verifiabledatastructures.api.LogTreeHashRequest
-	(*LogTreeHashResponse)(nil),         // 6: com.continusec.verifiabledatastructures.api.LogTreeHashResponse
-	(*MapTreeHashRequest)(nil),          // 7: com.continusec.verifiabledatastructures.api.MapTreeHashRequest
-	(*MapTreeHashResponse)(nil),         // 8: com.continusec.verifiabledatastructures.api.MapTreeHashResponse
-	(*LogInclusionProofRequest)(nil),    // 9: com.continusec.verifiabledatastructures.api.LogInclusionProofRequest
-	(*LogInclusionProofResponse)(nil),   // 10: com.continusec.verifiabledatastructures.api.LogInclusionProofResponse
-	(*LogConsistencyProofRequest)(nil),  // 11: com.continusec.verifiabledatastructures.api.LogConsistencyProofRequest
-	(*LogConsistencyProofResponse)(nil), // 12: com.continusec.verifiabledatastructures.api.LogConsistencyProofResponse
-	(*LeafData)(nil),                    // 13: com.continusec.verifiabledatastructures.api.LeafData
-	(*LogAddEntryRequest)(nil),          // 14: com.continusec.verifiabledatastructures.api.LogAddEntryRequest
-	(*LogAddEntryResponse)(nil),         // 15: com.continusec.verifiabledatastructures.api.LogAddEntryResponse
-	(*MapSetValueRequest)(nil),          // 16: com.continusec.verifiabledatastructures.api.MapSetValueRequest
-	(*MapSetValueResponse)(nil),         // 17: com.continusec.verifiabledatastructures.api.MapSetValueResponse
-	(*MapGetValueRequest)(nil),          // 18: com.continusec.verifiabledatastructures.api.MapGetValueRequest
-	(*MapGetValueResponse)(nil),         // 19: com.continusec.verifiabledatastructures.api.MapGetValueResponse
-	(*LogFetchEntriesRequest)(nil),      // 20: com.continusec.verifiabledatastructures.api.LogFetchEntriesRequest
-	(*LogFetchEntriesResponse)(nil),     // 21: com.continusec.verifiabledatastructures.api.LogFetchEntriesResponse
-	(*MapMutation)(nil),                 // 22: com.continusec.verifiabledatastructures.api.MapMutation
+	(LogType)(0),                                // 0: com.continusec.verifiabledatastructures.api.LogType
+	(HashAlgorithm)(0),                          // 1: com.continusec.verifiabledatastructures.api.HashAlgorithm
+	(DataFormat)(0),                             // 2: com.continusec.verifiabledatastructures.api.DataFormat
+	(*AccountRef)(nil),                          // 3: com.continusec.verifiabledatastructures.api.AccountRef
+	(*LogRef)(nil),                              // 4: com.continusec.verifiabledatastructures.api.LogRef
+	(*MapReducer)(nil),                          // 5: com.continusec.verifiabledatastructures.api.MapReducer
+	(*MapReducerRule)(nil),                      // 6: com.continusec.verifiabledatastructures.api.MapReducerRule
+	(*MapRef)(nil),                              // 7: com.continusec.verifiabledatastructures.api.MapRef
+	(*MultimapRef)(nil),                         // 8: com.continusec.verifiabledatastructures.api.MultimapRef
+	(*LogTreeHashRequest)(nil),                  // 9: com.continusec.verifiabledatastructures.api.LogTreeHashRequest
+	(*LogTreeHashResponse)(nil),                 // 10: com.continusec.verifiabledatastructures.api.LogTreeHashResponse
+	(*LogCheckpointRequest)(nil),                // 11: com.continusec.verifiabledatastructures.api.LogCheckpointRequest
+	(*LogCheckpointResponse)(nil),               // 12: com.continusec.verifiabledatastructures.api.LogCheckpointResponse
+	(*MapListLeavesRequest)(nil),                // 13: com.continusec.verifiabledatastructures.api.MapListLeavesRequest
+	(*MapLeaf)(nil),                             // 14: com.continusec.verifiabledatastructures.api.MapLeaf
+	(*MapSubTreeHash)(nil),                      // 15: com.continusec.verifiabledatastructures.api.MapSubTreeHash
+	(*MapListLeavesResponse)(nil),               // 16: com.continusec.verifiabledatastructures.api.MapListLeavesResponse
+	(*MapDiffRequest)(nil),                      // 17: com.continusec.verifiabledatastructures.api.MapDiffRequest
+	(*MapDiffEntry)(nil),                        // 18: com.continusec.verifiabledatastructures.api.MapDiffEntry
+	(*MapDiffResponse)(nil),                     // 19: com.continusec.verifiabledatastructures.api.MapDiffResponse
+	(*MapGetValuesRequest)(nil),                 // 20: com.continusec.verifiabledatastructures.api.MapGetValuesRequest
+	(*MapGetValuesResponse)(nil),                // 21: com.continusec.verifiabledatastructures.api.MapGetValuesResponse
+	(*MapGetKeyHistoryRequest)(nil),             // 22: com.continusec.verifiabledatastructures.api.MapGetKeyHistoryRequest
+	(*MapKeyHistoryEntry)(nil),                  // 23: com.continusec.verifiabledatastructures.api.MapKeyHistoryEntry
+	(*MapGetKeyHistoryResponse)(nil),            // 24: com.continusec.verifiabledatastructures.api.MapGetKeyHistoryResponse
+	(*MapTreeHashRequest)(nil),                  // 25: com.continusec.verifiabledatastructures.api.MapTreeHashRequest
+	(*MapTreeHashResponse)(nil),                 // 26: com.continusec.verifiabledatastructures.api.MapTreeHashResponse
+	(*LogGossip)(nil),                           // 27: com.continusec.verifiabledatastructures.api.LogGossip
+	(*MapGossip)(nil),                           // 28: com.continusec.verifiabledatastructures.api.MapGossip
+	(*GossipRequest)(nil),                       // 29: com.continusec.verifiabledatastructures.api.GossipRequest
+	(*GossipResponse)(nil),                      // 30: com.continusec.verifiabledatastructures.api.GossipResponse
+	(*TreeHeadSignature)(nil),                   // 31: com.continusec.verifiabledatastructures.api.TreeHeadSignature
+	(*LogInclusionProofRequest)(nil),            // 32: com.continusec.verifiabledatastructures.api.LogInclusionProofRequest
+	(*LogInclusionProofResponse)(nil),           // 33: com.continusec.verifiabledatastructures.api.LogInclusionProofResponse
+	(*LogBatchInclusionProofRequest)(nil),       // 34: com.continusec.verifiabledatastructures.api.LogBatchInclusionProofRequest
+	(*LogSubTreeHash)(nil),                      // 35: com.continusec.verifiabledatastructures.api.LogSubTreeHash
+	(*LogBatchInclusionProofResponse)(nil),      // 36: com.continusec.verifiabledatastructures.api.LogBatchInclusionProofResponse
+	(*LogConsistencyProofRequest)(nil),          // 37: com.continusec.verifiabledatastructures.api.LogConsistencyProofRequest
+	(*LogConsistencyProofResponse)(nil),         // 38: com.continusec.verifiabledatastructures.api.LogConsistencyProofResponse
+	(*LeafData)(nil),                            // 39: com.continusec.verifiabledatastructures.api.LeafData
+	(*LogAddEntryRequest)(nil),                  // 40: com.continusec.verifiabledatastructures.api.LogAddEntryRequest
+	(*LogAddEntryResponse)(nil),                 // 41: com.continusec.verifiabledatastructures.api.LogAddEntryResponse
+	(*LogAddEntriesRequest)(nil),                // 42: com.continusec.verifiabledatastructures.api.LogAddEntriesRequest
+	(*LogAddEntriesResponse)(nil),               // 43: com.continusec.verifiabledatastructures.api.LogAddEntriesResponse
+	(*MapSetValueRequest)(nil),                  // 44: com.continusec.verifiabledatastructures.api.MapSetValueRequest
+	(*MapSetValueResponse)(nil),                 // 45: com.continusec.verifiabledatastructures.api.MapSetValueResponse
+	(*MapApplyTransactionRequest)(nil),          // 46: com.continusec.verifiabledatastructures.api.MapApplyTransactionRequest
+	(*MapApplyTransactionResponse)(nil),         // 47: com.continusec.verifiabledatastructures.api.MapApplyTransactionResponse
+	(*MapGetValueRequest)(nil),                  // 48: com.continusec.verifiabledatastructures.api.MapGetValueRequest
+	(*MapGetValueResponse)(nil),                 // 49: com.continusec.verifiabledatastructures.api.MapGetValueResponse
+	(*LogFetchEntriesRequest)(nil),              // 50: com.continusec.verifiabledatastructures.api.LogFetchEntriesRequest
+	(*LogFetchEntriesResponse)(nil),             // 51: com.continusec.verifiabledatastructures.api.LogFetchEntriesResponse
+	(*StreamLogEntriesRequest)(nil),             // 52: com.continusec.verifiabledatastructures.api.StreamLogEntriesRequest
+	(*StreamLogEntriesResponse)(nil),            // 53: com.continusec.verifiabledatastructures.api.StreamLogEntriesResponse
+	(*WatchLogTreeHeadRequest)(nil),             // 54: com.continusec.verifiabledatastructures.api.WatchLogTreeHeadRequest
+	(*WatchMapTreeHeadRequest)(nil),             // 55: com.continusec.verifiabledatastructures.api.WatchMapTreeHeadRequest
+	(*LogFetchEntryBundleRequest)(nil),          // 56: com.continusec.verifiabledatastructures.api.LogFetchEntryBundleRequest
+	(*LogFetchEntryBundleResponse)(nil),         // 57: com.continusec.verifiabledatastructures.api.LogFetchEntryBundleResponse
+	(*MapGetValueBundleRequest)(nil),            // 58: com.continusec.verifiabledatastructures.api.MapGetValueBundleRequest
+	(*MapGetValueBundleResponse)(nil),           // 59: com.continusec.verifiabledatastructures.api.MapGetValueBundleResponse
+	(*MapMutation)(nil),                         // 60: com.continusec.verifiabledatastructures.api.MapMutation
+	(*MultimapAddValueRequest)(nil),             // 61: com.continusec.verifiabledatastructures.api.MultimapAddValueRequest
+	(*MultimapAddValueResponse)(nil),            // 62: com.continusec.verifiabledatastructures.api.MultimapAddValueResponse
+	(*MultimapTreeHashRequest)(nil),             // 63: com.continusec.verifiabledatastructures.api.MultimapTreeHashRequest
+	(*MultimapGetValuesRequest)(nil),            // 64: com.continusec.verifiabledatastructures.api.MultimapGetValuesRequest
+	(*MultimapGetValuesResponse)(nil),           // 65: com.continusec.verifiabledatastructures.api.MultimapGetValuesResponse
+	(*MultimapInclusionProofRequest)(nil),       // 66: com.continusec.verifiabledatastructures.api.MultimapInclusionProofRequest
+	(*MultimapInclusionProofResponse)(nil),      // 67: com.continusec.verifiabledatastructures.api.MultimapInclusionProofResponse
+	(*MultimapKeyConsistencyProofRequest)(nil),  // 68: com.continusec.verifiabledatastructures.api.MultimapKeyConsistencyProofRequest
+	(*MultimapKeyConsistencyProofResponse)(nil), // 69: com.continusec.verifiabledatastructures.api.MultimapKeyConsistencyProofResponse
 }
 var file_api_proto_depIdxs = []int32{
-	2,  // 0: com.continusec.verifiabledatastructures.api.LogRef.account:type_name -> com.continusec.verifiabledatastructures.api.AccountRef
-	0,  // 1: com.continusec.verifiabledatastructures.api.LogRef.log_type:type_name -> com.continusec.verifiabledatastructures.api.LogType
-	2,  // 2: com.continusec.verifiabledatastructures.api.MapRef.account:type_name -> com.continusec.verifiabledatastructures.api.AccountRef
-	3,  // 3: com.continusec.verifiabledatastructures.api.LogTreeHashRequest.log:type_name -> com.continusec.verifiabledatastructures.api.LogRef
-	4,  // 4: com.continusec.verifiabledatastructures.api.MapTreeHashRequest.map:type_name -> com.continusec.verifiabledatastructures.api.MapRef
-	6,  // 5: com.continusec.verifiabledatastructures.api.MapTreeHashResponse.mutation_log:type_name -> com.continusec.verifiabledatastructures.api.LogTreeHashResponse
-	3,  // 6: com.continusec.verifiabledatastructures.api.LogInclusionProofRequest.log:type_name -> com.continusec.verifiabledatastructures.api.LogRef
-	3,  // 7: com.continusec.verifiabledatastructures.api.LogConsistencyProofRequest.log:type_name -> com.continusec.verifiabledatastructures.api.LogRef
-	1,  // 8: com.continusec.verifiabledatastructures.api.LeafData.format:type_name -> com.continusec.verifiabledatastructures.api.DataFormat
-	3,  // 9: com.continusec.verifiabledatastructures.api.LogAddEntryRequest.log:type_name -> com.continusec.verifiabledatastructures.api.LogRef
-	13, // 10: com.continusec.verifiabledatastructures.api.LogAddEntryRequest.value:type_name -> com.continusec.verifiabledatastructures.api.LeafData
-	4,  // 11: com.continusec.verifiabledatastructures.api.MapSetValueRequest.map:type_name -> com.continusec.verifiabledatastructures.api.MapRef
-	22, // 12: com.continusec.verifiabledatastructures.api.MapSetValueRequest.mutation:type_name -> com.continusec.verifiabledatastructures.api.MapMutation
-	4,  // 13: com.continusec.verifiabledatastructures.api.MapGetValueRequest.map:type_name -> com.continusec.verifiabledatastructures.api.MapRef
-	13, // 14: com.continusec.verifiabledatastructures.api.MapGetValueResponse.value:type_name -> com.continusec.verifiabledatastructures.api.LeafData
-	3,  // 15: com.continusec.verifiabledatastructures.api.LogFetchEntriesRequest.log:type_name -> com.continusec.verifiabledatastructures.api.LogRef
-	13, // 16: com.continusec.verifiabledatastructures.api.LogFetchEntriesResponse.values:type_name -> com.continusec.verifiabledatastructures.api.LeafData
-	13, // 17: com.continusec.verifiabledatastructures.api.MapMutation.value:type_name -> com.continusec.verifiabledatastructures.api.LeafData
-	14, // 18: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.LogAddEntry:input_type -> com.continusec.verifiabledatastructures.api.LogAddEntryRequest
-	20, // 19: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.LogFetchEntries:input_type -> com.continusec.verifiabledatastructures.api.LogFetchEntriesRequest
-	5,  // 20: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.LogTreeHash:input_type -> com.continusec.verifiabledatastructures.api.LogTreeHashRequest
-	9,  // 21: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.LogInclusionProof:input_type -> com.continusec.verifiabledatastructures.api.LogInclusionProofRequest
-	11, // 22: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.LogConsistencyProof:input_type -> com.continusec.verifiabledatastructures.api.LogConsistencyProofRequest
-	16, // 23: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MapSetValue:input_type -> com.continusec.verifiabledatastructures.api.MapSetValueRequest
-	18, // 24: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MapGetValue:input_type -> com.continusec.verifiabledatastructures.api.MapGetValueRequest
-	7,  // 25: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MapTreeHash:input_type -> com.continusec.verifiabledatastructures.api.MapTreeHashRequest
-	15, // 26: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.LogAddEntry:output_type -> com.continusec.verifiabledatastructures.api.LogAddEntryResponse
-	21, // 27: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.LogFetchEntries:output_type -> com.continusec.verifiabledatastructures.api.LogFetchEntriesResponse
-	6,  // 28: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.LogTreeHash:output_type -> com.continusec.verifiabledatastructures.api.LogTreeHashResponse
-	10, // 29: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.LogInclusionProof:output_type -> com.continusec.verifiabledatastructures.api.LogInclusionProofResponse
-	12, // 30: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.LogConsistencyProof:output_type -> com.continusec.verifiabledatastructures.api.LogConsistencyProofResponse
-	17, // 31: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MapSetValue:output_type -> com.continusec.verifiabledatastructures.api.MapSetValueResponse
-	19, // 32: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MapGetValue:output_type -> com.continusec.verifiabledatastructures.api.MapGetValueResponse
-	8,  // 33: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MapTreeHash:output_type -> com.continusec.verifiabledatastructures.api.MapTreeHashResponse
-	26, // [26:34] is the sub-list for method output_type
-	18, // [18:26] is the sub-list for method input_type
-	18, // [18:18] is the sub-list for extension type_name
-	18, // [18:18] is the sub-list for extension extendee
-	0,  // [0:18] is the sub-list for field type_name
+	3,   // 0: com.continusec.verifiabledatastructures.api.LogRef.account:type_name -> com.continusec.verifiabledatastructures.api.AccountRef
+	0,   // 1: com.continusec.verifiabledatastructures.api.LogRef.log_type:type_name -> com.continusec.verifiabledatastructures.api.LogType
+	1,   // 2: com.continusec.verifiabledatastructures.api.LogRef.hash_algorithm:type_name -> com.continusec.verifiabledatastructures.api.HashAlgorithm
+	5,   // 3: com.continusec.verifiabledatastructures.api.LogRef.reducer:type_name -> com.continusec.verifiabledatastructures.api.MapReducer
+	6,   // 4: com.continusec.verifiabledatastructures.api.MapReducer.rules:type_name -> com.continusec.verifiabledatastructures.api.MapReducerRule
+	3,   // 5: com.continusec.verifiabledatastructures.api.MapRef.account:type_name -> com.continusec.verifiabledatastructures.api.AccountRef
+	1,   // 6: com.continusec.verifiabledatastructures.api.MapRef.hash_algorithm:type_name -> com.continusec.verifiabledatastructures.api.HashAlgorithm
+	3,   // 7: com.continusec.verifiabledatastructures.api.MultimapRef.account:type_name -> com.continusec.verifiabledatastructures.api.AccountRef
+	1,   // 8: com.continusec.verifiabledatastructures.api.MultimapRef.hash_algorithm:type_name -> com.continusec.verifiabledatastructures.api.HashAlgorithm
+	4,   // 9: com.continusec.verifiabledatastructures.api.LogTreeHashRequest.log:type_name -> com.continusec.verifiabledatastructures.api.LogRef
+	31,  // 10: com.continusec.verifiabledatastructures.api.LogTreeHashResponse.signature:type_name -> com.continusec.verifiabledatastructures.api.TreeHeadSignature
+	31,  // 11: com.continusec.verifiabledatastructures.api.LogTreeHashResponse.cosignatures:type_name -> com.continusec.verifiabledatastructures.api.TreeHeadSignature
+	4,   // 12: com.continusec.verifiabledatastructures.api.LogCheckpointRequest.log:type_name -> com.continusec.verifiabledatastructures.api.LogRef
+	7,   // 13: com.continusec.verifiabledatastructures.api.MapListLeavesRequest.map:type_name -> com.continusec.verifiabledatastructures.api.MapRef
+	39,  // 14: com.continusec.verifiabledatastructures.api.MapLeaf.value:type_name -> com.continusec.verifiabledatastructures.api.LeafData
+	14,  // 15: com.continusec.verifiabledatastructures.api.MapListLeavesResponse.leaves:type_name -> com.continusec.verifiabledatastructures.api.MapLeaf
+	15,  // 16: com.continusec.verifiabledatastructures.api.MapListLeavesResponse.boundary:type_name -> com.continusec.verifiabledatastructures.api.MapSubTreeHash
+	1,   // 17: com.continusec.verifiabledatastructures.api.MapListLeavesResponse.hash_algorithm:type_name -> com.continusec.verifiabledatastructures.api.HashAlgorithm
+	7,   // 18: com.continusec.verifiabledatastructures.api.MapDiffRequest.map:type_name -> com.continusec.verifiabledatastructures.api.MapRef
+	39,  // 19: com.continusec.verifiabledatastructures.api.MapDiffEntry.before:type_name -> com.continusec.verifiabledatastructures.api.LeafData
+	39,  // 20: com.continusec.verifiabledatastructures.api.MapDiffEntry.after:type_name -> com.continusec.verifiabledatastructures.api.LeafData
+	18,  // 21: com.continusec.verifiabledatastructures.api.MapDiffResponse.entries:type_name -> com.continusec.verifiabledatastructures.api.MapDiffEntry
+	15,  // 22: com.continusec.verifiabledatastructures.api.MapDiffResponse.audit_path:type_name -> com.continusec.verifiabledatastructures.api.MapSubTreeHash
+	15,  // 23: com.continusec.verifiabledatastructures.api.MapDiffResponse.from_boundary:type_name -> com.continusec.verifiabledatastructures.api.MapSubTreeHash
+	15,  // 24: com.continusec.verifiabledatastructures.api.MapDiffResponse.to_boundary:type_name -> com.continusec.verifiabledatastructures.api.MapSubTreeHash
+	1,   // 25: com.continusec.verifiabledatastructures.api.MapDiffResponse.hash_algorithm:type_name -> com.continusec.verifiabledatastructures.api.HashAlgorithm
+	7,   // 26: com.continusec.verifiabledatastructures.api.MapGetValuesRequest.map:type_name -> com.continusec.verifiabledatastructures.api.MapRef
+	39,  // 27: com.continusec.verifiabledatastructures.api.MapGetValuesResponse.values:type_name -> com.continusec.verifiabledatastructures.api.LeafData
+	15,  // 28: com.continusec.verifiabledatastructures.api.MapGetValuesResponse.audit_path:type_name -> com.continusec.verifiabledatastructures.api.MapSubTreeHash
+	1,   // 29: com.continusec.verifiabledatastructures.api.MapGetValuesResponse.hash_algorithm:type_name -> com.continusec.verifiabledatastructures.api.HashAlgorithm
+	7,   // 30: com.continusec.verifiabledatastructures.api.MapGetKeyHistoryRequest.map:type_name -> com.continusec.verifiabledatastructures.api.MapRef
+	39,  // 31: com.continusec.verifiabledatastructures.api.MapKeyHistoryEntry.value:type_name -> com.continusec.verifiabledatastructures.api.LeafData
+	39,  // 32: com.continusec.verifiabledatastructures.api.MapKeyHistoryEntry.mutation:type_name -> com.continusec.verifiabledatastructures.api.LeafData
+	33,  // 33: com.continusec.verifiabledatastructures.api.MapKeyHistoryEntry.inclusion_proof:type_name -> com.continusec.verifiabledatastructures.api.LogInclusionProofResponse
+	23,  // 34: com.continusec.verifiabledatastructures.api.MapGetKeyHistoryResponse.entries:type_name -> com.continusec.verifiabledatastructures.api.MapKeyHistoryEntry
+	1,   // 35: com.continusec.verifiabledatastructures.api.MapGetKeyHistoryResponse.hash_algorithm:type_name -> com.continusec.verifiabledatastructures.api.HashAlgorithm
+	7,   // 36: com.continusec.verifiabledatastructures.api.MapTreeHashRequest.map:type_name -> com.continusec.verifiabledatastructures.api.MapRef
+	10,  // 37: com.continusec.verifiabledatastructures.api.MapTreeHashResponse.mutation_log:type_name -> com.continusec.verifiabledatastructures.api.LogTreeHashResponse
+	31,  // 38: com.continusec.verifiabledatastructures.api.MapTreeHashResponse.signature:type_name -> com.continusec.verifiabledatastructures.api.TreeHeadSignature
+	4,   // 39: com.continusec.verifiabledatastructures.api.LogGossip.log:type_name -> com.continusec.verifiabledatastructures.api.LogRef
+	10,  // 40: com.continusec.verifiabledatastructures.api.LogGossip.head:type_name -> com.continusec.verifiabledatastructures.api.LogTreeHashResponse
+	7,   // 41: com.continusec.verifiabledatastructures.api.MapGossip.map:type_name -> com.continusec.verifiabledatastructures.api.MapRef
+	26,  // 42: com.continusec.verifiabledatastructures.api.MapGossip.map_head:type_name -> com.continusec.verifiabledatastructures.api.MapTreeHashResponse
+	10,  // 43: com.continusec.verifiabledatastructures.api.MapGossip.tree_head_log_head:type_name -> com.continusec.verifiabledatastructures.api.LogTreeHashResponse
+	27,  // 44: com.continusec.verifiabledatastructures.api.GossipRequest.logs:type_name -> com.continusec.verifiabledatastructures.api.LogGossip
+	28,  // 45: com.continusec.verifiabledatastructures.api.GossipRequest.maps:type_name -> com.continusec.verifiabledatastructures.api.MapGossip
+	27,  // 46: com.continusec.verifiabledatastructures.api.GossipResponse.inconsistent_logs:type_name -> com.continusec.verifiabledatastructures.api.LogGossip
+	28,  // 47: com.continusec.verifiabledatastructures.api.GossipResponse.inconsistent_maps:type_name -> com.continusec.verifiabledatastructures.api.MapGossip
+	4,   // 48: com.continusec.verifiabledatastructures.api.LogInclusionProofRequest.log:type_name -> com.continusec.verifiabledatastructures.api.LogRef
+	1,   // 49: com.continusec.verifiabledatastructures.api.LogInclusionProofResponse.hash_algorithm:type_name -> com.continusec.verifiabledatastructures.api.HashAlgorithm
+	4,   // 50: com.continusec.verifiabledatastructures.api.LogBatchInclusionProofRequest.log:type_name -> com.continusec.verifiabledatastructures.api.LogRef
+	35,  // 51: com.continusec.verifiabledatastructures.api.LogBatchInclusionProofResponse.audit_path:type_name -> com.continusec.verifiabledatastructures.api.LogSubTreeHash
+	1,   // 52: com.continusec.verifiabledatastructures.api.LogBatchInclusionProofResponse.hash_algorithm:type_name -> com.continusec.verifiabledatastructures.api.HashAlgorithm
+	4,   // 53: com.continusec.verifiabledatastructures.api.LogConsistencyProofRequest.log:type_name -> com.continusec.verifiabledatastructures.api.LogRef
+	1,   // 54: com.continusec.verifiabledatastructures.api.LogConsistencyProofResponse.hash_algorithm:type_name -> com.continusec.verifiabledatastructures.api.HashAlgorithm
+	2,   // 55: com.continusec.verifiabledatastructures.api.LeafData.format:type_name -> com.continusec.verifiabledatastructures.api.DataFormat
+	4,   // 56: com.continusec.verifiabledatastructures.api.LogAddEntryRequest.log:type_name -> com.continusec.verifiabledatastructures.api.LogRef
+	39,  // 57: com.continusec.verifiabledatastructures.api.LogAddEntryRequest.value:type_name -> com.continusec.verifiabledatastructures.api.LeafData
+	4,   // 58: com.continusec.verifiabledatastructures.api.LogAddEntriesRequest.log:type_name -> com.continusec.verifiabledatastructures.api.LogRef
+	39,  // 59: com.continusec.verifiabledatastructures.api.LogAddEntriesRequest.values:type_name -> com.continusec.verifiabledatastructures.api.LeafData
+	7,   // 60: com.continusec.verifiabledatastructures.api.MapSetValueRequest.map:type_name -> com.continusec.verifiabledatastructures.api.MapRef
+	60,  // 61: com.continusec.verifiabledatastructures.api.MapSetValueRequest.mutation:type_name -> com.continusec.verifiabledatastructures.api.MapMutation
+	7,   // 62: com.continusec.verifiabledatastructures.api.MapApplyTransactionRequest.map:type_name -> com.continusec.verifiabledatastructures.api.MapRef
+	60,  // 63: com.continusec.verifiabledatastructures.api.MapApplyTransactionRequest.mutations:type_name -> com.continusec.verifiabledatastructures.api.MapMutation
+	7,   // 64: com.continusec.verifiabledatastructures.api.MapGetValueRequest.map:type_name -> com.continusec.verifiabledatastructures.api.MapRef
+	39,  // 65: com.continusec.verifiabledatastructures.api.MapGetValueResponse.value:type_name -> com.continusec.verifiabledatastructures.api.LeafData
+	1,   // 66: com.continusec.verifiabledatastructures.api.MapGetValueResponse.hash_algorithm:type_name -> com.continusec.verifiabledatastructures.api.HashAlgorithm
+	4,   // 67: com.continusec.verifiabledatastructures.api.LogFetchEntriesRequest.log:type_name -> com.continusec.verifiabledatastructures.api.LogRef
+	39,  // 68: com.continusec.verifiabledatastructures.api.LogFetchEntriesResponse.values:type_name -> com.continusec.verifiabledatastructures.api.LeafData
+	4,   // 69: com.continusec.verifiabledatastructures.api.StreamLogEntriesRequest.log:type_name -> com.continusec.verifiabledatastructures.api.LogRef
+	39,  // 70: com.continusec.verifiabledatastructures.api.StreamLogEntriesResponse.value:type_name -> com.continusec.verifiabledatastructures.api.LeafData
+	4,   // 71: com.continusec.verifiabledatastructures.api.WatchLogTreeHeadRequest.log:type_name -> com.continusec.verifiabledatastructures.api.LogRef
+	7,   // 72: com.continusec.verifiabledatastructures.api.WatchMapTreeHeadRequest.map:type_name -> com.continusec.verifiabledatastructures.api.MapRef
+	4,   // 73: com.continusec.verifiabledatastructures.api.LogFetchEntryBundleRequest.log:type_name -> com.continusec.verifiabledatastructures.api.LogRef
+	10,  // 74: com.continusec.verifiabledatastructures.api.LogFetchEntryBundleResponse.head:type_name -> com.continusec.verifiabledatastructures.api.LogTreeHashResponse
+	39,  // 75: com.continusec.verifiabledatastructures.api.LogFetchEntryBundleResponse.value:type_name -> com.continusec.verifiabledatastructures.api.LeafData
+	33,  // 76: com.continusec.verifiabledatastructures.api.LogFetchEntryBundleResponse.inclusion_proof:type_name -> com.continusec.verifiabledatastructures.api.LogInclusionProofResponse
+	38,  // 77: com.continusec.verifiabledatastructures.api.LogFetchEntryBundleResponse.consistency_proof:type_name -> com.continusec.verifiabledatastructures.api.LogConsistencyProofResponse
+	7,   // 78: com.continusec.verifiabledatastructures.api.MapGetValueBundleRequest.map:type_name -> com.continusec.verifiabledatastructures.api.MapRef
+	26,  // 79: com.continusec.verifiabledatastructures.api.MapGetValueBundleResponse.map_head:type_name -> com.continusec.verifiabledatastructures.api.MapTreeHashResponse
+	49,  // 80: com.continusec.verifiabledatastructures.api.MapGetValueBundleResponse.value:type_name -> com.continusec.verifiabledatastructures.api.MapGetValueResponse
+	10,  // 81: com.continusec.verifiabledatastructures.api.MapGetValueBundleResponse.tree_head_log_head:type_name -> com.continusec.verifiabledatastructures.api.LogTreeHashResponse
+	33,  // 82: com.continusec.verifiabledatastructures.api.MapGetValueBundleResponse.tree_head_log_inclusion_proof:type_name -> com.continusec.verifiabledatastructures.api.LogInclusionProofResponse
+	38,  // 83: com.continusec.verifiabledatastructures.api.MapGetValueBundleResponse.mutation_log_consistency_proof:type_name -> com.continusec.verifiabledatastructures.api.LogConsistencyProofResponse
+	38,  // 84: com.continusec.verifiabledatastructures.api.MapGetValueBundleResponse.tree_head_log_consistency_proof:type_name -> com.continusec.verifiabledatastructures.api.LogConsistencyProofResponse
+	39,  // 85: com.continusec.verifiabledatastructures.api.MapMutation.value:type_name -> com.continusec.verifiabledatastructures.api.LeafData
+	60,  // 86: com.continusec.verifiabledatastructures.api.MapMutation.operations:type_name -> com.continusec.verifiabledatastructures.api.MapMutation
+	8,   // 87: com.continusec.verifiabledatastructures.api.MultimapAddValueRequest.multimap:type_name -> com.continusec.verifiabledatastructures.api.MultimapRef
+	39,  // 88: com.continusec.verifiabledatastructures.api.MultimapAddValueRequest.value:type_name -> com.continusec.verifiabledatastructures.api.LeafData
+	8,   // 89: com.continusec.verifiabledatastructures.api.MultimapTreeHashRequest.multimap:type_name -> com.continusec.verifiabledatastructures.api.MultimapRef
+	8,   // 90: com.continusec.verifiabledatastructures.api.MultimapGetValuesRequest.multimap:type_name -> com.continusec.verifiabledatastructures.api.MultimapRef
+	49,  // 91: com.continusec.verifiabledatastructures.api.MultimapGetValuesResponse.key_head:type_name -> com.continusec.verifiabledatastructures.api.MapGetValueResponse
+	39,  // 92: com.continusec.verifiabledatastructures.api.MultimapGetValuesResponse.values:type_name -> com.continusec.verifiabledatastructures.api.LeafData
+	8,   // 93: com.continusec.verifiabledatastructures.api.MultimapInclusionProofRequest.multimap:type_name -> com.continusec.verifiabledatastructures.api.MultimapRef
+	49,  // 94: com.continusec.verifiabledatastructures.api.MultimapInclusionProofResponse.key_head:type_name -> com.continusec.verifiabledatastructures.api.MapGetValueResponse
+	33,  // 95: com.continusec.verifiabledatastructures.api.MultimapInclusionProofResponse.proof:type_name -> com.continusec.verifiabledatastructures.api.LogInclusionProofResponse
+	8,   // 96: com.continusec.verifiabledatastructures.api.MultimapKeyConsistencyProofRequest.multimap:type_name -> com.continusec.verifiabledatastructures.api.MultimapRef
+	49,  // 97: com.continusec.verifiabledatastructures.api.MultimapKeyConsistencyProofResponse.from_key_head:type_name -> com.continusec.verifiabledatastructures.api.MapGetValueResponse
+	49,  // 98: com.continusec.verifiabledatastructures.api.MultimapKeyConsistencyProofResponse.key_head:type_name -> com.continusec.verifiabledatastructures.api.MapGetValueResponse
+	38,  // 99: com.continusec.verifiabledatastructures.api.MultimapKeyConsistencyProofResponse.proof:type_name -> com.continusec.verifiabledatastructures.api.LogConsistencyProofResponse
+	40,  // 100: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.LogAddEntry:input_type -> com.continusec.verifiabledatastructures.api.LogAddEntryRequest
+	42,  // 101: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.LogAddEntries:input_type -> com.continusec.verifiabledatastructures.api.LogAddEntriesRequest
+	50,  // 102: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.LogFetchEntries:input_type -> com.continusec.verifiabledatastructures.api.LogFetchEntriesRequest
+	52,  // 103: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.StreamLogEntries:input_type -> com.continusec.verifiabledatastructures.api.StreamLogEntriesRequest
+	56,  // 104: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.LogFetchEntryBundle:input_type -> com.continusec.verifiabledatastructures.api.LogFetchEntryBundleRequest
+	9,   // 105: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.LogTreeHash:input_type -> com.continusec.verifiabledatastructures.api.LogTreeHashRequest
+	32,  // 106: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.LogInclusionProof:input_type -> com.continusec.verifiabledatastructures.api.LogInclusionProofRequest
+	34,  // 107: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.LogBatchInclusionProof:input_type -> com.continusec.verifiabledatastructures.api.LogBatchInclusionProofRequest
+	37,  // 108: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.LogConsistencyProof:input_type -> com.continusec.verifiabledatastructures.api.LogConsistencyProofRequest
+	11,  // 109: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.LogCheckpoint:input_type -> com.continusec.verifiabledatastructures.api.LogCheckpointRequest
+	54,  // 110: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.WatchLogTreeHead:input_type -> com.continusec.verifiabledatastructures.api.WatchLogTreeHeadRequest
+	44,  // 111: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MapSetValue:input_type -> com.continusec.verifiabledatastructures.api.MapSetValueRequest
+	46,  // 112: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MapApplyTransaction:input_type -> com.continusec.verifiabledatastructures.api.MapApplyTransactionRequest
+	48,  // 113: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MapGetValue:input_type -> com.continusec.verifiabledatastructures.api.MapGetValueRequest
+	58,  // 114: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MapGetValueBundle:input_type -> com.continusec.verifiabledatastructures.api.MapGetValueBundleRequest
+	20,  // 115: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MapGetValues:input_type -> com.continusec.verifiabledatastructures.api.MapGetValuesRequest
+	22,  // 116: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MapGetKeyHistory:input_type -> com.continusec.verifiabledatastructures.api.MapGetKeyHistoryRequest
+	13,  // 117: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MapListLeaves:input_type -> com.continusec.verifiabledatastructures.api.MapListLeavesRequest
+	17,  // 118: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MapDiff:input_type -> com.continusec.verifiabledatastructures.api.MapDiffRequest
+	25,  // 119: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MapTreeHash:input_type -> com.continusec.verifiabledatastructures.api.MapTreeHashRequest
+	55,  // 120: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.WatchMapTreeHead:input_type -> com.continusec.verifiabledatastructures.api.WatchMapTreeHeadRequest
+	61,  // 121: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MultimapAddValue:input_type -> com.continusec.verifiabledatastructures.api.MultimapAddValueRequest
+	64,  // 122: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MultimapGetValues:input_type -> com.continusec.verifiabledatastructures.api.MultimapGetValuesRequest
+	66,  // 123: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MultimapInclusionProof:input_type -> com.continusec.verifiabledatastructures.api.MultimapInclusionProofRequest
+	68,  // 124: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MultimapKeyConsistencyProof:input_type -> com.continusec.verifiabledatastructures.api.MultimapKeyConsistencyProofRequest
+	63,  // 125: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MultimapTreeHash:input_type -> com.continusec.verifiabledatastructures.api.MultimapTreeHashRequest
+	29,  // 126: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.Gossip:input_type -> com.continusec.verifiabledatastructures.api.GossipRequest
+	41,  // 127: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.LogAddEntry:output_type -> com.continusec.verifiabledatastructures.api.LogAddEntryResponse
+	43,  // 128: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.LogAddEntries:output_type -> com.continusec.verifiabledatastructures.api.LogAddEntriesResponse
+	51,  // 129: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.LogFetchEntries:output_type -> com.continusec.verifiabledatastructures.api.LogFetchEntriesResponse
+	53,  // 130: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.StreamLogEntries:output_type -> com.continusec.verifiabledatastructures.api.StreamLogEntriesResponse
+	57,  // 131: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.LogFetchEntryBundle:output_type -> com.continusec.verifiabledatastructures.api.LogFetchEntryBundleResponse
+	10,  // 132: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.LogTreeHash:output_type -> com.continusec.verifiabledatastructures.api.LogTreeHashResponse
+	33,  // 133: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.LogInclusionProof:output_type -> com.continusec.verifiabledatastructures.api.LogInclusionProofResponse
+	36,  // 134: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.LogBatchInclusionProof:output_type -> com.continusec.verifiabledatastructures.api.LogBatchInclusionProofResponse
+	38,  // 135: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.LogConsistencyProof:output_type -> com.continusec.verifiabledatastructures.api.LogConsistencyProofResponse
+	12,  // 136: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.LogCheckpoint:output_type -> com.continusec.verifiabledatastructures.api.LogCheckpointResponse
+	10,  // 137: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.WatchLogTreeHead:output_type -> com.continusec.verifiabledatastructures.api.LogTreeHashResponse
+	45,  // 138: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MapSetValue:output_type -> com.continusec.verifiabledatastructures.api.MapSetValueResponse
+	47,  // 139: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MapApplyTransaction:output_type -> com.continusec.verifiabledatastructures.api.MapApplyTransactionResponse
+	49,  // 140: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MapGetValue:output_type -> com.continusec.verifiabledatastructures.api.MapGetValueResponse
+	59,  // 141: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MapGetValueBundle:output_type -> com.continusec.verifiabledatastructures.api.MapGetValueBundleResponse
+	21,  // 142: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MapGetValues:output_type -> com.continusec.verifiabledatastructures.api.MapGetValuesResponse
+	24,  // 143: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MapGetKeyHistory:output_type -> com.continusec.verifiabledatastructures.api.MapGetKeyHistoryResponse
+	16,  // 144: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MapListLeaves:output_type -> com.continusec.verifiabledatastructures.api.MapListLeavesResponse
+	19,  // 145: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MapDiff:output_type -> com.continusec.verifiabledatastructures.api.MapDiffResponse
+	26,  // 146: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MapTreeHash:output_type -> com.continusec.verifiabledatastructures.api.MapTreeHashResponse
+	26,  // 147: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.WatchMapTreeHead:output_type -> com.continusec.verifiabledatastructures.api.MapTreeHashResponse
+	62,  // 148: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MultimapAddValue:output_type -> com.continusec.verifiabledatastructures.api.MultimapAddValueResponse
+	65,  // 149: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MultimapGetValues:output_type -> com.continusec.verifiabledatastructures.api.MultimapGetValuesResponse
+	67,  // 150: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MultimapInclusionProof:output_type -> com.continusec.verifiabledatastructures.api.MultimapInclusionProofResponse
+	69,  // 151: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MultimapKeyConsistencyProof:output_type -> com.continusec.verifiabledatastructures.api.MultimapKeyConsistencyProofResponse
+	26,  // 152: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.MultimapTreeHash:output_type -> com.continusec.verifiabledatastructures.api.MapTreeHashResponse
+	30,  // 153: com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService.Gossip:output_type -> com.continusec.verifiabledatastructures.api.GossipResponse
+	127, // [127:154] is the sub-list for method output_type
+	100, // [100:127] is the sub-list for method input_type
+	100, // [100:100] is the sub-list for extension type_name
+	100, // [100:100] is the sub-list for extension extendee
+	0,   // [0:100] is the sub-list for field type_name
 }
 
 func init() { file_api_proto_init() }
@@ -1501,8 +5077,8 @@ func file_api_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_proto_rawDesc), len(file_api_proto_rawDesc)),
-			NumEnums:      2,
-			NumMessages:   21,
+			NumEnums:      3,
+			NumMessages:   67,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -36,14 +36,33 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	VerifiableDataStructuresService_LogAddEntry_FullMethodName         = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/LogAddEntry"
-	VerifiableDataStructuresService_LogFetchEntries_FullMethodName     = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/LogFetchEntries"
-	VerifiableDataStructuresService_LogTreeHash_FullMethodName         = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/LogTreeHash"
-	VerifiableDataStructuresService_LogInclusionProof_FullMethodName   = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/LogInclusionProof"
-	VerifiableDataStructuresService_LogConsistencyProof_FullMethodName = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/LogConsistencyProof"
-	VerifiableDataStructuresService_MapSetValue_FullMethodName         = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/MapSetValue"
-	VerifiableDataStructuresService_MapGetValue_FullMethodName         = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/MapGetValue"
-	VerifiableDataStructuresService_MapTreeHash_FullMethodName         = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/MapTreeHash"
+	VerifiableDataStructuresService_LogAddEntry_FullMethodName                 = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/LogAddEntry"
+	VerifiableDataStructuresService_LogAddEntries_FullMethodName               = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/LogAddEntries"
+	VerifiableDataStructuresService_LogFetchEntries_FullMethodName             = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/LogFetchEntries"
+	VerifiableDataStructuresService_StreamLogEntries_FullMethodName            = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/StreamLogEntries"
+	VerifiableDataStructuresService_LogFetchEntryBundle_FullMethodName         = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/LogFetchEntryBundle"
+	VerifiableDataStructuresService_LogTreeHash_FullMethodName                 = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/LogTreeHash"
+	VerifiableDataStructuresService_LogInclusionProof_FullMethodName           = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/LogInclusionProof"
+	VerifiableDataStructuresService_LogBatchInclusionProof_FullMethodName      = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/LogBatchInclusionProof"
+	VerifiableDataStructuresService_LogConsistencyProof_FullMethodName         = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/LogConsistencyProof"
+	VerifiableDataStructuresService_LogCheckpoint_FullMethodName               = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/LogCheckpoint"
+	VerifiableDataStructuresService_WatchLogTreeHead_FullMethodName            = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/WatchLogTreeHead"
+	VerifiableDataStructuresService_MapSetValue_FullMethodName                 = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/MapSetValue"
+	VerifiableDataStructuresService_MapApplyTransaction_FullMethodName         = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/MapApplyTransaction"
+	VerifiableDataStructuresService_MapGetValue_FullMethodName                 = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/MapGetValue"
+	VerifiableDataStructuresService_MapGetValueBundle_FullMethodName           = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/MapGetValueBundle"
+	VerifiableDataStructuresService_MapGetValues_FullMethodName                = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/MapGetValues"
+	VerifiableDataStructuresService_MapGetKeyHistory_FullMethodName            = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/MapGetKeyHistory"
+	VerifiableDataStructuresService_MapListLeaves_FullMethodName               = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/MapListLeaves"
+	VerifiableDataStructuresService_MapDiff_FullMethodName                     = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/MapDiff"
+	VerifiableDataStructuresService_MapTreeHash_FullMethodName                 = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/MapTreeHash"
+	VerifiableDataStructuresService_WatchMapTreeHead_FullMethodName            = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/WatchMapTreeHead"
+	VerifiableDataStructuresService_MultimapAddValue_FullMethodName            = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/MultimapAddValue"
+	VerifiableDataStructuresService_MultimapGetValues_FullMethodName           = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/MultimapGetValues"
+	VerifiableDataStructuresService_MultimapInclusionProof_FullMethodName      = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/MultimapInclusionProof"
+	VerifiableDataStructuresService_MultimapKeyConsistencyProof_FullMethodName = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/MultimapKeyConsistencyProof"
+	VerifiableDataStructuresService_MultimapTreeHash_FullMethodName            = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/MultimapTreeHash"
+	VerifiableDataStructuresService_Gossip_FullMethodName                      = "/com.continusec.verifiabledatastructures.api.VerifiableDataStructuresService/Gossip"
 )
 
 // VerifiableDataStructuresServiceClient is the client API for VerifiableDataStructuresService service.
@@ -51,13 +70,32 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type VerifiableDataStructuresServiceClient interface {
 	LogAddEntry(ctx context.Context, in *LogAddEntryRequest, opts ...grpc.CallOption) (*LogAddEntryResponse, error)
+	LogAddEntries(ctx context.Context, in *LogAddEntriesRequest, opts ...grpc.CallOption) (*LogAddEntriesResponse, error)
 	LogFetchEntries(ctx context.Context, in *LogFetchEntriesRequest, opts ...grpc.CallOption) (*LogFetchEntriesResponse, error)
+	StreamLogEntries(ctx context.Context, in *StreamLogEntriesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamLogEntriesResponse], error)
+	LogFetchEntryBundle(ctx context.Context, in *LogFetchEntryBundleRequest, opts ...grpc.CallOption) (*LogFetchEntryBundleResponse, error)
 	LogTreeHash(ctx context.Context, in *LogTreeHashRequest, opts ...grpc.CallOption) (*LogTreeHashResponse, error)
 	LogInclusionProof(ctx context.Context, in *LogInclusionProofRequest, opts ...grpc.CallOption) (*LogInclusionProofResponse, error)
+	LogBatchInclusionProof(ctx context.Context, in *LogBatchInclusionProofRequest, opts ...grpc.CallOption) (*LogBatchInclusionProofResponse, error)
 	LogConsistencyProof(ctx context.Context, in *LogConsistencyProofRequest, opts ...grpc.CallOption) (*LogConsistencyProofResponse, error)
+	LogCheckpoint(ctx context.Context, in *LogCheckpointRequest, opts ...grpc.CallOption) (*LogCheckpointResponse, error)
+	WatchLogTreeHead(ctx context.Context, in *WatchLogTreeHeadRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LogTreeHashResponse], error)
 	MapSetValue(ctx context.Context, in *MapSetValueRequest, opts ...grpc.CallOption) (*MapSetValueResponse, error)
+	MapApplyTransaction(ctx context.Context, in *MapApplyTransactionRequest, opts ...grpc.CallOption) (*MapApplyTransactionResponse, error)
 	MapGetValue(ctx context.Context, in *MapGetValueRequest, opts ...grpc.CallOption) (*MapGetValueResponse, error)
+	MapGetValueBundle(ctx context.Context, in *MapGetValueBundleRequest, opts ...grpc.CallOption) (*MapGetValueBundleResponse, error)
+	MapGetValues(ctx context.Context, in *MapGetValuesRequest, opts ...grpc.CallOption) (*MapGetValuesResponse, error)
+	MapGetKeyHistory(ctx context.Context, in *MapGetKeyHistoryRequest, opts ...grpc.CallOption) (*MapGetKeyHistoryResponse, error)
+	MapListLeaves(ctx context.Context, in *MapListLeavesRequest, opts ...grpc.CallOption) (*MapListLeavesResponse, error)
+	MapDiff(ctx context.Context, in *MapDiffRequest, opts ...grpc.CallOption) (*MapDiffResponse, error)
 	MapTreeHash(ctx context.Context, in *MapTreeHashRequest, opts ...grpc.CallOption) (*MapTreeHashResponse, error)
+	WatchMapTreeHead(ctx context.Context, in *WatchMapTreeHeadRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[MapTreeHashResponse], error)
+	MultimapAddValue(ctx context.Context, in *MultimapAddValueRequest, opts ...grpc.CallOption) (*MultimapAddValueResponse, error)
+	MultimapGetValues(ctx context.Context, in *MultimapGetValuesRequest, opts ...grpc.CallOption) (*MultimapGetValuesResponse, error)
+	MultimapInclusionProof(ctx context.Context, in *MultimapInclusionProofRequest, opts ...grpc.CallOption) (*MultimapInclusionProofResponse, error)
+	MultimapKeyConsistencyProof(ctx context.Context, in *MultimapKeyConsistencyProofRequest, opts ...grpc.CallOption) (*MultimapKeyConsistencyProofResponse, error)
+	MultimapTreeHash(ctx context.Context, in *MultimapTreeHashRequest, opts ...grpc.CallOption) (*MapTreeHashResponse, error)
+	Gossip(ctx context.Context, in *GossipRequest, opts ...grpc.CallOption) (*GossipResponse, error)
 }
 
 type verifiableDataStructuresServiceClient struct {
@@ -78,6 +116,16 @@ func (c *verifiableDataStructuresServiceClient) LogAddEntry(ctx context.Context,
 	return out, nil
 }
 
+func (c *verifiableDataStructuresServiceClient) LogAddEntries(ctx context.Context, in *LogAddEntriesRequest, opts ...grpc.CallOption) (*LogAddEntriesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LogAddEntriesResponse)
+	err := c.cc.Invoke(ctx, VerifiableDataStructuresService_LogAddEntries_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *verifiableDataStructuresServiceClient) LogFetchEntries(ctx context.Context, in *LogFetchEntriesRequest, opts ...grpc.CallOption) (*LogFetchEntriesResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(LogFetchEntriesResponse)
@@ -88,6 +136,35 @@ func (c *verifiableDataStructuresServiceClient) LogFetchEntries(ctx context.Cont
 	return out, nil
 }
 
+func (c *verifiableDataStructuresServiceClient) StreamLogEntries(ctx context.Context, in *StreamLogEntriesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[StreamLogEntriesResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &VerifiableDataStructuresService_ServiceDesc.Streams[0], VerifiableDataStructuresService_StreamLogEntries_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamLogEntriesRequest, StreamLogEntriesResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VerifiableDataStructuresService_StreamLogEntriesClient = grpc.ServerStreamingClient[StreamLogEntriesResponse]
+
+func (c *verifiableDataStructuresServiceClient) LogFetchEntryBundle(ctx context.Context, in *LogFetchEntryBundleRequest, opts ...grpc.CallOption) (*LogFetchEntryBundleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LogFetchEntryBundleResponse)
+	err := c.cc.Invoke(ctx, VerifiableDataStructuresService_LogFetchEntryBundle_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *verifiableDataStructuresServiceClient) LogTreeHash(ctx context.Context, in *LogTreeHashRequest, opts ...grpc.CallOption) (*LogTreeHashResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(LogTreeHashResponse)
@@ -108,6 +185,16 @@ func (c *verifiableDataStructuresServiceClient) LogInclusionProof(ctx context.Co
 	return out, nil
 }
 
+func (c *verifiableDataStructuresServiceClient) LogBatchInclusionProof(ctx context.Context, in *LogBatchInclusionProofRequest, opts ...grpc.CallOption) (*LogBatchInclusionProofResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LogBatchInclusionProofResponse)
+	err := c.cc.Invoke(ctx, VerifiableDataStructuresService_LogBatchInclusionProof_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *verifiableDataStructuresServiceClient) LogConsistencyProof(ctx context.Context, in *LogConsistencyProofRequest, opts ...grpc.CallOption) (*LogConsistencyProofResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(LogConsistencyProofResponse)
@@ -118,6 +205,35 @@ func (c *verifiableDataStructuresServiceClient) LogConsistencyProof(ctx context.
 	return out, nil
 }
 
+func (c *verifiableDataStructuresServiceClient) LogCheckpoint(ctx context.Context, in *LogCheckpointRequest, opts ...grpc.CallOption) (*LogCheckpointResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LogCheckpointResponse)
+	err := c.cc.Invoke(ctx, VerifiableDataStructuresService_LogCheckpoint_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *verifiableDataStructuresServiceClient) WatchLogTreeHead(ctx context.Context, in *WatchLogTreeHeadRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LogTreeHashResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &VerifiableDataStructuresService_ServiceDesc.Streams[1], VerifiableDataStructuresService_WatchLogTreeHead_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchLogTreeHeadRequest, LogTreeHashResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VerifiableDataStructuresService_WatchLogTreeHeadClient = grpc.ServerStreamingClient[LogTreeHashResponse]
+
 func (c *verifiableDataStructuresServiceClient) MapSetValue(ctx context.Context, in *MapSetValueRequest, opts ...grpc.CallOption) (*MapSetValueResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(MapSetValueResponse)
@@ -128,6 +244,16 @@ func (c *verifiableDataStructuresServiceClient) MapSetValue(ctx context.Context,
 	return out, nil
 }
 
+func (c *verifiableDataStructuresServiceClient) MapApplyTransaction(ctx context.Context, in *MapApplyTransactionRequest, opts ...grpc.CallOption) (*MapApplyTransactionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MapApplyTransactionResponse)
+	err := c.cc.Invoke(ctx, VerifiableDataStructuresService_MapApplyTransaction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *verifiableDataStructuresServiceClient) MapGetValue(ctx context.Context, in *MapGetValueRequest, opts ...grpc.CallOption) (*MapGetValueResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(MapGetValueResponse)
@@ -138,6 +264,56 @@ func (c *verifiableDataStructuresServiceClient) MapGetValue(ctx context.Context,
 	return out, nil
 }
 
+func (c *verifiableDataStructuresServiceClient) MapGetValueBundle(ctx context.Context, in *MapGetValueBundleRequest, opts ...grpc.CallOption) (*MapGetValueBundleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MapGetValueBundleResponse)
+	err := c.cc.Invoke(ctx, VerifiableDataStructuresService_MapGetValueBundle_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *verifiableDataStructuresServiceClient) MapGetValues(ctx context.Context, in *MapGetValuesRequest, opts ...grpc.CallOption) (*MapGetValuesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MapGetValuesResponse)
+	err := c.cc.Invoke(ctx, VerifiableDataStructuresService_MapGetValues_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *verifiableDataStructuresServiceClient) MapGetKeyHistory(ctx context.Context, in *MapGetKeyHistoryRequest, opts ...grpc.CallOption) (*MapGetKeyHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MapGetKeyHistoryResponse)
+	err := c.cc.Invoke(ctx, VerifiableDataStructuresService_MapGetKeyHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *verifiableDataStructuresServiceClient) MapListLeaves(ctx context.Context, in *MapListLeavesRequest, opts ...grpc.CallOption) (*MapListLeavesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MapListLeavesResponse)
+	err := c.cc.Invoke(ctx, VerifiableDataStructuresService_MapListLeaves_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *verifiableDataStructuresServiceClient) MapDiff(ctx context.Context, in *MapDiffRequest, opts ...grpc.CallOption) (*MapDiffResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MapDiffResponse)
+	err := c.cc.Invoke(ctx, VerifiableDataStructuresService_MapDiff_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *verifiableDataStructuresServiceClient) MapTreeHash(ctx context.Context, in *MapTreeHashRequest, opts ...grpc.CallOption) (*MapTreeHashResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(MapTreeHashResponse)
@@ -148,18 +324,116 @@ func (c *verifiableDataStructuresServiceClient) MapTreeHash(ctx context.Context,
 	return out, nil
 }
 
+func (c *verifiableDataStructuresServiceClient) WatchMapTreeHead(ctx context.Context, in *WatchMapTreeHeadRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[MapTreeHashResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &VerifiableDataStructuresService_ServiceDesc.Streams[2], VerifiableDataStructuresService_WatchMapTreeHead_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchMapTreeHeadRequest, MapTreeHashResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VerifiableDataStructuresService_WatchMapTreeHeadClient = grpc.ServerStreamingClient[MapTreeHashResponse]
+
+func (c *verifiableDataStructuresServiceClient) MultimapAddValue(ctx context.Context, in *MultimapAddValueRequest, opts ...grpc.CallOption) (*MultimapAddValueResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MultimapAddValueResponse)
+	err := c.cc.Invoke(ctx, VerifiableDataStructuresService_MultimapAddValue_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *verifiableDataStructuresServiceClient) MultimapGetValues(ctx context.Context, in *MultimapGetValuesRequest, opts ...grpc.CallOption) (*MultimapGetValuesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MultimapGetValuesResponse)
+	err := c.cc.Invoke(ctx, VerifiableDataStructuresService_MultimapGetValues_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *verifiableDataStructuresServiceClient) MultimapInclusionProof(ctx context.Context, in *MultimapInclusionProofRequest, opts ...grpc.CallOption) (*MultimapInclusionProofResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MultimapInclusionProofResponse)
+	err := c.cc.Invoke(ctx, VerifiableDataStructuresService_MultimapInclusionProof_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *verifiableDataStructuresServiceClient) MultimapKeyConsistencyProof(ctx context.Context, in *MultimapKeyConsistencyProofRequest, opts ...grpc.CallOption) (*MultimapKeyConsistencyProofResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MultimapKeyConsistencyProofResponse)
+	err := c.cc.Invoke(ctx, VerifiableDataStructuresService_MultimapKeyConsistencyProof_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *verifiableDataStructuresServiceClient) MultimapTreeHash(ctx context.Context, in *MultimapTreeHashRequest, opts ...grpc.CallOption) (*MapTreeHashResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MapTreeHashResponse)
+	err := c.cc.Invoke(ctx, VerifiableDataStructuresService_MultimapTreeHash_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *verifiableDataStructuresServiceClient) Gossip(ctx context.Context, in *GossipRequest, opts ...grpc.CallOption) (*GossipResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GossipResponse)
+	err := c.cc.Invoke(ctx, VerifiableDataStructuresService_Gossip_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // VerifiableDataStructuresServiceServer is the server API for VerifiableDataStructuresService service.
 // All implementations must embed UnimplementedVerifiableDataStructuresServiceServer
 // for forward compatibility.
 type VerifiableDataStructuresServiceServer interface {
 	LogAddEntry(context.Context, *LogAddEntryRequest) (*LogAddEntryResponse, error)
+	LogAddEntries(context.Context, *LogAddEntriesRequest) (*LogAddEntriesResponse, error)
 	LogFetchEntries(context.Context, *LogFetchEntriesRequest) (*LogFetchEntriesResponse, error)
+	StreamLogEntries(*StreamLogEntriesRequest, grpc.ServerStreamingServer[StreamLogEntriesResponse]) error
+	LogFetchEntryBundle(context.Context, *LogFetchEntryBundleRequest) (*LogFetchEntryBundleResponse, error)
 	LogTreeHash(context.Context, *LogTreeHashRequest) (*LogTreeHashResponse, error)
 	LogInclusionProof(context.Context, *LogInclusionProofRequest) (*LogInclusionProofResponse, error)
+	LogBatchInclusionProof(context.Context, *LogBatchInclusionProofRequest) (*LogBatchInclusionProofResponse, error)
 	LogConsistencyProof(context.Context, *LogConsistencyProofRequest) (*LogConsistencyProofResponse, error)
+	LogCheckpoint(context.Context, *LogCheckpointRequest) (*LogCheckpointResponse, error)
+	WatchLogTreeHead(*WatchLogTreeHeadRequest, grpc.ServerStreamingServer[LogTreeHashResponse]) error
 	MapSetValue(context.Context, *MapSetValueRequest) (*MapSetValueResponse, error)
+	MapApplyTransaction(context.Context, *MapApplyTransactionRequest) (*MapApplyTransactionResponse, error)
 	MapGetValue(context.Context, *MapGetValueRequest) (*MapGetValueResponse, error)
+	MapGetValueBundle(context.Context, *MapGetValueBundleRequest) (*MapGetValueBundleResponse, error)
+	MapGetValues(context.Context, *MapGetValuesRequest) (*MapGetValuesResponse, error)
+	MapGetKeyHistory(context.Context, *MapGetKeyHistoryRequest) (*MapGetKeyHistoryResponse, error)
+	MapListLeaves(context.Context, *MapListLeavesRequest) (*MapListLeavesResponse, error)
+	MapDiff(context.Context, *MapDiffRequest) (*MapDiffResponse, error)
 	MapTreeHash(context.Context, *MapTreeHashRequest) (*MapTreeHashResponse, error)
+	WatchMapTreeHead(*WatchMapTreeHeadRequest, grpc.ServerStreamingServer[MapTreeHashResponse]) error
+	MultimapAddValue(context.Context, *MultimapAddValueRequest) (*MultimapAddValueResponse, error)
+	MultimapGetValues(context.Context, *MultimapGetValuesRequest) (*MultimapGetValuesResponse, error)
+	MultimapInclusionProof(context.Context, *MultimapInclusionProofRequest) (*MultimapInclusionProofResponse, error)
+	MultimapKeyConsistencyProof(context.Context, *MultimapKeyConsistencyProofRequest) (*MultimapKeyConsistencyProofResponse, error)
+	MultimapTreeHash(context.Context, *MultimapTreeHashRequest) (*MapTreeHashResponse, error)
+	Gossip(context.Context, *GossipRequest) (*GossipResponse, error)
 	mustEmbedUnimplementedVerifiableDataStructuresServiceServer()
 }
 
@@ -173,27 +447,84 @@ type UnimplementedVerifiableDataStructuresServiceServer struct{}
 func (UnimplementedVerifiableDataStructuresServiceServer) LogAddEntry(context.Context, *LogAddEntryRequest) (*LogAddEntryResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method LogAddEntry not implemented")
 }
+func (UnimplementedVerifiableDataStructuresServiceServer) LogAddEntries(context.Context, *LogAddEntriesRequest) (*LogAddEntriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LogAddEntries not implemented")
+}
 func (UnimplementedVerifiableDataStructuresServiceServer) LogFetchEntries(context.Context, *LogFetchEntriesRequest) (*LogFetchEntriesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method LogFetchEntries not implemented")
 }
+func (UnimplementedVerifiableDataStructuresServiceServer) StreamLogEntries(*StreamLogEntriesRequest, grpc.ServerStreamingServer[StreamLogEntriesResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamLogEntries not implemented")
+}
+func (UnimplementedVerifiableDataStructuresServiceServer) LogFetchEntryBundle(context.Context, *LogFetchEntryBundleRequest) (*LogFetchEntryBundleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LogFetchEntryBundle not implemented")
+}
 func (UnimplementedVerifiableDataStructuresServiceServer) LogTreeHash(context.Context, *LogTreeHashRequest) (*LogTreeHashResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method LogTreeHash not implemented")
 }
 func (UnimplementedVerifiableDataStructuresServiceServer) LogInclusionProof(context.Context, *LogInclusionProofRequest) (*LogInclusionProofResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method LogInclusionProof not implemented")
 }
+func (UnimplementedVerifiableDataStructuresServiceServer) LogBatchInclusionProof(context.Context, *LogBatchInclusionProofRequest) (*LogBatchInclusionProofResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LogBatchInclusionProof not implemented")
+}
 func (UnimplementedVerifiableDataStructuresServiceServer) LogConsistencyProof(context.Context, *LogConsistencyProofRequest) (*LogConsistencyProofResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method LogConsistencyProof not implemented")
 }
+func (UnimplementedVerifiableDataStructuresServiceServer) LogCheckpoint(context.Context, *LogCheckpointRequest) (*LogCheckpointResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LogCheckpoint not implemented")
+}
+func (UnimplementedVerifiableDataStructuresServiceServer) WatchLogTreeHead(*WatchLogTreeHeadRequest, grpc.ServerStreamingServer[LogTreeHashResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchLogTreeHead not implemented")
+}
 func (UnimplementedVerifiableDataStructuresServiceServer) MapSetValue(context.Context, *MapSetValueRequest) (*MapSetValueResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method MapSetValue not implemented")
 }
+func (UnimplementedVerifiableDataStructuresServiceServer) MapApplyTransaction(context.Context, *MapApplyTransactionRequest) (*MapApplyTransactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MapApplyTransaction not implemented")
+}
 func (UnimplementedVerifiableDataStructuresServiceServer) MapGetValue(context.Context, *MapGetValueRequest) (*MapGetValueResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method MapGetValue not implemented")
 }
+func (UnimplementedVerifiableDataStructuresServiceServer) MapGetValueBundle(context.Context, *MapGetValueBundleRequest) (*MapGetValueBundleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MapGetValueBundle not implemented")
+}
+func (UnimplementedVerifiableDataStructuresServiceServer) MapGetValues(context.Context, *MapGetValuesRequest) (*MapGetValuesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MapGetValues not implemented")
+}
+func (UnimplementedVerifiableDataStructuresServiceServer) MapGetKeyHistory(context.Context, *MapGetKeyHistoryRequest) (*MapGetKeyHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MapGetKeyHistory not implemented")
+}
+func (UnimplementedVerifiableDataStructuresServiceServer) MapListLeaves(context.Context, *MapListLeavesRequest) (*MapListLeavesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MapListLeaves not implemented")
+}
+func (UnimplementedVerifiableDataStructuresServiceServer) MapDiff(context.Context, *MapDiffRequest) (*MapDiffResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MapDiff not implemented")
+}
 func (UnimplementedVerifiableDataStructuresServiceServer) MapTreeHash(context.Context, *MapTreeHashRequest) (*MapTreeHashResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method MapTreeHash not implemented")
 }
+func (UnimplementedVerifiableDataStructuresServiceServer) WatchMapTreeHead(*WatchMapTreeHeadRequest, grpc.ServerStreamingServer[MapTreeHashResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchMapTreeHead not implemented")
+}
+func (UnimplementedVerifiableDataStructuresServiceServer) MultimapAddValue(context.Context, *MultimapAddValueRequest) (*MultimapAddValueResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MultimapAddValue not implemented")
+}
+func (UnimplementedVerifiableDataStructuresServiceServer) MultimapGetValues(context.Context, *MultimapGetValuesRequest) (*MultimapGetValuesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MultimapGetValues not implemented")
+}
+func (UnimplementedVerifiableDataStructuresServiceServer) MultimapInclusionProof(context.Context, *MultimapInclusionProofRequest) (*MultimapInclusionProofResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MultimapInclusionProof not implemented")
+}
+func (UnimplementedVerifiableDataStructuresServiceServer) MultimapKeyConsistencyProof(context.Context, *MultimapKeyConsistencyProofRequest) (*MultimapKeyConsistencyProofResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MultimapKeyConsistencyProof not implemented")
+}
+func (UnimplementedVerifiableDataStructuresServiceServer) MultimapTreeHash(context.Context, *MultimapTreeHashRequest) (*MapTreeHashResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MultimapTreeHash not implemented")
+}
+func (UnimplementedVerifiableDataStructuresServiceServer) Gossip(context.Context, *GossipRequest) (*GossipResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Gossip not implemented")
+}
 func (UnimplementedVerifiableDataStructuresServiceServer) mustEmbedUnimplementedVerifiableDataStructuresServiceServer() {
 }
 func (UnimplementedVerifiableDataStructuresServiceServer) testEmbeddedByValue() {}
@@ -234,6 +565,24 @@ func _VerifiableDataStructuresService_LogAddEntry_Handler(srv interface{}, ctx c
 	return interceptor(ctx, in, info, handler)
 }
 
+func _VerifiableDataStructuresService_LogAddEntries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LogAddEntriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VerifiableDataStructuresServiceServer).LogAddEntries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VerifiableDataStructuresService_LogAddEntries_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VerifiableDataStructuresServiceServer).LogAddEntries(ctx, req.(*LogAddEntriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _VerifiableDataStructuresService_LogFetchEntries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(LogFetchEntriesRequest)
 	if err := dec(in); err != nil {
@@ -252,6 +601,35 @@ func _VerifiableDataStructuresService_LogFetchEntries_Handler(srv interface{}, c
 	return interceptor(ctx, in, info, handler)
 }
 
+func _VerifiableDataStructuresService_StreamLogEntries_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamLogEntriesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(VerifiableDataStructuresServiceServer).StreamLogEntries(m, &grpc.GenericServerStream[StreamLogEntriesRequest, StreamLogEntriesResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VerifiableDataStructuresService_StreamLogEntriesServer = grpc.ServerStreamingServer[StreamLogEntriesResponse]
+
+func _VerifiableDataStructuresService_LogFetchEntryBundle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LogFetchEntryBundleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VerifiableDataStructuresServiceServer).LogFetchEntryBundle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VerifiableDataStructuresService_LogFetchEntryBundle_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VerifiableDataStructuresServiceServer).LogFetchEntryBundle(ctx, req.(*LogFetchEntryBundleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _VerifiableDataStructuresService_LogTreeHash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(LogTreeHashRequest)
 	if err := dec(in); err != nil {
@@ -288,6 +666,24 @@ func _VerifiableDataStructuresService_LogInclusionProof_Handler(srv interface{},
 	return interceptor(ctx, in, info, handler)
 }
 
+func _VerifiableDataStructuresService_LogBatchInclusionProof_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LogBatchInclusionProofRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VerifiableDataStructuresServiceServer).LogBatchInclusionProof(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VerifiableDataStructuresService_LogBatchInclusionProof_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VerifiableDataStructuresServiceServer).LogBatchInclusionProof(ctx, req.(*LogBatchInclusionProofRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _VerifiableDataStructuresService_LogConsistencyProof_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(LogConsistencyProofRequest)
 	if err := dec(in); err != nil {
@@ -306,6 +702,35 @@ func _VerifiableDataStructuresService_LogConsistencyProof_Handler(srv interface{
 	return interceptor(ctx, in, info, handler)
 }
 
+func _VerifiableDataStructuresService_LogCheckpoint_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LogCheckpointRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VerifiableDataStructuresServiceServer).LogCheckpoint(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VerifiableDataStructuresService_LogCheckpoint_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VerifiableDataStructuresServiceServer).LogCheckpoint(ctx, req.(*LogCheckpointRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VerifiableDataStructuresService_WatchLogTreeHead_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchLogTreeHeadRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(VerifiableDataStructuresServiceServer).WatchLogTreeHead(m, &grpc.GenericServerStream[WatchLogTreeHeadRequest, LogTreeHashResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VerifiableDataStructuresService_WatchLogTreeHeadServer = grpc.ServerStreamingServer[LogTreeHashResponse]
+
 func _VerifiableDataStructuresService_MapSetValue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(MapSetValueRequest)
 	if err := dec(in); err != nil {
@@ -324,6 +749,24 @@ func _VerifiableDataStructuresService_MapSetValue_Handler(srv interface{}, ctx c
 	return interceptor(ctx, in, info, handler)
 }
 
+func _VerifiableDataStructuresService_MapApplyTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MapApplyTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VerifiableDataStructuresServiceServer).MapApplyTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VerifiableDataStructuresService_MapApplyTransaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VerifiableDataStructuresServiceServer).MapApplyTransaction(ctx, req.(*MapApplyTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _VerifiableDataStructuresService_MapGetValue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(MapGetValueRequest)
 	if err := dec(in); err != nil {
@@ -342,6 +785,96 @@ func _VerifiableDataStructuresService_MapGetValue_Handler(srv interface{}, ctx c
 	return interceptor(ctx, in, info, handler)
 }
 
+func _VerifiableDataStructuresService_MapGetValueBundle_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MapGetValueBundleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VerifiableDataStructuresServiceServer).MapGetValueBundle(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VerifiableDataStructuresService_MapGetValueBundle_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VerifiableDataStructuresServiceServer).MapGetValueBundle(ctx, req.(*MapGetValueBundleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VerifiableDataStructuresService_MapGetValues_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MapGetValuesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VerifiableDataStructuresServiceServer).MapGetValues(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VerifiableDataStructuresService_MapGetValues_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VerifiableDataStructuresServiceServer).MapGetValues(ctx, req.(*MapGetValuesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VerifiableDataStructuresService_MapGetKeyHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MapGetKeyHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VerifiableDataStructuresServiceServer).MapGetKeyHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VerifiableDataStructuresService_MapGetKeyHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VerifiableDataStructuresServiceServer).MapGetKeyHistory(ctx, req.(*MapGetKeyHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VerifiableDataStructuresService_MapListLeaves_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MapListLeavesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VerifiableDataStructuresServiceServer).MapListLeaves(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VerifiableDataStructuresService_MapListLeaves_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VerifiableDataStructuresServiceServer).MapListLeaves(ctx, req.(*MapListLeavesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VerifiableDataStructuresService_MapDiff_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MapDiffRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VerifiableDataStructuresServiceServer).MapDiff(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VerifiableDataStructuresService_MapDiff_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VerifiableDataStructuresServiceServer).MapDiff(ctx, req.(*MapDiffRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _VerifiableDataStructuresService_MapTreeHash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(MapTreeHashRequest)
 	if err := dec(in); err != nil {
@@ -360,6 +893,125 @@ func _VerifiableDataStructuresService_MapTreeHash_Handler(srv interface{}, ctx c
 	return interceptor(ctx, in, info, handler)
 }
 
+func _VerifiableDataStructuresService_WatchMapTreeHead_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchMapTreeHeadRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(VerifiableDataStructuresServiceServer).WatchMapTreeHead(m, &grpc.GenericServerStream[WatchMapTreeHeadRequest, MapTreeHashResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type VerifiableDataStructuresService_WatchMapTreeHeadServer = grpc.ServerStreamingServer[MapTreeHashResponse]
+
+func _VerifiableDataStructuresService_MultimapAddValue_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MultimapAddValueRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VerifiableDataStructuresServiceServer).MultimapAddValue(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VerifiableDataStructuresService_MultimapAddValue_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VerifiableDataStructuresServiceServer).MultimapAddValue(ctx, req.(*MultimapAddValueRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VerifiableDataStructuresService_MultimapGetValues_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MultimapGetValuesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VerifiableDataStructuresServiceServer).MultimapGetValues(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VerifiableDataStructuresService_MultimapGetValues_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VerifiableDataStructuresServiceServer).MultimapGetValues(ctx, req.(*MultimapGetValuesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VerifiableDataStructuresService_MultimapInclusionProof_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MultimapInclusionProofRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VerifiableDataStructuresServiceServer).MultimapInclusionProof(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VerifiableDataStructuresService_MultimapInclusionProof_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VerifiableDataStructuresServiceServer).MultimapInclusionProof(ctx, req.(*MultimapInclusionProofRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VerifiableDataStructuresService_MultimapKeyConsistencyProof_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MultimapKeyConsistencyProofRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VerifiableDataStructuresServiceServer).MultimapKeyConsistencyProof(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VerifiableDataStructuresService_MultimapKeyConsistencyProof_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VerifiableDataStructuresServiceServer).MultimapKeyConsistencyProof(ctx, req.(*MultimapKeyConsistencyProofRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VerifiableDataStructuresService_MultimapTreeHash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MultimapTreeHashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VerifiableDataStructuresServiceServer).MultimapTreeHash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VerifiableDataStructuresService_MultimapTreeHash_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VerifiableDataStructuresServiceServer).MultimapTreeHash(ctx, req.(*MultimapTreeHashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _VerifiableDataStructuresService_Gossip_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GossipRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(VerifiableDataStructuresServiceServer).Gossip(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: VerifiableDataStructuresService_Gossip_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(VerifiableDataStructuresServiceServer).Gossip(ctx, req.(*GossipRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // VerifiableDataStructuresService_ServiceDesc is the grpc.ServiceDesc for VerifiableDataStructuresService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -371,10 +1023,18 @@ var VerifiableDataStructuresService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "LogAddEntry",
 			Handler:    _VerifiableDataStructuresService_LogAddEntry_Handler,
 		},
+		{
+			MethodName: "LogAddEntries",
+			Handler:    _VerifiableDataStructuresService_LogAddEntries_Handler,
+		},
 		{
 			MethodName: "LogFetchEntries",
 			Handler:    _VerifiableDataStructuresService_LogFetchEntries_Handler,
 		},
+		{
+			MethodName: "LogFetchEntryBundle",
+			Handler:    _VerifiableDataStructuresService_LogFetchEntryBundle_Handler,
+		},
 		{
 			MethodName: "LogTreeHash",
 			Handler:    _VerifiableDataStructuresService_LogTreeHash_Handler,
@@ -383,23 +1043,95 @@ var VerifiableDataStructuresService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "LogInclusionProof",
 			Handler:    _VerifiableDataStructuresService_LogInclusionProof_Handler,
 		},
+		{
+			MethodName: "LogBatchInclusionProof",
+			Handler:    _VerifiableDataStructuresService_LogBatchInclusionProof_Handler,
+		},
 		{
 			MethodName: "LogConsistencyProof",
 			Handler:    _VerifiableDataStructuresService_LogConsistencyProof_Handler,
 		},
+		{
+			MethodName: "LogCheckpoint",
+			Handler:    _VerifiableDataStructuresService_LogCheckpoint_Handler,
+		},
 		{
 			MethodName: "MapSetValue",
 			Handler:    _VerifiableDataStructuresService_MapSetValue_Handler,
 		},
+		{
+			MethodName: "MapApplyTransaction",
+			Handler:    _VerifiableDataStructuresService_MapApplyTransaction_Handler,
+		},
 		{
 			MethodName: "MapGetValue",
 			Handler:    _VerifiableDataStructuresService_MapGetValue_Handler,
 		},
+		{
+			MethodName: "MapGetValueBundle",
+			Handler:    _VerifiableDataStructuresService_MapGetValueBundle_Handler,
+		},
+		{
+			MethodName: "MapGetValues",
+			Handler:    _VerifiableDataStructuresService_MapGetValues_Handler,
+		},
+		{
+			MethodName: "MapGetKeyHistory",
+			Handler:    _VerifiableDataStructuresService_MapGetKeyHistory_Handler,
+		},
+		{
+			MethodName: "MapListLeaves",
+			Handler:    _VerifiableDataStructuresService_MapListLeaves_Handler,
+		},
+		{
+			MethodName: "MapDiff",
+			Handler:    _VerifiableDataStructuresService_MapDiff_Handler,
+		},
 		{
 			MethodName: "MapTreeHash",
 			Handler:    _VerifiableDataStructuresService_MapTreeHash_Handler,
 		},
+		{
+			MethodName: "MultimapAddValue",
+			Handler:    _VerifiableDataStructuresService_MultimapAddValue_Handler,
+		},
+		{
+			MethodName: "MultimapGetValues",
+			Handler:    _VerifiableDataStructuresService_MultimapGetValues_Handler,
+		},
+		{
+			MethodName: "MultimapInclusionProof",
+			Handler:    _VerifiableDataStructuresService_MultimapInclusionProof_Handler,
+		},
+		{
+			MethodName: "MultimapKeyConsistencyProof",
+			Handler:    _VerifiableDataStructuresService_MultimapKeyConsistencyProof_Handler,
+		},
+		{
+			MethodName: "MultimapTreeHash",
+			Handler:    _VerifiableDataStructuresService_MultimapTreeHash_Handler,
+		},
+		{
+			MethodName: "Gossip",
+			Handler:    _VerifiableDataStructuresService_Gossip_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamLogEntries",
+			Handler:       _VerifiableDataStructuresService_StreamLogEntries_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchLogTreeHead",
+			Handler:       _VerifiableDataStructuresService_WatchLogTreeHead_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchMapTreeHead",
+			Handler:       _VerifiableDataStructuresService_WatchMapTreeHead_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "api.proto",
 }
@@ -138,6 +138,12 @@ type ServerConfig struct {
 	GrpcListenBind           string                 `protobuf:"bytes,8,opt,name=grpc_listen_bind,json=grpcListenBind,proto3" json:"grpc_listen_bind,omitempty"`
 	RestServer               bool                   `protobuf:"varint,9,opt,name=rest_server,json=restServer,proto3" json:"rest_server,omitempty"`
 	GrpcServer               bool                   `protobuf:"varint,10,opt,name=grpc_server,json=grpcServer,proto3" json:"grpc_server,omitempty"`
+	SigningKeyPath           string                 `protobuf:"bytes,11,opt,name=signing_key_path,json=signingKeyPath,proto3" json:"signing_key_path,omitempty"` // if set, PEM encoded PKCS#8 Ed25519 or ECDSA P-256 key used to sign tree heads
+	Witnesses                []*WitnessRef          `protobuf:"bytes,12,rep,name=witnesses,proto3" json:"witnesses,omitempty"`                                   // witnesses to request cosignatures from
+	WitnessQuorum            int32                  `protobuf:"varint,13,opt,name=witness_quorum,json=witnessQuorum,proto3" json:"witness_quorum,omitempty"`     // number of cosignatures needed for a cosigned tree head. If zero, all witnesses must cosign
+	MapRetention             *MapRetentionPolicy    `protobuf:"bytes,14,opt,name=map_retention,json=mapRetention,proto3" json:"map_retention,omitempty"`         // if set, map nodes only needed for proofs at other tree sizes are deleted
+	VrfKeyPath               string                 `protobuf:"bytes,15,opt,name=vrf_key_path,json=vrfKeyPath,proto3" json:"vrf_key_path,omitempty"`             // if set, PEM encoded PKCS#8 Ed25519 key used to derive key paths for maps with VRF keys
+	DerivedMaps              []*DerivedMap          `protobuf:"bytes,16,rep,name=derived_maps,json=derivedMaps,proto3" json:"derived_maps,omitempty"`            // maps that the server derives from the entries added to a log
 	unknownFields            protoimpl.UnknownFields
 	sizeCache                protoimpl.SizeCache
 }
@@ -242,6 +248,396 @@ func (x *ServerConfig) GetGrpcServer() bool {
 	return false
 }
 
+func (x *ServerConfig) GetSigningKeyPath() string {
+	if x != nil {
+		return x.SigningKeyPath
+	}
+	return ""
+}
+
+func (x *ServerConfig) GetWitnesses() []*WitnessRef {
+	if x != nil {
+		return x.Witnesses
+	}
+	return nil
+}
+
+func (x *ServerConfig) GetWitnessQuorum() int32 {
+	if x != nil {
+		return x.WitnessQuorum
+	}
+	return 0
+}
+
+func (x *ServerConfig) GetMapRetention() *MapRetentionPolicy {
+	if x != nil {
+		return x.MapRetention
+	}
+	return nil
+}
+
+func (x *ServerConfig) GetVrfKeyPath() string {
+	if x != nil {
+		return x.VrfKeyPath
+	}
+	return ""
+}
+
+func (x *ServerConfig) GetDerivedMaps() []*DerivedMap {
+	if x != nil {
+		return x.DerivedMaps
+	}
+	return nil
+}
+
+type DerivedMap struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Account       string                 `protobuf:"bytes,1,opt,name=account,proto3" json:"account,omitempty"` // account of both the log and the map
+	Log           string                 `protobuf:"bytes,2,opt,name=log,proto3" json:"log,omitempty"`         // name of the log that the map is derived from. Must be empty when first configured
+	Map           string                 `protobuf:"bytes,3,opt,name=map,proto3" json:"map,omitempty"`         // name of the map, which cannot otherwise be added to
+	Reducer       *MapReducer            `protobuf:"bytes,4,opt,name=reducer,proto3" json:"reducer,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DerivedMap) Reset() {
+	*x = DerivedMap{}
+	mi := &file_configuration_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DerivedMap) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DerivedMap) ProtoMessage() {}
+
+func (x *DerivedMap) ProtoReflect() protoreflect.Message {
+	mi := &file_configuration_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DerivedMap.ProtoReflect.Descriptor instead.
+func (*DerivedMap) Descriptor() ([]byte, []int) {
+	return file_configuration_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *DerivedMap) GetAccount() string {
+	if x != nil {
+		return x.Account
+	}
+	return ""
+}
+
+func (x *DerivedMap) GetLog() string {
+	if x != nil {
+		return x.Log
+	}
+	return ""
+}
+
+func (x *DerivedMap) GetMap() string {
+	if x != nil {
+		return x.Map
+	}
+	return ""
+}
+
+func (x *DerivedMap) GetReducer() *MapReducer {
+	if x != nil {
+		return x.Reducer
+	}
+	return nil
+}
+
+type MapRetentionPolicy struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Every         int64                  `protobuf:"varint,1,opt,name=every,proto3" json:"every,omitempty"`                       // keep proofs for every tree size that is a multiple of this. If zero, none are kept for this reason
+	KeepLast      int64                  `protobuf:"varint,2,opt,name=keep_last,json=keepLast,proto3" json:"keep_last,omitempty"` // keep proofs for this many of the latest tree sizes. At least 1
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MapRetentionPolicy) Reset() {
+	*x = MapRetentionPolicy{}
+	mi := &file_configuration_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapRetentionPolicy) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapRetentionPolicy) ProtoMessage() {}
+
+func (x *MapRetentionPolicy) ProtoReflect() protoreflect.Message {
+	mi := &file_configuration_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapRetentionPolicy.ProtoReflect.Descriptor instead.
+func (*MapRetentionPolicy) Descriptor() ([]byte, []int) {
+	return file_configuration_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *MapRetentionPolicy) GetEvery() int64 {
+	if x != nil {
+		return x.Every
+	}
+	return 0
+}
+
+func (x *MapRetentionPolicy) GetKeepLast() int64 {
+	if x != nil {
+		return x.KeepLast
+	}
+	return 0
+}
+
+type WitnessRef struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Address        string                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"` // gRPC address of the witness, e.g. "witness.example.com:8093"
+	NoGrpcSecurity bool                   `protobuf:"varint,2,opt,name=no_grpc_security,json=noGrpcSecurity,proto3" json:"no_grpc_security,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *WitnessRef) Reset() {
+	*x = WitnessRef{}
+	mi := &file_configuration_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WitnessRef) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WitnessRef) ProtoMessage() {}
+
+func (x *WitnessRef) ProtoReflect() protoreflect.Message {
+	mi := &file_configuration_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WitnessRef.ProtoReflect.Descriptor instead.
+func (*WitnessRef) Descriptor() ([]byte, []int) {
+	return file_configuration_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *WitnessRef) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *WitnessRef) GetNoGrpcSecurity() bool {
+	if x != nil {
+		return x.NoGrpcSecurity
+	}
+	return false
+}
+
+type WitnessConfig struct {
+	state                    protoimpl.MessageState `protogen:"open.v1"`
+	ServerCertPath           string                 `protobuf:"bytes,1,opt,name=server_cert_path,json=serverCertPath,proto3" json:"server_cert_path,omitempty"`
+	ServerKeyPath            string                 `protobuf:"bytes,2,opt,name=server_key_path,json=serverKeyPath,proto3" json:"server_key_path,omitempty"`
+	InsecureServerForTesting bool                   `protobuf:"varint,3,opt,name=insecure_server_for_testing,json=insecureServerForTesting,proto3" json:"insecure_server_for_testing,omitempty"`
+	GrpcListenProtocol       string                 `protobuf:"bytes,4,opt,name=grpc_listen_protocol,json=grpcListenProtocol,proto3" json:"grpc_listen_protocol,omitempty"`
+	GrpcListenBind           string                 `protobuf:"bytes,5,opt,name=grpc_listen_bind,json=grpcListenBind,proto3" json:"grpc_listen_bind,omitempty"`
+	BoltDbPath               string                 `protobuf:"bytes,6,opt,name=bolt_db_path,json=boltDbPath,proto3" json:"bolt_db_path,omitempty"`
+	SigningKeyPath           string                 `protobuf:"bytes,7,opt,name=signing_key_path,json=signingKeyPath,proto3" json:"signing_key_path,omitempty"` // PEM encoded PKCS#8 Ed25519 or ECDSA P-256 key used to cosign tree heads
+	Logs                     []*WitnessedLog        `protobuf:"bytes,8,rep,name=logs,proto3" json:"logs,omitempty"`
+	unknownFields            protoimpl.UnknownFields
+	sizeCache                protoimpl.SizeCache
+}
+
+func (x *WitnessConfig) Reset() {
+	*x = WitnessConfig{}
+	mi := &file_configuration_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WitnessConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WitnessConfig) ProtoMessage() {}
+
+func (x *WitnessConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_configuration_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WitnessConfig.ProtoReflect.Descriptor instead.
+func (*WitnessConfig) Descriptor() ([]byte, []int) {
+	return file_configuration_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *WitnessConfig) GetServerCertPath() string {
+	if x != nil {
+		return x.ServerCertPath
+	}
+	return ""
+}
+
+func (x *WitnessConfig) GetServerKeyPath() string {
+	if x != nil {
+		return x.ServerKeyPath
+	}
+	return ""
+}
+
+func (x *WitnessConfig) GetInsecureServerForTesting() bool {
+	if x != nil {
+		return x.InsecureServerForTesting
+	}
+	return false
+}
+
+func (x *WitnessConfig) GetGrpcListenProtocol() string {
+	if x != nil {
+		return x.GrpcListenProtocol
+	}
+	return ""
+}
+
+func (x *WitnessConfig) GetGrpcListenBind() string {
+	if x != nil {
+		return x.GrpcListenBind
+	}
+	return ""
+}
+
+func (x *WitnessConfig) GetBoltDbPath() string {
+	if x != nil {
+		return x.BoltDbPath
+	}
+	return ""
+}
+
+func (x *WitnessConfig) GetSigningKeyPath() string {
+	if x != nil {
+		return x.SigningKeyPath
+	}
+	return ""
+}
+
+func (x *WitnessConfig) GetLogs() []*WitnessedLog {
+	if x != nil {
+		return x.Logs
+	}
+	return nil
+}
+
+type WitnessedLog struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Origin        string                 `protobuf:"bytes,1,opt,name=origin,proto3" json:"origin,omitempty"`                                                                                                    // e.g. "1234/log/foo", as per verifiable.LogOrigin()
+	PublicKeyPath string                 `protobuf:"bytes,2,opt,name=public_key_path,json=publicKeyPath,proto3" json:"public_key_path,omitempty"`                                                               // PEM encoded PKIX key that tree heads must be signed by. If empty, tree heads need not be signed.
+	HashAlgorithm HashAlgorithm          `protobuf:"varint,3,opt,name=hash_algorithm,json=hashAlgorithm,proto3,enum=com.continusec.verifiabledatastructures.api.HashAlgorithm" json:"hash_algorithm,omitempty"` // as recorded for the log. Consistency proofs must use it
+	StartTreeSize int64                  `protobuf:"varint,4,opt,name=start_tree_size,json=startTreeSize,proto3" json:"start_tree_size,omitempty"`                                                              // if set, the first tree head cosigned must be consistent with this tree head, otherwise the first tree head presented is trusted
+	StartRootHash string                 `protobuf:"bytes,5,opt,name=start_root_hash,json=startRootHash,proto3" json:"start_root_hash,omitempty"`                                                               // hex encoded root hash of the log at start_tree_size
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WitnessedLog) Reset() {
+	*x = WitnessedLog{}
+	mi := &file_configuration_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WitnessedLog) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WitnessedLog) ProtoMessage() {}
+
+func (x *WitnessedLog) ProtoReflect() protoreflect.Message {
+	mi := &file_configuration_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WitnessedLog.ProtoReflect.Descriptor instead.
+func (*WitnessedLog) Descriptor() ([]byte, []int) {
+	return file_configuration_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *WitnessedLog) GetOrigin() string {
+	if x != nil {
+		return x.Origin
+	}
+	return ""
+}
+
+func (x *WitnessedLog) GetPublicKeyPath() string {
+	if x != nil {
+		return x.PublicKeyPath
+	}
+	return ""
+}
+
+func (x *WitnessedLog) GetHashAlgorithm() HashAlgorithm {
+	if x != nil {
+		return x.HashAlgorithm
+	}
+	return HashAlgorithm_HASH_SHA256
+}
+
+func (x *WitnessedLog) GetStartTreeSize() int64 {
+	if x != nil {
+		return x.StartTreeSize
+	}
+	return 0
+}
+
+func (x *WitnessedLog) GetStartRootHash() string {
+	if x != nil {
+		return x.StartRootHash
+	}
+	return ""
+}
+
 type AccessPolicy struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	ApiKey        string                 `protobuf:"bytes,1,opt,name=api_key,json=apiKey,proto3" json:"api_key,omitempty"`                                                                           // API key to activate this rule
@@ -254,7 +650,7 @@ type AccessPolicy struct {
 
 func (x *AccessPolicy) Reset() {
 	*x = AccessPolicy{}
-	mi := &file_configuration_proto_msgTypes[1]
+	mi := &file_configuration_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -266,7 +662,7 @@ func (x *AccessPolicy) String() string {
 func (*AccessPolicy) ProtoMessage() {}
 
 func (x *AccessPolicy) ProtoReflect() protoreflect.Message {
-	mi := &file_configuration_proto_msgTypes[1]
+	mi := &file_configuration_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -279,7 +675,7 @@ func (x *AccessPolicy) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AccessPolicy.ProtoReflect.Descriptor instead.
 func (*AccessPolicy) Descriptor() ([]byte, []int) {
-	return file_configuration_proto_rawDescGZIP(), []int{1}
+	return file_configuration_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *AccessPolicy) GetApiKey() string {
@@ -320,7 +716,7 @@ type ResourceAccount struct {
 
 func (x *ResourceAccount) Reset() {
 	*x = ResourceAccount{}
-	mi := &file_configuration_proto_msgTypes[2]
+	mi := &file_configuration_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -332,7 +728,7 @@ func (x *ResourceAccount) String() string {
 func (*ResourceAccount) ProtoMessage() {}
 
 func (x *ResourceAccount) ProtoReflect() protoreflect.Message {
-	mi := &file_configuration_proto_msgTypes[2]
+	mi := &file_configuration_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -345,7 +741,7 @@ func (x *ResourceAccount) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ResourceAccount.ProtoReflect.Descriptor instead.
 func (*ResourceAccount) Descriptor() ([]byte, []int) {
-	return file_configuration_proto_rawDescGZIP(), []int{2}
+	return file_configuration_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *ResourceAccount) GetId() string {
@@ -366,7 +762,7 @@ var File_configuration_proto protoreflect.FileDescriptor
 
 const file_configuration_proto_rawDesc = "" +
 	"\n" +
-	"\x13configuration.proto\x125com.continusec.verifiabledatastructures.configuration\"\xed\x03\n" +
+	"\x13configuration.proto\x125com.continusec.verifiabledatastructures.configuration\x1a\tapi.proto\"\x97\a\n" +
 	"\fServerConfig\x12(\n" +
 	"\x10server_cert_path\x18\x01 \x01(\tR\x0eserverCertPath\x12&\n" +
 	"\x0fserver_key_path\x18\x02 \x01(\tR\rserverKeyPath\x12(\n" +
@@ -381,7 +777,43 @@ const file_configuration_proto_rawDesc = "" +
 	"restServer\x12\x1f\n" +
 	"\vgrpc_server\x18\n" +
 	" \x01(\bR\n" +
-	"grpcServer\"\xd2\x01\n" +
+	"grpcServer\x12(\n" +
+	"\x10signing_key_path\x18\v \x01(\tR\x0esigningKeyPath\x12_\n" +
+	"\twitnesses\x18\f \x03(\v2A.com.continusec.verifiabledatastructures.configuration.WitnessRefR\twitnesses\x12%\n" +
+	"\x0ewitness_quorum\x18\r \x01(\x05R\rwitnessQuorum\x12n\n" +
+	"\rmap_retention\x18\x0e \x01(\v2I.com.continusec.verifiabledatastructures.configuration.MapRetentionPolicyR\fmapRetention\x12 \n" +
+	"\fvrf_key_path\x18\x0f \x01(\tR\n" +
+	"vrfKeyPath\x12d\n" +
+	"\fderived_maps\x18\x10 \x03(\v2A.com.continusec.verifiabledatastructures.configuration.DerivedMapR\vderivedMaps\"\x9d\x01\n" +
+	"\n" +
+	"DerivedMap\x12\x18\n" +
+	"\aaccount\x18\x01 \x01(\tR\aaccount\x12\x10\n" +
+	"\x03log\x18\x02 \x01(\tR\x03log\x12\x10\n" +
+	"\x03map\x18\x03 \x01(\tR\x03map\x12Q\n" +
+	"\areducer\x18\x04 \x01(\v27.com.continusec.verifiabledatastructures.api.MapReducerR\areducer\"G\n" +
+	"\x12MapRetentionPolicy\x12\x14\n" +
+	"\x05every\x18\x01 \x01(\x03R\x05every\x12\x1b\n" +
+	"\tkeep_last\x18\x02 \x01(\x03R\bkeepLast\"P\n" +
+	"\n" +
+	"WitnessRef\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\tR\aaddress\x12(\n" +
+	"\x10no_grpc_security\x18\x02 \x01(\bR\x0enoGrpcSecurity\"\xa1\x03\n" +
+	"\rWitnessConfig\x12(\n" +
+	"\x10server_cert_path\x18\x01 \x01(\tR\x0eserverCertPath\x12&\n" +
+	"\x0fserver_key_path\x18\x02 \x01(\tR\rserverKeyPath\x12=\n" +
+	"\x1binsecure_server_for_testing\x18\x03 \x01(\bR\x18insecureServerForTesting\x120\n" +
+	"\x14grpc_listen_protocol\x18\x04 \x01(\tR\x12grpcListenProtocol\x12(\n" +
+	"\x10grpc_listen_bind\x18\x05 \x01(\tR\x0egrpcListenBind\x12 \n" +
+	"\fbolt_db_path\x18\x06 \x01(\tR\n" +
+	"boltDbPath\x12(\n" +
+	"\x10signing_key_path\x18\a \x01(\tR\x0esigningKeyPath\x12W\n" +
+	"\x04logs\x18\b \x03(\v2C.com.continusec.verifiabledatastructures.configuration.WitnessedLogR\x04logs\"\x81\x02\n" +
+	"\fWitnessedLog\x12\x16\n" +
+	"\x06origin\x18\x01 \x01(\tR\x06origin\x12&\n" +
+	"\x0fpublic_key_path\x18\x02 \x01(\tR\rpublicKeyPath\x12a\n" +
+	"\x0ehash_algorithm\x18\x03 \x01(\x0e2:.com.continusec.verifiabledatastructures.api.HashAlgorithmR\rhashAlgorithm\x12&\n" +
+	"\x0fstart_tree_size\x18\x04 \x01(\x03R\rstartTreeSize\x12&\n" +
+	"\x0fstart_root_hash\x18\x05 \x01(\tR\rstartRootHash\"\xd2\x01\n" +
 	"\fAccessPolicy\x12\x17\n" +
 	"\aapi_key\x18\x01 \x01(\tR\x06apiKey\x12\x1d\n" +
 	"\n" +
@@ -424,22 +856,35 @@ func file_configuration_proto_rawDescGZIP() []byte {
 }
 
 var file_configuration_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_configuration_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_configuration_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
 var file_configuration_proto_goTypes = []any{
-	(Permission)(0),         // 0: com.continusec.verifiabledatastructures.configuration.Permission
-	(*ServerConfig)(nil),    // 1: com.continusec.verifiabledatastructures.configuration.ServerConfig
-	(*AccessPolicy)(nil),    // 2: com.continusec.verifiabledatastructures.configuration.AccessPolicy
-	(*ResourceAccount)(nil), // 3: com.continusec.verifiabledatastructures.configuration.ResourceAccount
+	(Permission)(0),            // 0: com.continusec.verifiabledatastructures.configuration.Permission
+	(*ServerConfig)(nil),       // 1: com.continusec.verifiabledatastructures.configuration.ServerConfig
+	(*DerivedMap)(nil),         // 2: com.continusec.verifiabledatastructures.configuration.DerivedMap
+	(*MapRetentionPolicy)(nil), // 3: com.continusec.verifiabledatastructures.configuration.MapRetentionPolicy
+	(*WitnessRef)(nil),         // 4: com.continusec.verifiabledatastructures.configuration.WitnessRef
+	(*WitnessConfig)(nil),      // 5: com.continusec.verifiabledatastructures.configuration.WitnessConfig
+	(*WitnessedLog)(nil),       // 6: com.continusec.verifiabledatastructures.configuration.WitnessedLog
+	(*AccessPolicy)(nil),       // 7: com.continusec.verifiabledatastructures.configuration.AccessPolicy
+	(*ResourceAccount)(nil),    // 8: com.continusec.verifiabledatastructures.configuration.ResourceAccount
+	(*MapReducer)(nil),         // 9: com.continusec.verifiabledatastructures.api.MapReducer
+	(HashAlgorithm)(0),         // 10: com.continusec.verifiabledatastructures.api.HashAlgorithm
 }
 var file_configuration_proto_depIdxs = []int32{
-	3, // 0: com.continusec.verifiabledatastructures.configuration.ServerConfig.accounts:type_name -> com.continusec.verifiabledatastructures.configuration.ResourceAccount
-	0, // 1: com.continusec.verifiabledatastructures.configuration.AccessPolicy.permissions:type_name -> com.continusec.verifiabledatastructures.configuration.Permission
-	2, // 2: com.continusec.verifiabledatastructures.configuration.ResourceAccount.policy:type_name -> com.continusec.verifiabledatastructures.configuration.AccessPolicy
-	3, // [3:3] is the sub-list for method output_type
-	3, // [3:3] is the sub-list for method input_type
-	3, // [3:3] is the sub-list for extension type_name
-	3, // [3:3] is the sub-list for extension extendee
-	0, // [0:3] is the sub-list for field type_name
+	8,  // 0: com.continusec.verifiabledatastructures.configuration.ServerConfig.accounts:type_name -> com.continusec.verifiabledatastructures.configuration.ResourceAccount
+	4,  // 1: com.continusec.verifiabledatastructures.configuration.ServerConfig.witnesses:type_name -> com.continusec.verifiabledatastructures.configuration.WitnessRef
+	3,  // 2: com.continusec.verifiabledatastructures.configuration.ServerConfig.map_retention:type_name -> com.continusec.verifiabledatastructures.configuration.MapRetentionPolicy
+	2,  // 3: com.continusec.verifiabledatastructures.configuration.ServerConfig.derived_maps:type_name -> com.continusec.verifiabledatastructures.configuration.DerivedMap
+	9,  // 4: com.continusec.verifiabledatastructures.configuration.DerivedMap.reducer:type_name -> com.continusec.verifiabledatastructures.api.MapReducer
+	6,  // 5: com.continusec.verifiabledatastructures.configuration.WitnessConfig.logs:type_name -> com.continusec.verifiabledatastructures.configuration.WitnessedLog
+	10, // 6: com.continusec.verifiabledatastructures.configuration.WitnessedLog.hash_algorithm:type_name -> com.continusec.verifiabledatastructures.api.HashAlgorithm
+	0,  // 7: com.continusec.verifiabledatastructures.configuration.AccessPolicy.permissions:type_name -> com.continusec.verifiabledatastructures.configuration.Permission
+	7,  // 8: com.continusec.verifiabledatastructures.configuration.ResourceAccount.policy:type_name -> com.continusec.verifiabledatastructures.configuration.AccessPolicy
+	9,  // [9:9] is the sub-list for method output_type
+	9,  // [9:9] is the sub-list for method input_type
+	9,  // [9:9] is the sub-list for extension type_name
+	9,  // [9:9] is the sub-list for extension extendee
+	0,  // [0:9] is the sub-list for field type_name
 }
 
 func init() { file_configuration_proto_init() }
@@ -447,13 +892,14 @@ func file_configuration_proto_init() {
 	if File_configuration_proto != nil {
 		return
 	}
+	file_api_proto_init()
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_configuration_proto_rawDesc), len(file_configuration_proto_rawDesc)),
 			NumEnums:      1,
-			NumMessages:   3,
+			NumMessages:   8,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -42,9 +42,15 @@ type Mutation struct {
 	state     protoimpl.MessageState `protogen:"open.v1"`
 	Namespace []byte                 `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
 	// one of the following
-	LogAddEntry   *LogAddEntryRequest `protobuf:"bytes,2,opt,name=log_add_entry,json=logAddEntry,proto3" json:"log_add_entry,omitempty"` // we don't have map set value, as that generates a LogAddEntryRequest to the underlying mutation log
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	LogAddEntry *LogAddEntryRequest `protobuf:"bytes,2,opt,name=log_add_entry,json=logAddEntry,proto3" json:"log_add_entry,omitempty"`
+	// we don't have map set value, as that generates a LogAddEntryRequest to the underlying mutation log
+	LogAddCosignedTreeHead  *LogAddCosignedTreeHead  `protobuf:"bytes,3,opt,name=log_add_cosigned_tree_head,json=logAddCosignedTreeHead,proto3" json:"log_add_cosigned_tree_head,omitempty"`
+	AddInconsistentTreeHead *InconsistentTreeHead    `protobuf:"bytes,4,opt,name=add_inconsistent_tree_head,json=addInconsistentTreeHead,proto3" json:"add_inconsistent_tree_head,omitempty"`
+	LogAddEntries           *LogAddEntriesRequest    `protobuf:"bytes,5,opt,name=log_add_entries,json=logAddEntries,proto3" json:"log_add_entries,omitempty"`
+	MapCompact              *MapCompact              `protobuf:"bytes,6,opt,name=map_compact,json=mapCompact,proto3" json:"map_compact,omitempty"`
+	MultimapAddValue        *MultimapAddValueRequest `protobuf:"bytes,7,opt,name=multimap_add_value,json=multimapAddValue,proto3" json:"multimap_add_value,omitempty"`
+	unknownFields           protoimpl.UnknownFields
+	sizeCache               protoimpl.SizeCache
 }
 
 func (x *Mutation) Reset() {
@@ -91,6 +97,199 @@ func (x *Mutation) GetLogAddEntry() *LogAddEntryRequest {
 	return nil
 }
 
+func (x *Mutation) GetLogAddCosignedTreeHead() *LogAddCosignedTreeHead {
+	if x != nil {
+		return x.LogAddCosignedTreeHead
+	}
+	return nil
+}
+
+func (x *Mutation) GetAddInconsistentTreeHead() *InconsistentTreeHead {
+	if x != nil {
+		return x.AddInconsistentTreeHead
+	}
+	return nil
+}
+
+func (x *Mutation) GetLogAddEntries() *LogAddEntriesRequest {
+	if x != nil {
+		return x.LogAddEntries
+	}
+	return nil
+}
+
+func (x *Mutation) GetMapCompact() *MapCompact {
+	if x != nil {
+		return x.MapCompact
+	}
+	return nil
+}
+
+func (x *Mutation) GetMultimapAddValue() *MultimapAddValueRequest {
+	if x != nil {
+		return x.MultimapAddValue
+	}
+	return nil
+}
+
+type MapCompact struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Map           *MapRef                `protobuf:"bytes,1,opt,name=map,proto3" json:"map,omitempty"`
+	Policy        *MapRetentionPolicy    `protobuf:"bytes,2,opt,name=policy,proto3" json:"policy,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MapCompact) Reset() {
+	*x = MapCompact{}
+	mi := &file_storage_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapCompact) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapCompact) ProtoMessage() {}
+
+func (x *MapCompact) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapCompact.ProtoReflect.Descriptor instead.
+func (*MapCompact) Descriptor() ([]byte, []int) {
+	return file_storage_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *MapCompact) GetMap() *MapRef {
+	if x != nil {
+		return x.Map
+	}
+	return nil
+}
+
+func (x *MapCompact) GetPolicy() *MapRetentionPolicy {
+	if x != nil {
+		return x.Policy
+	}
+	return nil
+}
+
+// A tree head submitted via gossip that is not consistent with our own log or map. Kept as evidence. Does not change the size of the log.
+type InconsistentTreeHead struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Log           *LogGossip             `protobuf:"bytes,1,opt,name=log,proto3" json:"log,omitempty"`
+	Map           *MapGossip             `protobuf:"bytes,2,opt,name=map,proto3" json:"map,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InconsistentTreeHead) Reset() {
+	*x = InconsistentTreeHead{}
+	mi := &file_storage_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InconsistentTreeHead) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InconsistentTreeHead) ProtoMessage() {}
+
+func (x *InconsistentTreeHead) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InconsistentTreeHead.ProtoReflect.Descriptor instead.
+func (*InconsistentTreeHead) Descriptor() ([]byte, []int) {
+	return file_storage_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *InconsistentTreeHead) GetLog() *LogGossip {
+	if x != nil {
+		return x.Log
+	}
+	return nil
+}
+
+func (x *InconsistentTreeHead) GetMap() *MapGossip {
+	if x != nil {
+		return x.Map
+	}
+	return nil
+}
+
+// Records a tree head that has been cosigned by a quorum of witnesses. Does not change the size of the log.
+type LogAddCosignedTreeHead struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Log           *LogRef                `protobuf:"bytes,1,opt,name=log,proto3" json:"log,omitempty"`
+	Head          *LogTreeHashResponse   `protobuf:"bytes,2,opt,name=head,proto3" json:"head,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LogAddCosignedTreeHead) Reset() {
+	*x = LogAddCosignedTreeHead{}
+	mi := &file_storage_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LogAddCosignedTreeHead) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LogAddCosignedTreeHead) ProtoMessage() {}
+
+func (x *LogAddCosignedTreeHead) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LogAddCosignedTreeHead.ProtoReflect.Descriptor instead.
+func (*LogAddCosignedTreeHead) Descriptor() ([]byte, []int) {
+	return file_storage_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *LogAddCosignedTreeHead) GetLog() *LogRef {
+	if x != nil {
+		return x.Log
+	}
+	return nil
+}
+
+func (x *LogAddCosignedTreeHead) GetHead() *LogTreeHashResponse {
+	if x != nil {
+		return x.Head
+	}
+	return nil
+}
+
 type LeafNode struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Mth           []byte                 `protobuf:"bytes,1,opt,name=mth,proto3" json:"mth,omitempty"`
@@ -100,7 +299,7 @@ type LeafNode struct {
 
 func (x *LeafNode) Reset() {
 	*x = LeafNode{}
-	mi := &file_storage_proto_msgTypes[1]
+	mi := &file_storage_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -112,7 +311,7 @@ func (x *LeafNode) String() string {
 func (*LeafNode) ProtoMessage() {}
 
 func (x *LeafNode) ProtoReflect() protoreflect.Message {
-	mi := &file_storage_proto_msgTypes[1]
+	mi := &file_storage_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -125,7 +324,7 @@ func (x *LeafNode) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LeafNode.ProtoReflect.Descriptor instead.
 func (*LeafNode) Descriptor() ([]byte, []int) {
-	return file_storage_proto_rawDescGZIP(), []int{1}
+	return file_storage_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *LeafNode) GetMth() []byte {
@@ -144,7 +343,7 @@ type TreeNode struct {
 
 func (x *TreeNode) Reset() {
 	*x = TreeNode{}
-	mi := &file_storage_proto_msgTypes[2]
+	mi := &file_storage_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -156,7 +355,7 @@ func (x *TreeNode) String() string {
 func (*TreeNode) ProtoMessage() {}
 
 func (x *TreeNode) ProtoReflect() protoreflect.Message {
-	mi := &file_storage_proto_msgTypes[2]
+	mi := &file_storage_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -169,7 +368,7 @@ func (x *TreeNode) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TreeNode.ProtoReflect.Descriptor instead.
 func (*TreeNode) Descriptor() ([]byte, []int) {
-	return file_storage_proto_rawDescGZIP(), []int{2}
+	return file_storage_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *TreeNode) GetMth() []byte {
@@ -188,7 +387,7 @@ type LogTreeHash struct {
 
 func (x *LogTreeHash) Reset() {
 	*x = LogTreeHash{}
-	mi := &file_storage_proto_msgTypes[3]
+	mi := &file_storage_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -200,7 +399,7 @@ func (x *LogTreeHash) String() string {
 func (*LogTreeHash) ProtoMessage() {}
 
 func (x *LogTreeHash) ProtoReflect() protoreflect.Message {
-	mi := &file_storage_proto_msgTypes[3]
+	mi := &file_storage_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -213,7 +412,7 @@ func (x *LogTreeHash) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use LogTreeHash.ProtoReflect.Descriptor instead.
 func (*LogTreeHash) Descriptor() ([]byte, []int) {
-	return file_storage_proto_rawDescGZIP(), []int{3}
+	return file_storage_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *LogTreeHash) GetMth() []byte {
@@ -232,7 +431,7 @@ type EntryIndex struct {
 
 func (x *EntryIndex) Reset() {
 	*x = EntryIndex{}
-	mi := &file_storage_proto_msgTypes[4]
+	mi := &file_storage_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -244,7 +443,7 @@ func (x *EntryIndex) String() string {
 func (*EntryIndex) ProtoMessage() {}
 
 func (x *EntryIndex) ProtoReflect() protoreflect.Message {
-	mi := &file_storage_proto_msgTypes[4]
+	mi := &file_storage_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -257,7 +456,7 @@ func (x *EntryIndex) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use EntryIndex.ProtoReflect.Descriptor instead.
 func (*EntryIndex) Descriptor() ([]byte, []int) {
-	return file_storage_proto_rawDescGZIP(), []int{4}
+	return file_storage_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *EntryIndex) GetIndex() int64 {
@@ -276,7 +475,7 @@ type ObjectSize struct {
 
 func (x *ObjectSize) Reset() {
 	*x = ObjectSize{}
-	mi := &file_storage_proto_msgTypes[5]
+	mi := &file_storage_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -288,7 +487,7 @@ func (x *ObjectSize) String() string {
 func (*ObjectSize) ProtoMessage() {}
 
 func (x *ObjectSize) ProtoReflect() protoreflect.Message {
-	mi := &file_storage_proto_msgTypes[5]
+	mi := &file_storage_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -301,7 +500,7 @@ func (x *ObjectSize) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ObjectSize.ProtoReflect.Descriptor instead.
 func (*ObjectSize) Descriptor() ([]byte, []int) {
-	return file_storage_proto_rawDescGZIP(), []int{5}
+	return file_storage_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *ObjectSize) GetSize() int64 {
@@ -311,6 +510,91 @@ func (x *ObjectSize) GetSize() int64 {
 	return 0
 }
 
+// Settings fixed when the first entry is added to a log or map
+type ObjectConfig struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	HashAlgorithm HashAlgorithm          `protobuf:"varint,1,opt,name=hash_algorithm,json=hashAlgorithm,proto3,enum=com.continusec.verifiabledatastructures.api.HashAlgorithm" json:"hash_algorithm,omitempty"`
+	WriteOnce     bool                   `protobuf:"varint,2,opt,name=write_once,json=writeOnce,proto3" json:"write_once,omitempty"`           // for maps only
+	VrfPublicKey  []byte                 `protobuf:"bytes,3,opt,name=vrf_public_key,json=vrfPublicKey,proto3" json:"vrf_public_key,omitempty"` // for maps with VRF keys only
+	SumField      string                 `protobuf:"bytes,4,opt,name=sum_field,json=sumField,proto3" json:"sum_field,omitempty"`               // for sum-tree maps only
+	Reducer       *MapReducer            `protobuf:"bytes,5,opt,name=reducer,proto3" json:"reducer,omitempty"`                                 // for logs with a derived map only
+	PatriciaTrie  bool                   `protobuf:"varint,6,opt,name=patricia_trie,json=patriciaTrie,proto3" json:"patricia_trie,omitempty"`  // for Merkle Patricia Trie maps only
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ObjectConfig) Reset() {
+	*x = ObjectConfig{}
+	mi := &file_storage_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ObjectConfig) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ObjectConfig) ProtoMessage() {}
+
+func (x *ObjectConfig) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ObjectConfig.ProtoReflect.Descriptor instead.
+func (*ObjectConfig) Descriptor() ([]byte, []int) {
+	return file_storage_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ObjectConfig) GetHashAlgorithm() HashAlgorithm {
+	if x != nil {
+		return x.HashAlgorithm
+	}
+	return HashAlgorithm_HASH_SHA256
+}
+
+func (x *ObjectConfig) GetWriteOnce() bool {
+	if x != nil {
+		return x.WriteOnce
+	}
+	return false
+}
+
+func (x *ObjectConfig) GetVrfPublicKey() []byte {
+	if x != nil {
+		return x.VrfPublicKey
+	}
+	return nil
+}
+
+func (x *ObjectConfig) GetSumField() string {
+	if x != nil {
+		return x.SumField
+	}
+	return ""
+}
+
+func (x *ObjectConfig) GetReducer() *MapReducer {
+	if x != nil {
+		return x.Reducer
+	}
+	return nil
+}
+
+func (x *ObjectConfig) GetPatriciaTrie() bool {
+	if x != nil {
+		return x.PatriciaTrie
+	}
+	return false
+}
+
 type MapNode struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// for parent nodes only
@@ -320,15 +604,18 @@ type MapNode struct {
 	LeftHash  []byte `protobuf:"bytes,3,opt,name=left_hash,json=leftHash,proto3" json:"left_hash,omitempty"`    // set if left is non-zero
 	RightHash []byte `protobuf:"bytes,4,opt,name=right_hash,json=rightHash,proto3" json:"right_hash,omitempty"` // set if right is non-zero
 	// for leaf nodes only
-	LeafHash      []byte `protobuf:"bytes,6,opt,name=leaf_hash,json=leafHash,proto3" json:"leaf_hash,omitempty"` // if set, both left and right num must be zero
-	Path          []byte `protobuf:"bytes,7,opt,name=path,proto3" json:"path,omitempty"`                         // if set, both left and right num must be zero
+	LeafHash []byte `protobuf:"bytes,6,opt,name=leaf_hash,json=leafHash,proto3" json:"leaf_hash,omitempty"` // if set, both left and right num must be zero
+	Path     []byte `protobuf:"bytes,7,opt,name=path,proto3" json:"path,omitempty"`                         // if set, both left and right num must be zero
+	LeafSum  uint64 `protobuf:"varint,9,opt,name=leaf_sum,json=leafSum,proto3" json:"leaf_sum,omitempty"`   // for sum-tree maps, the amount in the value with leaf_hash
+	// for root nodes only
+	Pruned        bool `protobuf:"varint,8,opt,name=pruned,proto3" json:"pruned,omitempty"` // if set, the nodes below may have been deleted, so only the root hash is available
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *MapNode) Reset() {
 	*x = MapNode{}
-	mi := &file_storage_proto_msgTypes[6]
+	mi := &file_storage_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -340,7 +627,7 @@ func (x *MapNode) String() string {
 func (*MapNode) ProtoMessage() {}
 
 func (x *MapNode) ProtoReflect() protoreflect.Message {
-	mi := &file_storage_proto_msgTypes[6]
+	mi := &file_storage_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -353,7 +640,7 @@ func (x *MapNode) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use MapNode.ProtoReflect.Descriptor instead.
 func (*MapNode) Descriptor() ([]byte, []int) {
-	return file_storage_proto_rawDescGZIP(), []int{6}
+	return file_storage_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *MapNode) GetLeftNumber() int64 {
@@ -398,14 +685,240 @@ func (x *MapNode) GetPath() []byte {
 	return nil
 }
 
+func (x *MapNode) GetLeafSum() uint64 {
+	if x != nil {
+		return x.LeafSum
+	}
+	return 0
+}
+
+func (x *MapNode) GetPruned() bool {
+	if x != nil {
+		return x.Pruned
+	}
+	return false
+}
+
+// How far the nodes for a map have been compacted, stored once per map
+type MapCompaction struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Size          int64                  `protobuf:"varint,1,opt,name=size,proto3" json:"size,omitempty"`                                     // tree sizes below this have been compacted
+	LastRetained  int64                  `protobuf:"varint,2,opt,name=last_retained,json=lastRetained,proto3" json:"last_retained,omitempty"` // largest tree size below size that was retained, or 0 if none
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MapCompaction) Reset() {
+	*x = MapCompaction{}
+	mi := &file_storage_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapCompaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapCompaction) ProtoMessage() {}
+
+func (x *MapCompaction) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapCompaction.ProtoReflect.Descriptor instead.
+func (*MapCompaction) Descriptor() ([]byte, []int) {
+	return file_storage_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *MapCompaction) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *MapCompaction) GetLastRetained() int64 {
+	if x != nil {
+		return x.LastRetained
+	}
+	return 0
+}
+
+// A node in the Merkle Patricia Trie for a map, indexed by its hash
+type MapPatriciaNode struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Encoding      []byte                 `protobuf:"bytes,1,opt,name=encoding,proto3" json:"encoding,omitempty"` // RLP encoding of the node
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MapPatriciaNode) Reset() {
+	*x = MapPatriciaNode{}
+	mi := &file_storage_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapPatriciaNode) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapPatriciaNode) ProtoMessage() {}
+
+func (x *MapPatriciaNode) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapPatriciaNode.ProtoReflect.Descriptor instead.
+func (*MapPatriciaNode) Descriptor() ([]byte, []int) {
+	return file_storage_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *MapPatriciaNode) GetEncoding() []byte {
+	if x != nil {
+		return x.Encoding
+	}
+	return nil
+}
+
+// The root hash of the Merkle Patricia Trie for a map, indexed by tree size
+type MapPatriciaRoot struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RootHash      []byte                 `protobuf:"bytes,1,opt,name=root_hash,json=rootHash,proto3" json:"root_hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MapPatriciaRoot) Reset() {
+	*x = MapPatriciaRoot{}
+	mi := &file_storage_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapPatriciaRoot) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapPatriciaRoot) ProtoMessage() {}
+
+func (x *MapPatriciaRoot) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapPatriciaRoot.ProtoReflect.Descriptor instead.
+func (*MapPatriciaRoot) Descriptor() ([]byte, []int) {
+	return file_storage_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *MapPatriciaRoot) GetRootHash() []byte {
+	if x != nil {
+		return x.RootHash
+	}
+	return nil
+}
+
+// A change to the value for a key in a map, indexed by key path and the number of changes before it
+type MapKeyChange struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MutationIndex int64                  `protobuf:"varint,1,opt,name=mutation_index,json=mutationIndex,proto3" json:"mutation_index,omitempty"` // index in the mutation log of the mutation that made the change
+	LeafHash      []byte                 `protobuf:"bytes,2,opt,name=leaf_hash,json=leafHash,proto3" json:"leaf_hash,omitempty"`                 // leaf hash for the key after the change
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MapKeyChange) Reset() {
+	*x = MapKeyChange{}
+	mi := &file_storage_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MapKeyChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MapKeyChange) ProtoMessage() {}
+
+func (x *MapKeyChange) ProtoReflect() protoreflect.Message {
+	mi := &file_storage_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MapKeyChange.ProtoReflect.Descriptor instead.
+func (*MapKeyChange) Descriptor() ([]byte, []int) {
+	return file_storage_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *MapKeyChange) GetMutationIndex() int64 {
+	if x != nil {
+		return x.MutationIndex
+	}
+	return 0
+}
+
+func (x *MapKeyChange) GetLeafHash() []byte {
+	if x != nil {
+		return x.LeafHash
+	}
+	return nil
+}
+
 var File_storage_proto protoreflect.FileDescriptor
 
 const file_storage_proto_rawDesc = "" +
 	"\n" +
-	"\rstorage.proto\x12/com.continusec.verifiabledatastructures.storage\x1a\tapi.proto\"\x8d\x01\n" +
+	"\rstorage.proto\x12/com.continusec.verifiabledatastructures.storage\x1a\tapi.proto\x1a\x13configuration.proto\"\xd5\x05\n" +
 	"\bMutation\x12\x1c\n" +
 	"\tnamespace\x18\x01 \x01(\fR\tnamespace\x12c\n" +
-	"\rlog_add_entry\x18\x02 \x01(\v2?.com.continusec.verifiabledatastructures.api.LogAddEntryRequestR\vlogAddEntry\"\x1c\n" +
+	"\rlog_add_entry\x18\x02 \x01(\v2?.com.continusec.verifiabledatastructures.api.LogAddEntryRequestR\vlogAddEntry\x12\x83\x01\n" +
+	"\x1alog_add_cosigned_tree_head\x18\x03 \x01(\v2G.com.continusec.verifiabledatastructures.storage.LogAddCosignedTreeHeadR\x16logAddCosignedTreeHead\x12\x82\x01\n" +
+	"\x1aadd_inconsistent_tree_head\x18\x04 \x01(\v2E.com.continusec.verifiabledatastructures.storage.InconsistentTreeHeadR\x17addInconsistentTreeHead\x12i\n" +
+	"\x0flog_add_entries\x18\x05 \x01(\v2A.com.continusec.verifiabledatastructures.api.LogAddEntriesRequestR\rlogAddEntries\x12\\\n" +
+	"\vmap_compact\x18\x06 \x01(\v2;.com.continusec.verifiabledatastructures.storage.MapCompactR\n" +
+	"mapCompact\x12r\n" +
+	"\x12multimap_add_value\x18\a \x01(\v2D.com.continusec.verifiabledatastructures.api.MultimapAddValueRequestR\x10multimapAddValue\"\xb6\x01\n" +
+	"\n" +
+	"MapCompact\x12E\n" +
+	"\x03map\x18\x01 \x01(\v23.com.continusec.verifiabledatastructures.api.MapRefR\x03map\x12a\n" +
+	"\x06policy\x18\x02 \x01(\v2I.com.continusec.verifiabledatastructures.configuration.MapRetentionPolicyR\x06policy\"\xaa\x01\n" +
+	"\x14InconsistentTreeHead\x12H\n" +
+	"\x03log\x18\x01 \x01(\v26.com.continusec.verifiabledatastructures.api.LogGossipR\x03log\x12H\n" +
+	"\x03map\x18\x02 \x01(\v26.com.continusec.verifiabledatastructures.api.MapGossipR\x03map\"\xb5\x01\n" +
+	"\x16LogAddCosignedTreeHead\x12E\n" +
+	"\x03log\x18\x01 \x01(\v23.com.continusec.verifiabledatastructures.api.LogRefR\x03log\x12T\n" +
+	"\x04head\x18\x02 \x01(\v2@.com.continusec.verifiabledatastructures.api.LogTreeHashResponseR\x04head\"\x1c\n" +
 	"\bLeafNode\x12\x10\n" +
 	"\x03mth\x18\x01 \x01(\fR\x03mth\"\x1c\n" +
 	"\bTreeNode\x12\x10\n" +
@@ -417,7 +930,15 @@ const file_storage_proto_rawDesc = "" +
 	"\x05index\x18\x01 \x01(\x03R\x05index\" \n" +
 	"\n" +
 	"ObjectSize\x12\x12\n" +
-	"\x04size\x18\x01 \x01(\x03R\x04size\"\xba\x01\n" +
+	"\x04size\x18\x01 \x01(\x03R\x04size\"\xcb\x02\n" +
+	"\fObjectConfig\x12a\n" +
+	"\x0ehash_algorithm\x18\x01 \x01(\x0e2:.com.continusec.verifiabledatastructures.api.HashAlgorithmR\rhashAlgorithm\x12\x1d\n" +
+	"\n" +
+	"write_once\x18\x02 \x01(\bR\twriteOnce\x12$\n" +
+	"\x0evrf_public_key\x18\x03 \x01(\fR\fvrfPublicKey\x12\x1b\n" +
+	"\tsum_field\x18\x04 \x01(\tR\bsumField\x12Q\n" +
+	"\areducer\x18\x05 \x01(\v27.com.continusec.verifiabledatastructures.api.MapReducerR\areducer\x12#\n" +
+	"\rpatricia_trie\x18\x06 \x01(\bR\fpatriciaTrie\"\xed\x01\n" +
 	"\aMapNode\x12\x1f\n" +
 	"\vleft_number\x18\x01 \x01(\x03R\n" +
 	"leftNumber\x12!\n" +
@@ -426,7 +947,19 @@ const file_storage_proto_rawDesc = "" +
 	"\n" +
 	"right_hash\x18\x04 \x01(\fR\trightHash\x12\x1b\n" +
 	"\tleaf_hash\x18\x06 \x01(\fR\bleafHash\x12\x12\n" +
-	"\x04path\x18\a \x01(\fR\x04pathB3Z1github.com/continusec/verifiabledatastructures/pbb\x06proto3"
+	"\x04path\x18\a \x01(\fR\x04path\x12\x19\n" +
+	"\bleaf_sum\x18\t \x01(\x04R\aleafSum\x12\x16\n" +
+	"\x06pruned\x18\b \x01(\bR\x06pruned\"H\n" +
+	"\rMapCompaction\x12\x12\n" +
+	"\x04size\x18\x01 \x01(\x03R\x04size\x12#\n" +
+	"\rlast_retained\x18\x02 \x01(\x03R\flastRetained\"-\n" +
+	"\x0fMapPatriciaNode\x12\x1a\n" +
+	"\bencoding\x18\x01 \x01(\fR\bencoding\".\n" +
+	"\x0fMapPatriciaRoot\x12\x1b\n" +
+	"\troot_hash\x18\x01 \x01(\fR\brootHash\"R\n" +
+	"\fMapKeyChange\x12%\n" +
+	"\x0emutation_index\x18\x01 \x01(\x03R\rmutationIndex\x12\x1b\n" +
+	"\tleaf_hash\x18\x02 \x01(\fR\bleafHashB3Z1github.com/continusec/verifiabledatastructures/pbb\x06proto3"
 
 var (
 	file_storage_proto_rawDescOnce sync.Once
@@ -440,24 +973,55 @@ func file_storage_proto_rawDescGZIP() []byte {
 	return file_storage_proto_rawDescData
 }
 
-var file_storage_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_storage_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
 var file_storage_proto_goTypes = []any{
-	(*Mutation)(nil),           // 0: com.continusec.verifiabledatastructures.storage.Mutation
-	(*LeafNode)(nil),           // 1: com.continusec.verifiabledatastructures.storage.LeafNode
-	(*TreeNode)(nil),           // 2: com.continusec.verifiabledatastructures.storage.TreeNode
-	(*LogTreeHash)(nil),        // 3: com.continusec.verifiabledatastructures.storage.LogTreeHash
-	(*EntryIndex)(nil),         // 4: com.continusec.verifiabledatastructures.storage.EntryIndex
-	(*ObjectSize)(nil),         // 5: com.continusec.verifiabledatastructures.storage.ObjectSize
-	(*MapNode)(nil),            // 6: com.continusec.verifiabledatastructures.storage.MapNode
-	(*LogAddEntryRequest)(nil), // 7: com.continusec.verifiabledatastructures.api.LogAddEntryRequest
+	(*Mutation)(nil),                // 0: com.continusec.verifiabledatastructures.storage.Mutation
+	(*MapCompact)(nil),              // 1: com.continusec.verifiabledatastructures.storage.MapCompact
+	(*InconsistentTreeHead)(nil),    // 2: com.continusec.verifiabledatastructures.storage.InconsistentTreeHead
+	(*LogAddCosignedTreeHead)(nil),  // 3: com.continusec.verifiabledatastructures.storage.LogAddCosignedTreeHead
+	(*LeafNode)(nil),                // 4: com.continusec.verifiabledatastructures.storage.LeafNode
+	(*TreeNode)(nil),                // 5: com.continusec.verifiabledatastructures.storage.TreeNode
+	(*LogTreeHash)(nil),             // 6: com.continusec.verifiabledatastructures.storage.LogTreeHash
+	(*EntryIndex)(nil),              // 7: com.continusec.verifiabledatastructures.storage.EntryIndex
+	(*ObjectSize)(nil),              // 8: com.continusec.verifiabledatastructures.storage.ObjectSize
+	(*ObjectConfig)(nil),            // 9: com.continusec.verifiabledatastructures.storage.ObjectConfig
+	(*MapNode)(nil),                 // 10: com.continusec.verifiabledatastructures.storage.MapNode
+	(*MapCompaction)(nil),           // 11: com.continusec.verifiabledatastructures.storage.MapCompaction
+	(*MapPatriciaNode)(nil),         // 12: com.continusec.verifiabledatastructures.storage.MapPatriciaNode
+	(*MapPatriciaRoot)(nil),         // 13: com.continusec.verifiabledatastructures.storage.MapPatriciaRoot
+	(*MapKeyChange)(nil),            // 14: com.continusec.verifiabledatastructures.storage.MapKeyChange
+	(*LogAddEntryRequest)(nil),      // 15: com.continusec.verifiabledatastructures.api.LogAddEntryRequest
+	(*LogAddEntriesRequest)(nil),    // 16: com.continusec.verifiabledatastructures.api.LogAddEntriesRequest
+	(*MultimapAddValueRequest)(nil), // 17: com.continusec.verifiabledatastructures.api.MultimapAddValueRequest
+	(*MapRef)(nil),                  // 18: com.continusec.verifiabledatastructures.api.MapRef
+	(*MapRetentionPolicy)(nil),      // 19: com.continusec.verifiabledatastructures.configuration.MapRetentionPolicy
+	(*LogGossip)(nil),               // 20: com.continusec.verifiabledatastructures.api.LogGossip
+	(*MapGossip)(nil),               // 21: com.continusec.verifiabledatastructures.api.MapGossip
+	(*LogRef)(nil),                  // 22: com.continusec.verifiabledatastructures.api.LogRef
+	(*LogTreeHashResponse)(nil),     // 23: com.continusec.verifiabledatastructures.api.LogTreeHashResponse
+	(HashAlgorithm)(0),              // 24: com.continusec.verifiabledatastructures.api.HashAlgorithm
+	(*MapReducer)(nil),              // 25: com.continusec.verifiabledatastructures.api.MapReducer
 }
 var file_storage_proto_depIdxs = []int32{
-	7, // 0: com.continusec.verifiabledatastructures.storage.Mutation.log_add_entry:type_name -> com.continusec.verifiabledatastructures.api.LogAddEntryRequest
-	1, // [1:1] is the sub-list for method output_type
-	1, // [1:1] is the sub-list for method input_type
-	1, // [1:1] is the sub-list for extension type_name
-	1, // [1:1] is the sub-list for extension extendee
-	0, // [0:1] is the sub-list for field type_name
+	15, // 0: com.continusec.verifiabledatastructures.storage.Mutation.log_add_entry:type_name -> com.continusec.verifiabledatastructures.api.LogAddEntryRequest
+	3,  // 1: com.continusec.verifiabledatastructures.storage.Mutation.log_add_cosigned_tree_head:type_name -> com.continusec.verifiabledatastructures.storage.LogAddCosignedTreeHead
+	2,  // 2: com.continusec.verifiabledatastructures.storage.Mutation.add_inconsistent_tree_head:type_name -> com.continusec.verifiabledatastructures.storage.InconsistentTreeHead
+	16, // 3: com.continusec.verifiabledatastructures.storage.Mutation.log_add_entries:type_name -> com.continusec.verifiabledatastructures.api.LogAddEntriesRequest
+	1,  // 4: com.continusec.verifiabledatastructures.storage.Mutation.map_compact:type_name -> com.continusec.verifiabledatastructures.storage.MapCompact
+	17, // 5: com.continusec.verifiabledatastructures.storage.Mutation.multimap_add_value:type_name -> com.continusec.verifiabledatastructures.api.MultimapAddValueRequest
+	18, // 6: com.continusec.verifiabledatastructures.storage.MapCompact.map:type_name -> com.continusec.verifiabledatastructures.api.MapRef
+	19, // 7: com.continusec.verifiabledatastructures.storage.MapCompact.policy:type_name -> com.continusec.verifiabledatastructures.configuration.MapRetentionPolicy
+	20, // 8: com.continusec.verifiabledatastructures.storage.InconsistentTreeHead.log:type_name -> com.continusec.verifiabledatastructures.api.LogGossip
+	21, // 9: com.continusec.verifiabledatastructures.storage.InconsistentTreeHead.map:type_name -> com.continusec.verifiabledatastructures.api.MapGossip
+	22, // 10: com.continusec.verifiabledatastructures.storage.LogAddCosignedTreeHead.log:type_name -> com.continusec.verifiabledatastructures.api.LogRef
+	23, // 11: com.continusec.verifiabledatastructures.storage.LogAddCosignedTreeHead.head:type_name -> com.continusec.verifiabledatastructures.api.LogTreeHashResponse
+	24, // 12: com.continusec.verifiabledatastructures.storage.ObjectConfig.hash_algorithm:type_name -> com.continusec.verifiabledatastructures.api.HashAlgorithm
+	25, // 13: com.continusec.verifiabledatastructures.storage.ObjectConfig.reducer:type_name -> com.continusec.verifiabledatastructures.api.MapReducer
+	14, // [14:14] is the sub-list for method output_type
+	14, // [14:14] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
 }
 
 func init() { file_storage_proto_init() }
@@ -466,13 +1030,14 @@ func file_storage_proto_init() {
 		return
 	}
 	file_api_proto_init()
+	file_configuration_proto_init()
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_storage_proto_rawDesc), len(file_storage_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   7,
+			NumMessages:   15,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
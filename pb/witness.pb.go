@@ -0,0 +1,235 @@
+//
+//
+//Copyright 2017 Continusec Pty Ltd
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+//
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v3.21.12
+// source: witness.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type AddLogTreeHeadRequest struct {
+	state         protoimpl.MessageState       `protogen:"open.v1"`
+	Log           *LogRef                      `protobuf:"bytes,1,opt,name=log,proto3" json:"log,omitempty"`                         // api key is ignored
+	OldSize       int64                        `protobuf:"varint,2,opt,name=old_size,json=oldSize,proto3" json:"old_size,omitempty"` // size of the tree head last cosigned by this witness, 0 if none
+	Head          *LogTreeHashResponse         `protobuf:"bytes,3,opt,name=head,proto3" json:"head,omitempty"`
+	Proof         *LogConsistencyProofResponse `protobuf:"bytes,4,opt,name=proof,proto3" json:"proof,omitempty"` // from old_size to head, unless old_size is 0 or equal to the head
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddLogTreeHeadRequest) Reset() {
+	*x = AddLogTreeHeadRequest{}
+	mi := &file_witness_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddLogTreeHeadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddLogTreeHeadRequest) ProtoMessage() {}
+
+func (x *AddLogTreeHeadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_witness_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddLogTreeHeadRequest.ProtoReflect.Descriptor instead.
+func (*AddLogTreeHeadRequest) Descriptor() ([]byte, []int) {
+	return file_witness_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AddLogTreeHeadRequest) GetLog() *LogRef {
+	if x != nil {
+		return x.Log
+	}
+	return nil
+}
+
+func (x *AddLogTreeHeadRequest) GetOldSize() int64 {
+	if x != nil {
+		return x.OldSize
+	}
+	return 0
+}
+
+func (x *AddLogTreeHeadRequest) GetHead() *LogTreeHashResponse {
+	if x != nil {
+		return x.Head
+	}
+	return nil
+}
+
+func (x *AddLogTreeHeadRequest) GetProof() *LogConsistencyProofResponse {
+	if x != nil {
+		return x.Proof
+	}
+	return nil
+}
+
+type AddLogTreeHeadResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Cosignature   *TreeHeadSignature     `protobuf:"bytes,1,opt,name=cosignature,proto3" json:"cosignature,omitempty"`                     // not set if old_size does not match witness_size
+	WitnessSize   int64                  `protobuf:"varint,2,opt,name=witness_size,json=witnessSize,proto3" json:"witness_size,omitempty"` // size of the tree head this witness has now cosigned
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddLogTreeHeadResponse) Reset() {
+	*x = AddLogTreeHeadResponse{}
+	mi := &file_witness_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddLogTreeHeadResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddLogTreeHeadResponse) ProtoMessage() {}
+
+func (x *AddLogTreeHeadResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_witness_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddLogTreeHeadResponse.ProtoReflect.Descriptor instead.
+func (*AddLogTreeHeadResponse) Descriptor() ([]byte, []int) {
+	return file_witness_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *AddLogTreeHeadResponse) GetCosignature() *TreeHeadSignature {
+	if x != nil {
+		return x.Cosignature
+	}
+	return nil
+}
+
+func (x *AddLogTreeHeadResponse) GetWitnessSize() int64 {
+	if x != nil {
+		return x.WitnessSize
+	}
+	return 0
+}
+
+var File_witness_proto protoreflect.FileDescriptor
+
+const file_witness_proto_rawDesc = "" +
+	"\n" +
+	"\rwitness.proto\x12/com.continusec.verifiabledatastructures.witness\x1a\tapi.proto\"\xaf\x02\n" +
+	"\x15AddLogTreeHeadRequest\x12E\n" +
+	"\x03log\x18\x01 \x01(\v23.com.continusec.verifiabledatastructures.api.LogRefR\x03log\x12\x19\n" +
+	"\bold_size\x18\x02 \x01(\x03R\aoldSize\x12T\n" +
+	"\x04head\x18\x03 \x01(\v2@.com.continusec.verifiabledatastructures.api.LogTreeHashResponseR\x04head\x12^\n" +
+	"\x05proof\x18\x04 \x01(\v2H.com.continusec.verifiabledatastructures.api.LogConsistencyProofResponseR\x05proof\"\x9d\x01\n" +
+	"\x16AddLogTreeHeadResponse\x12`\n" +
+	"\vcosignature\x18\x01 \x01(\v2>.com.continusec.verifiabledatastructures.api.TreeHeadSignatureR\vcosignature\x12!\n" +
+	"\fwitness_size\x18\x02 \x01(\x03R\vwitnessSize2\xb6\x01\n" +
+	"\x0eWitnessService\x12\xa3\x01\n" +
+	"\x0eAddLogTreeHead\x12F.com.continusec.verifiabledatastructures.witness.AddLogTreeHeadRequest\x1aG.com.continusec.verifiabledatastructures.witness.AddLogTreeHeadResponse\"\x00B3Z1github.com/continusec/verifiabledatastructures/pbb\x06proto3"
+
+var (
+	file_witness_proto_rawDescOnce sync.Once
+	file_witness_proto_rawDescData []byte
+)
+
+func file_witness_proto_rawDescGZIP() []byte {
+	file_witness_proto_rawDescOnce.Do(func() {
+		file_witness_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_witness_proto_rawDesc), len(file_witness_proto_rawDesc)))
+	})
+	return file_witness_proto_rawDescData
+}
+
+var file_witness_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_witness_proto_goTypes = []any{
+	(*AddLogTreeHeadRequest)(nil),       // 0: com.continusec.verifiabledatastructures.witness.AddLogTreeHeadRequest
+	(*AddLogTreeHeadResponse)(nil),      // 1: com.continusec.verifiabledatastructures.witness.AddLogTreeHeadResponse
+	(*LogRef)(nil),                      // 2: com.continusec.verifiabledatastructures.api.LogRef
+	(*LogTreeHashResponse)(nil),         // 3: com.continusec.verifiabledatastructures.api.LogTreeHashResponse
+	(*LogConsistencyProofResponse)(nil), // 4: com.continusec.verifiabledatastructures.api.LogConsistencyProofResponse
+	(*TreeHeadSignature)(nil),           // 5: com.continusec.verifiabledatastructures.api.TreeHeadSignature
+}
+var file_witness_proto_depIdxs = []int32{
+	2, // 0: com.continusec.verifiabledatastructures.witness.AddLogTreeHeadRequest.log:type_name -> com.continusec.verifiabledatastructures.api.LogRef
+	3, // 1: com.continusec.verifiabledatastructures.witness.AddLogTreeHeadRequest.head:type_name -> com.continusec.verifiabledatastructures.api.LogTreeHashResponse
+	4, // 2: com.continusec.verifiabledatastructures.witness.AddLogTreeHeadRequest.proof:type_name -> com.continusec.verifiabledatastructures.api.LogConsistencyProofResponse
+	5, // 3: com.continusec.verifiabledatastructures.witness.AddLogTreeHeadResponse.cosignature:type_name -> com.continusec.verifiabledatastructures.api.TreeHeadSignature
+	0, // 4: com.continusec.verifiabledatastructures.witness.WitnessService.AddLogTreeHead:input_type -> com.continusec.verifiabledatastructures.witness.AddLogTreeHeadRequest
+	1, // 5: com.continusec.verifiabledatastructures.witness.WitnessService.AddLogTreeHead:output_type -> com.continusec.verifiabledatastructures.witness.AddLogTreeHeadResponse
+	5, // [5:6] is the sub-list for method output_type
+	4, // [4:5] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_witness_proto_init() }
+func file_witness_proto_init() {
+	if File_witness_proto != nil {
+		return
+	}
+	file_api_proto_init()
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_witness_proto_rawDesc), len(file_witness_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_witness_proto_goTypes,
+		DependencyIndexes: file_witness_proto_depIdxs,
+		MessageInfos:      file_witness_proto_msgTypes,
+	}.Build()
+	File_witness_proto = out.File
+	file_witness_proto_goTypes = nil
+	file_witness_proto_depIdxs = nil
+}
@@ -0,0 +1,138 @@
+//
+//
+//Copyright 2017 Continusec Pty Ltd
+//
+//Licensed under the Apache License, Version 2.0 (the "License");
+//you may not use this file except in compliance with the License.
+//You may obtain a copy of the License at
+//
+//http://www.apache.org/licenses/LICENSE-2.0
+//
+//Unless required by applicable law or agreed to in writing, software
+//distributed under the License is distributed on an "AS IS" BASIS,
+//WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+//See the License for the specific language governing permissions and
+//limitations under the License.
+//
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v3.21.12
+// source: witness.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	WitnessService_AddLogTreeHead_FullMethodName = "/com.continusec.verifiabledatastructures.witness.WitnessService/AddLogTreeHead"
+)
+
+// WitnessServiceClient is the client API for WitnessService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type WitnessServiceClient interface {
+	AddLogTreeHead(ctx context.Context, in *AddLogTreeHeadRequest, opts ...grpc.CallOption) (*AddLogTreeHeadResponse, error)
+}
+
+type witnessServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewWitnessServiceClient(cc grpc.ClientConnInterface) WitnessServiceClient {
+	return &witnessServiceClient{cc}
+}
+
+func (c *witnessServiceClient) AddLogTreeHead(ctx context.Context, in *AddLogTreeHeadRequest, opts ...grpc.CallOption) (*AddLogTreeHeadResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddLogTreeHeadResponse)
+	err := c.cc.Invoke(ctx, WitnessService_AddLogTreeHead_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// WitnessServiceServer is the server API for WitnessService service.
+// All implementations must embed UnimplementedWitnessServiceServer
+// for forward compatibility.
+type WitnessServiceServer interface {
+	AddLogTreeHead(context.Context, *AddLogTreeHeadRequest) (*AddLogTreeHeadResponse, error)
+	mustEmbedUnimplementedWitnessServiceServer()
+}
+
+// UnimplementedWitnessServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedWitnessServiceServer struct{}
+
+func (UnimplementedWitnessServiceServer) AddLogTreeHead(context.Context, *AddLogTreeHeadRequest) (*AddLogTreeHeadResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddLogTreeHead not implemented")
+}
+func (UnimplementedWitnessServiceServer) mustEmbedUnimplementedWitnessServiceServer() {}
+func (UnimplementedWitnessServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeWitnessServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to WitnessServiceServer will
+// result in compilation errors.
+type UnsafeWitnessServiceServer interface {
+	mustEmbedUnimplementedWitnessServiceServer()
+}
+
+func RegisterWitnessServiceServer(s grpc.ServiceRegistrar, srv WitnessServiceServer) {
+	// If the following call pancis, it indicates UnimplementedWitnessServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&WitnessService_ServiceDesc, srv)
+}
+
+func _WitnessService_AddLogTreeHead_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddLogTreeHeadRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(WitnessServiceServer).AddLogTreeHead(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: WitnessService_AddLogTreeHead_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(WitnessServiceServer).AddLogTreeHead(ctx, req.(*AddLogTreeHeadRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// WitnessService_ServiceDesc is the grpc.ServiceDesc for WitnessService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var WitnessService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "com.continusec.verifiabledatastructures.witness.WitnessService",
+	HandlerType: (*WitnessServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AddLogTreeHead",
+			Handler:    _WitnessService_AddLogTreeHead_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "witness.proto",
+}
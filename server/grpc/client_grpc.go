@@ -20,6 +20,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"errors"
+	"io"
 	"log"
 
 	"golang.org/x/net/context"
@@ -52,6 +53,30 @@ type Client struct {
 // Dial connects to the server and returns an object to communicate with it.
 // Most users will wrap this with the higher-level API.
 func (g *Client) Dial() (pb.VerifiableDataStructuresServiceServer, error) {
+	conn, err := g.dialConn()
+	if err != nil {
+		return nil, err
+	}
+
+	return &wrapSillyClientAsServer{
+		Client: pb.NewVerifiableDataStructuresServiceClient(conn),
+	}, nil
+}
+
+// DialWitness connects to a witness server and returns an object to communicate with it.
+// This is normally passed to a verifiable.Service as one of its Witnesses.
+func (g *Client) DialWitness() (pb.WitnessServiceServer, error) {
+	conn, err := g.dialConn()
+	if err != nil {
+		return nil, err
+	}
+
+	return &wrapWitnessClientAsServer{
+		Client: pb.NewWitnessServiceClient(conn),
+	}, nil
+}
+
+func (g *Client) dialConn() (*grpc.ClientConn, error) {
 	var dialOptions []grpc.DialOption
 	if g.NoGrpcSecurity {
 		log.Println("WARNING: Disabling TLS  when connecting to gRPC server")
@@ -67,14 +92,7 @@ func (g *Client) Dial() (pb.VerifiableDataStructuresServiceServer, error) {
 		dialOptions = append(dialOptions, grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{}))) // uses the system CA pool
 	}
 
-	conn, err := grpc.Dial(g.Address, dialOptions...)
-	if err != nil {
-		return nil, err
-	}
-
-	return &wrapSillyClientAsServer{
-		Client: pb.NewVerifiableDataStructuresServiceClient(conn),
-	}, nil
+	return grpc.Dial(g.Address, dialOptions...)
 }
 
 // MustDial is a convenience method that exits with a fatal error if the operation fails
@@ -86,6 +104,15 @@ func (g *Client) MustDial() pb.VerifiableDataStructuresServiceServer {
 	return rv
 }
 
+// MustDialWitness is a convenience method that exits with a fatal error if the operation fails
+func (g *Client) MustDialWitness() pb.WitnessServiceServer {
+	rv, err := g.DialWitness()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return rv
+}
+
 type wrapSillyClientAsServer struct {
 	pb.UnimplementedVerifiableDataStructuresServiceServer
 	Client pb.VerifiableDataStructuresServiceClient
@@ -95,14 +122,74 @@ func (w *wrapSillyClientAsServer) LogAddEntry(ctx context.Context, r *pb.LogAddE
 	return w.Client.LogAddEntry(ctx, r)
 }
 
+func (w *wrapSillyClientAsServer) LogAddEntries(ctx context.Context, r *pb.LogAddEntriesRequest) (*pb.LogAddEntriesResponse, error) {
+	return w.Client.LogAddEntries(ctx, r)
+}
+
 func (w *wrapSillyClientAsServer) LogFetchEntries(ctx context.Context, r *pb.LogFetchEntriesRequest) (*pb.LogFetchEntriesResponse, error) {
 	return w.Client.LogFetchEntries(ctx, r)
 }
 
+func (w *wrapSillyClientAsServer) StreamLogEntries(r *pb.StreamLogEntriesRequest, stream pb.VerifiableDataStructuresService_StreamLogEntriesServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	cs, err := w.Client.StreamLogEntries(ctx, r)
+	if err != nil {
+		return err
+	}
+	return forwardStream[pb.StreamLogEntriesResponse](cs, stream)
+}
+
+func (w *wrapSillyClientAsServer) WatchLogTreeHead(r *pb.WatchLogTreeHeadRequest, stream pb.VerifiableDataStructuresService_WatchLogTreeHeadServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	cs, err := w.Client.WatchLogTreeHead(ctx, r)
+	if err != nil {
+		return err
+	}
+	return forwardStream[pb.LogTreeHashResponse](cs, stream)
+}
+
+func (w *wrapSillyClientAsServer) WatchMapTreeHead(r *pb.WatchMapTreeHeadRequest, stream pb.VerifiableDataStructuresService_WatchMapTreeHeadServer) error {
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	cs, err := w.Client.WatchMapTreeHead(ctx, r)
+	if err != nil {
+		return err
+	}
+	return forwardStream[pb.MapTreeHashResponse](cs, stream)
+}
+
+// forwardStream sends each response received from the server to the stream passed by the caller.
+// Callers should cancel the context used for the client stream when done.
+func forwardStream[T any](cs grpc.ServerStreamingClient[T], stream grpc.ServerStreamingServer[T]) error {
+	for {
+		resp, err := cs.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		err = stream.Send(resp)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (w *wrapSillyClientAsServer) LogFetchEntryBundle(ctx context.Context, r *pb.LogFetchEntryBundleRequest) (*pb.LogFetchEntryBundleResponse, error) {
+	return w.Client.LogFetchEntryBundle(ctx, r)
+}
+
 func (w *wrapSillyClientAsServer) LogTreeHash(ctx context.Context, r *pb.LogTreeHashRequest) (*pb.LogTreeHashResponse, error) {
 	return w.Client.LogTreeHash(ctx, r)
 }
 
+func (w *wrapSillyClientAsServer) LogBatchInclusionProof(ctx context.Context, r *pb.LogBatchInclusionProofRequest) (*pb.LogBatchInclusionProofResponse, error) {
+	return w.Client.LogBatchInclusionProof(ctx, r)
+}
+
 func (w *wrapSillyClientAsServer) LogInclusionProof(ctx context.Context, r *pb.LogInclusionProofRequest) (*pb.LogInclusionProofResponse, error) {
 	return w.Client.LogInclusionProof(ctx, r)
 }
@@ -111,6 +198,14 @@ func (w *wrapSillyClientAsServer) LogConsistencyProof(ctx context.Context, r *pb
 	return w.Client.LogConsistencyProof(ctx, r)
 }
 
+func (w *wrapSillyClientAsServer) LogCheckpoint(ctx context.Context, r *pb.LogCheckpointRequest) (*pb.LogCheckpointResponse, error) {
+	return w.Client.LogCheckpoint(ctx, r)
+}
+
+func (w *wrapSillyClientAsServer) MapApplyTransaction(ctx context.Context, r *pb.MapApplyTransactionRequest) (*pb.MapApplyTransactionResponse, error) {
+	return w.Client.MapApplyTransaction(ctx, r)
+}
+
 func (w *wrapSillyClientAsServer) MapSetValue(ctx context.Context, r *pb.MapSetValueRequest) (*pb.MapSetValueResponse, error) {
 	return w.Client.MapSetValue(ctx, r)
 }
@@ -119,6 +214,59 @@ func (w *wrapSillyClientAsServer) MapGetValue(ctx context.Context, r *pb.MapGetV
 	return w.Client.MapGetValue(ctx, r)
 }
 
+func (w *wrapSillyClientAsServer) MapGetValues(ctx context.Context, r *pb.MapGetValuesRequest) (*pb.MapGetValuesResponse, error) {
+	return w.Client.MapGetValues(ctx, r)
+}
+
+func (w *wrapSillyClientAsServer) MapGetKeyHistory(ctx context.Context, r *pb.MapGetKeyHistoryRequest) (*pb.MapGetKeyHistoryResponse, error) {
+	return w.Client.MapGetKeyHistory(ctx, r)
+}
+
+func (w *wrapSillyClientAsServer) MapListLeaves(ctx context.Context, r *pb.MapListLeavesRequest) (*pb.MapListLeavesResponse, error) {
+	return w.Client.MapListLeaves(ctx, r)
+}
+
+func (w *wrapSillyClientAsServer) MapDiff(ctx context.Context, r *pb.MapDiffRequest) (*pb.MapDiffResponse, error) {
+	return w.Client.MapDiff(ctx, r)
+}
+
+func (w *wrapSillyClientAsServer) MapGetValueBundle(ctx context.Context, r *pb.MapGetValueBundleRequest) (*pb.MapGetValueBundleResponse, error) {
+	return w.Client.MapGetValueBundle(ctx, r)
+}
+
 func (w *wrapSillyClientAsServer) MapTreeHash(ctx context.Context, r *pb.MapTreeHashRequest) (*pb.MapTreeHashResponse, error) {
 	return w.Client.MapTreeHash(ctx, r)
 }
+
+func (w *wrapSillyClientAsServer) MultimapAddValue(ctx context.Context, r *pb.MultimapAddValueRequest) (*pb.MultimapAddValueResponse, error) {
+	return w.Client.MultimapAddValue(ctx, r)
+}
+
+func (w *wrapSillyClientAsServer) MultimapGetValues(ctx context.Context, r *pb.MultimapGetValuesRequest) (*pb.MultimapGetValuesResponse, error) {
+	return w.Client.MultimapGetValues(ctx, r)
+}
+
+func (w *wrapSillyClientAsServer) MultimapInclusionProof(ctx context.Context, r *pb.MultimapInclusionProofRequest) (*pb.MultimapInclusionProofResponse, error) {
+	return w.Client.MultimapInclusionProof(ctx, r)
+}
+
+func (w *wrapSillyClientAsServer) MultimapKeyConsistencyProof(ctx context.Context, r *pb.MultimapKeyConsistencyProofRequest) (*pb.MultimapKeyConsistencyProofResponse, error) {
+	return w.Client.MultimapKeyConsistencyProof(ctx, r)
+}
+
+func (w *wrapSillyClientAsServer) MultimapTreeHash(ctx context.Context, r *pb.MultimapTreeHashRequest) (*pb.MapTreeHashResponse, error) {
+	return w.Client.MultimapTreeHash(ctx, r)
+}
+
+func (w *wrapSillyClientAsServer) Gossip(ctx context.Context, r *pb.GossipRequest) (*pb.GossipResponse, error) {
+	return w.Client.Gossip(ctx, r)
+}
+
+type wrapWitnessClientAsServer struct {
+	pb.UnimplementedWitnessServiceServer
+	Client pb.WitnessServiceClient
+}
+
+func (w *wrapWitnessClientAsServer) AddLogTreeHead(ctx context.Context, r *pb.AddLogTreeHeadRequest) (*pb.AddLogTreeHeadResponse, error) {
+	return w.Client.AddLogTreeHead(ctx, r)
+}
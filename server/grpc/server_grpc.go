@@ -53,3 +53,29 @@ func StartServer(conf *pb.ServerConfig, server pb.VerifiableDataStructuresServic
 
 	grpcServer.Serve(lis)
 }
+
+// StartWitnessServer starts a gRPC server for a witness. Normally this is an instance
+// of witness.Service.
+func StartWitnessServer(conf *pb.WitnessConfig, server pb.WitnessServiceServer) {
+	lis, err := net.Listen(conf.GrpcListenProtocol, conf.GrpcListenBind)
+	if err != nil {
+		log.Fatalf("Error establishing server listener: %s\n", err)
+	}
+	var grpcServer *grpc.Server
+	if conf.InsecureServerForTesting {
+		log.Println("WARNING: InsecureServerForTesting is set, your connections will not be encrypted")
+		grpcServer = grpc.NewServer()
+	} else {
+		tc, err := credentials.NewServerTLSFromFile(conf.ServerCertPath, conf.ServerKeyPath)
+		if err != nil {
+			log.Fatalf("Error reading server keys/certs: %s\n", err)
+		}
+		grpcServer = grpc.NewServer(grpc.Creds(tc))
+	}
+
+	pb.RegisterWitnessServiceServer(grpcServer, server)
+
+	log.Printf("Listening grpc on %s...", conf.GrpcListenBind)
+
+	grpcServer.Serve(lis)
+}
@@ -17,6 +17,7 @@
 package httprest
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/hex"
 	"encoding/json"
@@ -67,31 +68,79 @@ func (c *Client) MustDial() pb.VerifiableDataStructuresServiceServer {
 type httpRestImpl Client
 
 func (c *httpRestImpl) makeLogRequest(log *pb.LogRef, method, path string, data []byte, headers [][2]string) ([]byte, http.Header, error) {
-	prefix := ""
+	prefix, err := logPrefix(log)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.makeRequest(log.Account, method, prefix+path, data, withHashAlgorithm(log.HashAlgorithm, headers))
+}
+
+// logPrefix returns the path for the log, relative to the API version
+func logPrefix(log *pb.LogRef) (string, error) {
 	switch log.LogType {
 	case pb.LogType_STRUCT_TYPE_LOG:
-		prefix = fmt.Sprintf("/account/%s/log/%s", log.Account.Id, log.Name)
+		return fmt.Sprintf("/account/%s/log/%s", log.Account.Id, log.Name), nil
 	case pb.LogType_STRUCT_TYPE_MUTATION_LOG:
-		prefix = fmt.Sprintf("/account/%s/map/%s/log/mutation", log.Account.Id, log.Name)
+		return fmt.Sprintf("/account/%s/map/%s/log/mutation", log.Account.Id, log.Name), nil
 	case pb.LogType_STRUCT_TYPE_TREEHEAD_LOG:
-		prefix = fmt.Sprintf("/account/%s/map/%s/log/treehead", log.Account.Id, log.Name)
+		return fmt.Sprintf("/account/%s/map/%s/log/treehead", log.Account.Id, log.Name), nil
 	default:
-		return nil, nil, verifiable.ErrInvalidRequest
+		return "", verifiable.ErrInvalidRequest
 	}
-	return c.makeRequest(log.Account, method, prefix+path, data, headers)
 }
 
 func (c *httpRestImpl) makeMapRequest(vmap *pb.MapRef, method, path string, data []byte, headers [][2]string) ([]byte, http.Header, error) {
-	return c.makeRequest(vmap.Account, method, fmt.Sprintf("/account/%s/map/%s", vmap.Account.Id, vmap.Name)+path, data, headers)
+	if vmap.WriteOnce {
+		headers = append(headers, [2]string{"X-Verified-Write-Once", "true"})
+	}
+	if vmap.VrfKeys {
+		headers = append(headers, [2]string{"X-Verified-VRF-Keys", "true"})
+	}
+	if vmap.SumField != "" {
+		headers = append(headers, [2]string{"X-Verified-Sum-Field", vmap.SumField})
+	}
+	if vmap.PatriciaTrie {
+		headers = append(headers, [2]string{"X-Verified-Patricia-Trie", "true"})
+	}
+	return c.makeRequest(vmap.Account, method, fmt.Sprintf("/account/%s/map/%s", vmap.Account.Id, vmap.Name)+path, data, withHashAlgorithm(vmap.HashAlgorithm, headers))
+}
+
+func (c *httpRestImpl) makeMultimapRequest(mm *pb.MultimapRef, method, path string, data []byte, headers [][2]string) ([]byte, http.Header, error) {
+	return c.makeRequest(mm.Account, method, fmt.Sprintf("/account/%s/multimap/%s", mm.Account.Id, mm.Name)+path, data, withHashAlgorithm(mm.HashAlgorithm, headers))
+}
+
+// withHashAlgorithm adds a header for the hash algorithm if not the default
+func withHashAlgorithm(alg pb.HashAlgorithm, headers [][2]string) [][2]string {
+	if alg == pb.HashAlgorithm_HASH_SHA256 {
+		return headers
+	}
+	return append(headers, [2]string{"X-Verified-Hash-Algorithm", alg.String()})
 }
 
 // Intended for internal use, MakeRequest makes an HTTP request and converts the error
 // code to those appropriate for the rest of the library.
 func (c *httpRestImpl) makeRequest(account *pb.AccountRef, method, path string, data []byte, headers [][2]string) ([]byte, http.Header, error) {
-	req, err := http.NewRequest(method, c.BaseURL+restAPIVersion+path, bytes.NewReader(data))
+	resp, err := c.openRequest(context.Background(), account, method, path, data, headers)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer resp.Body.Close()
+	contents, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, nil, err
 	}
+	return contents, resp.Header, nil
+}
+
+// openRequest makes an HTTP request, and if successful returns the response for the caller to
+// read and close. Error codes are converted to those appropriate for the rest of the library.
+func (c *httpRestImpl) openRequest(ctx context.Context, account *pb.AccountRef, method, path string, data []byte, headers [][2]string) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.BaseURL+restAPIVersion+path, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
 	if account.ApiKey != "" {
 		req.Header.Set("Authorization", "Key "+account.ApiKey)
 	}
@@ -106,27 +155,55 @@ func (c *httpRestImpl) makeRequest(account *pb.AccountRef, method, path string,
 
 	resp, err := httpC.Do(req)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	defer resp.Body.Close()
-	contents, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, nil, err
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
 	}
+	resp.Body.Close()
 
 	switch resp.StatusCode {
-	case http.StatusOK:
-		return contents, resp.Header, nil
 	case http.StatusUnauthorized:
-		return nil, nil, status.Error(codes.PermissionDenied, "")
+		return nil, status.Error(codes.PermissionDenied, "")
 	case http.StatusBadRequest:
-		return nil, nil, status.Error(codes.InvalidArgument, "")
+		return nil, status.Error(codes.InvalidArgument, "")
 	case http.StatusNotFound:
-		return nil, nil, status.Error(codes.NotFound, "")
+		return nil, status.Error(codes.NotFound, "")
+	case http.StatusNotImplemented:
+		return nil, status.Error(codes.Unimplemented, "")
 	default:
-		return nil, nil, status.Error(codes.Internal, "")
+		return nil, status.Error(codes.Internal, "")
+	}
+}
+
+// readEvents passes the data of each server-sent event read from r to send, until r is exhausted
+// or send fails.
+func readEvents[T any](r io.Reader, send func(*T) error) error {
+	scanner := bufio.NewScanner(r)
+	var data []byte
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		switch {
+		case len(line) == 0: // end of event
+			if len(data) == 0 {
+				continue
+			}
+			var rv T
+			err := json.Unmarshal(data, &rv)
+			if err != nil {
+				return err
+			}
+			err = send(&rv)
+			if err != nil {
+				return err
+			}
+			data = nil
+		case bytes.HasPrefix(line, []byte("data:")):
+			data = append(data, bytes.TrimPrefix(bytes.TrimPrefix(line, []byte("data:")), []byte(" "))...)
+		}
 	}
+	return scanner.Err()
 }
 
 // LogAddEntry adds an entry to the log.
@@ -147,6 +224,24 @@ func (c *httpRestImpl) LogAddEntry(ctx context.Context, req *pb.LogAddEntryReque
 	return &rv, nil
 }
 
+// LogAddEntries adds a batch of entries to the log.
+func (c *httpRestImpl) LogAddEntries(ctx context.Context, req *pb.LogAddEntriesRequest) (*pb.LogAddEntriesResponse, error) {
+	reqData, err := json.Marshal(&pb.LogAddEntriesRequest{Values: req.Values})
+	if err != nil {
+		return nil, err
+	}
+	contents, _, err := c.makeLogRequest(req.Log, "POST", "/entries/extra", reqData, nil)
+	if err != nil {
+		return nil, err
+	}
+	var rv pb.LogAddEntriesResponse
+	err = json.Unmarshal(contents, &rv)
+	if err != nil {
+		return nil, err
+	}
+	return &rv, nil
+}
+
 // LogFetchEntries fetches entries from the log
 func (c *httpRestImpl) LogFetchEntries(ctx context.Context, req *pb.LogFetchEntriesRequest) (*pb.LogFetchEntriesResponse, error) {
 	contents, _, err := c.makeLogRequest(req.Log, "GET", fmt.Sprintf("/entries/%d-%d%s", req.First, req.Last, "/extra"), nil, nil)
@@ -162,9 +257,27 @@ func (c *httpRestImpl) LogFetchEntries(ctx context.Context, req *pb.LogFetchEntr
 	return &rv, nil
 }
 
+// LogFetchEntryBundle fetches an entry along with the tree hash and proofs needed to verify it
+func (c *httpRestImpl) LogFetchEntryBundle(ctx context.Context, req *pb.LogFetchEntryBundleRequest) (*pb.LogFetchEntryBundleResponse, error) {
+	contents, _, err := c.makeLogRequest(req.Log, "GET", fmt.Sprintf("/tree/%d/bundle/%d/from/%d", req.TreeSize, req.LeafIndex, req.FromSize), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var rv pb.LogFetchEntryBundleResponse
+	err = json.Unmarshal(contents, &rv)
+	if err != nil {
+		return nil, err
+	}
+	return &rv, nil
+}
+
 // LogTreeHash fetches the tree hash from the log
 func (c *httpRestImpl) LogTreeHash(ctx context.Context, req *pb.LogTreeHashRequest) (*pb.LogTreeHashResponse, error) {
-	contents, _, err := c.makeLogRequest(req.Log, "GET", fmt.Sprintf("/tree/%d", req.TreeSize), nil, nil)
+	path := fmt.Sprintf("/tree/%d", req.TreeSize)
+	if req.Cosigned {
+		path += "/cosigned"
+	}
+	contents, _, err := c.makeLogRequest(req.Log, "GET", path, nil, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -176,6 +289,31 @@ func (c *httpRestImpl) LogTreeHash(ctx context.Context, req *pb.LogTreeHashReque
 	return &rv, nil
 }
 
+// WatchLogTreeHead receives each new tree hash from the log as it is committed
+func (c *httpRestImpl) WatchLogTreeHead(req *pb.WatchLogTreeHeadRequest, stream pb.VerifiableDataStructuresService_WatchLogTreeHeadServer) error {
+	prefix, err := logPrefix(req.Log)
+	if err != nil {
+		return err
+	}
+	resp, err := c.openRequest(stream.Context(), req.Log.Account, "GET", prefix+fmt.Sprintf("/tree/%d/watch", req.TreeSize), nil, withHashAlgorithm(req.Log.HashAlgorithm, nil))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return readEvents(resp.Body, stream.Send)
+}
+
+// LogCheckpoint fetches the tree hash from the log as a signed checkpoint
+func (c *httpRestImpl) LogCheckpoint(ctx context.Context, req *pb.LogCheckpointRequest) (*pb.LogCheckpointResponse, error) {
+	contents, _, err := c.makeLogRequest(req.Log, "GET", fmt.Sprintf("/tree/%d/checkpoint", req.TreeSize), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.LogCheckpointResponse{
+		Checkpoint: contents,
+	}, nil
+}
+
 // LogInclusionProof fetches an inclusion proof from the logs
 func (c *httpRestImpl) LogInclusionProof(ctx context.Context, req *pb.LogInclusionProofRequest) (*pb.LogInclusionProofResponse, error) {
 	if len(req.MtlHash) == 0 {
@@ -202,6 +340,24 @@ func (c *httpRestImpl) LogInclusionProof(ctx context.Context, req *pb.LogInclusi
 	return &rv, nil
 }
 
+// LogBatchInclusionProof fetches a single inclusion proof for a batch of leaves from the log
+func (c *httpRestImpl) LogBatchInclusionProof(ctx context.Context, req *pb.LogBatchInclusionProofRequest) (*pb.LogBatchInclusionProofResponse, error) {
+	reqData, err := json.Marshal(&pb.LogBatchInclusionProofRequest{MtlHashes: req.MtlHashes, LeafIndices: req.LeafIndices})
+	if err != nil {
+		return nil, err
+	}
+	contents, _, err := c.makeLogRequest(req.Log, "POST", fmt.Sprintf("/tree/%d/inclusion", req.TreeSize), reqData, nil)
+	if err != nil {
+		return nil, err
+	}
+	var rv pb.LogBatchInclusionProofResponse
+	err = json.Unmarshal(contents, &rv)
+	if err != nil {
+		return nil, err
+	}
+	return &rv, nil
+}
+
 // LogConsistencyProof fetches a consistency proof from the log
 func (c *httpRestImpl) LogConsistencyProof(ctx context.Context, req *pb.LogConsistencyProofRequest) (*pb.LogConsistencyProofResponse, error) {
 	contents, _, err := c.makeLogRequest(req.Log, "GET", fmt.Sprintf("/tree/%d/consistency/%d", req.TreeSize, req.FromSize), nil, nil)
@@ -262,6 +418,79 @@ func (c *httpRestImpl) MapSetValue(ctx context.Context, req *pb.MapSetValueReque
 			return nil, err
 		}
 		return &rv, nil
+	case "create":
+		reqData, err := json.Marshal(req.Mutation.Value)
+		if err != nil {
+			return nil, err
+		}
+		contents, _, err := c.makeMapRequest(req.Map, "PUT", "/key/h/"+hex.EncodeToString(req.Mutation.Key)+"/extra", reqData, [][2]string{
+			[2]string{"X-Create-Only", "true"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		var rv pb.MapSetValueResponse
+		err = json.Unmarshal(contents, &rv)
+		if err != nil {
+			return nil, err
+		}
+		return &rv, nil
+	case "set_if_version":
+		reqData, err := json.Marshal(req.Mutation.Value)
+		if err != nil {
+			return nil, err
+		}
+		contents, _, err := c.makeMapRequest(req.Map, "PUT", "/key/h/"+hex.EncodeToString(req.Mutation.Key)+"/extra", reqData, [][2]string{
+			[2]string{"X-Previous-Version", strconv.FormatInt(req.Mutation.Version, 10)},
+		})
+		if err != nil {
+			return nil, err
+		}
+		var rv pb.MapSetValueResponse
+		err = json.Unmarshal(contents, &rv)
+		if err != nil {
+			return nil, err
+		}
+		return &rv, nil
+	case "patch":
+		contents, _, err := c.makeMapRequest(req.Map, "PATCH", "/key/h/"+hex.EncodeToString(req.Mutation.Key), req.Mutation.Patch, nil)
+		if err != nil {
+			return nil, err
+		}
+		var rv pb.MapSetValueResponse
+		err = json.Unmarshal(contents, &rv)
+		if err != nil {
+			return nil, err
+		}
+		return &rv, nil
+	case "increment":
+		reqData, err := json.Marshal(&pb.MapMutation{Field: req.Mutation.Field, Delta: req.Mutation.Delta})
+		if err != nil {
+			return nil, err
+		}
+		contents, _, err := c.makeMapRequest(req.Map, "POST", "/key/h/"+hex.EncodeToString(req.Mutation.Key)+"/increment", reqData, nil)
+		if err != nil {
+			return nil, err
+		}
+		var rv pb.MapSetValueResponse
+		err = json.Unmarshal(contents, &rv)
+		if err != nil {
+			return nil, err
+		}
+		return &rv, nil
+	case "compare_and_delete":
+		contents, _, err := c.makeMapRequest(req.Map, "DELETE", "/key/h/"+hex.EncodeToString(req.Mutation.Key), nil, [][2]string{
+			[2]string{"X-Previous-LeafHash", hex.EncodeToString(req.Mutation.PreviousLeafHash)},
+		})
+		if err != nil {
+			return nil, err
+		}
+		var rv pb.MapSetValueResponse
+		err = json.Unmarshal(contents, &rv)
+		if err != nil {
+			return nil, err
+		}
+		return &rv, nil
 	default:
 		return nil, verifiable.ErrInvalidRequest
 	}
@@ -293,14 +522,61 @@ func parseHeadersForProof(headers http.Header) ([][]byte, error) {
 	return prv, nil
 }
 
+// parseHeadersForCompactProof returns the hashes of a compact proof, in the order they were sent
+func parseHeadersForCompactProof(headers http.Header) ([][]byte, error) {
+	var rv [][]byte
+	for _, h := range headers[http.CanonicalHeaderKey("X-Verified-Proof")] {
+		for _, commad := range strings.Split(h, ",") {
+			bs, err := hex.DecodeString(strings.TrimSpace(commad))
+			if err != nil {
+				return nil, err
+			}
+			rv = append(rv, bs)
+		}
+	}
+	return rv, nil
+}
+
+// MapApplyTransaction sets, deletes and updates many values in the map as a single mutation
+func (c *httpRestImpl) MapApplyTransaction(ctx context.Context, req *pb.MapApplyTransactionRequest) (*pb.MapApplyTransactionResponse, error) {
+	reqData, err := json.Marshal(&pb.MapApplyTransactionRequest{Mutations: req.Mutations})
+	if err != nil {
+		return nil, err
+	}
+	contents, _, err := c.makeMapRequest(req.Map, "POST", "/transaction", reqData, nil)
+	if err != nil {
+		return nil, err
+	}
+	var rv pb.MapApplyTransactionResponse
+	err = json.Unmarshal(contents, &rv)
+	if err != nil {
+		return nil, err
+	}
+	return &rv, nil
+}
+
 // MapGetValue gets the value from the map
 func (c *httpRestImpl) MapGetValue(ctx context.Context, req *pb.MapGetValueRequest) (*pb.MapGetValueResponse, error) {
-	value, headers, err := c.makeMapRequest(req.Map, "GET", fmt.Sprintf("/tree/%d/key/h/%s%s", req.TreeSize, hex.EncodeToString(req.Key), "/extra"), nil, nil)
+	var reqHeaders [][2]string
+	if req.CompactProof {
+		reqHeaders = append(reqHeaders, [2]string{"X-Verified-Proof-Format", compactProofFormat})
+	}
+	value, headers, err := c.makeMapRequest(req.Map, "GET", fmt.Sprintf("/tree/%d/key/h/%s%s", req.TreeSize, hex.EncodeToString(req.Key), "/extra"), nil, reqHeaders)
 	if err != nil {
 		return nil, err
 	}
 
-	prv, err := parseHeadersForProof(headers)
+	var prv, compact [][]byte
+	var bitmap []byte
+	if bm := headers.Get("X-Verified-Proof-Bitmap"); bm != "" {
+		bitmap, err = hex.DecodeString(strings.TrimSpace(bm))
+		if err != nil {
+			return nil, err
+		}
+		compact, err = parseHeadersForCompactProof(headers)
+	} else {
+		prv, err = parseHeadersForProof(headers)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -310,6 +586,32 @@ func (c *httpRestImpl) MapGetValue(ctx context.Context, req *pb.MapGetValueReque
 		return nil, err
 	}
 
+	var alg pb.HashAlgorithm
+	if name := headers.Get("X-Verified-Hash-Algorithm"); name != "" {
+		v, ok := pb.HashAlgorithm_value[name]
+		if !ok {
+			return nil, verifiable.ErrNotImplemented
+		}
+		alg = pb.HashAlgorithm(v)
+	}
+
+	var keyProof []byte
+	if kp := headers.Get("X-Verified-Key-Proof"); kp != "" {
+		keyProof, err = hex.DecodeString(strings.TrimSpace(kp))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var patriciaProof [][]byte
+	for _, p := range headers[http.CanonicalHeaderKey("X-Verified-Patricia-Proof")] {
+		bs, err := hex.DecodeString(strings.TrimSpace(p))
+		if err != nil {
+			return nil, err
+		}
+		patriciaProof = append(patriciaProof, bs)
+	}
+
 	var rv pb.LeafData
 	err = json.Unmarshal(value, &rv)
 	if err != nil {
@@ -317,12 +619,108 @@ func (c *httpRestImpl) MapGetValue(ctx context.Context, req *pb.MapGetValueReque
 	}
 
 	return &pb.MapGetValueResponse{
-		AuditPath: prv,
-		TreeSize:  int64(vts),
-		Value:     &rv,
+		AuditPath:        prv,
+		TreeSize:         int64(vts),
+		Value:            &rv,
+		HashAlgorithm:    alg,
+		AuditPathBitmap:  bitmap,
+		CompactAuditPath: compact,
+		KeyProof:         keyProof,
+		PatriciaProof:    patriciaProof,
 	}, nil
 }
 
+// MapGetValueBundle gets a value from the map along with the tree hashes and proofs needed to verify it
+func (c *httpRestImpl) MapGetValueBundle(ctx context.Context, req *pb.MapGetValueBundleRequest) (*pb.MapGetValueBundleResponse, error) {
+	contents, _, err := c.makeMapRequest(req.Map, "GET", fmt.Sprintf("/tree/%d/bundle/from/%d-%d/key/h/%s", req.TreeSize, req.FromSize, req.TreeHeadLogFromSize, hex.EncodeToString(req.Key)), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var rv pb.MapGetValueBundleResponse
+	err = json.Unmarshal(contents, &rv)
+	if err != nil {
+		return nil, err
+	}
+	return &rv, nil
+}
+
+// MapGetValues gets the values for many keys from the map, along with a single proof for all of them
+func (c *httpRestImpl) MapGetValues(ctx context.Context, req *pb.MapGetValuesRequest) (*pb.MapGetValuesResponse, error) {
+	reqData, err := json.Marshal(&pb.MapGetValuesRequest{Keys: req.Keys})
+	if err != nil {
+		return nil, err
+	}
+	contents, _, err := c.makeMapRequest(req.Map, "POST", fmt.Sprintf("/tree/%d/keys", req.TreeSize), reqData, nil)
+	if err != nil {
+		return nil, err
+	}
+	var rv pb.MapGetValuesResponse
+	err = json.Unmarshal(contents, &rv)
+	if err != nil {
+		return nil, err
+	}
+	return &rv, nil
+}
+
+// MapGetKeyHistory gets every value a key in the map has had, along with the mutations that set them
+func (c *httpRestImpl) MapGetKeyHistory(ctx context.Context, req *pb.MapGetKeyHistoryRequest) (*pb.MapGetKeyHistoryResponse, error) {
+	contents, _, err := c.makeMapRequest(req.Map, "GET", fmt.Sprintf("/tree/%d/history/key/h/%s", req.TreeSize, hex.EncodeToString(req.Key)), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var rv pb.MapGetKeyHistoryResponse
+	err = json.Unmarshal(contents, &rv)
+	if err != nil {
+		return nil, err
+	}
+	return &rv, nil
+}
+
+// MapListLeaves lists a page of leaves from the map, along with the subtree hashes needed to verify it
+func (c *httpRestImpl) MapListLeaves(ctx context.Context, req *pb.MapListLeavesRequest) (*pb.MapListLeavesResponse, error) {
+	path := fmt.Sprintf("/tree/%d/leaves", req.TreeSize)
+	if len(req.StartKeyHash) != 0 || req.Limit != 0 {
+		start := req.StartKeyHash
+		if len(start) == 0 {
+			start = make([]byte, 32)
+		}
+		path += fmt.Sprintf("/from/%s/limit/%d", hex.EncodeToString(start), req.Limit)
+	}
+	contents, _, err := c.makeMapRequest(req.Map, "GET", path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var rv pb.MapListLeavesResponse
+	err = json.Unmarshal(contents, &rv)
+	if err != nil {
+		return nil, err
+	}
+	return &rv, nil
+}
+
+// MapDiff lists a page of keys whose values differ between two tree sizes of the map, along with the subtree hashes
+// needed to verify it
+func (c *httpRestImpl) MapDiff(ctx context.Context, req *pb.MapDiffRequest) (*pb.MapDiffResponse, error) {
+	path := fmt.Sprintf("/tree/%d/diff/%d", req.ToSize, req.FromSize)
+	if len(req.StartKeyHash) != 0 || req.Limit != 0 {
+		start := req.StartKeyHash
+		if len(start) == 0 {
+			start = make([]byte, 32)
+		}
+		path += fmt.Sprintf("/from/%s/limit/%d", hex.EncodeToString(start), req.Limit)
+	}
+	contents, _, err := c.makeMapRequest(req.Map, "GET", path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var rv pb.MapDiffResponse
+	err = json.Unmarshal(contents, &rv)
+	if err != nil {
+		return nil, err
+	}
+	return &rv, nil
+}
+
 // MapTreeHash gets the tree hash from the map
 func (c *httpRestImpl) MapTreeHash(ctx context.Context, req *pb.MapTreeHashRequest) (*pb.MapTreeHashResponse, error) {
 	contents, _, err := c.makeMapRequest(req.Map, "GET", fmt.Sprintf("/tree/%d", req.TreeSize), nil, nil)
@@ -336,3 +734,123 @@ func (c *httpRestImpl) MapTreeHash(ctx context.Context, req *pb.MapTreeHashReque
 	}
 	return &rv, nil
 }
+
+// WatchMapTreeHead receives each new tree hash from the map as it is committed
+func (c *httpRestImpl) WatchMapTreeHead(req *pb.WatchMapTreeHeadRequest, stream pb.VerifiableDataStructuresService_WatchMapTreeHeadServer) error {
+	resp, err := c.openRequest(stream.Context(), req.Map.Account, "GET", fmt.Sprintf("/account/%s/map/%s/tree/%d/watch", req.Map.Account.Id, req.Map.Name, req.TreeSize), nil, withHashAlgorithm(req.Map.HashAlgorithm, nil))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return readEvents(resp.Body, stream.Send)
+}
+
+// MultimapAddValue adds a value for a key in the multimap
+func (c *httpRestImpl) MultimapAddValue(ctx context.Context, req *pb.MultimapAddValueRequest) (*pb.MultimapAddValueResponse, error) {
+	reqData, err := json.Marshal(req.Value)
+	if err != nil {
+		return nil, err
+	}
+	contents, _, err := c.makeMultimapRequest(req.Multimap, "POST", "/key/h/"+hex.EncodeToString(req.Key)+"/extra", reqData, nil)
+	if err != nil {
+		return nil, err
+	}
+	var rv pb.MultimapAddValueResponse
+	err = json.Unmarshal(contents, &rv)
+	if err != nil {
+		return nil, err
+	}
+	return &rv, nil
+}
+
+// MultimapGetValues gets the values for a key in the multimap, along with proof of the tree head of their log
+func (c *httpRestImpl) MultimapGetValues(ctx context.Context, req *pb.MultimapGetValuesRequest) (*pb.MultimapGetValuesResponse, error) {
+	contents, _, err := c.makeMultimapRequest(req.Multimap, "GET", fmt.Sprintf("/tree/%d/key/h/%s/values", req.TreeSize, hex.EncodeToString(req.Key)), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var rv pb.MultimapGetValuesResponse
+	err = json.Unmarshal(contents, &rv)
+	if err != nil {
+		return nil, err
+	}
+	return &rv, nil
+}
+
+// MultimapInclusionProof fetches an inclusion proof for a value of a key in the multimap
+func (c *httpRestImpl) MultimapInclusionProof(ctx context.Context, req *pb.MultimapInclusionProofRequest) (*pb.MultimapInclusionProofResponse, error) {
+	path := fmt.Sprintf("/tree/%d/key/h/%s/inclusion/%d", req.TreeSize, hex.EncodeToString(req.Key), req.ValueIndex)
+	if len(req.MtlHash) != 0 {
+		path = fmt.Sprintf("/tree/%d/key/h/%s/inclusion/h/%s", req.TreeSize, hex.EncodeToString(req.Key), hex.EncodeToString(req.MtlHash))
+	}
+	contents, _, err := c.makeMultimapRequest(req.Multimap, "GET", path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var rv pb.MultimapInclusionProofResponse
+	err = json.Unmarshal(contents, &rv)
+	if err != nil {
+		return nil, err
+	}
+	return &rv, nil
+}
+
+// MultimapKeyConsistencyProof fetches a consistency proof for the values of a key between two tree sizes of the multimap
+func (c *httpRestImpl) MultimapKeyConsistencyProof(ctx context.Context, req *pb.MultimapKeyConsistencyProofRequest) (*pb.MultimapKeyConsistencyProofResponse, error) {
+	contents, _, err := c.makeMultimapRequest(req.Multimap, "GET", fmt.Sprintf("/tree/%d/key/h/%s/consistency/%d", req.TreeSize, hex.EncodeToString(req.Key), req.FromSize), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var rv pb.MultimapKeyConsistencyProofResponse
+	err = json.Unmarshal(contents, &rv)
+	if err != nil {
+		return nil, err
+	}
+	return &rv, nil
+}
+
+// MultimapTreeHash gets the tree hash from the multimap
+func (c *httpRestImpl) MultimapTreeHash(ctx context.Context, req *pb.MultimapTreeHashRequest) (*pb.MapTreeHashResponse, error) {
+	contents, _, err := c.makeMultimapRequest(req.Multimap, "GET", fmt.Sprintf("/tree/%d", req.TreeSize), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var rv pb.MapTreeHashResponse
+	err = json.Unmarshal(contents, &rv)
+	if err != nil {
+		return nil, err
+	}
+	return &rv, nil
+}
+
+// Gossip sends tree heads to the server. The API key of the first log or map is used for all.
+func (c *httpRestImpl) Gossip(ctx context.Context, req *pb.GossipRequest) (*pb.GossipResponse, error) {
+	account := &pb.AccountRef{}
+	for _, g := range req.Logs {
+		if g.GetLog().GetAccount().GetApiKey() != "" {
+			account = g.Log.Account
+			break
+		}
+	}
+	for _, g := range req.Maps {
+		if account.ApiKey == "" && g.GetMap().GetAccount().GetApiKey() != "" {
+			account = g.Map.Account
+			break
+		}
+	}
+
+	reqData, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	contents, _, err := c.makeRequest(account, "POST", "/gossip", reqData, nil)
+	if err != nil {
+		return nil, err
+	}
+	var rv pb.GossipResponse
+	err = json.Unmarshal(contents, &rv)
+	if err != nil {
+		return nil, err
+	}
+	return &rv, nil
+}
@@ -0,0 +1,112 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package httprest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// eventStream sends each message from a server-streaming method to the HTTP client as a
+// server-sent event. The response header is not written until the first message is sent,
+// so that errors can still be returned as a status code.
+type eventStream[T any] struct {
+	ctx     context.Context
+	w       http.ResponseWriter
+	started bool
+}
+
+func (e *eventStream[T]) Send(resp *T) error {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	if !e.started {
+		e.w.Header().Set("Content-Type", "text/event-stream")
+		e.w.Header().Set("Cache-Control", "no-cache")
+		e.w.WriteHeader(http.StatusOK)
+		e.started = true
+	}
+	_, err = fmt.Fprintf(e.w, "data: %s\n\n", data)
+	if err != nil {
+		return err
+	}
+	f, ok := e.w.(http.Flusher)
+	if ok {
+		f.Flush()
+	}
+	return nil
+}
+
+func (e *eventStream[T]) Context() context.Context {
+	return e.ctx
+}
+
+func (e *eventStream[T]) SetHeader(metadata.MD) error {
+	return nil
+}
+
+func (e *eventStream[T]) SendHeader(metadata.MD) error {
+	return nil
+}
+
+func (e *eventStream[T]) SetTrailer(metadata.MD) {}
+
+func (e *eventStream[T]) SendMsg(m interface{}) error {
+	resp, ok := m.(*T)
+	if !ok {
+		return status.Errorf(codes.Internal, "unexpected message type")
+	}
+	return e.Send(resp)
+}
+
+func (e *eventStream[T]) RecvMsg(m interface{}) error {
+	return status.Errorf(codes.Unimplemented, "not a client stream")
+}
+
+// streamContext returns a context for a long-lived request, that is cancelled when the client goes away
+func (as *apiServer) streamContext(r *http.Request) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(as.cc(r))
+	go func() {
+		select {
+		case <-r.Context().Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// endEventStream reports the error that ended a stream. Once started, the client will just see the
+// stream end, as the status code has already been sent.
+func (as *apiServer) endEventStream(started bool, err error, w http.ResponseWriter) {
+	if !started {
+		writeResponseHeader(as.logger, w, err)
+		return
+	}
+	if err != nil && as.logger != nil {
+		as.logger.Println(err.Error())
+	}
+}
@@ -31,7 +31,6 @@ import (
 
 	"github.com/continusec/objecthash"
 	"github.com/continusec/verifiabledatastructures/assets"
-	"github.com/continusec/verifiabledatastructures/merkle"
 	"github.com/continusec/verifiabledatastructures/pb"
 	"github.com/continusec/verifiabledatastructures/verifiable"
 	"github.com/gorilla/handlers"
@@ -43,6 +42,8 @@ import (
 const (
 	headStr = "head"
 
+	compactProofFormat = "compact"
+
 	stdFormat = 0
 	hexFormat = 1
 
@@ -118,16 +119,35 @@ func CreateRESTHandler(s pb.VerifiableDataStructuresServiceServer, cc ContextCre
 			}
 		}
 
+		// Insert a batch of log entries
+		r.HandleFunc(t.Prefix+"/entries/extra", wrapLogFunction(t.LogType, as.insertEntriesHandler)).Methods("POST")
+
 		// Get STH
 		r.HandleFunc(t.Prefix+"/tree/{treesize:(?:[0-9]+)|head}", wrapLogFunction(t.LogType, as.getLogTreeHashHandler)).Methods("GET")
 
+		// Get STH as a signed checkpoint
+		r.HandleFunc(t.Prefix+"/tree/{treesize:(?:[0-9]+)|head}/checkpoint", wrapLogFunction(t.LogType, as.getLogCheckpointHandler)).Methods("GET")
+
+		// Get STH cosigned by witnesses
+		r.HandleFunc(t.Prefix+"/tree/{treesize:(?:[0-9]+)|head}/cosigned", wrapLogFunction(t.LogType, as.getCosignedLogTreeHashHandler)).Methods("GET")
+
+		// Stream each new STH larger than treesize as server-sent events
+		r.HandleFunc(t.Prefix+"/tree/{treesize:[0-9]+}/watch", wrapLogFunction(t.LogType, as.watchLogTreeHashHandler)).Methods("GET")
+
 		// Get inclusion proof by Hash
 		r.HandleFunc(t.Prefix+"/tree/{treesize:[0-9]+}/inclusion/h/{hash:[0-9a-f]+}", wrapLogFunction(t.LogType, as.inclusionByHashProofHandler)).Methods("GET")
 		r.HandleFunc(t.Prefix+"/tree/{treesize:[0-9]+}/inclusion/s/{strentry:[0-9a-zA-Z-_]+}", wrapLogFunction(t.LogType, as.inclusionByStringProofHandler)).Methods("GET")
 		r.HandleFunc(t.Prefix+"/tree/{treesize:[0-9]+}/inclusion/{number:[0-9]+}", wrapLogFunction(t.LogType, as.inclusionByIndexProofHandler)).Methods("GET")
 
+		// Get a single inclusion proof for a batch of leaves
+		r.HandleFunc(t.Prefix+"/tree/{treesize:[0-9]+}/inclusion", wrapLogFunction(t.LogType, as.batchInclusionProofHandler)).Methods("POST")
+
 		// Get consistency proof
 		r.HandleFunc(t.Prefix+"/tree/{treesize:[0-9]+}/consistency/{oldsize:[0-9]+}", wrapLogFunction(t.LogType, as.getConsistencyProofHandler)).Methods("GET")
+
+		// Get entry, STH, inclusion proof and optionally consistency proof in one request
+		r.HandleFunc(t.Prefix+"/tree/{treesize:(?:[0-9]+)|head}/bundle/{number:[0-9]+}", wrapLogFunction(t.LogType, as.getEntryBundleHandler)).Methods("GET")
+		r.HandleFunc(t.Prefix+"/tree/{treesize:(?:[0-9]+)|head}/bundle/{number:[0-9]+}/from/{oldsize:[0-9]+}", wrapLogFunction(t.LogType, as.getEntryBundleHandler)).Methods("GET")
 	}
 	// MAP STUFF
 
@@ -147,13 +167,73 @@ func CreateRESTHandler(s pb.VerifiableDataStructuresServiceServer, cc ContextCre
 				r.HandleFunc(version+"/account/{account:[0-9]+}/map/{map:[0-9a-z-_]+}/tree/{treesize:(?:[0-9]+)|head}/key/"+h.Ch+f.Suffix, wrapMapFunctionWithKeyAndFormat(logger, h.KeyFormat, f.EntryFormat, as.getMapEntry)).Methods("GET")
 			}
 		}
+		// Get value, map STH, tree head log STH and the proofs to tie them together in one request
+		r.HandleFunc(version+"/account/{account:[0-9]+}/map/{map:[0-9a-z-_]+}/tree/{treesize:(?:[0-9]+)|head}/bundle/key/"+h.Ch, wrapMapFunctionWithKey(logger, h.KeyFormat, as.getMapEntryBundle)).Methods("GET")
+		r.HandleFunc(version+"/account/{account:[0-9]+}/map/{map:[0-9a-z-_]+}/tree/{treesize:(?:[0-9]+)|head}/bundle/from/{oldsize:[0-9]+}-{oldtreeheadsize:[0-9]+}/key/"+h.Ch, wrapMapFunctionWithKey(logger, h.KeyFormat, as.getMapEntryBundle)).Methods("GET")
+
+		// Get every value a map entry has had, with the mutations that set them
+		r.HandleFunc(version+"/account/{account:[0-9]+}/map/{map:[0-9a-z-_]+}/tree/{treesize:(?:[0-9]+)|head}/history/key/"+h.Ch, wrapMapFunctionWithKey(logger, h.KeyFormat, as.getMapEntryHistory)).Methods("GET")
+
 		// Delete a map entry
 		r.HandleFunc(version+"/account/{account:[0-9]+}/map/{map:[0-9a-z-_]+}/key/"+h.Ch, wrapMapFunctionWithKey(logger, h.KeyFormat, as.deleteMapEntryHandler)).Methods("DELETE")
+
+		// Modify a map entry with a value derived by the server
+		r.HandleFunc(version+"/account/{account:[0-9]+}/map/{map:[0-9a-z-_]+}/key/"+h.Ch, wrapMapFunctionWithKey(logger, h.KeyFormat, as.patchMapEntryHandler)).Methods("PATCH")
+		r.HandleFunc(version+"/account/{account:[0-9]+}/map/{map:[0-9a-z-_]+}/key/"+h.Ch+"/increment", wrapMapFunctionWithKey(logger, h.KeyFormat, as.incrementMapEntryHandler)).Methods("POST")
 	}
 
+	// Set, delete and update many map entries in a single mutation
+	r.HandleFunc(version+"/account/{account:[0-9]+}/map/{map:[0-9a-z-_]+}/transaction", wrapMapFunction(as.applyMapTransactionHandler)).Methods("POST")
+
 	// Get STH
 	r.HandleFunc(version+"/account/{account:[0-9]+}/map/{map:[0-9a-z-_]+}/tree/{treesize:(?:[0-9]+)|head}", wrapMapFunction(as.getMapRootHashHandler)).Methods("GET")
 
+	// Get values for many keys with a single proof
+	r.HandleFunc(version+"/account/{account:[0-9]+}/map/{map:[0-9a-z-_]+}/tree/{treesize:(?:[0-9]+)|head}/keys", wrapMapFunction(as.getMapEntriesHandler)).Methods("POST")
+
+	// List leaves with proof that none were skipped, a page at a time
+	r.HandleFunc(version+"/account/{account:[0-9]+}/map/{map:[0-9a-z-_]+}/tree/{treesize:(?:[0-9]+)|head}/leaves", wrapMapFunction(as.getMapLeavesHandler)).Methods("GET")
+	r.HandleFunc(version+"/account/{account:[0-9]+}/map/{map:[0-9a-z-_]+}/tree/{treesize:(?:[0-9]+)|head}/leaves/from/{start:[0-9a-f]+}", wrapMapFunction(as.getMapLeavesHandler)).Methods("GET")
+	r.HandleFunc(version+"/account/{account:[0-9]+}/map/{map:[0-9a-z-_]+}/tree/{treesize:(?:[0-9]+)|head}/leaves/from/{start:[0-9a-f]+}/limit/{limit:[0-9]+}", wrapMapFunction(as.getMapLeavesHandler)).Methods("GET")
+
+	// List keys whose values changed since an earlier tree size, with proof that none were skipped, a page at a time
+	r.HandleFunc(version+"/account/{account:[0-9]+}/map/{map:[0-9a-z-_]+}/tree/{treesize:(?:[0-9]+)|head}/diff/{fromsize:[0-9]+}", wrapMapFunction(as.getMapDiffHandler)).Methods("GET")
+	r.HandleFunc(version+"/account/{account:[0-9]+}/map/{map:[0-9a-z-_]+}/tree/{treesize:(?:[0-9]+)|head}/diff/{fromsize:[0-9]+}/from/{start:[0-9a-f]+}/limit/{limit:[0-9]+}", wrapMapFunction(as.getMapDiffHandler)).Methods("GET")
+
+	// Stream each new STH larger than treesize as server-sent events
+	r.HandleFunc(version+"/account/{account:[0-9]+}/map/{map:[0-9a-z-_]+}/tree/{treesize:[0-9]+}/watch", wrapMapFunction(as.watchMapRootHashHandler)).Methods("GET")
+
+	// MULTIMAP STUFF
+
+	for _, h := range []struct {
+		KeyFormat int
+		Ch        string
+	}{
+		{KeyFormat: stdFormat, Ch: "s/{key:[0-9a-zA-Z-_]+}"},
+		{KeyFormat: hexFormat, Ch: "h/{key:[0-9a-f]+}"},
+	} {
+		for _, f := range commonSuffixes {
+			// Add a value for a key
+			r.HandleFunc(version+"/account/{account:[0-9]+}/multimap/{multimap:[0-9a-z-_]+}/key/"+h.Ch+f.Suffix, wrapMultimapFunctionWithKeyAndFormat(logger, h.KeyFormat, f.EntryFormat, as.addMultimapValueHandler)).Methods("POST")
+		}
+
+		// Get all values for a key + proof
+		r.HandleFunc(version+"/account/{account:[0-9]+}/multimap/{multimap:[0-9a-z-_]+}/tree/{treesize:(?:[0-9]+)|head}/key/"+h.Ch+"/values", wrapMultimapFunctionWithKey(logger, h.KeyFormat, as.getMultimapValuesHandler)).Methods("GET")
+
+		// Get inclusion proof for a value of a key
+		r.HandleFunc(version+"/account/{account:[0-9]+}/multimap/{multimap:[0-9a-z-_]+}/tree/{treesize:(?:[0-9]+)|head}/key/"+h.Ch+"/inclusion/h/{hash:[0-9a-f]+}", wrapMultimapFunctionWithKey(logger, h.KeyFormat, as.multimapInclusionProofHandler)).Methods("GET")
+		r.HandleFunc(version+"/account/{account:[0-9]+}/multimap/{multimap:[0-9a-z-_]+}/tree/{treesize:(?:[0-9]+)|head}/key/"+h.Ch+"/inclusion/{number:[0-9]+}", wrapMultimapFunctionWithKey(logger, h.KeyFormat, as.multimapInclusionProofHandler)).Methods("GET")
+
+		// Get consistency proof for the values of a key between two tree sizes
+		r.HandleFunc(version+"/account/{account:[0-9]+}/multimap/{multimap:[0-9a-z-_]+}/tree/{treesize:(?:[0-9]+)|head}/key/"+h.Ch+"/consistency/{oldsize:[0-9]+}", wrapMultimapFunctionWithKey(logger, h.KeyFormat, as.multimapKeyConsistencyProofHandler)).Methods("GET")
+	}
+
+	// Get STH
+	r.HandleFunc(version+"/account/{account:[0-9]+}/multimap/{multimap:[0-9a-z-_]+}/tree/{treesize:(?:[0-9]+)|head}", wrapMultimapFunction(as.getMultimapRootHashHandler)).Methods("GET")
+
+	// Gossip tree heads
+	r.HandleFunc(version+"/gossip", as.gossipHandler).Methods("POST")
+
 	// Make sure we return 200 for OPTIONS requests since handlers below will fall through to us
 	r.HandleFunc("/{thing:.*}", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(200)
@@ -168,10 +248,10 @@ func CreateRESTHandler(s pb.VerifiableDataStructuresServiceServer, cc ContextCre
 
 	// Since we do NO cookie or basic auth, allow CORS
 	return handlers.CORS(
-		handlers.AllowedMethods([]string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
+		handlers.AllowedMethods([]string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}),
 		handlers.AllowedOrigins([]string{"*"}),
-		handlers.AllowedHeaders([]string{"Authorization", "Accept", "Content-Type"}),
-		handlers.ExposedHeaders([]string{"X-Verified-Treesize", "X-Verified-Proof"}),
+		handlers.AllowedHeaders([]string{"Authorization", "Accept", "Content-Type", "X-Verified-Hash-Algorithm", "X-Verified-Proof-Format", "X-Verified-Write-Once", "X-Verified-VRF-Keys", "X-Verified-Sum-Field", "X-Verified-Patricia-Trie", "X-Previous-LeafHash", "X-Previous-Version", "X-Create-Only"}),
+		handlers.ExposedHeaders([]string{"X-Verified-Treesize", "X-Verified-Proof", "X-Verified-Proof-Bitmap", "X-Verified-Hash-Algorithm", "X-Verified-Key-Proof"}),
 	)(r)
 }
 
@@ -198,6 +278,20 @@ func apiKeyFromRequest(r *http.Request) string {
 	return ""
 }
 
+// hashAlgorithmFromRequest returns the hash algorithm named in the X-Verified-Hash-Algorithm header,
+// defaulting to SHA256. Unknown names are passed on as an invalid value to be rejected by the service.
+func hashAlgorithmFromRequest(r *http.Request) pb.HashAlgorithm {
+	name := r.Header.Get("X-Verified-Hash-Algorithm")
+	if name == "" {
+		return pb.HashAlgorithm_HASH_SHA256
+	}
+	alg, ok := pb.HashAlgorithm_value[name]
+	if !ok {
+		return pb.HashAlgorithm(-1)
+	}
+	return pb.HashAlgorithm(alg)
+}
+
 func accountRefFromRequest(r *http.Request) (map[string]string, *pb.AccountRef) {
 	vars := mux.Vars(r)
 	return vars, &pb.AccountRef{
@@ -209,17 +303,32 @@ func accountRefFromRequest(r *http.Request) (map[string]string, *pb.AccountRef)
 func logRefFromRequest(r *http.Request, lt pb.LogType) (map[string]string, *pb.LogRef) {
 	vars, account := accountRefFromRequest(r)
 	return vars, &pb.LogRef{
-		Account: account,
-		Name:    vars["log"],
-		LogType: lt,
+		Account:       account,
+		Name:          vars["log"],
+		LogType:       lt,
+		HashAlgorithm: hashAlgorithmFromRequest(r),
 	}
 }
 
 func mapRefFromRequest(r *http.Request) (map[string]string, *pb.MapRef) {
 	vars, account := accountRefFromRequest(r)
 	return vars, &pb.MapRef{
-		Account: account,
-		Name:    vars["map"],
+		Account:       account,
+		Name:          vars["map"],
+		HashAlgorithm: hashAlgorithmFromRequest(r),
+		WriteOnce:     r.Header.Get("X-Verified-Write-Once") == "true",
+		VrfKeys:       r.Header.Get("X-Verified-VRF-Keys") == "true",
+		SumField:      r.Header.Get("X-Verified-Sum-Field"),
+		PatriciaTrie:  r.Header.Get("X-Verified-Patricia-Trie") == "true",
+	}
+}
+
+func multimapRefFromRequest(r *http.Request) (map[string]string, *pb.MultimapRef) {
+	vars, account := accountRefFromRequest(r)
+	return vars, &pb.MultimapRef{
+		Account:       account,
+		Name:          vars["multimap"],
+		HashAlgorithm: hashAlgorithmFromRequest(r),
 	}
 }
 
@@ -270,6 +379,40 @@ func wrapMapFunctionWithKeyAndFormat(logger *log.Logger, keyType int, ef int, f
 	})
 }
 
+func wrapMultimapFunction(f func(*pb.MultimapRef, map[string]string, http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	return func(w http.ResponseWriter, r *http.Request) {
+		vars, mmRef := multimapRefFromRequest(r)
+		f(mmRef, vars, w, r)
+	}
+}
+
+func wrapMultimapFunctionWithKey(logger *log.Logger, keyType int, f func(*pb.MultimapRef, []byte, map[string]string, http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	return wrapMultimapFunction(func(mm *pb.MultimapRef, vars map[string]string, w http.ResponseWriter, r *http.Request) {
+		var k []byte
+		switch keyType {
+		case stdFormat:
+			k = []byte(vars["key"])
+		case hexFormat:
+			var err error
+			k, err = hex.DecodeString(vars["key"])
+			if err != nil {
+				writeResponseHeader(logger, w, verifiable.ErrInvalidRequest)
+				return
+			}
+		default:
+			writeResponseHeader(logger, w, verifiable.ErrNotImplemented)
+			return
+		}
+		f(mm, k, vars, w, r)
+	})
+}
+
+func wrapMultimapFunctionWithKeyAndFormat(logger *log.Logger, keyType int, ef int, f func(*pb.MultimapRef, []byte, int, map[string]string, http.ResponseWriter, *http.Request)) func(http.ResponseWriter, *http.Request) {
+	return wrapMultimapFunctionWithKey(logger, keyType, func(mm *pb.MultimapRef, k []byte, vars map[string]string, w http.ResponseWriter, r *http.Request) {
+		f(mm, k, ef, vars, w, r)
+	})
+}
+
 func defaultRequestContext(r *http.Request) context.Context {
 	return context.Background()
 }
@@ -297,6 +440,8 @@ func writeResponseHeader(logger *log.Logger, w http.ResponseWriter, err error) {
 		w.WriteHeader(http.StatusBadRequest)
 	case codes.NotFound:
 		w.WriteHeader(http.StatusNotFound)
+	case codes.Unimplemented:
+		w.WriteHeader(http.StatusNotImplemented)
 	default:
 		w.WriteHeader(http.StatusInternalServerError)
 	}
@@ -315,6 +460,14 @@ func writeSuccessContent(w http.ResponseWriter, val []byte) {
 }
 
 func (as *apiServer) getLogTreeHashHandler(log *pb.LogRef, vars map[string]string, w http.ResponseWriter, r *http.Request) {
+	as.writeLogTreeHash(log, vars, false, w, r)
+}
+
+func (as *apiServer) getCosignedLogTreeHashHandler(log *pb.LogRef, vars map[string]string, w http.ResponseWriter, r *http.Request) {
+	as.writeLogTreeHash(log, vars, true, w, r)
+}
+
+func (as *apiServer) writeLogTreeHash(log *pb.LogRef, vars map[string]string, cosigned bool, w http.ResponseWriter, r *http.Request) {
 	var treeSize int64
 	if vars["treesize"] == headStr {
 		treeSize = 0
@@ -330,6 +483,7 @@ func (as *apiServer) getLogTreeHashHandler(log *pb.LogRef, vars map[string]strin
 	resp, err := as.service.LogTreeHash(as.cc(r), &pb.LogTreeHashRequest{
 		Log:      log,
 		TreeSize: treeSize,
+		Cosigned: cosigned,
 	})
 	if err != nil {
 		writeResponseHeader(as.logger, w, err)
@@ -339,6 +493,50 @@ func (as *apiServer) getLogTreeHashHandler(log *pb.LogRef, vars map[string]strin
 	writeSuccessJSON(w, resp)
 }
 
+func (as *apiServer) watchLogTreeHashHandler(log *pb.LogRef, vars map[string]string, w http.ResponseWriter, r *http.Request) {
+	treeSize, err := strconv.Atoi(vars["treesize"])
+	if err != nil {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
+	}
+
+	ctx, cancel := as.streamContext(r)
+	defer cancel()
+	stream := &eventStream[pb.LogTreeHashResponse]{ctx: ctx, w: w}
+	err = as.service.WatchLogTreeHead(&pb.WatchLogTreeHeadRequest{
+		Log:      log,
+		TreeSize: int64(treeSize),
+	}, stream)
+	as.endEventStream(stream.started, err, w)
+}
+
+func (as *apiServer) getLogCheckpointHandler(log *pb.LogRef, vars map[string]string, w http.ResponseWriter, r *http.Request) {
+	var treeSize int64
+	if vars["treesize"] == headStr {
+		treeSize = 0
+	} else {
+		ts, err := strconv.Atoi(vars["treesize"])
+		if err != nil {
+			writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+			return
+		}
+		treeSize = int64(ts)
+	}
+
+	resp, err := as.service.LogCheckpoint(as.cc(r), &pb.LogCheckpointRequest{
+		Log:      log,
+		TreeSize: treeSize,
+	})
+	if err != nil {
+		writeResponseHeader(as.logger, w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(resp.Checkpoint)
+}
+
 func (as *apiServer) getConsistencyProofHandler(log *pb.LogRef, vars map[string]string, w http.ResponseWriter, r *http.Request) {
 	var treeSize int
 	if vars["treesize"] == headStr {
@@ -370,6 +568,46 @@ func (as *apiServer) getConsistencyProofHandler(log *pb.LogRef, vars map[string]
 	writeSuccessJSON(w, resp)
 }
 
+// sizeFromVars parses a tree size from the request, returning zero if not present or for the head
+func sizeFromVars(vars map[string]string, name string) (int64, error) {
+	v, ok := vars[name]
+	if !ok || v == headStr {
+		return 0, nil
+	}
+	rv, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, err
+	}
+	return int64(rv), nil
+}
+
+func (as *apiServer) getEntryBundleHandler(log *pb.LogRef, vars map[string]string, w http.ResponseWriter, r *http.Request) {
+	req := &pb.LogFetchEntryBundleRequest{Log: log}
+	var err error
+	for _, v := range []struct {
+		Name string
+		Dest *int64
+	}{
+		{Name: "treesize", Dest: &req.TreeSize},
+		{Name: "number", Dest: &req.LeafIndex},
+		{Name: "oldsize", Dest: &req.FromSize},
+	} {
+		*v.Dest, err = sizeFromVars(vars, v.Name)
+		if err != nil {
+			writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+			return
+		}
+	}
+
+	resp, err := as.service.LogFetchEntryBundle(as.cc(r), req)
+	if err != nil {
+		writeResponseHeader(as.logger, w, err)
+		return
+	}
+
+	writeSuccessJSON(w, resp)
+}
+
 func (as *apiServer) inclusionProofHandler(log *pb.LogRef, vars map[string]string, partial *pb.LogInclusionProofRequest, w http.ResponseWriter, r *http.Request) {
 	treeSize, err := strconv.Atoi(vars["treesize"])
 	if err != nil {
@@ -402,8 +640,14 @@ func (as *apiServer) inclusionByIndexProofHandler(log *pb.LogRef, vars map[strin
 }
 
 func (as *apiServer) inclusionByStringProofHandler(log *pb.LogRef, vars map[string]string, w http.ResponseWriter, r *http.Request) {
+	h, err := verifiable.HasherForAlgorithm(log.HashAlgorithm)
+	if err != nil {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
+	}
+
 	as.inclusionProofHandler(log, vars, &pb.LogInclusionProofRequest{
-		MtlHash: merkle.LeafHash([]byte(vars["strentry"])),
+		MtlHash: h.LeafHash([]byte(vars["strentry"])),
 	}, w, r)
 }
 
@@ -419,6 +663,42 @@ func (as *apiServer) inclusionByHashProofHandler(log *pb.LogRef, vars map[string
 	}, w, r)
 }
 
+// batchInclusionProofHandler accepts a JSON LogBatchInclusionProofRequest, of which only the hashes and indices are used.
+func (as *apiServer) batchInclusionProofHandler(log *pb.LogRef, vars map[string]string, w http.ResponseWriter, r *http.Request) {
+	treeSize, err := strconv.Atoi(vars["treesize"])
+	if err != nil {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
+	}
+
+	var req pb.LogBatchInclusionProofRequest
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
+	}
+
+	resp, err := as.service.LogBatchInclusionProof(as.cc(r), &pb.LogBatchInclusionProofRequest{
+		Log:         log,
+		TreeSize:    int64(treeSize),
+		MtlHashes:   req.MtlHashes,
+		LeafIndices: req.LeafIndices,
+	})
+	if err != nil {
+		writeResponseHeader(as.logger, w, err)
+		return
+	}
+
+	writeSuccessJSON(w, resp)
+}
+
 func (as *apiServer) insertEntryHandler(log *pb.LogRef, ef *formatMetadata, vars map[string]string, w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	r.Body.Close()
@@ -446,6 +726,76 @@ func (as *apiServer) insertEntryHandler(log *pb.LogRef, ef *formatMetadata, vars
 
 }
 
+// insertEntriesHandler accepts a JSON LogAddEntriesRequest, of which only the values are used.
+func (as *apiServer) insertEntriesHandler(log *pb.LogRef, vars map[string]string, w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
+	}
+
+	var req pb.LogAddEntriesRequest
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
+	}
+
+	resp, err := as.service.LogAddEntries(as.cc(r), &pb.LogAddEntriesRequest{
+		Log:    log,
+		Values: req.Values,
+	})
+	if err != nil {
+		writeResponseHeader(as.logger, w, err)
+		return
+	}
+
+	writeSuccessJSON(w, resp)
+}
+
+// gossipHandler accepts a JSON GossipRequest. Any API key sent in the Authorization header
+// is used for all logs and maps in the request, and any in the body ignored.
+func (as *apiServer) gossipHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
+	}
+
+	var req pb.GossipRequest
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
+	}
+
+	apiKey := apiKeyFromRequest(r)
+	for _, g := range req.Logs {
+		if g.Log == nil || g.Log.Account == nil {
+			writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+			return
+		}
+		g.Log.Account.ApiKey = apiKey
+	}
+	for _, g := range req.Maps {
+		if g.Map == nil || g.Map.Account == nil {
+			writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+			return
+		}
+		g.Map.Account.ApiKey = apiKey
+	}
+
+	resp, err := as.service.Gossip(as.cc(r), &req)
+	if err != nil {
+		writeResponseHeader(as.logger, w, err)
+		return
+	}
+
+	writeSuccessJSON(w, resp)
+}
+
 func (as *apiServer) getEntryHandler(log *pb.LogRef, ef *formatMetadata, vars map[string]string, w http.ResponseWriter, r *http.Request) {
 	number, err := strconv.Atoi(vars["number"])
 	if err != nil {
@@ -524,58 +874,328 @@ func (as *apiServer) getMapRootHashHandler(vmap *pb.MapRef, vars map[string]stri
 
 }
 
-func (as *apiServer) queueMapMutation(vmap *pb.MapRef, mut *pb.MapMutation, w http.ResponseWriter, r *http.Request) {
-	resp, err := as.service.MapSetValue(as.cc(r), &pb.MapSetValueRequest{
-		Map:      vmap,
-		Mutation: mut,
+func (as *apiServer) getMapLeavesHandler(vmap *pb.MapRef, vars map[string]string, w http.ResponseWriter, r *http.Request) {
+	treeSize, err := sizeFromVars(vars, "treesize")
+	if err != nil {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
+	}
+	limit, err := sizeFromVars(vars, "limit")
+	if err != nil {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
+	}
+	var start []byte
+	if v, ok := vars["start"]; ok {
+		start, err = hex.DecodeString(v)
+		if err != nil {
+			writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+			return
+		}
+	}
+
+	resp, err := as.service.MapListLeaves(as.cc(r), &pb.MapListLeavesRequest{
+		Map:          vmap,
+		TreeSize:     treeSize,
+		StartKeyHash: start,
+		Limit:        int32(limit),
 	})
 	if err != nil {
 		writeResponseHeader(as.logger, w, err)
 		return
 	}
-	writeSuccessJSON(w, resp)
-}
 
-func (as *apiServer) deleteMapEntryHandler(vmap *pb.MapRef, key []byte, vars map[string]string, w http.ResponseWriter, r *http.Request) {
-	as.queueMapMutation(vmap, &pb.MapMutation{
-		Action: "delete",
-		Key:    key,
-	}, w, r)
+	writeSuccessJSON(w, resp)
 }
 
-func (as *apiServer) getMapEntry(vmap *pb.MapRef, key []byte, ef int, vars map[string]string, w http.ResponseWriter, r *http.Request) {
-	var treeSize int
-	if vars["treesize"] == headStr {
-		treeSize = 0
-	} else {
-		var err error
-		treeSize, err = strconv.Atoi(vars["treesize"])
+func (as *apiServer) getMapDiffHandler(vmap *pb.MapRef, vars map[string]string, w http.ResponseWriter, r *http.Request) {
+	toSize, err := sizeFromVars(vars, "treesize")
+	if err != nil {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
+	}
+	fromSize, err := sizeFromVars(vars, "fromsize")
+	if err != nil {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
+	}
+	limit, err := sizeFromVars(vars, "limit")
+	if err != nil {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
+	}
+	var start []byte
+	if v, ok := vars["start"]; ok {
+		start, err = hex.DecodeString(v)
 		if err != nil {
 			writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
 			return
 		}
 	}
 
-	resp, err := as.service.MapGetValue(as.cc(r), &pb.MapGetValueRequest{
-		Map:      vmap,
-		TreeSize: int64(treeSize),
-		Key:      key,
+	resp, err := as.service.MapDiff(as.cc(r), &pb.MapDiffRequest{
+		Map:          vmap,
+		FromSize:     fromSize,
+		ToSize:       toSize,
+		StartKeyHash: start,
+		Limit:        int32(limit),
 	})
 	if err != nil {
 		writeResponseHeader(as.logger, w, err)
 		return
 	}
 
-	w.Header().Set("X-Verified-TreeSize", strconv.Itoa(int(resp.TreeSize)))
+	writeSuccessJSON(w, resp)
+}
+
+// getMapEntriesHandler accepts a JSON MapGetValuesRequest, of which only the keys are used.
+func (as *apiServer) getMapEntriesHandler(vmap *pb.MapRef, vars map[string]string, w http.ResponseWriter, r *http.Request) {
+	treeSize, err := sizeFromVars(vars, "treesize")
+	if err != nil {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
+	}
+
+	var req pb.MapGetValuesRequest
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
+	}
+
+	resp, err := as.service.MapGetValues(as.cc(r), &pb.MapGetValuesRequest{
+		Map:      vmap,
+		TreeSize: treeSize,
+		Keys:     req.Keys,
+	})
+	if err != nil {
+		writeResponseHeader(as.logger, w, err)
+		return
+	}
+
+	writeSuccessJSON(w, resp)
+}
+
+func (as *apiServer) watchMapRootHashHandler(vmap *pb.MapRef, vars map[string]string, w http.ResponseWriter, r *http.Request) {
+	treeSize, err := strconv.Atoi(vars["treesize"])
+	if err != nil {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
+	}
+
+	ctx, cancel := as.streamContext(r)
+	defer cancel()
+	stream := &eventStream[pb.MapTreeHashResponse]{ctx: ctx, w: w}
+	err = as.service.WatchMapTreeHead(&pb.WatchMapTreeHeadRequest{
+		Map:      vmap,
+		TreeSize: int64(treeSize),
+	}, stream)
+	as.endEventStream(stream.started, err, w)
+}
+
+func (as *apiServer) queueMapMutation(vmap *pb.MapRef, mut *pb.MapMutation, w http.ResponseWriter, r *http.Request) {
+	resp, err := as.service.MapSetValue(as.cc(r), &pb.MapSetValueRequest{
+		Map:      vmap,
+		Mutation: mut,
+	})
+	if err != nil {
+		writeResponseHeader(as.logger, w, err)
+		return
+	}
+	writeSuccessJSON(w, resp)
+}
+
+// applyMapTransactionHandler accepts a JSON MapApplyTransactionRequest, of which only the mutations are used.
+func (as *apiServer) applyMapTransactionHandler(vmap *pb.MapRef, vars map[string]string, w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
+	}
+
+	var req pb.MapApplyTransactionRequest
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
+	}
+
+	resp, err := as.service.MapApplyTransaction(as.cc(r), &pb.MapApplyTransactionRequest{
+		Map:       vmap,
+		Mutations: req.Mutations,
+	})
+	if err != nil {
+		writeResponseHeader(as.logger, w, err)
+		return
+	}
+
+	writeSuccessJSON(w, resp)
+}
+
+func (as *apiServer) deleteMapEntryHandler(vmap *pb.MapRef, key []byte, vars map[string]string, w http.ResponseWriter, r *http.Request) {
+	prevLeafHashString := strings.TrimSpace(r.Header.Get("X-Previous-LeafHash"))
+	if len(prevLeafHashString) == 0 {
+		as.queueMapMutation(vmap, &pb.MapMutation{
+			Action: "delete",
+			Key:    key,
+		}, w, r)
+		return
+	}
+
+	prevLeafHash, err := hex.DecodeString(prevLeafHashString)
+	if err != nil || len(prevLeafHash) == 0 {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
+	}
+	as.queueMapMutation(vmap, &pb.MapMutation{
+		Action:           "compare_and_delete",
+		Key:              key,
+		PreviousLeafHash: prevLeafHash,
+	}, w, r)
+}
+
+func (as *apiServer) patchMapEntryHandler(vmap *pb.MapRef, key []byte, vars map[string]string, w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil || !json.Valid(body) {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
+	}
+
+	as.queueMapMutation(vmap, &pb.MapMutation{
+		Action: "patch",
+		Key:    key,
+		Patch:  body,
+	}, w, r)
+}
+
+func (as *apiServer) incrementMapEntryHandler(vmap *pb.MapRef, key []byte, vars map[string]string, w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
+	}
+
+	var req pb.MapMutation
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
+	}
+
+	as.queueMapMutation(vmap, &pb.MapMutation{
+		Action: "increment",
+		Key:    key,
+		Field:  req.Field,
+		Delta:  req.Delta,
+	}, w, r)
+}
+
+func (as *apiServer) getMapEntry(vmap *pb.MapRef, key []byte, ef int, vars map[string]string, w http.ResponseWriter, r *http.Request) {
+	var treeSize int
+	if vars["treesize"] == headStr {
+		treeSize = 0
+	} else {
+		var err error
+		treeSize, err = strconv.Atoi(vars["treesize"])
+		if err != nil {
+			writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+			return
+		}
+	}
+
+	resp, err := as.service.MapGetValue(as.cc(r), &pb.MapGetValueRequest{
+		Map:          vmap,
+		TreeSize:     int64(treeSize),
+		Key:          key,
+		CompactProof: r.Header.Get("X-Verified-Proof-Format") == compactProofFormat,
+	})
+	if err != nil {
+		writeResponseHeader(as.logger, w, err)
+		return
+	}
+
+	w.Header().Set("X-Verified-TreeSize", strconv.Itoa(int(resp.TreeSize)))
+	w.Header().Set("X-Verified-Hash-Algorithm", resp.HashAlgorithm.String())
+	if len(resp.KeyProof) != 0 {
+		w.Header().Set("X-Verified-Key-Proof", hex.EncodeToString(resp.KeyProof))
+	}
+	if len(resp.AuditPathBitmap) != 0 {
+		// Compact proofs list the hashes in level order, with the bitmap saying which levels they are for
+		w.Header().Set("X-Verified-Proof-Bitmap", hex.EncodeToString(resp.AuditPathBitmap))
+		for _, p := range resp.CompactAuditPath {
+			w.Header().Add("X-Verified-Proof", hex.EncodeToString(p))
+		}
+	}
 	for i, p := range resp.AuditPath {
 		if len(p) > 0 {
 			w.Header().Add("X-Verified-Proof", strconv.Itoa(i)+"/"+hex.EncodeToString(p))
 		}
 	}
+	// Patricia trie proofs list the RLP encoded nodes, starting with the root
+	for _, p := range resp.PatriciaProof {
+		w.Header().Add("X-Verified-Patricia-Proof", hex.EncodeToString(p))
+	}
 
 	writeResponseData(as.logger, w, resp.Value, ef)
 }
 
+func (as *apiServer) getMapEntryHistory(vmap *pb.MapRef, key []byte, vars map[string]string, w http.ResponseWriter, r *http.Request) {
+	treeSize, err := sizeFromVars(vars, "treesize")
+	if err != nil {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
+	}
+
+	resp, err := as.service.MapGetKeyHistory(as.cc(r), &pb.MapGetKeyHistoryRequest{
+		Map:      vmap,
+		TreeSize: treeSize,
+		Key:      key,
+	})
+	if err != nil {
+		writeResponseHeader(as.logger, w, err)
+		return
+	}
+
+	writeSuccessJSON(w, resp)
+}
+
+func (as *apiServer) getMapEntryBundle(vmap *pb.MapRef, key []byte, vars map[string]string, w http.ResponseWriter, r *http.Request) {
+	req := &pb.MapGetValueBundleRequest{Map: vmap, Key: key}
+	var err error
+	for _, v := range []struct {
+		Name string
+		Dest *int64
+	}{
+		{Name: "treesize", Dest: &req.TreeSize},
+		{Name: "oldsize", Dest: &req.FromSize},
+		{Name: "oldtreeheadsize", Dest: &req.TreeHeadLogFromSize},
+	} {
+		*v.Dest, err = sizeFromVars(vars, v.Name)
+		if err != nil {
+			writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+			return
+		}
+	}
+
+	resp, err := as.service.MapGetValueBundle(as.cc(r), req)
+	if err != nil {
+		writeResponseHeader(as.logger, w, err)
+		return
+	}
+
+	writeSuccessJSON(w, resp)
+}
+
 func getResponseData(ld *pb.LeafData, ef int) ([]byte, error) {
 	switch ef {
 	case rawEntry:
@@ -651,6 +1271,31 @@ func (as *apiServer) setMapEntry(vmap *pb.MapRef, key []byte, ef int, vars map[s
 		}
 	}
 
+	prevVersionString := strings.TrimSpace(r.Header.Get("X-Previous-Version"))
+	var prevVersion int64
+	if len(prevVersionString) > 0 {
+		var err error
+		prevVersion, err = strconv.ParseInt(prevVersionString, 10, 64)
+		if err != nil {
+			writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+			return
+		}
+	}
+
+	createOnly := strings.TrimSpace(r.Header.Get("X-Create-Only")) == "true"
+
+	// At most one precondition may be given
+	preconditions := 0
+	for _, given := range []bool{len(prevLeafHash) > 0, len(prevVersionString) > 0, createOnly} {
+		if given {
+			preconditions++
+		}
+	}
+	if preconditions > 1 {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
+	}
+
 	body, err := io.ReadAll(r.Body)
 	r.Body.Close()
 	if err != nil {
@@ -664,18 +1309,151 @@ func (as *apiServer) setMapEntry(vmap *pb.MapRef, key []byte, ef int, vars map[s
 		return
 	}
 
-	if len(prevLeafHash) == 0 {
-		as.queueMapMutation(vmap, &pb.MapMutation{
-			Action: "set",
-			Key:    key,
-			Value:  ld,
-		}, w, r)
-	} else { // must be an update
+	switch {
+	case len(prevLeafHash) > 0:
 		as.queueMapMutation(vmap, &pb.MapMutation{
 			Action:           "update",
 			Key:              key,
 			Value:            ld,
 			PreviousLeafHash: prevLeafHash,
 		}, w, r)
+	case len(prevVersionString) > 0:
+		as.queueMapMutation(vmap, &pb.MapMutation{
+			Action:  "set_if_version",
+			Key:     key,
+			Value:   ld,
+			Version: prevVersion,
+		}, w, r)
+	case createOnly:
+		as.queueMapMutation(vmap, &pb.MapMutation{
+			Action: "create",
+			Key:    key,
+			Value:  ld,
+		}, w, r)
+	default:
+		as.queueMapMutation(vmap, &pb.MapMutation{
+			Action: "set",
+			Key:    key,
+			Value:  ld,
+		}, w, r)
+	}
+}
+
+func (as *apiServer) addMultimapValueHandler(mm *pb.MultimapRef, key []byte, ef int, vars map[string]string, w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
 	}
+
+	ld, err := createLeafData(body, ef)
+	if err != nil {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
+	}
+
+	resp, err := as.service.MultimapAddValue(as.cc(r), &pb.MultimapAddValueRequest{
+		Multimap: mm,
+		Key:      key,
+		Value:    ld,
+	})
+	if err != nil {
+		writeResponseHeader(as.logger, w, err)
+		return
+	}
+
+	writeSuccessJSON(w, resp)
+}
+
+func (as *apiServer) getMultimapRootHashHandler(mm *pb.MultimapRef, vars map[string]string, w http.ResponseWriter, r *http.Request) {
+	treeSize, err := sizeFromVars(vars, "treesize")
+	if err != nil {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
+	}
+
+	resp, err := as.service.MultimapTreeHash(as.cc(r), &pb.MultimapTreeHashRequest{
+		Multimap: mm,
+		TreeSize: treeSize,
+	})
+	if err != nil {
+		writeResponseHeader(as.logger, w, err)
+		return
+	}
+
+	writeSuccessJSON(w, resp)
+}
+
+func (as *apiServer) getMultimapValuesHandler(mm *pb.MultimapRef, key []byte, vars map[string]string, w http.ResponseWriter, r *http.Request) {
+	treeSize, err := sizeFromVars(vars, "treesize")
+	if err != nil {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
+	}
+
+	resp, err := as.service.MultimapGetValues(as.cc(r), &pb.MultimapGetValuesRequest{
+		Multimap: mm,
+		TreeSize: treeSize,
+		Key:      key,
+	})
+	if err != nil {
+		writeResponseHeader(as.logger, w, err)
+		return
+	}
+
+	writeSuccessJSON(w, resp)
+}
+
+func (as *apiServer) multimapInclusionProofHandler(mm *pb.MultimapRef, key []byte, vars map[string]string, w http.ResponseWriter, r *http.Request) {
+	req := &pb.MultimapInclusionProofRequest{Multimap: mm, Key: key}
+	var err error
+	req.TreeSize, err = sizeFromVars(vars, "treesize")
+	if err != nil {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
+	}
+	if hs, ok := vars["hash"]; ok {
+		req.MtlHash, err = hex.DecodeString(hs)
+	} else {
+		req.ValueIndex, err = sizeFromVars(vars, "number")
+	}
+	if err != nil {
+		writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+		return
+	}
+
+	resp, err := as.service.MultimapInclusionProof(as.cc(r), req)
+	if err != nil {
+		writeResponseHeader(as.logger, w, err)
+		return
+	}
+
+	writeSuccessJSON(w, resp)
+}
+
+func (as *apiServer) multimapKeyConsistencyProofHandler(mm *pb.MultimapRef, key []byte, vars map[string]string, w http.ResponseWriter, r *http.Request) {
+	req := &pb.MultimapKeyConsistencyProofRequest{Multimap: mm, Key: key}
+	var err error
+	for _, v := range []struct {
+		Name string
+		Dest *int64
+	}{
+		{Name: "treesize", Dest: &req.TreeSize},
+		{Name: "oldsize", Dest: &req.FromSize},
+	} {
+		*v.Dest, err = sizeFromVars(vars, v.Name)
+		if err != nil {
+			writeResponseHeader(as.logger, w, verifiable.ErrInvalidRequest)
+			return
+		}
+	}
+
+	resp, err := as.service.MultimapKeyConsistencyProof(as.cc(r), req)
+	if err != nil {
+		writeResponseHeader(as.logger, w, err)
+		return
+	}
+
+	writeSuccessJSON(w, resp)
 }
@@ -0,0 +1,125 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/merkle"
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/server/httprest"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	"google.golang.org/grpc/codes"
+)
+
+func testLogAddEntries(t *testing.T, service pb.VerifiableDataStructuresServiceServer) {
+	ctx := context.TODO()
+	vlog := (&verifiable.Client{Service: service}).Account("0", "").VerifiableLog("foo")
+
+	// Nothing to add
+	_, err := vlog.AddEntries(ctx, nil)
+	expectErrCode(t, codes.InvalidArgument, err)
+
+	var entries []*pb.LeafData
+	for i := 0; i < 10; i++ {
+		entries = append(entries, &pb.LeafData{LeafInput: []byte(fmt.Sprintf("foo%d", i))})
+	}
+	// Duplicates within a batch are only added once
+	entries = append(entries, &pb.LeafData{LeafInput: []byte("foo3")})
+
+	proms, err := vlog.AddEntries(ctx, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proms) != len(entries) {
+		t.Fatal("wrong number of promises")
+	}
+	for i, p := range proms {
+		if !bytes.Equal(p.LeafHash(), merkle.LeafHash(entries[i].LeafInput)) {
+			t.Fatal("wrong leaf hash")
+		}
+	}
+	_, err = proms[len(proms)-2].Wait(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Entries already in the log, or earlier in the batch, are reported as already present
+	other := (&verifiable.Client{Service: service}).Account("0", "").VerifiableLog("bar")
+	resp, err := service.LogAddEntries(ctx, &pb.LogAddEntriesRequest{Log: other.Log, Values: entries[9:]})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.AlreadyPresent) != 2 || resp.AlreadyPresent[0] || resp.AlreadyPresent[1] {
+		t.Fatal("wrong already present flags")
+	}
+	_, err = other.BlockUntilPresent(ctx, resp.LeafHashes[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = service.LogAddEntries(ctx, &pb.LogAddEntriesRequest{Log: other.Log, Values: entries})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.AlreadyPresent) != len(entries) {
+		t.Fatal("wrong number of already present flags")
+	}
+	for i, present := range resp.AlreadyPresent {
+		if present != (i == 3 || i >= 9) {
+			t.Fatalf("wrong already present flag for entry %d", i)
+		}
+	}
+
+	head, err := vlog.VerifiedLatestTreeHead(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.TreeSize != 10 {
+		t.Fatalf("wrong tree size: %d", head.TreeSize)
+	}
+
+	// Check they went in order
+	err = vlog.VerifyEntries(ctx, nil, head, func(ctx context.Context, idx int64, entry *pb.LeafData) error {
+		if !bytes.Equal(entry.LeafInput, entries[idx].LeafInput) {
+			return verifiable.ErrVerificationFailed
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLogAddEntries(t *testing.T) {
+	testLogAddEntries(t, createCleanEmptyService())
+	testLogAddEntries(t, createCleanEmptyBatchMutatorService())
+
+	go httprest.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		RestListenBind:           ":8097",
+	}, createCleanEmptyService())
+	time.Sleep(50 * time.Millisecond)
+	testLogAddEntries(t, (&httprest.Client{
+		BaseURL: "http://localhost:8097",
+	}).MustDial())
+}
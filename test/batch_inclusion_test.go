@@ -0,0 +1,220 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/merkle"
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/server/grpc"
+	"github.com/continusec/verifiabledatastructures/server/httprest"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// noBatchService behaves as a server that pre-dates batch inclusion proofs and map multi-gets
+type noBatchService struct {
+	pb.VerifiableDataStructuresServiceServer
+}
+
+func (s *noBatchService) LogBatchInclusionProof(ctx context.Context, req *pb.LogBatchInclusionProofRequest) (*pb.LogBatchInclusionProofResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "no batch proofs")
+}
+
+func (s *noBatchService) MapGetValues(ctx context.Context, req *pb.MapGetValuesRequest) (*pb.MapGetValuesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "no batch proofs")
+}
+
+func leafHashes(indices ...int64) [][]byte {
+	rv := make([][]byte, len(indices))
+	for i, idx := range indices {
+		rv[i] = merkle.LeafHash([]byte(fmt.Sprintf("foo%d", idx)))
+	}
+	return rv
+}
+
+func TestBatchInclusionProof(t *testing.T) {
+	ctx := context.TODO()
+	service := createCleanEmptyService()
+	vlog := (&verifiable.Client{Service: service}).Account("0", "").VerifiableLog("foo")
+	addEntriesAndWait(t, vlog, 0, 40)
+
+	rnd := rand.New(rand.NewSource(0))
+	for n := int64(1); n <= 40; n++ {
+		head, err := vlog.VerifiedTreeHead(ctx, nil, n)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var all []int64
+		for i := int64(0); i < n; i++ {
+			all = append(all, i)
+		}
+		batches := [][]int64{all, {0, n - 1}, {n - 1, 0, n - 1}}
+		for i := int64(0); i < n; i++ {
+			batches = append(batches, []int64{i})
+		}
+		for i := 0; i < 10; i++ {
+			var b []int64
+			for _, idx := range all {
+				if rnd.Intn(4) == 0 {
+					b = append(b, idx)
+				}
+			}
+			if len(b) != 0 {
+				batches = append(batches, b)
+			}
+		}
+
+		for _, b := range batches {
+			err = vlog.VerifyBatchInclusion(ctx, head, leafHashes(b...))
+			if err != nil {
+				t.Fatalf("size %d, leaves %v: %s", n, b, err)
+			}
+
+			// Never larger than the sum of the individual proofs
+			proof, err := service.LogBatchInclusionProof(ctx, &pb.LogBatchInclusionProofRequest{
+				Log:         vlog.Log,
+				TreeSize:    n,
+				LeafIndices: b,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			total := 0
+			for _, idx := range b {
+				total += len(merkle.Path(idx, 0, n))
+			}
+			if len(proof.AuditPath) > total {
+				t.Fatal("batch proof larger than individual proofs")
+			}
+			err = verifiable.VerifyLogBatchInclusionProof(proof, leafHashes(b...), head)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// All leaves need no other hashes
+	proof, err := vlog.BatchInclusionProof(ctx, 40, leafHashes(0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32, 33, 34, 35, 36, 37, 38, 39))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(proof.AuditPath) != 0 {
+		t.Fatal("expected empty audit path")
+	}
+
+	// Tampered proofs must fail
+	head, err := vlog.VerifiedTreeHead(ctx, nil, 40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaves := leafHashes(3, 17)
+	for _, tamper := range []func(p *pb.LogBatchInclusionProofResponse, l [][]byte){
+		func(p *pb.LogBatchInclusionProofResponse, l [][]byte) { p.AuditPath = p.AuditPath[1:] },
+		func(p *pb.LogBatchInclusionProofResponse, l [][]byte) {
+			p.AuditPath = append(p.AuditPath, p.AuditPath[0])
+		},
+		func(p *pb.LogBatchInclusionProofResponse, l [][]byte) {
+			p.AuditPath = append(p.AuditPath, &pb.LogSubTreeHash{Start: 0, End: 40, Hash: head.RootHash})
+		},
+		func(p *pb.LogBatchInclusionProofResponse, l [][]byte) { p.AuditPath[0].Hash = l[0] },
+		func(p *pb.LogBatchInclusionProofResponse, l [][]byte) { l[0], l[1] = l[1], l[0] },
+		func(p *pb.LogBatchInclusionProofResponse, l [][]byte) { l[1] = leafHashes(18)[0] },
+		func(p *pb.LogBatchInclusionProofResponse, l [][]byte) { p.LeafIndices[1] = 40 },
+		func(p *pb.LogBatchInclusionProofResponse, l [][]byte) { p.TreeSize = 39 },
+	} {
+		proof, err := vlog.BatchInclusionProof(ctx, 40, leaves)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = verifiable.VerifyLogBatchInclusionProof(proof, leaves, head)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l := append([][]byte(nil), leaves...)
+		tamper(proof, l)
+		err = verifiable.VerifyLogBatchInclusionProof(proof, l, head)
+		expectErr(t, verifiable.ErrVerificationFailed, err)
+	}
+
+	// Leaves not in the log, or not in the tree size, are rejected
+	_, err = vlog.BatchInclusionProof(ctx, 40, leafHashes(3, 40))
+	if status.Code(err) != codes.NotFound {
+		t.Fatal("expected not found")
+	}
+	_, err = vlog.BatchInclusionProof(ctx, 20, leafHashes(3, 30))
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatal("expected invalid argument")
+	}
+
+	go grpc.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		GrpcListenBind:           ":8085",
+		GrpcListenProtocol:       "tcp4",
+	}, service)
+	go httprest.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		RestListenBind:           ":8100",
+	}, service)
+	time.Sleep(50 * time.Millisecond)
+
+	for _, s := range []pb.VerifiableDataStructuresServiceServer{
+		&noBatchService{service},
+		(&grpc.Client{
+			Address:        "localhost:8085",
+			NoGrpcSecurity: true,
+		}).MustDial(),
+		(&httprest.Client{
+			BaseURL: "http://localhost:8100",
+		}).MustDial(),
+	} {
+		vlog := (&verifiable.Client{Service: s}).Account("0", "").VerifiableLog("foo")
+		err = vlog.VerifyBatchInclusion(ctx, head, leafHashes(3, 17, 39))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = vlog.VerifyBatchInclusion(ctx, head, leafHashes(3, 17, 40))
+		if err == nil {
+			t.Fatal("expected error for leaf not in log")
+		}
+	}
+
+	// By index
+	proof, err = (&httprest.Client{
+		BaseURL: "http://localhost:8100",
+	}).MustDial().LogBatchInclusionProof(ctx, &pb.LogBatchInclusionProofRequest{
+		Log:         vlog.Log,
+		TreeSize:    40,
+		LeafIndices: []int64{5, 6},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = verifiable.VerifyLogBatchInclusionProof(proof, leafHashes(5, 6), head)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
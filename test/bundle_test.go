@@ -0,0 +1,224 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/server/grpc"
+	"github.com/continusec/verifiabledatastructures/server/httprest"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// noBundleService behaves as a server that pre-dates the bundle methods
+type noBundleService struct {
+	pb.VerifiableDataStructuresServiceServer
+}
+
+func (s *noBundleService) LogFetchEntryBundle(ctx context.Context, req *pb.LogFetchEntryBundleRequest) (*pb.LogFetchEntryBundleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "no bundles")
+}
+
+func (s *noBundleService) MapGetValueBundle(ctx context.Context, req *pb.MapGetValueBundleRequest) (*pb.MapGetValueBundleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "no bundles")
+}
+
+func addEntriesAndWait(t *testing.T, vlog *verifiable.Log, first, last int) {
+	var entries []*pb.LeafData
+	for i := first; i < last; i++ {
+		entries = append(entries, &pb.LeafData{LeafInput: []byte(fmt.Sprintf("foo%d", i))})
+	}
+	proms, err := vlog.AddEntries(context.TODO(), entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = proms[len(proms)-1].Wait(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testBundles(t *testing.T, service pb.VerifiableDataStructuresServiceServer, key crypto.PublicKey) {
+	ctx := context.TODO()
+	acc := (&verifiable.Client{Service: service, TrustedKey: key}).Account("0", "")
+
+	vlog := acc.VerifiableLog("foo")
+	_, _, err := vlog.VerifiedEntry(ctx, nil, 0, 0)
+	if err == nil {
+		t.Fatal("expected error for empty log")
+	}
+
+	addEntriesAndWait(t, vlog, 0, 5)
+	v, head, err := vlog.VerifiedEntry(ctx, nil, 2, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(v.LeafInput) != "foo2" || head.TreeSize != 5 {
+		t.Fatal("wrong entry or head")
+	}
+
+	// Newer head, consistent with the last
+	addEntriesAndWait(t, vlog, 5, 8)
+	v, newHead, err := vlog.VerifiedEntry(ctx, head, 6, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(v.LeafInput) != "foo6" || newHead.TreeSize != 8 {
+		t.Fatal("wrong entry or head")
+	}
+
+	// Older head, consistent with the newer
+	v, oldHead, err := vlog.VerifiedEntry(ctx, newHead, 1, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(v.LeafInput) != "foo1" || oldHead.TreeSize != 5 {
+		t.Fatal("wrong entry or head")
+	}
+
+	// Entry not yet in the tree head asked for
+	_, _, err = vlog.VerifiedEntry(ctx, nil, 6, 5)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	// A bad previous head must fail
+	_, _, err = vlog.VerifiedEntry(ctx, &pb.LogTreeHashResponse{TreeSize: 3, RootHash: head.RootHash}, 1, 0)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+
+	vmap := acc.VerifiableMap("foo")
+	_, _, err = vmap.VerifiedGetBundle(ctx, []byte("k0"), nil, 0)
+	expectErrCode(t, codes.NotFound, err)
+
+	for i := 0; i < 3; i++ {
+		p, err := vmap.Set(ctx, []byte(fmt.Sprintf("k%d", i)), &pb.LeafData{LeafInput: []byte(fmt.Sprintf("v%d", i))})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = p.Wait(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	v, state, err := vmap.VerifiedGetBundle(ctx, []byte("k1"), nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(v.LeafInput) != "v1" || state.TreeSize() != 3 {
+		t.Fatal("wrong value or state")
+	}
+
+	// Missing keys are proven empty
+	v, _, err = vmap.VerifiedGetBundle(ctx, []byte("nope"), state, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v.LeafInput) != 0 {
+		t.Fatal("expected empty value")
+	}
+
+	p, err := vmap.Set(ctx, []byte("k1"), &pb.LeafData{LeafInput: []byte("changed")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.Wait(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, newState, err := vmap.VerifiedGetBundle(ctx, []byte("k1"), state, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(v.LeafInput) != "changed" || newState.TreeSize() != 4 {
+		t.Fatal("wrong value or state")
+	}
+
+	// Same state as fetched the long way
+	ms, err := vmap.VerifiedMapState(ctx, state, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(ms.MapTreeHead.RootHash) != string(newState.MapTreeHead.RootHash) {
+		t.Fatal("wrong root hash")
+	}
+
+	// Older state, from the newer
+	v, oldState, err := vmap.VerifiedGetBundle(ctx, []byte("k1"), newState, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(v.LeafInput) != "v1" || oldState.TreeSize() != 3 {
+		t.Fatal("wrong value or state")
+	}
+
+	// A previous state from a different map must fail
+	other := acc.VerifiableMap("bar")
+	p, err = other.Set(ctx, []byte("k1"), &pb.LeafData{LeafInput: []byte("other")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.Wait(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherState, err := other.VerifiedGetBundle(ctx, []byte("k1"), nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, err = vmap.VerifiedGetBundle(ctx, []byte("k1"), otherState, 0)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+}
+
+func TestBundles(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testBundles(t, createSignedService(priv), priv.Public())
+	testBundles(t, createCleanEmptyBatchMutatorService(), nil)
+	testBundles(t, &noBundleService{createCleanEmptyService()}, nil)
+
+	go grpc.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		GrpcListenBind:           ":8084",
+		GrpcListenProtocol:       "tcp4",
+	}, createCleanEmptyService())
+	go httprest.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		RestListenBind:           ":8099",
+	}, createCleanEmptyService())
+	time.Sleep(50 * time.Millisecond)
+
+	testBundles(t, (&grpc.Client{
+		Address:        "localhost:8084",
+		NoGrpcSecurity: true,
+	}).MustDial(), nil)
+	testBundles(t, (&httprest.Client{
+		BaseURL: "http://localhost:8099",
+	}).MustDial(), nil)
+}
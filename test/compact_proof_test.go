@@ -0,0 +1,126 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/server/grpc"
+	"github.com/continusec/verifiabledatastructures/server/httprest"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	"google.golang.org/protobuf/proto"
+)
+
+func testCompactMapProofs(t *testing.T, service pb.VerifiableDataStructuresServiceServer) {
+	ctx := context.TODO()
+	vmap := (&verifiable.Client{Service: service}).Account("0", "").VerifiableMap("foo")
+
+	var muts []*pb.MapMutation
+	for i := 0; i < 20; i++ {
+		muts = append(muts, setMut(fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i)))
+	}
+	head := applyAndWait(t, vmap, muts...)
+
+	// Present and absent keys must both verify, in either form
+	for _, key := range []string{"key0", "key7", "key19", "missing"} {
+		full, err := service.MapGetValue(ctx, &pb.MapGetValueRequest{Map: vmap.Map, Key: []byte(key), TreeSize: head.MutationLog.TreeSize})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = verifiable.VerifyMapInclusionProof(full, []byte(key), head)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		compact, err := service.MapGetValue(ctx, &pb.MapGetValueRequest{Map: vmap.Map, Key: []byte(key), TreeSize: head.MutationLog.TreeSize, CompactProof: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(compact.AuditPath) != 0 || len(compact.AuditPathBitmap) != 32 {
+			t.Fatal("expected compact proof")
+		}
+		set := 0
+		for i := 0; i < 256; i++ {
+			if compact.AuditPathBitmap[i/8]&(0x80>>uint(i%8)) != 0 {
+				set++
+			}
+		}
+		if set != len(compact.CompactAuditPath) || set == 0 || set > 20 {
+			t.Fatalf("unexpected number of hashes in compact proof: %d", set)
+		}
+		if proto.Size(compact) >= proto.Size(full) {
+			t.Fatal("compact proof is not smaller")
+		}
+		err = verifiable.VerifyMapInclusionProof(compact, []byte(key), head)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Tampered proofs must fail
+		for _, tamper := range []func(p *pb.MapGetValueResponse){
+			func(p *pb.MapGetValueResponse) { p.AuditPathBitmap[0] ^= 0x80 },
+			func(p *pb.MapGetValueResponse) { p.AuditPathBitmap = p.AuditPathBitmap[1:] },
+			func(p *pb.MapGetValueResponse) { p.CompactAuditPath = p.CompactAuditPath[1:] },
+			func(p *pb.MapGetValueResponse) {
+				p.CompactAuditPath = append(p.CompactAuditPath, p.CompactAuditPath[0])
+			},
+			func(p *pb.MapGetValueResponse) { p.AuditPath = full.AuditPath },
+		} {
+			bad := proto.Clone(compact).(*pb.MapGetValueResponse)
+			tamper(bad)
+			err = verifiable.VerifyMapInclusionProof(bad, []byte(key), head)
+			expectErr(t, verifiable.ErrVerificationFailed, err)
+		}
+	}
+
+	// The high-level API requests compact proofs
+	ms, err := vmap.VerifiedLatestMapState(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectMapValues(t, vmap, ms, map[string]string{"key3": "value3", "missing": ""})
+}
+
+func TestCompactMapProofs(t *testing.T) {
+	testCompactMapProofs(t, createCleanEmptyService())
+	testCompactMapProofs(t, createCleanEmptyBatchMutatorService())
+
+	go grpc.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		GrpcListenBind:           ":8088",
+		GrpcListenProtocol:       "tcp4",
+	}, createCleanEmptyService())
+	go httprest.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		RestListenBind:           ":8103",
+	}, createCleanEmptyService())
+	time.Sleep(50 * time.Millisecond)
+
+	testCompactMapProofs(t, (&grpc.Client{
+		Address:        "localhost:8088",
+		NoGrpcSecurity: true,
+	}).MustDial())
+	testCompactMapProofs(t, (&httprest.Client{
+		BaseURL: "http://localhost:8103",
+	}).MustDial())
+}
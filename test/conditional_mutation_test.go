@@ -0,0 +1,141 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/merkle"
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/server/grpc"
+	"github.com/continusec/verifiabledatastructures/server/httprest"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+)
+
+func keyVersion(t *testing.T, vmap *verifiable.Map, key string) int64 {
+	resp, err := vmap.KeyHistory(context.TODO(), []byte(key), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Entries) == 0 {
+		return -1
+	}
+	return resp.Entries[len(resp.Entries)-1].MutationIndex
+}
+
+func testConditionalMutations(t *testing.T, service pb.VerifiableDataStructuresServiceServer) {
+	ctx := context.TODO()
+	acc := (&verifiable.Client{Service: service}).Account("0", "")
+	vmap := acc.VerifiableMap("foo")
+	v := func(s string) *pb.LeafData { return &pb.LeafData{LeafInput: []byte(s)} }
+	wait := func(p verifiable.MapUpdatePromise, err error) *pb.MapTreeHashResponse {
+		if err != nil {
+			t.Fatal(err)
+		}
+		rv, err := p.Wait(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rv
+	}
+
+	// Create only applies to a key with no value
+	head := wait(vmap.Create(ctx, []byte("a"), v("1")))
+	next := wait(vmap.Create(ctx, []byte("a"), v("2")))
+	if string(next.RootHash) != string(head.RootHash) {
+		t.Fatal("create of existing key should not apply")
+	}
+
+	// Compare and delete only applies if the leaf hash matches
+	next = wait(vmap.CompareAndDelete(ctx, []byte("a"), merkle.LeafHash([]byte("2"))))
+	if string(next.RootHash) != string(head.RootHash) {
+		t.Fatal("compare and delete with wrong leaf hash should not apply")
+	}
+	wait(vmap.CompareAndDelete(ctx, []byte("a"), merkle.LeafHash([]byte("1"))))
+	if keyVersion(t, vmap, "a") != 3 {
+		t.Fatal("expected delete to change the version")
+	}
+
+	// Set if version only applies if the key was last changed at that mutation index
+	wait(vmap.SetIfVersion(ctx, []byte("a"), v("3"), 0))
+	if keyVersion(t, vmap, "a") != 3 {
+		t.Fatal("set if version with wrong version should not apply")
+	}
+	wait(vmap.SetIfVersion(ctx, []byte("a"), v("3"), 3))
+	wait(vmap.SetIfVersion(ctx, []byte("b"), v("1"), -1))
+	if keyVersion(t, vmap, "a") != 5 || keyVersion(t, vmap, "b") != 6 {
+		t.Fatal("set if version with right version should apply")
+	}
+
+	// In a transaction, a key changed by an earlier operation has the version of the transaction
+	applyAndWait(t, vmap,
+		&pb.MapMutation{Action: "create", Key: []byte("c"), Value: v("1")},
+		&pb.MapMutation{Action: "set_if_version", Key: []byte("c"), Value: v("2"), Version: 7},
+		&pb.MapMutation{Action: "compare_and_delete", Key: []byte("b"), PreviousLeafHash: merkle.LeafHash([]byte("1"))},
+	)
+
+	// None apply if any precondition fails
+	head = applyAndWait(t, vmap,
+		setMut("d", "1"),
+		&pb.MapMutation{Action: "create", Key: []byte("c"), Value: v("3")},
+	)
+	next = applyAndWait(t, vmap,
+		setMut("d", "1"),
+		&pb.MapMutation{Action: "set_if_version", Key: []byte("a"), Value: v("4"), Version: 3},
+	)
+	if string(next.RootHash) != string(head.RootHash) {
+		t.Fatal("transaction with failed precondition should not apply")
+	}
+
+	ms, err := vmap.VerifiedLatestMapState(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectMapValues(t, vmap, ms, map[string]string{"a": "3", "b": "", "c": "2", "d": ""})
+	err = vmap.VerifyMap(ctx, nil, ms, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConditionalMutations(t *testing.T) {
+	testConditionalMutations(t, createCleanEmptyService())
+	testConditionalMutations(t, createCleanEmptyBatchMutatorService())
+
+	go grpc.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		GrpcListenBind:           ":8107",
+		GrpcListenProtocol:       "tcp4",
+	}, createCleanEmptyService())
+	go httprest.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		RestListenBind:           ":8108",
+	}, createCleanEmptyService())
+	time.Sleep(50 * time.Millisecond)
+
+	testConditionalMutations(t, (&grpc.Client{
+		Address:        "localhost:8107",
+		NoGrpcSecurity: true,
+	}).MustDial())
+	testConditionalMutations(t, (&httprest.Client{
+		BaseURL: "http://localhost:8108",
+	}).MustDial())
+}
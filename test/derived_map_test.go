@@ -0,0 +1,229 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/mutator/batch"
+	"github.com/continusec/verifiabledatastructures/mutator/instant"
+	"github.com/continusec/verifiabledatastructures/oracle/policy"
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/server/grpc"
+	"github.com/continusec/verifiabledatastructures/server/httprest"
+	"github.com/continusec/verifiabledatastructures/storage/memory"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	"google.golang.org/grpc/codes"
+)
+
+// latestEventReducer keeps the latest event for each subject, other than pings, until it is deleted
+var latestEventReducer = &pb.MapReducer{
+	KeyPath: "subject",
+	Rules: []*pb.MapReducerRule{
+		{Path: "type", Equals: "deleted", Action: "delete"},
+		{Path: "type", Equals: "ping", Action: "skip"},
+	},
+}
+
+var testDerivedMaps = []*pb.DerivedMap{
+	{Account: "0", Log: "events", Map: "latest", Reducer: latestEventReducer},
+	{Account: "0", Log: "docs", Map: "bodies", Reducer: &pb.MapReducer{KeyPath: "meta.id", ValuePath: "body"}},
+}
+
+func createDerivedMapService(db verifiable.StorageWriter, derived []*pb.DerivedMap, batched bool) pb.VerifiableDataStructuresServiceServer {
+	var mutator verifiable.MutatorService = &instant.Mutator{Writer: db}
+	if batched {
+		mutator = (&batch.Mutator{
+			Writer:     db,
+			BatchSize:  1000,
+			BufferSize: 100000,
+			Timeout:    time.Millisecond * 10,
+		}).MustCreate()
+	}
+	return (&verifiable.Service{
+		AccessPolicy: policy.Open,
+		Mutator:      mutator,
+		Reader:       db,
+		DerivedMaps:  derived,
+	}).MustCreate()
+}
+
+func addLeavesAndWait(t *testing.T, vlog *verifiable.Log, entries ...*pb.LeafData) {
+	proms, err := vlog.AddEntries(context.TODO(), entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = proms[len(proms)-1].Wait(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testDerivedMap(t *testing.T, service pb.VerifiableDataStructuresServiceServer) {
+	ctx := context.TODO()
+	acc := (&verifiable.Client{Service: service}).Account("0", "")
+	vlog := acc.VerifiableLog("events")
+	vmap := acc.VerifiableMap("latest")
+
+	addLeavesAndWait(t, vlog,
+		jsonLeafData(t, `{"subject":"alice","type":"login"}`),
+		jsonLeafData(t, `{"subject":"bob","type":"login"}`),
+		jsonLeafData(t, `{"subject":"alice","type":"logout"}`),
+		jsonLeafData(t, `{"type":"login"}`),
+		&pb.LeafData{LeafInput: []byte("not json")},
+	)
+	first, err := vmap.VerifiedLatestMapState(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.TreeSize() != 5 {
+		t.Fatalf("wrong map size: %d", first.TreeSize())
+	}
+
+	// Duplicate entries are only added to the log once, so only derive once
+	addLeavesAndWait(t, vlog,
+		jsonLeafData(t, `{"subject":"bob","type":"deleted"}`),
+		jsonLeafData(t, `{"subject":"carol","type":"ping"}`),
+		jsonLeafData(t, `{"subject":12345678901234567890,"type":"login"}`),
+		jsonLeafData(t, `{"subject":"alice","type":"login"}`),
+		jsonLeafData(t, `{"subject":"dave","type":"deleted"}`),
+	)
+	ms, err := vmap.VerifiedLatestMapState(ctx, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ms.TreeSize() != 9 {
+		t.Fatalf("wrong map size: %d", ms.TreeSize())
+	}
+
+	expectJSONValue(t, vmap, ms, "alice", `{"subject":"alice","type":"logout"}`)
+	expectJSONValue(t, vmap, ms, "12345678901234567890", `{"subject":12345678901234567890,"type":"login"}`)
+	for _, k := range []string{"bob", "carol", "dave"} {
+		entry, err := vmap.VerifiedGet(ctx, []byte(k), ms)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entry.LeafInput) != 0 {
+			t.Fatalf("%s should not have a value", k)
+		}
+	}
+	expectJSONValue(t, vmap, first, "bob", `{"subject":"bob","type":"login"}`)
+
+	err = vmap.VerifyMap(ctx, nil, ms, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = vmap.VerifyDerivedMap(ctx, vlog, latestEventReducer, ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = vmap.VerifyDerivedMap(ctx, vlog, latestEventReducer, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = vmap.VerifyDerivedMap(ctx, vlog, &pb.MapReducer{KeyPath: "subject"}, ms)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+
+	// The map is replayed as the kind of map the client expects, which must match the tree heads
+	summed := acc.VerifiableMap("latest")
+	summed.Map.SumField = "n"
+	err = summed.VerifyDerivedMap(ctx, vlog, latestEventReducer, ms)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+	vrfKeys := acc.VerifiableMap("latest")
+	vrfKeys.Map.VrfKeys = true
+	err = vrfKeys.VerifyDerivedMap(ctx, vlog, latestEventReducer, ms)
+	expectErr(t, verifiable.ErrInvalidRequest, err)
+
+	// Only the log can add to the map
+	_, err = vmap.Set(ctx, []byte("erin"), jsonLeafData(t, `{}`))
+	expectErrCode(t, codes.InvalidArgument, err)
+
+	// A value can come from part of each entry
+	docs := acc.VerifiableLog("docs")
+	bodies := acc.VerifiableMap("bodies")
+	addLeavesAndWait(t, docs,
+		jsonLeafData(t, `{"meta":{"id":"x"},"body":{"b":1,"a":[2,"c"]}}`),
+		jsonLeafData(t, `{"meta":{"id":"y"}}`),
+	)
+	bs, err := bodies.VerifiedLatestMapState(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectJSONValue(t, bodies, bs, "x", `{"a":[2,"c"],"b":1}`)
+	err = bodies.VerifyDerivedMap(ctx, docs, testDerivedMaps[1].Reducer, bs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = bodies.VerifyDerivedMap(ctx, vlog, testDerivedMaps[1].Reducer, bs)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+}
+
+func TestDerivedMap(t *testing.T) {
+	testDerivedMap(t, createDerivedMapService(&memory.TransientStorage{}, testDerivedMaps, false))
+	testDerivedMap(t, createDerivedMapService(&memory.TransientStorage{}, testDerivedMaps, true))
+
+	go grpc.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		GrpcListenBind:           ":8118",
+		GrpcListenProtocol:       "tcp4",
+	}, createDerivedMapService(&memory.TransientStorage{}, testDerivedMaps, false))
+	go httprest.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		RestListenBind:           ":8119",
+	}, createDerivedMapService(&memory.TransientStorage{}, testDerivedMaps, false))
+	time.Sleep(50 * time.Millisecond)
+
+	testDerivedMap(t, (&grpc.Client{
+		Address:        "localhost:8118",
+		NoGrpcSecurity: true,
+	}).MustDial())
+	testDerivedMap(t, (&httprest.Client{
+		BaseURL: "http://localhost:8119",
+	}).MustDial())
+}
+
+func TestDerivedMapConfig(t *testing.T) {
+	ctx := context.TODO()
+	db := &memory.TransientStorage{}
+	addLeavesAndWait(t, (&verifiable.Client{Service: createDerivedMapService(db, testDerivedMaps, false)}).Account("0", "").VerifiableLog("events"),
+		jsonLeafData(t, `{"subject":"alice"}`))
+
+	// The reducer cannot change once the log has entries, nor can a map be derived from a log that has some
+	for _, derived := range [][]*pb.DerivedMap{
+		{{Account: "0", Log: "events", Map: "latest", Reducer: &pb.MapReducer{KeyPath: "type"}}},
+		nil,
+	} {
+		_, err := (&verifiable.Client{Service: createDerivedMapService(db, derived, false)}).Account("0", "").VerifiableLog("events").Add(ctx, jsonLeafData(t, `{"subject":"bob"}`))
+		expectErrCode(t, codes.FailedPrecondition, err)
+	}
+
+	for _, derived := range [][]*pb.DerivedMap{
+		{{Account: "0", Log: "events", Map: "latest"}},
+		{{Account: "0", Log: "events", Map: "latest", Reducer: &pb.MapReducer{Rules: []*pb.MapReducerRule{{Action: "unset"}}}}},
+		{testDerivedMaps[0], {Account: "0", Log: "other", Map: "latest", Reducer: latestEventReducer}},
+		{testDerivedMaps[0], {Account: "0", Log: "events", Map: "other", Reducer: latestEventReducer}},
+	} {
+		_, err := (&verifiable.Service{DerivedMaps: derived}).Create()
+		if err == nil {
+			t.Fatal("expected bad derived maps to fail")
+		}
+	}
+}
@@ -0,0 +1,165 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/server/grpc"
+	"github.com/continusec/verifiabledatastructures/server/httprest"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+)
+
+func jsonLeafData(t *testing.T, s string) *pb.LeafData {
+	rv, err := verifiable.CreateJSONLeafData([]byte(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rv
+}
+
+func expectJSONValue(t *testing.T, vmap *verifiable.Map, ms *verifiable.MapTreeState, key, s string) {
+	entry, err := vmap.VerifiedGet(context.TODO(), []byte(key), ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(entry.LeafInput) != string(jsonLeafData(t, s).LeafInput) {
+		t.Fatalf("wrong value for %s: %s", key, entry.ExtraData)
+	}
+}
+
+func testDerivedMutations(t *testing.T, service pb.VerifiableDataStructuresServiceServer) {
+	ctx := context.TODO()
+	vmap := (&verifiable.Client{Service: service}).Account("0", "").VerifiableMap("foo")
+	wait := func(p verifiable.MapUpdatePromise, err error) *pb.MapTreeHashResponse {
+		if err != nil {
+			t.Fatal(err)
+		}
+		rv, err := p.Wait(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return rv
+	}
+
+	// Patch merges into the existing value
+	wait(vmap.Set(ctx, []byte("doc"), jsonLeafData(t, `{"a":1,"b":{"c":2}}`)))
+	wait(vmap.Patch(ctx, []byte("doc"), []byte(`{"b":{"c":null,"d":3},"e":"x"}`)))
+
+	// Increment adds to a field, or the value itself, treating absent values as zero
+	wait(vmap.Increment(ctx, []byte("counter"), "n", 2))
+	wait(vmap.Increment(ctx, []byte("counter"), "n", 3))
+	wait(vmap.Increment(ctx, []byte("num"), "", -4))
+
+	// Numbers are kept exactly, even beyond the precision of a float64
+	wait(vmap.Set(ctx, []byte("big"), jsonLeafData(t, `{"id":9007199254740993,"n":1.5}`)))
+	wait(vmap.Patch(ctx, []byte("big"), []byte(`{"tag":"x"}`)))
+	wait(vmap.Increment(ctx, []byte("big"), "id", 2))
+
+	// Neither applies if the value cannot be derived
+	head := wait(vmap.Set(ctx, []byte("plain"), &pb.LeafData{LeafInput: []byte("not json")}))
+	next := wait(vmap.Patch(ctx, []byte("plain"), []byte(`{"a":1}`)))
+	if string(next.RootHash) != string(head.RootHash) {
+		t.Fatal("patch of non-JSON value should not apply")
+	}
+	next = wait(vmap.Increment(ctx, []byte("doc"), "e", 1))
+	if string(next.RootHash) != string(head.RootHash) {
+		t.Fatal("increment of non-numeric field should not apply")
+	}
+	next = wait(vmap.Increment(ctx, []byte("big"), "n", 1))
+	if string(next.RootHash) != string(head.RootHash) {
+		t.Fatal("increment of non-integer field should not apply")
+	}
+	head = wait(vmap.Set(ctx, []byte("max"), jsonLeafData(t, `{"n":9223372036854775807}`)))
+	next = wait(vmap.Increment(ctx, []byte("max"), "n", 1))
+	if string(next.RootHash) != string(head.RootHash) {
+		t.Fatal("increment that overflows should not apply")
+	}
+
+	// In a transaction, each is derived from the value set by earlier operations
+	head = applyAndWait(t, vmap,
+		&pb.MapMutation{Action: "increment", Key: []byte("counter"), Field: "n", Delta: 1},
+		&pb.MapMutation{Action: "increment", Key: []byte("counter"), Field: "n", Delta: 1},
+		&pb.MapMutation{Action: "patch", Key: []byte("counter"), Patch: []byte(`{"m":true}`)},
+	)
+
+	// None apply if any cannot be derived
+	next = applyAndWait(t, vmap,
+		&pb.MapMutation{Action: "increment", Key: []byte("counter"), Field: "n", Delta: 1},
+		&pb.MapMutation{Action: "increment", Key: []byte("doc"), Field: "e", Delta: 1},
+	)
+	if string(next.RootHash) != string(head.RootHash) {
+		t.Fatal("transaction with value that cannot be derived should not apply")
+	}
+
+	ms, err := vmap.VerifiedLatestMapState(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectJSONValue(t, vmap, ms, "doc", `{"a":1,"b":{"d":3},"e":"x"}`)
+	expectJSONValue(t, vmap, ms, "counter", `{"n":7,"m":true}`)
+	expectJSONValue(t, vmap, ms, "num", `-4`)
+	big, err := vmap.VerifiedGet(ctx, []byte("big"), ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(big.ExtraData) != `{"id":9007199254740995,"n":1.5,"tag":"x"}` {
+		t.Fatalf("wrong value for big: %s", big.ExtraData)
+	}
+
+	history, err := vmap.VerifiedKeyHistory(ctx, []byte("counter"), ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 3 {
+		t.Fatalf("wrong number of history entries: %d", len(history))
+	}
+
+	err = vmap.VerifyMap(ctx, nil, ms, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDerivedMutations(t *testing.T) {
+	testDerivedMutations(t, createCleanEmptyService())
+	testDerivedMutations(t, createCleanEmptyBatchMutatorService())
+
+	go grpc.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		GrpcListenBind:           ":8109",
+		GrpcListenProtocol:       "tcp4",
+	}, createCleanEmptyService())
+	go httprest.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		RestListenBind:           ":8110",
+	}, createCleanEmptyService())
+	time.Sleep(50 * time.Millisecond)
+
+	testDerivedMutations(t, (&grpc.Client{
+		Address:        "localhost:8109",
+		NoGrpcSecurity: true,
+	}).MustDial())
+	testDerivedMutations(t, (&httprest.Client{
+		BaseURL: "http://localhost:8110",
+	}).MustDial())
+}
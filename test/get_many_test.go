@@ -0,0 +1,169 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/server/grpc"
+	"github.com/continusec/verifiabledatastructures/server/httprest"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	"google.golang.org/protobuf/proto"
+)
+
+func keysOf(ks ...string) [][]byte {
+	rv := make([][]byte, len(ks))
+	for i, k := range ks {
+		rv[i] = []byte(k)
+	}
+	return rv
+}
+
+func testMapGetValues(t *testing.T, service pb.VerifiableDataStructuresServiceServer) {
+	ctx := context.TODO()
+	vmap := (&verifiable.Client{Service: service}).Account("0", "").VerifiableMap("foo")
+
+	var muts []*pb.MapMutation
+	for i := 0; i < 50; i++ {
+		muts = append(muts, setMut(fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i)))
+	}
+	applyAndWait(t, vmap, muts...)
+	applyAndWait(t, vmap, deleteMut("key5"))
+	ms, err := vmap.VerifiedLatestMapState(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Present, absent, deleted and repeated keys
+	keys := keysOf("key3", "missing", "key5", "key49", "key3", "key0")
+	values, err := vmap.VerifiedGetMany(ctx, keys, ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []string{"value3", "", "", "value49", "value3", "value0"} {
+		if string(values[i].LeafInput) != want {
+			t.Fatalf("wrong value for %s: %s", keys[i], values[i].LeafInput)
+		}
+	}
+
+	// Shared subtrees are only sent once
+	proof, err := vmap.GetMany(ctx, keys, ms.TreeSize())
+	if err != nil {
+		t.Fatal(err)
+	}
+	total := 0
+	for _, key := range keys {
+		single, err := vmap.Get(ctx, key, ms.TreeSize())
+		if err != nil {
+			t.Fatal(err)
+		}
+		total += len(single.CompactAuditPath)
+	}
+	if len(proof.AuditPath) == 0 || len(proof.AuditPath) >= total {
+		t.Fatalf("expected merged proof to be smaller: %d vs %d", len(proof.AuditPath), total)
+	}
+
+	// Tampered proofs, or proofs for other keys, must fail
+	for _, tamper := range []func(p *pb.MapGetValuesResponse){
+		func(p *pb.MapGetValuesResponse) { p.Values[0], p.Values[3] = p.Values[3], p.Values[0] },
+		func(p *pb.MapGetValuesResponse) { p.Values[1] = &pb.LeafData{LeafInput: []byte("foo")} },
+		func(p *pb.MapGetValuesResponse) { p.Values[2] = &pb.LeafData{LeafInput: []byte("value5")} },
+		func(p *pb.MapGetValuesResponse) { p.Values[4] = p.Values[5] },
+		func(p *pb.MapGetValuesResponse) { p.Values = p.Values[1:] },
+		func(p *pb.MapGetValuesResponse) { p.AuditPath = p.AuditPath[1:] },
+		func(p *pb.MapGetValuesResponse) { p.AuditPath = append(p.AuditPath, p.AuditPath[0]) },
+		func(p *pb.MapGetValuesResponse) { p.AuditPath[0].Hash = ms.MapTreeHead.RootHash },
+		func(p *pb.MapGetValuesResponse) { p.AuditPath[0].Depth++ },
+	} {
+		bad := proto.Clone(proof).(*pb.MapGetValuesResponse)
+		tamper(bad)
+		err = verifiable.VerifyMapGetValues(bad, keys, ms.MapTreeHead)
+		expectErr(t, verifiable.ErrVerificationFailed, err)
+	}
+	err = verifiable.VerifyMapGetValues(proof, keysOf("key3", "missing", "key5", "key49", "key3", "key1"), ms.MapTreeHead)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+
+	// Earlier map sizes, including a single key
+	old, err := vmap.VerifiedMapState(ctx, nil, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values, err = vmap.VerifiedGetMany(ctx, keysOf("key5"), old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(values[0].LeafInput) != "value5" {
+		t.Fatal("wrong value for earlier map")
+	}
+
+	// Every key at once
+	var all [][]byte
+	for _, m := range muts {
+		all = append(all, m.Key)
+	}
+	values, err = vmap.VerifiedGetMany(ctx, all, old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range muts {
+		if string(values[i].LeafInput) != string(m.Value.LeafInput) {
+			t.Fatalf("wrong value for %s", m.Key)
+		}
+	}
+}
+
+func TestMapGetValues(t *testing.T) {
+	testMapGetValues(t, createCleanEmptyService())
+	testMapGetValues(t, createCleanEmptyBatchMutatorService())
+
+	// Servers without multi-gets fall back to a get for each key
+	service := createCleanEmptyService()
+	vmap := (&verifiable.Client{Service: &noBatchService{service}}).Account("0", "").VerifiableMap("foo")
+	ms := &verifiable.MapTreeState{MapTreeHead: applyAndWait(t, vmap, setMut("a", "1"), setMut("b", "2"))}
+	values, err := vmap.VerifiedGetMany(context.TODO(), keysOf("b", "c", "a"), ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(values[0].LeafInput) != "2" || len(values[1].LeafInput) != 0 || string(values[2].LeafInput) != "1" {
+		t.Fatal("wrong values from fallback")
+	}
+
+	go grpc.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		GrpcListenBind:           ":8089",
+		GrpcListenProtocol:       "tcp4",
+	}, createCleanEmptyService())
+	go httprest.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		RestListenBind:           ":8104",
+	}, createCleanEmptyService())
+	time.Sleep(50 * time.Millisecond)
+
+	testMapGetValues(t, (&grpc.Client{
+		Address:        "localhost:8089",
+		NoGrpcSecurity: true,
+	}).MustDial())
+	testMapGetValues(t, (&httprest.Client{
+		BaseURL: "http://localhost:8104",
+	}).MustDial())
+}
@@ -0,0 +1,215 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/gossip"
+	"github.com/continusec/verifiabledatastructures/mutator/instant"
+	"github.com/continusec/verifiabledatastructures/oracle/policy"
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/server/httprest"
+	"github.com/continusec/verifiabledatastructures/storage/memory"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+)
+
+func TestGossip(t *testing.T) {
+	ctx := context.TODO()
+
+	honest := createCleanEmptyService()
+	evil := createCleanEmptyService()
+
+	// Peer reached via REST
+	go httprest.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		RestListenBind:           ":8095",
+	}, honest)
+	time.Sleep(50 * time.Millisecond)
+	peer := (&httprest.Client{
+		BaseURL: "http://localhost:8095",
+	}).MustDial()
+
+	// Same log history to start with, then diverge. Map mutations are timestamped, so any two maps differ.
+	pool := &gossip.Pool{}
+	var reported *pb.GossipResponse
+	gossiper := &gossip.Gossiper{
+		Pool:  pool,
+		Peers: []pb.VerifiableDataStructuresServiceServer{peer},
+		OnInconsistent: func(p pb.VerifiableDataStructuresServiceServer, resp *pb.GossipResponse) {
+			reported = resp
+		},
+	}
+	for _, s := range []pb.VerifiableDataStructuresServiceServer{honest, evil} {
+		acc := (&verifiable.Client{Service: s}).Account("0", "")
+		for _, v := range []string{"a", "b", "c"} {
+			addAndWait(t, acc.VerifiableLog("foo"), v)
+		}
+	}
+
+	evilAcc := (&verifiable.Client{Service: evil}).Account("0", "")
+	lth, err := evilAcc.VerifiableLog("foo").VerifiedLatestTreeHead(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.AddLogTreeHead(evilAcc.VerifiableLog("foo").Log, lth)
+
+	// All consistent so far
+	err = gossiper.Exchange(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reported != nil {
+		t.Fatal("expected no inconsistencies")
+	}
+
+	// Now show a different view
+	addAndWait(t, evilAcc.VerifiableLog("foo"), "evil")
+	lth, err = evilAcc.VerifiableLog("foo").VerifiedLatestTreeHead(ctx, lth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.AddLogTreeHead(evilAcc.VerifiableLog("foo").Log, lth)
+
+	mp, err := evilAcc.VerifiableMap("foo").Set(ctx, []byte("a"), &pb.LeafData{LeafInput: []byte("evil")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = mp.Wait(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms, err := evilAcc.VerifiableMap("foo").VerifiedLatestMapState(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool.AddMapTreeState(evilAcc.VerifiableMap("foo").Map, ms)
+
+	// The honest server is behind, so can't yet tell
+	err = gossiper.Exchange(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reported != nil {
+		t.Fatal("expected larger tree heads to be ignored")
+	}
+
+	// Honest server now catches up with the same number of entries, but different contents
+	honestAcc := (&verifiable.Client{Service: honest}).Account("0", "")
+	addAndWait(t, honestAcc.VerifiableLog("foo"), "honest")
+	mp, err = honestAcc.VerifiableMap("foo").Set(ctx, []byte("a"), &pb.LeafData{LeafInput: []byte("honest")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = mp.Wait(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = gossiper.Exchange(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reported == nil || len(reported.InconsistentLogs) != 1 || len(reported.InconsistentMaps) != 1 {
+		t.Fatal("expected inconsistencies to be reported")
+	}
+
+	// And is happy with its own heads
+	lth, err = honestAcc.VerifiableLog("foo").VerifiedLatestTreeHead(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms, err = honestAcc.VerifiableMap("foo").VerifiedLatestMapState(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := peer.Gossip(ctx, &pb.GossipRequest{
+		Logs: []*pb.LogGossip{{Log: honestAcc.VerifiableLog("foo").Log, Head: lth}},
+		Maps: []*pb.MapGossip{{Map: honestAcc.VerifiableMap("foo").Map, MapHead: ms.MapTreeHead, TreeHeadLogHead: ms.TreeHeadLogTreeHead}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.InconsistentLogs) != 0 || len(resp.InconsistentMaps) != 0 {
+		t.Fatal("expected no inconsistencies")
+	}
+}
+
+func TestGossipPrivateLog(t *testing.T) {
+	ctx := context.TODO()
+
+	db := &memory.TransientStorage{}
+	private := (&verifiable.Service{
+		Mutator: &instant.Mutator{Writer: db},
+		Reader:  db,
+		AccessPolicy: &policy.Static{
+			Policy: []*pb.ResourceAccount{
+				{
+					Id: "0",
+					Policy: []*pb.AccessPolicy{
+						{
+							NameMatch:     "foo",
+							Permissions:   []pb.Permission{pb.Permission_PERM_ALL_PERMISSIONS},
+							ApiKey:        "secret",
+							AllowedFields: []string{"*"},
+						},
+					},
+				},
+			},
+		},
+	}).MustCreate()
+	other := createCleanEmptyService()
+
+	privateLog := (&verifiable.Client{Service: private}).Account("0", "secret").VerifiableLog("foo")
+	otherLog := (&verifiable.Client{Service: other}).Account("0", "").VerifiableLog("foo")
+	for _, v := range []string{"a", "b"} {
+		addAndWait(t, privateLog, v)
+	}
+	for _, v := range []string{"a", "c", "d"} {
+		addAndWait(t, otherLog, v)
+	}
+
+	// Larger than the private log, so can't be checked yet
+	head, err := otherLog.VerifiedLatestTreeHead(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unauthenticated := &pb.LogRef{Account: &pb.AccountRef{Id: "0"}, Name: "foo"}
+	resp, err := private.Gossip(ctx, &pb.GossipRequest{Logs: []*pb.LogGossip{{Log: unauthenticated, Head: head}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.InconsistentLogs) != 0 {
+		t.Fatal("expected larger tree head to be ignored")
+	}
+
+	// Gossip doesn't need read access to the log
+	head, err = otherLog.VerifiedTreeHead(ctx, head, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = private.Gossip(ctx, &pb.GossipRequest{Logs: []*pb.LogGossip{{Log: unauthenticated, Head: head}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.InconsistentLogs) != 1 {
+		t.Fatal("expected inconsistency to be reported")
+	}
+}
@@ -0,0 +1,149 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/merkle"
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/server/httprest"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	"google.golang.org/grpc/codes"
+)
+
+func testHashAlgorithm(t *testing.T, service pb.VerifiableDataStructuresServiceServer, alg pb.HashAlgorithm) {
+	ctx := context.TODO()
+	acc := (&verifiable.Client{Service: service}).Account("0", "")
+
+	vlog := acc.VerifiableLog("foo")
+	vlog.Log.HashAlgorithm = alg
+	h, err := vlog.Hasher()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addAndWait(t, vlog, "foo")
+	first, err := vlog.VerifiedLatestTreeHead(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first.RootHash, h.LeafHash([]byte("foo"))) {
+		t.Fatal("wrong root hash")
+	}
+	if alg != pb.HashAlgorithm_HASH_SHA256 && bytes.Equal(first.RootHash, merkle.LeafHash([]byte("foo"))) {
+		t.Fatal("hash algorithm not used")
+	}
+
+	for _, v := range []string{"bar", "baz", "qux"} {
+		addAndWait(t, vlog, v)
+	}
+	head, err := vlog.VerifiedLatestTreeHead(ctx, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = vlog.VerifyInclusion(ctx, head, h.LeafHash([]byte("baz")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = vlog.VerifyEntries(ctx, nil, head, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Once recorded, the hash algorithm can't be changed
+	other := acc.VerifiableLog("foo")
+	other.Log.HashAlgorithm = pb.HashAlgorithm_HASH_SHA512_256
+	if alg == other.Log.HashAlgorithm {
+		other.Log.HashAlgorithm = pb.HashAlgorithm_HASH_SHA256
+	}
+	_, err = other.Add(ctx, &pb.LeafData{LeafInput: []byte("quux")})
+	expectErrCode(t, codes.InvalidArgument, err)
+
+	// And clients expecting a different algorithm fail verification
+	_, err = other.VerifiedTreeHead(ctx, first, head.TreeSize)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+
+	vmap := acc.VerifiableMap("foo")
+	vmap.Map.HashAlgorithm = alg
+	for _, v := range []string{"foo", "bar"} {
+		p, err := vmap.Set(ctx, []byte(v), &pb.LeafData{LeafInput: []byte(v + "value")})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = p.Wait(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ms, err := vmap.VerifiedLatestMapState(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"foo", "bar", "baz"} {
+		v, err := vmap.VerifiedGet(ctx, []byte(k), ms)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if k != "baz" && string(v.LeafInput) != k+"value" {
+			t.Fatal("wrong value")
+		}
+	}
+	err = vmap.VerifyMap(ctx, nil, ms, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherMap := acc.VerifiableMap("foo")
+	otherMap.Map.HashAlgorithm = other.Log.HashAlgorithm
+	_, err = otherMap.VerifiedGet(ctx, []byte("foo"), ms)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+}
+
+func TestHashAlgorithms(t *testing.T) {
+	for _, alg := range []pb.HashAlgorithm{
+		pb.HashAlgorithm_HASH_SHA256,
+		pb.HashAlgorithm_HASH_SHA512_256,
+		pb.HashAlgorithm_HASH_BLAKE2B_256,
+		pb.HashAlgorithm_HASH_BLAKE3,
+	} {
+		testHashAlgorithm(t, createCleanEmptyService(), alg)
+	}
+
+	// Unknown algorithms are rejected
+	vlog := (&verifiable.Client{Service: createCleanEmptyService()}).Account("0", "").VerifiableLog("foo")
+	vlog.Log.HashAlgorithm = pb.HashAlgorithm(99)
+	_, err := vlog.Add(context.TODO(), &pb.LeafData{LeafInput: []byte("foo")})
+	expectErrCode(t, codes.InvalidArgument, err)
+}
+
+func TestHashAlgorithmREST(t *testing.T) {
+	go httprest.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		RestListenBind:           ":8096",
+	}, createCleanEmptyService())
+	time.Sleep(50 * time.Millisecond)
+
+	testHashAlgorithm(t, (&httprest.Client{
+		BaseURL: "http://localhost:8096",
+	}).MustDial(), pb.HashAlgorithm_HASH_BLAKE3)
+}
@@ -0,0 +1,134 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/server/grpc"
+	"github.com/continusec/verifiabledatastructures/server/httprest"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	"google.golang.org/protobuf/proto"
+)
+
+func expectHistory(t *testing.T, history []*pb.MapKeyHistoryEntry, indices []int64, values []string) {
+	if len(history) != len(indices) {
+		t.Fatalf("wrong number of history entries: %d", len(history))
+	}
+	for i, entry := range history {
+		if entry.MutationIndex != indices[i] || string(entry.Value.LeafInput) != values[i] {
+			t.Fatalf("wrong history entry %d: %d %s", i, entry.MutationIndex, entry.Value.LeafInput)
+		}
+	}
+}
+
+func testMapKeyHistory(t *testing.T, service pb.VerifiableDataStructuresServiceServer) {
+	ctx := context.TODO()
+	vmap := (&verifiable.Client{Service: service}).Account("0", "").VerifiableMap("foo")
+
+	applyAndWait(t, vmap, setMut("a", "1"))                   // 0
+	applyAndWait(t, vmap, setMut("b", "x"))                   // 1
+	applyAndWait(t, vmap, updateMut("a", "1", "2"))           // 2
+	applyAndWait(t, vmap, updateMut("a", "1", "9"))           // 3, does not apply
+	applyAndWait(t, vmap, deleteMut("a"))                     // 4
+	applyAndWait(t, vmap, setMut("a", "3"), setMut("c", "y")) // 5
+	applyAndWait(t, vmap, setMut("a", "4"), setMut("a", "5")) // 6
+	head := applyAndWait(t, vmap, setMut("a", "5"))           // 7, no change
+	ms := &verifiable.MapTreeState{MapTreeHead: head}
+
+	history, err := vmap.VerifiedKeyHistory(ctx, []byte("a"), ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectHistory(t, history, []int64{0, 2, 4, 5, 6}, []string{"1", "2", "", "3", "5"})
+
+	history, err = vmap.VerifiedKeyHistory(ctx, []byte("c"), ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectHistory(t, history, []int64{5}, []string{"y"})
+
+	history, err = vmap.VerifiedKeyHistory(ctx, []byte("missing"), ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectHistory(t, history, nil, nil)
+
+	// Earlier map sizes only include earlier changes
+	old, err := vmap.VerifiedMapState(ctx, nil, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	history, err = vmap.VerifiedKeyHistory(ctx, []byte("a"), old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectHistory(t, history, []int64{0, 2}, []string{"1", "2"})
+
+	// Tampered histories, or histories for other keys, must fail
+	resp, err := vmap.KeyHistory(ctx, []byte("a"), ms.TreeSize())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tamper := range []func(p *pb.MapGetKeyHistoryResponse){
+		func(p *pb.MapGetKeyHistoryResponse) { p.Entries[0].Value = &pb.LeafData{LeafInput: []byte("9")} },
+		func(p *pb.MapGetKeyHistoryResponse) { p.Entries[0], p.Entries[1] = p.Entries[1], p.Entries[0] },
+		func(p *pb.MapGetKeyHistoryResponse) { p.Entries[1].MutationIndex = 3 },
+		func(p *pb.MapGetKeyHistoryResponse) { p.Entries[1].Mutation = p.Entries[0].Mutation },
+		func(p *pb.MapGetKeyHistoryResponse) { p.Entries[2].InclusionProof = p.Entries[3].InclusionProof },
+		func(p *pb.MapGetKeyHistoryResponse) {
+			p.Entries[2].Mutation.LeafInput = p.Entries[3].Mutation.LeafInput
+		},
+		func(p *pb.MapGetKeyHistoryResponse) { p.TreeSize = 7 },
+	} {
+		bad := proto.Clone(resp).(*pb.MapGetKeyHistoryResponse)
+		tamper(bad)
+		err = verifiable.VerifyMapKeyHistory(bad, []byte("a"), ms.MapTreeHead)
+		expectErr(t, verifiable.ErrVerificationFailed, err)
+	}
+	err = verifiable.VerifyMapKeyHistory(resp, []byte("b"), ms.MapTreeHead)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+}
+
+func TestMapKeyHistory(t *testing.T) {
+	testMapKeyHistory(t, createCleanEmptyService())
+	testMapKeyHistory(t, createCleanEmptyBatchMutatorService())
+
+	go grpc.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		GrpcListenBind:           ":8090",
+		GrpcListenProtocol:       "tcp4",
+	}, createCleanEmptyService())
+	go httprest.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		RestListenBind:           ":8105",
+	}, createCleanEmptyService())
+	time.Sleep(50 * time.Millisecond)
+
+	testMapKeyHistory(t, (&grpc.Client{
+		Address:        "localhost:8090",
+		NoGrpcSecurity: true,
+	}).MustDial())
+	testMapKeyHistory(t, (&httprest.Client{
+		BaseURL: "http://localhost:8105",
+	}).MustDial())
+}
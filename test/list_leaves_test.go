@@ -0,0 +1,231 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/merkle"
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/server/grpc"
+	"github.com/continusec/verifiabledatastructures/server/httprest"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+)
+
+// listAllLeaves lists the leaves in ms a page at a time, verifying each, and returns the value for each key hash
+func listAllLeaves(t *testing.T, vmap *verifiable.Map, ms *verifiable.MapTreeState, limit int32) map[string]string {
+	rv := make(map[string]string)
+	var start []byte
+	for {
+		page, err := vmap.ListLeaves(context.TODO(), ms.TreeSize(), start, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if limit != 0 && len(page.Leaves) > int(limit) {
+			t.Fatal("too many leaves")
+		}
+		err = verifiable.VerifyMapListLeaves(page, start, ms.MapTreeHead)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, leaf := range page.Leaves {
+			rv[string(leaf.KeyHash)] = string(leaf.Value.LeafInput)
+		}
+		if len(page.NextKeyHash) == 0 {
+			return rv
+		}
+		start = page.NextKeyHash
+	}
+}
+
+func expectLeaves(t *testing.T, got map[string]string, keys map[string]string) {
+	if len(got) != len(keys) {
+		t.Fatalf("wrong number of leaves: %d", len(got))
+	}
+	for k, v := range keys {
+		if got[string(merkle.SHA256.KeyHash([]byte(k)))] != v {
+			t.Fatalf("wrong value for %s", k)
+		}
+	}
+}
+
+func testMapListLeaves(t *testing.T, service pb.VerifiableDataStructuresServiceServer) {
+	ctx := context.TODO()
+	acc := (&verifiable.Client{Service: service}).Account("0", "")
+
+	// An empty map has no leaves
+	vmap := acc.VerifiableMap("foo")
+	page, err := vmap.ListLeaves(ctx, 0, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Leaves) != 0 || len(page.Boundary) != 0 || len(page.NextKeyHash) != 0 {
+		t.Fatal("expected empty page")
+	}
+	head, err := vmap.TreeHead(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = verifiable.VerifyMapListLeaves(page, nil, head)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A single leaf is stored at the root
+	applyAndWait(t, vmap, setMut("only", "1"))
+	ms, err := vmap.VerifiedLatestMapState(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectLeaves(t, listAllLeaves(t, vmap, ms, 0), map[string]string{"only": "1"})
+	kh := merkle.SHA256.KeyHash([]byte("only"))
+	for _, start := range [][]byte{kh, {kh[0] + 1}} {
+		if len(start) == 1 {
+			start = append(start, make([]byte, 31)...)
+		}
+		page, err = vmap.ListLeaves(ctx, 0, start, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = verifiable.VerifyMapListLeaves(page, start, ms.MapTreeHead)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Many leaves, some deleted
+	var muts []*pb.MapMutation
+	keys := make(map[string]string)
+	for i := 0; i < 300; i++ {
+		k, v := fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i)
+		muts = append(muts, setMut(k, v))
+		keys[k] = v
+	}
+	vmap = acc.VerifiableMap("bar")
+	applyAndWait(t, vmap, muts...)
+	oldMs, err := vmap.VerifiedLatestMapState(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldKeys := make(map[string]string)
+	for k, v := range keys {
+		oldKeys[k] = v
+	}
+	muts = nil
+	for i := 0; i < 10; i++ {
+		muts = append(muts, deleteMut(fmt.Sprintf("key%d", i)))
+		delete(keys, fmt.Sprintf("key%d", i))
+	}
+	muts = append(muts, setMut("key10", "changed"))
+	keys["key10"] = "changed"
+	applyAndWait(t, vmap, muts...)
+	ms, err = vmap.VerifiedLatestMapState(ctx, oldMs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, limit := range []int32{1, 7, 1000} {
+		expectLeaves(t, listAllLeaves(t, vmap, ms, limit), keys)
+	}
+	expectLeaves(t, listAllLeaves(t, vmap, oldMs, 50), oldKeys)
+
+	cnt := 0
+	err = vmap.VerifiedLeaves(ctx, ms, func(ctx context.Context, leaf *pb.MapLeaf) error {
+		cnt++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cnt != 290 {
+		t.Fatal("wrong number of leaves")
+	}
+
+	// Tampered pages must fail
+	start := merkle.SHA256.KeyHash([]byte("key50"))
+	for _, tamper := range []func(p *pb.MapListLeavesResponse){
+		func(p *pb.MapListLeavesResponse) { p.Leaves = p.Leaves[1:] },
+		func(p *pb.MapListLeavesResponse) { p.Leaves = append(p.Leaves[:3], p.Leaves[4:]...) },
+		func(p *pb.MapListLeavesResponse) { p.Leaves[2], p.Leaves[3] = p.Leaves[3], p.Leaves[2] },
+		func(p *pb.MapListLeavesResponse) { p.Leaves[2].Value.LeafInput = []byte("other") },
+		func(p *pb.MapListLeavesResponse) { p.Leaves[2].LeafHash = merkle.LeafHash([]byte("other")) },
+		func(p *pb.MapListLeavesResponse) { p.Boundary = p.Boundary[1:] },
+		func(p *pb.MapListLeavesResponse) { p.Boundary = append(p.Boundary, p.Boundary[0]) },
+		func(p *pb.MapListLeavesResponse) { p.Boundary[0].Hash = p.Boundary[1].Hash },
+		func(p *pb.MapListLeavesResponse) { p.NextKeyHash = nil },
+		func(p *pb.MapListLeavesResponse) {
+			// Claim the page ends before the last leaf
+			p.NextKeyHash = p.Leaves[len(p.Leaves)-1].KeyHash
+		},
+		func(p *pb.MapListLeavesResponse) {
+			// Skip the first leaf, and cover it with a boundary hash
+			first := p.Leaves[0]
+			p.Leaves = p.Leaves[1:]
+			p.Boundary = append(p.Boundary, &pb.MapSubTreeHash{Depth: 256, KeyHash: first.KeyHash, Hash: first.LeafHash})
+		},
+		func(p *pb.MapListLeavesResponse) { p.TreeSize = 1 },
+	} {
+		page, err := vmap.ListLeaves(ctx, ms.TreeSize(), start, 7)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = verifiable.VerifyMapListLeaves(page, start, ms.MapTreeHead)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tamper(page)
+		err = verifiable.VerifyMapListLeaves(page, start, ms.MapTreeHead)
+		expectErr(t, verifiable.ErrVerificationFailed, err)
+	}
+
+	// A page for a different start must fail
+	page, err = vmap.ListLeaves(ctx, ms.TreeSize(), start, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = verifiable.VerifyMapListLeaves(page, nil, ms.MapTreeHead)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+}
+
+func TestMapListLeaves(t *testing.T) {
+	testMapListLeaves(t, createCleanEmptyService())
+	testMapListLeaves(t, createCleanEmptyBatchMutatorService())
+
+	go grpc.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		GrpcListenBind:           ":8087",
+		GrpcListenProtocol:       "tcp4",
+	}, createCleanEmptyService())
+	go httprest.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		RestListenBind:           ":8102",
+	}, createCleanEmptyService())
+	time.Sleep(50 * time.Millisecond)
+
+	testMapListLeaves(t, (&grpc.Client{
+		Address:        "localhost:8087",
+		NoGrpcSecurity: true,
+	}).MustDial())
+	testMapListLeaves(t, (&httprest.Client{
+		BaseURL: "http://localhost:8102",
+	}).MustDial())
+}
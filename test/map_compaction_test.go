@@ -0,0 +1,177 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/mutator/instant"
+	"github.com/continusec/verifiabledatastructures/oracle/policy"
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/server/httprest"
+	"github.com/continusec/verifiabledatastructures/storage/memory"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+)
+
+// nodeCountingStorage tracks the map nodes that are stored, across all namespaces
+type nodeCountingStorage struct {
+	memory.TransientStorage
+	nodes map[string]bool
+}
+
+func (s *nodeCountingStorage) ExecuteUpdate(ctx context.Context, namespace []byte, f func(ctx context.Context, db verifiable.KeyWriter) error) error {
+	return s.TransientStorage.ExecuteUpdate(ctx, namespace, func(ctx context.Context, db verifiable.KeyWriter) error {
+		return f(ctx, &nodeCountingWriter{KeyWriter: db, nodes: s.nodes})
+	})
+}
+
+type nodeCountingWriter struct {
+	verifiable.KeyWriter
+	nodes map[string]bool
+}
+
+func (w *nodeCountingWriter) Set(ctx context.Context, key []byte, value proto.Message) error {
+	if bytes.HasPrefix(key, []byte("map_node/")) {
+		if value == nil {
+			delete(w.nodes, string(key))
+		} else {
+			w.nodes[string(key)] = true
+		}
+	}
+	return w.KeyWriter.Set(ctx, key, value)
+}
+
+func createCompactingService(retention *pb.MapRetentionPolicy) (pb.VerifiableDataStructuresServiceServer, *nodeCountingStorage) {
+	db := &nodeCountingStorage{nodes: make(map[string]bool)}
+	return (&verifiable.Service{
+		AccessPolicy: policy.Open,
+		Mutator:      &instant.Mutator{Writer: db},
+		Reader:       db,
+		MapRetention: retention,
+	}).MustCreate(), db
+}
+
+// testMapCompaction applies a series of mutations, then checks that values verify at each retained
+// size, and that the others are pruned
+func testMapCompaction(t *testing.T, service pb.VerifiableDataStructuresServiceServer, retained func(size, latest int64) bool) {
+	ctx := context.TODO()
+	vmap := (&verifiable.Client{Service: service}).Account("0", "").VerifiableMap("foo")
+
+	var keys []string
+	for i := 0; i < 8; i++ {
+		keys = append(keys, fmt.Sprintf("key%d", i))
+	}
+	state := make(map[string]string)
+	states := []map[string]string{{}}
+	heads := []*pb.MapTreeHashResponse{nil}
+	for i := 0; i < 30; i++ {
+		var muts []*pb.MapMutation
+		switch {
+		case i%7 == 3:
+			muts = append(muts, deleteMut(keys[i%len(keys)]))
+			delete(state, keys[i%len(keys)])
+		case i%7 == 5:
+			muts = append(muts, setMut(keys[i%len(keys)], "a"), setMut(keys[(i+3)%len(keys)], "b"))
+			state[keys[i%len(keys)]], state[keys[(i+3)%len(keys)]] = "a", "b"
+		case i%7 == 6 && len(state) > 0: // no change
+			for k, v := range state {
+				muts = append(muts, setMut(k, v))
+				break
+			}
+		default:
+			v := fmt.Sprintf("value%d", i)
+			muts = append(muts, setMut(keys[i%len(keys)], v))
+			state[keys[i%len(keys)]] = v
+		}
+		heads = append(heads, applyAndWait(t, vmap, muts...))
+		s := make(map[string]string)
+		for k, v := range state {
+			s[k] = v
+		}
+		states = append(states, s)
+	}
+
+	latest := int64(len(heads) - 1)
+	for size := int64(1); size <= latest; size++ {
+		head, err := vmap.TreeHead(ctx, size)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(head.RootHash, heads[size].RootHash) {
+			t.Fatalf("wrong root hash for size %d", size)
+		}
+
+		if !retained(size, latest) {
+			_, err = vmap.Get(ctx, []byte(keys[0]), size)
+			expectErrCode(t, codes.NotFound, err)
+			continue
+		}
+		for _, k := range keys {
+			v, err := vmap.VerifiedGet(ctx, []byte(k), &verifiable.MapTreeState{MapTreeHead: heads[size]})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(v.LeafInput) != states[size][k] {
+				t.Fatalf("wrong value for %s at size %d", k, size)
+			}
+		}
+	}
+}
+
+func TestMapCompaction(t *testing.T) {
+	everything := func(size, latest int64) bool { return true }
+
+	// Without a policy, nothing is pruned
+	service, full := createCompactingService(nil)
+	testMapCompaction(t, service, everything)
+
+	retention := &pb.MapRetentionPolicy{Every: 4, KeepLast: 3}
+	retained := func(size, latest int64) bool { return size%4 == 0 || size > latest-3 }
+	service, compacted := createCompactingService(retention)
+	testMapCompaction(t, service, retained)
+	if len(compacted.nodes) >= len(full.nodes) {
+		t.Fatalf("expected fewer nodes after compaction: %d vs %d", len(compacted.nodes), len(full.nodes))
+	}
+
+	// Trie nodes are never compacted, so patricia trie maps can't be used with a policy
+	vmap := (&verifiable.Client{Service: service}).Account("0", "").VerifiableMap("patricia")
+	vmap.Map.PatriciaTrie = true
+	_, err := vmap.Set(context.TODO(), []byte("a"), &pb.LeafData{LeafInput: []byte("1")})
+	expectErrCode(t, codes.FailedPrecondition, err)
+
+	// Keeping only the latest still allows the map to be updated
+	service, _ = createCompactingService(&pb.MapRetentionPolicy{})
+	testMapCompaction(t, service, func(size, latest int64) bool { return size == latest })
+
+	service, _ = createCompactingService(retention)
+	go httprest.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		RestListenBind:           ":8113",
+	}, service)
+	time.Sleep(50 * time.Millisecond)
+	testMapCompaction(t, (&httprest.Client{
+		BaseURL: "http://localhost:8113",
+	}).MustDial(), retained)
+}
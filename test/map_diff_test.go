@@ -0,0 +1,156 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/merkle"
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/server/grpc"
+	"github.com/continusec/verifiabledatastructures/server/httprest"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	"google.golang.org/protobuf/proto"
+)
+
+// expectDiff checks that the verified diff is exactly the given changes, as before/after values by key
+func expectDiff(t *testing.T, vmap *verifiable.Map, from, to *verifiable.MapTreeState, changes map[string][2]string) {
+	got := make(map[string][2]string)
+	err := vmap.VerifiedDiff(context.TODO(), from, to, func(ctx context.Context, entry *pb.MapDiffEntry) error {
+		got[string(entry.KeyHash)] = [2]string{string(entry.Before.LeafInput), string(entry.After.LeafInput)}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(changes) {
+		t.Fatalf("wrong number of changes: %d", len(got))
+	}
+	for k, v := range changes {
+		if got[string(merkle.SHA256.KeyHash([]byte(k)))] != v {
+			t.Fatalf("wrong change for %s", k)
+		}
+	}
+}
+
+func testMapDiff(t *testing.T, service pb.VerifiableDataStructuresServiceServer) {
+	ctx := context.TODO()
+	vmap := (&verifiable.Client{Service: service}).Account("0", "").VerifiableMap("foo")
+
+	var muts []*pb.MapMutation
+	for i := 0; i < 10; i++ {
+		muts = append(muts, setMut(fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i)))
+	}
+	from := &verifiable.MapTreeState{MapTreeHead: applyAndWait(t, vmap, muts...)}
+
+	applyAndWait(t, vmap, setMut("key1", "changed"))
+	applyAndWait(t, vmap, deleteMut("key2"))
+	applyAndWait(t, vmap, setMut("key3", "value3"))                      // no change
+	applyAndWait(t, vmap, setMut("key4", "x"), setMut("key4", "value4")) // changed back
+	head := applyAndWait(t, vmap, setMut("new", "value"))
+	to := &verifiable.MapTreeState{MapTreeHead: head}
+
+	expectDiff(t, vmap, from, to, map[string][2]string{
+		"key1": {"value1", "changed"},
+		"key2": {"value2", ""},
+		"new":  {"", "value"},
+	})
+	expectDiff(t, vmap, to, to, nil)
+
+	// From the empty map, every key is new
+	all := map[string][2]string{"new": {"", "value"}, "key1": {"", "changed"}}
+	for i := 0; i < 10; i++ {
+		if i != 1 && i != 2 {
+			all[fmt.Sprintf("key%d", i)] = [2]string{"", fmt.Sprintf("value%d", i)}
+		}
+	}
+	expectDiff(t, vmap, nil, to, all)
+
+	// A page at a time, each of which verifies
+	var start []byte
+	pages := 0
+	for {
+		page, err := vmap.Diff(ctx, 0, to.TreeSize(), start, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = verifiable.VerifyMapDiff(page, start, nil, to.MapTreeHead)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Entries) > 2 {
+			t.Fatalf("too many entries in page: %d", len(page.Entries))
+		}
+		pages++
+		if len(page.NextKeyHash) == 0 {
+			break
+		}
+		start = page.NextKeyHash
+	}
+	if pages != 5 {
+		t.Fatalf("wrong number of pages: %d", pages)
+	}
+
+	// Tampering with the diff fails verification
+	page, err := vmap.Diff(ctx, from.TreeSize(), to.TreeSize(), nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = verifiable.VerifyMapDiff(page, nil, from.MapTreeHead, to.MapTreeHead)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tamper := range []func(p *pb.MapDiffResponse){
+		func(p *pb.MapDiffResponse) { p.Entries = p.Entries[1:] },
+		func(p *pb.MapDiffResponse) { p.Entries[0].After = &pb.LeafData{LeafInput: []byte("other")} },
+		func(p *pb.MapDiffResponse) { p.Entries[0].Before = p.Entries[0].After },
+		func(p *pb.MapDiffResponse) { p.NextKeyHash = p.Entries[len(p.Entries)-1].KeyHash },
+	} {
+		bad := proto.Clone(page).(*pb.MapDiffResponse)
+		tamper(bad)
+		expectErr(t, verifiable.ErrVerificationFailed, verifiable.VerifyMapDiff(bad, nil, from.MapTreeHead, to.MapTreeHead))
+	}
+}
+
+func TestMapDiff(t *testing.T) {
+	testMapDiff(t, createCleanEmptyService())
+	testMapDiff(t, createCleanEmptyBatchMutatorService())
+
+	go grpc.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		GrpcListenBind:           ":8111",
+		GrpcListenProtocol:       "tcp4",
+	}, createCleanEmptyService())
+	go httprest.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		RestListenBind:           ":8112",
+	}, createCleanEmptyService())
+	time.Sleep(50 * time.Millisecond)
+
+	testMapDiff(t, (&grpc.Client{
+		Address:        "localhost:8111",
+		NoGrpcSecurity: true,
+	}).MustDial())
+	testMapDiff(t, (&httprest.Client{
+		BaseURL: "http://localhost:8112",
+	}).MustDial())
+}
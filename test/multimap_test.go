@@ -0,0 +1,170 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/server/grpc"
+	"github.com/continusec/verifiabledatastructures/server/httprest"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// addValueAndWait adds a value for key to the multimap, and waits until it is present
+func addValueAndWait(t *testing.T, mm *verifiable.Multimap, key, value string) *pb.MapTreeHashResponse {
+	ctx := context.TODO()
+	p, err := mm.Add(ctx, []byte(key), &pb.LeafData{LeafInput: []byte(value)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := p.Wait(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return head
+}
+
+// expectMultimapValues verifies the values for key in the multimap tree head
+func expectMultimapValues(t *testing.T, mm *verifiable.Multimap, head *pb.MapTreeHashResponse, key string, expected ...string) {
+	values, err := mm.VerifiedValues(context.TODO(), []byte(key), head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != len(expected) {
+		t.Fatalf("expected %d values for %s, got %d", len(expected), key, len(values))
+	}
+	for i, v := range values {
+		if string(v.LeafInput) != expected[i] {
+			t.Fatalf("expected %s for value %d of %s, got %s", expected[i], i, key, v.LeafInput)
+		}
+	}
+}
+
+func testMultimap(t *testing.T, service pb.VerifiableDataStructuresServiceServer) {
+	ctx := context.TODO()
+	mm := (&verifiable.Client{Service: service}).Account("0", "").VerifiableMultimap("foo")
+	h, err := mm.Hasher()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := addValueAndWait(t, mm, "a", "1")
+	expectMultimapValues(t, mm, first, "a", "1")
+	expectMultimapValues(t, mm, first, "b")
+
+	addValueAndWait(t, mm, "b", "1")
+	addValueAndWait(t, mm, "a", "2")
+	head := addValueAndWait(t, mm, "a", "3")
+	expectMultimapValues(t, mm, head, "a", "1", "2", "3")
+	expectMultimapValues(t, mm, head, "b", "1")
+	expectMultimapValues(t, mm, first, "a", "1")
+
+	// Each value has its own proof
+	for _, v := range []string{"1", "2", "3"} {
+		err = mm.VerifyInclusion(ctx, []byte("a"), head, h.LeafHash([]byte(v)))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = mm.VerifyInclusion(ctx, []byte("a"), first, h.LeafHash([]byte("2")))
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected value to not be found: %v", err)
+	}
+	err = mm.VerifyInclusion(ctx, []byte("c"), head, h.LeafHash([]byte("1")))
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected key to not be found: %v", err)
+	}
+
+	// The values for a key are only ever appended to
+	for _, from := range []*pb.MapTreeHashResponse{nil, first, head} {
+		err = mm.VerifyKeyConsistency(ctx, []byte("a"), from, head)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = mm.VerifyKeyConsistency(ctx, []byte("b"), first, head)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A value can be added for a key more than once. Mutations are applied in order, so waiting for the
+	// next value means the repeated one has been added too, as waiting for it would find the first.
+	addValueAndWait(t, mm, "b", "2")
+	_, err = mm.Add(ctx, []byte("b"), &pb.LeafData{LeafInput: []byte("1")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := addValueAndWait(t, mm, "a", "4")
+	if next.MutationLog.TreeSize != head.MutationLog.TreeSize+3 {
+		t.Fatal("expected repeated value to be added")
+	}
+	expectMultimapValues(t, mm, next, "a", "1", "2", "3", "4")
+	expectMultimapValues(t, mm, next, "b", "1", "2", "1")
+	err = mm.VerifyInclusion(ctx, []byte("b"), next, h.LeafHash([]byte("1")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = mm.VerifyKeyConsistency(ctx, []byte("b"), head, next)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Values are checked against the multimap tree head
+	proof, err := mm.Values(ctx, []byte("a"), head.MutationLog.TreeSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof.Values = proof.Values[:2]
+	expectErr(t, verifiable.ErrVerificationFailed, verifiable.VerifyMultimapGetValues(proof, []byte("a"), head))
+	proof, err = mm.Values(ctx, []byte("a"), head.MutationLog.TreeSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof.Values[1] = &pb.LeafData{LeafInput: []byte("4")}
+	expectErr(t, verifiable.ErrVerificationFailed, verifiable.VerifyMultimapGetValues(proof, []byte("a"), head))
+}
+
+func TestMultimap(t *testing.T) {
+	testMultimap(t, createCleanEmptyService())
+	testMultimap(t, createCleanEmptyBatchMutatorService())
+
+	go grpc.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		GrpcListenBind:           ":8120",
+		GrpcListenProtocol:       "tcp4",
+	}, createCleanEmptyService())
+	go httprest.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		RestListenBind:           ":8121",
+	}, createCleanEmptyService())
+	time.Sleep(50 * time.Millisecond)
+
+	testMultimap(t, (&grpc.Client{
+		Address:        "localhost:8120",
+		NoGrpcSecurity: true,
+	}).MustDial())
+	testMultimap(t, (&httprest.Client{
+		BaseURL: "http://localhost:8121",
+	}).MustDial())
+}
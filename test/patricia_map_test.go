@@ -0,0 +1,273 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/mpt"
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/server/grpc"
+	"github.com/continusec/verifiabledatastructures/server/httprest"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestPatriciaTrie(t *testing.T) {
+	if !bytes.Equal(mpt.EmptyRoot, mustDecodeHex(t, "56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")) {
+		t.Fatal("wrong empty root")
+	}
+
+	// Test vectors from go-ethereum
+	trie := mpt.New(nil, nil)
+	for _, kv := range [][2]string{{"doe", "reindeer"}, {"dog", "puppy"}, {"dogglesworth", "cat"}} {
+		err := trie.Update([]byte(kv[0]), []byte(kv[1]))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !bytes.Equal(trie.Hash(), mustDecodeHex(t, "8aad789dff2f538bca5d8ea56e8abe10f4c7ba3a5dea95fea4cd6e7c3a1168d3")) {
+		t.Fatal("wrong root hash after insert")
+	}
+
+	trie = mpt.New(nil, nil)
+	for _, kv := range [][2]string{
+		{"do", "verb"},
+		{"ether", "wookiedoo"},
+		{"horse", "stallion"},
+		{"shaman", "horse"},
+		{"doge", "coin"},
+		{"ether", ""},
+		{"dog", "puppy"},
+		{"shaman", ""},
+	} {
+		err := trie.Update([]byte(kv[0]), []byte(kv[1]))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !bytes.Equal(trie.Hash(), mustDecodeHex(t, "5991bb8c6514148a29db676a14ac506cd2cd5775ace63c30a4fe457715e9ac84")) {
+		t.Fatal("wrong root hash after delete")
+	}
+
+	// Once committed, nodes are loaded as needed to get, prove and update
+	nodes := make(mpt.MemoryNodes)
+	root, err := trie.Commit(nodes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trie = mpt.New(root, nodes)
+	for _, kv := range [][2]string{{"do", "verb"}, {"dog", "puppy"}, {"doge", "coin"}, {"horse", "stallion"}, {"ether", ""}, {"shaman", ""}, {"d", ""}, {"", ""}} {
+		v, err := trie.Get([]byte(kv[0]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(v) != kv[1] {
+			t.Fatalf("wrong value for %s: %s", kv[0], v)
+		}
+		proof, err := trie.Prove([]byte(kv[0]))
+		if err != nil {
+			t.Fatal(err)
+		}
+		v, err = mpt.VerifyProof(root, []byte(kv[0]), proof)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(v) != kv[1] {
+			t.Fatalf("wrong proven value for %s: %s", kv[0], v)
+		}
+
+		// Every node in the proof is needed
+		for i := range proof {
+			bad := append(append([][]byte(nil), proof[:i]...), proof[i+1:]...)
+			_, err = mpt.VerifyProof(root, []byte(kv[0]), bad)
+			expectErr(t, mpt.ErrInvalidProof, err)
+		}
+	}
+	for _, k := range []string{"do", "dog", "doge", "horse"} {
+		err = trie.Update([]byte(k), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !bytes.Equal(trie.Hash(), mpt.EmptyRoot) {
+		t.Fatal("expected empty root once all deleted")
+	}
+
+	// Only canonical RLP is accepted
+	for _, s := range []string{"8100", "b800", "8200", ""} {
+		_, err = mpt.DecodeBytes(mustDecodeHex(t, s))
+		expectErr(t, mpt.ErrInvalidRLP, err)
+	}
+	v, err := mpt.DecodeBytes(mpt.EncodeBytes(bytes.Repeat([]byte("a"), 100)))
+	if err != nil || !bytes.Equal(v, bytes.Repeat([]byte("a"), 100)) {
+		t.Fatal("long string did not decode")
+	}
+}
+
+func testPatriciaMap(t *testing.T, service pb.VerifiableDataStructuresServiceServer) {
+	ctx := context.TODO()
+	acc := (&verifiable.Client{Service: service}).Account("0", "")
+	vmap := acc.VerifiableMap("foo")
+	vmap.Map.PatriciaTrie = true
+
+	// The empty map has the root hash of the empty trie
+	head, err := vmap.TreeHead(ctx, verifiable.Head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !head.PatriciaTrie || !bytes.Equal(head.RootHash, mpt.EmptyRoot) {
+		t.Fatal("expected empty trie")
+	}
+
+	applyAndWait(t, vmap, setMut("a", "1"), setMut("b", "2"), setMut("c", "3"))
+	first, err := vmap.VerifiedLatestMapState(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applyAndWait(t, vmap, deleteMut("b"))
+	applyAndWait(t, vmap, updateMut("a", "1", "4"))
+	applyAndWait(t, vmap, updateMut("c", "1", "5")) // not applied
+	applyAndWait(t, vmap, &pb.MapMutation{Action: "set", Key: []byte("d"), Value: jsonLeafData(t, `{"name":"dave"}`)})
+	ms, err := vmap.VerifiedLatestMapState(ctx, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ms.MapTreeHead.PatriciaTrie {
+		t.Fatal("expected patricia trie tree head")
+	}
+	expectMapValues(t, vmap, first, map[string]string{"a": "1", "b": "2", "c": "3", "d": ""})
+	expectMapValues(t, vmap, ms, map[string]string{"a": "4", "b": "", "c": "3", "e": ""})
+	expectJSONValue(t, vmap, ms, "d", `{"name":"dave"}`)
+	values, err := vmap.VerifiedGetMany(ctx, keysOf("a", "b", "c"), ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(values[0].LeafInput) != "4" || len(values[1].LeafInput) != 0 || string(values[2].LeafInput) != "3" {
+		t.Fatal("wrong values")
+	}
+	value, _, err := vmap.VerifiedGetBundle(ctx, []byte("c"), first, verifiable.Head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(value.LeafInput) != "3" {
+		t.Fatal("wrong bundle value")
+	}
+	err = vmap.VerifyMap(ctx, nil, ms, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The root hash is that of an Ethereum storage trie of the values, and the proofs are the same
+	dEntry, err := vmap.VerifiedGet(ctx, []byte("d"), ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trie := mpt.New(nil, nil)
+	for k, v := range map[string][]byte{"a": []byte("4"), "c": []byte("3"), "d": dEntry.LeafInput} {
+		err = trie.Update(mpt.Keccak256([]byte(k)), mpt.EncodeBytes(v))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !bytes.Equal(trie.Hash(), ms.MapTreeHead.RootHash) {
+		t.Fatal("wrong root hash")
+	}
+	resp, err := vmap.Get(ctx, []byte("c"), ms.TreeSize())
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := mpt.VerifyProof(ms.MapTreeHead.RootHash, mpt.Keccak256([]byte("c")), resp.PatriciaProof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(v, mpt.EncodeBytes([]byte("3"))) {
+		t.Fatal("wrong proven value")
+	}
+
+	// The value must match the proof, which must be for a patricia trie tree head
+	bad := proto.Clone(resp).(*pb.MapGetValueResponse)
+	bad.Value.LeafInput = []byte("4")
+	expectErr(t, verifiable.ErrVerificationFailed, verifiable.VerifyMapInclusionProof(bad, []byte("c"), ms.MapTreeHead))
+	bad = proto.Clone(resp).(*pb.MapGetValueResponse)
+	bad.Value.LeafInput = nil
+	expectErr(t, verifiable.ErrVerificationFailed, verifiable.VerifyMapInclusionProof(bad, []byte("c"), ms.MapTreeHead))
+	expectErr(t, verifiable.ErrVerificationFailed, verifiable.VerifyMapInclusionProof(resp, []byte("c"), first.MapTreeHead))
+	sparseHead := proto.Clone(ms.MapTreeHead).(*pb.MapTreeHashResponse)
+	sparseHead.PatriciaTrie = false
+	expectErr(t, verifiable.ErrVerificationFailed, verifiable.VerifyMapInclusionProof(resp, []byte("c"), sparseHead))
+
+	// Clients reject tree heads for a sparse tree, or for a patricia trie if they do not expect one
+	sparseState := &verifiable.MapTreeState{MapTreeHead: sparseHead, TreeHeadLogTreeHead: ms.TreeHeadLogTreeHead}
+	_, err = vmap.VerifiedGet(ctx, []byte("c"), sparseState)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+	_, err = vmap.VerifiedGetMany(ctx, keysOf("c"), sparseState)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+	sparse := acc.VerifiableMap("foo")
+	_, err = sparse.VerifiedLatestMapState(ctx, nil)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+	_, err = sparse.VerifiedGet(ctx, []byte("c"), ms)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+	err = sparse.VerifyMap(ctx, nil, ms, nil, nil)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+
+	// Proofs that only a sparse tree can provide are not available
+	err = vmap.VerifiedLeaves(ctx, ms, func(ctx context.Context, leaf *pb.MapLeaf) error {
+		return nil
+	})
+	expectErrCode(t, codes.Unimplemented, err)
+
+	// The flavour is fixed by the first mutation, and cannot be combined with VRF keys or a sum field
+	other := acc.VerifiableMap("foo")
+	_, err = other.Set(ctx, []byte("e"), &pb.LeafData{LeafInput: []byte("6")})
+	expectErrCode(t, codes.InvalidArgument, err)
+	other = acc.VerifiableMap("bar")
+	other.Map.PatriciaTrie = true
+	other.Map.SumField = "balance"
+	_, err = other.Set(ctx, []byte("e"), jsonLeafData(t, `{"balance":1}`))
+	expectErrCode(t, codes.InvalidArgument, err)
+}
+
+func TestPatriciaMap(t *testing.T) {
+	testPatriciaMap(t, createCleanEmptyService())
+	testPatriciaMap(t, createCleanEmptyBatchMutatorService())
+
+	go grpc.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		GrpcListenBind:           ":8122",
+		GrpcListenProtocol:       "tcp4",
+	}, createCleanEmptyService())
+	go httprest.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		RestListenBind:           ":8123",
+	}, createCleanEmptyService())
+	time.Sleep(50 * time.Millisecond)
+
+	testPatriciaMap(t, (&grpc.Client{
+		Address:        "localhost:8122",
+		NoGrpcSecurity: true,
+	}).MustDial())
+	testPatriciaMap(t, (&httprest.Client{
+		BaseURL: "http://localhost:8123",
+	}).MustDial())
+}
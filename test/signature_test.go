@@ -0,0 +1,221 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package test
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/mutator/instant"
+	"github.com/continusec/verifiabledatastructures/oracle/policy"
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/server/httprest"
+	"github.com/continusec/verifiabledatastructures/storage/memory"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	"google.golang.org/grpc/codes"
+)
+
+func createSignedService(signer crypto.Signer) pb.VerifiableDataStructuresServiceServer {
+	db := &memory.TransientStorage{}
+	return (&verifiable.Service{
+		AccessPolicy: policy.Open,
+		Mutator:      &instant.Mutator{Writer: db},
+		Reader:       db,
+		Signer:       signer,
+	}).MustCreate()
+}
+
+func testSignedTreeHeads(t *testing.T, signer crypto.Signer, other crypto.PublicKey) {
+	ctx := context.TODO()
+	service := createSignedService(signer)
+
+	acc := (&verifiable.Client{Service: service, TrustedKey: signer.Public()}).Account("0", "")
+	vlog := acc.VerifiableLog("foo")
+	p, err := vlog.Add(ctx, &pb.LeafData{LeafInput: []byte("bar")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.Wait(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lth, err := vlog.VerifiedLatestTreeHead(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Changing the head must break the signature
+	lth.TreeSize++
+	expectErr(t, verifiable.ErrVerificationFailed, verifiable.VerifyLogTreeHeadSignature(vlog.Log, lth, signer.Public()))
+
+	vmap := acc.VerifiableMap("foo")
+	mp, err := vmap.Set(ctx, []byte("foo"), &pb.LeafData{LeafInput: []byte("bar")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = mp.Wait(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ms, err := vmap.VerifiedLatestMapState(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := vmap.VerifiedGet(ctx, []byte("foo"), ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(v.LeafInput) != "bar" {
+		t.Fatal("wrong value")
+	}
+
+	// A different key must fail
+	_, err = (&verifiable.Client{Service: service, TrustedKey: other}).Account("0", "").VerifiableMap("foo").VerifiedLatestMapState(ctx, nil)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+
+	// As must an unsigned head
+	_, err = (&verifiable.Client{Service: createCleanEmptyService(), TrustedKey: signer.Public()}).Account("0", "").VerifiableLog("foo").VerifiedLatestTreeHead(ctx, nil)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+}
+
+func TestEd25519SignedTreeHeads(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testSignedTreeHeads(t, priv, other)
+}
+
+func TestECDSASignedTreeHeads(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testSignedTreeHeads(t, priv, other.Public())
+}
+
+func testCheckpoint(t *testing.T, signer crypto.Signer) {
+	ctx := context.TODO()
+	service := createSignedService(signer)
+
+	// Round trip via REST
+	go httprest.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		RestListenBind:           ":8094",
+	}, service)
+	time.Sleep(50 * time.Millisecond)
+	restClient := (&httprest.Client{
+		BaseURL: "http://localhost:8094",
+	}).MustDial()
+
+	vlog := (&verifiable.Client{Service: restClient, TrustedKey: signer.Public()}).Account("0", "").VerifiableLog("foo")
+
+	// An empty log has a checkpoint of size 0 with the root hash of an empty tree
+	cp, err := vlog.Checkpoint(ctx, verifiable.Head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, head, err := verifiable.ParseCheckpoint(cp, "0/log/foo", signer.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+	empty := sha256.Sum256(nil)
+	if head.TreeSize != 0 || !bytes.Equal(head.RootHash, empty[:]) {
+		t.Fatal("bad checkpoint for empty log")
+	}
+
+	addAndWait(t, vlog, "bar")
+	addAndWait(t, vlog, "baz")
+
+	lth, err := vlog.VerifiedLatestTreeHead(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ts := range []int64{verifiable.Head, 1} {
+		cp, err := vlog.Checkpoint(ctx, ts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(cp), "0/log/foo\n") {
+			t.Fatal("bad origin")
+		}
+		origin, head, err := verifiable.ParseCheckpoint(cp, "0/log/foo", signer.Public())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if origin != "0/log/foo" {
+			t.Fatal("bad origin")
+		}
+		err = verifiable.VerifyLogTreeHeadSignature(vlog.Log, head, signer.Public())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ts == verifiable.Head {
+			if head.TreeSize != lth.TreeSize || !bytes.Equal(head.RootHash, lth.RootHash) {
+				t.Fatal("checkpoint doesn't match tree head")
+			}
+		} else {
+			_, err = vlog.VerifiedTreeHead(ctx, lth, head.TreeSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		// Wrong key name
+		_, _, err = verifiable.ParseCheckpoint(cp, "0/log/bar", signer.Public())
+		expectErr(t, verifiable.ErrVerificationFailed, err)
+
+		// Tampered text
+		_, _, err = verifiable.ParseCheckpoint(bytes.Replace(cp, []byte("0/log/foo\n"), []byte("0/log/bar\n"), 1), "0/log/foo", signer.Public())
+		expectErr(t, verifiable.ErrVerificationFailed, err)
+
+		// Malformed
+		_, _, err = verifiable.ParseCheckpoint(cp[:len(cp)-1], "0/log/foo", signer.Public())
+		expectErr(t, verifiable.ErrInvalidRequest, err)
+	}
+
+	// Servers without a signing key can't produce checkpoints
+	_, err = (&verifiable.Client{Service: createCleanEmptyService()}).Account("0", "").VerifiableLog("foo").Checkpoint(ctx, verifiable.Head)
+	expectErrCode(t, codes.FailedPrecondition, err)
+}
+
+func TestCheckpoint(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCheckpoint(t, priv)
+}
@@ -0,0 +1,111 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/server/grpc"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+)
+
+func TestStreamLogEntries(t *testing.T) {
+	ctx := context.TODO()
+	service := createCleanEmptyService()
+
+	go grpc.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		GrpcListenBind:           ":8082",
+		GrpcListenProtocol:       "tcp4",
+	}, service)
+	time.Sleep(50 * time.Millisecond)
+	client := (&grpc.Client{
+		Address:        "localhost:8082",
+		NoGrpcSecurity: true,
+	}).MustDial()
+
+	vlog := (&verifiable.Client{Service: service}).Account("0", "").VerifiableLog("foo")
+	var entries []*pb.LeafData
+	for i := 0; i < 1200; i++ {
+		entries = append(entries, &pb.LeafData{LeafInput: []byte(fmt.Sprintf("foo%d", i))})
+	}
+	_, err := vlog.AddEntries(ctx, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []pb.VerifiableDataStructuresServiceServer{service, client} {
+		vlog := (&verifiable.Client{Service: s}).Account("0", "").VerifiableLog("foo")
+		head, err := vlog.VerifiedLatestTreeHead(ctx, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if head.TreeSize != 1200 {
+			t.Fatal("wrong tree size")
+		}
+		err = vlog.VerifyEntries(ctx, nil, head, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cnt := 0
+		for e := range vlog.Entries(ctx, 1100, 1150) {
+			if string(e.LeafInput) != fmt.Sprintf("foo%d", 1100+cnt) {
+				t.Fatal("wrong entry")
+			}
+			cnt++
+		}
+		if cnt != 50 {
+			t.Fatal("wrong number of entries")
+		}
+
+		// Asking for too much returns nothing
+		for range vlog.Entries(ctx, 1100, 1300) {
+			t.Fatal("should be out of range")
+		}
+	}
+
+	// Follow new entries as they are added
+	fctx, canc := context.WithCancel(ctx)
+	defer canc()
+	ch := (&verifiable.Client{Service: client}).Account("0", "").VerifiableLog("foo").FollowEntries(fctx, 1198)
+	for i := 1198; i < 1205; i++ {
+		if i >= 1200 {
+			addAndWait(t, vlog, fmt.Sprintf("foo%d", i))
+		}
+		select {
+		case e, ok := <-ch:
+			if !ok {
+				t.Fatal("stream closed early")
+			}
+			if string(e.LeafInput) != fmt.Sprintf("foo%d", i) {
+				t.Fatal("wrong entry")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for entry")
+		}
+	}
+	canc()
+	for range ch {
+	}
+}
@@ -0,0 +1,187 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/server/grpc"
+	"github.com/continusec/verifiabledatastructures/server/httprest"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+)
+
+func expectMapTotals(t *testing.T, head *pb.MapTreeHashResponse, count, sum uint64) {
+	c, s, err := verifiable.MapTreeHeadTotals(head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c != count || s != sum {
+		t.Fatalf("wanted %d keys summing to %d, got %d and %d", count, sum, c, s)
+	}
+}
+
+func testSumTreeMap(t *testing.T, service pb.VerifiableDataStructuresServiceServer) {
+	ctx := context.TODO()
+	acc := (&verifiable.Client{Service: service}).Account("0", "")
+	vmap := acc.VerifiableMap("foo")
+	vmap.Map.SumField = "balance"
+
+	// Values without an integer balance count as keys, but add nothing to the sum
+	applyAndWait(t, vmap,
+		&pb.MapMutation{Action: "set", Key: []byte("alice"), Value: jsonLeafData(t, `{"balance":100}`)},
+		&pb.MapMutation{Action: "set", Key: []byte("bob"), Value: jsonLeafData(t, `{"balance":250,"name":"Bob"}`)},
+		&pb.MapMutation{Action: "set", Key: []byte("carol"), Value: jsonLeafData(t, `{"balance":7}`)},
+		&pb.MapMutation{Action: "set", Key: []byte("dave"), Value: jsonLeafData(t, `{"balance":-3}`)},
+		&pb.MapMutation{Action: "set", Key: []byte("erin"), Value: &pb.LeafData{LeafInput: []byte("not json")}},
+	)
+	first, err := vmap.VerifiedLatestMapState(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectMapTotals(t, first.MapTreeHead, 5, 357)
+
+	applyAndWait(t, vmap, deleteMut("bob"))
+	applyAndWait(t, vmap, &pb.MapMutation{Action: "increment", Key: []byte("alice"), Field: "balance", Delta: 5})
+	ms, err := vmap.VerifiedLatestMapState(ctx, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectMapTotals(t, ms.MapTreeHead, 4, 112)
+
+	expectJSONValue(t, vmap, ms, "alice", `{"balance":105}`)
+	expectJSONValue(t, vmap, ms, "carol", `{"balance":7}`)
+	entry, err := vmap.VerifiedGet(ctx, []byte("bob"), ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entry.LeafInput) != 0 {
+		t.Fatal("deleted key should be empty")
+	}
+	_, err = vmap.VerifiedGetMany(ctx, keysOf("carol", "bob", "alice", "erin"), ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaves := 0
+	err = vmap.VerifiedLeaves(ctx, ms, func(ctx context.Context, leaf *pb.MapLeaf) error {
+		leaves++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if leaves != 4 {
+		t.Fatalf("wrong number of leaves: %d", leaves)
+	}
+	changes := 0
+	err = vmap.VerifiedDiff(ctx, first, ms, func(ctx context.Context, entry *pb.MapDiffEntry) error {
+		changes++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes != 2 {
+		t.Fatalf("wrong number of changes: %d", changes)
+	}
+	err = vmap.VerifyMap(ctx, nil, ms, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The count and sum for each sibling are verified, as is the amount in the value
+	resp, err := vmap.Get(ctx, []byte("carol"), ms.TreeSize())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = verifiable.VerifyMapInclusionProof(resp, []byte("carol"), ms.MapTreeHead)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range resp.AuditPath {
+		if len(p) == 0 {
+			continue
+		}
+		bad := proto.Clone(resp).(*pb.MapGetValueResponse)
+		bad.AuditPath[i][len(p)-1] ^= 1
+		err = verifiable.VerifyMapInclusionProof(bad, []byte("carol"), ms.MapTreeHead)
+		expectErr(t, verifiable.ErrVerificationFailed, err)
+	}
+	bad := proto.Clone(resp).(*pb.MapGetValueResponse)
+	bad.Value.ExtraData = []byte(`{"balance":8}`)
+	err = verifiable.VerifyMapInclusionProof(bad, []byte("carol"), ms.MapTreeHead)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+	plainHead := proto.Clone(ms.MapTreeHead).(*pb.MapTreeHashResponse)
+	plainHead.SumField = ""
+	err = verifiable.VerifyMapInclusionProof(resp, []byte("carol"), plainHead)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+	_, _, err = verifiable.MapTreeHeadTotals(plainHead)
+	expectErr(t, verifiable.ErrInvalidRequest, err)
+
+	// Clients reject tree heads for a plain tree, or that sum a different field
+	plainState := &verifiable.MapTreeState{MapTreeHead: plainHead, TreeHeadLogTreeHead: ms.TreeHeadLogTreeHead}
+	_, err = vmap.VerifiedGet(ctx, []byte("carol"), plainState)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+	_, err = vmap.VerifiedGetMany(ctx, keysOf("carol"), plainState)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+	for _, field := range []string{"", "amount"} {
+		other := acc.VerifiableMap("foo")
+		other.Map.SumField = field
+		_, err = other.VerifiedLatestMapState(ctx, nil)
+		expectErr(t, verifiable.ErrVerificationFailed, err)
+		err = other.VerifyMap(ctx, nil, ms, nil, nil)
+		expectErr(t, verifiable.ErrVerificationFailed, err)
+	}
+
+	// The field is fixed by the first mutation
+	for _, field := range []string{"", "other"} {
+		other := acc.VerifiableMap("foo")
+		other.Map.SumField = field
+		_, err = other.Set(ctx, []byte("frank"), jsonLeafData(t, `{"balance":1}`))
+		expectErrCode(t, codes.InvalidArgument, err)
+	}
+}
+
+func TestSumTreeMap(t *testing.T) {
+	testSumTreeMap(t, createCleanEmptyService())
+	testSumTreeMap(t, createCleanEmptyBatchMutatorService())
+
+	go grpc.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		GrpcListenBind:           ":8116",
+		GrpcListenProtocol:       "tcp4",
+	}, createCleanEmptyService())
+	go httprest.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		RestListenBind:           ":8117",
+	}, createCleanEmptyService())
+	time.Sleep(50 * time.Millisecond)
+
+	testSumTreeMap(t, (&grpc.Client{
+		Address:        "localhost:8116",
+		NoGrpcSecurity: true,
+	}).MustDial())
+	testSumTreeMap(t, (&httprest.Client{
+		BaseURL: "http://localhost:8117",
+	}).MustDial())
+}
@@ -0,0 +1,195 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/merkle"
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/server/grpc"
+	"github.com/continusec/verifiabledatastructures/server/httprest"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func setMut(k, v string) *pb.MapMutation {
+	return &pb.MapMutation{Action: "set", Key: []byte(k), Value: &pb.LeafData{LeafInput: []byte(v)}}
+}
+
+func deleteMut(k string) *pb.MapMutation {
+	return &pb.MapMutation{Action: "delete", Key: []byte(k)}
+}
+
+func updateMut(k, prev, v string) *pb.MapMutation {
+	return &pb.MapMutation{Action: "update", Key: []byte(k), Value: &pb.LeafData{LeafInput: []byte(v)}, PreviousLeafHash: merkle.LeafHash([]byte(prev))}
+}
+
+func applyAndWait(t *testing.T, vmap *verifiable.Map, muts ...*pb.MapMutation) *pb.MapTreeHashResponse {
+	p, err := vmap.ApplyTransaction(context.TODO(), muts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rv, err := p.Wait(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rv
+}
+
+func expectMapValues(t *testing.T, vmap *verifiable.Map, ms *verifiable.MapTreeState, kvs map[string]string) {
+	for k, v := range kvs {
+		entry, err := vmap.VerifiedGet(context.TODO(), []byte(k), ms)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(entry.LeafInput) != v {
+			t.Fatalf("wrong value for %s: %s", k, entry.LeafInput)
+		}
+	}
+}
+
+func testMapTransactions(t *testing.T, service pb.VerifiableDataStructuresServiceServer) {
+	ctx := context.TODO()
+	acc := (&verifiable.Client{Service: service}).Account("0", "")
+	vmap := acc.VerifiableMap("foo")
+
+	// All apply, in order, including those that depend on earlier operations
+	applyAndWait(t, vmap, setMut("a", "1"))
+	applyAndWait(t, vmap,
+		setMut("b", "2"),
+		setMut("c", "3"),
+		updateMut("a", "1", "1b"),
+		deleteMut("d"),
+		setMut("e", "x"),
+		updateMut("e", "x", "y"),
+		setMut("f", "1"),
+		deleteMut("f"),
+	)
+	ms, err := vmap.VerifiedLatestMapState(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ms.TreeSize() != 2 {
+		t.Fatal("expected a single mutation for the transaction")
+	}
+	expectMapValues(t, vmap, ms, map[string]string{"a": "1b", "b": "2", "c": "3", "d": "", "e": "y", "f": ""})
+
+	// None apply if any update fails, even if it comes last
+	head := applyAndWait(t, vmap,
+		setMut("g", "1"),
+		deleteMut("b"),
+		updateMut("a", "1", "wrong"),
+	)
+	if string(head.RootHash) != string(ms.MapTreeHead.RootHash) {
+		t.Fatal("expected root hash to be unchanged")
+	}
+	ms, err = vmap.VerifiedLatestMapState(ctx, ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectMapValues(t, vmap, ms, map[string]string{"a": "1b", "b": "2", "g": ""})
+
+	// Many keys at once, giving the same root as setting them one at a time
+	var muts []*pb.MapMutation
+	kvs := make(map[string]string)
+	other := acc.VerifiableMap("bar")
+	applyAndWait(t, other, setMut("a", "1"))
+	applyAndWait(t, other, setMut("b", "2"), setMut("c", "3"), updateMut("a", "1", "1b"), setMut("e", "y"))
+	for i := 0; i < 20; i++ {
+		k, v := fmt.Sprintf("key%d", i), fmt.Sprintf("value%d", i)
+		muts = append(muts, setMut(k, v))
+		kvs[k] = v
+		p, err := other.Set(ctx, []byte(k), &pb.LeafData{LeafInput: []byte(v)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = p.Wait(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	head = applyAndWait(t, vmap, muts...)
+	otherHead, err := other.TreeHead(ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(head.RootHash) != string(otherHead.RootHash) {
+		t.Fatal("expected same root hash as individual mutations")
+	}
+	ms, err = vmap.VerifiedLatestMapState(ctx, ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectMapValues(t, vmap, ms, kvs)
+
+	// The auditor must agree, and sees each operation that changes the map
+	changes := make(map[int64]int)
+	err = vmap.VerifyMap(ctx, nil, ms, nil, func(ctx context.Context, idx int64, key []byte, value *pb.LeafData) error {
+		changes[idx]++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes[0] != 1 || changes[1] != 7 || changes[2] != 0 || changes[3] != 20 {
+		t.Fatalf("wrong audit changes: %v", changes)
+	}
+
+	// Bad transactions are rejected up front
+	for _, muts := range [][]*pb.MapMutation{
+		nil,
+		{setMut("a", "1"), {Action: "transaction", Operations: []*pb.MapMutation{setMut("a", "1")}}},
+		{{Action: "set", Key: []byte("a")}},
+		{{Action: "bogus", Key: []byte("a")}},
+	} {
+		_, err = vmap.ApplyTransaction(ctx, muts)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Fatalf("expected invalid argument, got %s", err)
+		}
+	}
+}
+
+func TestMapTransactions(t *testing.T) {
+	testMapTransactions(t, createCleanEmptyService())
+	testMapTransactions(t, createCleanEmptyBatchMutatorService())
+
+	go grpc.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		GrpcListenBind:           ":8086",
+		GrpcListenProtocol:       "tcp4",
+	}, createCleanEmptyService())
+	go httprest.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		RestListenBind:           ":8101",
+	}, createCleanEmptyService())
+	time.Sleep(50 * time.Millisecond)
+
+	testMapTransactions(t, (&grpc.Client{
+		Address:        "localhost:8086",
+		NoGrpcSecurity: true,
+	}).MustDial())
+	testMapTransactions(t, (&httprest.Client{
+		BaseURL: "http://localhost:8101",
+	}).MustDial())
+}
@@ -0,0 +1,216 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/mutator/instant"
+	"github.com/continusec/verifiabledatastructures/oracle/policy"
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/server/grpc"
+	"github.com/continusec/verifiabledatastructures/server/httprest"
+	"github.com/continusec/verifiabledatastructures/storage/memory"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+)
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	rv, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rv
+}
+
+func testVRFKey() ed25519.PrivateKey {
+	return ed25519.NewKeyFromSeed(bytes.Repeat([]byte{7}, ed25519.SeedSize))
+}
+
+func createVRFService(vrfKey ed25519.PrivateKey) pb.VerifiableDataStructuresServiceServer {
+	db := &memory.TransientStorage{}
+	return (&verifiable.Service{
+		AccessPolicy: policy.Open,
+		Mutator:      &instant.Mutator{Writer: db},
+		Reader:       db,
+		VRFKey:       vrfKey,
+	}).MustCreate()
+}
+
+func testVRFMap(t *testing.T, service pb.VerifiableDataStructuresServiceServer) {
+	ctx := context.TODO()
+	acc := (&verifiable.Client{Service: service}).Account("0", "")
+	vmap := acc.VerifiableMap("foo")
+	vmap.Map.VrfKeys = true
+
+	applyAndWait(t, vmap, setMut("a", "1"))
+	applyAndWait(t, vmap, setMut("b", "2"), setMut("c", "3"), deleteMut("a"))
+	applyAndWait(t, vmap, setMut("a", "4"))
+	ms, err := vmap.VerifiedLatestMapState(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ms.MapTreeHead.VrfPublicKey, testVRFKey().Public().(ed25519.PublicKey)) {
+		t.Fatal("wrong VRF public key in tree head")
+	}
+
+	expectMapValues(t, vmap, ms, map[string]string{"a": "4", "b": "2", "c": "3", "missing": ""})
+	keys := keysOf("c", "missing", "a")
+	values, err := vmap.VerifiedGetMany(ctx, keys, ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []string{"3", "", "4"} {
+		if string(values[i].LeafInput) != want {
+			t.Fatalf("wrong value for %s: %s", keys[i], values[i].LeafInput)
+		}
+	}
+	err = vmap.VerifyMap(ctx, nil, ms, nil, func(ctx context.Context, idx int64, key []byte, value *pb.LeafData) error {
+		if len(key) != 0 {
+			t.Fatal("key should not be revealed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The mutation log has the VRF proof for each key, but not the key itself
+	for i := int64(0); i < ms.TreeSize(); i++ {
+		entry, err := vmap.MutationLog().Entry(ctx, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(entry.ExtraData, []byte(`"key":`)) || !bytes.Contains(entry.ExtraData, []byte(`"key_proof":`)) {
+			t.Fatalf("mutation %d should have a key proof and no key: %s", i, entry.ExtraData)
+		}
+	}
+	history, err := vmap.VerifiedKeyHistory(ctx, []byte("a"), ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectHistory(t, history, []int64{0, 1, 2}, []string{"1", "", "4"})
+
+	// Proofs must have a valid VRF proof for the key, and do not verify as plain key hashes
+	resp, err := vmap.Get(ctx, []byte("b"), ms.TreeSize())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = verifiable.VerifyMapInclusionProof(resp, []byte("b"), ms.MapTreeHead)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = verifiable.VerifyMapInclusionProof(resp, []byte("c"), ms.MapTreeHead)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+	bad := proto.Clone(resp).(*pb.MapGetValueResponse)
+	bad.KeyProof[0] ^= 1
+	err = verifiable.VerifyMapInclusionProof(bad, []byte("b"), ms.MapTreeHead)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+	bad.KeyProof = nil
+	err = verifiable.VerifyMapInclusionProof(bad, []byte("b"), ms.MapTreeHead)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+	plainHead := proto.Clone(ms.MapTreeHead).(*pb.MapTreeHashResponse)
+	plainHead.VrfPublicKey = nil
+	err = verifiable.VerifyMapInclusionProof(resp, []byte("b"), plainHead)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+
+	// Clients reject tree heads without VRF keys, or with a VRF key other than the one they trust
+	_, err = vmap.VerifiedGet(ctx, []byte("b"), &verifiable.MapTreeState{MapTreeHead: plainHead, TreeHeadLogTreeHead: ms.TreeHeadLogTreeHead})
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+	_, err = acc.VerifiableMap("foo").VerifiedLatestMapState(ctx, nil)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+	err = acc.VerifiableMap("foo").VerifyMap(ctx, nil, ms, nil, nil)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+	vmap.TrustedVRFKey = ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)).Public().(ed25519.PublicKey)
+	_, err = vmap.VerifiedLatestMapState(ctx, nil)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+	_, _, err = vmap.VerifiedGetBundle(ctx, []byte("b"), nil, verifiable.Head)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+	vmap.TrustedVRFKey = testVRFKey().Public().(ed25519.PublicKey)
+	_, _, err = vmap.VerifiedGetBundle(ctx, []byte("b"), ms, verifiable.Head)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	many, err := vmap.GetMany(ctx, keys, ms.TreeSize())
+	if err != nil {
+		t.Fatal(err)
+	}
+	many.KeyProofs = many.KeyProofs[1:]
+	err = verifiable.VerifyMapGetValues(many, keys, ms.MapTreeHead)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+
+	// The mode is fixed by the first mutation
+	other := acc.VerifiableMap("foo")
+	_, err = other.Set(ctx, []byte("d"), &pb.LeafData{LeafInput: []byte("1")})
+	expectErrCode(t, codes.InvalidArgument, err)
+	other = acc.VerifiableMap("bar")
+	applyAndWait(t, other, setMut("a", "1"))
+	other.Map.VrfKeys = true
+	_, err = other.Set(ctx, []byte("d"), &pb.LeafData{LeafInput: []byte("1")})
+	expectErrCode(t, codes.InvalidArgument, err)
+}
+
+func TestVRFMap(t *testing.T) {
+	testVRFMap(t, createVRFService(testVRFKey()))
+
+	// Without a VRF key, or with a different one to that recorded, maps with VRF keys can't be added to
+	ctx := context.TODO()
+	db := &memory.TransientStorage{}
+	for _, vrfKey := range []ed25519.PrivateKey{nil, testVRFKey(), ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))} {
+		vmap := (&verifiable.Client{Service: (&verifiable.Service{
+			AccessPolicy: policy.Open,
+			Mutator:      &instant.Mutator{Writer: db},
+			Reader:       db,
+			VRFKey:       vrfKey,
+		}).MustCreate()}).Account("0", "").VerifiableMap("foo")
+		vmap.Map.VrfKeys = true
+		if bytes.Equal(vrfKey, testVRFKey()) {
+			applyAndWait(t, vmap, setMut("a", "1"))
+			continue
+		}
+		_, err := vmap.Set(ctx, []byte("a"), &pb.LeafData{LeafInput: []byte("1")})
+		expectErrCode(t, codes.FailedPrecondition, err)
+	}
+
+	go grpc.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		GrpcListenBind:           ":8114",
+		GrpcListenProtocol:       "tcp4",
+	}, createVRFService(testVRFKey()))
+	go httprest.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		RestListenBind:           ":8115",
+	}, createVRFService(testVRFKey()))
+	time.Sleep(50 * time.Millisecond)
+
+	testVRFMap(t, (&grpc.Client{
+		Address:        "localhost:8114",
+		NoGrpcSecurity: true,
+	}).MustDial())
+	testVRFMap(t, (&httprest.Client{
+		BaseURL: "http://localhost:8115",
+	}).MustDial())
+}
@@ -0,0 +1,148 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/merkle"
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/server/grpc"
+	"github.com/continusec/verifiabledatastructures/server/httprest"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+)
+
+func testWatchTreeHeads(t *testing.T, service pb.VerifiableDataStructuresServiceServer) {
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Second)
+	defer cancel()
+	acc := (&verifiable.Client{Service: service}).Account("0", "")
+
+	vlog := acc.VerifiableLog("foo")
+	for _, v := range []string{"foo", "bar", "baz"} {
+		p, err := vlog.Add(ctx, &pb.LeafData{LeafInput: []byte(v)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		lth, err := p.Wait(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = vlog.VerifyInclusion(ctx, lth, p.LeafHash())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	vmap := acc.VerifiableMap("foo")
+	p, err := vmap.Set(ctx, []byte("foo"), &pb.LeafData{LeafInput: []byte("bar")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mth, err := p.Wait(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mth.MutationLog.TreeSize != 1 {
+		t.Fatal("wrong map size")
+	}
+	mth, err = vmap.BlockUntilSize(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mth.MutationLog.TreeSize != 1 {
+		t.Fatal("wrong map size")
+	}
+
+	// Waiting for something that never arrives should stop when the context is done
+	start := time.Now()
+	sctx, scancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer scancel()
+	_, err = vlog.BlockUntilPresent(sctx, merkle.LeafHash([]byte("never")))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	_, err = vmap.BlockUntilSize(sctx, 100)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Fatal("took too long to notice cancellation")
+	}
+}
+
+func TestWatchTreeHeads(t *testing.T) {
+	testWatchTreeHeads(t, createCleanEmptyService())
+	testWatchTreeHeads(t, createCleanEmptyBatchMutatorService())
+
+	go grpc.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		GrpcListenBind:           ":8083",
+		GrpcListenProtocol:       "tcp4",
+	}, createCleanEmptyBatchMutatorService())
+	go httprest.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		RestListenBind:           ":8098",
+	}, createCleanEmptyBatchMutatorService())
+	time.Sleep(50 * time.Millisecond)
+
+	testWatchTreeHeads(t, (&grpc.Client{
+		Address:        "localhost:8083",
+		NoGrpcSecurity: true,
+	}).MustDial())
+	testWatchTreeHeads(t, (&httprest.Client{
+		BaseURL: "http://localhost:8098",
+	}).MustDial())
+}
+
+func TestWatchEmptyMap(t *testing.T) {
+	ctx := context.TODO()
+	vmap := (&verifiable.Client{Service: createCleanEmptyService()}).Account("0", "").VerifiableMap("foo")
+
+	mth, err := vmap.TreeHead(ctx, verifiable.Head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mth.MutationLog.TreeSize != 0 {
+		t.Fatal("expected empty map")
+	}
+	ms, err := vmap.VerifiedLatestMapState(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ms != nil {
+		t.Fatal("expected no map state")
+	}
+
+	// Watching an empty map waits for the first mutation
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		vmap.Set(ctx, []byte("foo"), &pb.LeafData{LeafInput: []byte("bar")})
+	}()
+	wctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	mth, err = vmap.BlockUntilSize(wctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mth.MutationLog.TreeSize != 1 {
+		t.Fatal("wrong map size")
+	}
+}
@@ -0,0 +1,222 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"testing"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/mutator/instant"
+	"github.com/continusec/verifiabledatastructures/oracle/policy"
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/storage/memory"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	"github.com/continusec/verifiabledatastructures/witness"
+	"google.golang.org/grpc/codes"
+)
+
+func createWitnessedService(signer crypto.Signer, witnesses []pb.WitnessServiceServer) pb.VerifiableDataStructuresServiceServer {
+	db := &memory.TransientStorage{}
+	return (&verifiable.Service{
+		AccessPolicy: policy.Open,
+		Mutator:      &instant.Mutator{Writer: db},
+		Reader:       db,
+		Signer:       signer,
+		Witnesses:    witnesses,
+	}).MustCreate()
+}
+
+func addAndWait(t *testing.T, vlog *verifiable.Log, val string) {
+	ctx := context.TODO()
+	p, err := vlog.Add(ctx, &pb.LeafData{LeafInput: []byte(val)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = p.Wait(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitForCosigned waits for the tree head of the given size to be cosigned, which happens in the background
+func waitForCosigned(t *testing.T, vlog *verifiable.Log, treeSize int64) {
+	for i := 0; i < 200; i++ {
+		_, err := vlog.TreeHead(context.TODO(), treeSize)
+		if err == nil {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("tree head of size %d was not cosigned", treeSize)
+}
+
+func TestWitnessCosigning(t *testing.T) {
+	ctx := context.TODO()
+
+	_, logKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var witnesses []pb.WitnessServiceServer
+	var witnessKeys []crypto.PublicKey
+	for i := 0; i < 2; i++ {
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		witnesses = append(witnesses, (&witness.Service{
+			Signer:  priv,
+			Storage: &memory.TransientStorage{},
+			Logs:    map[string]*witness.Log{"0/log/foo": {PublicKey: logKey.Public()}},
+		}).MustCreate())
+		witnessKeys = append(witnessKeys, pub)
+	}
+
+	service := createWitnessedService(logKey, witnesses)
+	client := &verifiable.Client{
+		Service:     service,
+		TrustedKey:  logKey.Public(),
+		WitnessKeys: witnessKeys,
+	}
+	vlog := client.Account("0", "").VerifiableLog("foo")
+
+	// Nothing to cosign yet
+	_, err = vlog.TreeHead(ctx, verifiable.Head)
+	expectErrCode(t, codes.NotFound, err)
+
+	var prev *pb.LogTreeHashResponse
+	for i, v := range []string{"a", "b", "c"} {
+		addAndWait(t, vlog, v)
+		waitForCosigned(t, vlog, int64(i+1))
+		prev, err = vlog.VerifiedLatestTreeHead(ctx, prev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(prev.Cosignatures) != 2 {
+			t.Fatal("expected cosignatures from both witnesses")
+		}
+	}
+
+	// Earlier cosigned heads can still be fetched
+	_, err = vlog.VerifiedTreeHead(ctx, prev, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A missing witness must fail verification
+	other, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = (&verifiable.Client{Service: service, WitnessKeys: append(witnessKeys, other)}).Account("0", "").VerifiableLog("foo").VerifiedLatestTreeHead(ctx, nil)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+	_, err = (&verifiable.Client{Service: service, WitnessKeys: append(witnessKeys, other), WitnessQuorum: 2}).Account("0", "").VerifiableLog("foo").VerifiedLatestTreeHead(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A split view of the same log must not be cosigned
+	splitLog := (&verifiable.Client{Service: createWitnessedService(logKey, witnesses), WitnessKeys: witnessKeys}).Account("0", "").VerifiableLog("foo")
+	for _, v := range []string{"a", "b", "d", "e"} {
+		_, err = splitLog.Add(ctx, &pb.LeafData{LeafInput: []byte(v)}) // instant mutator, so no need to wait
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+	_, err = splitLog.TreeHead(ctx, verifiable.Head)
+	expectErrCode(t, codes.NotFound, err)
+
+	// The honest log can continue to be cosigned
+	addAndWait(t, vlog, "f")
+	waitForCosigned(t, vlog, 4)
+	_, err = vlog.VerifiedLatestTreeHead(ctx, prev)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWitnessPinnedLog(t *testing.T) {
+	ctx := context.TODO()
+
+	honest := createWitnessedService(nil, nil)
+	vlog := (&verifiable.Client{Service: honest}).Account("0", "").VerifiableLog("foo")
+	for _, v := range []string{"a", "b", "c"} {
+		addAndWait(t, vlog, v)
+	}
+	start, err := vlog.TreeHead(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := vlog.TreeHead(ctx, verifiable.Head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := vlog.ConsistencyProof(ctx, 2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := (&witness.Service{
+		Signer:  priv,
+		Storage: &memory.TransientStorage{},
+		Logs: map[string]*witness.Log{"0/log/foo": {
+			HashAlgorithm: pb.HashAlgorithm_HASH_SHA256,
+			StartHead:     start,
+		}},
+	}).MustCreate()
+	ref := &pb.LogRef{Account: &pb.AccountRef{Id: "0"}, Name: "foo"}
+
+	// The first head must be consistent with the start head
+	resp, err := w.AddLogTreeHead(ctx, &pb.AddLogTreeHeadRequest{Log: ref, Head: head})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Cosignature != nil || resp.WitnessSize != 2 {
+		t.Fatal("expected witness to require a proof from its start head")
+	}
+	_, err = w.AddLogTreeHead(ctx, &pb.AddLogTreeHeadRequest{Log: ref, OldSize: 2, Head: &pb.LogTreeHashResponse{TreeSize: 3, RootHash: start.RootHash}, Proof: proof})
+	expectErrCode(t, codes.FailedPrecondition, err)
+
+	// Proofs must use the pinned hash algorithm
+	_, err = w.AddLogTreeHead(ctx, &pb.AddLogTreeHeadRequest{Log: ref, OldSize: 2, Head: head, Proof: &pb.LogConsistencyProofResponse{
+		FromSize:      proof.FromSize,
+		TreeSize:      proof.TreeSize,
+		AuditPath:     proof.AuditPath,
+		HashAlgorithm: pb.HashAlgorithm_HASH_BLAKE3,
+	}})
+	expectErrCode(t, codes.FailedPrecondition, err)
+
+	resp, err = w.AddLogTreeHead(ctx, &pb.AddLogTreeHeadRequest{Log: ref, OldSize: 2, Head: head, Proof: proof})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Cosignature == nil || resp.WitnessSize != 3 {
+		t.Fatal("expected cosignature")
+	}
+}
@@ -0,0 +1,147 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/server/grpc"
+	"github.com/continusec/verifiabledatastructures/server/httprest"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func testWriteOnceMap(t *testing.T, service pb.VerifiableDataStructuresServiceServer) {
+	ctx := context.TODO()
+	acc := (&verifiable.Client{Service: service}).Account("0", "")
+	vmap := acc.VerifiableMap("foo")
+	vmap.Map.WriteOnce = true
+
+	head := applyAndWait(t, vmap, setMut("a", "1"))
+	if head.Rejected {
+		t.Fatal("first set should not be rejected")
+	}
+
+	// Changes to a key with a value are rejected
+	for _, mut := range []*pb.MapMutation{
+		setMut("a", "2"),
+		updateMut("a", "1", "2"),
+		deleteMut("a"),
+	} {
+		next := applyAndWait(t, vmap, mut)
+		if !next.Rejected || string(next.RootHash) != string(head.RootHash) {
+			t.Fatalf("%s should be rejected", mut.Action)
+		}
+	}
+
+	// The map can still be verified when the last mutation was rejected
+	ms, err := vmap.VerifiedLatestMapState(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ms.TreeSize() != 4 || string(ms.MapTreeHead.RootHash) != string(head.RootHash) {
+		t.Fatal("wrong map state after rejected mutation")
+	}
+	expectMapValues(t, vmap, ms, map[string]string{"a": "1"})
+	_, _, err = vmap.VerifiedGetBundle(ctx, []byte("a"), ms, verifiable.Head)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// As are transactions that include one, even if it is set earlier in the transaction
+	next := applyAndWait(t, vmap, setMut("b", "1"), setMut("a", "2"))
+	if !next.Rejected || string(next.RootHash) != string(head.RootHash) {
+		t.Fatal("transaction should be rejected")
+	}
+	next = applyAndWait(t, vmap, setMut("b", "1"), setMut("b", "2"))
+	if !next.Rejected || string(next.RootHash) != string(head.RootHash) {
+		t.Fatal("transaction should be rejected")
+	}
+
+	// Setting the same value, and new keys, are fine
+	next = applyAndWait(t, vmap, setMut("a", "1"))
+	if next.Rejected {
+		t.Fatal("same value should not be rejected")
+	}
+	next = applyAndWait(t, vmap, setMut("b", "1"), setMut("c", "1"))
+	if next.Rejected || string(next.RootHash) == string(head.RootHash) {
+		t.Fatal("new keys should not be rejected")
+	}
+
+	ms, err = vmap.VerifiedLatestMapState(ctx, ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectMapValues(t, vmap, ms, map[string]string{"a": "1", "b": "1", "c": "1"})
+	err = vmap.VerifyMap(ctx, nil, ms, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The mode is fixed by the first mutation
+	other := acc.VerifiableMap("foo")
+	_, err = other.Set(ctx, []byte("d"), &pb.LeafData{LeafInput: []byte("1")})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected mismatched write-once to fail: %v", err)
+	}
+
+	// A map that overwrites a key fails the audit as write-once
+	other = acc.VerifiableMap("bar")
+	applyAndWait(t, other, setMut("a", "1"))
+	applyAndWait(t, other, setMut("a", "2"))
+	ms, err = other.VerifiedLatestMapState(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = other.VerifyMap(ctx, nil, ms, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.Map.WriteOnce = true
+	err = other.VerifyMap(ctx, nil, ms, nil, nil)
+	expectErr(t, verifiable.ErrVerificationFailed, err)
+}
+
+func TestWriteOnceMap(t *testing.T) {
+	testWriteOnceMap(t, createCleanEmptyService())
+	testWriteOnceMap(t, createCleanEmptyBatchMutatorService())
+
+	go grpc.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		GrpcListenBind:           ":8091",
+		GrpcListenProtocol:       "tcp4",
+	}, createCleanEmptyService())
+	go httprest.StartServer(&pb.ServerConfig{
+		InsecureServerForTesting: true,
+		RestListenBind:           ":8106",
+	}, createCleanEmptyService())
+	time.Sleep(50 * time.Millisecond)
+
+	testWriteOnceMap(t, (&grpc.Client{
+		Address:        "localhost:8091",
+		NoGrpcSecurity: true,
+	}).MustDial())
+	testWriteOnceMap(t, (&httprest.Client{
+		BaseURL: "http://localhost:8106",
+	}).MustDial())
+}
@@ -126,9 +126,10 @@ type LeafDataAuditFunction func(context.Context, *pb.LeafData) error
 // so for example it is not called for a mutation that does not modify the value for a key,
 // such as setting the same value again (that is already set), or updates based on a previous
 // value where the previous value is not current.
+// For a transaction, it is called for each operation that changes the map, with the same idx.
 // idx the index of the mutation - while this will always increase, there may be gaps per the
 // reasons outlined above.
-// key is the key that is being changed
+// key is the key that is being changed, or nil for a map with VRF keys, as the mutation log does not reveal it
 // value (produced by VerifiableEntryFactory specified when creating the auditor) is the
 //
 //	value being set/deleted/modified.
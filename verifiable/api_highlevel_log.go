@@ -18,12 +18,12 @@ package verifiable
 
 import (
 	"bytes"
-	"time"
 
-	"github.com/continusec/verifiabledatastructures/merkle"
 	"github.com/continusec/verifiabledatastructures/pb"
 
 	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // VerifyInclusion will fetch a proof the the specified MerkleTreeHash is included in the
@@ -34,6 +34,11 @@ func (log *Log) VerifyInclusion(ctx context.Context, head *pb.LogTreeHashRespons
 		return err
 	}
 
+	// The server must use the hash algorithm we expect
+	if proof.HashAlgorithm != log.Log.HashAlgorithm {
+		return ErrVerificationFailed
+	}
+
 	err = VerifyLogInclusionProof(proof, leaf, head)
 	if err != nil {
 		return err
@@ -43,6 +48,32 @@ func (log *Log) VerifyInclusion(ctx context.Context, head *pb.LogTreeHashRespons
 	return nil
 }
 
+// VerifyBatchInclusion will fetch a single proof that all of the specified MerkleTreeHashes are
+// included in the log and verify that it produces the root hash in the specified LogTreeHead.
+// If the server does not support batch proofs, each leaf is verified with VerifyInclusion instead.
+func (log *Log) VerifyBatchInclusion(ctx context.Context, head *pb.LogTreeHashResponse, leaves [][]byte) error {
+	proof, err := log.BatchInclusionProof(ctx, head.TreeSize, leaves)
+	if status.Code(err) == codes.Unimplemented {
+		for _, leaf := range leaves {
+			err = log.VerifyInclusion(ctx, head, leaf)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// The server must use the hash algorithm we expect
+	if proof.HashAlgorithm != log.Log.HashAlgorithm {
+		return ErrVerificationFailed
+	}
+
+	return VerifyLogBatchInclusionProof(proof, leaves, head)
+}
+
 // VerifyConsistency takes two tree heads, retrieves a consistency proof, verifies it,
 // and returns the result. The two tree heads may be in either order (even equal), but both must be greater than zero and non-nil.
 func (log *Log) VerifyConsistency(ctx context.Context, a, b *pb.LogTreeHashResponse) error {
@@ -68,6 +99,9 @@ func (log *Log) VerifyConsistency(ctx context.Context, a, b *pb.LogTreeHashRespo
 	if err != nil {
 		return err
 	}
+	if proof.HashAlgorithm != log.Log.HashAlgorithm {
+		return ErrVerificationFailed
+	}
 	err = VerifyLogConsistencyProof(proof, a, b)
 	if err != nil {
 		return err
@@ -78,39 +112,44 @@ func (log *Log) VerifyConsistency(ctx context.Context, a, b *pb.LogTreeHashRespo
 }
 
 // BlockUntilPresent blocks until the log is able to produce a LogTreeHead that includes the
-// specified MerkleTreeLeaf. This polls TreeHead() and InclusionProof() until such time as a new
-// tree hash is produced that includes the given MerkleTreeLeaf. Exponential back-off is used
-// when no new tree hash is available.
+// specified MerkleTreeLeaf. Each new tree head is checked with InclusionProof() as the server
+// commits it. If the server is unable to push new tree heads, TreeHead() is polled instead.
 //
 // This is intended for test use.
 func (log *Log) BlockUntilPresent(ctx context.Context, leaf []byte) (*pb.LogTreeHashResponse, error) {
-	lastHead := int64(-1)
-	timeToSleep := time.Second
-	for {
-		lth, err := log.TreeHead(ctx, Head)
-		if err != nil {
-			return nil, err
-		}
-		if lth.TreeSize > lastHead {
-			lastHead = lth.TreeSize
-			err = log.VerifyInclusion(ctx, lth, leaf)
-			switch err {
-			case nil: // we found it
-				return lth, nil
-			case ErrNotFound:
-				// no good, continue
-			default:
-				// Should return error, but we're struggling to differentiate not found vs other errors
-				//return nil, err
-			}
-			// since we got a new tree head, reset sleep time
-			timeToSleep = time.Second
-		} else {
-			// no luck, snooze a bit longer
-			timeToSleep *= 2
+	var rv *pb.LogTreeHashResponse
+	err := log.watchTreeHeads(ctx, 0, func(lth *pb.LogTreeHashResponse) (bool, error) {
+		err := log.VerifyInclusion(ctx, lth, leaf)
+		switch err {
+		case nil: // we found it
+			rv = lth
+			return true, nil
+		case ErrNotFound:
+			// no good, continue
+		default:
+			// Should return error, but we're struggling to differentiate not found vs other errors
+			//return false, err
 		}
-		time.Sleep(timeToSleep)
+		return false, nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return rv, nil
+}
+
+// watchTreeHeads calls f with each new tree head larger than after, until f returns true or an error.
+func (log *Log) watchTreeHeads(ctx context.Context, after int64, f func(*pb.LogTreeHashResponse) (bool, error)) error {
+	return watchTreeHeads(ctx, after, func(after int64, stream *callbackStream[pb.LogTreeHashResponse]) error {
+		return log.Service.WatchLogTreeHead(&pb.WatchLogTreeHeadRequest{
+			Log:      log.Log,
+			TreeSize: after,
+		}, stream)
+	}, func() (*pb.LogTreeHashResponse, error) {
+		return log.TreeHead(ctx, Head)
+	}, func(lth *pb.LogTreeHashResponse) int64 {
+		return lth.TreeSize
+	}, f)
 }
 
 // VerifiedLatestTreeHead calls VerifiedTreeHead() with Head to fetch the latest tree head,
@@ -137,6 +176,8 @@ func (log *Log) VerifiedLatestTreeHead(ctx context.Context, prev *pb.LogTreeHash
 // VerifiedTreeHead is a utility method to fetch a LogTreeHead and verifies that it is consistent with
 // a tree head earlier fetched and persisted. For first use, pass nil for prev, which will
 // bypass consistency proof checking. Tree size may be older or newer than the previous head value.
+// If a TrustedKey is set on the log, the tree head must also be correctly signed by that key.
+// If WitnessKeys are set on the log, the tree head must also be cosigned by a quorum of those witnesses.
 //
 // Clients typically use VerifyLatestTreeHead().
 func (log *Log) VerifiedTreeHead(ctx context.Context, prev *pb.LogTreeHashResponse, treeSize int64) (*pb.LogTreeHashResponse, error) {
@@ -150,6 +191,20 @@ func (log *Log) VerifiedTreeHead(ctx context.Context, prev *pb.LogTreeHashRespon
 		return nil, err
 	}
 
+	if log.TrustedKey != nil {
+		err = VerifyLogTreeHeadSignature(log.Log, head, log.TrustedKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(log.WitnessKeys) != 0 {
+		err = VerifyLogTreeHeadCosignatures(log.Log, head, log.WitnessKeys, log.WitnessQuorum)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	if prev != nil {
 		err = log.VerifyConsistency(ctx, prev, head)
 		if err != nil {
@@ -167,6 +222,10 @@ func (log *Log) VerifiedTreeHead(ctx context.Context, prev *pb.LogTreeHashRespon
 // Upon success, the LogTreeHead returned is the one used to verify the inclusion proof - it may be newer or older than the one passed in.
 // In either case, it will have been verified as consistent.
 func (log *Log) VerifySuppliedInclusionProof(ctx context.Context, prev *pb.LogTreeHashResponse, proof *pb.LogInclusionProofResponse, leaf []byte) (*pb.LogTreeHashResponse, error) {
+	if proof.HashAlgorithm != log.Log.HashAlgorithm {
+		return nil, ErrVerificationFailed
+	}
+
 	headForInclProof, err := log.VerifiedTreeHead(ctx, prev, proof.TreeSize)
 	if err != nil {
 		return nil, err
@@ -181,6 +240,125 @@ func (log *Log) VerifySuppliedInclusionProof(ctx context.Context, prev *pb.LogTr
 	return headForInclProof, nil
 }
 
+// VerifiedEntry fetches the entry at leafIndex, a tree head of the given size (or Head), and the proofs
+// needed to verify both, in a single round trip. The tree head is verified as for VerifiedTreeHead(),
+// and the entry is verified as included in it. Returns the entry and the verified tree head.
+//
+// If the server does not support this, or WitnessKeys are set on the log, individual requests are made instead.
+func (log *Log) VerifiedEntry(ctx context.Context, prev *pb.LogTreeHashResponse, leafIndex, treeSize int64) (*pb.LeafData, *pb.LogTreeHashResponse, error) {
+	// Bundled tree heads are not cosigned
+	if len(log.WitnessKeys) == 0 {
+		req := &pb.LogFetchEntryBundleRequest{
+			Log:       log.Log,
+			LeafIndex: leafIndex,
+			TreeSize:  treeSize,
+		}
+		if prev != nil {
+			req.FromSize = prev.TreeSize
+		}
+		bundle, err := log.Service.LogFetchEntryBundle(ctx, req)
+		if err == nil {
+			return log.verifyEntryBundle(prev, leafIndex, treeSize, bundle)
+		}
+		if status.Code(err) != codes.Unimplemented {
+			return nil, nil, err
+		}
+	}
+
+	// Older servers may not support bundles, so fall back to separate requests
+	head, err := log.VerifiedTreeHead(ctx, prev, treeSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	entry, err := log.Entry(ctx, leafIndex)
+	if err != nil {
+		return nil, nil, err
+	}
+	proof, err := log.InclusionProofByIndex(ctx, head.TreeSize, leafIndex)
+	if err != nil {
+		return nil, nil, err
+	}
+	err = log.verifyEntryInclusion(proof, entry, leafIndex, head)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entry, head, nil
+}
+
+// verifyEntryBundle verifies each part of a bundle returned by the server
+func (log *Log) verifyEntryBundle(prev *pb.LogTreeHashResponse, leafIndex, treeSize int64, bundle *pb.LogFetchEntryBundleResponse) (*pb.LeafData, *pb.LogTreeHashResponse, error) {
+	head := bundle.Head
+	if head == nil || bundle.Value == nil {
+		return nil, nil, ErrVerificationFailed
+	}
+	if treeSize != 0 && head.TreeSize != treeSize {
+		return nil, nil, ErrVerificationFailed
+	}
+
+	if log.TrustedKey != nil {
+		err := VerifyLogTreeHeadSignature(log.Log, head, log.TrustedKey)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if prev != nil {
+		err := log.verifySuppliedConsistency(bundle.ConsistencyProof, prev, head)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	err := log.verifyEntryInclusion(bundle.InclusionProof, bundle.Value, leafIndex, head)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bundle.Value, head, nil
+}
+
+// verifyEntryInclusion verifies that the proof is for the entry at leafIndex in head
+func (log *Log) verifyEntryInclusion(proof *pb.LogInclusionProofResponse, entry *pb.LeafData, leafIndex int64, head *pb.LogTreeHashResponse) error {
+	if proof == nil || proof.LeafIndex != leafIndex {
+		return ErrVerificationFailed
+	}
+	// The server must use the hash algorithm we expect
+	if proof.HashAlgorithm != log.Log.HashAlgorithm {
+		return ErrVerificationFailed
+	}
+	h, err := log.Hasher()
+	if err != nil {
+		return err
+	}
+	return VerifyLogInclusionProof(proof, h.LeafHash(entry.LeafInput), head)
+}
+
+// verifySuppliedConsistency is as for VerifyConsistency, but uses a proof already supplied by the server,
+// which may be nil if the tree heads are the same size.
+func (log *Log) verifySuppliedConsistency(proof *pb.LogConsistencyProofResponse, a, b *pb.LogTreeHashResponse) error {
+	if a == nil || b == nil || a.TreeSize <= 0 || b.TreeSize <= 0 {
+		return ErrVerificationFailed
+	}
+
+	// Special case being equal
+	if a.TreeSize == b.TreeSize {
+		if !bytes.Equal(a.RootHash, b.RootHash) {
+			return ErrVerificationFailed
+		}
+		// All good
+		return nil
+	}
+
+	// If wrong order, swap 'em
+	if a.TreeSize > b.TreeSize {
+		a, b = b, a
+	}
+
+	if proof == nil || proof.HashAlgorithm != log.Log.HashAlgorithm {
+		return ErrVerificationFailed
+	}
+	return VerifyLogConsistencyProof(proof, a, b)
+}
+
 // VerifyEntries is a utility method for auditors that wish to audit the full content of
 // a log, as well as the log operation. This method will retrieve all entries in batch from
 // the log between the passed in prev and head LogTreeHeads, and ensure that the root hash in head can be confirmed to accurately represent
@@ -198,6 +376,11 @@ func (log *Log) VerifyEntries(ctx context.Context, prev *pb.LogTreeHashResponse,
 		return nil
 	}
 
+	h, err := log.Hasher()
+	if err != nil {
+		return err
+	}
+
 	merkleTreeStack := make([][]byte, 0)
 	idx := int64(0)
 	if prev != nil && prev.TreeSize > 0 {
@@ -211,7 +394,7 @@ func (log *Log) VerifyEntries(ctx context.Context, prev *pb.LogTreeHashResponse,
 			if firstHash == nil {
 				firstHash = b
 			} else {
-				firstHash = merkle.NodeHash(b, firstHash)
+				firstHash = h.NodeHash(b, firstHash)
 			}
 		}
 		if !bytes.Equal(firstHash, prev.RootHash) {
@@ -236,11 +419,11 @@ func (log *Log) VerifyEntries(ctx context.Context, prev *pb.LogTreeHashResponse,
 			}
 		}
 
-		mtlHash := merkle.LeafHash(entry.GetLeafInput())
+		mtlHash := h.LeafHash(entry.GetLeafInput())
 
 		merkleTreeStack = append(merkleTreeStack, mtlHash)
 		for z := idx; (z & 1) == 1; z >>= 1 {
-			merkleTreeStack = append(merkleTreeStack[:len(merkleTreeStack)-2], merkle.NodeHash(merkleTreeStack[len(merkleTreeStack)-2], merkleTreeStack[len(merkleTreeStack)-1]))
+			merkleTreeStack = append(merkleTreeStack[:len(merkleTreeStack)-2], h.NodeHash(merkleTreeStack[len(merkleTreeStack)-2], merkleTreeStack[len(merkleTreeStack)-1]))
 		}
 
 		idx++
@@ -256,7 +439,7 @@ func (log *Log) VerifyEntries(ctx context.Context, prev *pb.LogTreeHashResponse,
 
 	headHash := merkleTreeStack[len(merkleTreeStack)-1]
 	for z := len(merkleTreeStack) - 2; z >= 0; z-- {
-		headHash = merkle.NodeHash(merkleTreeStack[z], headHash)
+		headHash = h.NodeHash(merkleTreeStack[z], headHash)
 	}
 
 	if !bytes.Equal(headHash, head.RootHash) {
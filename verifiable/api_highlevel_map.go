@@ -17,13 +17,34 @@
 package verifiable
 
 import (
-	"time"
+	"bytes"
 
-	"github.com/continusec/verifiabledatastructures/merkle"
 	"github.com/continusec/verifiabledatastructures/pb"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// checkMapTreeHead verifies that a map tree head is for the kind of map we expect. Otherwise a server
+// could choose what each proof is verified against, such as a different VRF key to its own.
+func (vmap *Map) checkMapTreeHead(head *pb.MapTreeHashResponse) error {
+	if head == nil {
+		return ErrNilTreeHead
+	}
+	// The server must use VRF keys if we expect them, and the VRF key we trust if we have one
+	if vmap.Map.VrfKeys != (len(head.VrfPublicKey) != 0) {
+		return ErrVerificationFailed
+	}
+	if vmap.TrustedVRFKey != nil && !bytes.Equal(head.VrfPublicKey, vmap.TrustedVRFKey) {
+		return ErrVerificationFailed
+	}
+	// And sum the field we expect, and prove values with the trie we expect
+	if head.SumField != vmap.Map.SumField || head.PatriciaTrie != vmap.Map.PatriciaTrie {
+		return ErrVerificationFailed
+	}
+	return nil
+}
+
 // VerifiedGet gets the value for the given key in the specified MapTreeState, and verifies that it is
 // included in the MapTreeHead (wrapped by the MapTreeState) before returning.
 func (vmap *Map) VerifiedGet(ctx context.Context, key []byte, mapHead *MapTreeState) (*pb.LeafData, error) {
@@ -31,6 +52,15 @@ func (vmap *Map) VerifiedGet(ctx context.Context, key []byte, mapHead *MapTreeSt
 	if err != nil {
 		return nil, err
 	}
+	// The server must use the map options we expect
+	err = vmap.checkMapTreeHead(mapHead.MapTreeHead)
+	if err != nil {
+		return nil, err
+	}
+	// The server must use the hash algorithm we expect
+	if proof.HashAlgorithm != vmap.Map.HashAlgorithm {
+		return nil, ErrVerificationFailed
+	}
 	err = VerifyMapInclusionProof(proof, key, mapHead.MapTreeHead)
 	if err != nil {
 		return nil, err
@@ -38,32 +68,204 @@ func (vmap *Map) VerifiedGet(ctx context.Context, key []byte, mapHead *MapTreeSt
 	return proof.Value, nil
 }
 
-// BlockUntilSize blocks until the map has caught up to a certain size. This polls
-// TreeHead() until such time as a new tree hash is produced that is of at least this
-// size.
+// VerifiedGetMany gets the values for the given keys in the specified MapTreeState, in the order given, and
+// verifies that they are included in the MapTreeHead (wrapped by the MapTreeState) before returning.
+// If the server does not support fetching many values at once, each value is fetched with VerifiedGet instead.
+func (vmap *Map) VerifiedGetMany(ctx context.Context, keys [][]byte, mapHead *MapTreeState) ([]*pb.LeafData, error) {
+	// The server must use the map options we expect
+	err := vmap.checkMapTreeHead(mapHead.MapTreeHead)
+	if err != nil {
+		return nil, err
+	}
+	proof, err := vmap.GetMany(ctx, keys, mapHead.TreeSize())
+	if status.Code(err) == codes.Unimplemented {
+		rv := make([]*pb.LeafData, len(keys))
+		for i, key := range keys {
+			rv[i], err = vmap.VerifiedGet(ctx, key, mapHead)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return rv, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// The server must use the hash algorithm we expect
+	if proof.HashAlgorithm != vmap.Map.HashAlgorithm {
+		return nil, ErrVerificationFailed
+	}
+	err = VerifyMapGetValues(proof, keys, mapHead.MapTreeHead)
+	if err != nil {
+		return nil, err
+	}
+	return proof.Values, nil
+}
+
+// VerifiedKeyHistory gets each value that the given key has had in the specified MapTreeState, and verifies
+// that each was set by a mutation included in the mutation log for the MapTreeHead (wrapped by the MapTreeState)
+// before returning. The server is trusted not to omit any changes.
+func (vmap *Map) VerifiedKeyHistory(ctx context.Context, key []byte, mapHead *MapTreeState) ([]*pb.MapKeyHistoryEntry, error) {
+	history, err := vmap.KeyHistory(ctx, key, mapHead.TreeSize())
+	if err != nil {
+		return nil, err
+	}
+	// The server must use the map options we expect
+	err = vmap.checkMapTreeHead(mapHead.MapTreeHead)
+	if err != nil {
+		return nil, err
+	}
+	// The server must use the hash algorithm we expect
+	if history.HashAlgorithm != vmap.Map.HashAlgorithm {
+		return nil, ErrVerificationFailed
+	}
+	err = VerifyMapKeyHistory(history, key, mapHead.MapTreeHead)
+	if err != nil {
+		return nil, err
+	}
+	return history.Entries, nil
+}
+
+// VerifiedGetBundle fetches the value for key, the map state for the given size (or Head), and the proofs
+// needed to verify both, in a single round trip. The map state is verified as for VerifiedMapState(),
+// and the value is verified as included in it. Returns the value and the verified map state.
 //
-// This is intended for test use.
-func (vmap *Map) BlockUntilSize(ctx context.Context, treeSize int64) (*pb.MapTreeHashResponse, error) {
-	lastHead := int64(-1)
-	timeToSleep := time.Second
-	for {
-		lth, err := vmap.TreeHead(ctx, Head)
+// If the server does not support this, individual requests are made instead.
+func (vmap *Map) VerifiedGetBundle(ctx context.Context, key []byte, prev *MapTreeState, treeSize int64) (*pb.LeafData, *MapTreeState, error) {
+	req := &pb.MapGetValueBundleRequest{
+		Map:      vmap.Map,
+		Key:      key,
+		TreeSize: treeSize,
+	}
+	if prev != nil {
+		req.FromSize = prev.TreeSize()
+		req.TreeHeadLogFromSize = prev.TreeHeadLogTreeHead.TreeSize
+	}
+	bundle, err := vmap.Service.MapGetValueBundle(ctx, req)
+	if err == nil {
+		return vmap.verifyGetBundle(key, prev, treeSize, bundle)
+	}
+	if status.Code(err) != codes.Unimplemented {
+		return nil, nil, err
+	}
+
+	// Older servers may not support bundles, so fall back to separate requests
+	state, err := vmap.VerifiedMapState(ctx, prev, treeSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	if state == nil {
+		return nil, nil, status.Errorf(codes.NotFound, "map is empty")
+	}
+	value, err := vmap.VerifiedGet(ctx, key, state)
+	if err != nil {
+		return nil, nil, err
+	}
+	return value, state, nil
+}
+
+// verifyGetBundle verifies each part of a bundle returned by the server
+func (vmap *Map) verifyGetBundle(key []byte, prev *MapTreeState, treeSize int64, bundle *pb.MapGetValueBundleResponse) (*pb.LeafData, *MapTreeState, error) {
+	mapHead, thlth := bundle.MapHead, bundle.TreeHeadLogHead
+	if mapHead == nil || mapHead.MutationLog == nil || thlth == nil || bundle.Value == nil {
+		return nil, nil, ErrVerificationFailed
+	}
+	if treeSize != 0 && mapHead.MutationLog.TreeSize != treeSize {
+		return nil, nil, ErrVerificationFailed
+	}
+
+	// The server must use the map options we expect
+	err := vmap.checkMapTreeHead(mapHead)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	thLog := vmap.TreeHeadLog()
+
+	// If we have a trusted key, then both heads must be signed by it
+	if vmap.TrustedKey != nil {
+		err = VerifyMapTreeHeadSignature(vmap.Map, mapHead, vmap.TrustedKey)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+		err = VerifyLogTreeHeadSignature(thLog.Log, thlth, vmap.TrustedKey)
+		if err != nil {
+			return nil, nil, err
 		}
-		if lth.MutationLog.TreeSize >= treeSize {
-			return lth, nil
+	}
+
+	// If we have a previous state, then make sure both logs are consistent with it
+	if prev != nil {
+		err = vmap.MutationLog().verifySuppliedConsistency(bundle.MutationLogConsistencyProof, prev.MapTreeHead.MutationLog, mapHead.MutationLog)
+		if err != nil {
+			return nil, nil, err
 		}
-		if lth.MutationLog.TreeSize > lastHead {
-			lastHead = lth.MutationLog.TreeSize
-			// since we got a new tree head, reset sleep time
-			timeToSleep = time.Second
-		} else {
-			// no luck, snooze a bit longer
-			timeToSleep *= 2
+		err = thLog.verifySuppliedConsistency(bundle.TreeHeadLogConsistencyProof, prev.TreeHeadLogTreeHead, thlth)
+		if err != nil {
+			return nil, nil, err
 		}
-		time.Sleep(timeToSleep)
 	}
+
+	// Make sure the map head is in the tree head log
+	li, err := CreateJSONLeafDataFromObject(unsignedMapTreeHead(mapHead))
+	if err != nil {
+		return nil, nil, err
+	}
+	h, err := vmap.Hasher()
+	if err != nil {
+		return nil, nil, err
+	}
+	proof := bundle.TreeHeadLogInclusionProof
+	if proof == nil || proof.HashAlgorithm != vmap.Map.HashAlgorithm {
+		return nil, nil, ErrVerificationFailed
+	}
+	err = VerifyLogInclusionProof(proof, h.LeafHash(li.LeafInput), thlth)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// And that the value is in the map head
+	if bundle.Value.HashAlgorithm != vmap.Map.HashAlgorithm {
+		return nil, nil, ErrVerificationFailed
+	}
+	err = VerifyMapInclusionProof(bundle.Value, key, mapHead)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return bundle.Value.Value, &MapTreeState{
+		MapTreeHead:         mapHead,
+		TreeHeadLogTreeHead: thlth,
+	}, nil
+}
+
+// BlockUntilSize blocks until the map has caught up to a certain size. New tree heads are
+// pushed by the server as they are committed. If the server is unable to do so, TreeHead()
+// is polled instead until such time as a tree hash of at least this size is produced.
+//
+// This is intended for test use.
+func (vmap *Map) BlockUntilSize(ctx context.Context, treeSize int64) (*pb.MapTreeHashResponse, error) {
+	if treeSize <= 0 {
+		return vmap.TreeHead(ctx, Head)
+	}
+	var rv *pb.MapTreeHashResponse
+	err := watchTreeHeads(ctx, treeSize-1, func(after int64, stream *callbackStream[pb.MapTreeHashResponse]) error {
+		return vmap.Service.WatchMapTreeHead(&pb.WatchMapTreeHeadRequest{
+			Map:      vmap.Map,
+			TreeSize: after,
+		}, stream)
+	}, func() (*pb.MapTreeHashResponse, error) {
+		return vmap.TreeHead(ctx, Head)
+	}, func(mth *pb.MapTreeHashResponse) int64 {
+		return mth.MutationLog.TreeSize
+	}, func(mth *pb.MapTreeHashResponse) (bool, error) {
+		rv = mth
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rv, nil
 }
 
 // VerifiedLatestMapState fetches the latest MapTreeState, verifies it is consistent with,
@@ -92,6 +294,8 @@ func (vmap *Map) VerifiedLatestMapState(ctx context.Context, prev *MapTreeState)
 // Note that the TreeHeadLogTreeHead returned may differ between calls, even for the same treeSize,
 // as all future LogTreeHeads can also be proven to contain the MapTreeHead.
 //
+// If a TrustedKey is set on the map, the map tree head must be correctly signed by that key.
+//
 // Typical clients that only need to access current data will instead use VerifiedLatestMapState()
 // Can return nil, nil if the map is empty (and prev was nil)
 func (vmap *Map) VerifiedMapState(ctx context.Context, prev *MapTreeState, treeSize int64) (*MapTreeState, error) {
@@ -111,6 +315,20 @@ func (vmap *Map) VerifiedMapState(ctx context.Context, prev *MapTreeState, treeS
 		return nil, nil
 	}
 
+	// The server must use the map options we expect
+	err = vmap.checkMapTreeHead(mapHead)
+	if err != nil {
+		return nil, err
+	}
+
+	// If we have a trusted key, then the map head must be signed by it
+	if vmap.TrustedKey != nil {
+		err = VerifyMapTreeHeadSignature(vmap.Map, mapHead, vmap.TrustedKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// If we have a previous state, then make sure both logs are consistent with it
 	if prev != nil {
 		// Make sure that the mutation log is consistent with what we had
@@ -132,7 +350,7 @@ func (vmap *Map) VerifiedMapState(ctx context.Context, prev *MapTreeState, treeS
 	// If we already have a tree head that is the size of our map, then we
 	// probably don't need a new one, so try that first.
 	if prevThlth != nil && prevThlth.TreeSize >= mapHead.MutationLog.TreeSize {
-		lh, err := CreateJSONLeafDataFromObject(mapHead)
+		lh, err := CreateJSONLeafDataFromObject(unsignedMapTreeHead(mapHead))
 		if err != nil {
 			return nil, err
 		}
@@ -152,11 +370,15 @@ func (vmap *Map) VerifiedMapState(ctx context.Context, prev *MapTreeState, treeS
 		}
 
 		// And make sure we are in it
-		li, err := CreateJSONLeafDataFromObject(mapHead)
+		li, err := CreateJSONLeafDataFromObject(unsignedMapTreeHead(mapHead))
 		if err != nil {
 			return nil, err
 		}
-		err = vmap.TreeHeadLog().VerifyInclusion(ctx, thlth, merkle.LeafHash(li.LeafInput))
+		h, err := vmap.Hasher()
+		if err != nil {
+			return nil, err
+		}
+		err = vmap.TreeHeadLog().VerifyInclusion(ctx, thlth, h.LeafHash(li.LeafInput))
 		if err != nil {
 			return nil, err
 		}
@@ -169,6 +391,112 @@ func (vmap *Map) VerifiedMapState(ctx context.Context, prev *MapTreeState, treeS
 	}, nil
 }
 
+// VerifiedLeaves lists every non-empty leaf in the map for the given MapTreeState, in key hash order,
+// calling f for each. Each page of leaves is verified against the map root hash before f is called
+// for any leaf in it, so that no leaf can be omitted. The keys themselves are not stored in the map,
+// only their hashes.
+func (vmap *Map) VerifiedLeaves(ctx context.Context, ms *MapTreeState, f func(ctx context.Context, leaf *pb.MapLeaf) error) error {
+	// Nothing to list, and zero would fetch the latest
+	if ms.TreeSize() == 0 {
+		return nil
+	}
+
+	// The server must use the map options we expect
+	err := vmap.checkMapTreeHead(ms.MapTreeHead)
+	if err != nil {
+		return err
+	}
+
+	var start []byte
+	for {
+		page, err := vmap.ListLeaves(ctx, ms.TreeSize(), start, 0)
+		if err != nil {
+			return err
+		}
+
+		// The server must use the hash algorithm we expect
+		if page.HashAlgorithm != vmap.Map.HashAlgorithm {
+			return ErrVerificationFailed
+		}
+
+		err = VerifyMapListLeaves(page, start, ms.MapTreeHead)
+		if err != nil {
+			return err
+		}
+
+		for _, leaf := range page.Leaves {
+			err = f(ctx, leaf)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(page.NextKeyHash) == 0 {
+			return nil
+		}
+		start = page.NextKeyHash
+	}
+}
+
+// VerifiedDiff lists every key, by key hash, whose value differs between the map for the from and to
+// MapTreeStates, in key hash order, calling f for each with the value in each. A nil from is the empty map.
+// Each page of changes is verified against both map root hashes before f is called for any change in it,
+// so that no change can be omitted.
+func (vmap *Map) VerifiedDiff(ctx context.Context, from, to *MapTreeState, f func(ctx context.Context, entry *pb.MapDiffEntry) error) error {
+	var fromSize int64
+	var fromHead *pb.MapTreeHashResponse
+	if from != nil {
+		fromSize, fromHead = from.TreeSize(), from.MapTreeHead
+	}
+
+	// Nothing changed, and zero would fetch the latest
+	if to.TreeSize() == 0 || to.TreeSize() == fromSize {
+		return nil
+	}
+
+	// The server must use the map options we expect
+	err := vmap.checkMapTreeHead(to.MapTreeHead)
+	if err != nil {
+		return err
+	}
+	if fromHead != nil {
+		err = vmap.checkMapTreeHead(fromHead)
+		if err != nil {
+			return err
+		}
+	}
+
+	var start []byte
+	for {
+		page, err := vmap.Diff(ctx, fromSize, to.TreeSize(), start, 0)
+		if err != nil {
+			return err
+		}
+
+		// The server must use the hash algorithm we expect
+		if page.HashAlgorithm != vmap.Map.HashAlgorithm {
+			return ErrVerificationFailed
+		}
+
+		err = VerifyMapDiff(page, start, fromHead, to.MapTreeHead)
+		if err != nil {
+			return err
+		}
+
+		for _, entry := range page.Entries {
+			err = f(ctx, entry)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(page.NextKeyHash) == 0 {
+			return nil
+		}
+		start = page.NextKeyHash
+	}
+}
+
 // VerifyMap (Experimental API surface, likely to change) is a utility method for auditors
 // that wish to audit the full content of a map, as well as the map operation. This method
 // will verify every entry in the TreeHeadLogTreeHead between prev and head - and to do so
@@ -182,6 +510,13 @@ func (vmap *Map) VerifiedMapState(ctx context.Context, prev *MapTreeState, treeS
 // in a call to auditFunc - operations that result in no change to the map will not call
 // the audit function.
 //
+// If the MapRef is write-once, then the map is also verified to have never changed a key with
+// a non-empty value. For a patricia trie map, the root hash is verified to be that of the trie
+// of the values in the map.
+//
+// Values set by "patch" and "increment" mutations are derived from the previous value for the key, as
+// set in the mutation log, so the audit function is passed the derived value.
+//
 // To verify all every log tree head entry, pass nil for prev, which will also bypass consistency proof checking. Head must not be nil.
 //
 // Example usage:
@@ -211,9 +546,105 @@ func (vmap *Map) VerifyMap(ctx context.Context, prev *MapTreeState, head *MapTre
 		return ErrNilTreeHead
 	}
 
-	return vmap.TreeHeadLog().VerifyEntries(ctx, prevLth, head.TreeHeadLogTreeHead, (&auditState{
+	a, err := vmap.newAuditState(head, leafFunc, auditFunc)
+	if err != nil {
+		return err
+	}
+	return vmap.TreeHeadLog().VerifyEntries(ctx, prevLth, head.TreeHeadLogTreeHead, a.CheckTreeHeadEntry)
+}
+
+// newAuditState returns the state for replaying the mutation log of the map up to head, which must be
+// for the kind of map we expect
+func (vmap *Map) newAuditState(head *MapTreeState, leafFunc LeafDataAuditFunction, auditFunc MapAuditFunction) (*auditState, error) {
+	// The server must use the map options we expect
+	err := vmap.checkMapTreeHead(head.MapTreeHead)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := vmap.Hasher()
+	if err != nil {
+		return nil, err
+	}
+
+	rv := &auditState{
 		Map:                   vmap,
+		Hasher:                h,
+		MapHasher:             mapTreeHasher(h, head.MapTreeHead.SumField),
+		SumField:              head.MapTreeHead.SumField,
+		VRFPublicKey:          head.MapTreeHead.VrfPublicKey,
 		MapAuditFunction:      auditFunc,
 		LeafDataAuditFunction: leafFunc,
-	}).CheckTreeHeadEntry)
+	}
+	if head.MapTreeHead.PatriciaTrie {
+		rv.PatriciaTrie = newPatriciaAuditTrie()
+	}
+	return rv, nil
+}
+
+// VerifyDerivedMap verifies that the map is derived from the entries in source by reducer. All entries
+// in source up to the size of head are fetched with VerifyEntries, each is reduced to the mutation it
+// derives, and these are applied to an in-memory copy of the map. Every tree head for the map up to head
+// must then match, for both the map and its mutation log.
+//
+// The entries must be fetched in full, as a redacted entry does not reduce to the same mutation. The map
+// is replayed with the same options as for VerifyMap, except that a map with VRF keys cannot be verified,
+// as the proofs for the keys cannot be derived from the entries. Head must not be nil.
+//
+// Example usage:
+//
+//	latestMapState, err := vmap.VerifiedLatestMapState(ctx, nil)
+//	if err != nil {
+//		...
+//	}
+//
+//	err = vmap.VerifyDerivedMap(ctx, account.VerifiableLog("events"), &pb.MapReducer{KeyPath: "subject"}, latestMapState)
+//	if err != nil {
+//		...
+//	}
+//
+// As for VerifyMap(), this requires the entire map be built in-memory.
+func (vmap *Map) VerifyDerivedMap(ctx context.Context, source *Log, reducer *pb.MapReducer, head *MapTreeState) error {
+	if head == nil {
+		return ErrNilTreeHead
+	}
+
+	// The mutations derived from entries have no VRF proofs for their keys, so cannot be replayed
+	if vmap.Map.VrfKeys {
+		return ErrInvalidRequest
+	}
+
+	audit, err := vmap.newAuditState(head, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	// Nothing to verify for an empty map, and a tree size of 0 would fetch the latest head for source
+	if head.TreeSize() == 0 {
+		return nil
+	}
+
+	// A derived map always has the same size as its log
+	sourceHead, err := source.VerifiedTreeHead(ctx, nil, head.TreeSize())
+	if err != nil {
+		return err
+	}
+	if sourceHead == nil || sourceHead.TreeSize != head.TreeSize() {
+		return ErrVerificationFailed
+	}
+
+	err = source.VerifyEntries(ctx, nil, sourceHead, func(ctx context.Context, idx int64, entry *pb.LeafData) error {
+		mut := reduceLogEntry(reducer, idx, entry)
+		mutData, err := CreateJSONLeafDataFromMutation(mut)
+		if err != nil {
+			return err
+		}
+		return audit.ApplyMutation(ctx, idx, mutData, mut)
+	})
+	if err != nil {
+		return err
+	}
+	audit.Size = sourceHead.TreeSize
+
+	return vmap.TreeHeadLog().VerifyEntries(ctx, nil, head.TreeHeadLogTreeHead, audit.CheckTreeHeadEntry)
 }
@@ -0,0 +1,118 @@
+/*
+   Copyright 2017 Continusec Pty Ltd
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package verifiable
+
+import (
+	"github.com/continusec/verifiabledatastructures/pb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// VerifiedTreeHead fetches the multimap tree head for the given tree size, or Head for the latest.
+// If a TrustedKey is set on the multimap, the tree head must be correctly signed by that key.
+func (mm *Multimap) VerifiedTreeHead(ctx context.Context, treeSize int64) (*pb.MapTreeHashResponse, error) {
+	head, err := mm.TreeHead(ctx, treeSize)
+	if err != nil {
+		return nil, err
+	}
+	if mm.TrustedKey != nil {
+		err = VerifyMultimapTreeHeadSignature(mm.Multimap, head, mm.TrustedKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return head, nil
+}
+
+// VerifiedValues gets each value for the given key in the specified multimap tree head, in the order
+// added, and verifies that they are exactly the values in the log of values for the key, which is in
+// turn verified as included in the multimap tree head, before returning.
+func (mm *Multimap) VerifiedValues(ctx context.Context, key []byte, head *pb.MapTreeHashResponse) ([]*pb.LeafData, error) {
+	proof, err := mm.Values(ctx, key, head.GetMutationLog().GetTreeSize())
+	if err != nil {
+		return nil, err
+	}
+	// The server must use the hash algorithm we expect
+	if proof.GetKeyHead().GetHashAlgorithm() != mm.Multimap.HashAlgorithm {
+		return nil, ErrVerificationFailed
+	}
+	err = VerifyMultimapGetValues(proof, key, head)
+	if err != nil {
+		return nil, err
+	}
+	return proof.Values, nil
+}
+
+// VerifyInclusion will fetch a proof that the value with the specified leaf hash is in the list of values
+// for the key in the specified multimap tree head, and verify it.
+func (mm *Multimap) VerifyInclusion(ctx context.Context, key []byte, head *pb.MapTreeHashResponse, leaf []byte) error {
+	proof, err := mm.InclusionProof(ctx, key, head.GetMutationLog().GetTreeSize(), leaf)
+	if err != nil {
+		return err
+	}
+	// The server must use the hash algorithm we expect
+	if proof.GetKeyHead().GetHashAlgorithm() != mm.Multimap.HashAlgorithm {
+		return ErrVerificationFailed
+	}
+	return VerifyMultimapInclusionProof(proof, key, leaf, head)
+}
+
+// VerifyKeyConsistency will fetch a proof that the list of values for the key in the multimap tree head
+// to is an append-only extension of that in the multimap tree head from, and verify it. Pass nil for from
+// to verify against the empty multimap, which is always consistent.
+func (mm *Multimap) VerifyKeyConsistency(ctx context.Context, key []byte, from, to *pb.MapTreeHashResponse) error {
+	proof, err := mm.KeyConsistencyProof(ctx, key, from.GetMutationLog().GetTreeSize(), to.GetMutationLog().GetTreeSize())
+	if err != nil {
+		return err
+	}
+	// The server must use the hash algorithm we expect
+	if proof.GetKeyHead().GetHashAlgorithm() != mm.Multimap.HashAlgorithm {
+		return ErrVerificationFailed
+	}
+	return VerifyMultimapKeyConsistencyProof(proof, key, from, to)
+}
+
+// BlockUntilPresent polls the multimap until it is able to produce a tree head that includes the value
+// with the specified leaf hash in the list of values for the key. Each new tree head is checked with
+// VerifyInclusion().
+//
+// This is intended for test use.
+func (mm *Multimap) BlockUntilPresent(ctx context.Context, key, leaf []byte) (*pb.MapTreeHashResponse, error) {
+	var rv *pb.MapTreeHashResponse
+	err := pollTreeHeads(ctx, 0, func() (*pb.MapTreeHashResponse, error) {
+		return mm.TreeHead(ctx, Head)
+	}, func(mth *pb.MapTreeHashResponse) int64 {
+		return mth.GetMutationLog().GetTreeSize()
+	}, func(mth *pb.MapTreeHashResponse) (bool, error) {
+		err := mm.VerifyInclusion(ctx, key, mth, leaf)
+		switch {
+		case err == nil: // we found it
+			rv = mth
+			return true, nil
+		case status.Code(err) == codes.NotFound:
+			// not added yet, continue
+			return false, nil
+		default:
+			return false, err
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rv, nil
+}
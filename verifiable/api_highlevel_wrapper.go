@@ -17,8 +17,14 @@
 package verifiable
 
 import (
+	"crypto"
+	"crypto/ed25519"
+
+	"github.com/continusec/verifiabledatastructures/merkle"
 	"github.com/continusec/verifiabledatastructures/pb"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // Client is the primary point to begin interaction with
@@ -26,6 +32,18 @@ import (
 // an underlying lower-level API.
 type Client struct {
 	Service pb.VerifiableDataStructuresServiceServer
+
+	// TrustedKey, if set, is the public key that all tree heads must be signed by.
+	// Verified operations will fail for unsigned or mis-signed tree heads.
+	TrustedKey crypto.PublicKey
+
+	// WitnessKeys, if set, are the public keys of witnesses that log tree heads must be cosigned by.
+	// Only cosigned tree heads will be requested from the service.
+	WitnessKeys []crypto.PublicKey
+
+	// WitnessQuorum is the number of WitnessKeys that must have cosigned a log tree head. If zero,
+	// all witnesses must have cosigned.
+	WitnessQuorum int
 }
 
 // Account returns an object that can be used to access objects within that account
@@ -35,15 +53,21 @@ func (v *Client) Account(id string, apiKey string) *Account {
 			Id:     id,
 			ApiKey: apiKey,
 		},
-		Service: v.Service,
+		Service:       v.Service,
+		TrustedKey:    v.TrustedKey,
+		WitnessKeys:   v.WitnessKeys,
+		WitnessQuorum: v.WitnessQuorum,
 	}
 }
 
 // Account is used to access your Continusec account.
 type Account struct {
-	Account *pb.AccountRef
-	APIKey  string
-	Service pb.VerifiableDataStructuresServiceServer
+	Account       *pb.AccountRef
+	APIKey        string
+	Service       pb.VerifiableDataStructuresServiceServer
+	TrustedKey    crypto.PublicKey
+	WitnessKeys   []crypto.PublicKey
+	WitnessQuorum int
 }
 
 // VerifiableMap returns an object representing a Verifiable Map. This function simply
@@ -55,7 +79,8 @@ func (acc *Account) VerifiableMap(name string) *Map {
 			Account: acc.Account,
 			Name:    name,
 		},
-		Service: acc.Service,
+		Service:    acc.Service,
+		TrustedKey: acc.TrustedKey,
 	}
 }
 
@@ -69,7 +94,24 @@ func (acc *Account) VerifiableLog(name string) *Log {
 			Name:    name,
 			LogType: pb.LogType_STRUCT_TYPE_LOG,
 		},
-		Service: acc.Service,
+		Service:       acc.Service,
+		TrustedKey:    acc.TrustedKey,
+		WitnessKeys:   acc.WitnessKeys,
+		WitnessQuorum: acc.WitnessQuorum,
+	}
+}
+
+// VerifiableMultimap returns an object representing a Verifiable Multimap, where each key has
+// an append-only list of values. This function simply returns a pointer to an object that can be
+// used to interact with the Multimap, and won't by itself cause any API calls to be generated.
+func (acc *Account) VerifiableMultimap(name string) *Multimap {
+	return &Multimap{
+		Multimap: &pb.MultimapRef{
+			Account: acc.Account,
+			Name:    name,
+		},
+		Service:    acc.Service,
+		TrustedKey: acc.TrustedKey,
 	}
 }
 
@@ -77,6 +119,22 @@ func (acc *Account) VerifiableLog(name string) *Log {
 type Map struct {
 	Map     *pb.MapRef
 	Service pb.VerifiableDataStructuresServiceServer
+
+	// TrustedKey, if set, is the public key that map tree heads must be signed by.
+	TrustedKey crypto.PublicKey
+
+	// TrustedVRFKey, if set, is the VRF public key that map tree heads must have, for a map with VRF keys.
+	TrustedVRFKey ed25519.PublicKey
+}
+
+// Multimap is an object used to interact with Verifiable Multimaps. The value for each key
+// in the underlying map is the tree head of a log of the values added for that key.
+type Multimap struct {
+	Multimap *pb.MultimapRef
+	Service  pb.VerifiableDataStructuresServiceServer
+
+	// TrustedKey, if set, is the public key that multimap tree heads must be signed by.
+	TrustedKey crypto.PublicKey
 }
 
 // Log is an object used to interact with Verifiable Logs. To construct this
@@ -84,17 +142,49 @@ type Map struct {
 type Log struct {
 	Log     *pb.LogRef
 	Service pb.VerifiableDataStructuresServiceServer
+
+	// TrustedKey, if set, is the public key that log tree heads must be signed by.
+	TrustedKey crypto.PublicKey
+
+	// WitnessKeys, if set, are the public keys of witnesses that log tree heads must be cosigned by.
+	WitnessKeys []crypto.PublicKey
+
+	// WitnessQuorum is the number of WitnessKeys that must have cosigned a log tree head. If zero,
+	// all witnesses must have cosigned.
+	WitnessQuorum int
+}
+
+// Hasher returns the hash strategy for the log, as set by the HashAlgorithm in the LogRef.
+// Use this to calculate leaf hashes to pass to VerifyInclusion.
+func (g *Log) Hasher() (merkle.Hasher, error) {
+	return HasherForAlgorithm(g.Log.HashAlgorithm)
 }
 
 // TreeHead returns tree root hash for the log at the given tree size. Specify continusec.Head
-// to receive a root hash for the latest tree size.
+// to receive a root hash for the latest tree size. If WitnessKeys are set on the log, then
+// only tree heads that have been cosigned by witnesses are returned.
 func (g *Log) TreeHead(ctx context.Context, treeSize int64) (*pb.LogTreeHashResponse, error) {
 	return g.Service.LogTreeHash(ctx, &pb.LogTreeHashRequest{
 		Log:      g.Log,
 		TreeSize: treeSize,
+		Cosigned: len(g.WitnessKeys) != 0,
 	})
 }
 
+// Checkpoint returns the tree head for the log at the given tree size as a signed checkpoint, in the
+// C2SP signed-note format. Specify continusec.Head for the latest tree size. The server must be
+// configured with a signing key. Use ParseCheckpoint() to verify and parse the result.
+func (g *Log) Checkpoint(ctx context.Context, treeSize int64) ([]byte, error) {
+	resp, err := g.Service.LogCheckpoint(ctx, &pb.LogCheckpointRequest{
+		Log:      g.Log,
+		TreeSize: treeSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Checkpoint, nil
+}
+
 // Add will send an API call to add the specified entry to the log. If the exact entry
 // already exists in the log, it will not be added a second time.
 // Returns an AddEntryResponse which includes the leaf hash, whether it is a duplicate or not. Note that the
@@ -115,6 +205,30 @@ func (g *Log) Add(ctx context.Context, e *pb.LeafData) (LogUpdatePromise, error)
 	}, nil
 }
 
+// AddEntries sends a batch of entries to the log in a single call, to be sequenced in order.
+// A promise is returned for each entry, in the same order. As for Add, the entries are
+// sequenced asynchronously.
+func (g *Log) AddEntries(ctx context.Context, entries []*pb.LeafData) ([]LogUpdatePromise, error) {
+	resp, err := g.Service.LogAddEntries(ctx, &pb.LogAddEntriesRequest{
+		Log:    g.Log,
+		Values: entries,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.LeafHashes) != len(entries) {
+		return nil, ErrInternalError
+	}
+	rv := make([]LogUpdatePromise, len(resp.LeafHashes))
+	for i, lh := range resp.LeafHashes {
+		rv[i] = &logAddPromise{
+			Log: g,
+			MTL: lh,
+		}
+	}
+	return rv, nil
+}
+
 // InclusionProof will return a proof the the specified MerkleTreeLeaf is included in the
 // log. The proof consists of the index within the log that the entry is stored, and an
 // audit path which returns the corresponding leaf nodes that can be applied to the input
@@ -129,6 +243,19 @@ func (g *Log) InclusionProof(ctx context.Context, treeSize int64, leaf []byte) (
 	})
 }
 
+// BatchInclusionProof will return a single proof that all of the specified MerkleTreeLeafs are included
+// in the log. The proof consists of the index of each entry, and a deduplicated set of subtree hashes
+// that can be combined with the input leaf hashes to generate the root tree hash for the log.
+//
+// Most clients instead use VerifyBatchInclusion which additionally verifies the returned proof.
+func (g *Log) BatchInclusionProof(ctx context.Context, treeSize int64, leaves [][]byte) (*pb.LogBatchInclusionProofResponse, error) {
+	return g.Service.LogBatchInclusionProof(ctx, &pb.LogBatchInclusionProofRequest{
+		Log:       g.Log,
+		MtlHashes: leaves,
+		TreeSize:  treeSize,
+	})
+}
+
 // InclusionProofByIndex will return an inclusion proof for a specified tree size and leaf index.
 // This is not used by typical clients, however it can be useful for certain audit operations and debugging tools.
 // The LogInclusionProof returned by this method will not have the LeafHash filled in and as such will fail to verify.
@@ -173,7 +300,7 @@ func (g *Log) Entry(ctx context.Context, idx int64) (*pb.LeafData, error) {
 	return resp.Values[0], nil
 }
 
-// Entries batches requests to fetch entries from the server and returns a channel with the data
+// Entries streams entries from the server and returns a channel with the data
 // for each entry. Close the context passed to terminate early if desired. If an error is
 // encountered, the channel will be closed early before all items are returned.
 //
@@ -182,40 +309,94 @@ func (g *Log) Entries(ctx context.Context, start, end int64) <-chan *pb.LeafData
 	rv := make(chan *pb.LeafData)
 	go func() {
 		defer close(rv)
-		batchSize := int64(500)
-		for start < end {
-			lastToFetch := start + batchSize
-			if lastToFetch > end {
-				lastToFetch = end
-			}
+		if start >= end {
+			return
+		}
+		next, err := g.streamEntries(ctx, &pb.StreamLogEntriesRequest{
+			Log:   g.Log,
+			First: start,
+			Last:  end,
+		}, rv)
+		// Older servers may not support streaming, so fall back to fetching batches
+		if status.Code(err) == codes.Unimplemented && next == start {
+			g.fetchEntries(ctx, start, end, rv)
+		}
+	}()
+	return rv
+}
 
-			resp, err := g.Service.LogFetchEntries(ctx, &pb.LogFetchEntriesRequest{
-				Log:   g.Log,
-				First: start,
-				Last:  lastToFetch,
-			})
-			if err != nil {
-				return
+// FollowEntries streams entries from the server starting at start, and continues to return new
+// entries as they are sequenced. Close the context passed to stop. If an error is encountered,
+// the channel will be closed.
+func (g *Log) FollowEntries(ctx context.Context, start int64) <-chan *pb.LeafData {
+	rv := make(chan *pb.LeafData)
+	go func() {
+		defer close(rv)
+		g.streamEntries(ctx, &pb.StreamLogEntriesRequest{
+			Log:    g.Log,
+			First:  start,
+			Follow: true,
+		}, rv)
+	}()
+	return rv
+}
+
+// streamEntries sends entries to rv, returning the index of the next entry that would have been sent
+func (g *Log) streamEntries(ctx context.Context, req *pb.StreamLogEntriesRequest, rv chan<- *pb.LeafData) (int64, error) {
+	next := req.First
+	err := g.Service.StreamLogEntries(req, &callbackStream[pb.StreamLogEntriesResponse]{
+		ctx: ctx,
+		send: func(resp *pb.StreamLogEntriesResponse) error {
+			// Entries must arrive in order
+			if resp.Index != next {
+				return ErrVerificationFailed
+			}
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case rv <- resp.Value:
+				next++
+				return nil
 			}
+		},
+	})
+	return next, err
+}
 
-			gotOne := false
-			for _, e := range resp.Values {
-				select {
-				case <-ctx.Done():
-					return
-				case rv <- e:
-					start++
-					gotOne = true
-				}
+// fetchEntries batches requests to fetch entries from the server
+func (g *Log) fetchEntries(ctx context.Context, start, end int64, rv chan<- *pb.LeafData) {
+	batchSize := int64(500)
+	for start < end {
+		lastToFetch := start + batchSize
+		if lastToFetch > end {
+			lastToFetch = end
+		}
 
-			}
-			// if we didn't get anything new
-			if !gotOne {
+		resp, err := g.Service.LogFetchEntries(ctx, &pb.LogFetchEntriesRequest{
+			Log:   g.Log,
+			First: start,
+			Last:  lastToFetch,
+		})
+		if err != nil {
+			return
+		}
+
+		gotOne := false
+		for _, e := range resp.Values {
+			select {
+			case <-ctx.Done():
 				return
+			case rv <- e:
+				start++
+				gotOne = true
 			}
+
 		}
-	}()
-	return rv
+		// if we didn't get anything new
+		if !gotOne {
+			return
+		}
+	}
 }
 
 // MutationLog returns a pointer to the underlying Verifiable Log that represents
@@ -226,10 +407,12 @@ func (g *Map) MutationLog() *Log {
 	return &Log{
 		Service: g.Service,
 		Log: &pb.LogRef{
-			Account: g.Map.Account,
-			Name:    g.Map.Name,
-			LogType: pb.LogType_STRUCT_TYPE_MUTATION_LOG,
+			Account:       g.Map.Account,
+			Name:          g.Map.Name,
+			LogType:       pb.LogType_STRUCT_TYPE_MUTATION_LOG,
+			HashAlgorithm: g.Map.HashAlgorithm,
 		},
+		TrustedKey: g.TrustedKey,
 	}
 }
 
@@ -240,19 +423,53 @@ func (g *Map) TreeHeadLog() *Log {
 	return &Log{
 		Service: g.Service,
 		Log: &pb.LogRef{
-			Account: g.Map.Account,
-			Name:    g.Map.Name,
-			LogType: pb.LogType_STRUCT_TYPE_TREEHEAD_LOG,
+			Account:       g.Map.Account,
+			Name:          g.Map.Name,
+			LogType:       pb.LogType_STRUCT_TYPE_TREEHEAD_LOG,
+			HashAlgorithm: g.Map.HashAlgorithm,
 		},
+		TrustedKey: g.TrustedKey,
 	}
 }
 
+// Hasher returns the hash strategy for the map, as set by the HashAlgorithm in the MapRef.
+func (g *Map) Hasher() (merkle.Hasher, error) {
+	return HasherForAlgorithm(g.Map.HashAlgorithm)
+}
+
 // Get will return the value for the given key at the given treeSize. Pass continusec.Head
 // to always get the latest value. factory is normally one of RawDataEntryFactory, JsonEntryFactory or RedactedJsonEntryFactory.
 //
 // Clients normally instead call VerifiedGet() with a MapTreeHead returned by VerifiedLatestMapState as this will also perform verification of inclusion.
+// A compact proof is requested, though servers that do not support these will return a full proof instead.
 func (g *Map) Get(ctx context.Context, key []byte, treeSize int64) (*pb.MapGetValueResponse, error) {
 	return g.Service.MapGetValue(ctx, &pb.MapGetValueRequest{
+		Key:          key,
+		Map:          g.Map,
+		TreeSize:     treeSize,
+		CompactProof: true,
+	})
+}
+
+// GetMany will return the values for the given keys at the given treeSize, in the order given, along with
+// a single proof of their inclusion. Pass continusec.Head to always get the latest values.
+//
+// Clients normally instead call VerifiedGetMany() as this will also perform verification of inclusion.
+func (g *Map) GetMany(ctx context.Context, keys [][]byte, treeSize int64) (*pb.MapGetValuesResponse, error) {
+	return g.Service.MapGetValues(ctx, &pb.MapGetValuesRequest{
+		Keys:     keys,
+		Map:      g.Map,
+		TreeSize: treeSize,
+	})
+}
+
+// KeyHistory will return each value that the given key has had, up to the given treeSize, along with the
+// mutation that set it and proof of the inclusion of that mutation in the mutation log. Pass continusec.Head
+// to include all changes so far.
+//
+// Clients normally instead call VerifiedKeyHistory() as this will also perform verification.
+func (g *Map) KeyHistory(ctx context.Context, key []byte, treeSize int64) (*pb.MapGetKeyHistoryResponse, error) {
+	return g.Service.MapGetKeyHistory(ctx, &pb.MapGetKeyHistoryRequest{
 		Key:      key,
 		Map:      g.Map,
 		TreeSize: treeSize,
@@ -324,6 +541,172 @@ func (g *Map) Update(ctx context.Context, key []byte, value *pb.LeafData, previo
 	}, nil
 }
 
+// Create will generate a map mutation to set the given value for the given key, conditional on the
+// key having no value.
+// While this will return quickly, the change will be reflected asynchronously in the map.
+// Returns an AddEntryResponse which contains the leaf hash for the mutation log entry.
+func (g *Map) Create(ctx context.Context, key []byte, value *pb.LeafData) (MapUpdatePromise, error) {
+	resp, err := g.Service.MapSetValue(ctx, &pb.MapSetValueRequest{
+		Map: g.Map,
+		Mutation: &pb.MapMutation{
+			Action: "create",
+			Key:    key,
+			Value:  value,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &mapSetPromise{
+		Map: g,
+		MTL: resp.LeafHash,
+	}, nil
+}
+
+// CompareAndDelete will generate a map mutation to delete the value for the given key, conditional on
+// the previous leaf hash being that specified by previousLeaf.
+// While this will return quickly, the change will be reflected asynchronously in the map.
+// Returns an AddEntryResponse which contains the leaf hash for the mutation log entry.
+func (g *Map) CompareAndDelete(ctx context.Context, key []byte, previousLeaf []byte) (MapUpdatePromise, error) {
+	resp, err := g.Service.MapSetValue(ctx, &pb.MapSetValueRequest{
+		Map: g.Map,
+		Mutation: &pb.MapMutation{
+			Action:           "compare_and_delete",
+			Key:              key,
+			PreviousLeafHash: previousLeaf,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &mapSetPromise{
+		Map: g,
+		MTL: resp.LeafHash,
+	}, nil
+}
+
+// SetIfVersion will generate a map mutation to set the given value for the given key, conditional on
+// the key having last been changed by the mutation at index version (-1 for never changed), as
+// returned by KeyHistory.
+// While this will return quickly, the change will be reflected asynchronously in the map.
+// Returns an AddEntryResponse which contains the leaf hash for the mutation log entry.
+func (g *Map) SetIfVersion(ctx context.Context, key []byte, value *pb.LeafData, version int64) (MapUpdatePromise, error) {
+	resp, err := g.Service.MapSetValue(ctx, &pb.MapSetValueRequest{
+		Map: g.Map,
+		Mutation: &pb.MapMutation{
+			Action:  "set_if_version",
+			Key:     key,
+			Value:   value,
+			Version: version,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &mapSetPromise{
+		Map: g,
+		MTL: resp.LeafHash,
+	}, nil
+}
+
+// Patch will generate a map mutation to apply the given JSON merge patch (RFC 7396) to the JSON value for
+// the given key. The new value is derived by the server, so this need not be retried under contention.
+// If the current value is not JSON, the mutation has no effect.
+// While this will return quickly, the change will be reflected asynchronously in the map.
+// Returns an AddEntryResponse which contains the leaf hash for the mutation log entry.
+func (g *Map) Patch(ctx context.Context, key []byte, patch []byte) (MapUpdatePromise, error) {
+	resp, err := g.Service.MapSetValue(ctx, &pb.MapSetValueRequest{
+		Map: g.Map,
+		Mutation: &pb.MapMutation{
+			Action: "patch",
+			Key:    key,
+			Patch:  patch,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &mapSetPromise{
+		Map: g,
+		MTL: resp.LeafHash,
+	}, nil
+}
+
+// Increment will generate a map mutation to add delta to the integer field in the JSON object value for
+// the given key, or to the value itself if field is empty. Absent values are treated as zero. The new value
+// is derived by the server, so this need not be retried under contention. If the current value is not an
+// integer, or the result would overflow a 64-bit integer, the mutation has no effect.
+// While this will return quickly, the change will be reflected asynchronously in the map.
+// Returns an AddEntryResponse which contains the leaf hash for the mutation log entry.
+func (g *Map) Increment(ctx context.Context, key []byte, field string, delta int64) (MapUpdatePromise, error) {
+	resp, err := g.Service.MapSetValue(ctx, &pb.MapSetValueRequest{
+		Map: g.Map,
+		Mutation: &pb.MapMutation{
+			Action: "increment",
+			Key:    key,
+			Field:  field,
+			Delta:  delta,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &mapSetPromise{
+		Map: g,
+		MTL: resp.LeafHash,
+	}, nil
+}
+
+// ApplyTransaction will generate a single map mutation that applies each of the given "set", "delete",
+// "update", "create", "compare_and_delete", "set_if_version", "patch" and "increment" mutations in order.
+// Either all are applied, or if the precondition for any conditional mutation does not hold, or any
+// derived value cannot be derived, none are.
+// While this will return quickly, the change will be reflected asynchronously in the map.
+// Returns a MapUpdatePromise for the mutation log entry.
+func (g *Map) ApplyTransaction(ctx context.Context, mutations []*pb.MapMutation) (MapUpdatePromise, error) {
+	resp, err := g.Service.MapApplyTransaction(ctx, &pb.MapApplyTransactionRequest{
+		Map:       g.Map,
+		Mutations: mutations,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &mapSetPromise{
+		Map: g,
+		MTL: resp.LeafHash,
+	}, nil
+}
+
+// ListLeaves returns a page of up to limit leaves (0 for the server maximum) in the map at the given tree size,
+// in key hash order, starting with startKeyHash (nil for the start of the map). The page includes the
+// NextKeyHash to pass to list the next page, and subtree hashes that prove no leaves were omitted.
+//
+// Most clients instead use VerifiedLeaves which additionally verifies each page.
+func (g *Map) ListLeaves(ctx context.Context, treeSize int64, startKeyHash []byte, limit int32) (*pb.MapListLeavesResponse, error) {
+	return g.Service.MapListLeaves(ctx, &pb.MapListLeavesRequest{
+		Map:          g.Map,
+		TreeSize:     treeSize,
+		StartKeyHash: startKeyHash,
+		Limit:        limit,
+	})
+}
+
+// Diff returns a page of up to limit (0 for the server maximum) keys, by key hash, whose value differs between the
+// map at fromSize (0 for the empty map) and at toSize, in key hash order, starting with startKeyHash (nil for the
+// start of the map). The page includes the NextKeyHash to pass to diff the next page, and subtree hashes that prove
+// no changes were omitted.
+//
+// Most clients instead use VerifiedDiff which additionally verifies each page.
+func (g *Map) Diff(ctx context.Context, fromSize, toSize int64, startKeyHash []byte, limit int32) (*pb.MapDiffResponse, error) {
+	return g.Service.MapDiff(ctx, &pb.MapDiffRequest{
+		Map:          g.Map,
+		FromSize:     fromSize,
+		ToSize:       toSize,
+		StartKeyHash: startKeyHash,
+		Limit:        limit,
+	})
+}
+
 // TreeHead returns map root hash for the map at the given tree size. Specify continusec.Head
 // to receive a root hash for the latest tree size.
 func (g *Map) TreeHead(ctx context.Context, treeSize int64) (*pb.MapTreeHashResponse, error) {
@@ -333,6 +716,79 @@ func (g *Map) TreeHead(ctx context.Context, treeSize int64) (*pb.MapTreeHashResp
 	})
 }
 
+// Hasher returns the hash strategy for the multimap, as set by the HashAlgorithm in the MultimapRef.
+// The same strategy is used for the log of values for each key.
+func (g *Multimap) Hasher() (merkle.Hasher, error) {
+	return HasherForAlgorithm(g.Multimap.HashAlgorithm)
+}
+
+// TreeHead returns the root hash for the multimap at the given tree size. Specify continusec.Head
+// to receive a root hash for the latest tree size.
+func (g *Multimap) TreeHead(ctx context.Context, treeSize int64) (*pb.MapTreeHashResponse, error) {
+	return g.Service.MultimapTreeHash(ctx, &pb.MultimapTreeHashRequest{
+		Multimap: g.Multimap,
+		TreeSize: treeSize,
+	})
+}
+
+// Add will send an API call to add the value to the list of values for the key. If the exact value
+// has already been added for the key, it will not be added a second time.
+// While this will return quickly, the change will be reflected asynchronously in the multimap.
+// The promise returned has the leaf hash of the value in the log of values for the key.
+func (g *Multimap) Add(ctx context.Context, key []byte, value *pb.LeafData) (MapUpdatePromise, error) {
+	resp, err := g.Service.MultimapAddValue(ctx, &pb.MultimapAddValueRequest{
+		Multimap: g.Multimap,
+		Key:      key,
+		Value:    value,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &multimapAddPromise{
+		Multimap: g,
+		Key:      key,
+		MTL:      resp.LeafHash,
+	}, nil
+}
+
+// Values will return each value for the given key at the given treeSize, in the order added, along with
+// proof of the tree head of the log of values. Pass continusec.Head to always get the latest values.
+//
+// Clients normally instead call VerifiedValues() as this will also perform verification.
+func (g *Multimap) Values(ctx context.Context, key []byte, treeSize int64) (*pb.MultimapGetValuesResponse, error) {
+	return g.Service.MultimapGetValues(ctx, &pb.MultimapGetValuesRequest{
+		Multimap: g.Multimap,
+		Key:      key,
+		TreeSize: treeSize,
+	})
+}
+
+// InclusionProof will return a proof that the value with the specified leaf hash is in the list of values
+// for the key at the given treeSize. Pass continusec.Head for the latest.
+//
+// Clients normally instead call VerifyInclusion() as this will also perform verification.
+func (g *Multimap) InclusionProof(ctx context.Context, key []byte, treeSize int64, leaf []byte) (*pb.MultimapInclusionProofResponse, error) {
+	return g.Service.MultimapInclusionProof(ctx, &pb.MultimapInclusionProofRequest{
+		Multimap: g.Multimap,
+		Key:      key,
+		TreeSize: treeSize,
+		MtlHash:  leaf,
+	})
+}
+
+// KeyConsistencyProof will return a proof that the list of values for the key at fromSize has only been
+// appended to at treeSize. Pass continusec.Head for treeSize for the latest.
+//
+// Clients normally instead call VerifyKeyConsistency() as this will also perform verification.
+func (g *Multimap) KeyConsistencyProof(ctx context.Context, key []byte, fromSize, treeSize int64) (*pb.MultimapKeyConsistencyProofResponse, error) {
+	return g.Service.MultimapKeyConsistencyProof(ctx, &pb.MultimapKeyConsistencyProofRequest{
+		Multimap: g.Multimap,
+		Key:      key,
+		FromSize: fromSize,
+		TreeSize: treeSize,
+	})
+}
+
 type mapSetPromise struct {
 	Map *Map
 	MTL []byte
@@ -350,6 +806,20 @@ func (p *mapSetPromise) Wait(ctx context.Context) (*pb.MapTreeHashResponse, erro
 	return p.Map.TreeHead(ctx, lth.TreeSize)
 }
 
+type multimapAddPromise struct {
+	Multimap *Multimap
+	Key      []byte
+	MTL      []byte
+}
+
+func (p *multimapAddPromise) LeafHash() []byte {
+	return p.MTL
+}
+
+func (p *multimapAddPromise) Wait(ctx context.Context) (*pb.MapTreeHashResponse, error) {
+	return p.Multimap.BlockUntilPresent(ctx, p.Key, p.MTL)
+}
+
 type logAddPromise struct {
 	Log *Log
 	MTL []byte
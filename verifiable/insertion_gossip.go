@@ -0,0 +1,77 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"crypto/sha256"
+
+	"golang.org/x/net/context"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/continusec/verifiabledatastructures/pb"
+)
+
+// applyAddInconsistentTreeHead keeps a gossiped tree head that is not consistent with our own log or map.
+// Each distinct tree head is kept only once. The size of the log is unchanged.
+func applyAddInconsistentTreeHead(ctx context.Context, db KeyWriter, sizeBefore int64, req *pb.InconsistentTreeHead) (int64, error) {
+	h, err := inconsistentTreeHeadHash(req)
+	if err != nil {
+		return 0, err
+	}
+
+	// Do we have it already?
+	_, err = lookupGossipIndexByHash(ctx, db, h)
+	switch err {
+	case nil:
+		return sizeBefore, nil
+	case ErrNoSuchKey:
+		// good, continue
+	default:
+		return 0, err
+	}
+
+	idx, err := readGossipSize(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+	err = writeInconsistentTreeHead(ctx, db, idx, req)
+	if err != nil {
+		return 0, err
+	}
+	err = writeGossipIndexByHash(ctx, db, h, &pb.EntryIndex{Index: idx})
+	if err != nil {
+		return 0, err
+	}
+	err = writeGossipSize(ctx, db, idx+1)
+	if err != nil {
+		return 0, err
+	}
+
+	return sizeBefore, nil
+}
+
+// inconsistentTreeHeadHash identifies a kept tree head, so that each is kept only once
+func inconsistentTreeHeadHash(ith *pb.InconsistentTreeHead) ([]byte, error) {
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(ith)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.Sum256(b)
+	return h[:], nil
+}
@@ -19,6 +19,7 @@ limitations under the License.
 package verifiable
 
 import (
+	"bytes"
 	"encoding/json"
 
 	"golang.org/x/net/context"
@@ -27,10 +28,10 @@ import (
 	"github.com/continusec/verifiabledatastructures/pb"
 )
 
-func writeOutLogTreeNodes(ctx context.Context, db KeyWriter, log *pb.LogRef, entryIndex int64, mtl []byte, stack [][]byte) ([]byte, error) {
+func writeOutLogTreeNodes(ctx context.Context, db KeyWriter, h merkle.Hasher, log *pb.LogRef, entryIndex int64, mtl []byte, stack [][]byte) ([]byte, error) {
 	stack = append(stack, mtl)
 	for zz, width := entryIndex, int64(2); (zz & 1) == 1; zz, width = zz>>1, width<<1 {
-		parN := merkle.NodeHash(stack[len(stack)-2], stack[len(stack)-1])
+		parN := h.NodeHash(stack[len(stack)-2], stack[len(stack)-1])
 		stack = append(stack[:len(stack)-2], parN)
 		err := writeTreeNodeByRange(ctx, db, log.LogType, entryIndex+1-width, entryIndex+1, &pb.TreeNode{Mth: parN})
 		if err != nil {
@@ -40,29 +41,48 @@ func writeOutLogTreeNodes(ctx context.Context, db KeyWriter, log *pb.LogRef, ent
 	// Collapse stack to get tree head
 	headHash := stack[len(stack)-1]
 	for z := len(stack) - 2; z >= 0; z-- {
-		headHash = merkle.NodeHash(stack[z], headHash)
+		headHash = h.NodeHash(stack[z], headHash)
 	}
 	return headHash, nil
 }
 
 // Must be idempotent, ie call it many times with same result.
 // return nil, nil if already exists
-func addEntryToLog(ctx context.Context, db KeyWriter, sizeBefore int64, log *pb.LogRef, data *pb.LeafData) (*pb.LogTreeHashResponse, error) {
+func addEntryToLog(ctx context.Context, db KeyWriter, h merkle.Hasher, sizeBefore int64, log *pb.LogRef, data *pb.LeafData) (*pb.LogTreeHashResponse, error) {
 	// First, calc our hash
-	mtl := merkle.LeafHash(data.LeafInput)
+	mtl := h.LeafHash(data.LeafInput)
 
 	// Now, see if we already have it stored
-	ei, err := lookupIndexByLeafHash(ctx, db, log.LogType, mtl)
+	have, err := hasLeafHashBefore(ctx, db, log.LogType, mtl, sizeBefore)
+	if err != nil {
+		return nil, err
+	}
+	if have {
+		return nil, nil
+	}
+	return appendEntryToLog(ctx, db, h, sizeBefore, log, data)
+}
+
+// hasLeafHashBefore returns whether the log has an entry with the leaf hash at an index before size
+func hasLeafHashBefore(ctx context.Context, db KeyReader, lt pb.LogType, mtl []byte, size int64) (bool, error) {
+	ei, err := lookupIndexByLeafHash(ctx, db, lt, mtl)
 	switch err {
 	case nil:
-		if ei.Index < sizeBefore {
-			// we already have it
-			return nil, nil
-		}
-		// else, we don't technically have it yet, so continue
+		// if not before size, we don't technically have it yet
+		return ei.Index < size, nil
 	case ErrNoSuchKey:
-		// good, continue
+		return false, nil
 	default:
+		return false, err
+	}
+}
+
+// appendEntryToLog adds the entry at index sizeBefore, even if the log already has it. An entry added
+// more than once is found by its leaf hash at the first index it was added at.
+func appendEntryToLog(ctx context.Context, db KeyWriter, h merkle.Hasher, sizeBefore int64, log *pb.LogRef, data *pb.LeafData) (*pb.LogTreeHashResponse, error) {
+	mtl := h.LeafHash(data.LeafInput)
+	have, err := hasLeafHashBefore(ctx, db, log.LogType, mtl, sizeBefore)
+	if err != nil {
 		return nil, err
 	}
 
@@ -79,9 +99,11 @@ func addEntryToLog(ctx context.Context, db KeyWriter, sizeBefore int64, log *pb.
 	}
 
 	// And our index by leaf hash
-	err = writeIndexByLeafHash(ctx, db, log.LogType, mtl, &pb.EntryIndex{Index: sizeBefore})
-	if err != nil {
-		return nil, err
+	if !have {
+		err = writeIndexByLeafHash(ctx, db, log.LogType, mtl, &pb.EntryIndex{Index: sizeBefore})
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	// Write out needed hashes
@@ -89,7 +111,7 @@ func addEntryToLog(ctx context.Context, db KeyWriter, sizeBefore int64, log *pb.
 	if err != nil {
 		return nil, err
 	}
-	rootHash, err := writeOutLogTreeNodes(ctx, db, log, sizeBefore, mtl, stack)
+	rootHash, err := writeOutLogTreeNodes(ctx, db, h, log, sizeBefore, mtl, stack)
 	if err != nil {
 		return nil, err
 	}
@@ -107,8 +129,24 @@ func addEntryToLog(ctx context.Context, db KeyWriter, sizeBefore int64, log *pb.
 }
 
 func applyLogAddEntry(ctx context.Context, db KeyWriter, sizeBefore int64, req *pb.LogAddEntryRequest) (int64, error) {
+	// Step 0 - the first entry fixes the config of the log or map.
+	// For all, this is the hash algorithm.
+	// For maps, it is also write-once, the VRF key, the sum field and whether it is a patricia trie.
+	// For logs, it is the reducer for the derived map, if any.
+	h, err := hasherForUpdate(ctx, db, sizeBefore, &pb.ObjectConfig{
+		HashAlgorithm: req.Log.HashAlgorithm,
+		WriteOnce:     req.Log.WriteOnce,
+		VrfPublicKey:  req.Log.VrfPublicKey,
+		SumField:      req.Log.SumField,
+		Reducer:       req.Log.Reducer,
+		PatriciaTrie:  req.Log.PatriciaTrie,
+	})
+	if err != nil {
+		return 0, err
+	}
+
 	// Step 1 - add entry to log as request
-	mutLogHead, err := addEntryToLog(ctx, db, sizeBefore, req.Log, req.Value)
+	mutLogHead, err := addEntryToLog(ctx, db, h, sizeBefore, req.Log, req.Value)
 	if err != nil {
 		return 0, err
 	}
@@ -118,28 +156,37 @@ func applyLogAddEntry(ctx context.Context, db KeyWriter, sizeBefore int64, req *
 		return sizeBefore, nil
 	}
 
+	switch {
 	// Special case mutation log for maps
-	if req.Log.LogType == pb.LogType_STRUCT_TYPE_MUTATION_LOG {
-		// Step 2 - add entries to map if needed
+	case req.Log.LogType == pb.LogType_STRUCT_TYPE_MUTATION_LOG:
 		var mut pb.MapMutation
 		err = json.Unmarshal(req.Value.ExtraData, &mut)
 		if err != nil {
 			return 0, err
 		}
-		mrh, err := setMapValue(ctx, db, mapForMutationLog(req.Log), sizeBefore, &mut)
+		err = applyMutationLogEntry(ctx, db, h, req.Log, sizeBefore, mutLogHead, &mut)
 		if err != nil {
 			return 0, err
 		}
 
-		// Step 3 - add entries to treehead log if neeed
-		thld, err := CreateJSONLeafDataFromProto(&pb.MapTreeHashResponse{
-			RootHash:    mrh,
-			MutationLog: mutLogHead,
-		})
+	// A log with a derived map adds the mutation derived from the entry to the map's mutation log, which
+	// is kept alongside, so that both always have the same size
+	case req.Log.Reducer != nil:
+		mut := reduceLogEntry(req.Log.Reducer, sizeBefore, req.Value)
+		mutData, err := CreateJSONLeafDataFromMutation(mut)
 		if err != nil {
 			return 0, err
 		}
-		_, err = addEntryToLog(ctx, db, sizeBefore, treeHeadLogForMutationLog(req.Log), thld)
+		mutLog := derivedMapLog(req.Log.Account, req.Log.Name, pb.LogType_STRUCT_TYPE_MUTATION_LOG, req.Log.HashAlgorithm)
+		mutLogHead, err := addEntryToLog(ctx, db, h, sizeBefore, mutLog, mutData)
+		if err != nil {
+			return 0, err
+		}
+		if mutLogHead == nil {
+			// Never the case, as each mutation has a different source index
+			return 0, ErrObjectConflict
+		}
+		err = applyMutationLogEntry(ctx, db, h, mutLog, sizeBefore, mutLogHead, mut)
 		if err != nil {
 			return 0, err
 		}
@@ -147,3 +194,91 @@ func applyLogAddEntry(ctx context.Context, db KeyWriter, sizeBefore int64, req *
 
 	return sizeBefore + 1, nil
 }
+
+// applyMutationLogEntry applies mut, just added to the mutation log for a map with the new head mutLogHead,
+// to the map, and adds the new map tree head to the tree head log.
+func applyMutationLogEntry(ctx context.Context, db KeyWriter, h merkle.Hasher, mutLog *pb.LogRef, sizeBefore int64, mutLogHead *pb.LogTreeHashResponse, mut *pb.MapMutation) error {
+	// Step 2 - add entries to map if needed
+	mrh, err := applyMapMutation(ctx, db, mapTreeHasher(h, mutLog.SumField), mapForMutationLog(mutLog), sizeBefore, mut)
+	if err != nil {
+		return err
+	}
+
+	// A patricia trie map has the root hash of its trie instead
+	if mutLog.PatriciaTrie {
+		mrh, err = applyPatriciaMutation(ctx, db, h, sizeBefore, mut)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Step 3 - add entries to treehead log if neeed
+	thld, err := CreateJSONLeafDataFromProto(&pb.MapTreeHashResponse{
+		RootHash:     mrh,
+		MutationLog:  mutLogHead,
+		VrfPublicKey: mutLog.VrfPublicKey,
+		SumField:     mutLog.SumField,
+		PatriciaTrie: mutLog.PatriciaTrie,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = addEntryToLog(ctx, db, h, sizeBefore, treeHeadLogForMutationLog(mutLog), thld)
+	return err
+}
+
+// applyLogAddEntries adds each entry in turn, so that the whole batch is sequenced in a single transaction.
+// As for a single entry, any already in the log are skipped, which LogAddEntries reports to the caller.
+func applyLogAddEntries(ctx context.Context, db KeyWriter, sizeBefore int64, req *pb.LogAddEntriesRequest) (int64, error) {
+	size := sizeBefore
+	for _, v := range req.Values {
+		var err error
+		size, err = applyLogAddEntry(ctx, db, size, &pb.LogAddEntryRequest{
+			Log:   req.Log,
+			Value: v,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+	return size, nil
+}
+
+// applyLogAddCosignedTreeHead stores a tree head along with its witness cosignatures. The size of
+// the log is unchanged.
+func applyLogAddCosignedTreeHead(ctx context.Context, db KeyWriter, sizeBefore int64, req *pb.LogAddCosignedTreeHead) (int64, error) {
+	// Only store heads that match our own, else we were called in error so quit early
+	if req.Head.TreeSize <= 0 || req.Head.TreeSize > sizeBefore {
+		return sizeBefore, nil
+	}
+	lth, err := lookupLogRootHashBySize(ctx, db, req.Log.LogType, req.Head.TreeSize)
+	if err != nil {
+		return 0, err
+	}
+	if !bytes.Equal(lth.Mth, req.Head.RootHash) {
+		return sizeBefore, nil
+	}
+
+	head := &pb.LogTreeHashResponse{
+		TreeSize:     req.Head.TreeSize,
+		RootHash:     req.Head.RootHash,
+		Cosignatures: req.Head.Cosignatures,
+	}
+	err = writeCosignedTreeHeadBySize(ctx, db, req.Log.LogType, head.TreeSize, head)
+	if err != nil {
+		return 0, err
+	}
+
+	latest, err := lookupLatestCosignedTreeHead(ctx, db, req.Log.LogType)
+	if err != nil {
+		return 0, err
+	}
+	if head.TreeSize > latest.TreeSize {
+		err = writeLatestCosignedTreeHead(ctx, db, req.Log.LogType, head)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return sizeBefore, nil
+}
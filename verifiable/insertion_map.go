@@ -26,23 +26,44 @@ import (
 	"github.com/continusec/verifiabledatastructures/pb"
 )
 
-var (
-	nullLeafHash = merkle.LeafHash([]byte{})
-)
+// nullLeafHash is the leaf hash of an empty value
+func nullLeafHash(h merkle.Hasher) []byte {
+	if sh, ok := h.(*sumTreeHasher); ok {
+		return sh.Hasher.DefaultLeafValue(256)
+	}
+	return h.DefaultLeafValue(256)
+}
+
+// mutationPreconditionHolds returns whether a conditional mutation should be applied to a key with
+// prevLeafHash, last changed at mutation index prevVersion (-1 if never). Unconditional mutations always hold.
+func mutationPreconditionHolds(h merkle.Hasher, mut *pb.MapMutation, prevLeafHash []byte, prevVersion int64) bool {
+	switch mut.Action {
+	case "update", "compare_and_delete":
+		return bytes.Equal(prevLeafHash, mut.PreviousLeafHash)
+	case "create":
+		return bytes.Equal(prevLeafHash, nullLeafHash(h))
+	case "set_if_version":
+		return prevVersion == mut.Version
+	default:
+		return true
+	}
+}
 
 // prevLeafHash must never be nil, it will often be nullLeafHash though
+// prevVersion is the mutation index at which the key last changed, or -1 if never
 // Never returns nil (except on err), always returns nullLeafHash instead
-func mutationLeafHash(mut *pb.MapMutation, prevLeafHash []byte) ([]byte, error) {
+func mutationLeafHash(h merkle.Hasher, mut *pb.MapMutation, prevLeafHash []byte, prevVersion int64) ([]byte, error) {
 	switch mut.Action {
-	case "set":
-		return merkle.LeafHash(mut.Value.LeafInput), nil
-	case "delete":
-		return nullLeafHash, nil
-	case "update":
-		if bytes.Equal(prevLeafHash, mut.PreviousLeafHash) {
-			return merkle.LeafHash(mut.Value.LeafInput), nil
-		}
-		return prevLeafHash, nil
+	case "set", "update", "create", "set_if_version":
+		if !mutationPreconditionHolds(h, mut, prevLeafHash, prevVersion) {
+			return prevLeafHash, nil
+		}
+		return h.LeafHash(mut.Value.LeafInput), nil
+	case "delete", "compare_and_delete":
+		if !mutationPreconditionHolds(h, mut, prevLeafHash, prevVersion) {
+			return prevLeafHash, nil
+		}
+		return nullLeafHash(h), nil
 	default:
 		return nil, ErrInvalidRequest
 	}
@@ -75,9 +96,9 @@ func descendToFork(ctx context.Context, db KeyReader, path BPath, root *pb.MapNo
 	}
 }
 
-func writeAncestors(ctx context.Context, db KeyWriter, last *pb.MapNode, ancestors []*pb.MapNode, keyPath BPath, mutationIndex int64) ([]byte, error) {
+func writeAncestors(ctx context.Context, db KeyWriter, h merkle.Hasher, last *pb.MapNode, ancestors []*pb.MapNode, keyPath BPath, mutationIndex int64) ([]byte, error) {
 	// Write out ancestor chain
-	curHash, err := calcNodeHash(last, uint(len(ancestors)))
+	curHash, err := calcNodeHash(h, last, uint(len(ancestors)))
 	if err != nil {
 		return nil, err
 	}
@@ -102,7 +123,7 @@ func writeAncestors(ctx context.Context, db KeyWriter, last *pb.MapNode, ancesto
 		if err != nil {
 			return nil, err
 		}
-		curHash, err = calcNodeHash(last, uint(i))
+		curHash, err = calcNodeHash(h, last, uint(i))
 		if err != nil {
 			return nil, err
 		}
@@ -110,15 +131,216 @@ func writeAncestors(ctx context.Context, db KeyWriter, last *pb.MapNode, ancesto
 	return curHash, nil
 }
 
-func isEmptyNode(mn *pb.MapNode) bool {
-	return ((len(mn.LeafHash) == 0) || bytes.Equal(mn.LeafHash, nullLeafHash)) && mn.LeftNumber == 0 && mn.RightNumber == 0
+// appendMapKeyHistory records that the leaf hash for keyPath changed at mutationIndex. A key changed more
+// than once by the same mutation is recorded once, with the last leaf hash.
+func appendMapKeyHistory(ctx context.Context, db KeyWriter, keyPath BPath, mutationIndex int64, leafHash []byte) error {
+	size, err := lookupMapKeyHistorySize(ctx, db, keyPath)
+	if err != nil {
+		return err
+	}
+	if size > 0 {
+		last, err := lookupMapKeyChange(ctx, db, keyPath, size-1)
+		if err != nil {
+			return err
+		}
+		if last.MutationIndex == mutationIndex {
+			return writeMapKeyChange(ctx, db, keyPath, size-1, &pb.MapKeyChange{
+				MutationIndex: mutationIndex,
+				LeafHash:      leafHash,
+			})
+		}
+	}
+	err = writeMapKeyChange(ctx, db, keyPath, size, &pb.MapKeyChange{
+		MutationIndex: mutationIndex,
+		LeafHash:      leafHash,
+	})
+	if err != nil {
+		return err
+	}
+	return writeMapKeyHistorySize(ctx, db, keyPath, size+1)
 }
 
-func setMapValue(ctx context.Context, db KeyWriter, vmap *pb.MapRef, mutationIndex int64, mut *pb.MapMutation) ([]byte, error) {
-	keyPath := BPathFromKey(mut.Key)
+// lookupMapKeyVersion returns the mutation index at which the leaf hash for keyPath last changed, or -1 if never
+func lookupMapKeyVersion(ctx context.Context, db KeyReader, keyPath BPath) (int64, error) {
+	size, err := lookupMapKeyHistorySize(ctx, db, keyPath)
+	if err != nil {
+		return 0, err
+	}
+	if size == 0 {
+		return -1, nil
+	}
+	last, err := lookupMapKeyChange(ctx, db, keyPath, size-1)
+	if err != nil {
+		return 0, err
+	}
+	return last.MutationIndex, nil
+}
 
-	// Get the root node for tree size, will never be nil
+// lookupMapValue returns the value with the given leaf hash, or nil if it is nullLeafHash
+func lookupMapValue(ctx context.Context, db KeyReader, h merkle.Hasher, leafHash []byte) (*pb.LeafData, error) {
+	if bytes.Equal(leafHash, nullLeafHash(h)) {
+		return nil, nil
+	}
+	return lookupDataByLeafHash(ctx, db, pb.LogType_STRUCT_TYPE_MUTATION_LOG, leafHash)
+}
+
+// resolveMapMutation returns mut, or if mut derives the value from the previous value with prevLeafHash,
+// the mutation to set the derived value. Returns false if the value cannot be derived.
+func resolveMapMutation(ctx context.Context, db KeyReader, h merkle.Hasher, mut *pb.MapMutation, prevLeafHash []byte) (*pb.MapMutation, bool, error) {
+	if !isDerivedMutation(mut) {
+		return mut, true, nil
+	}
+	prevValue, err := lookupMapValue(ctx, db, h, prevLeafHash)
+	if err != nil {
+		return nil, false, err
+	}
+	rv, ok := derivedMapMutation(ctx, mut, prevValue)
+	return rv, ok, nil
+}
+
+func isEmptyNode(h merkle.Hasher, mn *pb.MapNode) bool {
+	return ((len(mn.LeafHash) == 0) || bytes.Equal(mn.LeafHash, nullLeafHash(h))) && mn.LeftNumber == 0 && mn.RightNumber == 0
+}
+
+// applyMapMutation applies a mutation log entry to the map, returning the new root hash. The operations
+// in a transaction are applied in order if all of their preconditions hold, else none are. For a
+// write-once map, a mutation that would change a key with a non-empty value is rejected.
+func applyMapMutation(ctx context.Context, db KeyWriter, h merkle.Hasher, vmap *pb.MapRef, mutationIndex int64, mut *pb.MapMutation) ([]byte, error) {
+	writeOnce, err := readWriteOnce(ctx, db, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if mut.Action != "transaction" {
+		return setMapValue(ctx, db, h, vmap, writeOnce, mutationIndex, mutationIndex, mut)
+	}
+
+	ok, rejected, err := checkMapTransaction(ctx, db, h, writeOnce, mutationIndex, mut.Operations)
+	if err != nil {
+		return nil, err
+	}
+	if rejected {
+		err = writeMapRejection(ctx, db, mutationIndex)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !ok || len(mut.Operations) == 0 {
+		// Then we just need to re-write root with new sequence numbers
+		root, err := lookupMapHash(ctx, db, mutationIndex, BPathEmpty)
+		if err != nil {
+			return nil, err
+		}
+		err = writeMapHash(ctx, db, mutationIndex+1, nil, root)
+		if err != nil {
+			return nil, err
+		}
+		return calcNodeHash(h, root, 0)
+	}
+
+	// The first operation starts from the previous root, the rest from the root written by the one before
+	var rv []byte
+	rootNumber := mutationIndex
+	for _, op := range mut.Operations {
+		rv, err = setMapValue(ctx, db, h, vmap, writeOnce, rootNumber, mutationIndex, op)
+		if err != nil {
+			return nil, err
+		}
+		rootNumber = mutationIndex + 1
+	}
+	return rv, nil
+}
+
+// checkMapTransaction returns whether the precondition for every conditional op in ops holds, and
+// every derived value can be derived, when the ops are applied in order to the map at mutationIndex. For a write-once map, it also returns
+// whether any op is rejected for changing a key with a non-empty value.
+func checkMapTransaction(ctx context.Context, db KeyReader, h merkle.Hasher, writeOnce bool, mutationIndex int64, ops []*pb.MapMutation) (bool, bool, error) {
 	root, err := lookupMapHash(ctx, db, mutationIndex, BPathEmpty)
+	if err != nil {
+		return false, false, err
+	}
+
+	// Leaf hashes, versions and values as set by earlier operations in the transaction
+	pending := make(map[string][]byte)
+	pendingVersions := make(map[string]int64)
+	pendingValues := make(map[string]*pb.LeafData)
+	for _, op := range ops {
+		kh, err := mutationKeyHash(h, op)
+		if err != nil {
+			return false, false, err
+		}
+		keyPath := bPathFromKeyHash(kh)
+		prevLeafHash, ok := pending[string(keyPath)]
+		prevVersion := pendingVersions[string(keyPath)]
+		if !ok {
+			prevLeafHash, err = lookupMapLeafHash(ctx, db, h, keyPath, root)
+			if err != nil {
+				return false, false, err
+			}
+			prevVersion, err = lookupMapKeyVersion(ctx, db, keyPath)
+			if err != nil {
+				return false, false, err
+			}
+		}
+		if isDerivedMutation(op) {
+			prevValue, ok := pendingValues[string(keyPath)]
+			if !ok {
+				prevValue, err = lookupMapValue(ctx, db, h, prevLeafHash)
+				if err != nil {
+					return false, false, err
+				}
+			}
+			op, ok = derivedMapMutation(ctx, op, prevValue)
+			if !ok {
+				return false, false, nil
+			}
+		}
+		if !mutationPreconditionHolds(h, op, prevLeafHash, prevVersion) {
+			return false, false, nil
+		}
+		nextLeafHash, err := mutationLeafHash(h, op, prevLeafHash, prevVersion)
+		if err != nil {
+			return false, false, err
+		}
+		if writeOnce && writeOnceRejects(h, prevLeafHash, nextLeafHash) {
+			return false, true, nil
+		}
+		if !bytes.Equal(prevLeafHash, nextLeafHash) {
+			prevVersion = mutationIndex
+			pendingValues[string(keyPath)] = op.Value
+			if bytes.Equal(nextLeafHash, nullLeafHash(h)) {
+				pendingValues[string(keyPath)] = nil
+			}
+		}
+		pending[string(keyPath)] = nextLeafHash
+		pendingVersions[string(keyPath)] = prevVersion
+	}
+	return true, false, nil
+}
+
+// lookupMapLeafHash returns the leaf hash for keyPath in the map with the given root, or nullLeafHash if not set
+func lookupMapLeafHash(ctx context.Context, db KeyReader, h merkle.Hasher, keyPath BPath, root *pb.MapNode) ([]byte, error) {
+	head, _, err := descendToFork(ctx, db, keyPath, root)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(head.Path, keyPath) {
+		return head.LeafHash, nil
+	}
+	return nullLeafHash(h), nil
+}
+
+// setMapValue applies mut to the map with the root written at rootNumber, writing the new nodes at mutationIndex+1
+func setMapValue(ctx context.Context, db KeyWriter, h merkle.Hasher, vmap *pb.MapRef, writeOnce bool, rootNumber, mutationIndex int64, mut *pb.MapMutation) ([]byte, error) {
+	kh, err := mutationKeyHash(h, mut)
+	if err != nil {
+		return nil, err
+	}
+	keyPath := bPathFromKeyHash(kh)
+
+	// Get the root node for tree size, will never be nil
+	root, err := lookupMapHash(ctx, db, rootNumber, BPathEmpty)
 	if err != nil {
 		return nil, err
 	}
@@ -135,13 +357,35 @@ func setMapValue(ctx context.Context, db KeyWriter, vmap *pb.MapRef, mutationInd
 	if isMatch {
 		prevLeafHash = head.LeafHash
 	} else {
-		prevLeafHash = nullLeafHash
+		prevLeafHash = nullLeafHash(h)
 	}
-	nextLeafHash, err := mutationLeafHash(mut, prevLeafHash)
+	prevVersion, err := lookupMapKeyVersion(ctx, db, keyPath)
 	if err != nil {
 		return nil, err
 	}
 
+	// A value derived from the previous value is set as any other, unless it cannot be derived
+	mut, ok, err := resolveMapMutation(ctx, db, h, mut, prevLeafHash)
+	if err != nil {
+		return nil, err
+	}
+	nextLeafHash := prevLeafHash
+	if ok {
+		nextLeafHash, err = mutationLeafHash(h, mut, prevLeafHash, prevVersion)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Write-once maps never change a key once it has a value, so record that we didn't
+	if writeOnce && writeOnceRejects(h, prevLeafHash, nextLeafHash) {
+		err = writeMapRejection(ctx, db, mutationIndex)
+		if err != nil {
+			return nil, err
+		}
+		nextLeafHash = prevLeafHash
+	}
+
 	// Can we short-circuit since nothing changed?
 	if bytes.Equal(prevLeafHash, nextLeafHash) {
 		// Then we just need to re-write root with new sequence numbers
@@ -149,11 +393,16 @@ func setMapValue(ctx context.Context, db KeyWriter, vmap *pb.MapRef, mutationInd
 		if err != nil {
 			return nil, err
 		}
-		return calcNodeHash(root, 0)
+		return calcNodeHash(h, root, 0)
+	}
+
+	err = appendMapKeyHistory(ctx, db, keyPath, mutationIndex, nextLeafHash)
+	if err != nil {
+		return nil, err
 	}
 
 	// Time to start writing our data
-	if !bytes.Equal(nextLeafHash, nullLeafHash) {
+	if !bytes.Equal(nextLeafHash, nullLeafHash(h)) {
 		err = writeDataByLeafHash(ctx, db, pb.LogType_STRUCT_TYPE_MUTATION_LOG, nextLeafHash, mut.Value)
 		if err != nil {
 			return nil, err
@@ -161,16 +410,17 @@ func setMapValue(ctx context.Context, db KeyWriter, vmap *pb.MapRef, mutationInd
 	}
 
 	// OK, instead, is the leaf us exactly? If so, easy we just rewrite it.
-	if isMatch || isEmptyNode(head) {
+	if isMatch || isEmptyNode(h, head) {
 		last := &pb.MapNode{
 			LeafHash: nextLeafHash,
 			Path:     keyPath,
+			LeafSum:  mapLeafSum(h, mut.Value),
 		}
 		err = writeMapHash(ctx, db, mutationIndex+1, keyPath.Slice(0, uint(len(ancestors))), last)
 		if err != nil {
 			return nil, err
 		}
-		return writeAncestors(ctx, db, last, ancestors, keyPath, mutationIndex)
+		return writeAncestors(ctx, db, h, last, ancestors, keyPath, mutationIndex)
 	}
 
 	if len(head.LeafHash) == 0 { // node
@@ -184,7 +434,7 @@ func setMapValue(ctx context.Context, db KeyWriter, vmap *pb.MapRef, mutationInd
 		// Now we create a new parent with two children, us and the previous node.
 		// Was the previous node a leaf? (if not, we can skip the sibling bit)
 		// Start with writing the sibling
-		theirHash, err := calcNodeHash(head, uint(len(ancestors)+1))
+		theirHash, err := calcNodeHash(h, head, uint(len(ancestors)+1))
 		if err != nil {
 			return nil, err
 		}
@@ -213,25 +463,53 @@ func setMapValue(ctx context.Context, db KeyWriter, vmap *pb.MapRef, mutationInd
 	last := &pb.MapNode{
 		Path:     keyPath,
 		LeafHash: nextLeafHash,
+		LeafSum:  mapLeafSum(h, mut.Value),
 	}
 	err = writeMapHash(ctx, db, mutationIndex+1, keyPath.Slice(0, uint(len(ancestors))), last)
 	if err != nil {
 		return nil, err
 	}
-	return writeAncestors(ctx, db, last, ancestors, keyPath, mutationIndex)
+	return writeAncestors(ctx, db, h, last, ancestors, keyPath, mutationIndex)
 }
 
 func mapForMutationLog(m *pb.LogRef) *pb.MapRef {
 	return &pb.MapRef{
-		Account: m.Account,
-		Name:    m.Name,
+		Account:       m.Account,
+		Name:          m.Name,
+		HashAlgorithm: m.HashAlgorithm,
+		WriteOnce:     m.WriteOnce,
+		VrfKeys:       len(m.VrfPublicKey) != 0,
+		SumField:      m.SumField,
+		PatriciaTrie:  m.PatriciaTrie,
 	}
 }
 
 func treeHeadLogForMutationLog(m *pb.LogRef) *pb.LogRef {
 	return &pb.LogRef{
-		Account: m.Account,
-		Name:    m.Name,
-		LogType: pb.LogType_STRUCT_TYPE_TREEHEAD_LOG,
+		Account:       m.Account,
+		Name:          m.Name,
+		LogType:       pb.LogType_STRUCT_TYPE_TREEHEAD_LOG,
+		HashAlgorithm: m.HashAlgorithm,
+	}
+}
+
+func mutationLogForMap(m *pb.MapRef) *pb.LogRef {
+	return &pb.LogRef{
+		Account:       m.Account,
+		Name:          m.Name,
+		LogType:       pb.LogType_STRUCT_TYPE_MUTATION_LOG,
+		HashAlgorithm: m.HashAlgorithm,
+		WriteOnce:     m.WriteOnce,
+		SumField:      m.SumField,
+		PatriciaTrie:  m.PatriciaTrie,
+	}
+}
+
+func treeHeadLogForMap(m *pb.MapRef) *pb.LogRef {
+	return &pb.LogRef{
+		Account:       m.Account,
+		Name:          m.Name,
+		LogType:       pb.LogType_STRUCT_TYPE_TREEHEAD_LOG,
+		HashAlgorithm: m.HashAlgorithm,
 	}
 }
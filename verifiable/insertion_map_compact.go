@@ -0,0 +1,146 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"github.com/continusec/verifiabledatastructures/pb"
+	"golang.org/x/net/context"
+)
+
+// maxMapCompactSizes is the most tree sizes compacted by one mutation, so that a map with a long
+// history is compacted over a number of mutations rather than one very large one
+const maxMapCompactSizes = 100
+
+// mapSizeRetained returns whether proofs are kept for a map at size, when the latest size is latest
+func mapSizeRetained(policy *pb.MapRetentionPolicy, size, latest int64) bool {
+	if size > latest-mapKeepLast(policy) {
+		return true
+	}
+	return policy.GetEvery() > 0 && size%policy.GetEvery() == 0
+}
+
+// mapKeepLast returns the number of latest sizes to keep proofs for, which always includes the latest,
+// as it is needed to apply the next mutation
+func mapKeepLast(policy *pb.MapRetentionPolicy) int64 {
+	if policy.GetKeepLast() < 1 {
+		return 1
+	}
+	return policy.GetKeepLast()
+}
+
+// applyMapCompact deletes the map nodes that are only needed for proofs at tree sizes that the policy
+// does not retain. Sizes are compacted in order, each once, so a change of policy only applies to sizes
+// not yet compacted. The root node for each pruned size is kept, marked as pruned, so that its root hash
+// is still available.
+func applyMapCompact(ctx context.Context, db KeyWriter, sizeBefore int64, req *pb.MapCompact) (int64, error) {
+	state, err := readMapCompaction(ctx, db)
+	if err != nil {
+		return 0, err
+	}
+	if state.Size < 1 { // there are no nodes for size 0
+		state.Size = 1
+	}
+
+	last := sizeBefore - mapKeepLast(req.Policy)
+	if last >= state.Size+maxMapCompactSizes {
+		last = state.Size + maxMapCompactSizes - 1
+	}
+	if last < state.Size {
+		return sizeBefore, nil
+	}
+
+	for ; state.Size <= last; state.Size++ {
+		if mapSizeRetained(req.Policy, state.Size, sizeBefore) {
+			state.LastRetained = state.Size
+			continue
+		}
+		err = pruneMapSize(ctx, db, state.Size, state.LastRetained)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	err = writeMapCompaction(ctx, db, state)
+	if err != nil {
+		return 0, err
+	}
+	return sizeBefore, nil
+}
+
+// pruneMapSize deletes the nodes below the root for size that are not needed by the next size, nor by
+// lastRetained, the largest smaller size that is still needed. All sizes in between have been pruned,
+// and the sizes that refer to any one node are consecutive, so a node that neither refers to is only
+// needed by pruned sizes.
+func pruneMapSize(ctx context.Context, db KeyWriter, size, lastRetained int64) error {
+	root, err := lookupMapHash(ctx, db, size, BPathEmpty)
+	if err != nil {
+		return err
+	}
+	next, err := lookupMapHash(ctx, db, size+1, BPathEmpty)
+	if err != nil {
+		return err
+	}
+	err = pruneMapNodeChildren(ctx, db, root, next, BPathEmpty, lastRetained)
+	if err != nil {
+		return err
+	}
+	root.Pruned = true
+	return writeMapHash(ctx, db, size, BPathEmpty, root)
+}
+
+// pruneMapNodeChildren deletes the nodes below mn at path that are not also below next (which may be nil)
+// and were written after lastRetained. Nodes written earlier are needed by lastRetained, as are any below them.
+func pruneMapNodeChildren(ctx context.Context, db KeyWriter, mn, next *pb.MapNode, path BPath, lastRetained int64) error {
+	for _, c := range []struct {
+		right      bool
+		number     int64
+		nextNumber int64
+	}{
+		{right: false, number: mn.LeftNumber, nextNumber: next.GetLeftNumber()},
+		{right: true, number: mn.RightNumber, nextNumber: next.GetRightNumber()},
+	} {
+		if c.number <= lastRetained || c.number == c.nextNumber {
+			continue
+		}
+		childPath := BPathJoin(path, BPathFalse)
+		if c.right {
+			childPath = BPathJoin(path, BPathTrue)
+		}
+		child, err := lookupMapHash(ctx, db, c.number, childPath)
+		if err != nil {
+			return err
+		}
+		var nextChild *pb.MapNode
+		if c.nextNumber != 0 {
+			nextChild, err = lookupMapHash(ctx, db, c.nextNumber, childPath)
+			if err != nil {
+				return err
+			}
+		}
+		err = pruneMapNodeChildren(ctx, db, child, nextChild, childPath, lastRetained)
+		if err != nil {
+			return err
+		}
+		err = deleteMapHash(ctx, db, c.number, childPath)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -29,3 +29,10 @@ type MutatorService interface {
 	// QueueMutation requests an asynchronous mutation in a namespace.
 	QueueMutation(ctx context.Context, namespace []byte, mut *pb.Mutation) error
 }
+
+// UpdateNotifier may optionally be implemented by a MutatorService that is able to signal when
+// mutations have been committed. If not implemented, watchers fall back to polling.
+type UpdateNotifier interface {
+	// Updated returns a channel that is closed after the next update to the namespace is committed.
+	Updated(namespace []byte) <-chan struct{}
+}
@@ -20,7 +20,10 @@ package verifiable
 
 import (
 	"context"
+	"crypto"
+	"crypto/ed25519"
 	"log"
+	"sync"
 
 	"github.com/continusec/verifiabledatastructures/pb"
 )
@@ -39,12 +42,42 @@ type Service struct {
 
 	// Reader points to the underlying data that we can read from
 	Reader StorageReader
+
+	// Signer, if set, is used to sign all log and map tree heads returned. Must be an Ed25519 or ECDSA P-256 key.
+	Signer crypto.Signer
+
+	// Witnesses, if set, are asked in the background to cosign log tree heads after entries are added
+	Witnesses []pb.WitnessServiceServer
+
+	// WitnessQuorum is the number of witness cosignatures needed for a tree head to be considered
+	// cosigned. If zero, all witnesses must cosign.
+	WitnessQuorum int
+
+	// MapRetention, if set, determines the tree sizes that map proofs are kept for. After each map
+	// mutation, the nodes only needed for other sizes are deleted, and requests for proofs at those
+	// sizes fail. Root hashes remain available for all sizes.
+	MapRetention *pb.MapRetentionPolicy
+
+	// VRFKey, if set, is used to derive the path for each key in maps with VRF keys, so that proofs do
+	// not reveal which keys exist. It must not change once such a map has been added to.
+	VRFKey ed25519.PrivateKey
+
+	// DerivedMaps, if set, are maps that are derived from the entries added to a log. Each entry is reduced
+	// to a mutation that is added to the map along with it, and the map cannot otherwise be added to.
+	DerivedMaps []*pb.DerivedMap
+
+	// cosignLocks has a mutex for each log namespace, held while collecting cosignatures for it
+	cosignLocks sync.Map
 }
 
 type localServiceImpl Service
 
 // Create returns a low-level API object to interact with the specified service
 func (l *Service) Create() (pb.VerifiableDataStructuresServiceServer, error) {
+	err := validateDerivedMaps(l.DerivedMaps)
+	if err != nil {
+		return nil, err
+	}
 	return (*localServiceImpl)(l), nil
 }
 
@@ -62,6 +95,16 @@ func ApplyMutation(ctx context.Context, db KeyWriter, sizeBefore int64, mut *pb.
 	switch {
 	case mut.LogAddEntry != nil:
 		return applyLogAddEntry(ctx, db, sizeBefore, mut.LogAddEntry)
+	case mut.LogAddEntries != nil:
+		return applyLogAddEntries(ctx, db, sizeBefore, mut.LogAddEntries)
+	case mut.LogAddCosignedTreeHead != nil:
+		return applyLogAddCosignedTreeHead(ctx, db, sizeBefore, mut.LogAddCosignedTreeHead)
+	case mut.AddInconsistentTreeHead != nil:
+		return applyAddInconsistentTreeHead(ctx, db, sizeBefore, mut.AddInconsistentTreeHead)
+	case mut.MapCompact != nil:
+		return applyMapCompact(ctx, db, sizeBefore, mut.MapCompact)
+	case mut.MultimapAddValue != nil:
+		return applyMultimapAddValue(ctx, db, sizeBefore, mut.MultimapAddValue)
 	default:
 		return 0, ErrNotImplemented
 	}
@@ -0,0 +1,214 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"bytes"
+
+	"github.com/continusec/verifiabledatastructures/pb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Gossip accepts tree heads observed by clients, and checks each against our own logs and maps.
+// Those that are shown not to be consistent are returned, and are kept as evidence if the caller
+// may write to the log or map. Tree heads larger than our own cannot be checked yet, so are ignored.
+func (s *localServiceImpl) Gossip(ctx context.Context, req *pb.GossipRequest) (*pb.GossipResponse, error) {
+	rv := &pb.GossipResponse{}
+	for _, g := range req.Logs {
+		if g.Log == nil || g.Log.Account == nil {
+			return nil, status.Errorf(codes.InvalidArgument, "log must be specified")
+		}
+		ns, err := s.logBucket(g.Log)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "unknown err: %s", err)
+		}
+		var ok bool
+		err = s.Reader.ExecuteReadOnly(ctx, ns, func(ctx context.Context, kr KeyReader) error {
+			ok, err = readLogHeadConsistent(ctx, kr, g.Log, g.Head)
+			return err
+		})
+		if err != nil {
+			return nil, gossipError(err)
+		}
+		if ok {
+			continue
+		}
+
+		_, err = s.verifyAccessForLogOperation(ctx, g.Log, operationRawAdd)
+		if err == nil {
+			err = s.keepInconsistentTreeHead(ctx, ns, &pb.InconsistentTreeHead{Log: &pb.LogGossip{
+				Log: &pb.LogRef{
+					Account: &pb.AccountRef{Id: g.Log.Account.Id}, // don't keep the API key
+					Name:    g.Log.Name,
+					LogType: g.Log.LogType,
+				},
+				Head: g.Head,
+			}})
+			if err != nil {
+				return nil, err
+			}
+		}
+		rv.InconsistentLogs = append(rv.InconsistentLogs, g)
+	}
+
+	for _, g := range req.Maps {
+		if g.Map == nil || g.Map.Account == nil {
+			return nil, status.Errorf(codes.InvalidArgument, "map must be specified")
+		}
+		ns, err := s.mapBucket(g.Map)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "unknown err: %s", err)
+		}
+		var ok bool
+		err = s.Reader.ExecuteReadOnly(ctx, ns, func(ctx context.Context, kr KeyReader) error {
+			ok, err = readMapStateConsistent(ctx, kr, g)
+			return err
+		})
+		if err != nil {
+			return nil, gossipError(err)
+		}
+		if ok {
+			continue
+		}
+
+		_, err = s.verifyAccessForMap(ctx, g.Map, pb.Permission_PERM_MAP_SET_VALUE)
+		if err == nil {
+			err = s.keepInconsistentTreeHead(ctx, ns, &pb.InconsistentTreeHead{Map: &pb.MapGossip{
+				Map: &pb.MapRef{
+					Account: &pb.AccountRef{Id: g.Map.Account.Id}, // don't keep the API key
+					Name:    g.Map.Name,
+				},
+				MapHead:         g.MapHead,
+				TreeHeadLogHead: g.TreeHeadLogHead,
+			}})
+			if err != nil {
+				return nil, err
+			}
+		}
+		rv.InconsistentMaps = append(rv.InconsistentMaps, g)
+	}
+
+	return rv, nil
+}
+
+func gossipError(err error) error {
+	_, ok := status.FromError(err)
+	if !ok {
+		err = status.Errorf(codes.Internal, "unknown err: %s", err)
+	}
+	return err
+}
+
+// keepInconsistentTreeHead queues the evidence to be kept, unless we have kept it already
+func (s *localServiceImpl) keepInconsistentTreeHead(ctx context.Context, ns []byte, ith *pb.InconsistentTreeHead) error {
+	if s.Mutator == nil { // we are readonly, so can only report these
+		return nil
+	}
+	h, err := inconsistentTreeHeadHash(ith)
+	if err != nil {
+		return status.Errorf(codes.Internal, "unknown err: %s", err)
+	}
+	kept := false
+	err = s.Reader.ExecuteReadOnly(ctx, ns, func(ctx context.Context, kr KeyReader) error {
+		_, err := lookupGossipIndexByHash(ctx, kr, h)
+		switch err {
+		case nil:
+			kept = true
+			return nil
+		case ErrNoSuchKey:
+			return nil
+		default:
+			return err
+		}
+	})
+	if err != nil {
+		return gossipError(err)
+	}
+	if kept {
+		return nil
+	}
+	err = s.Mutator.QueueMutation(ctx, ns, &pb.Mutation{
+		AddInconsistentTreeHead: ith,
+	})
+	if err != nil {
+		return status.Errorf(codes.Internal, "error queuing mutation: %s", err)
+	}
+	return nil
+}
+
+// readLogHeadConsistent returns false only if a tree head is shown by a consistency proof not to be
+// consistent with our own copy of the log. Tree heads larger than ours can't be checked yet.
+func readLogHeadConsistent(ctx context.Context, kr KeyReader, log *pb.LogRef, head *pb.LogTreeHashResponse) (bool, error) {
+	// Nothing to check
+	if head == nil || head.TreeSize == 0 {
+		return true, nil
+	}
+
+	ours, err := readLogTreeHash(ctx, kr, log.LogType, 0)
+	if err != nil {
+		return false, err
+	}
+
+	switch {
+	case head.TreeSize > ours.TreeSize:
+		return true, nil
+	case head.TreeSize == ours.TreeSize:
+		return bytes.Equal(head.RootHash, ours.RootHash), nil
+	}
+
+	proof, err := readLogConsistencyProof(ctx, kr, log, head.TreeSize, ours.TreeSize)
+	if err != nil {
+		return false, err
+	}
+
+	return VerifyLogConsistencyProof(proof, head, ours) == nil, nil
+}
+
+// readMapStateConsistent returns false only if a map tree state is shown not to be consistent with
+// our own copy of the map.
+func readMapStateConsistent(ctx context.Context, kr KeyReader, g *pb.MapGossip) (bool, error) {
+	mutLog := mutationLogForMap(g.Map)
+	ok, err := readLogHeadConsistent(ctx, kr, mutLog, g.GetMapHead().GetMutationLog())
+	if err != nil || !ok {
+		return ok, err
+	}
+
+	// If the mutation log is consistent, we also need the same map root hash for that size
+	treeSize := g.GetMapHead().GetMutationLog().GetTreeSize()
+	if treeSize != 0 {
+		th, err := readLogTreeHash(ctx, kr, pb.LogType_STRUCT_TYPE_TREEHEAD_LOG, 0)
+		if err != nil {
+			return false, err
+		}
+		if treeSize > th.TreeSize {
+			return true, nil
+		}
+		ours, err := readMapTreeHash(ctx, kr, g.Map, treeSize)
+		if err != nil {
+			return false, err
+		}
+		if !bytes.Equal(ours.RootHash, g.MapHead.RootHash) {
+			return false, nil
+		}
+	}
+
+	return readLogHeadConsistent(ctx, kr, treeHeadLogForMutationLog(mutLog), g.TreeHeadLogHead)
+}
@@ -0,0 +1,132 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"github.com/continusec/verifiabledatastructures/pb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// LogAddEntries adds a batch of entries to a log, as a single mutation
+func (s *localServiceImpl) LogAddEntries(ctx context.Context, req *pb.LogAddEntriesRequest) (*pb.LogAddEntriesResponse, error) {
+	_, err := s.verifyAccessForLogOperation(ctx, req.Log, operationRawAdd)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "no access: %s", err)
+	}
+
+	if req.Log.LogType != pb.LogType_STRUCT_TYPE_LOG {
+		return nil, status.Errorf(codes.InvalidArgument, "wrong log type")
+	}
+
+	if len(req.Values) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "no entries")
+	}
+	for _, v := range req.Values {
+		if v == nil {
+			return nil, status.Errorf(codes.InvalidArgument, "nil entry")
+		}
+	}
+
+	ns, err := s.logBucket(req.Log)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "extra getting bucket: %s", err)
+	}
+
+	h, err := s.checkHashAlgorithm(ctx, ns, req.Log.HashAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only we can say which map is derived from the log, so replace any reducer we were given
+	log, err := s.logForUpdate(ctx, ns, req.Log)
+	if err != nil {
+		return nil, err
+	}
+	req = &pb.LogAddEntriesRequest{
+		Log:    log,
+		Values: req.Values,
+	}
+
+	// Check before queuing, as the mutation may be applied straight away
+	rv := make([][]byte, len(req.Values))
+	for i, v := range req.Values {
+		rv[i] = h.LeafHash(v.LeafInput)
+	}
+	present, err := s.readAlreadyPresent(ctx, ns, rv)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.Mutator.QueueMutation(ctx, ns, &pb.Mutation{
+		LogAddEntries: req,
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "error queuing mutation: %s", err)
+	}
+
+	if dm := s.derivedMapForLog(log); dm != nil {
+		err = s.queueMapCompaction(ctx, ns, &pb.MapRef{
+			Account: log.Account,
+			Name:    dm.Map,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s.cosignAfterAdding(ns, log, rv)
+
+	return &pb.LogAddEntriesResponse{
+		LeafHashes:     rv,
+		AlreadyPresent: present,
+	}, nil
+}
+
+// readAlreadyPresent returns, for each leaf hash, whether it is already in the log or earlier in the
+// list, so will not be added again. Entries being added concurrently may not yet be seen.
+func (s *localServiceImpl) readAlreadyPresent(ctx context.Context, ns []byte, leafHashes [][]byte) ([]bool, error) {
+	rv := make([]bool, len(leafHashes))
+	seen := make(map[string]bool)
+	err := s.Reader.ExecuteReadOnly(ctx, ns, func(ctx context.Context, kr KeyReader) error {
+		for i, lh := range leafHashes {
+			if seen[string(lh)] {
+				rv[i] = true
+				continue
+			}
+			seen[string(lh)] = true
+
+			_, err := lookupIndexByLeafHash(ctx, kr, pb.LogType_STRUCT_TYPE_LOG, lh)
+			switch err {
+			case nil:
+				rv[i] = true
+			case ErrNoSuchKey:
+				// good, will be added
+			default:
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unknown err: %s", err)
+	}
+	return rv, nil
+}
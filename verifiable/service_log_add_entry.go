@@ -19,7 +19,6 @@ limitations under the License.
 package verifiable
 
 import (
-	"github.com/continusec/verifiabledatastructures/merkle"
 	"github.com/continusec/verifiabledatastructures/pb"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc/codes"
@@ -37,11 +36,26 @@ func (s *localServiceImpl) LogAddEntry(ctx context.Context, req *pb.LogAddEntryR
 		return nil, status.Errorf(codes.InvalidArgument, "wrong log type")
 	}
 
-	ns, err := logBucket(req.Log)
+	ns, err := s.logBucket(req.Log)
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "extra getting bucket: %s", err)
 	}
 
+	h, err := s.checkHashAlgorithm(ctx, ns, req.Log.HashAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only we can say which map is derived from the log, so replace any reducer we were given
+	log, err := s.logForUpdate(ctx, ns, req.Log)
+	if err != nil {
+		return nil, err
+	}
+	req = &pb.LogAddEntryRequest{
+		Log:   log,
+		Value: req.Value,
+	}
+
 	err = s.Mutator.QueueMutation(ctx, ns, &pb.Mutation{
 		LogAddEntry: req,
 	})
@@ -49,7 +63,20 @@ func (s *localServiceImpl) LogAddEntry(ctx context.Context, req *pb.LogAddEntryR
 		return nil, status.Errorf(codes.Internal, "error queuing mutation: %s", err)
 	}
 
+	if dm := s.derivedMapForLog(log); dm != nil {
+		err = s.queueMapCompaction(ctx, ns, &pb.MapRef{
+			Account: log.Account,
+			Name:    dm.Map,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	lh := h.LeafHash(req.Value.LeafInput)
+	s.cosignAfterAdding(ns, log, [][]byte{lh})
+
 	return &pb.LogAddEntryResponse{
-		LeafHash: merkle.LeafHash(req.Value.LeafInput),
+		LeafHash: lh,
 	}, nil
 }
@@ -0,0 +1,216 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"bytes"
+	"slices"
+
+	"github.com/continusec/verifiabledatastructures/merkle"
+	"github.com/continusec/verifiabledatastructures/pb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// LogBatchInclusionProof returns a single proof for the inclusion of many leaves
+func (s *localServiceImpl) LogBatchInclusionProof(ctx context.Context, req *pb.LogBatchInclusionProofRequest) (*pb.LogBatchInclusionProofResponse, error) {
+	_, err := s.verifyAccessForLogOperation(ctx, req.Log, operationProveInclusion)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "no access: %s", err)
+	}
+
+	if req.TreeSize < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "bad tree size")
+	}
+
+	if len(req.MtlHashes) == 0 && len(req.LeafIndices) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "no leaves")
+	}
+
+	var rv *pb.LogBatchInclusionProofResponse
+	ns, err := s.logBucket(req.Log)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "extra getting bucket: %s", err)
+	}
+	err = s.Reader.ExecuteReadOnly(ctx, ns, func(ctx context.Context, kr KeyReader) error {
+		rv, err = readLogBatchInclusionProof(ctx, kr, req.Log, req.TreeSize, req.LeafIndices, req.MtlHashes)
+		return err
+	})
+	if err != nil {
+		_, ok := status.FromError(err)
+		if !ok {
+			err = status.Errorf(codes.Internal, "unknown err: %s", err)
+		}
+		return nil, err
+	}
+	return rv, nil
+}
+
+// readLogBatchInclusionProof returns a proof for the leaves at leafIndices, or with hashes mtlHashes if set,
+// in the log of the given size, or the latest if zero.
+func readLogBatchInclusionProof(ctx context.Context, kr KeyReader, log *pb.LogRef, treeSize int64, leafIndices []int64, mtlHashes [][]byte) (*pb.LogBatchInclusionProofResponse, error) {
+	head, err := lookupLogTreeHead(ctx, kr, log.LogType)
+	if err != nil {
+		return nil, err
+	}
+
+	if treeSize == 0 {
+		treeSize = head.TreeSize
+	}
+
+	if treeSize > head.TreeSize {
+		return nil, status.Errorf(codes.InvalidArgument, "bad tree size")
+	}
+
+	if len(mtlHashes) != 0 {
+		// Then we must fetch the indices
+		leafIndices = make([]int64, len(mtlHashes))
+		for i, mtlHash := range mtlHashes {
+			ei, err := lookupIndexByLeafHash(ctx, kr, log.LogType, mtlHash)
+			switch err {
+			case nil:
+				// good, continue
+			case ErrNoSuchKey:
+				return nil, status.Errorf(codes.NotFound, "cannot find it")
+			default:
+				return nil, err
+			}
+			leafIndices[i] = ei.Index
+		}
+	}
+
+	for _, leafIndex := range leafIndices {
+		// As for LogInclusionProof, NotFound if not yet completely written
+		if leafIndex < 0 || leafIndex >= head.TreeSize {
+			return nil, status.Errorf(codes.NotFound, "cannot find it")
+		}
+
+		// Client needs a new STH
+		if leafIndex >= treeSize {
+			return nil, status.Errorf(codes.InvalidArgument, "bad tree size")
+		}
+	}
+
+	alg, h, err := readHashAlgorithm(ctx, kr, log.HashAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	ranges := merkle.BatchPath(leafIndices, treeSize)
+	hashes, err := fetchPathHashes(ctx, kr, h, log.LogType, ranges)
+	if err != nil {
+		return nil, err
+	}
+
+	path := make([]*pb.LogSubTreeHash, len(ranges))
+	for i, rr := range ranges {
+		path[i] = &pb.LogSubTreeHash{
+			Start: rr[0],
+			End:   rr[1],
+			Hash:  hashes[i],
+		}
+	}
+
+	return &pb.LogBatchInclusionProofResponse{
+		TreeSize:      treeSize,
+		LeafIndices:   leafIndices,
+		AuditPath:     path,
+		HashAlgorithm: alg,
+	}, nil
+}
+
+// VerifyLogBatchInclusionProof verifies a batch inclusion proof against a LogTreeHead, using the hash algorithm
+// given in the proof. leafHashes must correspond, in order, to the LeafIndices in the proof, and must have been
+// calculated with the same algorithm. Every subtree hash in the proof must be used.
+func VerifyLogBatchInclusionProof(self *pb.LogBatchInclusionProofResponse, leafHashes [][]byte, head *pb.LogTreeHashResponse) error {
+	h, err := HasherForAlgorithm(self.HashAlgorithm)
+	if err != nil {
+		return err
+	}
+	if self.TreeSize != head.TreeSize {
+		return ErrVerificationFailed
+	}
+	if len(leafHashes) == 0 || len(leafHashes) != len(self.LeafIndices) {
+		return ErrVerificationFailed
+	}
+
+	leaves := make(map[int64][]byte)
+	for i, leafIndex := range self.LeafIndices {
+		if leafIndex < 0 || leafIndex >= self.TreeSize {
+			return ErrVerificationFailed
+		}
+		prev, ok := leaves[leafIndex]
+		if ok && !bytes.Equal(prev, leafHashes[i]) {
+			return ErrVerificationFailed
+		}
+		leaves[leafIndex] = leafHashes[i]
+	}
+	sorted := make([]int64, 0, len(leaves))
+	for leafIndex := range leaves {
+		sorted = append(sorted, leafIndex)
+	}
+	slices.Sort(sorted)
+
+	supplied := make(map[[2]int64][]byte)
+	for _, p := range self.AuditPath {
+		supplied[[2]int64{p.Start, p.End}] = p.Hash
+	}
+
+	// Calculate the hash for [start, end), returning nil if we can't
+	used := 0
+	var calc func(start, end int64) []byte
+	calc = func(start, end int64) []byte {
+		// Subtrees containing a leaf we are proving must be calculated, never supplied
+		i, _ := slices.BinarySearch(sorted, start)
+		if i == len(sorted) || sorted[i] >= end {
+			rv := supplied[[2]int64{start, end}]
+			if len(rv) == 0 {
+				return nil
+			}
+			used++
+			return rv
+		}
+		if end-start == 1 {
+			return leaves[start]
+		}
+		k := merkle.CalcK(end - start)
+		l, r := calc(start, start+k), calc(start+k, end)
+		if l == nil || r == nil {
+			return nil
+		}
+		return h.NodeHash(l, r)
+	}
+
+	r := calc(0, self.TreeSize)
+	if r == nil || used != len(self.AuditPath) {
+		return ErrVerificationFailed
+	}
+	if !bytes.Equal(r, head.RootHash) {
+		return ErrVerificationFailed
+	}
+
+	// should not happen, but guarding anyway
+	if len(r) != 32 {
+		return ErrVerificationFailed
+	}
+
+	// all clear
+	return nil
+}
@@ -0,0 +1,64 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"github.com/continusec/verifiabledatastructures/pb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// LogCheckpoint returns the log tree hash as a checkpoint, signed by the server
+func (s *localServiceImpl) LogCheckpoint(ctx context.Context, req *pb.LogCheckpointRequest) (*pb.LogCheckpointResponse, error) {
+	if s.Signer == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "no signing key configured")
+	}
+
+	// Access is checked by LogTreeHash
+	head, err := s.LogTreeHash(ctx, &pb.LogTreeHashRequest{
+		Log:      req.Log,
+		TreeSize: req.TreeSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// An empty log has the root hash of an empty tree, which is the hash of nothing (RFC 6962)
+	if head.TreeSize == 0 {
+		h, err := HasherForAlgorithm(req.Log.HashAlgorithm)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "unsupported hash algorithm: %s", err)
+		}
+		head.RootHash = h.KeyHash(nil)
+		head.Signature, err = signTreeHeadText(s.Signer, LogTreeHeadText(req.Log, head))
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "error signing tree head: %s", err)
+		}
+	}
+
+	cp, err := FormatCheckpoint(req.Log, head, s.Signer.Public())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "error formatting checkpoint: %s", err)
+	}
+
+	return &pb.LogCheckpointResponse{
+		Checkpoint: cp,
+	}, nil
+}
@@ -44,51 +44,13 @@ func (s *localServiceImpl) LogConsistencyProof(ctx context.Context, req *pb.LogC
 	}
 
 	var rv *pb.LogConsistencyProofResponse
-	ns, err := logBucket(req.Log)
+	ns, err := s.logBucket(req.Log)
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "extra getting bucket: %s", err)
 	}
 	err = s.Reader.ExecuteReadOnly(ctx, ns, func(ctx context.Context, kr KeyReader) error {
-		head, err := lookupLogTreeHead(ctx, kr, req.Log.LogType)
-		if err != nil {
-			return err
-		}
-		second := req.TreeSize
-		if second == 0 {
-			second = head.TreeSize
-		}
-
-		if second <= 0 || second > head.TreeSize {
-			return status.Errorf(codes.InvalidArgument, "tree size out of range")
-		}
-		if req.FromSize >= second {
-			return status.Errorf(codes.InvalidArgument, "tree size out of range")
-		}
-
-		// Ranges are good
-		ranges := merkle.SubProof(req.FromSize, 0, second, true)
-		path, err := fetchSubTreeHashes(ctx, kr, req.Log.LogType, ranges, false)
-		if err != nil {
-			return err
-		}
-		for i, rr := range ranges {
-			if len(path[i]) == 0 {
-				if merkle.IsPow2(rr[1] - rr[0]) {
-					// Would have been nice if GetSubTreeHashes could better handle these
-					return ErrNoSuchKey
-				}
-				path[i], err = calcSubTreeHash(ctx, kr, req.Log.LogType, rr[0], rr[1])
-				if err != nil {
-					return err
-				}
-			}
-		}
-		rv = &pb.LogConsistencyProofResponse{
-			FromSize:  req.FromSize,
-			TreeSize:  second,
-			AuditPath: path,
-		}
-		return nil
+		rv, err = readLogConsistencyProof(ctx, kr, req.Log, req.FromSize, req.TreeSize)
+		return err
 	})
 	if err != nil {
 		_, ok := status.FromError(err)
@@ -100,8 +62,76 @@ func (s *localServiceImpl) LogConsistencyProof(ctx context.Context, req *pb.LogC
 	return rv, nil
 }
 
-// VerifyLogConsistencyProof will verify that the consistency proof stored in this object can produce both the LogTreeHeads passed to this method.
+// readLogConsistencyProof returns a consistency proof between fromSize and the given size of the log,
+// or the latest if zero.
+func readLogConsistencyProof(ctx context.Context, kr KeyReader, log *pb.LogRef, fromSize, treeSize int64) (*pb.LogConsistencyProofResponse, error) {
+	head, err := lookupLogTreeHead(ctx, kr, log.LogType)
+	if err != nil {
+		return nil, err
+	}
+	second := treeSize
+	if second == 0 {
+		second = head.TreeSize
+	}
+
+	if second <= 0 || second > head.TreeSize {
+		return nil, status.Errorf(codes.InvalidArgument, "tree size out of range")
+	}
+	if fromSize >= second {
+		return nil, status.Errorf(codes.InvalidArgument, "tree size out of range")
+	}
+
+	alg, h, err := readHashAlgorithm(ctx, kr, log.HashAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ranges are good
+	ranges := merkle.SubProof(fromSize, 0, second, true)
+	path, err := fetchSubTreeHashes(ctx, kr, log.LogType, ranges, false)
+	if err != nil {
+		return nil, err
+	}
+	for i, rr := range ranges {
+		if len(path[i]) == 0 {
+			if merkle.IsPow2(rr[1] - rr[0]) {
+				// Would have been nice if GetSubTreeHashes could better handle these
+				return nil, ErrNoSuchKey
+			}
+			path[i], err = calcSubTreeHash(ctx, kr, h, log.LogType, rr[0], rr[1])
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &pb.LogConsistencyProofResponse{
+		FromSize:      fromSize,
+		TreeSize:      second,
+		AuditPath:     path,
+		HashAlgorithm: alg,
+	}, nil
+}
+
+// readLogConsistencyBetween returns a consistency proof between two sizes of a log, given in either
+// order. If a is zero or the sizes are the same, no proof is needed and nil is returned.
+func readLogConsistencyBetween(ctx context.Context, kr KeyReader, log *pb.LogRef, a, b int64) (*pb.LogConsistencyProofResponse, error) {
+	if a == 0 || a == b {
+		return nil, nil
+	}
+	if a > b {
+		a, b = b, a
+	}
+	return readLogConsistencyProof(ctx, kr, log, a, b)
+}
+
+// VerifyLogConsistencyProof will verify that the consistency proof stored in this object can produce both the LogTreeHeads passed to this method,
+// using the hash algorithm given in the proof.
 func VerifyLogConsistencyProof(self *pb.LogConsistencyProofResponse, first, second *pb.LogTreeHashResponse) error {
+	h, err := HasherForAlgorithm(self.HashAlgorithm)
+	if err != nil {
+		return err
+	}
+
 	if first.TreeSize != self.FromSize {
 		return ErrVerificationFailed
 	}
@@ -142,14 +172,14 @@ func VerifyLogConsistencyProof(self *pb.LogConsistencyProofResponse, first, seco
 			return ErrVerificationFailed
 		}
 		if (1 == (fn & 1)) || (fn == sn) {
-			fr = merkle.NodeHash(c, fr)
-			sr = merkle.NodeHash(c, sr)
+			fr = h.NodeHash(c, fr)
+			sr = h.NodeHash(c, sr)
 			for !((fn == 0) || (1 == (fn & 1))) {
 				fn >>= 1
 				sn >>= 1
 			}
 		} else {
-			sr = merkle.NodeHash(sr, c)
+			sr = h.NodeHash(sr, c)
 		}
 		fn >>= 1
 		sn >>= 1
@@ -0,0 +1,236 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"sync"
+	"time"
+
+	"github.com/continusec/verifiabledatastructures/pb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// How long a background job waits for added entries to be sequenced before giving up on cosigning them
+const cosignTimeout = time.Minute
+
+// cosignedLogTreeHash returns the latest (or specified) tree head that has been cosigned by a quorum
+// of witnesses. Only cosigned tree heads that have already been stored are returned, as these are
+// collected in the background after entries are added to the log.
+func (s *localServiceImpl) cosignedLogTreeHash(ctx context.Context, req *pb.LogTreeHashRequest) (*pb.LogTreeHashResponse, error) {
+	ns, err := s.logBucket(req.Log)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unknown err: %s", err)
+	}
+
+	var rv *pb.LogTreeHashResponse
+	err = s.Reader.ExecuteReadOnly(ctx, ns, func(ctx context.Context, kr KeyReader) error {
+		if req.TreeSize != 0 {
+			rv, err = lookupCosignedTreeHeadBySize(ctx, kr, req.Log.LogType, req.TreeSize)
+			if err == ErrNoSuchKey {
+				return status.Errorf(codes.NotFound, "no cosigned tree head for that size")
+			}
+			return err
+		}
+
+		rv, err = lookupLatestCosignedTreeHead(ctx, kr, req.Log.LogType)
+		return err
+	})
+	if err != nil {
+		_, ok := status.FromError(err)
+		if !ok {
+			err = status.Errorf(codes.Internal, "unknown err: %s", err)
+		}
+		return nil, err
+	}
+
+	if rv.TreeSize == 0 {
+		return nil, status.Errorf(codes.NotFound, "no cosigned tree head available")
+	}
+
+	if s.Signer != nil {
+		rv.Signature, err = signTreeHeadText(s.Signer, LogTreeHeadText(req.Log, rv))
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "error signing tree head: %s", err)
+		}
+	}
+
+	return rv, nil
+}
+
+// cosignAfterAdding starts a background job that waits for the entries with leafHashes to be sequenced in
+// the log, then asks the witnesses to cosign the tree head of the log and stores the result. Jobs for the
+// same log run one at a time, and skip any tree head that has already been cosigned, so that a burst of
+// additions does not result in a burst of requests to the witnesses.
+func (s *localServiceImpl) cosignAfterAdding(ns []byte, log *pb.LogRef, leafHashes [][]byte) {
+	if len(s.Witnesses) == 0 || s.Mutator == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), cosignTimeout)
+		defer cancel()
+
+		err := s.waitForLeafHashes(ctx, ns, log, leafHashes)
+		if err != nil {
+			return // we'll try again after the next entries are added
+		}
+
+		lock, _ := s.cosignLocks.LoadOrStore(string(ns), &sync.Mutex{})
+		lock.(*sync.Mutex).Lock()
+		defer lock.(*sync.Mutex).Unlock()
+
+		s.cosignLatestTreeHead(ctx, ns, log)
+	}()
+}
+
+// waitForLeafHashes blocks until every entry with one of leafHashes is in the log
+func (s *localServiceImpl) waitForLeafHashes(ctx context.Context, ns []byte, log *pb.LogRef, leafHashes [][]byte) error {
+	for {
+		// Must be called before reading, so that we don't miss an update
+		updated := s.updated(ns)
+
+		found := true
+		err := s.Reader.ExecuteReadOnly(ctx, ns, func(ctx context.Context, kr KeyReader) error {
+			for _, lh := range leafHashes {
+				_, err := lookupIndexByLeafHash(ctx, kr, log.LogType, lh)
+				switch err {
+				case nil:
+				case ErrNoSuchKey:
+					found = false
+					return nil
+				default:
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if found {
+			return nil
+		}
+
+		select {
+		case <-updated:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// cosignLatestTreeHead asks the witnesses to cosign the tree head of the log, if it is newer than the last
+// cosigned tree head, and queues the cosigned tree head to be stored if a quorum do so. Witnesses that are
+// unavailable do not count towards the quorum, and we'll try again after the next entries are added.
+func (s *localServiceImpl) cosignLatestTreeHead(ctx context.Context, ns []byte, log *pb.LogRef) {
+	var head, prev *pb.LogTreeHashResponse
+	err := s.Reader.ExecuteReadOnly(ctx, ns, func(ctx context.Context, kr KeyReader) error {
+		var err error
+		head, err = lookupLogTreeHead(ctx, kr, log.LogType)
+		if err != nil {
+			return err
+		}
+		prev, err = lookupLatestCosignedTreeHead(ctx, kr, log.LogType)
+		return err
+	})
+	if err != nil || head.TreeSize <= prev.TreeSize {
+		return
+	}
+
+	cosigned, err := s.requestCosignatures(ctx, ns, log, prev, head)
+	if err != nil || cosigned == nil {
+		return
+	}
+	s.Mutator.QueueMutation(ctx, ns, &pb.Mutation{
+		LogAddCosignedTreeHead: &pb.LogAddCosignedTreeHead{
+			Log:  log,
+			Head: cosigned,
+		},
+	})
+}
+
+// requestCosignatures asks each witness to cosign head, given that prev is the tree head that was
+// last cosigned. Returns nil, nil if a quorum of cosignatures was not received.
+func (s *localServiceImpl) requestCosignatures(ctx context.Context, ns []byte, log *pb.LogRef, prev, head *pb.LogTreeHashResponse) (*pb.LogTreeHashResponse, error) {
+	// Never pass on the API key of the caller
+	ref := &pb.LogRef{
+		Account: &pb.AccountRef{Id: log.Account.Id},
+		Name:    log.Name,
+		LogType: log.LogType,
+	}
+
+	signed := &pb.LogTreeHashResponse{
+		TreeSize: head.TreeSize,
+		RootHash: head.RootHash,
+	}
+	if s.Signer != nil {
+		var err error
+		signed.Signature, err = signTreeHeadText(s.Signer, LogTreeHeadText(ref, signed))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rv := &pb.LogTreeHashResponse{
+		TreeSize: head.TreeSize,
+		RootHash: head.RootHash,
+	}
+	for _, w := range s.Witnesses {
+		oldSize := prev.TreeSize
+		// If a witness has cosigned a different tree head to us, it tells us its size, so we try once more.
+		for attempt := 0; attempt < 2 && oldSize <= head.TreeSize; attempt++ {
+			var proof *pb.LogConsistencyProofResponse
+			if oldSize > 0 && oldSize < head.TreeSize {
+				err := s.Reader.ExecuteReadOnly(ctx, ns, func(ctx context.Context, kr KeyReader) error {
+					var err error
+					proof, err = readLogConsistencyProof(ctx, kr, log, oldSize, head.TreeSize)
+					return err
+				})
+				if err != nil {
+					return nil, err
+				}
+			}
+			resp, err := w.AddLogTreeHead(ctx, &pb.AddLogTreeHeadRequest{
+				Log:     ref,
+				OldSize: oldSize,
+				Head:    signed,
+				Proof:   proof,
+			})
+			if err != nil {
+				break // an unavailable witness simply doesn't count towards the quorum
+			}
+			if resp.Cosignature != nil {
+				rv.Cosignatures = append(rv.Cosignatures, resp.Cosignature)
+				break
+			}
+			oldSize = resp.WitnessSize
+		}
+	}
+
+	quorum := s.WitnessQuorum
+	if quorum == 0 {
+		quorum = len(s.Witnesses)
+	}
+	if len(rv.Cosignatures) < quorum {
+		return nil, nil
+	}
+
+	return rv, nil
+}
@@ -39,7 +39,7 @@ func (s *localServiceImpl) LogFetchEntries(ctx context.Context, req *pb.LogFetch
 	}
 
 	var rv *pb.LogFetchEntriesResponse
-	ns, err := logBucket(req.Log)
+	ns, err := s.logBucket(req.Log)
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "extra getting bucket: %s", err)
 	}
@@ -73,37 +73,9 @@ func (s *localServiceImpl) LogFetchEntries(ctx context.Context, req *pb.LogFetch
 				return err
 			}
 
-			switch req.Log.LogType {
-			case pb.LogType_STRUCT_TYPE_LOG:
-				vals[i], err = filterLeafData(v, am)
-				if err != nil {
-					return err
-				}
-			case pb.LogType_STRUCT_TYPE_TREEHEAD_LOG:
-				vals[i] = v
-			case pb.LogType_STRUCT_TYPE_MUTATION_LOG:
-				var mm pb.MapMutation
-				err = json.Unmarshal(v.ExtraData, &mm)
-				if err != nil {
-					return err
-				}
-				mm.Value, err = filterLeafData(mm.Value, am)
-				if err != nil {
-					return err
-				}
-
-				newVal, err := json.Marshal(&mm)
-				if err != nil {
-					return err
-				}
-
-				vals[i] = &pb.LeafData{
-					LeafInput: v.LeafInput,
-					Format:    v.Format,
-					ExtraData: newVal,
-				}
-			default:
-				return status.Errorf(codes.InvalidArgument, "bad log type")
+			vals[i], err = filterLogEntry(req.Log.LogType, v, am)
+			if err != nil {
+				return err
 			}
 		}
 
@@ -122,3 +94,45 @@ func (s *localServiceImpl) LogFetchEntries(ctx context.Context, req *pb.LogFetch
 
 	return rv, nil
 }
+
+// filterLogEntry redacts fields from an entry as needed by the access modifier
+func filterLogEntry(lt pb.LogType, v *pb.LeafData, am *AccessModifier) (*pb.LeafData, error) {
+	switch lt {
+	case pb.LogType_STRUCT_TYPE_LOG:
+		return filterLeafData(v, am)
+	case pb.LogType_STRUCT_TYPE_TREEHEAD_LOG:
+		return v, nil
+	case pb.LogType_STRUCT_TYPE_MUTATION_LOG:
+		var mm pb.MapMutation
+		err := json.Unmarshal(v.ExtraData, &mm)
+		if err != nil {
+			return nil, err
+		}
+		mm.Value, err = filterLeafData(mm.Value, am)
+		if err != nil {
+			return nil, err
+		}
+		for _, op := range mm.Operations {
+			if op.Value == nil { // delete
+				continue
+			}
+			op.Value, err = filterLeafData(op.Value, am)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		newVal, err := json.Marshal(&mm)
+		if err != nil {
+			return nil, err
+		}
+
+		return &pb.LeafData{
+			LeafInput: v.LeafInput,
+			Format:    v.Format,
+			ExtraData: newVal,
+		}, nil
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "bad log type")
+	}
+}
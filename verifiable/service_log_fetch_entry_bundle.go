@@ -0,0 +1,102 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"github.com/continusec/verifiabledatastructures/pb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// LogFetchEntryBundle returns an entry along with the tree head and proofs needed to verify it,
+// all read from the same snapshot of the log.
+func (s *localServiceImpl) LogFetchEntryBundle(ctx context.Context, req *pb.LogFetchEntryBundleRequest) (*pb.LogFetchEntryBundleResponse, error) {
+	am, err := s.verifyAccessForLogOperation(ctx, req.Log, operationReadEntry)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "no access: %s", err)
+	}
+	_, err = s.verifyAccessForLogOperation(ctx, req.Log, operationProveInclusion)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "no access: %s", err)
+	}
+
+	if req.LeafIndex < 0 || req.TreeSize < 0 || req.FromSize < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "bad tree size")
+	}
+
+	var rv *pb.LogFetchEntryBundleResponse
+	ns, err := s.logBucket(req.Log)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "extra getting bucket: %s", err)
+	}
+	err = s.Reader.ExecuteReadOnly(ctx, ns, func(ctx context.Context, kr KeyReader) error {
+		head, err := readLogTreeHash(ctx, kr, req.Log.LogType, req.TreeSize)
+		if err != nil {
+			return err
+		}
+
+		proof, err := readLogInclusionProof(ctx, kr, req.Log, head.TreeSize, req.LeafIndex, nil)
+		if err != nil {
+			return err
+		}
+
+		ln, err := lookupLeafNodeByIndex(ctx, kr, req.Log.LogType, req.LeafIndex)
+		if err != nil {
+			return err
+		}
+		v, err := lookupDataByLeafHash(ctx, kr, req.Log.LogType, ln.Mth)
+		if err != nil {
+			return err
+		}
+		v, err = filterLogEntry(req.Log.LogType, v, am)
+		if err != nil {
+			return err
+		}
+
+		cons, err := readLogConsistencyBetween(ctx, kr, req.Log, req.FromSize, head.TreeSize)
+		if err != nil {
+			return err
+		}
+
+		rv = &pb.LogFetchEntryBundleResponse{
+			Head:             head,
+			Value:            v,
+			InclusionProof:   proof,
+			ConsistencyProof: cons,
+		}
+		return nil
+	})
+	if err != nil {
+		_, ok := status.FromError(err)
+		if !ok {
+			err = status.Errorf(codes.Internal, "unknown err: %s", err)
+		}
+		return nil, err
+	}
+
+	if s.Signer != nil {
+		rv.Head.Signature, err = signTreeHeadText(s.Signer, LogTreeHeadText(req.Log, rv.Head))
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "error signing tree head: %s", err)
+		}
+	}
+
+	return rv, nil
+}
@@ -49,86 +49,87 @@ func (s *localServiceImpl) LogInclusionProof(ctx context.Context, req *pb.LogInc
 	}
 
 	var rv *pb.LogInclusionProofResponse
-	ns, err := logBucket(req.Log)
+	ns, err := s.logBucket(req.Log)
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "extra getting bucket: %s", err)
 	}
 	err = s.Reader.ExecuteReadOnly(ctx, ns, func(ctx context.Context, kr KeyReader) error {
-		head, err := lookupLogTreeHead(ctx, kr, req.Log.LogType)
-		if err != nil {
-			return err
+		rv, err = readLogInclusionProof(ctx, kr, req.Log, req.TreeSize, req.LeafIndex, req.MtlHash)
+		return err
+	})
+	if err != nil {
+		_, ok := status.FromError(err)
+		if !ok {
+			err = status.Errorf(codes.Internal, "unknown err: %s", err)
 		}
+		return nil, err
+	}
+	return rv, nil
+}
 
-		treeSize := req.TreeSize
-		if treeSize == 0 {
-			treeSize = head.TreeSize
-		}
+// readLogInclusionProof returns an inclusion proof for the leaf at leafIndex, or with hash mtlHash if set,
+// in the log of the given size, or the latest if zero.
+func readLogInclusionProof(ctx context.Context, kr KeyReader, log *pb.LogRef, treeSize, leafIndex int64, mtlHash []byte) (*pb.LogInclusionProofResponse, error) {
+	head, err := lookupLogTreeHead(ctx, kr, log.LogType)
+	if err != nil {
+		return nil, err
+	}
 
-		if treeSize > head.TreeSize {
-			return status.Errorf(codes.InvalidArgument, "bad tree size")
-		}
+	if treeSize == 0 {
+		treeSize = head.TreeSize
+	}
 
-		var leafIndex int64
-		if len(req.MtlHash) == 0 {
-			leafIndex = req.LeafIndex
-		} else {
-			// Then we must fetch the index
-			ei, err := lookupIndexByLeafHash(ctx, kr, req.Log.LogType, req.MtlHash)
-			if err != nil {
-				return err
-			}
-			leafIndex = ei.Index
-		}
+	if treeSize > head.TreeSize {
+		return nil, status.Errorf(codes.InvalidArgument, "bad tree size")
+	}
 
-		// We technically shouldn't have found it (it may not be completely written yet)
-		if leafIndex < 0 || leafIndex >= head.TreeSize {
-			// we use the NotFound error code so that normal usage of GetInclusionProof, that calls this, returns a uniform error.
-			return status.Errorf(codes.NotFound, "cannot find it")
+	if len(mtlHash) != 0 {
+		// Then we must fetch the index
+		ei, err := lookupIndexByLeafHash(ctx, kr, log.LogType, mtlHash)
+		if err != nil {
+			return nil, err
 		}
+		leafIndex = ei.Index
+	}
 
-		// Client needs a new STH
-		if leafIndex >= treeSize {
-			return status.Errorf(codes.InvalidArgument, "bad tree size")
-		}
+	// We technically shouldn't have found it (it may not be completely written yet)
+	if leafIndex < 0 || leafIndex >= head.TreeSize {
+		// we use the NotFound error code so that normal usage of GetInclusionProof, that calls this, returns a uniform error.
+		return nil, status.Errorf(codes.NotFound, "cannot find it")
+	}
 
-		// Ranges are good
-		ranges := merkle.Path(leafIndex, 0, treeSize)
-		path, err := fetchSubTreeHashes(ctx, kr, req.Log.LogType, ranges, false)
-		if err != nil {
-			return err
-		}
-		for i, rr := range ranges {
-			if len(path[i]) == 0 {
-				if merkle.IsPow2(rr[1] - rr[0]) {
-					// Would have been nice if GetSubTreeHashes could better handle these
-					return ErrNotFound
-				}
-				path[i], err = calcSubTreeHash(ctx, kr, req.Log.LogType, rr[0], rr[1])
-				if err != nil {
-					return err
-				}
-			}
-		}
+	// Client needs a new STH
+	if leafIndex >= treeSize {
+		return nil, status.Errorf(codes.InvalidArgument, "bad tree size")
+	}
 
-		rv = &pb.LogInclusionProofResponse{
-			LeafIndex: leafIndex,
-			TreeSize:  treeSize,
-			AuditPath: path,
-		}
-		return nil
-	})
+	alg, h, err := readHashAlgorithm(ctx, kr, log.HashAlgorithm)
 	if err != nil {
-		_, ok := status.FromError(err)
-		if !ok {
-			err = status.Errorf(codes.Internal, "unknown err: %s", err)
-		}
 		return nil, err
 	}
-	return rv, nil
+
+	// Ranges are good
+	ranges := merkle.Path(leafIndex, 0, treeSize)
+	path, err := fetchPathHashes(ctx, kr, h, log.LogType, ranges)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.LogInclusionProofResponse{
+		LeafIndex:     leafIndex,
+		TreeSize:      treeSize,
+		AuditPath:     path,
+		HashAlgorithm: alg,
+	}, nil
 }
 
-// VerifyLogInclusionProof verifies an inclusion proof against a LogTreeHead
+// VerifyLogInclusionProof verifies an inclusion proof against a LogTreeHead, using the hash algorithm
+// given in the proof. The leaf hash must have been calculated with the same algorithm.
 func VerifyLogInclusionProof(self *pb.LogInclusionProofResponse, leafHash []byte, head *pb.LogTreeHashResponse) error {
+	h, err := HasherForAlgorithm(self.HashAlgorithm)
+	if err != nil {
+		return err
+	}
 	if self.TreeSize != head.TreeSize {
 		return ErrVerificationFailed
 	}
@@ -143,13 +144,13 @@ func VerifyLogInclusionProof(self *pb.LogInclusionProofResponse, leafHash []byte
 	r := leafHash
 	for _, p := range self.AuditPath {
 		if (fn == sn) || ((fn & 1) == 1) {
-			r = merkle.NodeHash(p, r)
+			r = h.NodeHash(p, r)
 			for !((fn == 0) || ((fn & 1) == 1)) {
 				fn >>= 1
 				sn >>= 1
 			}
 		} else {
-			r = merkle.NodeHash(r, p)
+			r = h.NodeHash(r, p)
 		}
 		fn >>= 1
 		sn >>= 1
@@ -0,0 +1,112 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"github.com/continusec/verifiabledatastructures/pb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StreamLogEntries sends log entries as they are read from storage. All entries up to the current head
+// are read in a single transaction. If following, new entries are sent as they are sequenced.
+func (s *localServiceImpl) StreamLogEntries(req *pb.StreamLogEntriesRequest, stream pb.VerifiableDataStructuresService_StreamLogEntriesServer) error {
+	ctx := stream.Context()
+	am, err := s.verifyAccessForLogOperation(ctx, req.Log, operationReadEntry)
+	if err != nil {
+		return status.Errorf(codes.PermissionDenied, "no access: %s", err)
+	}
+
+	if req.First < 0 || req.Last < 0 || (req.Last != 0 && req.First >= req.Last) {
+		return status.Errorf(codes.InvalidArgument, "tree size out of range")
+	}
+
+	ns, err := s.logBucket(req.Log)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "extra getting bucket: %s", err)
+	}
+
+	next := req.First
+	for {
+		updated := s.updated(ns)
+		err = s.Reader.ExecuteReadOnly(ctx, ns, func(ctx context.Context, kr KeyReader) error {
+			head, err := lookupLogTreeHead(ctx, kr, req.Log.LogType)
+			if err != nil {
+				return err
+			}
+
+			last := req.Last
+			if req.Follow {
+				if last == 0 || last > head.TreeSize {
+					last = head.TreeSize
+				}
+			} else {
+				if last == 0 {
+					last = head.TreeSize
+				}
+
+				// Are we asking for something silly?
+				if last > head.TreeSize || next >= last {
+					return status.Errorf(codes.InvalidArgument, "tree size out of range")
+				}
+			}
+
+			for ; next < last; next++ {
+				ln, err := lookupLeafNodeByIndex(ctx, kr, req.Log.LogType, next)
+				if err != nil {
+					return err
+				}
+				v, err := lookupDataByLeafHash(ctx, kr, req.Log.LogType, ln.Mth)
+				if err != nil {
+					return err
+				}
+				v, err = filterLogEntry(req.Log.LogType, v, am)
+				if err != nil {
+					return err
+				}
+				err = stream.Send(&pb.StreamLogEntriesResponse{
+					Index: next,
+					Value: v,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			_, ok := status.FromError(err)
+			if !ok {
+				err = status.Errorf(codes.Internal, "unknown err: %s", err)
+			}
+			return err
+		}
+
+		if !req.Follow || (req.Last != 0 && next >= req.Last) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-updated:
+		}
+	}
+}
@@ -36,38 +36,18 @@ func (s *localServiceImpl) LogTreeHash(ctx context.Context, req *pb.LogTreeHashR
 		return nil, status.Errorf(codes.InvalidArgument, "bad tree size")
 	}
 
+	if req.Cosigned {
+		return s.cosignedLogTreeHash(ctx, req)
+	}
+
 	var rv *pb.LogTreeHashResponse
-	ns, err := logBucket(req.Log)
+	ns, err := s.logBucket(req.Log)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "unknown err: %s", err)
 	}
 	err = s.Reader.ExecuteReadOnly(ctx, ns, func(ctx context.Context, kr KeyReader) error {
-		head, err := lookupLogTreeHead(ctx, kr, req.Log.LogType)
-		if err != nil {
-			return err
-		}
-
-		// Do we have it already?
-		if req.TreeSize == 0 || req.TreeSize == head.TreeSize {
-			rv = head
-			return nil
-		}
-
-		// Are we asking for something silly?
-		if req.TreeSize > head.TreeSize {
-			return status.Errorf(codes.InvalidArgument, "bad tree size")
-		}
-
-		m, err := lookupLogRootHashBySize(ctx, kr, req.Log.LogType, req.TreeSize)
-		if err != nil {
-			return err
-		}
-
-		rv = &pb.LogTreeHashResponse{
-			TreeSize: req.TreeSize,
-			RootHash: m.Mth,
-		}
-		return nil
+		rv, err = readLogTreeHash(ctx, kr, req.Log.LogType, req.TreeSize)
+		return err
 	})
 	if err != nil {
 		_, ok := status.FromError(err)
@@ -77,5 +57,40 @@ func (s *localServiceImpl) LogTreeHash(ctx context.Context, req *pb.LogTreeHashR
 		return nil, err
 	}
 
+	if s.Signer != nil {
+		rv.Signature, err = signTreeHeadText(s.Signer, LogTreeHeadText(req.Log, rv))
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "error signing tree head: %s", err)
+		}
+	}
+
 	return rv, nil
 }
+
+// readLogTreeHash returns the unsigned tree hash for a log of the given size, or the latest if zero.
+func readLogTreeHash(ctx context.Context, kr KeyReader, logType pb.LogType, treeSize int64) (*pb.LogTreeHashResponse, error) {
+	head, err := lookupLogTreeHead(ctx, kr, logType)
+	if err != nil {
+		return nil, err
+	}
+
+	// Do we have it already?
+	if treeSize == 0 || treeSize == head.TreeSize {
+		return head, nil
+	}
+
+	// Are we asking for something silly?
+	if treeSize > head.TreeSize {
+		return nil, status.Errorf(codes.InvalidArgument, "bad tree size")
+	}
+
+	m, err := lookupLogRootHashBySize(ctx, kr, logType, treeSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.LogTreeHashResponse{
+		TreeSize: treeSize,
+		RootHash: m.Mth,
+	}, nil
+}
@@ -0,0 +1,61 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"github.com/continusec/verifiabledatastructures/pb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// WatchLogTreeHead sends the latest log tree head, then each new tree head as it is committed,
+// until the call is cancelled.
+func (s *localServiceImpl) WatchLogTreeHead(req *pb.WatchLogTreeHeadRequest, stream pb.VerifiableDataStructuresService_WatchLogTreeHeadServer) error {
+	ctx := stream.Context()
+	if req.TreeSize < 0 {
+		return status.Errorf(codes.InvalidArgument, "bad tree size")
+	}
+
+	ns, err := s.logBucket(req.Log)
+	if err != nil {
+		return status.Errorf(codes.Internal, "unknown err: %s", err)
+	}
+
+	last := req.TreeSize
+	for {
+		updated := s.updated(ns)
+		head, err := s.LogTreeHash(ctx, &pb.LogTreeHashRequest{Log: req.Log})
+		if err != nil {
+			return err
+		}
+		if head.TreeSize > last {
+			err = stream.Send(head)
+			if err != nil {
+				return err
+			}
+			last = head.TreeSize
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-updated:
+		}
+	}
+}
@@ -0,0 +1,81 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"encoding/json"
+
+	"github.com/continusec/verifiabledatastructures/pb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MapApplyTransaction sets, deletes and updates many values in a map, as a single mutation
+func (s *localServiceImpl) MapApplyTransaction(ctx context.Context, req *pb.MapApplyTransactionRequest) (*pb.MapApplyTransactionResponse, error) {
+	_, err := s.verifyAccessForMap(ctx, req.Map, pb.Permission_PERM_MAP_SET_VALUE)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "no access: %s", err)
+	}
+
+	if len(req.Mutations) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "no mutations")
+	}
+	for _, m := range req.Mutations {
+		if m == nil || len(m.Operations) != 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "bad mutation")
+		}
+		switch m.Action {
+		case "set", "update", "create", "set_if_version":
+			if m.Value == nil {
+				return nil, status.Errorf(codes.InvalidArgument, "no value")
+			}
+		case "delete", "compare_and_delete", "increment":
+			// nothing to check
+		case "patch":
+			if !json.Valid(m.Patch) {
+				return nil, status.Errorf(codes.InvalidArgument, "bad patch")
+			}
+		default:
+			return nil, status.Errorf(codes.InvalidArgument, "bad action")
+		}
+	}
+
+	// Clone and add timestamp to the transaction as a whole
+	mm, err := makeJSONMutationEntry(&pb.MapSetValueRequest{
+		Mutation: &pb.MapMutation{
+			Action:     "transaction",
+			Operations: req.Mutations,
+		},
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "bad mutation creation: %s", err)
+	}
+	for _, op := range mm.Operations {
+		op.Timestamp = ""
+	}
+
+	leafHash, err := s.queueMapMutation(ctx, req.Map, mm)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.MapApplyTransactionResponse{
+		LeafHash: leafHash,
+	}, nil
+}
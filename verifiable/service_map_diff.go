@@ -0,0 +1,392 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"bytes"
+
+	"github.com/continusec/verifiabledatastructures/merkle"
+	"github.com/continusec/verifiabledatastructures/pb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxMapDiffEntries is the most changed keys that may be returned in a single call to MapDiff
+const maxMapDiffEntries = 1000
+
+// MapDiff returns a page of the keys whose leaf hash differs between two sizes of a map, with the values
+// at each size, and the subtree hashes needed to verify that no changes were omitted.
+func (s *localServiceImpl) MapDiff(ctx context.Context, req *pb.MapDiffRequest) (*pb.MapDiffResponse, error) {
+	am, err := s.verifyAccessForMap(ctx, req.Map, pb.Permission_PERM_MAP_GET_VALUE)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "no access: %s", err)
+	}
+
+	if req.FromSize < 0 || req.ToSize < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "bad tree size")
+	}
+
+	start := req.StartKeyHash
+	if len(start) == 0 {
+		start = make([]byte, mapKeyHashLength)
+	}
+	if len(start) != mapKeyHashLength {
+		return nil, status.Errorf(codes.InvalidArgument, "bad start key hash")
+	}
+
+	limit := int(req.Limit)
+	if limit <= 0 || limit > maxMapDiffEntries {
+		limit = maxMapDiffEntries
+	}
+
+	var rv *pb.MapDiffResponse
+	ns, err := s.mapBucket(req.Map)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unknown err: %s", err)
+	}
+	err = s.Reader.ExecuteReadOnly(ctx, ns, func(ctx context.Context, kr KeyReader) error {
+		rv, err = readMapDiff(ctx, kr, req.Map, req.FromSize, req.ToSize, start, limit, am)
+		return err
+	})
+	if err != nil {
+		_, ok := status.FromError(err)
+		if !ok {
+			err = status.Errorf(codes.Internal, "unknown err: %s", err)
+		}
+		return nil, err
+	}
+
+	return rv, nil
+}
+
+// readMapDiff returns up to limit changed keys from start, between the map of fromSize and that of toSize,
+// or the latest if zero.
+func readMapDiff(ctx context.Context, kr KeyReader, vmap *pb.MapRef, fromSize, toSize int64, start []byte, limit int, am *AccessModifier) (*pb.MapDiffResponse, error) {
+	err := checkNotPatriciaTrie(ctx, kr, vmap)
+	if err != nil {
+		return nil, err
+	}
+
+	alg, h, err := readHashAlgorithm(ctx, kr, vmap.HashAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	h, err = readMapTreeHasher(ctx, kr, h, vmap.SumField)
+	if err != nil {
+		return nil, err
+	}
+
+	th, err := lookupLogTreeHead(ctx, kr, pb.LogType_STRUCT_TYPE_TREEHEAD_LOG)
+	if err != nil {
+		return nil, err
+	}
+	if toSize == 0 {
+		toSize = th.TreeSize
+	}
+
+	// Are we asking for something silly?
+	if toSize > th.TreeSize || fromSize > toSize {
+		return nil, status.Errorf(codes.InvalidArgument, "bad tree size")
+	}
+
+	fromRoot, err := lookupProvableMapRoot(ctx, kr, fromSize)
+	if err != nil {
+		return nil, err
+	}
+	toRoot, err := lookupProvableMapRoot(ctx, kr, toSize)
+	if err != nil {
+		return nil, err
+	}
+
+	// Look for one more than we need, which marks the end of the page
+	md := &mapDiffer{
+		ctx:   ctx,
+		kr:    kr,
+		h:     h,
+		start: start,
+		limit: limit + 1,
+	}
+	first := make([]byte, mapKeyHashLength)
+	err = md.diff(fromRoot, toRoot, first, 0)
+	if err != nil {
+		return nil, err
+	}
+	rng := keyRange{start: start}
+	var next []byte
+	if len(md.changed) > limit {
+		next = md.changed[limit]
+		md.changed = md.changed[:limit]
+		rng.end = next
+	}
+
+	// Now that we know the end, prove the changes at each size. Subtrees in the range without
+	// changes are the same at both, so the audit path is too.
+	provers := make([]*mapMultiProver, 2)
+	for i, root := range []*pb.MapNode{fromRoot, toRoot} {
+		provers[i] = &mapMultiProver{
+			ctx:        ctx,
+			kr:         kr,
+			h:          h,
+			rng:        rng,
+			leafHashes: make(map[string][]byte),
+		}
+		err = provers[i].prove(root, first, 0, md.changed)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	entries := make([]*pb.MapDiffEntry, len(md.changed))
+	for i, kh := range md.changed {
+		entries[i] = &pb.MapDiffEntry{KeyHash: kh}
+		entries[i].Before, err = readMapDiffValue(ctx, kr, h, provers[0].leafHashes[string(kh)], am)
+		if err != nil {
+			return nil, err
+		}
+		entries[i].After, err = readMapDiffValue(ctx, kr, h, provers[1].leafHashes[string(kh)], am)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &pb.MapDiffResponse{
+		FromSize:      fromSize,
+		ToSize:        toSize,
+		Entries:       entries,
+		NextKeyHash:   next,
+		AuditPath:     provers[1].auditPath,
+		FromBoundary:  provers[0].boundary,
+		ToBoundary:    provers[1].boundary,
+		HashAlgorithm: alg,
+	}, nil
+}
+
+// readMapDiffValue returns the value with leaf hash lh, which is empty if lh is nil or nullLeafHash
+func readMapDiffValue(ctx context.Context, kr KeyReader, h merkle.Hasher, lh []byte, am *AccessModifier) (*pb.LeafData, error) {
+	if len(lh) == 0 || bytes.Equal(lh, nullLeafHash(h)) {
+		return &pb.LeafData{}, nil
+	}
+	value, err := lookupDataByLeafHash(ctx, kr, pb.LogType_STRUCT_TYPE_MUTATION_LOG, lh)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check for fields that need redacting
+	return filterLeafData(value, am)
+}
+
+// mapDiffer walks the stored map nodes of two sizes of a map in parallel for the leaves that differ,
+// skipping any subtree with the same hash in both
+type mapDiffer struct {
+	ctx   context.Context
+	kr    KeyReader
+	h     merkle.Hasher
+	start []byte
+	limit int
+
+	changed [][]byte // key hashes, in order
+}
+
+// hash returns the hash of the subtree at depth, which is the default value if mn is nil
+func (md *mapDiffer) hash(mn *pb.MapNode, depth int) ([]byte, error) {
+	if mn == nil {
+		return md.h.DefaultLeafValue(depth), nil
+	}
+	return calcNodeHash(md.h, mn, uint(depth))
+}
+
+// leaves returns up to limit non-empty leaves in the subtree at depth, starting with kh, that are not before
+// the start, in order. mn may be nil for an empty subtree.
+func (md *mapDiffer) leaves(mn *pb.MapNode, kh []byte, depth int, limit int) ([]*pb.MapLeaf, error) {
+	if mn == nil {
+		return nil, nil
+	}
+	ll := &mapLeafLister{
+		ctx:   md.ctx,
+		kr:    md.kr,
+		h:     md.h,
+		rng:   keyRange{start: md.start},
+		limit: limit,
+	}
+	err := ll.collect(mn, kh, depth)
+	if err != nil {
+		return nil, err
+	}
+	return ll.leaves, nil
+}
+
+// diff adds the key hashes of leaves that differ between a and b, the subtrees at depth starting with kh at
+// each size, that are not before the start, in order, until the limit is reached. Either may be nil for an
+// empty subtree.
+func (md *mapDiffer) diff(a, b *pb.MapNode, kh []byte, depth int) error {
+	if len(md.changed) >= md.limit {
+		return nil
+	}
+	if (keyRange{start: md.start}).classify(kh, depth) == subTreeOutside {
+		return nil
+	}
+
+	ha, err := md.hash(a, depth)
+	if err != nil {
+		return err
+	}
+	hb, err := md.hash(b, depth)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(ha, hb) {
+		return nil
+	}
+
+	if a == nil || b == nil || isLeaf(a) || isLeaf(b) {
+		return md.diffLeaves(a, b, kh, depth)
+	}
+
+	// Neither side is a leaf, so descend both
+	ll := &mapLeafLister{ctx: md.ctx, kr: md.kr, h: md.h}
+	for _, right := range []bool{false, true} {
+		ca, ckh, err := ll.child(a, kh, depth, right)
+		if err != nil {
+			return err
+		}
+		cb, _, err := ll.child(b, kh, depth, right)
+		if err != nil {
+			return err
+		}
+		err = md.diff(ca, cb, ckh, depth+1)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diffLeaves adds the key hashes of leaves that differ between a and b, when at least one of them is empty
+// or a single leaf. Then at most one leaf is the same in both, so a few more leaves than the number left to
+// find is enough from each.
+func (md *mapDiffer) diffLeaves(a, b *pb.MapNode, kh []byte, depth int) error {
+	remaining := md.limit - len(md.changed)
+	la, err := md.leaves(a, kh, depth, remaining+2)
+	if err != nil {
+		return err
+	}
+	lb, err := md.leaves(b, kh, depth, remaining+2)
+	if err != nil {
+		return err
+	}
+
+	i, j := 0, 0
+	for (i < len(la) || j < len(lb)) && len(md.changed) < md.limit {
+		switch {
+		case j == len(lb) || (i < len(la) && bytes.Compare(la[i].KeyHash, lb[j].KeyHash) < 0):
+			md.changed = append(md.changed, la[i].KeyHash)
+			i++
+		case i == len(la) || bytes.Compare(la[i].KeyHash, lb[j].KeyHash) > 0:
+			md.changed = append(md.changed, lb[j].KeyHash)
+			j++
+		default:
+			if !bytes.Equal(la[i].LeafHash, lb[j].LeafHash) {
+				md.changed = append(md.changed, la[i].KeyHash)
+			}
+			i++
+			j++
+		}
+	}
+	return nil
+}
+
+// VerifyMapDiff verifies that a page of a diff is every key hash from start (which may be empty for the start of
+// the map) up to the NextKeyHash in the page with a different value in the from and to MapTreeHeads, with the
+// value in each, using the hash algorithm given in the page. A nil from is the empty map. Pages may be verified
+// one after the other to verify that every change between the two was listed.
+func VerifyMapDiff(self *pb.MapDiffResponse, start []byte, from, to *pb.MapTreeHashResponse) error {
+	h, err := HasherForAlgorithm(self.HashAlgorithm)
+	if err != nil {
+		return err
+	}
+	if from != nil && from.SumField != to.SumField {
+		return ErrVerificationFailed
+	}
+	h = mapTreeHasher(h, to.SumField)
+
+	fromSize := int64(0)
+	fromRoot, err := calcNodeHash(h, &pb.MapNode{}, 0)
+	if err != nil {
+		return err
+	}
+	if from != nil {
+		fromSize, fromRoot = from.MutationLog.TreeSize, from.RootHash
+	}
+	if self.FromSize != fromSize || self.ToSize != to.MutationLog.TreeSize {
+		return ErrVerificationFailed
+	}
+
+	if len(start) == 0 {
+		start = make([]byte, mapKeyHashLength)
+	}
+	if len(start) != mapKeyHashLength {
+		return ErrVerificationFailed
+	}
+	rng := keyRange{start: start}
+	if len(self.NextKeyHash) != 0 {
+		if len(self.NextKeyHash) != mapKeyHashLength || bytes.Compare(self.NextKeyHash, start) <= 0 {
+			return ErrVerificationFailed
+		}
+		rng.end = self.NextKeyHash
+	}
+
+	// Entries must be in order, and actually changed
+	keyHashes := make([][]byte, len(self.Entries))
+	before := make(map[string]*pb.LeafData)
+	after := make(map[string]*pb.LeafData)
+	for i, entry := range self.Entries {
+		if len(entry.KeyHash) != mapKeyHashLength {
+			return ErrVerificationFailed
+		}
+		if i > 0 && bytes.Compare(keyHashes[i-1], entry.KeyHash) >= 0 {
+			return ErrVerificationFailed
+		}
+		keyHashes[i] = entry.KeyHash
+		before[string(entry.KeyHash)] = entry.Before
+		after[string(entry.KeyHash)] = entry.After
+		if bytes.Equal(h.LeafHash(entry.Before.GetLeafInput()), h.LeafHash(entry.After.GetLeafInput())) {
+			return ErrVerificationFailed
+		}
+	}
+
+	// Every other key in the range is in a subtree that is the same in both
+	r, err := calcMapRootHash(h, rng, keyHashes, before, self.AuditPath, self.FromBoundary)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(r, fromRoot) {
+		return ErrVerificationFailed
+	}
+	r, err = calcMapRootHash(h, rng, keyHashes, after, self.AuditPath, self.ToBoundary)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(r, to.RootHash) {
+		return ErrVerificationFailed
+	}
+
+	// all clear
+	return nil
+}
@@ -0,0 +1,260 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+
+	"github.com/continusec/verifiabledatastructures/pb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MapGetKeyHistory returns each value that a key in a map has had, along with the mutation that set it
+// and proof of the inclusion of that mutation in the mutation log.
+func (s *localServiceImpl) MapGetKeyHistory(ctx context.Context, req *pb.MapGetKeyHistoryRequest) (*pb.MapGetKeyHistoryResponse, error) {
+	am, err := s.verifyAccessForMap(ctx, req.Map, pb.Permission_PERM_MAP_GET_VALUE)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "no access: %s", err)
+	}
+	mutLog := mutationLogForMap(req.Map)
+	mutAm, err := s.verifyAccessForLogOperation(ctx, mutLog, operationReadEntry)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "no access: %s", err)
+	}
+	_, err = s.verifyAccessForLogOperation(ctx, mutLog, operationProveInclusion)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "no access: %s", err)
+	}
+
+	if req.TreeSize < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "bad tree size")
+	}
+
+	var rv *pb.MapGetKeyHistoryResponse
+	ns, err := s.mapBucket(req.Map)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unknown err: %s", err)
+	}
+	err = s.Reader.ExecuteReadOnly(ctx, ns, func(ctx context.Context, kr KeyReader) error {
+		rv, err = readMapKeyHistory(ctx, kr, req.Map, req.Key, req.TreeSize, am, mutAm, s.VRFKey)
+		return err
+	})
+	if err != nil {
+		_, ok := status.FromError(err)
+		if !ok {
+			err = status.Errorf(codes.Internal, "unknown err: %s", err)
+		}
+		return nil, err
+	}
+
+	return rv, nil
+}
+
+// readMapKeyHistory returns the changes to the value for key in the map of the given size, or the latest if zero.
+func readMapKeyHistory(ctx context.Context, kr KeyReader, vmap *pb.MapRef, key []byte, treeSize int64, am, mutAm *AccessModifier, vrfKey ed25519.PrivateKey) (*pb.MapGetKeyHistoryResponse, error) {
+	alg, h, err := readHashAlgorithm(ctx, kr, vmap.HashAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	kh, _, err := readMapKeyHash(ctx, kr, h, vrfKey, key)
+	if err != nil {
+		return nil, err
+	}
+	kp := bPathFromKeyHash(kh)
+
+	th, err := lookupLogTreeHead(ctx, kr, pb.LogType_STRUCT_TYPE_TREEHEAD_LOG)
+	if err != nil {
+		return nil, err
+	}
+	if treeSize == 0 {
+		treeSize = th.TreeSize
+	}
+
+	// Are we asking for something silly?
+	if treeSize > th.TreeSize {
+		return nil, status.Errorf(codes.InvalidArgument, "bad tree size")
+	}
+
+	size, err := lookupMapKeyHistorySize(ctx, kr, kp)
+	if err != nil {
+		return nil, err
+	}
+
+	mutLog := mutationLogForMap(vmap)
+	var entries []*pb.MapKeyHistoryEntry
+	for i := int64(0); i < size; i++ {
+		change, err := lookupMapKeyChange(ctx, kr, kp, i)
+		if err != nil {
+			return nil, err
+		}
+		// Changes are in order, so the rest are too new
+		if change.MutationIndex >= treeSize {
+			break
+		}
+
+		value := &pb.LeafData{} // empty value
+		if !bytes.Equal(change.LeafHash, nullLeafHash(h)) {
+			value, err = lookupDataByLeafHash(ctx, kr, pb.LogType_STRUCT_TYPE_MUTATION_LOG, change.LeafHash)
+			if err != nil {
+				return nil, err
+			}
+			// Check for fields that need redacting
+			value, err = filterLeafData(value, am)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		ln, err := lookupLeafNodeByIndex(ctx, kr, pb.LogType_STRUCT_TYPE_MUTATION_LOG, change.MutationIndex)
+		if err != nil {
+			return nil, err
+		}
+		mutation, err := lookupDataByLeafHash(ctx, kr, pb.LogType_STRUCT_TYPE_MUTATION_LOG, ln.Mth)
+		if err != nil {
+			return nil, err
+		}
+		mutation, err = filterLogEntry(pb.LogType_STRUCT_TYPE_MUTATION_LOG, mutation, mutAm)
+		if err != nil {
+			return nil, err
+		}
+
+		proof, err := readLogInclusionProof(ctx, kr, mutLog, treeSize, change.MutationIndex, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, &pb.MapKeyHistoryEntry{
+			MutationIndex:  change.MutationIndex,
+			Value:          value,
+			Mutation:       mutation,
+			InclusionProof: proof,
+		})
+	}
+
+	return &pb.MapGetKeyHistoryResponse{
+		TreeSize:      treeSize,
+		Entries:       entries,
+		HashAlgorithm: alg,
+	}, nil
+}
+
+// mutationValueForKey returns the value that the mutation log entry sets for key, which is empty if
+// deleted, or nil if it does not change key. For a transaction, the last operation for key is used.
+// Values derived by "patch" and "increment" are derived from prev, the value before the mutation. For a
+// map with VRF keys, the mutation is matched to key by its VRF proof, which is verified with pub.
+func mutationValueForKey(ctx context.Context, pub ed25519.PublicKey, entry *pb.LeafData, key []byte, prev *pb.LeafData) (*pb.LeafData, error) {
+	err := ValidateJSONLeafDataFromMutation(entry)
+	if err != nil {
+		return nil, err
+	}
+	var mut pb.MapMutation
+	err = json.Unmarshal(entry.ExtraData, &mut)
+	if err != nil {
+		return nil, ErrVerificationFailed
+	}
+
+	ops := []*pb.MapMutation{&mut}
+	if mut.Action == "transaction" {
+		ops = mut.Operations
+	}
+	var rv *pb.LeafData
+	for _, op := range ops {
+		if !mutationIsForKey(pub, op, key) {
+			continue
+		}
+		if isDerivedMutation(op) {
+			cur := prev
+			if rv != nil {
+				cur = rv
+			}
+			var ok bool
+			op, ok = derivedMapMutation(ctx, op, cur)
+			if !ok {
+				return nil, ErrVerificationFailed
+			}
+		}
+		switch op.Action {
+		case "delete", "compare_and_delete":
+			rv = &pb.LeafData{}
+		case "set", "update", "create", "set_if_version":
+			if op.Value == nil {
+				return nil, ErrVerificationFailed
+			}
+			rv = op.Value
+		default:
+			return nil, ErrVerificationFailed
+		}
+	}
+	return rv, nil
+}
+
+// VerifyMapKeyHistory verifies that each entry in the history for key is a value set by a mutation
+// included in the mutation log for the MapTreeHead, in order, using the hash algorithm given in the
+// history. A value derived by a "patch" or "increment" mutation is derived from the value in the entry
+// before it. It cannot prove that no changes were omitted.
+func VerifyMapKeyHistory(self *pb.MapGetKeyHistoryResponse, key []byte, head *pb.MapTreeHashResponse) error {
+	h, err := HasherForAlgorithm(self.HashAlgorithm)
+	if err != nil {
+		return err
+	}
+	if self.TreeSize != head.MutationLog.TreeSize {
+		return ErrVerificationFailed
+	}
+
+	for i, entry := range self.Entries {
+		if entry.Mutation == nil || entry.InclusionProof == nil {
+			return ErrVerificationFailed
+		}
+		if entry.MutationIndex < 0 || entry.MutationIndex >= self.TreeSize {
+			return ErrVerificationFailed
+		}
+		if i > 0 && entry.MutationIndex <= self.Entries[i-1].MutationIndex {
+			return ErrVerificationFailed
+		}
+
+		// The mutation must be in the log at the index given
+		if entry.InclusionProof.LeafIndex != entry.MutationIndex || entry.InclusionProof.HashAlgorithm != self.HashAlgorithm {
+			return ErrVerificationFailed
+		}
+		err = VerifyLogInclusionProof(entry.InclusionProof, h.LeafHash(entry.Mutation.LeafInput), head.MutationLog)
+		if err != nil {
+			return err
+		}
+
+		// And must set the value given
+		var prev *pb.LeafData
+		if i > 0 {
+			prev = self.Entries[i-1].Value
+		}
+		value, err := mutationValueForKey(context.Background(), head.VrfPublicKey, entry.Mutation, key, prev)
+		if err != nil {
+			return err
+		}
+		if value == nil || !bytes.Equal(value.LeafInput, entry.Value.GetLeafInput()) {
+			return ErrVerificationFailed
+		}
+	}
+
+	// all clear
+	return nil
+}
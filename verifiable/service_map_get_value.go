@@ -20,6 +20,7 @@ package verifiable
 
 import (
 	"bytes"
+	"crypto/ed25519"
 
 	"github.com/continusec/verifiabledatastructures/merkle"
 	"github.com/continusec/verifiabledatastructures/pb"
@@ -40,132 +41,243 @@ func (s *localServiceImpl) MapGetValue(ctx context.Context, req *pb.MapGetValueR
 	}
 
 	var rv *pb.MapGetValueResponse
-	ns, err := mapBucket(req.Map)
+	ns, err := s.mapBucket(req.Map)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "unknown err: %s", err)
 	}
 	err = s.Reader.ExecuteReadOnly(ctx, ns, func(ctx context.Context, kr KeyReader) error {
-		kp := BPathFromKey(req.Key)
-
-		th, err := lookupLogTreeHead(ctx, kr, pb.LogType_STRUCT_TYPE_TREEHEAD_LOG)
+		rv, err = readMapValue(ctx, kr, req.Map, req.Key, req.TreeSize, am, s.VRFKey)
 		if err != nil {
 			return err
 		}
-		treeSize := req.TreeSize
-		if treeSize == 0 {
-			treeSize = th.TreeSize
+		if req.CompactProof {
+			return compactMapValueProof(ctx, kr, req.Map, rv)
 		}
+		return nil
+	})
 
-		// Are we asking for something silly?
-		if treeSize > th.TreeSize {
-			return status.Errorf(codes.InvalidArgument, "bad tree size")
+	if err != nil {
+		_, ok := status.FromError(err)
+		if !ok {
+			err = status.Errorf(codes.Internal, "unknown err: %s", err)
 		}
+		return nil, err
+	}
 
-		root, err := lookupMapHash(ctx, kr, treeSize, BPathEmpty)
+	return rv, nil
+}
 
-		if err != nil {
-			return err
-		}
+// readMapValue returns the value for a key, and proof of its inclusion, in the map of the given size,
+// or the latest if zero.
+func readMapValue(ctx context.Context, kr KeyReader, vmap *pb.MapRef, key []byte, treeSize int64, am *AccessModifier, vrfKey ed25519.PrivateKey) (*pb.MapGetValueResponse, error) {
+	alg, h, err := readHashAlgorithm(ctx, kr, vmap.HashAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	h, err = readMapTreeHasher(ctx, kr, h, vmap.SumField)
+	if err != nil {
+		return nil, err
+	}
+	kh, keyProof, err := readMapKeyHash(ctx, kr, h, vrfKey, key)
+	if err != nil {
+		return nil, err
+	}
+	kp := bPathFromKeyHash(kh)
 
-		cur, ancestors, err := descendToFork(ctx, kr, kp, root)
-		if err != nil {
-			return err
-		}
+	th, err := lookupLogTreeHead(ctx, kr, pb.LogType_STRUCT_TYPE_TREEHEAD_LOG)
+	if err != nil {
+		return nil, err
+	}
+	if treeSize == 0 {
+		treeSize = th.TreeSize
+	}
 
-		proof := make([][]byte, kp.Length())
-		ptr := uint(0)
-		for i := 0; i < len(ancestors); i++ {
-			if kp.At(uint(i)) { // right
-				proof[i] = ancestors[i].LeftHash
-			} else {
-				proof[i] = ancestors[i].RightHash
-			}
-			ptr++
+	// Are we asking for something silly?
+	if treeSize > th.TreeSize {
+		return nil, status.Errorf(codes.InvalidArgument, "bad tree size")
+	}
+
+	// A patricia trie map proves the value with its trie instead
+	patricia, err := readPatriciaTrie(ctx, kr, vmap.PatriciaTrie)
+	if err != nil {
+		return nil, err
+	}
+	if patricia {
+		return readPatriciaMapValue(ctx, kr, h, alg, key, treeSize, am)
+	}
+
+	root, err := lookupProvableMapRoot(ctx, kr, treeSize)
+	if err != nil {
+		return nil, err
+	}
+
+	cur, ancestors, err := descendToFork(ctx, kr, kp, root)
+	if err != nil {
+		return nil, err
+	}
+
+	proof := make([][]byte, kp.Length())
+	ptr := uint(0)
+	for i := 0; i < len(ancestors); i++ {
+		if kp.At(uint(i)) { // right
+			proof[i] = ancestors[i].LeftHash
+		} else {
+			proof[i] = ancestors[i].RightHash
 		}
+		ptr++
+	}
 
-		var dataRv *pb.LeafData
-		if len(cur.LeafHash) == 0 { // we're a node
-			dataRv = &pb.LeafData{} // empty value
-			if kp.At(ptr) {         // right
-				proof[ptr] = cur.LeftHash
+	var dataRv *pb.LeafData
+	if len(cur.LeafHash) == 0 { // we're a node
+		dataRv = &pb.LeafData{} // empty value
+		if kp.At(ptr) {         // right
+			proof[ptr] = cur.LeftHash
+		} else {
+			proof[ptr] = cur.RightHash
+		}
+	} else { // we're a leaf
+		// Check value is actually us, else we need to manufacture a proof
+		if bytes.Equal(kp, cur.Path) {
+			if bytes.Equal(cur.LeafHash, nullLeafHash(h)) {
+				dataRv = &pb.LeafData{} // empty value
 			} else {
-				proof[ptr] = cur.RightHash
-			}
-		} else { // we're a leaf
-			// Check value is actually us, else we need to manufacture a proof
-			if bytes.Equal(kp, cur.Path) {
-				if bytes.Equal(cur.LeafHash, nullLeafHash) {
-					dataRv = &pb.LeafData{} // empty value
-				} else {
-					dataRv, err = lookupDataByLeafHash(ctx, kr, pb.LogType_STRUCT_TYPE_MUTATION_LOG, cur.LeafHash)
-					if err != nil {
-						return err
-					}
+				dataRv, err = lookupDataByLeafHash(ctx, kr, pb.LogType_STRUCT_TYPE_MUTATION_LOG, cur.LeafHash)
+				if err != nil {
+					return nil, err
 				}
-			} else {
-				dataRv = &pb.LeafData{} // empty value
+			}
+		} else {
+			dataRv = &pb.LeafData{} // empty value
 
-				// Add empty proof paths for common ancestors
-				for kp.At(ptr) == BPath(cur.Path).At(ptr) {
-					ptr++
-				}
+			// Add empty proof paths for common ancestors
+			for kp.At(ptr) == BPath(cur.Path).At(ptr) {
+				ptr++
+			}
 
-				// Add sibling hash
-				theirHash, err := calcNodeHash(cur, uint(ptr+1))
-				if err != nil {
-					return err
-				}
-				proof[ptr] = theirHash
+			// Add sibling hash
+			theirHash, err := calcNodeHash(h, cur, uint(ptr+1))
+			if err != nil {
+				return nil, err
 			}
+			proof[ptr] = theirHash
 		}
+	}
 
-		// Check for fields that need redacting
-		dataRv, err = filterLeafData(dataRv, am)
-		if err != nil {
-			return err
-		}
+	// Check for fields that need redacting
+	dataRv, err = filterLeafData(dataRv, am)
+	if err != nil {
+		return nil, err
+	}
 
-		rv = &pb.MapGetValueResponse{
-			AuditPath: proof,
-			TreeSize:  treeSize,
-			Value:     dataRv,
-		}
-		return nil
-	})
+	return &pb.MapGetValueResponse{
+		AuditPath:     proof,
+		TreeSize:      treeSize,
+		Value:         dataRv,
+		HashAlgorithm: alg,
+		KeyProof:      keyProof,
+	}, nil
+}
 
+// compactMapValueProof replaces the audit path in the response with a bitmap of the levels
+// that are not the default, and the hashes for just those levels. Proofs for a patricia trie
+// map are left as is.
+func compactMapValueProof(ctx context.Context, kr KeyReader, vmap *pb.MapRef, rv *pb.MapGetValueResponse) error {
+	if len(rv.AuditPath) == 0 {
+		return nil
+	}
+	h, err := HasherForAlgorithm(rv.HashAlgorithm)
 	if err != nil {
-		_, ok := status.FromError(err)
-		if !ok {
-			err = status.Errorf(codes.Internal, "unknown err: %s", err)
+		return err
+	}
+	h, err = readMapTreeHasher(ctx, kr, h, vmap.SumField)
+	if err != nil {
+		return err
+	}
+	bitmap := make([]byte, mapKeyHashLength)
+	var hashes [][]byte
+	for i, p := range rv.AuditPath {
+		if len(p) != 0 && !bytes.Equal(p, h.DefaultLeafValue(i+1)) {
+			bitmap[i/8] |= 0x80 >> uint(i%8)
+			hashes = append(hashes, p)
 		}
-		return nil, err
+	}
+	rv.AuditPath = nil
+	rv.AuditPathBitmap = bitmap
+	rv.CompactAuditPath = hashes
+	return nil
+}
+
+// expandMapAuditPath returns the full audit path for a proof, which may be in either compact or
+// full form. Levels that are default are returned as nil.
+func expandMapAuditPath(self *pb.MapGetValueResponse, length int) ([][]byte, error) {
+	if len(self.AuditPathBitmap) == 0 {
+		if len(self.CompactAuditPath) != 0 || len(self.AuditPath) > length {
+			return nil, ErrVerificationFailed
+		}
+		rv := make([][]byte, length)
+		copy(rv, self.AuditPath)
+		return rv, nil
 	}
 
+	if len(self.AuditPath) != 0 || len(self.AuditPathBitmap)*8 != length {
+		return nil, ErrVerificationFailed
+	}
+	rv := make([][]byte, length)
+	used := 0
+	for i := range rv {
+		if self.AuditPathBitmap[i/8]&(0x80>>uint(i%8)) != 0 {
+			if used >= len(self.CompactAuditPath) {
+				return nil, ErrVerificationFailed
+			}
+			rv[i] = self.CompactAuditPath[used]
+			used++
+		}
+	}
+	if used != len(self.CompactAuditPath) {
+		return nil, ErrVerificationFailed
+	}
 	return rv, nil
 }
 
-var (
-	defaultLeafValues = merkle.GenerateMapDefaultLeafValues()
-)
-
-// VerifyMapInclusionProof verifies an inclusion proof against a MapTreeHead
+// VerifyMapInclusionProof verifies an inclusion proof against a MapTreeHead, using the hash algorithm
+// given in the proof. The proof may be in either compact or full form. For a sum-tree map, the count
+// and sum for each sibling in the proof are verified along with its hash. For a patricia trie map,
+// the patricia proof is verified instead, as for a storage proof from eth_getProof. The kind of map is
+// taken from the head, so callers must first check that it is the kind they expect, as VerifiedGet does.
 func VerifyMapInclusionProof(self *pb.MapGetValueResponse, key []byte, head *pb.MapTreeHashResponse) error {
+	if head.PatriciaTrie {
+		return verifyPatriciaMapInclusionProof(self, key, head)
+	}
+
+	h, err := HasherForAlgorithm(self.HashAlgorithm)
+	if err != nil {
+		return err
+	}
+	h = mapTreeHasher(h, head.SumField)
 	if self.TreeSize != head.MutationLog.TreeSize {
 		return ErrVerificationFailed
 	}
 
-	kp := merkle.ConstructMapKeyPath(key)
-	t := merkle.LeafHash(self.Value.GetLeafInput())
+	kh, err := verifiedKeyHash(h, head.VrfPublicKey, key, self.KeyProof)
+	if err != nil {
+		return err
+	}
+	kp := merkle.KeyHashPath(kh)
+	auditPath, err := expandMapAuditPath(self, len(kp))
+	if err != nil {
+		return err
+	}
+	t := mapValueNodeHash(h, self.Value)
 	for i := len(kp) - 1; i >= 0; i-- {
-		p := self.AuditPath[i]
+		p := auditPath[i]
 		if len(p) == 0 { // some transport layers change nil to zero length, so we handle either in the same way
-			p = defaultLeafValues[i+1]
+			p = h.DefaultLeafValue(i + 1)
 		}
 
 		if kp[i] {
-			t = merkle.NodeHash(p, t)
+			t = h.NodeHash(p, t)
 		} else {
-			t = merkle.NodeHash(t, p)
+			t = h.NodeHash(t, p)
 		}
 	}
 
@@ -174,7 +286,7 @@ func VerifyMapInclusionProof(self *pb.MapGetValueResponse, key []byte, head *pb.
 	}
 
 	// should not happen, but guarding anyway
-	if len(t) != 32 {
+	if len(t) != len(h.DefaultLeafValue(0)) {
 		return ErrVerificationFailed
 	}
 
@@ -0,0 +1,124 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"github.com/continusec/verifiabledatastructures/pb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MapGetValueBundle returns a value from a map along with the map tree head, and the proofs needed to
+// verify both the value and that the map tree head is in the tree head log, all read from the same
+// snapshot of the map.
+func (s *localServiceImpl) MapGetValueBundle(ctx context.Context, req *pb.MapGetValueBundleRequest) (*pb.MapGetValueBundleResponse, error) {
+	am, err := s.verifyAccessForMap(ctx, req.Map, pb.Permission_PERM_MAP_GET_VALUE)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "no access: %s", err)
+	}
+	thLog := treeHeadLogForMap(req.Map)
+	_, err = s.verifyAccessForLogOperation(ctx, thLog, operationProveInclusion)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "no access: %s", err)
+	}
+	mutLog := mutationLogForMap(req.Map)
+	if req.FromSize != 0 {
+		_, err = s.verifyAccessForLogOperation(ctx, mutLog, operationReadHash)
+		if err != nil {
+			return nil, status.Errorf(codes.PermissionDenied, "no access: %s", err)
+		}
+	}
+
+	if req.TreeSize < 0 || req.FromSize < 0 || req.TreeHeadLogFromSize < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "bad tree size")
+	}
+
+	var rv *pb.MapGetValueBundleResponse
+	ns, err := s.mapBucket(req.Map)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unknown err: %s", err)
+	}
+	err = s.Reader.ExecuteReadOnly(ctx, ns, func(ctx context.Context, kr KeyReader) error {
+		mapHead, err := readMapTreeHash(ctx, kr, req.Map, req.TreeSize)
+		if err != nil {
+			return err
+		}
+		size := mapHead.MutationLog.TreeSize
+
+		// Until the first mutation, there is no tree head log to prove anything in
+		if size == 0 {
+			return status.Errorf(codes.NotFound, "map is empty")
+		}
+
+		val, err := readMapValue(ctx, kr, req.Map, req.Key, size, am, s.VRFKey)
+		if err != nil {
+			return err
+		}
+
+		// The head for each map size is appended to the tree head log as the mutation is applied
+		thHead, err := readLogTreeHash(ctx, kr, thLog.LogType, 0)
+		if err != nil {
+			return err
+		}
+		thProof, err := readLogInclusionProof(ctx, kr, thLog, thHead.TreeSize, size-1, nil)
+		if err != nil {
+			return err
+		}
+
+		mutCons, err := readLogConsistencyBetween(ctx, kr, mutLog, req.FromSize, size)
+		if err != nil {
+			return err
+		}
+		thCons, err := readLogConsistencyBetween(ctx, kr, thLog, req.TreeHeadLogFromSize, thHead.TreeSize)
+		if err != nil {
+			return err
+		}
+
+		rv = &pb.MapGetValueBundleResponse{
+			MapHead:                     mapHead,
+			Value:                       val,
+			TreeHeadLogHead:             thHead,
+			TreeHeadLogInclusionProof:   thProof,
+			MutationLogConsistencyProof: mutCons,
+			TreeHeadLogConsistencyProof: thCons,
+		}
+		return nil
+	})
+	if err != nil {
+		_, ok := status.FromError(err)
+		if !ok {
+			err = status.Errorf(codes.Internal, "unknown err: %s", err)
+		}
+		return nil, err
+	}
+
+	if s.Signer != nil {
+		rv.MapHead.Signature, err = signTreeHeadText(s.Signer, MapTreeHeadText(req.Map, rv.MapHead))
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "error signing tree head: %s", err)
+		}
+		rv.TreeHeadLogHead.Signature, err = signTreeHeadText(s.Signer, LogTreeHeadText(thLog, rv.TreeHeadLogHead))
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "error signing tree head: %s", err)
+		}
+	}
+
+	return rv, nil
+}
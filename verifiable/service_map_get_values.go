@@ -0,0 +1,414 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"sort"
+
+	"github.com/continusec/verifiabledatastructures/merkle"
+	"github.com/continusec/verifiabledatastructures/pb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxMapGetValues is the most keys that may be requested in a single call to MapGetValues
+const maxMapGetValues = 1000
+
+// MapGetValues returns the values for many keys in a map, with a single proof of their inclusion
+// in which subtrees shared by the keys appear only once.
+func (s *localServiceImpl) MapGetValues(ctx context.Context, req *pb.MapGetValuesRequest) (*pb.MapGetValuesResponse, error) {
+	am, err := s.verifyAccessForMap(ctx, req.Map, pb.Permission_PERM_MAP_GET_VALUE)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "no access: %s", err)
+	}
+
+	if req.TreeSize < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "bad tree size")
+	}
+
+	if len(req.Keys) == 0 || len(req.Keys) > maxMapGetValues {
+		return nil, status.Errorf(codes.InvalidArgument, "bad number of keys")
+	}
+
+	var rv *pb.MapGetValuesResponse
+	ns, err := s.mapBucket(req.Map)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unknown err: %s", err)
+	}
+	err = s.Reader.ExecuteReadOnly(ctx, ns, func(ctx context.Context, kr KeyReader) error {
+		rv, err = readMapValues(ctx, kr, req.Map, req.Keys, req.TreeSize, am, s.VRFKey)
+		return err
+	})
+	if err != nil {
+		_, ok := status.FromError(err)
+		if !ok {
+			err = status.Errorf(codes.Internal, "unknown err: %s", err)
+		}
+		return nil, err
+	}
+
+	return rv, nil
+}
+
+// readMapValues returns the values for keys, and a single proof of their inclusion, in the map of the
+// given size, or the latest if zero.
+func readMapValues(ctx context.Context, kr KeyReader, vmap *pb.MapRef, keys [][]byte, treeSize int64, am *AccessModifier, vrfKey ed25519.PrivateKey) (*pb.MapGetValuesResponse, error) {
+	err := checkNotPatriciaTrie(ctx, kr, vmap)
+	if err != nil {
+		return nil, err
+	}
+
+	alg, h, err := readHashAlgorithm(ctx, kr, vmap.HashAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	h, err = readMapTreeHasher(ctx, kr, h, vmap.SumField)
+	if err != nil {
+		return nil, err
+	}
+
+	th, err := lookupLogTreeHead(ctx, kr, pb.LogType_STRUCT_TYPE_TREEHEAD_LOG)
+	if err != nil {
+		return nil, err
+	}
+	if treeSize == 0 {
+		treeSize = th.TreeSize
+	}
+
+	// Are we asking for something silly?
+	if treeSize > th.TreeSize {
+		return nil, status.Errorf(codes.InvalidArgument, "bad tree size")
+	}
+
+	root, err := lookupProvableMapRoot(ctx, kr, treeSize)
+	if err != nil {
+		return nil, err
+	}
+
+	keyHashes := make([][]byte, len(keys))
+	var keyProofs [][]byte
+	for i, key := range keys {
+		var keyProof []byte
+		keyHashes[i], keyProof, err = readMapKeyHash(ctx, kr, h, vrfKey, key)
+		if err != nil {
+			return nil, err
+		}
+		if keyProof != nil {
+			keyProofs = append(keyProofs, keyProof)
+		}
+	}
+
+	mp := &mapMultiProver{
+		ctx:        ctx,
+		kr:         kr,
+		h:          h,
+		leafHashes: make(map[string][]byte),
+	}
+	err = mp.prove(root, make([]byte, mapKeyHashLength), 0, sortedKeyHashes(keyHashes))
+	if err != nil {
+		return nil, err
+	}
+
+	// Look up each distinct value once, then return one for each key requested
+	values := make(map[string]*pb.LeafData)
+	for kh, lh := range mp.leafHashes {
+		if bytes.Equal(lh, nullLeafHash(h)) {
+			continue
+		}
+		value, err := lookupDataByLeafHash(ctx, kr, pb.LogType_STRUCT_TYPE_MUTATION_LOG, lh)
+		if err != nil {
+			return nil, err
+		}
+
+		// Check for fields that need redacting
+		values[kh], err = filterLeafData(value, am)
+		if err != nil {
+			return nil, err
+		}
+	}
+	rv := make([]*pb.LeafData, len(keyHashes))
+	for i, kh := range keyHashes {
+		rv[i] = values[string(kh)]
+		if rv[i] == nil {
+			rv[i] = &pb.LeafData{} // empty value
+		}
+	}
+
+	return &pb.MapGetValuesResponse{
+		TreeSize:      treeSize,
+		Values:        rv,
+		AuditPath:     mp.auditPath,
+		HashAlgorithm: alg,
+		KeyProofs:     keyProofs,
+	}, nil
+}
+
+// sortedKeyHashes returns the distinct key hashes in order
+func sortedKeyHashes(keyHashes [][]byte) [][]byte {
+	rv := append([][]byte(nil), keyHashes...)
+	sort.Slice(rv, func(i, j int) bool {
+		return bytes.Compare(rv[i], rv[j]) < 0
+	})
+	distinct := rv[:0]
+	for i, kh := range rv {
+		if i == 0 || !bytes.Equal(rv[i-1], kh) {
+			distinct = append(distinct, kh)
+		}
+	}
+	return distinct
+}
+
+// splitKeyHashes returns the index of the first of the ordered key hashes that is in the right child
+// of the subtree at depth
+func splitKeyHashes(keyHashes [][]byte, depth int) int {
+	return sort.Search(len(keyHashes), func(i int) bool {
+		return keyHashBit(keyHashes[i], depth)
+	})
+}
+
+// mapMultiProver walks the stored map nodes for the leaves for many keys, all within a range of key
+// hashes. The zero range is the whole map.
+type mapMultiProver struct {
+	ctx context.Context
+	kr  KeyReader
+	h   merkle.Hasher
+	rng keyRange
+
+	leafHashes map[string][]byte // by key hash, for those keys with a leaf
+	auditPath  []*pb.MapSubTreeHash
+	boundary   []*pb.MapSubTreeHash
+}
+
+// prove finds the leaf for each of the ordered key hashes within the subtree at depth starting with kh,
+// and adds the hash of each largest non-empty subtree in the range that contains none of them to the
+// audit path, and each largest non-empty subtree outside the range to the boundary. mn may be nil for
+// an empty subtree.
+func (mp *mapMultiProver) prove(mn *pb.MapNode, kh []byte, depth int, keyHashes [][]byte) error {
+	if mn == nil || isEmptyNode(mp.h, mn) {
+		return nil
+	}
+
+	cls := mp.rng.classify(kh, depth)
+	if cls == subTreeOutside || (cls == subTreeInside && len(keyHashes) == 0) {
+		hash, err := calcNodeHash(mp.h, mn, uint(depth))
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(hash, mp.h.DefaultLeafValue(depth)) { // e.g. only deleted leaves
+			return nil
+		}
+		st := &pb.MapSubTreeHash{
+			Depth:   int32(depth),
+			KeyHash: kh,
+			Hash:    hash,
+		}
+		if cls == subTreeOutside {
+			mp.boundary = append(mp.boundary, st)
+		} else {
+			mp.auditPath = append(mp.auditPath, st)
+		}
+		return nil
+	}
+
+	i := splitKeyHashes(keyHashes, depth)
+	if isLeaf(mn) {
+		leafKeyHash := []byte(mn.Path[1:])
+		if len(keyHashes) == 1 && bytes.Equal(leafKeyHash, keyHashes[0]) {
+			mp.leafHashes[string(leafKeyHash)] = mn.LeafHash
+			return nil
+		}
+
+		// Leaves are stored above their full depth, so descend to the side it is on
+		right := BPath(mn.Path).At(uint(depth))
+		if right {
+			return mp.prove(mn, childKeyHash(kh, depth, true), depth+1, keyHashes[i:])
+		}
+		return mp.prove(mn, childKeyHash(kh, depth, false), depth+1, keyHashes[:i])
+	}
+
+	for _, c := range []struct {
+		right     bool
+		number    int64
+		keyHashes [][]byte
+	}{
+		{right: false, number: mn.LeftNumber, keyHashes: keyHashes[:i]},
+		{right: true, number: mn.RightNumber, keyHashes: keyHashes[i:]},
+	} {
+		if c.number == 0 {
+			continue
+		}
+		ckh := childKeyHash(kh, depth, c.right)
+		child, err := lookupMapHash(mp.ctx, mp.kr, c.number, bPathFromKeyHash(ckh).Slice(0, uint(depth+1)))
+		if err != nil {
+			return err
+		}
+		err = mp.prove(child, ckh, depth+1, c.keyHashes)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VerifyMapGetValues verifies that the values in a response are those for the keys, in the order given,
+// in the MapTreeHead, using the hash algorithm given in the response.
+func VerifyMapGetValues(self *pb.MapGetValuesResponse, keys [][]byte, head *pb.MapTreeHashResponse) error {
+	h, err := HasherForAlgorithm(self.HashAlgorithm)
+	if err != nil {
+		return err
+	}
+	h = mapTreeHasher(h, head.SumField)
+	if self.TreeSize != head.MutationLog.TreeSize {
+		return ErrVerificationFailed
+	}
+	if len(keys) == 0 || len(self.Values) != len(keys) {
+		return ErrVerificationFailed
+	}
+	if len(head.VrfPublicKey) != 0 && len(self.KeyProofs) != len(keys) {
+		return ErrVerificationFailed
+	}
+
+	// The same key requested more than once must have the same value each time
+	keyHashes := make([][]byte, len(keys))
+	leafHashes := make(map[string][]byte)
+	values := make(map[string]*pb.LeafData)
+	for i, key := range keys {
+		var keyProof []byte
+		if len(head.VrfPublicKey) != 0 {
+			keyProof = self.KeyProofs[i]
+		}
+		keyHashes[i], err = verifiedKeyHash(h, head.VrfPublicKey, key, keyProof)
+		if err != nil {
+			return err
+		}
+		lh := h.LeafHash(self.Values[i].GetLeafInput())
+		if prev, ok := leafHashes[string(keyHashes[i])]; ok && !bytes.Equal(prev, lh) {
+			return ErrVerificationFailed
+		}
+		leafHashes[string(keyHashes[i])] = lh
+		values[string(keyHashes[i])] = self.Values[i]
+	}
+	r, err := calcMapRootHash(h, keyRange{}, sortedKeyHashes(keyHashes), values, self.AuditPath, nil)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(r, head.RootHash) {
+		return ErrVerificationFailed
+	}
+
+	// all clear
+	return nil
+}
+
+// calcMapRootHash calculates the map root hash given the values for the ordered key hashes, which must be
+// within rng, the hashes of the largest non-empty subtrees in rng containing none of them, and the hashes of the
+// largest non-empty subtrees outside rng. The zero range is the whole map.
+func calcMapRootHash(h merkle.Hasher, rng keyRange, keyHashes [][]byte, values map[string]*pb.LeafData, auditPath, boundary []*pb.MapSubTreeHash) ([]byte, error) {
+	for _, kh := range keyHashes {
+		if rng.classify(kh, 256) != subTreeInside {
+			return nil, ErrVerificationFailed
+		}
+	}
+
+	// Subtree hashes must not contain any of the keys, and be inside or outside the range as expected
+	type subTree struct {
+		depth   int
+		keyHash string
+	}
+	supplied := make(map[subTree][]byte)
+	var starts [][]byte // first key hash of each supplied subtree, in order
+	for _, c := range []struct {
+		subTrees []*pb.MapSubTreeHash
+		cls      int
+	}{
+		{subTrees: auditPath, cls: subTreeInside},
+		{subTrees: boundary, cls: subTreeOutside},
+	} {
+		for _, b := range c.subTrees {
+			if b.Depth < 0 || b.Depth > 256 || len(b.KeyHash) != mapKeyHashLength {
+				return nil, ErrVerificationFailed
+			}
+			first, last := subTreeBounds(b.KeyHash, int(b.Depth))
+			if !bytes.Equal(first, b.KeyHash) || rng.classify(b.KeyHash, int(b.Depth)) != c.cls {
+				return nil, ErrVerificationFailed
+			}
+			i := sort.Search(len(keyHashes), func(i int) bool {
+				return bytes.Compare(keyHashes[i], first) >= 0
+			})
+			if i < len(keyHashes) && bytes.Compare(keyHashes[i], last) <= 0 {
+				return nil, ErrVerificationFailed
+			}
+			st := subTree{depth: int(b.Depth), keyHash: string(b.KeyHash)}
+			if _, ok := supplied[st]; ok {
+				return nil, ErrVerificationFailed
+			}
+			supplied[st] = b.Hash
+			starts = append(starts, b.KeyHash)
+		}
+	}
+	sort.Slice(starts, func(i, j int) bool {
+		return bytes.Compare(starts[i], starts[j]) < 0
+	})
+
+	// Calculate the hash for the subtree at depth starting with kh, containing the ordered key hashes
+	used := 0
+	var calc func(kh []byte, depth int, keyHashes [][]byte) []byte
+	calc = func(kh []byte, depth int, keyHashes [][]byte) []byte {
+		switch len(keyHashes) {
+		case 0:
+			rv, ok := supplied[subTree{depth: depth, keyHash: string(kh)}]
+			if ok {
+				used++
+				return rv
+			}
+			if rng.classify(kh, depth) != subTreePartial {
+				return h.DefaultLeafValue(depth)
+			}
+		case 1:
+			// Shortcut if nothing else was supplied in this subtree
+			first, last := subTreeBounds(kh, depth)
+			i := sort.Search(len(starts), func(i int) bool {
+				return bytes.Compare(starts[i], first) >= 0
+			})
+			if i == len(starts) || bytes.Compare(starts[i], last) > 0 {
+				value := values[string(keyHashes[0])]
+				rv, _ := calcNodeHash(h, &pb.MapNode{
+					Path:     bPathFromKeyHash(keyHashes[0]),
+					LeafHash: h.LeafHash(value.GetLeafInput()),
+					LeafSum:  mapLeafSum(h, value),
+				}, uint(depth))
+				return rv
+			}
+		}
+		i := splitKeyHashes(keyHashes, depth)
+		return h.NodeHash(
+			calc(childKeyHash(kh, depth, false), depth+1, keyHashes[:i]),
+			calc(childKeyHash(kh, depth, true), depth+1, keyHashes[i:]),
+		)
+	}
+
+	r := calc(make([]byte, mapKeyHashLength), 0, keyHashes)
+	if used != len(supplied) {
+		return nil, ErrVerificationFailed
+	}
+	return r, nil
+}
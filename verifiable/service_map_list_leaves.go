@@ -0,0 +1,382 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/continusec/verifiabledatastructures/merkle"
+	"github.com/continusec/verifiabledatastructures/pb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxMapListLeaves is the most leaves returned in a single page by MapListLeaves
+const maxMapListLeaves = 1000
+
+// mapKeyHashLength is the length of the key hash for all supported hash algorithms
+const mapKeyHashLength = 32
+
+// MapListLeaves returns a page of leaves from a map, in key hash order, with the subtree hashes
+// needed to prove that no leaves were omitted.
+func (s *localServiceImpl) MapListLeaves(ctx context.Context, req *pb.MapListLeavesRequest) (*pb.MapListLeavesResponse, error) {
+	am, err := s.verifyAccessForMap(ctx, req.Map, pb.Permission_PERM_MAP_GET_VALUE)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "no access: %s", err)
+	}
+
+	if req.TreeSize < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "bad tree size")
+	}
+
+	start := req.StartKeyHash
+	if len(start) == 0 {
+		start = make([]byte, mapKeyHashLength)
+	}
+	if len(start) != mapKeyHashLength {
+		return nil, status.Errorf(codes.InvalidArgument, "bad start key hash")
+	}
+
+	limit := int(req.Limit)
+	if limit <= 0 || limit > maxMapListLeaves {
+		limit = maxMapListLeaves
+	}
+
+	var rv *pb.MapListLeavesResponse
+	ns, err := s.mapBucket(req.Map)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unknown err: %s", err)
+	}
+	err = s.Reader.ExecuteReadOnly(ctx, ns, func(ctx context.Context, kr KeyReader) error {
+		rv, err = readMapLeaves(ctx, kr, req.Map, req.TreeSize, start, limit, am)
+		return err
+	})
+	if err != nil {
+		_, ok := status.FromError(err)
+		if !ok {
+			err = status.Errorf(codes.Internal, "unknown err: %s", err)
+		}
+		return nil, err
+	}
+
+	return rv, nil
+}
+
+// readMapLeaves returns up to limit leaves from start, in the map of the given size, or the latest if zero.
+func readMapLeaves(ctx context.Context, kr KeyReader, vmap *pb.MapRef, treeSize int64, start []byte, limit int, am *AccessModifier) (*pb.MapListLeavesResponse, error) {
+	err := checkNotPatriciaTrie(ctx, kr, vmap)
+	if err != nil {
+		return nil, err
+	}
+
+	alg, h, err := readHashAlgorithm(ctx, kr, vmap.HashAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	h, err = readMapTreeHasher(ctx, kr, h, vmap.SumField)
+	if err != nil {
+		return nil, err
+	}
+
+	th, err := lookupLogTreeHead(ctx, kr, pb.LogType_STRUCT_TYPE_TREEHEAD_LOG)
+	if err != nil {
+		return nil, err
+	}
+	if treeSize == 0 {
+		treeSize = th.TreeSize
+	}
+
+	// Are we asking for something silly?
+	if treeSize > th.TreeSize {
+		return nil, status.Errorf(codes.InvalidArgument, "bad tree size")
+	}
+
+	root, err := lookupProvableMapRoot(ctx, kr, treeSize)
+	if err != nil {
+		return nil, err
+	}
+
+	// Look for one more than we need, which marks the end of the page
+	ll := &mapLeafLister{
+		ctx:   ctx,
+		kr:    kr,
+		h:     h,
+		rng:   keyRange{start: start},
+		limit: limit + 1,
+	}
+	first := make([]byte, mapKeyHashLength)
+	err = ll.collect(root, first, 0)
+	if err != nil {
+		return nil, err
+	}
+	var next []byte
+	if len(ll.leaves) > limit {
+		next = ll.leaves[limit].KeyHash
+		ll.leaves = ll.leaves[:limit]
+		ll.rng.end = next
+	}
+
+	// Now that we know the end, prove everything else
+	err = ll.prove(root, first, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, leaf := range ll.leaves {
+		leaf.Value, err = lookupDataByLeafHash(ctx, kr, pb.LogType_STRUCT_TYPE_MUTATION_LOG, leaf.LeafHash)
+		if err != nil {
+			return nil, err
+		}
+
+		// Check for fields that need redacting
+		leaf.Value, err = filterLeafData(leaf.Value, am)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &pb.MapListLeavesResponse{
+		TreeSize:      treeSize,
+		Leaves:        ll.leaves,
+		NextKeyHash:   next,
+		Boundary:      ll.boundary,
+		HashAlgorithm: alg,
+	}, nil
+}
+
+// mapLeafLister walks the stored map nodes for a page of leaves
+type mapLeafLister struct {
+	ctx   context.Context
+	kr    KeyReader
+	h     merkle.Hasher
+	rng   keyRange
+	limit int
+
+	leaves   []*pb.MapLeaf
+	boundary []*pb.MapSubTreeHash
+}
+
+// child returns the left or right child, which may be nil, of an internal node, along with the first key hash for it
+func (ll *mapLeafLister) child(mn *pb.MapNode, kh []byte, depth int, right bool) (*pb.MapNode, []byte, error) {
+	ckh := childKeyHash(kh, depth, right)
+	number := mn.LeftNumber
+	if right {
+		number = mn.RightNumber
+	}
+	if number == 0 {
+		return nil, ckh, nil
+	}
+	child, err := lookupMapHash(ll.ctx, ll.kr, number, bPathFromKeyHash(ckh).Slice(0, uint(depth+1)))
+	if err != nil {
+		return nil, nil, err
+	}
+	return child, ckh, nil
+}
+
+// collect adds non-empty leaves in the subtree at depth, starting with kh, that are not before the start
+// of the range, in order, until the limit is reached
+func (ll *mapLeafLister) collect(mn *pb.MapNode, kh []byte, depth int) error {
+	if len(ll.leaves) >= ll.limit {
+		return nil
+	}
+
+	if isLeaf(mn) {
+		leafKeyHash := []byte(mn.Path[1:])
+		if !bytes.Equal(mn.LeafHash, nullLeafHash(ll.h)) && bytes.Compare(leafKeyHash, ll.rng.start) >= 0 {
+			ll.leaves = append(ll.leaves, &pb.MapLeaf{
+				KeyHash:  leafKeyHash,
+				LeafHash: mn.LeafHash,
+			})
+		}
+		return nil
+	}
+
+	for _, right := range []bool{false, true} {
+		// The end of the range is not yet set, so outside means before the start
+		if ll.rng.classify(childKeyHash(kh, depth, right), depth+1) == subTreeOutside {
+			continue
+		}
+		child, ckh, err := ll.child(mn, kh, depth, right)
+		if err != nil {
+			return err
+		}
+		if child != nil {
+			err = ll.collect(child, ckh, depth+1)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// prove adds the hash of each largest non-empty subtree outside the range, within the subtree at depth starting with kh
+func (ll *mapLeafLister) prove(mn *pb.MapNode, kh []byte, depth int) error {
+	switch ll.rng.classify(kh, depth) {
+	case subTreeInside:
+		return nil
+	case subTreeOutside:
+		if isEmptyNode(ll.h, mn) {
+			return nil
+		}
+		hash, err := calcNodeHash(ll.h, mn, uint(depth))
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(hash, ll.h.DefaultLeafValue(depth)) { // e.g. only deleted leaves
+			return nil
+		}
+		ll.boundary = append(ll.boundary, &pb.MapSubTreeHash{
+			Depth:   int32(depth),
+			KeyHash: kh,
+			Hash:    hash,
+		})
+		return nil
+	}
+
+	if isLeaf(mn) {
+		// Leaves are stored above their full depth, so descend to the side it is on
+		right := BPath(mn.Path).At(uint(depth))
+		return ll.prove(mn, childKeyHash(kh, depth, right), depth+1)
+	}
+
+	for _, right := range []bool{false, true} {
+		child, ckh, err := ll.child(mn, kh, depth, right)
+		if err != nil {
+			return err
+		}
+		if child != nil {
+			err = ll.prove(child, ckh, depth+1)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// VerifyMapListLeaves verifies that a page of leaves is every non-empty leaf in the MapTreeHead with a key hash from
+// start (which may be empty for the start of the map) up to the NextKeyHash in the page, using the hash algorithm
+// given in the page. Pages may be verified one after the other to verify that every leaf in the map was listed.
+func VerifyMapListLeaves(self *pb.MapListLeavesResponse, start []byte, head *pb.MapTreeHashResponse) error {
+	h, err := HasherForAlgorithm(self.HashAlgorithm)
+	if err != nil {
+		return err
+	}
+	h = mapTreeHasher(h, head.SumField)
+	if self.TreeSize != head.MutationLog.TreeSize {
+		return ErrVerificationFailed
+	}
+
+	if len(start) == 0 {
+		start = make([]byte, mapKeyHashLength)
+	}
+	if len(start) != mapKeyHashLength {
+		return ErrVerificationFailed
+	}
+	rng := keyRange{start: start}
+	if len(self.NextKeyHash) != 0 {
+		if len(self.NextKeyHash) != mapKeyHashLength || bytes.Compare(self.NextKeyHash, start) <= 0 {
+			return ErrVerificationFailed
+		}
+		rng.end = self.NextKeyHash
+	}
+
+	// Leaves must be in order, non-empty, and in the range
+	for i, leaf := range self.Leaves {
+		if len(leaf.KeyHash) != mapKeyHashLength || rng.classify(leaf.KeyHash, 256) != subTreeInside {
+			return ErrVerificationFailed
+		}
+		if i > 0 && bytes.Compare(self.Leaves[i-1].KeyHash, leaf.KeyHash) >= 0 {
+			return ErrVerificationFailed
+		}
+		if leaf.Value == nil || !bytes.Equal(leaf.LeafHash, h.LeafHash(leaf.Value.LeafInput)) {
+			return ErrVerificationFailed
+		}
+	}
+
+	// Subtree hashes must be outside the range
+	type subTree struct {
+		depth   int
+		keyHash string
+	}
+	supplied := make(map[subTree][]byte)
+	for _, b := range self.Boundary {
+		if b.Depth < 0 || b.Depth > 256 || len(b.KeyHash) != mapKeyHashLength {
+			return ErrVerificationFailed
+		}
+		first, _ := subTreeBounds(b.KeyHash, int(b.Depth))
+		if !bytes.Equal(first, b.KeyHash) || rng.classify(b.KeyHash, int(b.Depth)) != subTreeOutside {
+			return ErrVerificationFailed
+		}
+		st := subTree{depth: int(b.Depth), keyHash: string(b.KeyHash)}
+		if _, ok := supplied[st]; ok {
+			return ErrVerificationFailed
+		}
+		supplied[st] = b.Hash
+	}
+
+	// Calculate the hash for the subtree at depth starting with kh, containing leaves
+	used := 0
+	var calc func(kh []byte, depth int, leaves []*pb.MapLeaf) []byte
+	calc = func(kh []byte, depth int, leaves []*pb.MapLeaf) []byte {
+		switch rng.classify(kh, depth) {
+		case subTreeOutside:
+			rv, ok := supplied[subTree{depth: depth, keyHash: string(kh)}]
+			if !ok {
+				return h.DefaultLeafValue(depth)
+			}
+			used++
+			return rv
+		case subTreeInside:
+			switch len(leaves) {
+			case 0:
+				return h.DefaultLeafValue(depth)
+			case 1:
+				rv, _ := calcNodeHash(h, &pb.MapNode{
+					Path:     bPathFromKeyHash(leaves[0].KeyHash),
+					LeafHash: leaves[0].LeafHash,
+					LeafSum:  mapLeafSum(h, leaves[0].Value),
+				}, uint(depth))
+				return rv
+			}
+		}
+		// Leaves are in order, so those on the right follow those on the left
+		i := sort.Search(len(leaves), func(i int) bool {
+			return keyHashBit(leaves[i].KeyHash, depth)
+		})
+		return h.NodeHash(
+			calc(childKeyHash(kh, depth, false), depth+1, leaves[:i]),
+			calc(childKeyHash(kh, depth, true), depth+1, leaves[i:]),
+		)
+	}
+
+	r := calc(make([]byte, mapKeyHashLength), 0, self.Leaves)
+	if used != len(self.Boundary) {
+		return ErrVerificationFailed
+	}
+	if !bytes.Equal(r, head.RootHash) {
+		return ErrVerificationFailed
+	}
+
+	// all clear
+	return nil
+}
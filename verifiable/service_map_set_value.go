@@ -19,9 +19,9 @@ limitations under the License.
 package verifiable
 
 import (
+	"crypto/ed25519"
 	"time"
 
-	"github.com/continusec/verifiabledatastructures/merkle"
 	"github.com/continusec/verifiabledatastructures/pb"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc/codes"
@@ -58,30 +58,97 @@ func (s *localServiceImpl) MapSetValue(ctx context.Context, req *pb.MapSetValueR
 		return nil, status.Errorf(codes.Internal, "bad mutation creation: %s", err)
 	}
 
-	mutData, err := CreateJSONLeafDataFromMutation(mm)
+	leafHash, err := s.queueMapMutation(ctx, req.Map, mm)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "bad leaf data creation: %s", err)
+		return nil, err
+	}
+	return &pb.MapSetValueResponse{
+		LeafHash: leafHash,
+	}, nil
+}
+
+// queueMapMutation adds mm to the mutation log for the map, returning the leaf hash of the entry
+func (s *localServiceImpl) queueMapMutation(ctx context.Context, vmap *pb.MapRef, mm *pb.MapMutation) ([]byte, error) {
+	// Only the log a map is derived from can add to it
+	if s.derivedMapForMap(vmap) != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "map is derived from a log")
+	}
+
+	ns, err := s.mapBucket(vmap)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unknown err: %s", err)
+	}
+
+	h, err := s.checkHashAlgorithm(ctx, ns, vmap.HashAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkWriteOnce(ctx, ns, vmap.WriteOnce)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkSumField(ctx, ns, vmap.SumField)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.checkPatriciaTrie(ctx, ns, vmap)
+	if err != nil {
+		return nil, err
 	}
 
-	ns, err := mapBucket(req.Map)
+	// Only we can say where a key goes, so replace any proofs we were given
+	vrfKey, err := s.vrfKeyForUpdate(ctx, ns, vmap.VrfKeys)
+	if err != nil {
+		return nil, err
+	}
+	err = setMutationKeyProofs(vrfKey, mm)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "unknown err: %s", err)
 	}
+	mutLog := mutationLogForMap(vmap)
+	if vrfKey != nil {
+		mutLog.VrfPublicKey = vrfKey.Public().(ed25519.PublicKey)
+	}
+
+	mutData, err := CreateJSONLeafDataFromMutation(mm)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "bad leaf data creation: %s", err)
+	}
 
 	err = s.Mutator.QueueMutation(ctx, ns, &pb.Mutation{
 		LogAddEntry: &pb.LogAddEntryRequest{
-			Log: &pb.LogRef{
-				Account: req.Map.Account,
-				Name:    req.Map.Name,
-				LogType: pb.LogType_STRUCT_TYPE_MUTATION_LOG,
-			},
+			Log:   mutLog,
 			Value: mutData,
 		},
 	})
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "unknown err: %s", err)
 	}
-	return &pb.MapSetValueResponse{
-		LeafHash: merkle.LeafHash(mutData.LeafInput),
-	}, nil
+
+	err = s.queueMapCompaction(ctx, ns, vmap)
+	if err != nil {
+		return nil, err
+	}
+	return h.LeafHash(mutData.LeafInput), nil
+}
+
+// queueMapCompaction compacts the map after a mutation if there is a retention policy, so that storage
+// stays proportional to the sizes retained
+func (s *localServiceImpl) queueMapCompaction(ctx context.Context, ns []byte, vmap *pb.MapRef) error {
+	if s.MapRetention == nil {
+		return nil
+	}
+	err := s.Mutator.QueueMutation(ctx, ns, &pb.Mutation{
+		MapCompact: &pb.MapCompact{
+			Map:    vmap,
+			Policy: s.MapRetention,
+		},
+	})
+	if err != nil {
+		return status.Errorf(codes.Internal, "unknown err: %s", err)
+	}
+	return nil
 }
@@ -37,57 +37,109 @@ func (s *localServiceImpl) MapTreeHash(ctx context.Context, req *pb.MapTreeHashR
 	}
 
 	var rv *pb.MapTreeHashResponse
-	ns, err := mapBucket(req.Map)
+	ns, err := s.mapBucket(req.Map)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "unknown err: %s", err)
 	}
 	err = s.Reader.ExecuteReadOnly(ctx, ns, func(ctx context.Context, kr KeyReader) error {
-		th, err := lookupLogTreeHead(ctx, kr, pb.LogType_STRUCT_TYPE_TREEHEAD_LOG)
-		if err != nil {
-			return err
-		}
-		treeSize := req.TreeSize
-		if treeSize == 0 {
-			treeSize = th.TreeSize
-		}
-
-		// Are we asking for something silly?
-		if treeSize > th.TreeSize {
-			return status.Errorf(codes.InvalidArgument, "bad tree size")
+		rv, err = readMapTreeHash(ctx, kr, req.Map, req.TreeSize)
+		return err
+	})
+	if err != nil {
+		_, ok := status.FromError(err)
+		if !ok {
+			err = status.Errorf(codes.Internal, "unknown err: %s", err)
 		}
+		return nil, err
+	}
 
-		// Need this for response
-		mutHead, err := lookupLogRootHashBySize(ctx, kr, pb.LogType_STRUCT_TYPE_MUTATION_LOG, treeSize)
+	if s.Signer != nil {
+		rv.Signature, err = signTreeHeadText(s.Signer, MapTreeHeadText(req.Map, rv))
 		if err != nil {
-			return err
+			return nil, status.Errorf(codes.Internal, "error signing tree head: %s", err)
 		}
+	}
 
-		// Get the root node for tree size
-		mapNode, err := lookupMapHash(ctx, kr, treeSize, BPathEmpty)
+	return rv, nil
+}
+
+// readMapTreeHash returns the unsigned tree hash for a map of the given size, or the latest if zero.
+func readMapTreeHash(ctx context.Context, kr KeyReader, vmap *pb.MapRef, treeSize int64) (*pb.MapTreeHashResponse, error) {
+	th, err := lookupLogTreeHead(ctx, kr, pb.LogType_STRUCT_TYPE_TREEHEAD_LOG)
+	if err != nil {
+		return nil, err
+	}
+	if treeSize == 0 {
+		treeSize = th.TreeSize
+	}
+
+	// Are we asking for something silly?
+	if treeSize > th.TreeSize {
+		return nil, status.Errorf(codes.InvalidArgument, "bad tree size")
+	}
+
+	// Need this for response, an empty map has an empty mutation log
+	mutHead := &pb.LogTreeHash{}
+	rejected := false
+	if treeSize != 0 {
+		mutHead, err = lookupLogRootHashBySize(ctx, kr, pb.LogType_STRUCT_TYPE_MUTATION_LOG, treeSize)
 		if err != nil {
-			return err
+			return nil, err
 		}
-
-		rh, err := calcNodeHash(mapNode, 0)
+		rejected, err = lookupMapRejection(ctx, kr, treeSize-1)
 		if err != nil {
-			return err
+			return nil, err
 		}
+	}
 
-		rv = &pb.MapTreeHashResponse{
-			RootHash: rh,
-			MutationLog: &pb.LogTreeHashResponse{
-				RootHash: mutHead.Mth,
-				TreeSize: treeSize,
-			},
-		}
-		return nil
-	})
+	// Get the root node for tree size
+	mapNode, err := lookupMapHash(ctx, kr, treeSize, BPathEmpty)
 	if err != nil {
-		_, ok := status.FromError(err)
-		if !ok {
-			err = status.Errorf(codes.Internal, "unknown err: %s", err)
+		return nil, err
+	}
+
+	_, h, err := readHashAlgorithm(ctx, kr, vmap.HashAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+	sumField, err := readSumField(ctx, kr, vmap.SumField)
+	if err != nil {
+		return nil, err
+	}
+	h = mapTreeHasher(h, sumField)
+
+	rh, err := calcNodeHash(h, mapNode, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	vrfPub, err := readVRFPublicKey(ctx, kr)
+	if err != nil {
+		return nil, err
+	}
+
+	// A patricia trie map has the root hash of its trie instead
+	patricia, err := readPatriciaTrie(ctx, kr, vmap.PatriciaTrie)
+	if err != nil {
+		return nil, err
+	}
+	if patricia {
+		pr, err := lookupMapPatriciaRoot(ctx, kr, treeSize)
+		if err != nil {
+			return nil, err
 		}
+		rh = pr.RootHash
 	}
 
-	return rv, nil
+	return &pb.MapTreeHashResponse{
+		RootHash: rh,
+		MutationLog: &pb.LogTreeHashResponse{
+			RootHash: mutHead.Mth,
+			TreeSize: treeSize,
+		},
+		Rejected:     rejected,
+		VrfPublicKey: vrfPub,
+		SumField:     sumField,
+		PatriciaTrie: patricia,
+	}, nil
 }
@@ -0,0 +1,59 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"github.com/continusec/verifiabledatastructures/pb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MultimapAddValue adds a value to the list of values for a key in a multimap
+func (s *localServiceImpl) MultimapAddValue(ctx context.Context, req *pb.MultimapAddValueRequest) (*pb.MultimapAddValueResponse, error) {
+	_, err := s.verifyAccessForMultimap(ctx, req.Multimap, pb.Permission_PERM_MAP_SET_VALUE)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "no access: %s", err)
+	}
+
+	if req.Value == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "no value")
+	}
+
+	ns, err := multimapBucket(req.Multimap)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unknown err: %s", err)
+	}
+
+	h, err := s.checkHashAlgorithm(ctx, ns, req.Multimap.HashAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.Mutator.QueueMutation(ctx, ns, &pb.Mutation{
+		MultimapAddValue: req,
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "error queuing mutation: %s", err)
+	}
+
+	return &pb.MultimapAddValueResponse{
+		LeafHash: h.LeafHash(req.Value.LeafInput),
+	}, nil
+}
@@ -0,0 +1,108 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"bytes"
+
+	"github.com/continusec/verifiabledatastructures/pb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MultimapGetValues returns the values for a key in a multimap, along with proof of the tree head of the log of
+// values for the key
+func (s *localServiceImpl) MultimapGetValues(ctx context.Context, req *pb.MultimapGetValuesRequest) (*pb.MultimapGetValuesResponse, error) {
+	am, err := s.verifyAccessForMultimap(ctx, req.Multimap, pb.Permission_PERM_MAP_GET_VALUE)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "no access: %s", err)
+	}
+
+	if req.TreeSize < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "bad tree size")
+	}
+
+	var rv *pb.MultimapGetValuesResponse
+	ns, err := multimapBucket(req.Multimap)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unknown err: %s", err)
+	}
+	err = s.Reader.ExecuteReadOnly(ctx, ns, func(ctx context.Context, kr KeyReader) error {
+		kv, keyHead, keyKR, err := readMultimapKeyHead(ctx, kr, req.Multimap, req.Key, req.TreeSize)
+		if err != nil {
+			return err
+		}
+		values := make([]*pb.LeafData, keyHead.TreeSize)
+		for i := range values {
+			ln, err := lookupLeafNodeByIndex(ctx, keyKR, pb.LogType_STRUCT_TYPE_LOG, int64(i))
+			if err != nil {
+				return err
+			}
+			v, err := lookupDataByLeafHash(ctx, keyKR, pb.LogType_STRUCT_TYPE_LOG, ln.Mth)
+			if err != nil {
+				return err
+			}
+			values[i], err = filterLeafData(v, am)
+			if err != nil {
+				return err
+			}
+		}
+		rv = &pb.MultimapGetValuesResponse{
+			KeyHead: kv,
+			Values:  values,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, multimapStatusError(err)
+	}
+
+	return rv, nil
+}
+
+// VerifyMultimapGetValues verifies that the values returned for a key are all of those in the log of values for
+// the key, in order, and that the tree head of that log is included in the multimap tree head.
+func VerifyMultimapGetValues(self *pb.MultimapGetValuesResponse, key []byte, head *pb.MapTreeHashResponse) error {
+	keyHead, err := verifyMultimapKeyHead(self.KeyHead, key, head)
+	if err != nil {
+		return err
+	}
+	if int64(len(self.Values)) != keyHead.TreeSize {
+		return ErrVerificationFailed
+	}
+	if keyHead.TreeSize == 0 {
+		return nil
+	}
+
+	h, err := HasherForAlgorithm(self.KeyHead.HashAlgorithm)
+	if err != nil {
+		return err
+	}
+	leafHashes := make([][]byte, len(self.Values))
+	for i, v := range self.Values {
+		leafHashes[i] = h.LeafHash(v.GetLeafInput())
+	}
+	if !bytes.Equal(logRootHash(h, leafHashes), keyHead.RootHash) {
+		return ErrVerificationFailed
+	}
+
+	// all clear
+	return nil
+}
@@ -0,0 +1,93 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"github.com/continusec/verifiabledatastructures/pb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MultimapInclusionProof returns proof that a value is in the list of values for a key in a multimap
+func (s *localServiceImpl) MultimapInclusionProof(ctx context.Context, req *pb.MultimapInclusionProofRequest) (*pb.MultimapInclusionProofResponse, error) {
+	_, err := s.verifyAccessForMultimap(ctx, req.Multimap, pb.Permission_PERM_MAP_GET_VALUE)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "no access: %s", err)
+	}
+
+	if req.TreeSize < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "bad tree size")
+	}
+
+	var rv *pb.MultimapInclusionProofResponse
+	ns, err := multimapBucket(req.Multimap)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unknown err: %s", err)
+	}
+	err = s.Reader.ExecuteReadOnly(ctx, ns, func(ctx context.Context, kr KeyReader) error {
+		kv, keyHead, keyKR, err := readMultimapKeyHead(ctx, kr, req.Multimap, req.Key, req.TreeSize)
+		if err != nil {
+			return err
+		}
+		if keyHead.TreeSize == 0 {
+			return status.Errorf(codes.NotFound, "cannot find it")
+		}
+		// A value added for the key after this size is not in its list, rather than needing a later size
+		valueIndex := req.ValueIndex
+		if len(req.MtlHash) != 0 {
+			ei, err := lookupIndexByLeafHash(ctx, keyKR, pb.LogType_STRUCT_TYPE_LOG, req.MtlHash)
+			if err != nil {
+				return err
+			}
+			valueIndex = ei.Index
+		}
+		if valueIndex >= keyHead.TreeSize {
+			return status.Errorf(codes.NotFound, "cannot find it")
+		}
+		proof, err := readLogInclusionProof(ctx, keyKR, keyLogForMultimap(req.Multimap), keyHead.TreeSize, valueIndex, nil)
+		if err != nil {
+			return err
+		}
+		rv = &pb.MultimapInclusionProofResponse{
+			KeyHead: kv,
+			Proof:   proof,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, multimapStatusError(err)
+	}
+
+	return rv, nil
+}
+
+// VerifyMultimapInclusionProof verifies that the value with the given leaf hash is in the log of values for
+// a key, and that the tree head of that log is included in the multimap tree head.
+func VerifyMultimapInclusionProof(self *pb.MultimapInclusionProofResponse, key, leafHash []byte, head *pb.MapTreeHashResponse) error {
+	keyHead, err := verifyMultimapKeyHead(self.KeyHead, key, head)
+	if err != nil {
+		return err
+	}
+	// The log of values must use the same hash algorithm as the map
+	if self.Proof == nil || self.Proof.HashAlgorithm != self.KeyHead.HashAlgorithm {
+		return ErrVerificationFailed
+	}
+	return VerifyLogInclusionProof(self.Proof, leafHash, keyHead)
+}
@@ -0,0 +1,119 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"bytes"
+
+	"github.com/continusec/verifiabledatastructures/pb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MultimapKeyConsistencyProof returns proof that the list of values for a key in a multimap of one size was only
+// appended to in the multimap of a later size
+func (s *localServiceImpl) MultimapKeyConsistencyProof(ctx context.Context, req *pb.MultimapKeyConsistencyProofRequest) (*pb.MultimapKeyConsistencyProofResponse, error) {
+	_, err := s.verifyAccessForMultimap(ctx, req.Multimap, pb.Permission_PERM_MAP_GET_VALUE)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "no access: %s", err)
+	}
+
+	if req.FromSize < 0 || req.TreeSize < 0 || (req.TreeSize != 0 && req.FromSize > req.TreeSize) {
+		return nil, status.Errorf(codes.InvalidArgument, "tree size out of range")
+	}
+
+	var rv *pb.MultimapKeyConsistencyProofResponse
+	ns, err := multimapBucket(req.Multimap)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unknown err: %s", err)
+	}
+	err = s.Reader.ExecuteReadOnly(ctx, ns, func(ctx context.Context, kr KeyReader) error {
+		kv, keyHead, keyKR, err := readMultimapKeyHead(ctx, kr, req.Multimap, req.Key, req.TreeSize)
+		if err != nil {
+			return err
+		}
+		// The empty map has no value for any key
+		fromKV := &pb.MapGetValueResponse{}
+		fromKeyHead := &pb.LogTreeHashResponse{}
+		if req.FromSize != 0 {
+			if req.FromSize > kv.TreeSize {
+				return status.Errorf(codes.InvalidArgument, "tree size out of range")
+			}
+			fromKV, fromKeyHead, _, err = readMultimapKeyHead(ctx, kr, req.Multimap, req.Key, req.FromSize)
+			if err != nil {
+				return err
+			}
+		}
+		var proof *pb.LogConsistencyProofResponse
+		if fromKeyHead.TreeSize != 0 && fromKeyHead.TreeSize != keyHead.TreeSize {
+			proof, err = readLogConsistencyProof(ctx, keyKR, keyLogForMultimap(req.Multimap), fromKeyHead.TreeSize, keyHead.TreeSize)
+			if err != nil {
+				return err
+			}
+		}
+		rv = &pb.MultimapKeyConsistencyProofResponse{
+			FromKeyHead: fromKV,
+			KeyHead:     kv,
+			Proof:       proof,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, multimapStatusError(err)
+	}
+
+	return rv, nil
+}
+
+// VerifyMultimapKeyConsistencyProof verifies that the log of values for a key in the multimap tree head to
+// is an append-only extension of that in the multimap tree head from. If from is nil, the multimap was empty.
+func VerifyMultimapKeyConsistencyProof(self *pb.MultimapKeyConsistencyProofResponse, key []byte, from, to *pb.MapTreeHashResponse) error {
+	fromKeyHead := &pb.LogTreeHashResponse{}
+	if from != nil && from.GetMutationLog().GetTreeSize() != 0 {
+		var err error
+		fromKeyHead, err = verifyMultimapKeyHead(self.FromKeyHead, key, from)
+		if err != nil {
+			return err
+		}
+	}
+	keyHead, err := verifyMultimapKeyHead(self.KeyHead, key, to)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case fromKeyHead.TreeSize == 0:
+		// anything is consistent with an empty log
+		return nil
+	case fromKeyHead.TreeSize > keyHead.TreeSize:
+		return ErrVerificationFailed
+	case fromKeyHead.TreeSize == keyHead.TreeSize:
+		if !bytes.Equal(fromKeyHead.RootHash, keyHead.RootHash) {
+			return ErrVerificationFailed
+		}
+		return nil
+	}
+
+	// The log of values must use the same hash algorithm as the map
+	if self.Proof == nil || self.Proof.HashAlgorithm != self.KeyHead.HashAlgorithm {
+		return ErrVerificationFailed
+	}
+	return VerifyLogConsistencyProof(self.Proof, fromKeyHead, keyHead)
+}
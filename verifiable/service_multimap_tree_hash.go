@@ -0,0 +1,60 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"github.com/continusec/verifiabledatastructures/pb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MultimapTreeHash returns the tree hash for a multimap
+func (s *localServiceImpl) MultimapTreeHash(ctx context.Context, req *pb.MultimapTreeHashRequest) (*pb.MapTreeHashResponse, error) {
+	_, err := s.verifyAccessForMultimap(ctx, req.Multimap, pb.Permission_PERM_MAP_GET_VALUE)
+	if err != nil {
+		return nil, status.Errorf(codes.PermissionDenied, "no access: %s", err)
+	}
+
+	if req.TreeSize < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "bad tree size")
+	}
+
+	var rv *pb.MapTreeHashResponse
+	ns, err := multimapBucket(req.Multimap)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unknown err: %s", err)
+	}
+	err = s.Reader.ExecuteReadOnly(ctx, ns, func(ctx context.Context, kr KeyReader) error {
+		rv, err = readMapTreeHash(ctx, kr, mapForMultimap(req.Multimap), req.TreeSize)
+		return err
+	})
+	if err != nil {
+		return nil, multimapStatusError(err)
+	}
+
+	if s.Signer != nil {
+		rv.Signature, err = signTreeHeadText(s.Signer, MultimapTreeHeadText(req.Multimap, rv))
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "error signing tree head: %s", err)
+		}
+	}
+
+	return rv, nil
+}
@@ -0,0 +1,61 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
+)
+
+// callbackStream allows the client to call server-streaming methods on any
+// VerifiableDataStructuresServiceServer, passing each response to a callback.
+type callbackStream[T any] struct {
+	ctx  context.Context
+	send func(*T) error
+}
+
+func (e *callbackStream[T]) Send(resp *T) error {
+	return e.send(resp)
+}
+
+func (e *callbackStream[T]) Context() context.Context {
+	return e.ctx
+}
+
+func (e *callbackStream[T]) SetHeader(metadata.MD) error {
+	return nil
+}
+
+func (e *callbackStream[T]) SendHeader(metadata.MD) error {
+	return nil
+}
+
+func (e *callbackStream[T]) SetTrailer(metadata.MD) {}
+
+func (e *callbackStream[T]) SendMsg(m interface{}) error {
+	resp, ok := m.(*T)
+	if !ok {
+		return ErrInvalidRequest
+	}
+	return e.send(resp)
+}
+
+func (e *callbackStream[T]) RecvMsg(m interface{}) error {
+	return ErrNotImplemented
+}
@@ -0,0 +1,169 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/continusec/verifiabledatastructures/pb"
+)
+
+const (
+	// checkpointSignaturePrefix begins each signature line in a signed note
+	checkpointSignaturePrefix = "\u2014 "
+
+	// algEd25519 is the signature type identifier used in signed note key hashes
+	algEd25519 = 0x01
+)
+
+// checkpointKeyHash returns the 4 byte key hash that identifies a key in a signed note. For Ed25519
+// keys, this is as per the C2SP signed-note spec. For ECDSA keys, it is the first 4 bytes of the key
+// ID, as used by other ECDSA-signed checkpoints.
+func checkpointKeyHash(name string, pub crypto.PublicKey) ([]byte, error) {
+	switch key := pub.(type) {
+	case ed25519.PublicKey:
+		h := sha256.New()
+		h.Write([]byte(name))
+		h.Write([]byte{'\n', algEd25519})
+		h.Write(key)
+		return h.Sum(nil)[:4], nil
+	case *ecdsa.PublicKey:
+		keyID, err := TreeHeadKeyID(key)
+		if err != nil {
+			return nil, err
+		}
+		return keyID[:4], nil
+	default:
+		return nil, ErrNotImplemented
+	}
+}
+
+// FormatCheckpoint returns a log tree head in the C2SP checkpoint format, which is a signed note
+// with the log origin, tree size and base64 encoded root hash as its text. The tree head must be
+// signed by pub, and the key is named with the log origin.
+func FormatCheckpoint(log *pb.LogRef, head *pb.LogTreeHashResponse, pub crypto.PublicKey) ([]byte, error) {
+	err := VerifyLogTreeHeadSignature(log, head, pub)
+	if err != nil {
+		return nil, err
+	}
+
+	origin := LogOrigin(log)
+	keyHash, err := checkpointKeyHash(origin, pub)
+	if err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	b.Write(LogTreeHeadText(log, head))
+	b.WriteString("\n")
+	b.WriteString(checkpointSignaturePrefix + origin + " " + base64.StdEncoding.EncodeToString(append(keyHash, head.Signature.Signature...)) + "\n")
+	return b.Bytes(), nil
+}
+
+// ParseCheckpoint parses a checkpoint in the C2SP signed-note format, such as those produced by
+// FormatCheckpoint() or other transparency log tooling. The checkpoint must include a valid signature
+// by pub, for a key named keyName. For checkpoints produced by this package, keyName is the log origin.
+// Other signatures in the note are ignored. Returns the origin and tree head, with the signature set.
+// A checkpoint for an empty log has a tree size of 0 and the root hash of an empty tree.
+func ParseCheckpoint(data []byte, keyName string, pub crypto.PublicKey) (string, *pb.LogTreeHashResponse, error) {
+	if !utf8.Valid(data) {
+		return "", nil, ErrInvalidRequest
+	}
+	for _, c := range data {
+		if c < 0x20 && c != '\n' {
+			return "", nil, ErrInvalidRequest
+		}
+	}
+
+	// Text is separated from the signatures by the last blank line
+	idx := bytes.LastIndex(data, []byte("\n\n"))
+	if idx == -1 || idx+2 == len(data) || data[len(data)-1] != '\n' {
+		return "", nil, ErrInvalidRequest
+	}
+	text := data[:idx+1]
+	sigs := strings.Split(string(data[idx+2:len(data)-1]), "\n")
+
+	// Origin, size and root hash. Any further lines are extensions, which we ignore.
+	lines := strings.Split(string(text[:len(text)-1]), "\n")
+	if len(lines) < 3 || lines[0] == "" {
+		return "", nil, ErrInvalidRequest
+	}
+	origin := lines[0]
+	if len(lines[1]) > 1 && lines[1][0] == '0' {
+		return "", nil, ErrInvalidRequest // no leading zeroes
+	}
+	treeSize, err := strconv.ParseInt(lines[1], 10, 64)
+	if err != nil || treeSize < 0 || lines[1][0] == '+' {
+		return "", nil, ErrInvalidRequest
+	}
+	rootHash, err := base64.StdEncoding.DecodeString(lines[2])
+	if err != nil || len(rootHash) != sha256.Size {
+		return "", nil, ErrInvalidRequest
+	}
+
+	keyHash, err := checkpointKeyHash(keyName, pub)
+	if err != nil {
+		return "", nil, err
+	}
+	keyID, err := TreeHeadKeyID(pub)
+	if err != nil {
+		return "", nil, err
+	}
+
+	for _, line := range sigs {
+		if !strings.HasPrefix(line, checkpointSignaturePrefix) {
+			return "", nil, ErrInvalidRequest
+		}
+		parts := strings.Split(line[len(checkpointSignaturePrefix):], " ")
+		if len(parts) != 2 || parts[0] == "" {
+			return "", nil, ErrInvalidRequest
+		}
+		sig, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil || len(sig) <= len(keyHash) {
+			return "", nil, ErrInvalidRequest
+		}
+		if parts[0] != keyName || !bytes.Equal(sig[:len(keyHash)], keyHash) {
+			continue
+		}
+		ths := &pb.TreeHeadSignature{
+			KeyId:     keyID,
+			Signature: sig[len(keyHash):],
+		}
+		if verifyTreeHeadText(pub, text, ths) != nil {
+			continue
+		}
+
+		// all clear
+		return origin, &pb.LogTreeHashResponse{
+			TreeSize:  treeSize,
+			RootHash:  rootHash,
+			Signature: ths,
+		}, nil
+	}
+
+	return "", nil, ErrVerificationFailed
+}
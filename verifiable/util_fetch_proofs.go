@@ -69,8 +69,30 @@ func fetchSubTreeHashes(ctx context.Context, kr KeyReader, lt pb.LogType, ranges
 	return rv, nil
 }
 
+// fetchPathHashes returns the hash for each of the ranges, as returned by merkle.Path, calculating
+// any that are not stored. Assumes all args are range checked first.
+func fetchPathHashes(ctx context.Context, kr KeyReader, h merkle.Hasher, lt pb.LogType, ranges [][2]int64) ([][]byte, error) {
+	rv, err := fetchSubTreeHashes(ctx, kr, lt, ranges, false)
+	if err != nil {
+		return nil, err
+	}
+	for i, rr := range ranges {
+		if len(rv[i]) == 0 {
+			if merkle.IsPow2(rr[1] - rr[0]) {
+				// Would have been nice if GetSubTreeHashes could better handle these
+				return nil, ErrNotFound
+			}
+			rv[i], err = calcSubTreeHash(ctx, kr, h, lt, rr[0], rr[1])
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return rv, nil
+}
+
 /* Assumes all args are range checked first */
-func calcSubTreeHash(ctx context.Context, kr KeyReader, lt pb.LogType, start, end int64) ([]byte, error) {
+func calcSubTreeHash(ctx context.Context, kr KeyReader, h merkle.Hasher, lt pb.LogType, start, end int64) ([]byte, error) {
 	r := make([][2]int64, 0, 8) // magic number bad - why did we do this?
 
 	for start != end {
@@ -90,7 +112,7 @@ func calcSubTreeHash(ctx context.Context, kr KeyReader, lt pb.LogType, start, en
 
 	rv := hashes[len(hashes)-1]
 	for i := len(hashes) - 2; i >= 0; i-- {
-		rv = merkle.NodeHash(hashes[i], rv)
+		rv = h.NodeHash(hashes[i], rv)
 	}
 
 	return rv, nil
@@ -100,15 +122,15 @@ func isLeaf(mn *pb.MapNode) bool {
 	return len(mn.LeafHash) != 0
 }
 
-func calcNodeHash(mn *pb.MapNode, depth uint) ([]byte, error) {
+func calcNodeHash(h merkle.Hasher, mn *pb.MapNode, depth uint) ([]byte, error) {
 	if isLeaf(mn) {
-		rv := mn.LeafHash
+		rv := mapLeafNodeHash(h, mn.LeafHash, mn.LeafSum)
 		// Must make i int, else we underflow on next line and never terminate
 		for i := 255; i >= int(depth); i-- {
 			if BPath(mn.Path).At(uint(i)) {
-				rv = merkle.NodeHash(defaultLeafValues[i+1], rv)
+				rv = h.NodeHash(h.DefaultLeafValue(i+1), rv)
 			} else {
-				rv = merkle.NodeHash(rv, defaultLeafValues[i+1])
+				rv = h.NodeHash(rv, h.DefaultLeafValue(i+1))
 			}
 		}
 		return rv, nil
@@ -116,14 +138,14 @@ func calcNodeHash(mn *pb.MapNode, depth uint) ([]byte, error) {
 
 	var leftHash, rightHash []byte
 	if mn.LeftNumber == 0 {
-		leftHash = defaultLeafValues[depth+1]
+		leftHash = h.DefaultLeafValue(int(depth) + 1)
 	} else {
 		leftHash = mn.LeftHash
 	}
 	if mn.RightNumber == 0 {
-		rightHash = defaultLeafValues[depth+1]
+		rightHash = h.DefaultLeafValue(int(depth) + 1)
 	} else {
 		rightHash = mn.RightHash
 	}
-	return merkle.NodeHash(leftHash, rightHash), nil
+	return h.NodeHash(leftHash, rightHash), nil
 }
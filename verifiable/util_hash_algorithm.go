@@ -0,0 +1,106 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"github.com/continusec/verifiabledatastructures/merkle"
+	"github.com/continusec/verifiabledatastructures/pb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// HasherForAlgorithm returns the hash strategy for a hash algorithm.
+func HasherForAlgorithm(alg pb.HashAlgorithm) (merkle.Hasher, error) {
+	switch alg {
+	case pb.HashAlgorithm_HASH_SHA256:
+		return merkle.SHA256, nil
+	case pb.HashAlgorithm_HASH_SHA512_256:
+		return merkle.SHA512_256, nil
+	case pb.HashAlgorithm_HASH_BLAKE2B_256:
+		return merkle.BLAKE2b256, nil
+	case pb.HashAlgorithm_HASH_BLAKE3:
+		return merkle.BLAKE3, nil
+	default:
+		return nil, ErrNotImplemented
+	}
+}
+
+// readHashAlgorithm returns the hash algorithm recorded for the object. If nothing has been
+// added yet, then def is returned. Objects created before the algorithm was recorded are SHA256.
+func readHashAlgorithm(ctx context.Context, kr KeyReader, def pb.HashAlgorithm) (pb.HashAlgorithm, merkle.Hasher, error) {
+	conf, err := lookupObjectConfig(ctx, kr)
+	switch err {
+	case nil:
+		def = conf.HashAlgorithm
+	case ErrNoSuchKey:
+		size, err := ReadObjectSize(ctx, kr)
+		if err != nil {
+			return 0, nil, err
+		}
+		if size != 0 {
+			def = pb.HashAlgorithm_HASH_SHA256
+		}
+	default:
+		return 0, nil, err
+	}
+	h, err := HasherForAlgorithm(def)
+	if err != nil {
+		return 0, nil, status.Errorf(codes.InvalidArgument, "unknown hash algorithm: %s", def)
+	}
+	return def, h, nil
+}
+
+// hasherForUpdate records the config, including the hash algorithm, when the first entry is added
+// to an object, and returns the recorded hasher thereafter.
+func hasherForUpdate(ctx context.Context, db KeyWriter, sizeBefore int64, conf *pb.ObjectConfig) (merkle.Hasher, error) {
+	if sizeBefore == 0 {
+		err := writeObjectConfig(ctx, db, conf)
+		if err != nil {
+			return nil, err
+		}
+	}
+	_, h, err := readHashAlgorithm(ctx, db, conf.HashAlgorithm)
+	return h, err
+}
+
+// checkHashAlgorithm returns the hasher for an object that is about to be added to, failing if the
+// requested algorithm does not match that already recorded.
+func (s *localServiceImpl) checkHashAlgorithm(ctx context.Context, ns []byte, alg pb.HashAlgorithm) (merkle.Hasher, error) {
+	var rv merkle.Hasher
+	err := s.Reader.ExecuteReadOnly(ctx, ns, func(ctx context.Context, kr KeyReader) error {
+		recorded, h, err := readHashAlgorithm(ctx, kr, alg)
+		if err != nil {
+			return err
+		}
+		if recorded != alg {
+			return status.Errorf(codes.InvalidArgument, "hash algorithm %s does not match %s", alg, recorded)
+		}
+		rv = h
+		return nil
+	})
+	if err != nil {
+		_, ok := status.FromError(err)
+		if !ok {
+			err = status.Errorf(codes.Internal, "unknown err: %s", err)
+		}
+		return nil, err
+	}
+	return rv, nil
+}
@@ -41,12 +41,9 @@ func CreateJSONLeafDataFromMutation(mm *pb.MapMutation) (*pb.LeafData, error) {
 		return nil, err
 	}
 
-	if m.Value != nil {
-		m.Value.ExtraData = nil // we don't need this - TODO, think about some more
-		m.Value.Format = 0
-	}
+	shedMutationExtraData(&m)
 
-	data, err = json.Marshal(m)
+	data, err = json.Marshal(&m)
 	if err != nil {
 		return nil, err
 	}
@@ -73,6 +70,18 @@ func CreateJSONLeafDataFromMutation(mm *pb.MapMutation) (*pb.LeafData, error) {
 	}, nil
 }
 
+// shedMutationExtraData clears the extra data for the value in a mutation, and in each
+// operation of a transaction, as it is not included in the object hash.
+func shedMutationExtraData(m *pb.MapMutation) {
+	if m.Value != nil {
+		m.Value.ExtraData = nil // we don't need this - TODO, think about some more
+		m.Value.Format = 0
+	}
+	for _, op := range m.Operations {
+		shedMutationExtraData(op)
+	}
+}
+
 // CreateJSONLeafData creates a JSON based objecthash for the given JSON bytes.
 func CreateJSONLeafData(data []byte) (*pb.LeafData, error) {
 	var o interface{}
@@ -187,10 +196,7 @@ func ValidateJSONLeafDataFromMutation(entry *pb.LeafData) error {
 	if err != nil {
 		return ErrVerificationFailed
 	}
-	if o.Value != nil {
-		o.Value.ExtraData = nil
-		o.Value.Format = 0
-	}
+	shedMutationExtraData(o)
 
 	data, err := json.Marshal(o)
 	if err != nil {
@@ -18,6 +18,7 @@ package verifiable
 
 import (
 	"bytes"
+	"crypto/ed25519"
 	"encoding/json"
 	"fmt"
 	"reflect"
@@ -45,6 +46,12 @@ type mapAuditNode struct {
 	// Ignored if unless leaf is set. Actual value (differs from Hash since Hash takes into account RemPath)
 	LeafHash []byte
 
+	// Ignored if unless leaf is set. Mutation index at which LeafHash last changed, or -1 if never
+	Version int64
+
+	// Ignored if unless leaf is set. Value with LeafHash, used to derive the next value, nil if none
+	Value *pb.LeafData
+
 	// The Left and Right child nodes. May be nil.
 	Left, Right *mapAuditNode
 }
@@ -71,39 +78,43 @@ func (node *mapAuditNode) Dump() {
 }
 
 // Return hash for this node, calculating if necessary
-func (node *mapAuditNode) CalcHash() []byte {
+func (node *mapAuditNode) CalcHash(h merkle.Hasher) []byte {
 	if node.Hash == nil {
 		if node.Leaf {
-			node.Hash = node.LeafHash
+			node.Hash = mapLeafNodeHash(h, node.LeafHash, mapLeafSum(h, node.Value))
 			for i := 256; i > node.Depth; i-- {
 				if node.KeyPath[i-1] {
-					node.Hash = merkle.NodeHash(defaultLeafValues[i], node.Hash)
+					node.Hash = h.NodeHash(h.DefaultLeafValue(i), node.Hash)
 				} else {
-					node.Hash = merkle.NodeHash(node.Hash, defaultLeafValues[i])
+					node.Hash = h.NodeHash(node.Hash, h.DefaultLeafValue(i))
 				}
 			}
 		} else {
 			var left, right []byte
 			if node.Left == nil {
-				left = defaultLeafValues[node.Depth+1]
+				left = h.DefaultLeafValue(node.Depth + 1)
 			} else {
-				left = node.Left.CalcHash()
+				left = node.Left.CalcHash(h)
 			}
 			if node.Right == nil {
-				right = defaultLeafValues[node.Depth+1]
+				right = h.DefaultLeafValue(node.Depth + 1)
 			} else {
-				right = node.Right.CalcHash()
+				right = node.Right.CalcHash(h)
 			}
-			node.Hash = merkle.NodeHash(left, right)
+			node.Hash = h.NodeHash(left, right)
 		}
 	}
 	return node.Hash
 }
 
-// Given a root node, update it with a given map mutation, returning the new
-// root hash.
-func addMutationToTree(root *mapAuditNode, mut *pb.MapMutation) ([]byte, error) {
-	keyPath := merkle.ConstructMapKeyPath(mut.Key)
+// Given a root node, update it with a given map mutation from the mutation log entry at mutationIndex,
+// returning the new root hash. For a write-once map, a key with a non-empty value is never changed.
+func addMutationToTree(h merkle.Hasher, root *mapAuditNode, mut *pb.MapMutation, writeOnce bool, mutationIndex int64) ([]byte, error) {
+	kh, err := mutationKeyHash(h, mut)
+	if err != nil {
+		return nil, err
+	}
+	keyPath := merkle.KeyHashPath(kh)
 	head := root
 
 	// First, set head to as far down as we can go
@@ -127,8 +138,10 @@ func addMutationToTree(root *mapAuditNode, mut *pb.MapMutation) ([]byte, error)
 				Leaf:     true,
 				KeyPath:  head.KeyPath,
 				LeafHash: head.LeafHash,
+				Version:  head.Version,
+				Value:    head.Value,
 			}
-			head.Leaf, head.LeafHash, head.KeyPath = false, nil, nil
+			head.Leaf, head.LeafHash, head.KeyPath, head.Version, head.Value = false, nil, nil, 0, nil
 			if child.KeyPath[head.Depth] {
 				head.Left, head.Right = nil, child
 			} else {
@@ -141,7 +154,8 @@ func addMutationToTree(root *mapAuditNode, mut *pb.MapMutation) ([]byte, error)
 			Depth:    head.Depth + 1,
 			Leaf:     true,
 			KeyPath:  keyPath,
-			LeafHash: defaultLeafValues[256],
+			LeafHash: nullLeafHash(h),
+			Version:  -1,
 		}
 		if child.KeyPath[head.Depth] {
 			head.Right = child
@@ -152,27 +166,166 @@ func addMutationToTree(root *mapAuditNode, mut *pb.MapMutation) ([]byte, error)
 		head = child
 	}
 
+	next := head.LeafHash
 	switch mut.Action {
 	case "set":
-		head.LeafHash = merkle.LeafHash(mut.Value.LeafInput)
+		next = h.LeafHash(mut.Value.LeafInput)
 	case "delete":
-		head.LeafHash = defaultLeafValues[256]
+		next = nullLeafHash(h)
 	case "update":
 		if bytes.Equal(head.LeafHash, mut.PreviousLeafHash) {
-			head.LeafHash = merkle.LeafHash(mut.Value.LeafInput)
+			next = h.LeafHash(mut.Value.LeafInput)
+		}
+	case "create":
+		if bytes.Equal(head.LeafHash, nullLeafHash(h)) {
+			next = h.LeafHash(mut.Value.LeafInput)
+		}
+	case "compare_and_delete":
+		if bytes.Equal(head.LeafHash, mut.PreviousLeafHash) {
+			next = nullLeafHash(h)
+		}
+	case "set_if_version":
+		if head.Version == mut.Version {
+			next = h.LeafHash(mut.Value.LeafInput)
 		}
 	default:
 		return nil, ErrVerificationFailed
 	}
+	if !(writeOnce && writeOnceRejects(h, head.LeafHash, next)) && !bytes.Equal(head.LeafHash, next) {
+		head.LeafHash = next
+		head.Version = mutationIndex
+		head.Value = mut.Value
+		if bytes.Equal(next, nullLeafHash(h)) {
+			head.Value = nil
+		}
+	}
 	head.Hash = nil
 
-	return root.CalcHash(), nil
+	return root.CalcHash(h), nil
+}
+
+// Return the leaf hash, version and value for a key path, or the default leaf value, -1 and nil if not set
+func (node *mapAuditNode) leafForKey(h merkle.Hasher, keyPath []bool) ([]byte, int64, *pb.LeafData) {
+	for next := node; next != nil; {
+		if next.Leaf {
+			if reflect.DeepEqual(keyPath, next.KeyPath) {
+				return next.LeafHash, next.Version, next.Value
+			}
+			break
+		}
+		if keyPath[next.Depth] {
+			next = next.Right
+		} else {
+			next = next.Left
+		}
+	}
+	return nullLeafHash(h), -1, nil
+}
+
+// Given a root node and the mutation log entry at mutationIndex, return the mutations to apply to the
+// tree, with any derived values resolved to the value to set. For a transaction, this is each of its
+// operations if all of their preconditions hold, every derived value can be derived, and for a
+// write-once map none would change a key with a non-empty value, else none.
+func mutationsToApply(ctx context.Context, h merkle.Hasher, root *mapAuditNode, mut *pb.MapMutation, writeOnce bool, mutationIndex int64) ([]*pb.MapMutation, error) {
+	if mut.Action != "transaction" {
+		kh, err := mutationKeyHash(h, mut)
+		if err != nil {
+			return nil, err
+		}
+		_, _, prevValue := root.leafForKey(h, merkle.KeyHashPath(kh))
+		resolved, ok := derivedMapMutation(ctx, mut, prevValue)
+		if !ok {
+			return nil, nil
+		}
+		return []*pb.MapMutation{resolved}, nil
+	}
+
+	// Leaf hashes, versions and values as set by earlier operations in the transaction
+	pending := make(map[string][]byte)
+	pendingVersions := make(map[string]int64)
+	pendingValues := make(map[string]*pb.LeafData)
+	rv := make([]*pb.MapMutation, 0, len(mut.Operations))
+	for _, op := range mut.Operations {
+		kh, err := mutationKeyHash(h, op)
+		if err != nil {
+			return nil, err
+		}
+		k := string(kh)
+		prev, ok := pending[k]
+		prevVersion, prevValue := pendingVersions[k], pendingValues[k]
+		if !ok {
+			prev, prevVersion, prevValue = root.leafForKey(h, merkle.KeyHashPath(kh))
+		}
+		op, ok = derivedMapMutation(ctx, op, prevValue)
+		if !ok {
+			return nil, nil
+		}
+		var next []byte
+		switch op.Action {
+		case "set":
+			next = h.LeafHash(op.Value.LeafInput)
+		case "delete":
+			next = nullLeafHash(h)
+		case "update":
+			if !bytes.Equal(prev, op.PreviousLeafHash) {
+				return nil, nil
+			}
+			next = h.LeafHash(op.Value.LeafInput)
+		case "create":
+			if !bytes.Equal(prev, nullLeafHash(h)) {
+				return nil, nil
+			}
+			next = h.LeafHash(op.Value.LeafInput)
+		case "compare_and_delete":
+			if !bytes.Equal(prev, op.PreviousLeafHash) {
+				return nil, nil
+			}
+			next = nullLeafHash(h)
+		case "set_if_version":
+			if prevVersion != op.Version {
+				return nil, nil
+			}
+			next = h.LeafHash(op.Value.LeafInput)
+		default:
+			return nil, ErrVerificationFailed
+		}
+		if writeOnce && writeOnceRejects(h, prev, next) {
+			return nil, nil
+		}
+		if !bytes.Equal(prev, next) {
+			prevVersion = mutationIndex
+			prevValue = op.Value
+			if bytes.Equal(next, nullLeafHash(h)) {
+				prevValue = nil
+			}
+		}
+		pending[k] = next
+		pendingVersions[k] = prevVersion
+		pendingValues[k] = prevValue
+		rv = append(rv, op)
+	}
+	return rv, nil
 }
 
 type auditState struct {
 	// Must be set
 	Map *Map
 
+	// Must be set, the hash strategy for the map
+	Hasher merkle.Hasher
+
+	// Must be set, the hash strategy for the tree of the map, which differs from Hasher for a sum-tree map
+	MapHasher merkle.Hasher
+
+	// For a sum-tree map, the field that every tree head must sum
+	SumField string
+
+	// For a map with VRF keys, the public key that the proof for each key must verify with
+	VRFPublicKey ed25519.PublicKey
+
+	// For a patricia trie map, the trie of the values in the map, whose root hash every tree head must have
+	PatriciaTrie *patriciaAuditTrie
+
 	// Current mutation log tree head
 	MutLogHead *pb.LogTreeHashResponse
 
@@ -204,9 +357,6 @@ func (a *auditState) ProcessUntilAtLeast(ctx context.Context, size int64) error
 			return err
 		}
 
-		// Save this off, we need to compare later
-		lastRootHash := a.Root.CalcHash()
-
 		// Perform audit of the mutation log, providing a special function to apply mutations
 		// to our copy of the map
 		err = mutLog.VerifyEntries(ctx, a.MutLogHead, mutLogHead, func(ctx context.Context, idx int64, entry *pb.LeafData) error {
@@ -225,67 +375,107 @@ func (a *auditState) ProcessUntilAtLeast(ctx context.Context, size int64) error
 				return err
 			}
 
-			if a.LeafDataAuditFunction != nil {
-				err = a.LeafDataAuditFunction(ctx, mutation.Value)
-				if err != nil {
-					return err
-				}
-			}
+			return a.ApplyMutation(ctx, idx, entry, &mutation)
+		})
+		if err != nil {
+			return err
+		}
 
-			// Apply it to our copy of the map
-			rh, err := addMutationToTree(&a.Root, &mutation)
-			if err != nil {
-				return err
-			}
+		// Save off mutation log for next run
+		a.MutLogHead = mutLogHead
+		a.Size = a.MutLogHead.TreeSize
+	}
 
-			// Keep our own copy of the mutation log hash stack so that we can
-			// verify the mutation log heads as well.
-			lh := merkle.LeafHash(entry.LeafInput)
+	if size > a.Size {
+		return ErrVerificationFailed
+	}
 
-			// Apply to stack
-			a.MutLogHashStack = append(a.MutLogHashStack, lh)
-			for z := idx; (z & 1) == 1; z >>= 1 {
-				a.MutLogHashStack = append(a.MutLogHashStack[:len(a.MutLogHashStack)-2], merkle.NodeHash(a.MutLogHashStack[len(a.MutLogHashStack)-2], a.MutLogHashStack[len(a.MutLogHashStack)-1]))
-			}
+	return nil
+}
 
-			// Save off current one
-			headHash := a.MutLogHashStack[len(a.MutLogHashStack)-1]
-			for z := len(a.MutLogHashStack) - 2; z >= 0; z-- {
-				headHash = merkle.NodeHash(a.MutLogHashStack[z], headHash)
+// ApplyMutation applies mutation, the mutation log entry at idx, to our copy of the map, and records the
+// resulting map and mutation log tree heads.
+func (a *auditState) ApplyMutation(ctx context.Context, idx int64, entry *pb.LeafData, mutation *pb.MapMutation) error {
+	// The path for each key comes from its VRF proof, so that must be right
+	err := verifyMutationKeyProofs(a.VRFPublicKey, mutation)
+	if err != nil {
+		return err
+	}
+
+	if a.LeafDataAuditFunction != nil {
+		values := []*pb.LeafData{mutation.Value}
+		if mutation.Action == "transaction" {
+			values = values[:0]
+			for _, op := range mutation.Operations {
+				values = append(values, op.Value)
+			}
+		}
+		for _, v := range values {
+			err = a.LeafDataAuditFunction(ctx, v)
+			if err != nil {
+				return err
 			}
+		}
+	}
 
-			// Now add both to our saved copy of the tree head log.
-			a.MutationLogTreeHeads = append(a.MutationLogTreeHeads, headHash)
-			a.MapTreeHeads = append(a.MapTreeHeads, rh)
+	// Start from the last root hash we saved, which for a patricia trie map is not that of our copy
+	var rh []byte
+	if len(a.MapTreeHeads) == 0 || a.PatriciaTrie != nil {
+		rh = a.Root.CalcHash(a.MapHasher)
+	} else {
+		rh = a.MapTreeHeads[len(a.MapTreeHeads)-1]
+	}
 
-			// Finally, if we actually made a change (ie the mutation did something)
-			// then call the underlying audit function provided by the client.
-			if a.MapAuditFunction != nil && !bytes.Equal(lastRootHash, rh) {
-				err = a.MapAuditFunction(ctx, idx, mutation.Key, mutation.Value)
-				if err != nil {
-					return err
-				}
-				return nil
-			}
+	// Apply it to our copy of the map
+	muts, err := mutationsToApply(ctx, a.MapHasher, &a.Root, mutation, a.Map.Map.WriteOnce, idx)
+	if err != nil {
+		return err
+	}
+	for _, mut := range muts {
+		prev := rh
+		rh, err = addMutationToTree(a.MapHasher, &a.Root, mut, a.Map.Map.WriteOnce, idx)
+		if err != nil {
+			return err
+		}
 
-			// Save for next time
-			lastRootHash = rh
+		// If we actually made a change (ie the mutation did something)
+		// then call the underlying audit function provided by the client.
+		if a.MapAuditFunction != nil && !bytes.Equal(prev, rh) {
+			err = a.MapAuditFunction(ctx, idx, mut.Key, mut.Value)
+			if err != nil {
+				return err
+			}
+		}
+	}
 
-			return nil
-		})
+	// A patricia trie map has the root hash of its trie instead
+	if a.PatriciaTrie != nil {
+		rh, err = a.PatriciaTrie.Apply(a.MapHasher, &a.Root, muts)
 		if err != nil {
 			return err
 		}
+	}
 
-		// Save off mutation log for next run
-		a.MutLogHead = mutLogHead
-		a.Size = a.MutLogHead.TreeSize
+	// Keep our own copy of the mutation log hash stack so that we can
+	// verify the mutation log heads as well.
+	lh := a.Hasher.LeafHash(entry.LeafInput)
+
+	// Apply to stack
+	a.MutLogHashStack = append(a.MutLogHashStack, lh)
+	for z := idx; (z & 1) == 1; z >>= 1 {
+		a.MutLogHashStack = append(a.MutLogHashStack[:len(a.MutLogHashStack)-2], a.Hasher.NodeHash(a.MutLogHashStack[len(a.MutLogHashStack)-2], a.MutLogHashStack[len(a.MutLogHashStack)-1]))
 	}
 
-	if size > a.Size {
-		return ErrVerificationFailed
+	// Save off current one
+	headHash := a.MutLogHashStack[len(a.MutLogHashStack)-1]
+	for z := len(a.MutLogHashStack) - 2; z >= 0; z-- {
+		headHash = a.Hasher.NodeHash(a.MutLogHashStack[z], headHash)
 	}
 
+	// Now add both to our saved copy of the tree head log.
+	a.MutationLogTreeHeads = append(a.MutationLogTreeHeads, headHash)
+	a.MapTreeHeads = append(a.MapTreeHeads, rh)
+
 	return nil
 }
 
@@ -305,6 +495,11 @@ func (a *auditState) CheckTreeHeadEntry(ctx context.Context, idx int64, entry *p
 		return err
 	}
 
+	// Every tree head must have the same VRF key and sum field, and be a patricia trie or not
+	if !bytes.Equal(mth.VrfPublicKey, a.VRFPublicKey) || mth.SumField != a.SumField || mth.PatriciaTrie != (a.PatriciaTrie != nil) {
+		return ErrVerificationFailed
+	}
+
 	// Advance the state of the auditor to at least this size
 	err = a.ProcessUntilAtLeast(ctx, mth.MutationLog.TreeSize)
 	if err != nil {
@@ -0,0 +1,155 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+
+	"github.com/continusec/verifiabledatastructures/pb"
+	"golang.org/x/net/context"
+)
+
+// isDerivedMutation returns whether the value set by mut is derived from the previous value for the key
+func isDerivedMutation(mut *pb.MapMutation) bool {
+	return mut.Action == "patch" || mut.Action == "increment"
+}
+
+// derivedMapMutation returns a "set" mutation for the value that a "patch" or "increment" mutation derives
+// from prev, the current value for the key (nil or empty if none). Other mutations are returned unchanged.
+// Returns false if the value cannot be derived, in which case the mutation has no effect.
+//
+// The previous value must be JSON, with a LeafInput that is the objecthash of the ExtraData. Numbers are
+// kept exactly as written, and the derived value is serialized with sorted keys, so that the server and
+// auditors derive exactly the same value.
+func derivedMapMutation(ctx context.Context, mut *pb.MapMutation, prev *pb.LeafData) (*pb.MapMutation, bool) {
+	if !isDerivedMutation(mut) {
+		return mut, true
+	}
+
+	var o interface{}
+	if len(prev.GetLeafInput()) != 0 {
+		if ValidateJSONLeafData(ctx, prev) != nil {
+			return nil, false
+		}
+		var err error
+		o, err = decodeJSONWithNumbers(prev.ExtraData)
+		if err != nil {
+			return nil, false
+		}
+	}
+
+	switch mut.Action {
+	case "patch":
+		patch, err := decodeJSONWithNumbers(mut.Patch)
+		if err != nil {
+			return nil, false
+		}
+		o = applyMergePatch(o, patch)
+	case "increment":
+		var ok bool
+		o, ok = applyIncrement(o, mut.Field, mut.Delta)
+		if !ok {
+			return nil, false
+		}
+	}
+
+	data, err := json.Marshal(o)
+	if err != nil {
+		return nil, false
+	}
+	ld, err := CreateJSONLeafData(data)
+	if err != nil {
+		return nil, false
+	}
+	return &pb.MapMutation{
+		Action:   "set",
+		Key:      mut.Key,
+		Value:    ld,
+		KeyProof: mut.KeyProof,
+	}, true
+}
+
+// applyMergePatch applies a JSON merge patch, as per RFC 7396, to target, which is not modified
+func applyMergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	rv := make(map[string]interface{})
+	if t, ok := target.(map[string]interface{}); ok {
+		for k, v := range t {
+			rv[k] = v
+		}
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(rv, k)
+		} else {
+			rv[k] = applyMergePatch(rv[k], v)
+		}
+	}
+	return rv
+}
+
+// applyIncrement adds delta to the integer field in the JSON object target, or to target itself if field
+// is empty. Absent values are treated as zero, and an absent target as an empty object. Returns false if
+// the value to add to is not an integer that fits in 64 bits, or the result does not.
+func applyIncrement(target interface{}, field string, delta int64) (interface{}, bool) {
+	add := func(v interface{}) (interface{}, bool) {
+		var n int64
+		if v != nil {
+			num, ok := v.(json.Number)
+			if !ok {
+				return nil, false
+			}
+			var err error
+			n, err = strconv.ParseInt(string(num), 10, 64)
+			if err != nil {
+				return nil, false
+			}
+		}
+		if (delta > 0 && n > math.MaxInt64-delta) || (delta < 0 && n < math.MinInt64-delta) {
+			return nil, false
+		}
+		return json.Number(strconv.FormatInt(n+delta, 10)), true
+	}
+
+	if field == "" {
+		return add(target)
+	}
+
+	rv := make(map[string]interface{})
+	if target != nil {
+		t, ok := target.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		for k, v := range t {
+			rv[k] = v
+		}
+	}
+	n, ok := add(rv[field])
+	if !ok {
+		return nil, false
+	}
+	rv[field] = n
+	return rv, true
+}
@@ -0,0 +1,77 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import "bytes"
+
+// Relationship of a map subtree to a keyRange
+const (
+	subTreeOutside = iota
+	subTreeInside
+	subTreePartial
+)
+
+// keyRange is a range of map key hashes from start, up to but not including end. A nil end is the end of the map.
+type keyRange struct {
+	start, end []byte
+}
+
+// classify returns whether the subtree at depth containing kh is inside, outside or partially within the range
+func (r keyRange) classify(kh []byte, depth int) int {
+	first, last := subTreeBounds(kh, depth)
+	if bytes.Compare(last, r.start) < 0 || (r.end != nil && bytes.Compare(first, r.end) >= 0) {
+		return subTreeOutside
+	}
+	if bytes.Compare(first, r.start) >= 0 && (r.end == nil || bytes.Compare(last, r.end) < 0) {
+		return subTreeInside
+	}
+	return subTreePartial
+}
+
+// subTreeBounds returns the first and last key hash in the subtree at depth containing kh
+func subTreeBounds(kh []byte, depth int) ([]byte, []byte) {
+	first, last := make([]byte, len(kh)), make([]byte, len(kh))
+	for i := range kh {
+		bits := depth - (i * 8) // number of bits in this byte that are in the prefix
+		switch {
+		case bits >= 8:
+			first[i], last[i] = kh[i], kh[i]
+		case bits <= 0:
+			first[i], last[i] = 0, 0xff
+		default:
+			mask := byte(0xff << (8 - bits))
+			first[i], last[i] = kh[i]&mask, kh[i]|^mask
+		}
+	}
+	return first, last
+}
+
+// childKeyHash returns the first key hash in the left or right child of the subtree at depth containing kh
+func childKeyHash(kh []byte, depth int, right bool) []byte {
+	rv, _ := subTreeBounds(kh, depth)
+	if right {
+		rv[depth/8] |= 1 << (7 - (depth % 8))
+	}
+	return rv
+}
+
+// keyHashBit returns the bit at depth in kh, true for right
+func keyHashBit(kh []byte, depth int) bool {
+	return (kh[depth/8]>>(7-(depth%8)))&1 == 1
+}
@@ -0,0 +1,282 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/continusec/verifiabledatastructures/pb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// reduceLogEntry returns the mutation that r derives from entry, the log entry at index idx. Every entry
+// derives exactly one mutation, so that a derived map always has the same size as its log. An entry that
+// is skipped derives an empty transaction, which does not change the map.
+func reduceLogEntry(r *pb.MapReducer, idx int64, entry *pb.LeafData) *pb.MapMutation {
+	skip := &pb.MapMutation{Action: "transaction", SourceIndex: idx}
+
+	o, err := decodeJSONWithNumbers(entry.GetExtraData())
+	if err != nil {
+		return skip
+	}
+
+	var key []byte
+	k, _ := lookupJSONPath(o, r.GetKeyPath())
+	switch k := k.(type) {
+	case string:
+		key = []byte(k)
+	case json.Number:
+		key = []byte(k)
+	default:
+		return skip
+	}
+
+	action := "set"
+	for _, rule := range r.GetRules() {
+		if reducerRuleMatches(rule, o) {
+			action = rule.Action
+			break
+		}
+	}
+
+	switch action {
+	case "set":
+		value := entry
+		if r.GetValuePath() != "" {
+			v, ok := lookupJSONPath(o, r.ValuePath)
+			if !ok {
+				return skip
+			}
+			data, err := json.Marshal(v)
+			if err != nil {
+				return skip
+			}
+			value, err = CreateJSONLeafData(data)
+			if err != nil {
+				return skip
+			}
+		}
+		return &pb.MapMutation{Action: "set", Key: key, Value: value, SourceIndex: idx}
+	case "delete":
+		return &pb.MapMutation{Action: "delete", Key: key, SourceIndex: idx}
+	default:
+		return skip
+	}
+}
+
+// decodeJSONWithNumbers decodes data, keeping numbers as written so that keys and values derived from
+// them are exact.
+func decodeJSONWithNumbers(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var rv interface{}
+	err := dec.Decode(&rv)
+	if err != nil {
+		return nil, ErrInvalidJSON
+	}
+	if dec.Decode(&struct{}{}) != io.EOF {
+		return nil, ErrInvalidJSON
+	}
+	return rv, nil
+}
+
+// lookupJSONPath returns the value at the dot separated path of object keys in o, or false if there is none.
+// An empty path is o itself.
+func lookupJSONPath(o interface{}, path string) (interface{}, bool) {
+	if path == "" {
+		return o, true
+	}
+	for _, k := range strings.Split(path, ".") {
+		m, ok := o.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		o, ok = m[k]
+		if !ok {
+			return nil, false
+		}
+	}
+	return o, true
+}
+
+// reducerRuleMatches returns whether the value at the path for rule in o is equal to that in the rule
+func reducerRuleMatches(rule *pb.MapReducerRule, o interface{}) bool {
+	if rule.Path == "" {
+		return true
+	}
+	v, ok := lookupJSONPath(o, rule.Path)
+	if !ok {
+		return false
+	}
+	if s, ok := v.(string); ok {
+		return s == rule.Equals
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return false
+	}
+	return string(data) == rule.Equals
+}
+
+// validateDerivedMaps fails if any map is derived from more than one log, a log has more than one
+// derived map, or a reducer is missing or has a rule with an unknown action.
+func validateDerivedMaps(dms []*pb.DerivedMap) error {
+	logs := make(map[string]bool)
+	maps := make(map[string]bool)
+	for _, dm := range dms {
+		if dm.Log == "" || dm.Map == "" || dm.Reducer == nil {
+			return errors.New("derived map must name a log and a map, and have a reducer")
+		}
+		l, m := dm.Account+"/log/"+dm.Log, dm.Account+"/map/"+dm.Map
+		if logs[l] || maps[m] {
+			return fmt.Errorf("log %s or map %s has more than one derived map", l, m)
+		}
+		logs[l], maps[m] = true, true
+		for _, rule := range dm.GetReducer().GetRules() {
+			switch rule.Action {
+			case "set", "delete", "skip":
+			default:
+				return fmt.Errorf("unknown action %q for derived map %s", rule.Action, m)
+			}
+		}
+	}
+	return nil
+}
+
+// derivedMapForLog returns the map derived from the user log, or nil if none
+func (s *localServiceImpl) derivedMapForLog(log *pb.LogRef) *pb.DerivedMap {
+	if log.LogType != pb.LogType_STRUCT_TYPE_LOG {
+		return nil
+	}
+	for _, dm := range s.DerivedMaps {
+		if dm.Account == log.GetAccount().GetId() && dm.Log == log.Name {
+			return dm
+		}
+	}
+	return nil
+}
+
+// derivedMapForMap returns the configuration for the map if it is derived from a log, else nil
+func (s *localServiceImpl) derivedMapForMap(vmap *pb.MapRef) *pb.DerivedMap {
+	for _, dm := range s.DerivedMaps {
+		if dm.Account == vmap.GetAccount().GetId() && dm.Map == vmap.Name {
+			return dm
+		}
+	}
+	return nil
+}
+
+// mapBucket returns the namespace for the map. A derived map is kept with the log it is derived from,
+// so that each entry and the mutation derived from it are added together.
+func (s *localServiceImpl) mapBucket(vmap *pb.MapRef) ([]byte, error) {
+	dm := s.derivedMapForMap(vmap)
+	if dm == nil {
+		return mapBucket(vmap)
+	}
+	return logBucket(derivedMapLog(vmap.Account, dm.Log, pb.LogType_STRUCT_TYPE_LOG, vmap.HashAlgorithm))
+}
+
+// derivedMapLog returns the log of the given type that is kept with the user log named logName. For a
+// log with a derived map, this is the log itself, or the mutation or tree head log of the map.
+func derivedMapLog(account *pb.AccountRef, logName string, logType pb.LogType, alg pb.HashAlgorithm) *pb.LogRef {
+	return &pb.LogRef{
+		Account:       account,
+		Name:          logName,
+		LogType:       logType,
+		HashAlgorithm: alg,
+	}
+}
+
+// logBucket returns the namespace for the log, which for the mutation or tree head log of a derived
+// map is that of the log it is derived from.
+func (s *localServiceImpl) logBucket(log *pb.LogRef) ([]byte, error) {
+	if log.LogType == pb.LogType_STRUCT_TYPE_LOG {
+		return logBucket(log)
+	}
+	return s.mapBucket(mapForMutationLog(log))
+}
+
+// readReducer returns the reducer recorded for the log. If nothing has been added yet, then def is
+// returned. Logs created before this was recorded have none.
+func readReducer(ctx context.Context, kr KeyReader, def *pb.MapReducer) (*pb.MapReducer, error) {
+	conf, err := lookupObjectConfig(ctx, kr)
+	switch err {
+	case nil:
+		return conf.Reducer, nil
+	case ErrNoSuchKey:
+		size, err := ReadObjectSize(ctx, kr)
+		if err != nil {
+			return nil, err
+		}
+		if size != 0 {
+			return nil, nil
+		}
+		return def, nil
+	default:
+		return nil, err
+	}
+}
+
+// checkReducer fails if the reducer configured for a log that is about to be added to does not match
+// that already recorded, as the derived map could then not be verified.
+func (s *localServiceImpl) checkReducer(ctx context.Context, ns []byte, reducer *pb.MapReducer) error {
+	err := s.Reader.ExecuteReadOnly(ctx, ns, func(ctx context.Context, kr KeyReader) error {
+		recorded, err := readReducer(ctx, kr, reducer)
+		if err != nil {
+			return err
+		}
+		if !proto.Equal(recorded, reducer) {
+			return status.Errorf(codes.FailedPrecondition, "reducer for derived map does not match that recorded for the log")
+		}
+		return nil
+	})
+	if err != nil {
+		_, ok := status.FromError(err)
+		if !ok {
+			err = status.Errorf(codes.Internal, "unknown err: %s", err)
+		}
+		return err
+	}
+	return nil
+}
+
+// logForUpdate returns the log to add entries to, with the reducer for the map derived from it if any, in
+// place of any reducer that was requested.
+func (s *localServiceImpl) logForUpdate(ctx context.Context, ns []byte, log *pb.LogRef) (*pb.LogRef, error) {
+	var reducer *pb.MapReducer
+	dm := s.derivedMapForLog(log)
+	if dm != nil {
+		reducer = dm.Reducer
+	}
+	err := s.checkReducer(ctx, ns, reducer)
+	if err != nil {
+		return nil, err
+	}
+	rv := proto.Clone(log).(*pb.LogRef)
+	rv.Reducer = reducer
+	return rv, nil
+}
@@ -0,0 +1,238 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/continusec/verifiabledatastructures/merkle"
+	"github.com/continusec/verifiabledatastructures/pb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// prefixedKeyReader reads from the keys in a namespace that start with prefix, so that
+// structures such as logs can be stored within another structure.
+type prefixedKeyReader struct {
+	KeyReader
+	prefix []byte
+}
+
+func (p *prefixedKeyReader) Get(ctx context.Context, key []byte, value proto.Message) error {
+	return p.KeyReader.Get(ctx, makeStorageKey(p.prefix, key), value)
+}
+
+// prefixedKeyWriter writes to the keys in a namespace that start with prefix.
+type prefixedKeyWriter struct {
+	KeyWriter
+	prefix []byte
+}
+
+func (p *prefixedKeyWriter) Get(ctx context.Context, key []byte, value proto.Message) error {
+	return p.KeyWriter.Get(ctx, makeStorageKey(p.prefix, key), value)
+}
+
+func (p *prefixedKeyWriter) Set(ctx context.Context, key []byte, value proto.Message) error {
+	return p.KeyWriter.Set(ctx, makeStorageKey(p.prefix, key), value)
+}
+
+// multimapKeyPrefix returns the prefix for the keys used to store the log of values for a key
+func multimapKeyPrefix(h merkle.Hasher, key []byte) []byte {
+	return makeStorageKey(multimapKeyBucket, append(h.KeyHash(key), '/'))
+}
+
+// mapForMultimap returns the map that holds the tree head of the log of values for each key
+func mapForMultimap(mm *pb.MultimapRef) *pb.MapRef {
+	return &pb.MapRef{
+		Account:       mm.Account,
+		Name:          mm.Name,
+		HashAlgorithm: mm.HashAlgorithm,
+	}
+}
+
+// keyLogForMultimap returns the log of values for a key, which is stored under multimapKeyPrefix
+func keyLogForMultimap(mm *pb.MultimapRef) *pb.LogRef {
+	return &pb.LogRef{
+		Account:       mm.Account,
+		Name:          mm.Name,
+		LogType:       pb.LogType_STRUCT_TYPE_LOG,
+		HashAlgorithm: mm.HashAlgorithm,
+	}
+}
+
+// multimapKeyHeadMutation returns the mutation that sets the value for key to the tree head of its log of values
+func multimapKeyHeadMutation(key []byte, head *pb.LogTreeHashResponse) (*pb.MapMutation, error) {
+	value, err := CreateJSONLeafDataFromProto(&pb.LogTreeHashResponse{
+		TreeSize: head.TreeSize,
+		RootHash: head.RootHash,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.MapMutation{
+		Action: "set",
+		Key:    key,
+		Value:  value,
+	}, nil
+}
+
+// parseMultimapKeyHead returns the tree head of the log of values for a key held in value, the value for the
+// key in the map for a multimap. An empty value is an empty log.
+func parseMultimapKeyHead(value *pb.LeafData) (*pb.LogTreeHashResponse, error) {
+	if len(value.GetLeafInput()) == 0 {
+		return &pb.LogTreeHashResponse{}, nil
+	}
+	var rv pb.LogTreeHashResponse
+	err := json.Unmarshal(value.ExtraData, &rv)
+	if err != nil {
+		return nil, ErrVerificationFailed
+	}
+	// Make sure that the tree head is what is committed to
+	ld, err := CreateJSONLeafDataFromProto(&pb.LogTreeHashResponse{
+		TreeSize: rv.TreeSize,
+		RootHash: rv.RootHash,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(ld.LeafInput, value.LeafInput) || rv.TreeSize <= 0 {
+		return nil, ErrVerificationFailed
+	}
+	return &rv, nil
+}
+
+// applyMultimapAddValue appends the value to the log of values for the key, and sets the value for the key in
+// the map to the new tree head of that log. A value may be added for a key more than once.
+func applyMultimapAddValue(ctx context.Context, db KeyWriter, sizeBefore int64, req *pb.MultimapAddValueRequest) (int64, error) {
+	h, err := hasherForUpdate(ctx, db, sizeBefore, &pb.ObjectConfig{
+		HashAlgorithm: req.Multimap.HashAlgorithm,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Add the value to the log for the key, which has its own size and config
+	keyDB := &prefixedKeyWriter{KeyWriter: db, prefix: multimapKeyPrefix(h, req.Key)}
+	keySize, err := ReadObjectSize(ctx, keyDB)
+	if err != nil {
+		return 0, err
+	}
+	_, err = hasherForUpdate(ctx, keyDB, keySize, &pb.ObjectConfig{
+		HashAlgorithm: req.Multimap.HashAlgorithm,
+	})
+	if err != nil {
+		return 0, err
+	}
+	keyHead, err := appendEntryToLog(ctx, keyDB, h, keySize, keyLogForMultimap(req.Multimap), req.Value)
+	if err != nil {
+		return 0, err
+	}
+	err = WriteObjectSize(ctx, keyDB, keyHead.TreeSize)
+	if err != nil {
+		return 0, err
+	}
+
+	// Then set the new tree head for the key in the map
+	mut, err := multimapKeyHeadMutation(req.Key, keyHead)
+	if err != nil {
+		return 0, err
+	}
+	mutData, err := CreateJSONLeafDataFromMutation(mut)
+	if err != nil {
+		return 0, err
+	}
+	mutLog := mutationLogForMap(mapForMultimap(req.Multimap))
+	mutLogHead, err := addEntryToLog(ctx, db, h, sizeBefore, mutLog, mutData)
+	if err != nil {
+		return 0, err
+	}
+	if mutLogHead == nil {
+		// Never the case, as the log for the key is larger each time
+		return 0, ErrObjectConflict
+	}
+	err = applyMutationLogEntry(ctx, db, h, mutLog, sizeBefore, mutLogHead, mut)
+	if err != nil {
+		return 0, err
+	}
+
+	return sizeBefore + 1, nil
+}
+
+// readMultimapKeyHead returns the value for key in the map for the multimap of the given size, or the latest if
+// zero, along with the tree head of the log of values for the key that it holds, and a reader for that log.
+func readMultimapKeyHead(ctx context.Context, kr KeyReader, mm *pb.MultimapRef, key []byte, treeSize int64) (*pb.MapGetValueResponse, *pb.LogTreeHashResponse, KeyReader, error) {
+	_, h, err := readHashAlgorithm(ctx, kr, mm.HashAlgorithm)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	// The tree head is never redacted, so that it can be verified
+	kv, err := readMapValue(ctx, kr, mapForMultimap(mm), key, treeSize, &AccessModifier{FieldFilter: AllFields}, nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	keyHead, err := parseMultimapKeyHead(kv.Value)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return kv, keyHead, &prefixedKeyReader{KeyReader: kr, prefix: multimapKeyPrefix(h, key)}, nil
+}
+
+// logRootHash returns the root hash of a log with the given leaf hashes, which must not be empty.
+func logRootHash(h merkle.Hasher, leafHashes [][]byte) []byte {
+	stack := make([][]byte, 0)
+	for idx, lh := range leafHashes {
+		stack = append(stack, lh)
+		for z := idx; (z & 1) == 1; z >>= 1 {
+			stack = append(stack[:len(stack)-2], h.NodeHash(stack[len(stack)-2], stack[len(stack)-1]))
+		}
+	}
+	rv := stack[len(stack)-1]
+	for z := len(stack) - 2; z >= 0; z-- {
+		rv = h.NodeHash(stack[z], rv)
+	}
+	return rv
+}
+
+// verifyMultimapKeyHead verifies the value for key in a multimap head, and returns the tree head of the log
+// of values for the key that it holds.
+func verifyMultimapKeyHead(kv *pb.MapGetValueResponse, key []byte, head *pb.MapTreeHashResponse) (*pb.LogTreeHashResponse, error) {
+	if kv == nil {
+		return nil, ErrVerificationFailed
+	}
+	err := VerifyMapInclusionProof(kv, key, head)
+	if err != nil {
+		return nil, err
+	}
+	return parseMultimapKeyHead(kv.Value)
+}
+
+// multimapStatusError converts errors from reading a multimap to those returned by the service
+func multimapStatusError(err error) error {
+	if err == ErrNoSuchKey {
+		return status.Errorf(codes.NotFound, "cannot find it")
+	}
+	_, ok := status.FromError(err)
+	if !ok {
+		err = status.Errorf(codes.Internal, "unknown err: %s", err)
+	}
+	return err
+}
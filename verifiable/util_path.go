@@ -18,7 +18,7 @@ limitations under the License.
 
 package verifiable
 
-import "crypto/sha256"
+import "github.com/continusec/verifiabledatastructures/merkle"
 
 // BPath represents a binary path (up to 256 bits)
 type BPath []byte
@@ -118,7 +118,11 @@ func BPathJoin(a, b BPath) BPath {
 
 // BPathFromKey creates a BPath based on a key. This is done by taking SHA256 hash of the key
 func BPathFromKey(key []byte) BPath {
-	h := sha256.Sum256(key)
+	return bPathFromKeyHash(merkle.SHA256.KeyHash(key))
+}
+
+// bPathFromKeyHash creates a BPath based on the hash of a key
+func bPathFromKeyHash(h []byte) BPath {
 	nm := len(h) * 8
 	rv := make([]byte, 1+len(h))
 	if nm == 256 { // yes, it always will be unless we change hash function for testing to something shorter
@@ -126,6 +130,6 @@ func BPathFromKey(key []byte) BPath {
 	} else {
 		rv[0] = byte(nm)
 	}
-	copy(rv[1:], h[:])
+	copy(rv[1:], h)
 	return BPath(rv)
 }
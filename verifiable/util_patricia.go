@@ -0,0 +1,290 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"bytes"
+
+	"github.com/continusec/verifiabledatastructures/merkle"
+	"github.com/continusec/verifiabledatastructures/mpt"
+	"github.com/continusec/verifiabledatastructures/pb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// A Merkle Patricia Trie map keeps the same sparse tree as any other map, which decides the value for
+// each key, and also a trie of those values. The root hash for the map is that of the trie, so that
+// values are proven as for Ethereum storage, where the path for a key is the Keccak-256 hash of it,
+// and the value is the RLP encoding of the leaf input.
+
+// patriciaNodes loads the nodes of the trie for a map
+type patriciaNodes struct {
+	ctx context.Context
+	kr  KeyReader
+}
+
+func (p *patriciaNodes) Node(hash []byte) ([]byte, error) {
+	n, err := lookupMapPatriciaNode(p.ctx, p.kr, hash)
+	switch err {
+	case nil:
+		return n.Encoding, nil
+	case ErrNoSuchKey:
+		return nil, mpt.ErrMissingNode
+	default:
+		return nil, err
+	}
+}
+
+// patriciaNodeWriter also stores the nodes of the trie for a map
+type patriciaNodeWriter struct {
+	patriciaNodes
+	kw KeyWriter
+}
+
+func (p *patriciaNodeWriter) PutNode(hash, enc []byte) error {
+	return writeMapPatriciaNode(p.ctx, p.kw, hash, &pb.MapPatriciaNode{Encoding: enc})
+}
+
+// patriciaTrieKey returns the key in the trie for a key in the map
+func patriciaTrieKey(key []byte) []byte {
+	return mpt.Keccak256(key)
+}
+
+// patriciaTrieValue returns the value in the trie for a value in the map, which is nil if empty
+func patriciaTrieValue(value *pb.LeafData) []byte {
+	if len(value.GetLeafInput()) == 0 {
+		return nil
+	}
+	return mpt.EncodeBytes(value.LeafInput)
+}
+
+// readPatriciaTrie returns whether the map is recorded as a Merkle Patricia Trie map. If nothing
+// has been added yet, then def is returned.
+func readPatriciaTrie(ctx context.Context, kr KeyReader, def bool) (bool, error) {
+	conf, err := lookupObjectConfig(ctx, kr)
+	switch err {
+	case nil:
+		return conf.PatriciaTrie, nil
+	case ErrNoSuchKey:
+		size, err := ReadObjectSize(ctx, kr)
+		if err != nil {
+			return false, err
+		}
+		if size != 0 {
+			return false, nil
+		}
+		return def, nil
+	default:
+		return false, err
+	}
+}
+
+// checkPatriciaTrie fails if the requested Merkle Patricia Trie mode for a map that is about to be
+// added to does not match that already recorded, or is combined with VRF keys or a sum field, as
+// neither can be proven by the trie. Trie nodes are shared between tree sizes, so are never compacted,
+// and patricia trie maps are not supported with a map retention policy.
+func (s *localServiceImpl) checkPatriciaTrie(ctx context.Context, ns []byte, vmap *pb.MapRef) error {
+	if vmap.PatriciaTrie && (vmap.VrfKeys || vmap.SumField != "") {
+		return status.Errorf(codes.InvalidArgument, "patricia trie maps cannot have VRF keys or a sum field")
+	}
+	if vmap.PatriciaTrie && s.MapRetention != nil {
+		return status.Errorf(codes.FailedPrecondition, "patricia trie maps are not supported with a map retention policy")
+	}
+	err := s.Reader.ExecuteReadOnly(ctx, ns, func(ctx context.Context, kr KeyReader) error {
+		recorded, err := readPatriciaTrie(ctx, kr, vmap.PatriciaTrie)
+		if err != nil {
+			return err
+		}
+		if recorded != vmap.PatriciaTrie {
+			return status.Errorf(codes.InvalidArgument, "patricia trie %t does not match %t", vmap.PatriciaTrie, recorded)
+		}
+		return nil
+	})
+	if err != nil {
+		_, ok := status.FromError(err)
+		if !ok {
+			err = status.Errorf(codes.Internal, "unknown err: %s", err)
+		}
+		return err
+	}
+	return nil
+}
+
+// mutationOperations returns the mutations that make up a mutation log entry, which for a transaction
+// is each of its operations
+func mutationOperations(mut *pb.MapMutation) []*pb.MapMutation {
+	if mut.Action == "transaction" {
+		return mut.Operations
+	}
+	return []*pb.MapMutation{mut}
+}
+
+// applyPatriciaMutation updates the trie for a map after mut, the mutation log entry at mutationIndex,
+// has been applied to its sparse tree, returning the new root hash of the trie. The value for each key
+// in the mutation is set to that in the sparse tree, so a mutation that is not applied sets the same
+// values, and the root hash is unchanged.
+func applyPatriciaMutation(ctx context.Context, db KeyWriter, h merkle.Hasher, mutationIndex int64, mut *pb.MapMutation) ([]byte, error) {
+	prev, err := lookupMapPatriciaRoot(ctx, db, mutationIndex)
+	if err != nil {
+		return nil, err
+	}
+	root, err := lookupMapHash(ctx, db, mutationIndex+1, BPathEmpty)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := &patriciaNodeWriter{patriciaNodes: patriciaNodes{ctx: ctx, kr: db}, kw: db}
+	t := mpt.New(prev.RootHash, &nodes.patriciaNodes)
+	for _, op := range mutationOperations(mut) {
+		kh, err := mutationKeyHash(h, op)
+		if err != nil {
+			return nil, err
+		}
+		lh, err := lookupMapLeafHash(ctx, db, h, bPathFromKeyHash(kh), root)
+		if err != nil {
+			return nil, err
+		}
+		value, err := lookupMapValue(ctx, db, h, lh)
+		if err != nil {
+			return nil, err
+		}
+		err = t.Update(patriciaTrieKey(op.Key), patriciaTrieValue(value))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rv, err := t.Commit(nodes)
+	if err != nil {
+		return nil, err
+	}
+	err = writeMapPatriciaRoot(ctx, db, mutationIndex+1, &pb.MapPatriciaRoot{RootHash: rv})
+	if err != nil {
+		return nil, err
+	}
+	return rv, nil
+}
+
+// readPatriciaMapValue returns the value for a key, and proof of its inclusion in the trie, for a
+// Merkle Patricia Trie map of the given size, which must be valid
+func readPatriciaMapValue(ctx context.Context, kr KeyReader, h merkle.Hasher, alg pb.HashAlgorithm, key []byte, treeSize int64, am *AccessModifier) (*pb.MapGetValueResponse, error) {
+	root, err := lookupMapPatriciaRoot(ctx, kr, treeSize)
+	if err != nil {
+		return nil, err
+	}
+	t := mpt.New(root.RootHash, &patriciaNodes{ctx: ctx, kr: kr})
+	tk := patriciaTrieKey(key)
+	v, err := t.Get(tk)
+	if err != nil {
+		return nil, err
+	}
+	proof, err := t.Prove(tk)
+	if err != nil {
+		return nil, err
+	}
+
+	dataRv := &pb.LeafData{} // empty value
+	if v != nil {
+		input, err := mpt.DecodeBytes(v)
+		if err != nil {
+			return nil, err
+		}
+		dataRv, err = lookupDataByLeafHash(ctx, kr, pb.LogType_STRUCT_TYPE_MUTATION_LOG, h.LeafHash(input))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Check for fields that need redacting
+	dataRv, err = filterLeafData(dataRv, am)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.MapGetValueResponse{
+		TreeSize:      treeSize,
+		Value:         dataRv,
+		HashAlgorithm: alg,
+		PatriciaProof: proof,
+	}, nil
+}
+
+// verifyPatriciaMapInclusionProof verifies an inclusion proof for a Merkle Patricia Trie map against a
+// MapTreeHead. The proof is checked as for a storage proof from eth_getProof, with the key being the
+// Keccak-256 hash of the key, and the value being the RLP encoding of the leaf input, or none if empty.
+func verifyPatriciaMapInclusionProof(self *pb.MapGetValueResponse, key []byte, head *pb.MapTreeHashResponse) error {
+	if !head.PatriciaTrie || self.TreeSize != head.GetMutationLog().GetTreeSize() {
+		return ErrVerificationFailed
+	}
+	v, err := mpt.VerifyProof(head.RootHash, patriciaTrieKey(key), self.PatriciaProof)
+	if err != nil {
+		return ErrVerificationFailed
+	}
+	if !bytes.Equal(v, patriciaTrieValue(self.Value)) {
+		return ErrVerificationFailed
+	}
+
+	// all clear
+	return nil
+}
+
+// patriciaAuditTrie is the trie for a Merkle Patricia Trie map that is being audited, kept in memory
+type patriciaAuditTrie struct {
+	Trie  *mpt.Trie
+	Nodes mpt.MemoryNodes
+}
+
+func newPatriciaAuditTrie() *patriciaAuditTrie {
+	nodes := make(mpt.MemoryNodes)
+	return &patriciaAuditTrie{
+		Trie:  mpt.New(mpt.EmptyRoot, nodes),
+		Nodes: nodes,
+	}
+}
+
+// Apply sets the value for each key changed by muts to that in the audited copy of the sparse tree,
+// returning the new root hash of the trie
+func (p *patriciaAuditTrie) Apply(h merkle.Hasher, root *mapAuditNode, muts []*pb.MapMutation) ([]byte, error) {
+	for _, mut := range muts {
+		kh, err := mutationKeyHash(h, mut)
+		if err != nil {
+			return nil, err
+		}
+		_, _, value := root.leafForKey(h, merkle.KeyHashPath(kh))
+		err = p.Trie.Update(patriciaTrieKey(mut.Key), patriciaTrieValue(value))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return p.Trie.Commit(p.Nodes)
+}
+
+// checkNotPatriciaTrie fails for a patricia trie map, for requests with proofs that only a sparse
+// tree can provide
+func checkNotPatriciaTrie(ctx context.Context, kr KeyReader, vmap *pb.MapRef) error {
+	patricia, err := readPatriciaTrie(ctx, kr, vmap.PatriciaTrie)
+	if err != nil {
+		return err
+	}
+	if patricia {
+		return status.Errorf(codes.Unimplemented, "not supported for patricia trie maps")
+	}
+	return nil
+}
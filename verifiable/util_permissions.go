@@ -58,6 +58,11 @@ func (s *localServiceImpl) verifyAccessForMap(ctx context.Context, vmap *pb.MapR
 	return s.AccessPolicy.VerifyAllowed(ctx, vmap.Account.Id, vmap.Account.ApiKey, vmap.Name, perm)
 }
 
+// verifyAccessForMultimap checks the map permissions for a multimap, which is a map of logs
+func (s *localServiceImpl) verifyAccessForMultimap(ctx context.Context, mm *pb.MultimapRef, perm pb.Permission) (*AccessModifier, error) {
+	return s.AccessPolicy.VerifyAllowed(ctx, mm.Account.Id, mm.Account.ApiKey, mm.Name, perm)
+}
+
 func (s *localServiceImpl) verifyAccessForLog(ctx context.Context, log *pb.LogRef, perm pb.Permission) (*AccessModifier, error) {
 	return s.AccessPolicy.VerifyAllowed(ctx, log.Account.Id, log.Account.ApiKey, log.Name, perm)
 }
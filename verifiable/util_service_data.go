@@ -21,8 +21,11 @@ package verifiable
 import (
 	"encoding/binary"
 
+	"github.com/continusec/verifiabledatastructures/mpt"
 	"github.com/continusec/verifiabledatastructures/pb"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/continusec/objecthash"
 )
@@ -33,6 +36,8 @@ const (
 	treeNodeByRange = 2
 	rootHashBySize  = 3
 	indexByLeafHash = 4
+	cosignedBySize  = 5
+	cosignedLatest  = 6
 )
 
 func generateBucketNames() map[int]map[pb.LogType][]byte {
@@ -46,6 +51,8 @@ func generateBucketNames() map[int]map[pb.LogType][]byte {
 		{BucketType: treeNodeByRange, Suffix: "node"},
 		{BucketType: rootHashBySize, Suffix: "tree"},
 		{BucketType: indexByLeafHash, Suffix: "index"},
+		{BucketType: cosignedBySize, Suffix: "cosigned"},
+		{BucketType: cosignedLatest, Suffix: "cosigned_head"},
 	} {
 		rv[b.BucketType] = make(map[pb.LogType][]byte)
 		for _, lt := range []struct {
@@ -66,7 +73,21 @@ var (
 	buckets = generateBucketNames()
 
 	objSizeKey    = []byte("metadata/size")
+	objConfigKey  = []byte("metadata/config")
 	mapNodeBucket = []byte("map_node/")
+
+	mapKeyHistorySizeBucket = []byte("map_key_history_size/")
+	mapKeyHistoryBucket     = []byte("map_key_history/")
+	mapRejectedBucket       = []byte("map_rejected/")
+	mapCompactionKey        = []byte("metadata/map_compaction")
+	mapPatriciaNodeBucket   = []byte("map_patricia_node/")
+	mapPatriciaRootBucket   = []byte("map_patricia_root/")
+
+	multimapKeyBucket = []byte("multimap_key/")
+
+	gossipSizeKey        = []byte("metadata/gossip_size")
+	gossipBucket         = []byte("gossip/")
+	gossipIndexByHashKey = []byte("gossip_index/")
 )
 
 // Start pair
@@ -155,6 +176,81 @@ func lookupIndexByLeafHash(ctx context.Context, kr KeyReader, lt pb.LogType, lh
 
 // Start pair
 
+func writeCosignedTreeHeadBySize(ctx context.Context, kr KeyWriter, lt pb.LogType, size int64, data *pb.LogTreeHashResponse) error {
+	return kr.Set(ctx, makeStorageKey(buckets[cosignedBySize][lt], toIntBinary(uint64(size))), data)
+}
+
+func lookupCosignedTreeHeadBySize(ctx context.Context, kr KeyReader, lt pb.LogType, size int64) (*pb.LogTreeHashResponse, error) {
+	var m pb.LogTreeHashResponse
+	err := kr.Get(ctx, makeStorageKey(buckets[cosignedBySize][lt], toIntBinary(uint64(size))), &m)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Start pair
+
+func writeLatestCosignedTreeHead(ctx context.Context, kr KeyWriter, lt pb.LogType, data *pb.LogTreeHashResponse) error {
+	return kr.Set(ctx, buckets[cosignedLatest][lt], data)
+}
+
+// returns a zero-ed out tree head if none has been cosigned
+func lookupLatestCosignedTreeHead(ctx context.Context, kr KeyReader, lt pb.LogType) (*pb.LogTreeHashResponse, error) {
+	var m pb.LogTreeHashResponse
+	err := kr.Get(ctx, buckets[cosignedLatest][lt], &m)
+	switch err {
+	case nil:
+		return &m, nil
+	case ErrNoSuchKey:
+		return &pb.LogTreeHashResponse{}, nil
+	default:
+		return nil, err
+	}
+}
+
+// Kept as evidence only, so no lookup for now
+
+func writeInconsistentTreeHead(ctx context.Context, kr KeyWriter, idx int64, data *pb.InconsistentTreeHead) error {
+	return kr.Set(ctx, makeStorageKey(gossipBucket, toIntBinary(uint64(idx))), data)
+}
+
+// Start pair
+
+func writeGossipIndexByHash(ctx context.Context, kr KeyWriter, h []byte, data *pb.EntryIndex) error {
+	return kr.Set(ctx, makeStorageKey(gossipIndexByHashKey, h), data)
+}
+
+func lookupGossipIndexByHash(ctx context.Context, kr KeyReader, h []byte) (*pb.EntryIndex, error) {
+	var m pb.EntryIndex
+	err := kr.Get(ctx, makeStorageKey(gossipIndexByHashKey, h), &m)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Start pair
+
+func writeGossipSize(ctx context.Context, kr KeyWriter, size int64) error {
+	return kr.Set(ctx, gossipSizeKey, &pb.ObjectSize{Size: size})
+}
+
+func readGossipSize(ctx context.Context, kr KeyReader) (int64, error) {
+	var m pb.ObjectSize
+	err := kr.Get(ctx, gossipSizeKey, &m)
+	switch err {
+	case nil:
+		return m.Size, nil
+	case ErrNoSuchKey:
+		return 0, nil
+	default:
+		return 0, err
+	}
+}
+
+// Start pair
+
 func WriteObjectSize(ctx context.Context, kr KeyWriter, size int64) error {
 	return kr.Set(ctx, objSizeKey, &pb.ObjectSize{Size: size})
 }
@@ -172,6 +268,22 @@ func ReadObjectSize(ctx context.Context, kr KeyReader) (int64, error) {
 	}
 }
 
+// Start pair
+
+func writeObjectConfig(ctx context.Context, kr KeyWriter, data *pb.ObjectConfig) error {
+	return kr.Set(ctx, objConfigKey, data)
+}
+
+// lookupObjectConfig returns ErrNoSuchKey if nothing has been added to the object yet
+func lookupObjectConfig(ctx context.Context, kr KeyReader) (*pb.ObjectConfig, error) {
+	var rv pb.ObjectConfig
+	err := kr.Get(ctx, objConfigKey, &rv)
+	if err != nil {
+		return nil, err
+	}
+	return &rv, nil
+}
+
 func lookupLogTreeHead(ctx context.Context, kr KeyReader, lt pb.LogType) (*pb.LogTreeHashResponse, error) {
 	objectSize, err := ReadObjectSize(ctx, kr)
 	if err != nil {
@@ -212,6 +324,131 @@ func lookupMapHash(ctx context.Context, kr KeyReader, number int64, path BPath)
 	return &m, nil
 }
 
+func deleteMapHash(ctx context.Context, kr KeyWriter, number int64, path BPath) error {
+	return kr.Set(ctx, makeStorageKey(mapNodeBucket, append(toIntBinary(uint64(number)), path...)), nil)
+}
+
+// lookupProvableMapRoot returns the root node for a map tree size that proofs are needed for,
+// failing if the nodes below it have been deleted by compaction.
+func lookupProvableMapRoot(ctx context.Context, kr KeyReader, treeSize int64) (*pb.MapNode, error) {
+	root, err := lookupMapHash(ctx, kr, treeSize, BPathEmpty)
+	if err != nil {
+		return nil, err
+	}
+	if root.Pruned {
+		return nil, status.Errorf(codes.NotFound, "tree size %d has been pruned", treeSize)
+	}
+	return root, nil
+}
+
+// Start pair
+
+func writeMapCompaction(ctx context.Context, kr KeyWriter, data *pb.MapCompaction) error {
+	return kr.Set(ctx, mapCompactionKey, data)
+}
+
+func readMapCompaction(ctx context.Context, kr KeyReader) (*pb.MapCompaction, error) {
+	var m pb.MapCompaction
+	err := kr.Get(ctx, mapCompactionKey, &m)
+	switch err {
+	case nil:
+		return &m, nil
+	case ErrNoSuchKey:
+		return &pb.MapCompaction{}, nil
+	default:
+		return nil, err
+	}
+}
+
+// Start pair
+
+func writeMapKeyHistorySize(ctx context.Context, kr KeyWriter, keyPath BPath, size int64) error {
+	return kr.Set(ctx, makeStorageKey(mapKeyHistorySizeBucket, keyPath), &pb.ObjectSize{Size: size})
+}
+
+// returns 0 if the key has never changed
+func lookupMapKeyHistorySize(ctx context.Context, kr KeyReader, keyPath BPath) (int64, error) {
+	var m pb.ObjectSize
+	err := kr.Get(ctx, makeStorageKey(mapKeyHistorySizeBucket, keyPath), &m)
+	switch err {
+	case nil:
+		return m.Size, nil
+	case ErrNoSuchKey:
+		return 0, nil
+	default:
+		return 0, err
+	}
+}
+
+// Start pair
+
+func writeMapKeyChange(ctx context.Context, kr KeyWriter, keyPath BPath, idx int64, data *pb.MapKeyChange) error {
+	return kr.Set(ctx, makeStorageKey(mapKeyHistoryBucket, append(append([]byte(nil), keyPath...), toIntBinary(uint64(idx))...)), data)
+}
+
+func lookupMapKeyChange(ctx context.Context, kr KeyReader, keyPath BPath, idx int64) (*pb.MapKeyChange, error) {
+	var m pb.MapKeyChange
+	err := kr.Get(ctx, makeStorageKey(mapKeyHistoryBucket, append(append([]byte(nil), keyPath...), toIntBinary(uint64(idx))...)), &m)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Start pair
+
+func writeMapRejection(ctx context.Context, kr KeyWriter, mutationIndex int64) error {
+	return kr.Set(ctx, makeStorageKey(mapRejectedBucket, toIntBinary(uint64(mutationIndex))), &pb.EntryIndex{Index: mutationIndex})
+}
+
+// returns false if the mutation was not rejected
+func lookupMapRejection(ctx context.Context, kr KeyReader, mutationIndex int64) (bool, error) {
+	var m pb.EntryIndex
+	err := kr.Get(ctx, makeStorageKey(mapRejectedBucket, toIntBinary(uint64(mutationIndex))), &m)
+	switch err {
+	case nil:
+		return true, nil
+	case ErrNoSuchKey:
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// Start pair
+
+func writeMapPatriciaNode(ctx context.Context, kr KeyWriter, hash []byte, data *pb.MapPatriciaNode) error {
+	return kr.Set(ctx, makeStorageKey(mapPatriciaNodeBucket, hash), data)
+}
+
+func lookupMapPatriciaNode(ctx context.Context, kr KeyReader, hash []byte) (*pb.MapPatriciaNode, error) {
+	var m pb.MapPatriciaNode
+	err := kr.Get(ctx, makeStorageKey(mapPatriciaNodeBucket, hash), &m)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Start pair
+
+func writeMapPatriciaRoot(ctx context.Context, kr KeyWriter, size int64, data *pb.MapPatriciaRoot) error {
+	return kr.Set(ctx, makeStorageKey(mapPatriciaRootBucket, toIntBinary(uint64(size))), data)
+}
+
+// returns the root hash of the empty trie for size 0
+func lookupMapPatriciaRoot(ctx context.Context, kr KeyReader, size int64) (*pb.MapPatriciaRoot, error) {
+	if size == 0 {
+		return &pb.MapPatriciaRoot{RootHash: mpt.EmptyRoot}, nil
+	}
+	var m pb.MapPatriciaRoot
+	err := kr.Get(ctx, makeStorageKey(mapPatriciaRootBucket, toIntBinary(uint64(size))), &m)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
 // End pairs
 
 func lookupLogEntryHashes(ctx context.Context, kr KeyReader, lt pb.LogType, first, last int64) ([][]byte, error) {
@@ -262,3 +499,11 @@ func mapBucket(vmap *pb.MapRef) ([]byte, error) {
 		"type":    "map",
 	})
 }
+
+func multimapBucket(mm *pb.MultimapRef) ([]byte, error) {
+	return objecthash.ObjectHash(map[string]interface{}{
+		"account": mm.Account.Id,
+		"name":    mm.Name,
+		"type":    "multimap",
+	})
+}
@@ -0,0 +1,184 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/continusec/verifiabledatastructures/merkle"
+	"github.com/continusec/verifiabledatastructures/pb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// sumTreeHasher is the hash strategy for the tree of a sum-tree map, where the amount for each
+// value is the integer in field
+type sumTreeHasher struct {
+	*merkle.SumHasher
+	field string
+}
+
+type sumTreeHasherKey struct {
+	h     merkle.Hasher
+	field string
+}
+
+// sumTreeHashers caches sum-tree hashers, since each calculates its default values when created
+var sumTreeHashers sync.Map
+
+// mapTreeHasher returns the hash strategy for the tree of a map with the given hasher, which for
+// a sum-tree map is a sumTreeHasher. Leaf hashes are unchanged.
+func mapTreeHasher(h merkle.Hasher, sumField string) merkle.Hasher {
+	if sumField == "" {
+		return h
+	}
+	k := sumTreeHasherKey{h: h, field: sumField}
+	rv, ok := sumTreeHashers.Load(k)
+	if !ok {
+		rv, _ = sumTreeHashers.LoadOrStore(k, &sumTreeHasher{
+			SumHasher: merkle.NewSumHasher(h),
+			field:     sumField,
+		})
+	}
+	return rv.(*sumTreeHasher)
+}
+
+// readSumField returns the field summed by the map, or "" if it is not a sum-tree map. If nothing
+// has been added yet, then def is returned.
+func readSumField(ctx context.Context, kr KeyReader, def string) (string, error) {
+	conf, err := lookupObjectConfig(ctx, kr)
+	switch err {
+	case nil:
+		return conf.SumField, nil
+	case ErrNoSuchKey:
+		size, err := ReadObjectSize(ctx, kr)
+		if err != nil {
+			return "", err
+		}
+		if size != 0 {
+			return "", nil
+		}
+		return def, nil
+	default:
+		return "", err
+	}
+}
+
+// readMapTreeHasher returns the hash strategy for the tree of the map, given its hasher, and the field
+// it sums if nothing has been added yet
+func readMapTreeHasher(ctx context.Context, kr KeyReader, h merkle.Hasher, def string) (merkle.Hasher, error) {
+	field, err := readSumField(ctx, kr, def)
+	if err != nil {
+		return nil, err
+	}
+	return mapTreeHasher(h, field), nil
+}
+
+// checkSumField fails if the requested sum field for a map that is about to be added to does not
+// match that already recorded.
+func (s *localServiceImpl) checkSumField(ctx context.Context, ns []byte, field string) error {
+	if strings.ContainsAny(field, "\r\n") {
+		return status.Errorf(codes.InvalidArgument, "bad sum field")
+	}
+	err := s.Reader.ExecuteReadOnly(ctx, ns, func(ctx context.Context, kr KeyReader) error {
+		recorded, err := readSumField(ctx, kr, field)
+		if err != nil {
+			return err
+		}
+		if recorded != field {
+			return status.Errorf(codes.InvalidArgument, "sum field %q does not match %q", field, recorded)
+		}
+		return nil
+	})
+	if err != nil {
+		_, ok := status.FromError(err)
+		if !ok {
+			err = status.Errorf(codes.Internal, "unknown err: %s", err)
+		}
+		return err
+	}
+	return nil
+}
+
+// mapLeafAmount returns the amount that a value adds to the sum of a sum-tree map, which is the
+// integer in field if the value is a JSON object with a non-negative integer there, else zero.
+func mapLeafAmount(field string, value *pb.LeafData) uint64 {
+	if len(value.GetExtraData()) == 0 {
+		return 0
+	}
+	d := json.NewDecoder(bytes.NewReader(value.ExtraData))
+	d.UseNumber()
+	var o map[string]interface{}
+	if d.Decode(&o) != nil {
+		return 0
+	}
+	n, ok := o[field].(json.Number)
+	if !ok {
+		return 0
+	}
+	rv, err := strconv.ParseUint(string(n), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return rv
+}
+
+// mapLeafSum returns the amount to record for a leaf with value in a map with hasher h, which
+// is zero unless it is a sum-tree map
+func mapLeafSum(h merkle.Hasher, value *pb.LeafData) uint64 {
+	sh, ok := h.(*sumTreeHasher)
+	if !ok {
+		return 0
+	}
+	return mapLeafAmount(sh.field, value)
+}
+
+// mapLeafNodeHash returns the hash for a leaf in the tree of a map with hasher h, given its leaf hash
+// and the amount it adds to the sum. For maps other than sum-tree maps, this is just the leaf hash.
+func mapLeafNodeHash(h merkle.Hasher, leafHash []byte, amount uint64) []byte {
+	sh, ok := h.(*sumTreeHasher)
+	if !ok {
+		return leafHash
+	}
+	return sh.LeafNodeHash(leafHash, amount)
+}
+
+// mapValueNodeHash returns the hash for a leaf in the tree of a map with hasher h, given its value
+func mapValueNodeHash(h merkle.Hasher, value *pb.LeafData) []byte {
+	return mapLeafNodeHash(h, h.LeafHash(value.GetLeafInput()), mapLeafSum(h, value))
+}
+
+// MapTreeHeadTotals returns the number of keys with a non-empty value in a sum-tree map, and the sum
+// of the summed field in them, as committed to by the root hash in the MapTreeHead. The MapTreeHead
+// should be verified before relying on these.
+func MapTreeHeadTotals(head *pb.MapTreeHashResponse) (uint64, uint64, error) {
+	if head.SumField == "" {
+		return 0, 0, ErrInvalidRequest
+	}
+	count, sum, ok := merkle.SumTotals(head.RootHash)
+	if !ok {
+		return 0, 0, ErrVerificationFailed
+	}
+	return count, sum, nil
+}
@@ -0,0 +1,270 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/continusec/verifiabledatastructures/pb"
+)
+
+// LogOrigin returns the string used to identify a log in signed tree heads. It mirrors
+// the path used to access the log over the REST API.
+func LogOrigin(log *pb.LogRef) string {
+	switch log.LogType {
+	case pb.LogType_STRUCT_TYPE_MUTATION_LOG:
+		return fmt.Sprintf("%s/map/%s/log/mutation", log.Account.Id, log.Name)
+	case pb.LogType_STRUCT_TYPE_TREEHEAD_LOG:
+		return fmt.Sprintf("%s/map/%s/log/treehead", log.Account.Id, log.Name)
+	default:
+		return fmt.Sprintf("%s/log/%s", log.Account.Id, log.Name)
+	}
+}
+
+// MapOrigin returns the string used to identify a map in signed tree heads.
+func MapOrigin(vmap *pb.MapRef) string {
+	return fmt.Sprintf("%s/map/%s", vmap.Account.Id, vmap.Name)
+}
+
+// MultimapOrigin returns the string used to identify a multimap in signed tree heads.
+func MultimapOrigin(mm *pb.MultimapRef) string {
+	return fmt.Sprintf("%s/multimap/%s", mm.Account.Id, mm.Name)
+}
+
+// LogTreeHeadText returns the text that is signed for a log tree head. This is the
+// origin, tree size and base64 encoded root hash, each followed by a newline.
+func LogTreeHeadText(log *pb.LogRef, head *pb.LogTreeHashResponse) []byte {
+	return []byte(fmt.Sprintf("%s\n%d\n%s\n", LogOrigin(log), head.TreeSize, base64.StdEncoding.EncodeToString(head.RootHash)))
+}
+
+// MapTreeHeadText returns the text that is signed for a map tree head. This is the
+// origin, mutation log tree size, base64 encoded map root hash and base64 encoded
+// mutation log root hash, each followed by a newline. For a map with VRF keys, this
+// is followed by the base64 encoded VRF public key and a newline, for a sum-tree
+// map, by "sum " and the sum field and a newline, and for a patricia trie map, by
+// "patricia" and a newline.
+func MapTreeHeadText(vmap *pb.MapRef, head *pb.MapTreeHashResponse) []byte {
+	return mapTreeHeadText(MapOrigin(vmap), head)
+}
+
+// MultimapTreeHeadText returns the text that is signed for a multimap tree head. This is
+// the same as for a map, but with the origin for the multimap.
+func MultimapTreeHeadText(mm *pb.MultimapRef, head *pb.MapTreeHashResponse) []byte {
+	return mapTreeHeadText(MultimapOrigin(mm), head)
+}
+
+func mapTreeHeadText(origin string, head *pb.MapTreeHashResponse) []byte {
+	rv := fmt.Sprintf("%s\n%d\n%s\n%s\n", origin, head.GetMutationLog().GetTreeSize(), base64.StdEncoding.EncodeToString(head.RootHash), base64.StdEncoding.EncodeToString(head.GetMutationLog().GetRootHash()))
+	if len(head.VrfPublicKey) != 0 {
+		rv += base64.StdEncoding.EncodeToString(head.VrfPublicKey) + "\n"
+	}
+	if head.SumField != "" {
+		rv += "sum " + head.SumField + "\n"
+	}
+	if head.PatriciaTrie {
+		rv += "patricia\n"
+	}
+	return []byte(rv)
+}
+
+// TreeHeadKeyID returns the key ID used for a public key, which is the SHA256 hash
+// of the DER encoded PKIX public key.
+func TreeHeadKeyID(pub crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.Sum256(der)
+	return h[:], nil
+}
+
+// ParseTreeHeadSigningKey parses a PEM encoded PKCS#8 private key suitable for signing tree heads.
+// Only Ed25519 and ECDSA P-256 keys are accepted.
+func ParseTreeHeadSigningKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrInvalidRequest
+	}
+	k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	switch key := k.(type) {
+	case ed25519.PrivateKey:
+		return key, nil
+	case *ecdsa.PrivateKey:
+		if key.Curve != elliptic.P256() {
+			return nil, ErrNotImplemented
+		}
+		return key, nil
+	default:
+		return nil, ErrNotImplemented
+	}
+}
+
+// ParseTreeHeadPublicKey parses a PEM encoded PKIX public key suitable for verifying tree heads.
+func ParseTreeHeadPublicKey(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrInvalidRequest
+	}
+	return x509.ParsePKIXPublicKey(block.Bytes)
+}
+
+func signTreeHeadText(signer crypto.Signer, text []byte) (*pb.TreeHeadSignature, error) {
+	keyID, err := TreeHeadKeyID(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	var sig []byte
+	switch signer.Public().(type) {
+	case ed25519.PublicKey:
+		sig, err = signer.Sign(rand.Reader, text, crypto.Hash(0))
+	case *ecdsa.PublicKey:
+		h := sha256.Sum256(text)
+		sig, err = signer.Sign(rand.Reader, h[:], crypto.SHA256)
+	default:
+		return nil, ErrNotImplemented
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &pb.TreeHeadSignature{
+		KeyId:     keyID,
+		Signature: sig,
+	}, nil
+}
+
+func verifyTreeHeadText(pub crypto.PublicKey, text []byte, sig *pb.TreeHeadSignature) error {
+	if sig == nil || len(sig.Signature) == 0 {
+		return ErrVerificationFailed
+	}
+	keyID, err := TreeHeadKeyID(pub)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(keyID, sig.KeyId) {
+		return ErrVerificationFailed
+	}
+	switch key := pub.(type) {
+	case ed25519.PublicKey:
+		if !ed25519.Verify(key, text, sig.Signature) {
+			return ErrVerificationFailed
+		}
+	case *ecdsa.PublicKey:
+		h := sha256.Sum256(text)
+		if !ecdsa.VerifyASN1(key, h[:], sig.Signature) {
+			return ErrVerificationFailed
+		}
+	default:
+		return ErrNotImplemented
+	}
+
+	// all clear
+	return nil
+}
+
+// VerifyLogTreeHeadSignature verifies that a LogTreeHead has been signed by the given public key.
+// Unsigned tree heads fail verification.
+func VerifyLogTreeHeadSignature(log *pb.LogRef, head *pb.LogTreeHashResponse, pub crypto.PublicKey) error {
+	if head == nil {
+		return ErrNilTreeHead
+	}
+	return verifyTreeHeadText(pub, LogTreeHeadText(log, head), head.Signature)
+}
+
+// SignLogTreeHead returns a signature over the LogTreeHeadText for a tree head. This is used by witnesses
+// to cosign tree heads.
+func SignLogTreeHead(signer crypto.Signer, log *pb.LogRef, head *pb.LogTreeHashResponse) (*pb.TreeHeadSignature, error) {
+	if head == nil {
+		return nil, ErrNilTreeHead
+	}
+	return signTreeHeadText(signer, LogTreeHeadText(log, head))
+}
+
+// VerifyLogTreeHeadCosignatures verifies that a LogTreeHead has been cosigned by at least quorum of the
+// given witness public keys. Each witness is counted at most once. If quorum is zero, all witnesses must
+// have cosigned.
+func VerifyLogTreeHeadCosignatures(log *pb.LogRef, head *pb.LogTreeHashResponse, witnesses []crypto.PublicKey, quorum int) error {
+	if head == nil {
+		return ErrNilTreeHead
+	}
+	if quorum == 0 {
+		quorum = len(witnesses)
+	}
+	text := LogTreeHeadText(log, head)
+	good := 0
+	for _, pub := range witnesses {
+		for _, sig := range head.Cosignatures {
+			if verifyTreeHeadText(pub, text, sig) == nil {
+				good++
+				break
+			}
+		}
+	}
+	if good < quorum {
+		return ErrVerificationFailed
+	}
+
+	// all clear
+	return nil
+}
+
+// VerifyMapTreeHeadSignature verifies that a MapTreeHead has been signed by the given public key.
+// Unsigned tree heads fail verification.
+func VerifyMapTreeHeadSignature(vmap *pb.MapRef, head *pb.MapTreeHashResponse, pub crypto.PublicKey) error {
+	if head == nil || head.MutationLog == nil {
+		return ErrNilTreeHead
+	}
+	return verifyTreeHeadText(pub, MapTreeHeadText(vmap, head), head.Signature)
+}
+
+// VerifyMultimapTreeHeadSignature verifies that a multimap tree head has been signed by the given public key.
+// Unsigned tree heads fail verification.
+func VerifyMultimapTreeHeadSignature(mm *pb.MultimapRef, head *pb.MapTreeHashResponse, pub crypto.PublicKey) error {
+	if head == nil || head.MutationLog == nil {
+		return ErrNilTreeHead
+	}
+	return verifyTreeHeadText(pub, MultimapTreeHeadText(mm, head), head.Signature)
+}
+
+// unsignedMapTreeHead returns a copy of the map tree head without any signatures, or other fields
+// that are not stored, which is the form that is stored in the tree head log.
+func unsignedMapTreeHead(head *pb.MapTreeHashResponse) *pb.MapTreeHashResponse {
+	return &pb.MapTreeHashResponse{
+		RootHash: head.RootHash,
+		MutationLog: &pb.LogTreeHashResponse{
+			TreeSize: head.GetMutationLog().GetTreeSize(),
+			RootHash: head.GetMutationLog().GetRootHash(),
+		},
+		VrfPublicKey: head.VrfPublicKey,
+		SumField:     head.SumField,
+		PatriciaTrie: head.PatriciaTrie,
+	}
+}
@@ -0,0 +1,74 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"sync"
+	"time"
+)
+
+// How often to check for updates when the mutator can't tell us
+const updatePollInterval = 250 * time.Millisecond
+
+// UpdateBroadcaster is a simple implementation of UpdateNotifier suitable for embedding in a
+// MutatorService, which should call Notify after each update is committed. The zero value is ready to use.
+type UpdateBroadcaster struct {
+	lock    sync.Mutex
+	waiting map[string]chan struct{}
+}
+
+// Updated returns a channel that is closed after the next call to Notify for the namespace.
+func (u *UpdateBroadcaster) Updated(namespace []byte) <-chan struct{} {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	if u.waiting == nil {
+		u.waiting = make(map[string]chan struct{})
+	}
+	rv, ok := u.waiting[string(namespace)]
+	if !ok {
+		rv = make(chan struct{})
+		u.waiting[string(namespace)] = rv
+	}
+	return rv
+}
+
+// Notify wakes anyone waiting on an update to the namespace.
+func (u *UpdateBroadcaster) Notify(namespace []byte) {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	ch, ok := u.waiting[string(namespace)]
+	if ok {
+		close(ch)
+		delete(u.waiting, string(namespace))
+	}
+}
+
+// updated returns a channel that is closed when the namespace may have been updated. This must
+// be called before reading, so that updates committed during the read are not missed.
+func (s *localServiceImpl) updated(namespace []byte) <-chan struct{} {
+	un, ok := s.Mutator.(UpdateNotifier)
+	if ok {
+		return un.Updated(namespace)
+	}
+	rv := make(chan struct{})
+	time.AfterFunc(updatePollInterval, func() { close(rv) })
+	return rv
+}
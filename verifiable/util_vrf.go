@@ -0,0 +1,210 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"bytes"
+	"crypto/ed25519"
+
+	"github.com/continusec/verifiabledatastructures/merkle"
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/vrf"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ParseVRFKey parses a PEM encoded PKCS#8 private key suitable for deriving key paths for maps with VRF keys.
+// Only Ed25519 keys are accepted.
+func ParseVRFKey(data []byte) (ed25519.PrivateKey, error) {
+	k, err := ParseTreeHeadSigningKey(data)
+	if err != nil {
+		return nil, err
+	}
+	rv, ok := k.(ed25519.PrivateKey)
+	if !ok {
+		return nil, ErrNotImplemented
+	}
+	return rv, nil
+}
+
+// readVRFPublicKey returns the VRF public key recorded for a map, or nil if it does not have VRF keys
+func readVRFPublicKey(ctx context.Context, kr KeyReader) (ed25519.PublicKey, error) {
+	conf, err := lookupObjectConfig(ctx, kr)
+	switch err {
+	case nil:
+		return conf.VrfPublicKey, nil
+	case ErrNoSuchKey:
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
+// readMapKeyHash returns the hash of a key that determines its path in a map. For a map with VRF keys,
+// this is derived from the VRF output for the key, which is calculated with vrfKey, and the VRF proof
+// is also returned.
+func readMapKeyHash(ctx context.Context, kr KeyReader, h merkle.Hasher, vrfKey ed25519.PrivateKey, key []byte) ([]byte, []byte, error) {
+	pub, err := readVRFPublicKey(ctx, kr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(pub) == 0 {
+		return h.KeyHash(key), nil, nil
+	}
+	if vrfKey == nil || !pub.Equal(vrfKey.Public()) {
+		return nil, nil, status.Errorf(codes.FailedPrecondition, "VRF key for map not configured")
+	}
+	proof, output, err := vrf.Prove(vrfKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return h.KeyHash(output), proof, nil
+}
+
+// mutationKeyHash returns the hash of the key for a mutation, which is derived from the VRF proof
+// for the key if it has one. The proof is not verified, as it was set by the server.
+func mutationKeyHash(h merkle.Hasher, mut *pb.MapMutation) ([]byte, error) {
+	if len(mut.KeyProof) == 0 {
+		return h.KeyHash(mut.Key), nil
+	}
+	output, err := vrf.ProofToHash(mut.KeyProof)
+	if err != nil {
+		return nil, err
+	}
+	return h.KeyHash(output), nil
+}
+
+// setMutationKeyProofs sets the VRF proof for the key of a mutation, and each operation in a transaction,
+// using vrfKey, or clears them if it is nil. With a VRF key, the key itself is then cleared, so that the
+// mutation log does not reveal it.
+func setMutationKeyProofs(vrfKey ed25519.PrivateKey, mut *pb.MapMutation) error {
+	ops := []*pb.MapMutation{mut}
+	if mut.Action == "transaction" {
+		mut.KeyProof = nil
+		ops = mut.Operations
+	}
+	for _, op := range ops {
+		op.KeyProof = nil
+		if vrfKey != nil {
+			var err error
+			op.KeyProof, _, err = vrf.Prove(vrfKey, op.Key)
+			if err != nil {
+				return err
+			}
+			op.Key = nil
+		}
+	}
+	return nil
+}
+
+// verifyMutationKeyProofs checks that each operation in a mutation has a well-formed VRF proof and no key
+// if pub is set, or no proof otherwise. As the key is not revealed, the proof can't be verified against it,
+// but the path it determines can be.
+func verifyMutationKeyProofs(pub ed25519.PublicKey, mut *pb.MapMutation) error {
+	ops := []*pb.MapMutation{mut}
+	if mut.Action == "transaction" {
+		if len(mut.KeyProof) != 0 {
+			return ErrVerificationFailed
+		}
+		ops = mut.Operations
+	}
+	for _, op := range ops {
+		if len(pub) == 0 {
+			if len(op.KeyProof) != 0 {
+				return ErrVerificationFailed
+			}
+			continue
+		}
+		if len(op.Key) != 0 {
+			return ErrVerificationFailed
+		}
+		_, err := vrf.ProofToHash(op.KeyProof)
+		if err != nil {
+			return ErrVerificationFailed
+		}
+	}
+	return nil
+}
+
+// mutationIsForKey returns whether a mutation is for key. For a map with VRF keys, the mutation has only
+// the VRF proof for its key, which must verify for key against pub.
+func mutationIsForKey(pub ed25519.PublicKey, mut *pb.MapMutation, key []byte) bool {
+	if len(pub) == 0 {
+		return len(mut.KeyProof) == 0 && bytes.Equal(mut.Key, key)
+	}
+	if len(mut.Key) != 0 {
+		return false
+	}
+	_, err := vrf.Verify(pub, key, mut.KeyProof)
+	return err == nil
+}
+
+// verifiedKeyHash returns the hash of a key that determines its path in a map, after verifying the VRF
+// proof for it against pub for a map with VRF keys.
+func verifiedKeyHash(h merkle.Hasher, pub ed25519.PublicKey, key, proof []byte) ([]byte, error) {
+	if len(pub) == 0 {
+		return h.KeyHash(key), nil
+	}
+	output, err := vrf.Verify(pub, key, proof)
+	if err != nil {
+		return nil, ErrVerificationFailed
+	}
+	return h.KeyHash(output), nil
+}
+
+// vrfKeyForUpdate returns the VRF key to derive key paths with for a map that is about to be added to,
+// or nil if it does not have VRF keys, failing if the requested mode does not match that already recorded,
+// or the key recorded is not ours.
+func (s *localServiceImpl) vrfKeyForUpdate(ctx context.Context, ns []byte, vrfKeys bool) (ed25519.PrivateKey, error) {
+	err := s.Reader.ExecuteReadOnly(ctx, ns, func(ctx context.Context, kr KeyReader) error {
+		size, err := ReadObjectSize(ctx, kr)
+		if err != nil {
+			return err
+		}
+		if size == 0 {
+			return nil
+		}
+		pub, err := readVRFPublicKey(ctx, kr)
+		if err != nil {
+			return err
+		}
+		if (len(pub) != 0) != vrfKeys {
+			return status.Errorf(codes.InvalidArgument, "VRF keys %t does not match %t", vrfKeys, len(pub) != 0)
+		}
+		if vrfKeys && (s.VRFKey == nil || !pub.Equal(s.VRFKey.Public())) {
+			return status.Errorf(codes.FailedPrecondition, "VRF key for map not configured")
+		}
+		return nil
+	})
+	if err != nil {
+		_, ok := status.FromError(err)
+		if !ok {
+			err = status.Errorf(codes.Internal, "unknown err: %s", err)
+		}
+		return nil, err
+	}
+	if !vrfKeys {
+		return nil, nil
+	}
+	if s.VRFKey == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "no VRF key configured")
+	}
+	return s.VRFKey, nil
+}
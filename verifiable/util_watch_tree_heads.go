@@ -0,0 +1,97 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"errors"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errStopWatching is returned by the stream callback to end a watch once the caller is satisfied
+var errStopWatching = errors.New("stop watching")
+
+// watchTreeHeads calls f with each tree head larger than after, until f returns true or an error.
+// Tree heads are pushed by the server as they are committed. If the server is unable to do so,
+// poll is called instead, with exponential back-off used when no new tree head is available.
+func watchTreeHeads[T any](ctx context.Context, after int64, watch func(after int64, stream *callbackStream[T]) error, poll func() (*T, error), size func(*T) int64, f func(*T) (bool, error)) error {
+	watched := false
+	for {
+		err := watch(after, &callbackStream[T]{
+			ctx: ctx,
+			send: func(th *T) error {
+				watched = true
+				after = size(th)
+				done, err := f(th)
+				if err != nil {
+					return err
+				}
+				if done {
+					return errStopWatching
+				}
+				return nil
+			},
+		})
+		switch {
+		case err == errStopWatching:
+			return nil
+		case status.Code(err) == codes.Unimplemented && !watched:
+			// Older servers may not support watching, so fall back to polling
+			return pollTreeHeads(ctx, after, poll, size, f)
+		case err != nil:
+			return err
+		}
+		// else the server closed the stream, so watch again
+	}
+}
+
+// pollTreeHeads calls f each time poll returns a tree head larger than any seen before, until f returns
+// true or an error.
+func pollTreeHeads[T any](ctx context.Context, after int64, poll func() (*T, error), size func(*T) int64, f func(*T) (bool, error)) error {
+	timeToSleep := time.Second
+	for {
+		th, err := poll()
+		if err != nil {
+			return err
+		}
+		if size(th) > after {
+			after = size(th)
+			done, err := f(th)
+			if err != nil {
+				return err
+			}
+			if done {
+				return nil
+			}
+			// since we got a new tree head, reset sleep time
+			timeToSleep = time.Second
+		} else {
+			// no luck, snooze a bit longer
+			timeToSleep *= 2
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(timeToSleep):
+		}
+	}
+}
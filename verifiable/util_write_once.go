@@ -0,0 +1,78 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package verifiable
+
+import (
+	"bytes"
+
+	"github.com/continusec/verifiabledatastructures/merkle"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// readWriteOnce returns whether the map is recorded as write-once. If nothing has been added yet,
+// then def is returned. Maps created before this was recorded are not write-once.
+func readWriteOnce(ctx context.Context, kr KeyReader, def bool) (bool, error) {
+	conf, err := lookupObjectConfig(ctx, kr)
+	switch err {
+	case nil:
+		return conf.WriteOnce, nil
+	case ErrNoSuchKey:
+		size, err := ReadObjectSize(ctx, kr)
+		if err != nil {
+			return false, err
+		}
+		if size != 0 {
+			return false, nil
+		}
+		return def, nil
+	default:
+		return false, err
+	}
+}
+
+// checkWriteOnce fails if the requested write-once mode for a map that is about to be added to
+// does not match that already recorded.
+func (s *localServiceImpl) checkWriteOnce(ctx context.Context, ns []byte, writeOnce bool) error {
+	err := s.Reader.ExecuteReadOnly(ctx, ns, func(ctx context.Context, kr KeyReader) error {
+		recorded, err := readWriteOnce(ctx, kr, writeOnce)
+		if err != nil {
+			return err
+		}
+		if recorded != writeOnce {
+			return status.Errorf(codes.InvalidArgument, "write-once %t does not match %t", writeOnce, recorded)
+		}
+		return nil
+	})
+	if err != nil {
+		_, ok := status.FromError(err)
+		if !ok {
+			err = status.Errorf(codes.Internal, "unknown err: %s", err)
+		}
+		return err
+	}
+	return nil
+}
+
+// writeOnceRejects returns whether a write-once map must reject changing the leaf hash for a key from prev to next,
+// which is the case if the key has a non-empty value that would change.
+func writeOnceRejects(h merkle.Hasher, prev, next []byte) bool {
+	return !bytes.Equal(prev, nullLeafHash(h)) && !bytes.Equal(prev, next)
+}
@@ -0,0 +1,192 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+// Package vrf implements the ECVRF-EDWARDS25519-SHA512-TAI verifiable random function, as described
+// in RFC 9381, using Ed25519 keys. A VRF maps an input to a pseudorandom output that only the holder of
+// the private key can calculate, along with a proof that anyone with the public key can check.
+package vrf
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha512"
+	"errors"
+
+	"filippo.io/edwards25519"
+)
+
+const (
+	suite = 0x03 // ECVRF-EDWARDS25519-SHA512-TAI
+
+	// ProofSize is the size of a proof, being an encoded point, and two scalars of 16 and 32 bytes.
+	ProofSize = 80
+
+	// OutputSize is the size of the VRF output.
+	OutputSize = sha512.Size
+)
+
+var (
+	// ErrInvalidProof is returned when a proof does not verify.
+	ErrInvalidProof = errors.New("ErrInvalidProof")
+
+	// ErrInvalidKey is returned when a public key is not valid for use with a VRF.
+	ErrInvalidKey = errors.New("ErrInvalidKey")
+)
+
+// Prove returns the proof and output of the VRF for alpha.
+func Prove(priv ed25519.PrivateKey, alpha []byte) ([]byte, []byte, error) {
+	digest := sha512.Sum512(priv.Seed())
+	x, err := edwards25519.NewScalar().SetBytesWithClamping(digest[:32])
+	if err != nil {
+		return nil, nil, err
+	}
+	pub := priv.Public().(ed25519.PublicKey)
+	y, err := new(edwards25519.Point).SetBytes(pub)
+	if err != nil {
+		return nil, nil, ErrInvalidKey
+	}
+
+	h, err := encodeToCurve(pub, alpha)
+	if err != nil {
+		return nil, nil, err
+	}
+	gamma := new(edwards25519.Point).ScalarMult(x, h)
+
+	// The nonce is derived as for Ed25519 signatures (RFC 8032)
+	nonce := sha512.New()
+	nonce.Write(digest[32:])
+	nonce.Write(h.Bytes())
+	k, err := edwards25519.NewScalar().SetUniformBytes(nonce.Sum(nil))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c, err := challenge(y, h, gamma, new(edwards25519.Point).ScalarBaseMult(k), new(edwards25519.Point).ScalarMult(k, h))
+	if err != nil {
+		return nil, nil, err
+	}
+	s := edwards25519.NewScalar().MultiplyAdd(c, x, k)
+
+	pi := make([]byte, 0, ProofSize)
+	pi = append(pi, gamma.Bytes()...)
+	pi = append(pi, c.Bytes()[:16]...)
+	pi = append(pi, s.Bytes()...)
+	return pi, proofToHash(gamma), nil
+}
+
+// Verify checks that pi is a valid proof for alpha with the public key pub, and if so returns the VRF output.
+func Verify(pub ed25519.PublicKey, alpha, pi []byte) ([]byte, error) {
+	if len(pub) != ed25519.PublicKeySize {
+		return nil, ErrInvalidKey
+	}
+	y, err := new(edwards25519.Point).SetBytes(pub)
+	if err != nil {
+		return nil, ErrInvalidKey
+	}
+	if new(edwards25519.Point).MultByCofactor(y).Equal(edwards25519.NewIdentityPoint()) == 1 {
+		return nil, ErrInvalidKey // low order
+	}
+
+	gamma, c, s, err := decodeProof(pi)
+	if err != nil {
+		return nil, err
+	}
+	h, err := encodeToCurve(pub, alpha)
+	if err != nil {
+		return nil, err
+	}
+
+	// U = s*B - c*Y, V = s*H - c*Gamma
+	negC := edwards25519.NewScalar().Negate(c)
+	u := new(edwards25519.Point).VarTimeDoubleScalarBaseMult(negC, y, s)
+	v := new(edwards25519.Point).VarTimeMultiScalarMult([]*edwards25519.Scalar{s, negC}, []*edwards25519.Point{h, gamma})
+
+	expected, err := challenge(y, h, gamma, u, v)
+	if err != nil {
+		return nil, err
+	}
+	if expected.Equal(c) != 1 {
+		return nil, ErrInvalidProof
+	}
+	return proofToHash(gamma), nil
+}
+
+// ProofToHash returns the VRF output for a proof, without verifying it.
+func ProofToHash(pi []byte) ([]byte, error) {
+	gamma, _, _, err := decodeProof(pi)
+	if err != nil {
+		return nil, err
+	}
+	return proofToHash(gamma), nil
+}
+
+func proofToHash(gamma *edwards25519.Point) []byte {
+	d := sha512.New()
+	d.Write([]byte{suite, 0x03})
+	d.Write(new(edwards25519.Point).MultByCofactor(gamma).Bytes())
+	d.Write([]byte{0x00})
+	return d.Sum(nil)
+}
+
+func decodeProof(pi []byte) (*edwards25519.Point, *edwards25519.Scalar, *edwards25519.Scalar, error) {
+	if len(pi) != ProofSize {
+		return nil, nil, nil, ErrInvalidProof
+	}
+	gamma, err := new(edwards25519.Point).SetBytes(pi[:32])
+	if err != nil {
+		return nil, nil, nil, ErrInvalidProof
+	}
+	c, err := edwards25519.NewScalar().SetCanonicalBytes(append(bytes.Clone(pi[32:48]), make([]byte, 16)...))
+	if err != nil {
+		return nil, nil, nil, ErrInvalidProof
+	}
+	s, err := edwards25519.NewScalar().SetCanonicalBytes(pi[48:])
+	if err != nil { // s must be less than the group order
+		return nil, nil, nil, ErrInvalidProof
+	}
+	return gamma, c, s, nil
+}
+
+// encodeToCurve hashes alpha to a point in the prime order subgroup by try-and-increment
+func encodeToCurve(pub ed25519.PublicKey, alpha []byte) (*edwards25519.Point, error) {
+	for ctr := 0; ctr < 256; ctr++ {
+		d := sha512.New()
+		d.Write([]byte{suite, 0x01})
+		d.Write(pub)
+		d.Write(alpha)
+		d.Write([]byte{byte(ctr), 0x00})
+		p, err := new(edwards25519.Point).SetBytes(d.Sum(nil)[:32])
+		if err == nil {
+			return p.MultByCofactor(p), nil
+		}
+	}
+	return nil, errors.New("unable to encode to curve") // with overwhelming probability, never happens
+}
+
+// challenge returns the challenge scalar for the points, which is a hash of them truncated to 16 bytes
+func challenge(points ...*edwards25519.Point) (*edwards25519.Scalar, error) {
+	d := sha512.New()
+	d.Write([]byte{suite, 0x02})
+	for _, p := range points {
+		d.Write(p.Bytes())
+	}
+	d.Write([]byte{0x00})
+	c := make([]byte, 32)
+	copy(c, d.Sum(nil)[:16])
+	return edwards25519.NewScalar().SetCanonicalBytes(c)
+}
@@ -0,0 +1,82 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+package vrf
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"testing"
+)
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	rv, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rv
+}
+
+func TestVRF(t *testing.T) {
+	// Example 16 from RFC 9381
+	priv := ed25519.NewKeyFromSeed(mustDecodeHex(t, "9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60"))
+	if !bytes.Equal(priv.Public().(ed25519.PublicKey), mustDecodeHex(t, "d75a980182b10ab7d54bfed3c964073a0ee172f3daa62325af021a68f707511a")) {
+		t.Fatal("wrong public key")
+	}
+	expPi := mustDecodeHex(t, "8657106690b5526245a92b003bb079ccd1a92130477671f6fc01ad16f26f723f26f8a57ccaed74ee1b190bed1f479d9727d2d0f9b005a6e456a35d4fb0daab1268a1b0db10836d9826a528ca76567805")
+	expBeta := mustDecodeHex(t, "90cf1df3b703cce59e2a35b925d411164068269d7b2d29f3301c03dd757876ff66b71dda49d2de59d03450451af026798e8f81cd2e333de5cdf4f3e140fdd8ae")
+
+	pi, beta, err := Prove(priv, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(pi, expPi) || !bytes.Equal(beta, expBeta) {
+		t.Fatal("wrong proof or output")
+	}
+	beta, err = Verify(priv.Public().(ed25519.PublicKey), nil, pi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(beta, expBeta) {
+		t.Fatal("wrong output")
+	}
+
+	// Proofs only verify for the input and key they were made for
+	_, err = Verify(priv.Public().(ed25519.PublicKey), []byte("other"), pi)
+	if err != ErrInvalidProof {
+		t.Fatalf("Wanted %s, got %s", ErrInvalidProof, err)
+	}
+	other := ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize))
+	_, err = Verify(other.Public().(ed25519.PublicKey), nil, pi)
+	if err != ErrInvalidProof {
+		t.Fatalf("Wanted %s, got %s", ErrInvalidProof, err)
+	}
+	for i := range pi {
+		bad := append([]byte(nil), pi...)
+		bad[i] ^= 1
+		_, err = Verify(priv.Public().(ed25519.PublicKey), nil, bad)
+		if err == nil {
+			t.Fatalf("tampered proof at %d should fail", i)
+		}
+	}
+	_, err = Verify(priv.Public().(ed25519.PublicKey), nil, pi[1:])
+	if err != ErrInvalidProof {
+		t.Fatalf("Wanted %s, got %s", ErrInvalidProof, err)
+	}
+}
@@ -0,0 +1,191 @@
+/*
+
+Copyright 2017 Continusec Pty Ltd
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+
+*/
+
+// Package witness implements a witness that cosigns log tree heads. A witness remembers the
+// last tree head it cosigned for each log, and will only cosign a new tree head if it is
+// shown to be consistent with that. Clients that require tree heads to be cosigned by a
+// quorum of independent witnesses are protected from a server presenting split views of a log.
+package witness
+
+import (
+	"bytes"
+	"crypto"
+	"log"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/continusec/objecthash"
+	"github.com/continusec/verifiabledatastructures/pb"
+	"github.com/continusec/verifiabledatastructures/verifiable"
+)
+
+var (
+	headKey = []byte("witness/head")
+)
+
+// Service is the configuration for a witness. It may be started as a gRPC server,
+// or passed directly to a verifiable.Service as one of its Witnesses.
+type Service struct {
+	pb.UnimplementedWitnessServiceServer
+
+	// Signer is used to cosign tree heads. Must be an Ed25519 or ECDSA P-256 key.
+	Signer crypto.Signer
+
+	// Storage is where the last cosigned tree head for each log is kept
+	Storage verifiable.StorageWriter
+
+	// Logs is keyed by log origin (as per verifiable.LogOrigin()) and is the set of logs this
+	// witness will cosign for.
+	Logs map[string]*Log
+}
+
+// Log is the configuration for a log that a witness cosigns for
+type Log struct {
+	// PublicKey, if non-nil, is the key that all tree heads for the log must be signed by
+	PublicKey crypto.PublicKey
+
+	// HashAlgorithm is the hash algorithm recorded for the log. Consistency proofs must use it.
+	HashAlgorithm pb.HashAlgorithm
+
+	// StartHead, if set, is a tree head for the log that is known to be good. The first tree head
+	// cosigned must be consistent with it. If nil, the first tree head presented is trusted.
+	StartHead *pb.LogTreeHashResponse
+}
+
+type localWitnessImpl Service
+
+// Create returns a low-level API object for the witness
+func (w *Service) Create() (pb.WitnessServiceServer, error) {
+	return (*localWitnessImpl)(w), nil
+}
+
+// MustCreate is a convenience method that exits with a fatal error if the operation fails
+func (w *Service) MustCreate() pb.WitnessServiceServer {
+	rv, err := w.Create()
+	if err != nil {
+		log.Fatal(err)
+	}
+	return rv
+}
+
+// AddLogTreeHead cosigns the given tree head if it is consistent with the last tree head that
+// this witness cosigned for the log. If req.OldSize does not match the size of that tree head,
+// then no cosignature is returned, and the caller should try again with a proof from the WitnessSize returned.
+func (w *localWitnessImpl) AddLogTreeHead(ctx context.Context, req *pb.AddLogTreeHeadRequest) (*pb.AddLogTreeHeadResponse, error) {
+	if req.Log == nil || req.Log.Account == nil || req.Head == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "log and head must be specified")
+	}
+	if req.Head.TreeSize <= 0 || req.OldSize < 0 || req.OldSize > req.Head.TreeSize {
+		return nil, status.Errorf(codes.InvalidArgument, "bad tree size")
+	}
+
+	origin := verifiable.LogOrigin(req.Log)
+	conf, ok := w.Logs[origin]
+	if !ok {
+		return nil, status.Errorf(codes.PermissionDenied, "unknown log: %s", origin)
+	}
+	if conf.PublicKey != nil {
+		err := verifiable.VerifyLogTreeHeadSignature(req.Log, req.Head, conf.PublicKey)
+		if err != nil {
+			return nil, status.Errorf(codes.PermissionDenied, "bad tree head signature: %s", err)
+		}
+	}
+
+	ns, err := objecthash.ObjectHash(map[string]interface{}{
+		"origin": origin,
+		"type":   "witness",
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "unknown err: %s", err)
+	}
+
+	head := &pb.LogTreeHashResponse{
+		TreeSize: req.Head.TreeSize,
+		RootHash: req.Head.RootHash,
+	}
+	var rv *pb.AddLogTreeHeadResponse
+	err = w.Storage.ExecuteUpdate(ctx, ns, func(ctx context.Context, kw verifiable.KeyWriter) error {
+		var prev pb.LogTreeHashResponse
+		err := kw.Get(ctx, headKey, &prev)
+		switch err {
+		case nil:
+			// good, continue
+		case verifiable.ErrNoSuchKey:
+			// first head we've seen for this log, so start from the configured head, if any
+			if conf.StartHead != nil {
+				prev.TreeSize = conf.StartHead.TreeSize
+				prev.RootHash = conf.StartHead.RootHash
+			}
+		default:
+			return err
+		}
+
+		// Does the caller know what we last cosigned?
+		if req.OldSize != prev.TreeSize {
+			rv = &pb.AddLogTreeHeadResponse{
+				WitnessSize: prev.TreeSize,
+			}
+			return nil
+		}
+
+		if prev.TreeSize != 0 {
+			switch {
+			case prev.TreeSize == head.TreeSize:
+				if !bytes.Equal(prev.RootHash, head.RootHash) {
+					err = verifiable.ErrVerificationFailed
+				}
+			case req.Proof == nil:
+				err = verifiable.ErrVerificationFailed
+			case req.Proof.HashAlgorithm != conf.HashAlgorithm:
+				err = verifiable.ErrVerificationFailed
+			default:
+				err = verifiable.VerifyLogConsistencyProof(req.Proof, &prev, head)
+			}
+			if err != nil {
+				return status.Errorf(codes.FailedPrecondition, "tree head is not consistent with previously cosigned head: %s", err)
+			}
+		}
+
+		sig, err := verifiable.SignLogTreeHead(w.Signer, req.Log, head)
+		if err != nil {
+			return err
+		}
+
+		err = kw.Set(ctx, headKey, head)
+		if err != nil {
+			return err
+		}
+
+		rv = &pb.AddLogTreeHeadResponse{
+			Cosignature: sig,
+			WitnessSize: head.TreeSize,
+		}
+		return nil
+	})
+	if err != nil {
+		_, ok := status.FromError(err)
+		if !ok {
+			err = status.Errorf(codes.Internal, "unknown err: %s", err)
+		}
+		return nil, err
+	}
+
+	return rv, nil
+}